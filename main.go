@@ -1,28 +1,9 @@
+// Command devlog records development activity and summarizes it. The
+// implementation is in package devlog, which other tools can also import.
 package main
 
-import "os"
+import "github.com/chadnorvell/devlog/pkg/devlog"
 
 func main() {
-	if len(os.Args) < 2 {
-		cmdNote()
-		return
-	}
-	switch os.Args[1] {
-	case "gen":
-		cmdGen()
-	case "gen-prompt":
-		cmdGenPrompt()
-	case "watch":
-		cmdWatch()
-	case "unwatch":
-		cmdUnwatch()
-	case "start":
-		cmdStart()
-	case "stop":
-		cmdStop()
-	case "status":
-		cmdStatus()
-	default:
-		cmdNote()
-	}
+	devlog.Main()
 }
@@ -0,0 +1,311 @@
+package devlog
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NoteArgs is the body of POST /api/notes.
+type NoteArgs struct {
+	Text     string   `json:"text"`
+	Projects []string `json:"projects,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// NotesData is the response to GET /api/notes/{date}.
+type NotesData struct {
+	Date    string `json:"date"`
+	Content string `json:"content"`
+}
+
+// SummaryData is the response to GET /api/summary/{date}. Content is the
+// whole summary, or only the requested project's section.
+type SummaryData struct {
+	Date    string `json:"date"`
+	Content string `json:"content"`
+}
+
+// GenData is the response to POST /api/gen/{date}. Job is the ID of the
+// job in the server's queue that generated the summary.
+type GenData struct {
+	Date     string `json:"date"`
+	Projects int    `json:"projects"`
+	Job      int    `json:"job"`
+}
+
+// apiServer serves the optional HTTP+JSON API. Responses use the IPC
+// response envelope, and the daemon operations are the IPC handlers.
+type apiServer struct {
+	server *Server
+	logger *slog.Logger
+	token  string
+}
+
+func (a *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", a.handleStatus)
+	mux.HandleFunc("GET /api/watch", a.handleWatched)
+	mux.HandleFunc("POST /api/watch", a.ipcHandler("watch", a.server.handleWatch))
+	mux.HandleFunc("POST /api/unwatch", a.ipcHandler("unwatch", a.server.handleUnwatch))
+	mux.HandleFunc("POST /api/notes", a.handleAddNote)
+	mux.HandleFunc("GET /api/notes/{date}", a.handleNotes)
+	mux.HandleFunc("GET /api/summary/{date}", a.handleSummary)
+	mux.HandleFunc("POST /api/gen/{date}", a.handleGen)
+	return a.authenticate(mux)
+}
+
+// authenticate rejects requests without "Authorization: Bearer <token>".
+func (a *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(a.token)) != 1 {
+			writeAPIResponse(w, http.StatusUnauthorized, IPCResponse{OK: false, Error: "missing or invalid token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *apiServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeAPIResponse(w, http.StatusOK, a.server.handleStatus())
+}
+
+func (a *apiServer) handleWatched(w http.ResponseWriter, r *http.Request) {
+	a.server.mu.RLock()
+	resp := a.server.watchedResponse()
+	a.server.mu.RUnlock()
+	writeAPIResponse(w, http.StatusOK, resp)
+}
+
+// ipcHandler serves an IPC command whose args are the request body.
+func (a *apiServer) ipcHandler(command string, handle func(IPCRequest) IPCResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		resp := handle(IPCRequest{Command: command, Args: body})
+		status := http.StatusOK
+		if !resp.OK {
+			status = http.StatusBadRequest
+		}
+		writeAPIResponse(w, status, resp)
+	}
+}
+
+func (a *apiServer) handleAddNote(w http.ResponseWriter, r *http.Request) {
+	var args NoteArgs
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&args); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid args: %w", err))
+		return
+	}
+	text := strings.TrimSpace(args.Text)
+	if text == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("empty note"))
+		return
+	}
+	for _, p := range args.Projects {
+		if err := validateProjectName(p); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	for _, t := range args.Tags {
+		if !containsString(semanticNoteTags, t) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("unknown tag %q (valid tags: %s)", t, strings.Join(semanticNoteTags, ", ")))
+			return
+		}
+	}
+
+	now := time.Now()
+	cfg := a.config()
+	if err := writeNote(cfg.noteHeadings(), notesPathFor(cfg, now.Format("2006-01-02"), args.Projects), now, text, append(args.Projects, args.Tags...)...); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.logger.Info("note added", "projects", args.Projects)
+	writeAPIData(w, struct{}{})
+}
+
+func (a *apiServer) handleNotes(w http.ResponseWriter, r *http.Request) {
+	date, ok := a.date(w, r)
+	if !ok {
+		return
+	}
+	data := NotesData{Date: date}
+	if days := collectNotes(a.config(), []string{date}, r.URL.Query().Get("project")); len(days) > 0 {
+		data.Content = days[0].Content
+	}
+	writeAPIData(w, data)
+}
+
+func (a *apiServer) handleSummary(w http.ResponseWriter, r *http.Request) {
+	date, ok := a.date(w, r)
+	if !ok {
+		return
+	}
+	content, err := os.ReadFile(summaryPath(a.config(), date))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no summary for %s", date))
+		return
+	}
+	data := SummaryData{Date: date, Content: stripSummaryMeta(string(content))}
+	if project := r.URL.Query().Get("project"); project != "" {
+		data.Content = ""
+		for _, s := range splitSummary(string(content)) {
+			if s.Project == project {
+				data.Content = s.Text
+			}
+		}
+		if data.Content == "" {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no summary for %s on %s", project, date))
+			return
+		}
+	}
+	writeAPIData(w, data)
+}
+
+func (a *apiServer) handleGen(w http.ResponseWriter, r *http.Request) {
+	date, ok := a.date(w, r)
+	if !ok {
+		return
+	}
+	// The job is not cancelled if the client gives up: other requests may
+	// have been coalesced into it.
+	job, done := a.server.jobs.enqueue(date, nil)
+	select {
+	case <-done:
+	case <-r.Context().Done():
+		return
+	}
+	job, _ = a.server.jobs.job(job.ID)
+	switch job.Status {
+	case jobDone:
+		writeAPIData(w, GenData{Date: date, Projects: job.Summarized, Job: job.ID})
+	case jobCancelled:
+		writeAPIError(w, http.StatusConflict, errGenCancelled)
+	default:
+		writeAPIError(w, http.StatusInternalServerError, errors.New(job.Error))
+	}
+}
+
+// date returns the {date} path value, writing an error if it is invalid.
+func (a *apiServer) date(w http.ResponseWriter, r *http.Request) (string, bool) {
+	date := r.PathValue("date")
+	if !isValidDate(date) {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", date))
+		return "", false
+	}
+	return date, true
+}
+
+func (a *apiServer) config() Config {
+	a.server.mu.RLock()
+	defer a.server.mu.RUnlock()
+	return a.server.cfg
+}
+
+func writeAPIData(w http.ResponseWriter, v any) {
+	data, _ := json.Marshal(v)
+	writeAPIResponse(w, http.StatusOK, IPCResponse{OK: true, Data: json.RawMessage(data)})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIResponse(w, status, IPCResponse{OK: false, Error: err.Error()})
+}
+
+func writeAPIResponse(w http.ResponseWriter, status int, resp IPCResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	data, _ := json.Marshal(resp)
+	w.Write(append(data, '\n'))
+}
+
+// isLoopbackAddr reports whether the host of a host:port address is
+// localhost or a loopback IP.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// loadAPIToken reads the API token from path, generating a random one on
+// first use. The file is only readable by the user.
+func loadAPIToken(path string) (string, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading API token: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating API token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("creating state dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("writing API token: %w", err)
+	}
+	return token, nil
+}
+
+// startAPI serves the HTTP API on the configured api_addr. Returns a cleanup
+// function, or nil if the API is disabled or could not be started.
+func startAPI(s *Server) func() {
+	addr := s.cfg.APIAddr
+	if addr == "" {
+		return nil
+	}
+	logger := s.logger.With("component", "api")
+
+	token, err := loadAPIToken(resolveAPITokenPath())
+	if err != nil {
+		logger.Error("HTTP API disabled", "err", err)
+		return nil
+	}
+	if !isLoopbackAddr(addr) {
+		logger.Warn("HTTP API is listening on a non-loopback address", "addr", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Error("HTTP API disabled", "err", err)
+		return nil
+	}
+
+	a := &apiServer{server: s, logger: logger, token: token}
+	srv := &http.Server{Handler: a.routes(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP API stopped", "err", err)
+		}
+	}()
+	logger.Info("HTTP API listening", "addr", ln.Addr().String())
+
+	return func() {
+		srv.Close()
+	}
+}
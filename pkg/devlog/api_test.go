@@ -0,0 +1,164 @@
+package devlog
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAPI(t *testing.T) (*apiServer, Config) {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := Config{SnapshotInterval: 300, RawDir: t.TempDir(), LogDir: t.TempDir()}
+	s := newServer(cfg)
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Cleanup(s.cancel)
+	return &apiServer{server: s, logger: s.logger, token: "secret"}, cfg
+}
+
+func apiDo(t *testing.T, h http.Handler, method, path, body string) (int, IPCResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp IPCResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: invalid response %q", method, path, rec.Body.String())
+	}
+	return rec.Code, resp
+}
+
+func TestAPIAuth(t *testing.T) {
+	a, _ := newTestAPI(t)
+	h := a.routes()
+
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest("GET", "/api/status", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected 401, got %d", auth, rec.Code)
+		}
+	}
+
+	if code, resp := apiDo(t, h, "GET", "/api/status", ""); code != http.StatusOK || !resp.OK {
+		t.Errorf("expected status with valid token, got %d %+v", code, resp)
+	}
+}
+
+func TestAPIWatch(t *testing.T) {
+	a, _ := newTestAPI(t)
+	h := a.routes()
+	repo := initTestRepo(t)
+
+	body, _ := json.Marshal(WatchArgs{Path: repo, Name: "alpha"})
+	code, resp := apiDo(t, h, "POST", "/api/watch", string(body))
+	if code != http.StatusOK || !resp.OK {
+		t.Fatalf("watch failed: %d %+v", code, resp)
+	}
+	_, resp = apiDo(t, h, "GET", "/api/watch", "")
+	var wd WatchResponseData
+	json.Unmarshal(resp.Data, &wd)
+	if len(wd.Watched) != 1 || wd.Watched[0].Name != "alpha" {
+		t.Errorf("expected alpha to be watched, got %+v", wd.Watched)
+	}
+
+	if code, resp := apiDo(t, h, "POST", "/api/watch", "{"); code != http.StatusBadRequest || resp.OK {
+		t.Errorf("expected 400 for invalid body, got %d %+v", code, resp)
+	}
+}
+
+func TestAPINotes(t *testing.T) {
+	a, cfg := newTestAPI(t)
+	h := a.routes()
+
+	code, resp := apiDo(t, h, "POST", "/api/notes", `{"text": "Found the leak", "projects": ["alpha"], "tags": ["bug"]}`)
+	if code != http.StatusOK || !resp.OK {
+		t.Fatalf("adding note failed: %d %+v", code, resp)
+	}
+	today := time.Now().Format("2006-01-02")
+	data, _ := os.ReadFile(resolveNotesPath(cfg, today))
+	if !strings.Contains(string(data), "#alpha #bug\nFound the leak") {
+		t.Errorf("note not written:\n%s", data)
+	}
+
+	_, resp = apiDo(t, h, "GET", "/api/notes/"+today+"?project=alpha", "")
+	var nd NotesData
+	json.Unmarshal(resp.Data, &nd)
+	if !strings.Contains(nd.Content, "Found the leak") {
+		t.Errorf("expected note in listing, got %+v", nd)
+	}
+
+	for _, body := range []string{`{"text": ""}`, `{"text": "x", "projects": ["a b"]}`, `{"text": "x", "tags": ["nope"]}`} {
+		if code, _ := apiDo(t, h, "POST", "/api/notes", body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, code)
+		}
+	}
+}
+
+func TestAPISummary(t *testing.T) {
+	a, cfg := newTestAPI(t)
+	h := a.routes()
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nAlpha work.\n\n## beta\n\nBeta work.\n"), 0o644)
+
+	_, resp := apiDo(t, h, "GET", "/api/summary/2024-01-15?project=beta", "")
+	var sd SummaryData
+	json.Unmarshal(resp.Data, &sd)
+	if sd.Content != "Beta work." {
+		t.Errorf("expected beta section, got %+v", sd)
+	}
+
+	if code, _ := apiDo(t, h, "GET", "/api/summary/2024-01-16", ""); code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing summary, got %d", code)
+	}
+	if code, _ := apiDo(t, h, "GET", "/api/summary/2024-01-15?project=gamma", ""); code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing project, got %d", code)
+	}
+	if code, _ := apiDo(t, h, "GET", "/api/summary/yesterday", ""); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid date, got %d", code)
+	}
+}
+
+func TestLoadAPIToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devlog", "api-token")
+	token, err := loadAPIToken(path)
+	if err != nil {
+		t.Fatalf("loadAPIToken: %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("expected 64 hex chars, got %q", token)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+	again, _ := loadAPIToken(path)
+	if again != token {
+		t.Errorf("expected token to be reused, got %q then %q", token, again)
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8377": true,
+		"localhost:8377": true,
+		"[::1]:8377":     true,
+		"0.0.0.0:8377":   false,
+		":8377":          false,
+		"example.com:80": false,
+	} {
+		if got := isLoopbackAddr(addr); got != want {
+			t.Errorf("isLoopbackAddr(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package devlog
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archiveVersion is the version of the archive layout written by
+// `devlog export --archive`.
+const archiveVersion = 1
+
+// archiveManifest is the first entry of an archive, manifest.json.
+type archiveManifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Profile string    `json:"profile,omitempty"`
+	Dates   []string  `json:"dates"`
+}
+
+// archiveEntry is a file to put in an archive under Name. Names record what
+// a file is rather than where it was, so that importing places it where the
+// importing config keeps such files:
+//
+//	raw/<date>/git/<project>.log         git snapshot log
+//	raw/<date>/notes.md                  notes file
+//	raw/<date>/notes/<project>.md        per-project notes file
+//	raw/<date>/term/<project>/<file>     terminal log
+//	raw/<date>/files/<path>              anything else in <raw_dir>/<date>
+//	log/<date>.md                        summary
+//	state/state.json                     watched repos
+//	state/questions/<project>.json       open questions
+type archiveEntry struct {
+	Name, Path string
+}
+
+// archiveCompression returns the compression of an archive from its file
+// name: "zst", "gz", or "" for a plain tar file.
+func archiveCompression(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return "zst", nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "gz", nil
+	case strings.HasSuffix(name, ".tar"):
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown archive type %q (use .tar.zst, .tar.gz, or .tar)", name)
+}
+
+// archiveEntries returns the raw data and summaries on dates and the state
+// files worth carrying to another machine. Caches and the API token are
+// left out.
+func archiveEntries(cfg Config, dates []string) ([]archiveEntry, []string) {
+	var entries []archiveEntry
+	var withData []string
+	seen := make(map[string]bool)
+	add := func(name, path string) {
+		if !seen[path] && fileExists(path) {
+			seen[path] = true
+			entries = append(entries, archiveEntry{Name: name, Path: path})
+		}
+	}
+
+	for _, date := range dates {
+		n := len(entries)
+		for _, p := range discoverProjects(cfg, date) {
+			add("raw/"+date+"/git/"+p+".log", resolveGitPath(cfg, date, p))
+			matches, _ := filepath.Glob(resolveTermGlob(cfg, date, p))
+			for _, m := range matches {
+				add("raw/"+date+"/term/"+p+"/"+filepath.Base(m), m)
+			}
+		}
+		add("raw/"+date+"/notes.md", resolveNotesPath(cfg, date))
+		for p, path := range projectNotesFiles(cfg, date) {
+			add("raw/"+date+"/notes/"+p+".md", path)
+		}
+		dayDir := filepath.Join(resolveRawDir(cfg), date)
+		filepath.WalkDir(dayDir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				rel, _ := filepath.Rel(dayDir, path)
+				add("raw/"+date+"/files/"+filepath.ToSlash(rel), path)
+			}
+			return nil
+		})
+		add("log/"+date+".md", summaryPath(cfg, date))
+		if len(entries) > n {
+			withData = append(withData, date)
+		}
+	}
+
+	stateDir := filepath.Dir(resolveStatePath())
+	add("state/state.json", resolveStatePath())
+	questions, _ := filepath.Glob(filepath.Join(stateDir, "questions", "*.json"))
+	for _, q := range questions {
+		add("state/questions/"+filepath.Base(q), q)
+	}
+	return entries, withData
+}
+
+// createArchive writes the data on dates to a tar archive at path,
+// compressed according to its name, and returns the number of files in it.
+// zstd compression runs the zstd command.
+func createArchive(cfg Config, path string, dates []string) (int, error) {
+	compression, err := archiveCompression(path)
+	if err != nil {
+		return 0, err
+	}
+	entries, withData := archiveEntries(cfg, dates)
+	if len(withData) == 0 {
+		return 0, errors.New("no data in the given dates")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("creating archive: %w", err)
+	}
+	defer f.Close()
+
+	var w io.WriteCloser = nopWriteCloser{f}
+	switch compression {
+	case "gz":
+		w = gzip.NewWriter(f)
+	case "zst":
+		if w, err = zstdWriter(f); err != nil {
+			return 0, fmt.Errorf("%w (use .tar.gz if it is not installed)", err)
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	manifest, _ := json.MarshalIndent(archiveManifest{
+		Version: archiveVersion,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Profile: activeProfile(),
+		Dates:   withData,
+	}, "", "  ")
+	err = writeTarFile(tw, "manifest.json", append(manifest, '\n'), time.Now())
+	for _, e := range entries {
+		if err != nil {
+			break
+		}
+		err = addTarFile(tw, e)
+	}
+	if closeErr := tw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, fmt.Errorf("writing archive: %w", err)
+	}
+	return len(entries), nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// zstdCmdWriter compresses what is written to it with the zstd command.
+type zstdCmdWriter struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+// zstdWriter returns a writer that compresses to w by running the zstd
+// command. Closing it waits for zstd to finish.
+func zstdWriter(w io.Writer) (io.WriteCloser, error) {
+	cmd := exec.Command("zstd", "-q", "-c")
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("running zstd: %w", err)
+	}
+	return zstdCmdWriter{in, cmd}, nil
+}
+
+func (z zstdCmdWriter) Close() error {
+	err := z.WriteCloser.Close()
+	if waitErr := z.cmd.Wait(); err == nil && waitErr != nil {
+		err = fmt.Errorf("zstd: %w", waitErr)
+	}
+	return err
+}
+
+// zstdCmdReader decompresses with the zstd command.
+type zstdCmdReader struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+// zstdReader returns a reader of the decompressed contents of r, running the
+// zstd command. Close it when done, also after stopping early.
+func zstdReader(r io.Reader) (io.ReadCloser, error) {
+	cmd := exec.Command("zstd", "-d", "-q", "-c")
+	cmd.Stdin = r
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("running zstd: %w", err)
+	}
+	return zstdCmdReader{out, cmd}, nil
+}
+
+// Close stops zstd. Stopping early leaves it blocked writing, so it is
+// killed rather than waited for.
+func (z zstdCmdReader) Close() error {
+	z.cmd.Process.Kill()
+	z.cmd.Wait()
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func addTarFile(tw *tar.Writer, e archiveEntry) error {
+	data, err := os.ReadFile(e.Path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		return err
+	}
+	return writeTarFile(tw, e.Name, data, info.ModTime())
+}
+
+// archiveTarget returns where the archive entry name goes under cfg and its
+// date ("" for state files). Names that are not part of the archive layout,
+// or that would escape the data directories, are rejected.
+func archiveTarget(cfg Config, name string) (string, string, error) {
+	parts := strings.Split(name, "/")
+	bad := fmt.Errorf("unexpected archive entry %q", name)
+	switch {
+	case len(parts) == 2 && parts[0] == "log" && strings.HasSuffix(parts[1], ".md"):
+		date := strings.TrimSuffix(parts[1], ".md")
+		if !isValidDate(date) {
+			return "", "", bad
+		}
+		return summaryPath(cfg, date), date, nil
+	case len(parts) == 2 && name == "state/state.json":
+		return resolveStatePath(), "", nil
+	case len(parts) == 3 && parts[0] == "state" && parts[1] == "questions":
+		project := strings.TrimSuffix(parts[2], ".json")
+		if !isArchiveProject(project) {
+			return "", "", bad
+		}
+		return resolveQuestionsPath(project), "", nil
+	case len(parts) < 3 || parts[0] != "raw" || !isValidDate(parts[1]):
+		return "", "", bad
+	}
+
+	date := parts[1]
+	switch {
+	case len(parts) == 3 && parts[2] == "notes.md":
+		return resolveNotesPath(cfg, date), date, nil
+	case len(parts) == 4 && parts[2] == "notes" && strings.HasSuffix(parts[3], ".md"):
+		project := strings.TrimSuffix(parts[3], ".md")
+		if !isArchiveProject(project) {
+			return "", "", bad
+		}
+		return resolveProjectNotesPath(cfg, date, project), date, nil
+	case len(parts) == 4 && parts[2] == "git" && strings.HasSuffix(parts[3], ".log"):
+		project := strings.TrimSuffix(parts[3], ".log")
+		if !isArchiveProject(project) {
+			return "", "", bad
+		}
+		return resolveGitPath(cfg, date, project), date, nil
+	case len(parts) == 5 && parts[2] == "term":
+		if !isArchiveProject(parts[3]) || !filepath.IsLocal(parts[4]) {
+			return "", "", bad
+		}
+		return filepath.Join(filepath.Dir(resolveTermGlob(cfg, date, parts[3])), parts[4]), date, nil
+	case len(parts) >= 4 && parts[2] == "files":
+		rel := filepath.FromSlash(strings.Join(parts[3:], "/"))
+		if !filepath.IsLocal(rel) {
+			return "", "", bad
+		}
+		return filepath.Join(resolveRawDir(cfg), date, rel), date, nil
+	}
+	return "", "", bad
+}
+
+// isArchiveProject reports whether project, read from an archive, is a
+// valid project name that cannot take a path template out of its directory.
+func isArchiveProject(project string) bool {
+	return validateProjectName(project) == nil && project != "." && project != ".."
+}
+
+// importResult counts what importArchive did.
+type importResult struct {
+	Imported, Skipped int
+}
+
+// importArchive extracts an archive written by createArchive into the
+// locations cfg uses, keeping only the dates in dates if it is not nil.
+// Files that already exist are skipped unless force is set. Modification
+// times are kept, so the staleness check of `devlog gen` sees imported
+// summaries as up to date with their raw data.
+func importArchive(cfg Config, path string, dates []string, force bool) (importResult, error) {
+	var res importResult
+	compression, err := archiveCompression(path)
+	if err != nil {
+		return res, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return res, fmt.Errorf("opening archive: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch compression {
+	case "gz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return res, fmt.Errorf("reading archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	case "zst":
+		zstd, err := zstdReader(f)
+		if err != nil {
+			return res, err
+		}
+		defer zstd.Close()
+		r = zstd
+	}
+
+	var only map[string]bool
+	if dates != nil {
+		only = make(map[string]bool)
+		for _, d := range dates {
+			only[d] = true
+		}
+	}
+
+	tr := tar.NewReader(r)
+	for first := true; ; first = false {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return res, fmt.Errorf("reading archive: %w", err)
+		}
+		if first {
+			if hdr.Name != "manifest.json" {
+				return res, errors.New("not a devlog archive: no manifest.json")
+			}
+			var m archiveManifest
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return res, fmt.Errorf("reading manifest: %w", err)
+			}
+			if m.Version != archiveVersion {
+				return res, fmt.Errorf("unsupported archive version %d", m.Version)
+			}
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		target, date, err := archiveTarget(cfg, hdr.Name)
+		if err != nil {
+			return res, err
+		}
+		if only != nil && date != "" && !only[date] {
+			continue
+		}
+		if !force && fileExists(target) {
+			res.Skipped++
+			continue
+		}
+		if err := extractTarFile(tr, target, hdr.ModTime); err != nil {
+			return res, err
+		}
+		res.Imported++
+	}
+	return res, nil
+}
+
+func extractTarFile(r io.Reader, target string, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(target), err)
+	}
+	tmp := target + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", target, err)
+	}
+	_, err = io.Copy(out, r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing %s: %w", target, err)
+	}
+	os.Chtimes(target, modTime, modTime)
+	return nil
+}
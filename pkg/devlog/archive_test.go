@@ -0,0 +1,151 @@
+package devlog
+
+import (
+	"archive/tar"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setArchiveDirs points devlog's data and state directories under dir.
+func setArchiveDirs(t *testing.T, dir string) Config {
+	t.Setenv("DEVLOG_RAW_DIR", filepath.Join(dir, "raw"))
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(dir, "log"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	return Config{}
+}
+
+func writeArchiveFixtures(t *testing.T, cfg Config) time.Time {
+	t.Helper()
+	mtime := time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC)
+	files := map[string]string{
+		resolveGitPath(cfg, "2024-01-15", "api"):                           "=== SNAPSHOT 10:00 ===\n+x\n\n",
+		resolveNotesPath(cfg, "2024-01-15"):                                "### At 10:00 #api\nnote\n",
+		filepath.Join(resolveRawDir(cfg), "2024-01-15", "term-api-1.log"):  "$ make\n",
+		filepath.Join(resolveRawDir(cfg), "2024-01-15", "comp-git-api.md"): "compressed\n",
+		filepath.Join(attachmentsDir(cfg, "2024-01-15"), "shot.png"):       "png",
+		summaryPath(cfg, "2024-01-15"):                                     "# 2024-01-15\n\n## api\n\nDid things.\n",
+		resolveNotesPath(cfg, "2024-01-16"):                                "### At 09:00\nnext day\n",
+		resolveStatePath():                                                 `{"watched": [{"path": "/src/api", "name": "api"}]}`,
+		resolveQuestionsPath("api"):                                        `{"next_id": 0, "questions": []}`,
+	}
+	for path, content := range files {
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mtime, mtime)
+	}
+	return mtime
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := setArchiveDirs(t, t.TempDir())
+	mtime := writeArchiveFixtures(t, src)
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+
+	n, err := createArchive(src, archive, []string{"2024-01-14", "2024-01-15", "2024-01-16"})
+	if err != nil {
+		t.Fatalf("createArchive: %v", err)
+	}
+	if n != 9 {
+		t.Errorf("archived %d files, want 9", n)
+	}
+
+	// Import on a "new machine" that keeps git logs elsewhere.
+	dst := setArchiveDirs(t, t.TempDir())
+	dst.GitPath = "<raw_dir>/git/<project>/<date>.log"
+	res, err := importArchive(dst, archive, []string{"2024-01-15"}, false)
+	if err != nil {
+		t.Fatalf("importArchive: %v", err)
+	}
+	if res.Imported != 8 || res.Skipped != 0 {
+		t.Errorf("import = %+v, want 8 imported", res)
+	}
+	for _, path := range []string{
+		resolveGitPath(dst, "2024-01-15", "api"),
+		filepath.Join(resolveRawDir(dst), "2024-01-15", "term-api-1.log"),
+		filepath.Join(attachmentsDir(dst, "2024-01-15"), "shot.png"),
+		resolveStatePath(),
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("missing %s", path)
+		} else if !info.ModTime().Equal(mtime) {
+			t.Errorf("%s: mtime %v, want %v", path, info.ModTime(), mtime)
+		}
+	}
+	if !strings.HasSuffix(resolveGitPath(dst, "2024-01-15", "api"), filepath.Join("git", "api", "2024-01-15.log")) {
+		t.Errorf("git log should follow the importing git_path")
+	}
+	if fileExists(resolveNotesPath(dst, "2024-01-16")) {
+		t.Error("2024-01-16 is outside the imported range")
+	}
+	if state, err := loadState(); err != nil || len(state.Watched) != 1 {
+		t.Errorf("state not imported: %+v, %v", state, err)
+	}
+
+	os.WriteFile(resolveNotesPath(dst, "2024-01-15"), []byte("local\n"), 0o644)
+	res, err = importArchive(dst, archive, nil, false)
+	if err != nil || res.Imported != 1 || res.Skipped != 8 {
+		t.Errorf("re-import = %+v, %v; want 1 imported, 8 skipped", res, err)
+	}
+	if data, _ := os.ReadFile(resolveNotesPath(dst, "2024-01-15")); string(data) != "local\n" {
+		t.Error("existing file should be kept without --force")
+	}
+	if res, _ = importArchive(dst, archive, nil, true); res.Imported != 9 {
+		t.Errorf("forced import = %+v, want 9 imported", res)
+	}
+}
+
+func TestArchiveZstd(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd not installed")
+	}
+	src := setArchiveDirs(t, t.TempDir())
+	writeArchiveFixtures(t, src)
+	archive := filepath.Join(t.TempDir(), "backup.tar.zst")
+	if _, err := createArchive(src, archive, []string{"2024-01-15"}); err != nil {
+		t.Fatalf("createArchive: %v", err)
+	}
+	dst := setArchiveDirs(t, t.TempDir())
+	if res, err := importArchive(dst, archive, nil, false); err != nil || res.Imported != 8 {
+		t.Errorf("importArchive = %+v, %v", res, err)
+	}
+}
+
+func TestImportArchiveRejectsEscapes(t *testing.T) {
+	cfg := setArchiveDirs(t, t.TempDir())
+	for _, name := range []string{
+		"raw/2024-01-15/files/../../../evil",
+		"raw/2024-01-15/git/...log",
+		"raw/2024-01-15/term/api/../x",
+		"raw/not-a-date/notes.md",
+		"state/api-token",
+	} {
+		archive := filepath.Join(t.TempDir(), "bad.tar")
+		f, _ := os.Create(archive)
+		tw := tar.NewWriter(f)
+		writeTarFile(tw, "manifest.json", []byte(`{"version": 1}`), time.Now())
+		writeTarFile(tw, name, []byte("x"), time.Now())
+		tw.Close()
+		f.Close()
+		if _, err := importArchive(cfg, archive, nil, false); err == nil || !strings.Contains(err.Error(), "unexpected archive entry") {
+			t.Errorf("%s: expected an unexpected entry error, got %v", name, err)
+		}
+	}
+}
+
+func TestArchiveCompression(t *testing.T) {
+	for name, want := range map[string]string{"a.tar.zst": "zst", "a.tgz": "gz", "a.tar.gz": "gz", "a.tar": ""} {
+		if got, err := archiveCompression(name); err != nil || got != want {
+			t.Errorf("archiveCompression(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := archiveCompression("a.zip"); err == nil {
+		t.Error("expected an error for .zip")
+	}
+}
@@ -0,0 +1,153 @@
+package devlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// askSources is the default number of documents given to the model as
+// context for `devlog ask`.
+const askSources = 12
+
+// askStopWords are left out of keyword retrieval for `devlog ask`, since a
+// question is phrased as a sentence rather than as search terms.
+var askStopWords = map[string]bool{
+	"a": true, "about": true, "all": true, "an": true, "and": true, "any": true,
+	"are": true, "did": true, "do": true, "does": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "how": true, "in": true, "is": true,
+	"it": true, "last": true, "me": true, "my": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "where": true,
+	"which": true, "who": true, "why": true, "with": true, "i": true,
+}
+
+// questionTerms returns the words of question worth searching for.
+func questionTerms(question string) []string {
+	var terms []string
+	for _, f := range strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		f = strings.Trim(f, "-_.")
+		if len(f) < 2 || askStopWords[f] || containsString(terms, f) {
+			continue
+		}
+		terms = append(terms, f)
+	}
+	return terms
+}
+
+// termSearch scores each document by how many of terms it contains, so that
+// documents matching only some of a question's words are still found.
+// Occurrences beyond the first count for a little, to break ties.
+func termSearch(docs []searchDoc, terms []string) []searchHit {
+	var hits []searchHit
+	for _, d := range docs {
+		lower := strings.ToLower(d.Text)
+		score := 0.0
+		for _, t := range terms {
+			if n := strings.Count(lower, t); n > 0 {
+				score += 1 + 0.1*float64(min(n-1, 5))
+			}
+		}
+		if score > 0 {
+			hits = append(hits, searchHit{Doc: d, Score: score})
+		}
+	}
+	return hits
+}
+
+// topHits returns the n best hits, most relevant first, with ties going to
+// the later date.
+func topHits(hits []searchHit, n int) []searchHit {
+	sorted := append([]searchHit(nil), hits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score > sorted[j].Score
+		}
+		return sorted[i].Doc.Date > sorted[j].Doc.Date
+	})
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// sourceLabel names a document for citation, e.g. "2024-01-15 alpha summary".
+func sourceLabel(d searchDoc) string {
+	label := d.Date
+	if len(d.Projects) > 0 {
+		label += " " + strings.Join(d.Projects, ", ")
+	}
+	return label + " " + d.Kind
+}
+
+// assembleAskPrompt builds the prompt that answers question from sources,
+// given in date order.
+func assembleAskPrompt(question string, sources []searchDoc, budget int) string {
+	ordered := append([]searchDoc(nil), sources...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Date < ordered[j].Date })
+
+	files := make(map[string]string)
+	var names []string
+	for i, d := range ordered {
+		// The index keeps names unique and in date order.
+		name := fmt.Sprintf("%02d %s", i+1, sourceLabel(d))
+		files[name] = d.Text
+		names = append(names, name)
+	}
+	counts := applyTokenBudget(files, budget)
+	warnTruncated("ask prompt", counts, budget)
+
+	var b strings.Builder
+	b.WriteString("You are answering a software engineer's question about their own past work,\n" +
+		"using excerpts from their work log: daily summaries written at the end of\n" +
+		"each day, and notes they logged during the day.\n\n" +
+		"Below are the excerpts most relevant to the question, in date order. Each is\n" +
+		"labeled with its date, project, and kind.\n")
+	for _, name := range names {
+		_, label, _ := strings.Cut(name, " ")
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n", label, files[name])
+	}
+	fmt.Fprintf(&b, "\nQuestion: %s\n", question)
+	b.WriteString(`
+Guidelines:
+- Answer only from the excerpts. If they do not contain the answer, say so
+  and mention what they do show that is closest.
+- Cite the date of every fact in square brackets, e.g. [2024-01-15].
+- Be concise: answer the question first, then give the supporting details.
+- Write in second person ("you fixed ...").
+
+Output only the answer, nothing else.
+`)
+	return b.String()
+}
+
+// askPrompt retrieves the documents most relevant to question on dates,
+// limited to project if set, and returns the prompt to answer it and the
+// documents used. Semantic retrieval uses the embedding index as for
+// `devlog search --semantic`.
+func askPrompt(cfg Config, question string, dates []string, project string, semantic bool, n int, p *genProgress) (string, []searchDoc, error) {
+	var hits []searchHit
+	if semantic {
+		var err error
+		if hits, err = searchHistory(cfg, question, dates, project, true, p); err != nil {
+			return "", nil, err
+		}
+	} else {
+		terms := questionTerms(question)
+		if len(terms) == 0 {
+			return "", nil, fmt.Errorf("no search terms in %q", question)
+		}
+		hits = termSearch(collectSearchDocs(cfg, dates, project), terms)
+	}
+	if len(hits) == 0 {
+		return "", nil, fmt.Errorf("nothing in the log matches %q", question)
+	}
+
+	var sources []searchDoc
+	for _, h := range topHits(hits, n) {
+		sources = append(sources, h.Doc)
+	}
+	return assembleAskPrompt(question, sources, cfg.TokenBudget), sources, nil
+}
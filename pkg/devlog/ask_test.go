@@ -0,0 +1,53 @@
+package devlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuestionTerms(t *testing.T) {
+	got := questionTerms("When did I fix the migration-ordering bug, and how?")
+	if strings.Join(got, ",") != "fix,migration-ordering,bug" {
+		t.Errorf("unexpected terms %q", got)
+	}
+}
+
+func TestAskPrompt(t *testing.T) {
+	cfg := writeSearchFixtures(t, t.TempDir())
+	cfg.TokenBudget = 150000
+	dates := []string{"2024-01-15", "2024-01-16"}
+
+	prompt, used, err := askPrompt(cfg, "How did I fix the migration ordering?", dates, "", false, 2, nil)
+	if err != nil {
+		t.Fatalf("askPrompt: %v", err)
+	}
+	if len(used) != 1 || sourceLabel(used[0]) != "2024-01-15 alpha summary" {
+		t.Errorf("unexpected sources %+v", used)
+	}
+	for _, want := range []string{
+		"--- 2024-01-15 alpha summary ---\nFixed the migration ordering bug.",
+		"Question: How did I fix the migration ordering?",
+		"[2024-01-15]",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	// Partial matches are found, best first, and given in date order.
+	_, used, _ = askPrompt(cfg, "what was the websocket race?", dates, "", false, 1, nil)
+	if len(used) != 1 || used[0].Date != "2024-01-16" {
+		t.Errorf("expected the note mentioning both terms, got %+v", used)
+	}
+	prompt, _, _ = askPrompt(cfg, "websocket reconnect race", dates, "", false, 5, nil)
+	if strings.Index(prompt, "2024-01-15 beta summary") > strings.Index(prompt, "2024-01-16 beta note") {
+		t.Errorf("expected sources in date order:\n%s", prompt)
+	}
+
+	if _, _, err := askPrompt(cfg, "kubernetes", dates, "", false, 5, nil); err == nil {
+		t.Error("expected error when nothing matches")
+	}
+	if _, _, err := askPrompt(cfg, "what is it?", dates, "", false, 5, nil); err == nil {
+		t.Error("expected error for a question without search terms")
+	}
+}
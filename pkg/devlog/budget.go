@@ -0,0 +1,212 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const snapshotHeaderPrefix = "=== SNAPSHOT "
+
+const truncatedMarker = "[... earlier content truncated to fit token budget ...]\n"
+
+// sourceTokens records the estimated size of one prompt data source after
+// budgeting, and whether it had to be cut down to fit.
+type sourceTokens struct {
+	Name      string
+	Tokens    int
+	Truncated bool
+}
+
+// estimateTokens approximates the token count of s. Most tokenizers average
+// around four bytes per token for English prose and code, which is accurate
+// enough for deciding whether a prompt is in danger of overflowing.
+func estimateTokens(s string) int {
+	return bytesToTokens(len(s))
+}
+
+func bytesToTokens(n int) int {
+	return (n + 3) / 4
+}
+
+// snapshotLog is a git snapshot log being trimmed by applyTokenBudget: any
+// content before its first snapshot header, its snapshots, how many of the
+// oldest have been dropped, and the size of what remains.
+type snapshotLog struct {
+	preamble string
+	snaps    []string
+	dropped  int
+	size     int
+}
+
+// applyTokenBudget trims the contents of files in place so that their
+// combined estimated token count fits within budget. Git snapshot logs lose
+// their oldest snapshots first, since each snapshot is a full diff against
+// HEAD and later ones supersede earlier ones. If that isn't enough, the
+// largest remaining sources are cut from the front (oldest content first).
+// Content before a log's first snapshot header is kept with its latest
+// snapshot. A budget of zero or less disables trimming. The returned counts
+// are sorted by source name.
+func applyTokenBudget(files map[string]string, budget int) []sourceTokens {
+	truncated := make(map[string]bool)
+
+	if budget > 0 && totalTokens(files) > budget {
+		// Phase 1: drop the oldest snapshot from the largest snapshot log
+		// until within budget or every log is down to its latest snapshot.
+		// Each log is split once and the drops are tallied by size, so the
+		// logs are only rebuilt at the end.
+		tokens := make(map[string]int, len(files))
+		total := 0
+		logs := make(map[string]*snapshotLog)
+		for name, content := range files {
+			tokens[name] = estimateTokens(content)
+			total += tokens[name]
+			snaps := splitSnapshots(content)
+			if len(snaps) < 2 {
+				continue
+			}
+			preamble := ""
+			if !strings.HasPrefix(snaps[0], snapshotHeaderPrefix) {
+				i := strings.Index(snaps[0], "\n"+snapshotHeaderPrefix) + 1
+				preamble, snaps[0] = snaps[0][:i], snaps[0][i:]
+			}
+			logs[name] = &snapshotLog{preamble: preamble, snaps: snaps, size: len(content)}
+		}
+		for total > budget {
+			name := ""
+			for n, l := range logs {
+				if len(l.snaps)-l.dropped < 2 {
+					continue
+				}
+				if name == "" || l.size > logs[name].size || (l.size == logs[name].size && n < name) {
+					name = n
+				}
+			}
+			if name == "" {
+				break
+			}
+			l := logs[name]
+			l.size -= len(l.snaps[l.dropped])
+			l.dropped++
+			total += bytesToTokens(l.size) - tokens[name]
+			tokens[name] = bytesToTokens(l.size)
+		}
+		for name, l := range logs {
+			if l.dropped > 0 {
+				files[name] = l.preamble + strings.Join(l.snaps[l.dropped:], "")
+				truncated[name] = true
+			}
+		}
+
+		// Phase 2: cut the largest sources from the front.
+		for _, name := range namesBySizeDesc(files) {
+			excess := totalTokens(files) - budget
+			if excess <= 0 {
+				break
+			}
+			files[name] = trimHead(files[name], excess*4)
+			truncated[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counts := make([]sourceTokens, 0, len(names))
+	for _, name := range names {
+		counts = append(counts, sourceTokens{
+			Name:      name,
+			Tokens:    estimateTokens(files[name]),
+			Truncated: truncated[name],
+		})
+	}
+	return counts
+}
+
+// splitSnapshots splits a git snapshot log into chunks that each begin with
+// a snapshot header. Any content before the first header is kept attached to
+// the first chunk. Content without headers is returned as a single chunk.
+func splitSnapshots(content string) []string {
+	var starts []int
+	for i := 0; i < len(content); {
+		if strings.HasPrefix(content[i:], snapshotHeaderPrefix) {
+			starts = append(starts, i)
+		}
+		nl := strings.IndexByte(content[i:], '\n')
+		if nl < 0 {
+			break
+		}
+		i += nl + 1
+	}
+	if len(starts) == 0 {
+		return []string{content}
+	}
+	starts[0] = 0
+
+	chunks := make([]string, 0, len(starts))
+	for i, start := range starts {
+		end := len(content)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		chunks = append(chunks, content[start:end])
+	}
+	return chunks
+}
+
+// trimHead removes at least n bytes from the front of s, cutting at a line
+// boundary, and prefixes the remainder with a truncation marker.
+func trimHead(s string, n int) string {
+	n += len(truncatedMarker)
+	if n >= len(s) {
+		return truncatedMarker
+	}
+	if nl := strings.IndexByte(s[n:], '\n'); nl >= 0 {
+		n += nl + 1
+	} else {
+		return truncatedMarker
+	}
+	return truncatedMarker + s[n:]
+}
+
+func totalTokens(files map[string]string) int {
+	total := 0
+	for _, content := range files {
+		total += estimateTokens(content)
+	}
+	return total
+}
+
+func namesBySizeDesc(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(files[names[i]]) != len(files[names[j]]) {
+			return len(files[names[i]]) > len(files[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// warnTruncated prints a warning to stderr naming any sources that were
+// truncated to fit the token budget.
+func warnTruncated(label string, counts []sourceTokens, budget int) {
+	var names []string
+	for _, c := range counts {
+		if c.Truncated {
+			names = append(names, fmt.Sprintf("%s (~%d tokens)", c.Name, c.Tokens))
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s exceeded token budget of %d; truncated %s\n",
+		label, budget, strings.Join(names, ", "))
+}
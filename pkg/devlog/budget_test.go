@@ -0,0 +1,147 @@
+package devlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	if got := estimateTokens(""); got != 0 {
+		t.Errorf("empty: got %d, want 0", got)
+	}
+	if got := estimateTokens(strings.Repeat("a", 400)); got != 100 {
+		t.Errorf("400 bytes: got %d, want 100", got)
+	}
+}
+
+func TestSplitSnapshots(t *testing.T) {
+	content := "=== SNAPSHOT 10:00 ===\nfirst\n=== SNAPSHOT 10:05 ===\nsecond\n"
+	chunks := splitSnapshots(content)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	if !strings.Contains(chunks[0], "first") || !strings.Contains(chunks[1], "second") {
+		t.Errorf("unexpected chunks: %q", chunks)
+	}
+	if strings.Join(chunks, "") != content {
+		t.Error("chunks should reassemble to the original content")
+	}
+
+	if got := splitSnapshots("no headers here\n"); len(got) != 1 {
+		t.Errorf("expected 1 chunk without headers, got %d", len(got))
+	}
+}
+
+func TestApplyTokenBudgetUnderBudget(t *testing.T) {
+	files := map[string]string{"notes.md": "short note\n"}
+	counts := applyTokenBudget(files, 1000)
+
+	if files["notes.md"] != "short note\n" {
+		t.Error("content under budget should be unchanged")
+	}
+	if len(counts) != 1 || counts[0].Truncated {
+		t.Errorf("unexpected counts: %+v", counts)
+	}
+}
+
+func TestApplyTokenBudgetDisabled(t *testing.T) {
+	big := strings.Repeat("x\n", 1000)
+	files := map[string]string{"notes.md": big}
+	applyTokenBudget(files, 0)
+
+	if files["notes.md"] != big {
+		t.Error("budget of 0 should disable trimming")
+	}
+}
+
+func TestApplyTokenBudgetTrimsOldestSnapshots(t *testing.T) {
+	var b strings.Builder
+	for _, ts := range []string{"10:00", "10:05", "10:10", "10:15"} {
+		b.WriteString("=== SNAPSHOT " + ts + " ===\n")
+		b.WriteString(strings.Repeat("diff line\n", 40))
+	}
+	files := map[string]string{
+		"git-proj.log": b.String(),
+		"notes.md":     "### At 10:20 #proj\nkeep me\n",
+	}
+
+	budget := estimateTokens(files["notes.md"]) + 250
+	counts := applyTokenBudget(files, budget)
+
+	git := files["git-proj.log"]
+	if strings.Contains(git, "10:00") {
+		t.Error("oldest snapshot should have been dropped")
+	}
+	if !strings.Contains(git, "10:15") {
+		t.Error("latest snapshot should be kept")
+	}
+	if files["notes.md"] != "### At 10:20 #proj\nkeep me\n" {
+		t.Error("notes should be untouched when snapshot trimming suffices")
+	}
+
+	for _, c := range counts {
+		switch c.Name {
+		case "git-proj.log":
+			if !c.Truncated {
+				t.Error("git log should be marked truncated")
+			}
+		case "notes.md":
+			if c.Truncated {
+				t.Error("notes should not be marked truncated")
+			}
+		}
+	}
+	if totalTokens(files) > budget {
+		t.Errorf("total %d exceeds budget %d", totalTokens(files), budget)
+	}
+}
+
+func TestApplyTokenBudgetKeepsPreamble(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("# tracking started\n")
+	for _, ts := range []string{"10:00", "10:05", "10:10", "10:15"} {
+		b.WriteString("=== SNAPSHOT " + ts + " ===\n")
+		b.WriteString(strings.Repeat("diff line\n", 40))
+	}
+	files := map[string]string{"git-proj.log": b.String()}
+
+	applyTokenBudget(files, 120)
+
+	git := files["git-proj.log"]
+	if !strings.HasPrefix(git, "# tracking started\n=== SNAPSHOT 10:15 ===\n") {
+		t.Errorf("expected the preamble and latest snapshot, got %q", git[:min(len(git), 60)])
+	}
+	for _, ts := range []string{"10:00", "10:05", "10:10"} {
+		if strings.Contains(git, ts) {
+			t.Errorf("snapshot %s should have been dropped", ts)
+		}
+	}
+	if totalTokens(files) > 120 {
+		t.Errorf("total %d exceeds budget 120", totalTokens(files))
+	}
+}
+
+func TestApplyTokenBudgetTrimsHead(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		b.WriteString("terminal output line\n")
+	}
+	b.WriteString("final line\n")
+	files := map[string]string{"term-proj.log": b.String()}
+
+	counts := applyTokenBudget(files, 100)
+
+	term := files["term-proj.log"]
+	if !strings.HasPrefix(term, truncatedMarker) {
+		t.Error("truncated content should start with the marker")
+	}
+	if !strings.HasSuffix(term, "final line\n") {
+		t.Error("most recent content should be kept")
+	}
+	if !counts[0].Truncated {
+		t.Error("term log should be marked truncated")
+	}
+	if totalTokens(files) > 100 {
+		t.Errorf("total %d exceeds budget 100", totalTokens(files))
+	}
+}
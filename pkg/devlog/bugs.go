@@ -0,0 +1,178 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultBugRange is the range `devlog bugs` covers by default.
+const defaultBugRange = "30d"
+
+// bugFollowDays is the number of days after a bug turns up whose summaries
+// are given to the summarizer, to find the fix that followed.
+const bugFollowDays = 3
+
+// Limits on the failing-test excerpts taken from a terminal log.
+const (
+	failureBefore      = 3  // lines of context before a failure line
+	failureAfter       = 10 // and after it
+	failureExcerptsMax = 8  // excerpts per log
+)
+
+var (
+	// failureLineRe matches lines reporting a failing test or a crash in the
+	// output of common test runners.
+	failureLineRe = regexp.MustCompile(`^--- FAIL|^FAIL\b|\bFAILED\b|\b\d+ (failed|failing)\b|^panic: |^Traceback \(most recent call last\)|AssertionError|^error\[E\d+\]|✗|✕`)
+
+	// ansiRe matches the terminal escape sequences in `script` recordings.
+	ansiRe = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07]*\x07|\r`)
+)
+
+// bugNotes returns the entries of notes content tagged #bug.
+func bugNotes(headings *noteHeadingFormat, content string) string {
+	var bugs []string
+	for _, entry := range splitNoteEntries(headings, content) {
+		heading, _, _ := strings.Cut(entry, "\n")
+		if noteHeadingHasTag(headings, heading, "bug") {
+			bugs = append(bugs, entry)
+		}
+	}
+	return strings.Join(bugs, "\n\n")
+}
+
+// failureExcerpts returns the parts of a terminal log around lines that
+// report failing tests, with escape sequences removed. Overlapping excerpts
+// are merged, and excerpts are separated by "...".
+func failureExcerpts(log string) string {
+	lines := strings.Split(ansiRe.ReplaceAllString(log, ""), "\n")
+	var excerpts []string
+	end := -1
+	for i := 0; i < len(lines) && len(excerpts) < failureExcerptsMax; i++ {
+		if !failureLineRe.MatchString(lines[i]) || i <= end {
+			continue
+		}
+		start := max(i-failureBefore, end+1, 0)
+		end = min(i+failureAfter, len(lines)-1)
+		// Extend over failure lines that follow closely.
+		for j := i + 1; j <= end && j < len(lines); j++ {
+			if failureLineRe.MatchString(lines[j]) {
+				end = min(j+failureAfter, len(lines)-1)
+			}
+		}
+		excerpts = append(excerpts, strings.TrimRight(strings.Join(lines[start:end+1], "\n"), "\n "))
+	}
+	return strings.Join(excerpts, "\n...\n")
+}
+
+// collectBugData gathers the material for project's bug journal over dates:
+// for each date, its #bug notes ("<date>/bug-notes.md") and failing-test
+// excerpts from its terminal logs ("<date>/test-failures.txt"), and the
+// project's summary of that date and of the bugFollowDays dates after it
+// ("<date>/summary.md"), which tell how the bugs were investigated and
+// fixed.
+func collectBugData(cfg Config, state State, dates []string, project string) map[string]string {
+	files := make(map[string]string)
+	follow := 0
+	for _, date := range dates {
+		found := false
+		if notes := bugNotes(cfg.noteHeadings(), filterNotesForProject(cfg.noteHeadings(), readNotes(cfg, date), project)); notes != "" {
+			files[date+"/bug-notes.md"] = notes
+			found = true
+		}
+		var failures []string
+		for _, path := range termFilesForProject(cfg, state, date, project) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			if ex := failureExcerpts(string(data)); ex != "" {
+				failures = append(failures, ex)
+			}
+		}
+		if len(failures) > 0 {
+			files[date+"/test-failures.txt"] = strings.Join(failures, "\n...\n")
+			found = true
+		}
+
+		if found {
+			follow = bugFollowDays + 1
+		}
+		if follow > 0 {
+			follow--
+			if content, err := readSummary(cfg, date); err == nil {
+				for _, s := range splitSummary(content) {
+					if s.Project == project {
+						files[date+"/summary.md"] = s.Text
+					}
+				}
+			}
+		}
+	}
+	return files
+}
+
+// assembleBugJournalPrompt builds the prompt for project's bug journal from
+// the files of collectBugData.
+func assembleBugJournalPrompt(project, from, to string, files map[string]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are writing a bug journal for the software project %q, covering %s\n"+
+		"to %s, from records kept by its developer.\n\n"+
+		"Below are, for each date on which bugs turned up:\n"+
+		"- <date>/bug-notes.md: notes the developer tagged #bug, with their time.\n"+
+		"- <date>/test-failures.txt: excerpts of terminal sessions around failing\n"+
+		"  tests, panics, and tracebacks, separated by \"...\".\n"+
+		"- <date>/summary.md: the summary of the day's work on the project, for\n"+
+		"  those dates and the few days after them.\n", project, from, to)
+	writeSortedFiles(&b, files)
+	b.WriteString(`
+Task: Write a journal entry for each distinct bug, oldest first, telling how
+it went from symptom to fix.
+
+Use this Markdown for each bug:
+
+## <date first seen>: <short title>
+
+**Symptom:** What went wrong and how it showed: the failing test, error
+message, or behavior.
+
+**Investigation:** What was tried to find the cause, including dead ends,
+and what the cause turned out to be.
+
+**Fix:** What fixed it and on which date, or "Unresolved" if the records do
+not show a fix.
+
+Guidelines:
+- Treat failures of the same test or with the same error across days as one
+  bug, unless the records show they had different causes.
+- Leave out failures that were expected, such as a test written to fail
+  first, and failures the records say nothing more about.
+- Quote error messages and test names exactly, in backticks.
+- Only report what the records support; do not guess at causes or fixes.
+- Write in first person.
+
+Output only the journal entries, nothing else.
+`)
+	return b.String()
+}
+
+// bugJournalPrompt returns the prompt for project's bug journal over dates.
+func bugJournalPrompt(cfg Config, state State, dates []string, project string) (string, error) {
+	from, to := dates[0], dates[len(dates)-1]
+	files := collectBugData(cfg, state, dates, project)
+	hasBugs := false
+	for name := range files {
+		if !strings.HasSuffix(name, "/summary.md") {
+			hasBugs = true
+		}
+	}
+	if !hasBugs {
+		return "", fmt.Errorf("no #bug notes or failing tests for %s from %s to %s", project, from, to)
+	}
+
+	counts := applyTokenBudget(files, cfg.TokenBudget)
+	warnTruncated("bug journal prompt", counts, cfg.TokenBudget)
+	return assembleBugJournalPrompt(project, from, to, files), nil
+}
@@ -0,0 +1,78 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailureExcerpts(t *testing.T) {
+	var log []string
+	for i := 0; i < 30; i++ {
+		log = append(log, "filler")
+	}
+	log[5] = "\x1b[31m--- FAIL: TestParse (0.00s)\x1b[0m\r"
+	log[7] = "FAIL\tgithub.com/x/parser\t0.01s"
+	log[25] = "panic: runtime error: index out of range"
+	got := failureExcerpts(strings.Join(log, "\n"))
+
+	excerpts := strings.Split(got, "\n...\n")
+	if len(excerpts) != 2 {
+		t.Fatalf("expected 2 excerpts, got %d:\n%s", len(excerpts), got)
+	}
+	// The two nearby failures are merged: 3 lines before the first, 10
+	// after the second.
+	if lines := strings.Split(excerpts[0], "\n"); len(lines) != 16 || lines[3] != "--- FAIL: TestParse (0.00s)" {
+		t.Errorf("first excerpt:\n%s", excerpts[0])
+	}
+	if !strings.HasPrefix(excerpts[1], "filler\nfiller\nfiller\npanic:") {
+		t.Errorf("second excerpt:\n%s", excerpts[1])
+	}
+	if failureExcerpts("ok  \tgithub.com/x/parser\t0.01s\nPASS\n") != "" {
+		t.Error("expected no excerpts from passing tests")
+	}
+}
+
+func TestBugJournalPrompt(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	cfg := Config{LogDir: filepath.Join(tmp, "log")}
+	os.MkdirAll(cfg.LogDir, 0o755)
+	write := func(path, content string) {
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+	write(filepath.Join(rawDir, "2024-01-15", "notes.md"),
+		"### At 10:00 #api #bug\nLogin returns 500 for new users\n\n### At 11:00 #api\nroutine\n\n### At 12:00 #web #bug\nother project\n")
+	write(filepath.Join(rawDir, "2024-01-15", "term-api.log"), "$ go test ./...\n--- FAIL: TestLogin (0.01s)\n")
+	write(filepath.Join(cfg.LogDir, "2024-01-16.md"), "# 2024-01-16\n\n## api\n\nFixed the login bug: a nil profile.\n")
+	write(filepath.Join(cfg.LogDir, "2024-01-25.md"), "# 2024-01-25\n\n## api\n\nUnrelated work.\n")
+
+	dates, _ := parseDateRange("2024-01-01..2024-01-31", time.Now())
+	prompt, err := bugJournalPrompt(cfg, State{}, dates, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"--- 2024-01-15/bug-notes.md ---\n### At 10:00 #api #bug\nLogin returns 500 for new users\n",
+		"--- 2024-01-15/test-failures.txt ---\n$ go test ./...\n--- FAIL: TestLogin (0.01s)\n",
+		"--- 2024-01-16/summary.md ---\nFixed the login bug",
+		"**Symptom:**",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	for _, unwanted := range []string{"routine", "other project", "Unrelated work"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("prompt includes %q", unwanted)
+		}
+	}
+
+	if _, err := bugJournalPrompt(cfg, State{}, dates, "web2"); err == nil {
+		t.Error("expected an error for a project without bugs")
+	}
+}
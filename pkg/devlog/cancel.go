@@ -0,0 +1,153 @@
+package devlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// errGenCancelled is the error of a generation stopped by Ctrl-C or `devlog
+// gen --cancel`. The commands in flight are killed; the compressed data
+// already cached is kept, and the summary is left as it was.
+var errGenCancelled = errors.New("generation cancelled")
+
+// genRunPath returns the path of the runtime file that marks the generation
+// run by process pid, see startGenRun.
+func genRunPath(pid int) string {
+	return runtimePath(fmt.Sprintf(".gen-%d", pid))
+}
+
+// genRunMarker returns the contents of the runtime file marking the
+// generation run by process pid: the PID and when the process started, so
+// that a process that is given the PID later is not taken for it.
+func genRunMarker(pid int) (string, error) {
+	start, err := processStartTime(pid)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d\n%s\n", pid, start), nil
+}
+
+// startGenRun returns the context of a generation run by this process: one
+// that is cancelled on Ctrl-C or SIGTERM, which `devlog gen --cancel` sends.
+// While it lasts, the run is marked by a runtime file (see genRunMarker).
+// stop removes the file and restores the default handling of the signals.
+func startGenRun() (ctx context.Context, stop func()) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	killChildrenOnExit()
+	path := genRunPath(os.Getpid())
+	// Without the file the run can still be stopped with Ctrl-C.
+	if marker, err := genRunMarker(os.Getpid()); err == nil && prepareRuntimeDir(filepath.Dir(path)) == nil {
+		os.WriteFile(path, []byte(marker), 0o600)
+	}
+	return ctx, func() {
+		os.Remove(path)
+		cancel()
+	}
+}
+
+// cancelGenRuns stops the generations run by other devlog processes, found
+// by their runtime files, and returns how many it stopped. A process is only
+// stopped if it is the one that wrote the file, started when the file says.
+// Files left by processes that have exited, or whose PID is now another
+// process's, are removed.
+func cancelGenRuns() (int, error) {
+	if err := checkRuntimeDir(filepath.Dir(runtimePath(".gen-"))); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	paths, err := filepath.Glob(runtimePath(".gen-*"))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0]))
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		if marker, err := genRunMarker(pid); err != nil || marker != string(data) || !isProcessRunning(pid) {
+			os.Remove(path)
+			continue
+		}
+		if err := stopProcess(pid); err != nil {
+			return n, fmt.Errorf("stopping generation (PID %d): %w", pid, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// cancelGens stops the generations in progress: the server's, through the
+// cancel_gen command, and those of other devlog processes. It returns how
+// many it stopped.
+func cancelGens() (int, error) {
+	n := 0
+	resp, err := ipcSend(IPCRequest{Command: "cancel_gen"})
+	if err != nil && !isServerNotRunning(err) {
+		return 0, err
+	}
+	if err == nil {
+		if !resp.OK {
+			return 0, errors.New(resp.Error)
+		}
+		var data CancelGenData
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return 0, fmt.Errorf("parsing response: %w", err)
+		}
+		n += data.Cancelled
+	}
+	stopped, err := cancelGenRuns()
+	return n + stopped, err
+}
+
+// startGen returns the context of a generation run by the server, which
+// ends with parent or when cancel_gen is received, and a func to call when
+// the generation is done.
+func (s *Server) startGen(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	s.mu.Lock()
+	if s.gens == nil {
+		s.gens = make(map[int]context.CancelFunc)
+	}
+	s.nextGen++
+	id := s.nextGen
+	s.gens[id] = cancel
+	s.mu.Unlock()
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.gens, id)
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+// handleCancelGen cancels the running generation and the queued ones.
+func (s *Server) handleCancelGen() IPCResponse {
+	n := s.jobs.cancelQueued()
+	s.mu.Lock()
+	n += len(s.gens)
+	for _, cancel := range s.gens {
+		cancel()
+	}
+	s.mu.Unlock()
+	if n > 0 {
+		s.logger.Info("cancelling generation", "count", n)
+	}
+	data, _ := json.Marshal(CancelGenData{Cancelled: n})
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
@@ -0,0 +1,147 @@
+package devlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRunGenCancel(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	// The summarizer hangs after saying it started.
+	started := filepath.Join(tmp, "started")
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "hung"), []byte("#!/bin/sh\ntouch "+started+"\nexec sleep 30\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"), []byte("=== SNAPSHOT 10:00 ===\ndiff\n\n"), 0o644)
+	cfg := Config{GenCmd: "hung", CompCmd: "mycompressor"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		_, err := runGen(ctx, cfg, State{}, date, nil)
+		errc <- err
+	}()
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the summarizer did not start")
+		}
+	}
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, errGenCancelled) {
+			t.Errorf("runGen = %v, want errGenCancelled", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("runGen did not stop")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled generation took %v to stop", elapsed)
+	}
+
+	if entries, _ := os.ReadDir(logDir); len(entries) != 0 {
+		t.Errorf("a cancelled generation should write nothing to the log dir, found %v", entries)
+	}
+	if data, err := os.ReadFile(compCachePath(cfg, "git", "alpha", date)); err != nil || string(data) != "Compressed data." {
+		t.Errorf("the comp cache written before the cancel should be kept, got %q, %v", data, err)
+	}
+}
+
+func TestServerCancelGen(t *testing.T) {
+	s := newServer(Config{})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s.jobs.enqueue("2024-01-15", nil)
+	ctx, done := s.startGen(context.Background())
+	defer done()
+
+	var data CancelGenData
+	json.Unmarshal(s.handleCancelGen().Data, &data)
+	if data.Cancelled != 2 || ctx.Err() == nil {
+		t.Errorf("cancel_gen cancelled %d, ctx.Err() = %v; want the running and the queued one", data.Cancelled, ctx.Err())
+	}
+	done()
+	json.Unmarshal(s.handleCancelGen().Data, &data)
+	if data.Cancelled != 0 {
+		t.Errorf("cancel_gen with no generation cancelled %d", data.Cancelled)
+	}
+}
+
+func TestCancelGenRuns(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	proc := exec.Command("sleep", "30")
+	if err := proc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Process.Kill()
+	marker, err := genRunMarker(proc.Process.Pid)
+	if err != nil {
+		t.Fatalf("genRunMarker: %v", err)
+	}
+	os.WriteFile(genRunPath(proc.Process.Pid), []byte(marker), 0o600)
+
+	// A file left by a process that has exited is removed.
+	gone := exec.Command("true")
+	gone.Run()
+	stale := genRunPath(gone.Process.Pid)
+	os.WriteFile(stale, []byte(strconv.Itoa(gone.Process.Pid)+"\n1\n"), 0o600)
+
+	// So is one whose PID now belongs to another process, which is left
+	// running.
+	other := exec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Process.Kill()
+	reused := genRunPath(other.Process.Pid)
+	os.WriteFile(reused, []byte(strconv.Itoa(other.Process.Pid)+"\n1\n"), 0o600)
+
+	n, err := cancelGenRuns()
+	if err != nil || n != 1 {
+		t.Fatalf("cancelGenRuns = %d, %v; want 1", n, err)
+	}
+	if _, err := os.Stat(reused); !os.IsNotExist(err) {
+		t.Error("the file of a reused PID should be removed")
+	}
+	if !isProcessRunning(other.Process.Pid) {
+		t.Error("a process that reused the PID of a generation was stopped")
+	}
+	exited := make(chan struct{})
+	go func() {
+		proc.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Error("the generation process was not stopped")
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("the stale generation file should be removed")
+	}
+}
@@ -0,0 +1,120 @@
+package devlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// noChangelogEntries is what the changelog prompt asks for when a period
+// has no user-facing changes; such periods are left out.
+const noChangelogEntries = "No user-facing changes."
+
+// weekStart returns the Monday of the week of date ("YYYY-MM-DD").
+func weekStart(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	offset := (int(d.Weekday()) + 6) % 7
+	return d.AddDate(0, 0, -offset).Format("2006-01-02")
+}
+
+// assembleChangelogPrompt builds the prompt that distills a project's
+// summary sections from from to to into changelog entries.
+func assembleChangelogPrompt(project, from, to string, files map[string]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are writing changelog entries for the project %q, covering %s to\n"+
+		"%s. Below are the developer's daily work summaries for the project, one\n"+
+		"file per day.\n", project, from, to)
+	writeSortedFiles(&b, files)
+	b.WriteString(`
+Task: Distill the summaries into changelog entries for the project's users.
+
+Use these Markdown sections, in this order, leaving out any that would be
+empty:
+
+### Added
+### Changed
+### Fixed
+### Removed
+
+Guidelines:
+- Write one bullet per change, describing what users of the project will
+  notice, not how it was implemented.
+- Only include work that was finished. Leave out work in progress, dead
+  ends, refactoring, tests, and other internal changes.
+- Merge work on the same change over several days into one bullet.
+- Use the imperative mood, e.g. "Add --json output to the status command".
+- If there are no user-facing changes, output exactly "` + noChangelogEntries + `"
+
+Output only the sections, nothing else.
+`)
+	return b.String()
+}
+
+// changelog returns a Markdown changelog for project over dates, built from
+// its summary sections by calling run with each prompt. With byWeek, a
+// prompt is run for each week with summaries, and the weeks are listed
+// newest first under "## Week of <Monday>" headings.
+func changelog(cfg Config, project string, dates []string, byWeek bool, run func(prompt string) (string, error)) (string, error) {
+	from, to := dates[0], dates[len(dates)-1]
+	files := collectSummaries(cfg, dates, []string{project})
+	if len(files) == 0 {
+		return "", fmt.Errorf("no summaries of %s between %s and %s", project, from, to)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Changelog: %s\n", project)
+
+	if !byWeek {
+		counts := applyTokenBudget(files, cfg.TokenBudget)
+		warnTruncated("changelog prompt", counts, cfg.TokenBudget)
+		entries, err := run(assembleChangelogPrompt(project, from, to, files))
+		if err != nil {
+			return "", err
+		}
+		if entries == noChangelogEntries {
+			return "", fmt.Errorf("no user-facing changes to %s between %s and %s", project, from, to)
+		}
+		fmt.Fprintf(&b, "\n## %s to %s\n\n%s\n", from, to, entries)
+		return b.String(), nil
+	}
+
+	weeks := make(map[string]map[string]string)
+	for name, content := range files {
+		week := weekStart(strings.TrimSuffix(name, ".md"))
+		if weeks[week] == nil {
+			weeks[week] = make(map[string]string)
+		}
+		weeks[week][name] = content
+	}
+	starts := make([]string, 0, len(weeks))
+	for week := range weeks {
+		starts = append(starts, week)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(starts)))
+
+	written := 0
+	for _, week := range starts {
+		weekFiles := weeks[week]
+		counts := applyTokenBudget(weekFiles, cfg.TokenBudget)
+		warnTruncated("changelog prompt for the week of "+week, counts, cfg.TokenBudget)
+		end, _ := time.Parse("2006-01-02", week)
+		entries, err := run(assembleChangelogPrompt(project, week, end.AddDate(0, 0, 6).Format("2006-01-02"), weekFiles))
+		if err != nil {
+			return "", fmt.Errorf("week of %s: %w", week, err)
+		}
+		if entries == noChangelogEntries {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## Week of %s\n\n%s\n", week, entries)
+		written++
+	}
+	if written == 0 {
+		return "", fmt.Errorf("no user-facing changes to %s between %s and %s", project, from, to)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,74 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	for date, want := range map[string]string{
+		"2024-01-15": "2024-01-15", // Monday
+		"2024-01-17": "2024-01-15",
+		"2024-01-21": "2024-01-15", // Sunday
+		"2024-01-01": "2024-01-01",
+		"2024-03-02": "2024-02-26",
+	} {
+		if got := weekStart(date); got != want {
+			t.Errorf("weekStart(%s) = %s, want %s", date, got, want)
+		}
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir()}
+	for date, content := range map[string]string{
+		"2024-01-16": "## alpha\n\nAdded export.\n\n## beta\n\nBeta work.\n",
+		"2024-01-18": "## alpha\n\nFixed export crash.\n",
+		"2024-01-23": "## alpha\n\nRefactored internals.\n",
+		"2024-01-30": "## alpha\n\nAdded import.\n",
+	} {
+		os.WriteFile(filepath.Join(cfg.LogDir, date+".md"), []byte("# "+date+"\n\n"+content), 0o644)
+	}
+	dates, _ := parseDateRange("2024-01-15..2024-01-31", time.Now())
+
+	var prompts []string
+	run := func(prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if strings.Contains(prompt, "covering 2024-01-22") {
+			return noChangelogEntries, nil
+		}
+		return "### Added\n\n- Something", nil
+	}
+
+	doc, err := changelog(cfg, "alpha", dates, false, run)
+	if err != nil {
+		t.Fatalf("changelog: %v", err)
+	}
+	if len(prompts) != 1 || strings.Contains(prompts[0], "Beta work.") || !strings.Contains(prompts[0], "Fixed export crash.") {
+		t.Errorf("unexpected prompt:\n%s", prompts[0])
+	}
+	if doc != "# Changelog: alpha\n\n## 2024-01-15 to 2024-01-31\n\n### Added\n\n- Something\n" {
+		t.Errorf("unexpected changelog:\n%s", doc)
+	}
+
+	prompts = nil
+	doc, err = changelog(cfg, "alpha", dates, true, run)
+	if err != nil {
+		t.Fatalf("changelog by week: %v", err)
+	}
+	if len(prompts) != 3 {
+		t.Errorf("expected a prompt per week, got %d", len(prompts))
+	}
+	// Newest first, without the week that had no user-facing changes.
+	if !strings.Contains(doc, "## Week of 2024-01-29") || strings.Contains(doc, "2024-01-22") ||
+		strings.Index(doc, "Week of 2024-01-29") > strings.Index(doc, "Week of 2024-01-15") {
+		t.Errorf("unexpected weekly changelog:\n%s", doc)
+	}
+
+	if _, err := changelog(cfg, "gamma", dates, false, run); err == nil {
+		t.Error("expected error for a project without summaries")
+	}
+}
@@ -0,0 +1,475 @@
+package devlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ccEntry struct {
+	Type      string     `json:"type"`
+	Timestamp string     `json:"timestamp"`
+	SessionID string     `json:"sessionId"`
+	Cwd       string     `json:"cwd"`
+	Message   *ccMessage `json:"message"`
+}
+
+type ccMessage struct {
+	ID      string          `json:"id"`
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+	Usage   *ccUsage        `json:"usage"`
+}
+
+type ccUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+type ccContentBlock struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// claudeSource is a Claude Code project directory to read sessions from.
+// When cwd is set, only entries whose working directory is cwd or below it
+// are used.
+type claudeSource struct {
+	dir string
+	cwd string
+}
+
+// claudeSourcesFor returns the Claude Code session sources for a watched
+// entry. Sessions are stored by the directory Claude Code was started in, so
+// a monorepo subdirectory also reads the sessions started at the repo root,
+// filtered to the entries whose cwd is within the subdirectory.
+func claudeSourcesFor(claudeDir string, w WatchEntry) []claudeSource {
+	sources := []claudeSource{{dir: filepath.Join(claudeDir, repoPathToClaudeDir(w.Path))}}
+	if w.Subdir != "" {
+		sources = append(sources, claudeSource{
+			dir: filepath.Join(claudeDir, repoPathToClaudeDir(w.repoRoot())),
+			cwd: w.Path,
+		})
+	}
+	return sources
+}
+
+// claudeSourceFiles returns the session JSONL files of sources.
+func claudeSourceFiles(sources []claudeSource) []string {
+	var files []string
+	for _, src := range sources {
+		matches, _ := filepath.Glob(filepath.Join(src.dir, "*.jsonl"))
+		files = append(files, matches...)
+	}
+	return files
+}
+
+func inCwd(entryCwd, cwd string) bool {
+	return cwd == "" || entryCwd == cwd || strings.HasPrefix(entryCwd, cwd+string(filepath.Separator))
+}
+
+func preprocessClaudeCodeSessions(sources []claudeSource, date string, loc *time.Location) (string, error) {
+	return preprocessSessions(sources, func(t time.Time) bool {
+		return t.In(loc).Format("2006-01-02") == date
+	}, loc)
+}
+
+// preprocessClaudeCodeSessionsSince is preprocessClaudeCodeSessions for the
+// messages at or after since, on any date.
+func preprocessClaudeCodeSessionsSince(sources []claudeSource, since time.Time, loc *time.Location) (string, error) {
+	return preprocessSessions(sources, func(t time.Time) bool {
+		return !t.Before(since)
+	}, loc)
+}
+
+// preprocessSessions returns the transcripts of the sessions in sources,
+// limited to the messages whose time keep accepts, oldest session first.
+func preprocessSessions(sources []claudeSource, keep func(time.Time) bool, loc *time.Location) (string, error) {
+	type sessionResult struct {
+		transcript string
+		firstTime  time.Time
+	}
+
+	var sessions []sessionResult
+	for _, src := range sources {
+		matches, err := filepath.Glob(filepath.Join(src.dir, "*.jsonl"))
+		if err != nil {
+			return "", err
+		}
+		for _, path := range matches {
+			transcript, firstTime, err := parseSession(path, src.cwd, keep, loc)
+			if err != nil {
+				continue
+			}
+			if transcript != "" {
+				sessions = append(sessions, sessionResult{transcript, firstTime})
+			}
+		}
+	}
+
+	if len(sessions) == 0 {
+		return "", nil
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].firstTime.Before(sessions[j].firstTime)
+	})
+
+	var b strings.Builder
+	for i, s := range sessions {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(s.transcript)
+	}
+
+	return b.String(), nil
+}
+
+func parseSessionForDate(path, cwd, targetDate string, loc *time.Location) (string, time.Time, error) {
+	return parseSession(path, cwd, func(t time.Time) bool {
+		return t.In(loc).Format("2006-01-02") == targetDate
+	}, loc)
+}
+
+// parseSession returns the transcript of the messages of the session log at
+// path whose time keep accepts, and the local time of the first of them.
+func parseSession(path, cwd string, keep func(time.Time) bool, loc *time.Location) (string, time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer f.Close()
+
+	var entries []ccEntry
+	var firstTime time.Time
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry ccEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+
+		if entry.Type != "user" && entry.Type != "assistant" {
+			continue
+		}
+		if entry.Timestamp == "" || entry.Message == nil || !inCwd(entry.Cwd, cwd) {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			continue
+		}
+		localTime := t.In(loc)
+		if !keep(localTime) {
+			continue
+		}
+
+		if firstTime.IsZero() || localTime.Before(firstTime) {
+			firstTime = localTime
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return "", time.Time{}, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== SESSION started %s ===\n", firstTime.Format("15:04"))
+
+	for _, entry := range entries {
+		if entry.Message.Role == "user" {
+			text := extractUserText(entry.Message.Content)
+			if text != "" {
+				fmt.Fprintf(&b, "\n> %s\n", text)
+			}
+		} else if entry.Message.Role == "assistant" {
+			blocks := extractAssistantBlocks(entry.Message.Content)
+			for _, block := range blocks {
+				switch block.Type {
+				case "text":
+					fmt.Fprintf(&b, "\n%s\n", block.Text)
+				case "tool_use":
+					summary := summarizeToolInput(block.Name, block.Input)
+					fmt.Fprintf(&b, "\n%s\n", summary)
+				}
+			}
+		}
+	}
+
+	return b.String(), firstTime, nil
+}
+
+func extractUserText(content json.RawMessage) string {
+	// Try as string first
+	var s string
+	if err := json.Unmarshal(content, &s); err == nil {
+		return s
+	}
+	// If it's an array (tool results), skip
+	return ""
+}
+
+func extractAssistantBlocks(content json.RawMessage) []ccContentBlock {
+	var blocks []ccContentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return nil
+	}
+	// Filter out thinking blocks
+	var result []ccContentBlock
+	for _, b := range blocks {
+		if b.Type == "thinking" {
+			continue
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
+func hasEntriesOnDate(sources []claudeSource, targetDate string, loc *time.Location) bool {
+	for _, src := range sources {
+		matches, _ := filepath.Glob(filepath.Join(src.dir, "*.jsonl"))
+		for _, path := range matches {
+			if checkFileForDate(path, src.cwd, targetDate, loc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkFileForDate(path, cwd, targetDate string, loc *time.Location) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			Timestamp string `json:"timestamp"`
+			Cwd       string `json:"cwd"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Timestamp == "" || !inCwd(entry.Cwd, cwd) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil {
+			continue
+		}
+		if t.In(loc).Format("2006-01-02") == targetDate {
+			return true
+		}
+	}
+	return false
+}
+
+func summarizeToolInput(name string, input json.RawMessage) string {
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(input, &params); err != nil {
+		return fmt.Sprintf("[Tool: %s]", name)
+	}
+
+	var keyParam string
+	switch name {
+	case "Read", "Edit", "Write":
+		keyParam = "file_path"
+	case "Bash":
+		keyParam = "command"
+	case "Grep", "Glob":
+		keyParam = "pattern"
+	case "WebSearch":
+		keyParam = "query"
+	case "WebFetch":
+		keyParam = "url"
+	case "Task":
+		keyParam = "prompt"
+	default:
+		return fmt.Sprintf("[Tool: %s]", name)
+	}
+
+	raw, ok := params[keyParam]
+	if !ok {
+		return fmt.Sprintf("[Tool: %s]", name)
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return fmt.Sprintf("[Tool: %s]", name)
+	}
+
+	return fmt.Sprintf("[Tool: %s %s=%q]", name, keyParam, value)
+}
+
+// claudeUsage totals Claude Code activity for one project.
+type claudeUsage struct {
+	Sessions     map[string]bool // session IDs with entries on the date
+	InputTokens  int             // including cache creation and cache reads
+	OutputTokens int
+	Times        []time.Time // timestamps of the user and assistant entries
+}
+
+// claudeUsageForDate counts the sessions and token usage recorded in a
+// project's Claude Code sources on the target date. Claude Code writes one
+// entry per assistant content block, each repeating the message's usage, so
+// usage is counted once per message ID.
+func claudeUsageForDate(sources []claudeSource, targetDate string, loc *time.Location) claudeUsage {
+	usage := claudeUsage{Sessions: make(map[string]bool)}
+	seenMessages := make(map[string]bool)
+	for _, src := range sources {
+		matches, _ := filepath.Glob(filepath.Join(src.dir, "*.jsonl"))
+		for _, path := range matches {
+			usageFromFile(&usage, seenMessages, path, src.cwd, targetDate, loc)
+		}
+	}
+	return usage
+}
+
+func usageFromFile(usage *claudeUsage, seenMessages map[string]bool, path, cwd, targetDate string, loc *time.Location) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry ccEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Type != "user" && entry.Type != "assistant" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if err != nil || t.In(loc).Format("2006-01-02") != targetDate || !inCwd(entry.Cwd, cwd) {
+			continue
+		}
+
+		sessionID := entry.SessionID
+		if sessionID == "" {
+			sessionID = path
+		}
+		usage.Sessions[sessionID] = true
+		usage.Times = append(usage.Times, t)
+
+		if entry.Message == nil || entry.Message.Usage == nil {
+			continue
+		}
+		if id := entry.Message.ID; id != "" {
+			if seenMessages[id] {
+				continue
+			}
+			seenMessages[id] = true
+		}
+		u := entry.Message.Usage
+		usage.InputTokens += u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+		usage.OutputTokens += u.OutputTokens
+	}
+}
+
+// claudeSuggestWindow is how recent a Claude Code session in an unwatched
+// repo must be for the server to report the repo (see unwatchedClaudeRepos).
+const claudeSuggestWindow = 7 * 24 * time.Hour
+
+// sessionCwd returns the working directory of the first entry of the session
+// file at path that records one.
+func sessionCwd(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry ccEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Cwd != "" {
+			return entry.Cwd
+		}
+	}
+	return ""
+}
+
+// watchesPath reports whether an entry of watched covers path: path is
+// the entry's repo root or inside the directory it watches.
+func watchesPath(watched []WatchEntry, path string) bool {
+	for _, w := range watched {
+		if w.repoRoot() == path || inCwd(path, w.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// unwatchedClaudeRepos returns the git repos that Claude Code sessions
+// changed since since were started in but that watched does not cover, most
+// recent session first. A session's repo is that of its working directory,
+// as the directory names of claudeDir cannot be decoded reliably.
+func unwatchedClaudeRepos(claudeDir string, watched []WatchEntry, since time.Time) []UnwatchedRepo {
+	dirs, err := os.ReadDir(claudeDir)
+	if err != nil {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, w := range watched {
+		known[repoPathToClaudeDir(w.Path)] = true
+	}
+
+	last := make(map[string]time.Time)
+	for _, d := range dirs {
+		if !d.IsDir() || known[d.Name()] {
+			continue
+		}
+		var latest string
+		var latestTime time.Time
+		for _, file := range claudeSourceFiles([]claudeSource{{dir: filepath.Join(claudeDir, d.Name())}}) {
+			if info, err := os.Stat(file); err == nil && info.ModTime().After(latestTime) {
+				latest, latestTime = file, info.ModTime()
+			}
+		}
+		if latestTime.Before(since) {
+			continue
+		}
+		cwd := sessionCwd(latest)
+		if cwd == "" {
+			continue
+		}
+		root, err := resolveRepoRoot(cwd)
+		if err != nil || watchesPath(watched, root) || watchesPath(watched, cwd) {
+			continue
+		}
+		if latestTime.After(last[root]) {
+			last[root] = latestTime
+		}
+	}
+
+	repos := make([]UnwatchedRepo, 0, len(last))
+	for path, t := range last {
+		repos = append(repos, UnwatchedRepo{Path: path, LastSession: t})
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].LastSession.After(repos[j].LastSession)
+	})
+	return repos
+}
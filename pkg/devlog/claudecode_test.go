@@ -1,4 +1,4 @@
-package main
+package devlog
 
 import (
 	"encoding/json"
@@ -16,6 +16,7 @@ func TestRepoPathToClaudeDir(t *testing.T) {
 		{"/home/chad/dev/ctrl", "-home-chad-dev-ctrl"},
 		{"/home/user/work/api", "-home-user-work-api"},
 		{"/tmp/test", "-tmp-test"},
+		{`C:\Users\chad\dev\ctrl`, "C--Users-chad-dev-ctrl"},
 	}
 	for _, tt := range tests {
 		got := repoPathToClaudeDir(tt.input)
@@ -64,7 +65,7 @@ func TestPreprocessClaudeCodeSessions(t *testing.T) {
 
 	os.WriteFile(filepath.Join(tmp, "session1.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
 
-	result, err := preprocessClaudeCodeSessions(tmp, date, loc)
+	result, err := preprocessClaudeCodeSessions([]claudeSource{{dir: tmp}}, date, loc)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -119,7 +120,7 @@ func TestPreprocessClaudeCodeSessionsMultiple(t *testing.T) {
 	os.WriteFile(filepath.Join(tmp, "sess2.jsonl"), []byte(strings.Join(session2, "\n")+"\n"), 0o644)
 	os.WriteFile(filepath.Join(tmp, "sess1.jsonl"), []byte(strings.Join(session1, "\n")+"\n"), 0o644)
 
-	result, err := preprocessClaudeCodeSessions(tmp, date, loc)
+	result, err := preprocessClaudeCodeSessions([]claudeSource{{dir: tmp}}, date, loc)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -150,7 +151,7 @@ func TestPreprocessClaudeCodeSessionsNoMatch(t *testing.T) {
 
 	os.WriteFile(filepath.Join(tmp, "session.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
 
-	result, err := preprocessClaudeCodeSessions(tmp, "2024-06-15", loc)
+	result, err := preprocessClaudeCodeSessions([]claudeSource{{dir: tmp}}, "2024-06-15", loc)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -178,7 +179,7 @@ func TestPreprocessClaudeCodeSessionsSkipsSubagents(t *testing.T) {
 	}
 	os.WriteFile(filepath.Join(subDir, "sub.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
 
-	result, err := preprocessClaudeCodeSessions(tmp, date, loc)
+	result, err := preprocessClaudeCodeSessions([]claudeSource{{dir: tmp}}, date, loc)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -269,10 +270,10 @@ func TestHasEntriesOnDate(t *testing.T) {
 	}
 	os.WriteFile(filepath.Join(tmp, "session.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
 
-	if !hasEntriesOnDate(tmp, "2024-06-15", loc) {
+	if !hasEntriesOnDate([]claudeSource{{dir: tmp}}, "2024-06-15", loc) {
 		t.Error("should find entries on matching date")
 	}
-	if hasEntriesOnDate(tmp, "2024-06-16", loc) {
+	if hasEntriesOnDate([]claudeSource{{dir: tmp}}, "2024-06-16", loc) {
 		t.Error("should NOT find entries on different date")
 	}
 }
@@ -293,7 +294,7 @@ func TestParseSessionDateFilteringUTC(t *testing.T) {
 	os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
 
 	// In UTC, this is June 15
-	transcript, _, err := parseSessionForDate(path, "2024-06-15", time.UTC)
+	transcript, _, err := parseSessionForDate(path, "", "2024-06-15", time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -303,7 +304,7 @@ func TestParseSessionDateFilteringUTC(t *testing.T) {
 
 	// In UTC+2, this is June 16
 	loc := time.FixedZone("UTC+2", 2*60*60)
-	transcript, _, err = parseSessionForDate(path, "2024-06-16", loc)
+	transcript, _, err = parseSessionForDate(path, "", "2024-06-16", loc)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -312,7 +313,7 @@ func TestParseSessionDateFilteringUTC(t *testing.T) {
 	}
 
 	// In UTC+2, should NOT match June 15
-	transcript, _, err = parseSessionForDate(path, "2024-06-15", loc)
+	transcript, _, err = parseSessionForDate(path, "", "2024-06-15", loc)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -329,3 +330,89 @@ func jsonLine(t *testing.T, v interface{}) string {
 	}
 	return string(data)
 }
+
+func TestClaudeUsageForDate(t *testing.T) {
+	tmp := t.TempDir()
+	usage := map[string]interface{}{
+		"input_tokens": 100, "output_tokens": 50,
+		"cache_creation_input_tokens": 10, "cache_read_input_tokens": 5,
+	}
+	lines := []string{
+		jsonLine(t, map[string]interface{}{
+			"type": "user", "timestamp": "2024-06-15T10:00:00.000Z", "sessionId": "s1",
+			"message": map[string]interface{}{"role": "user", "content": "hi"},
+		}),
+		// Two entries for the same message repeat its usage
+		jsonLine(t, map[string]interface{}{
+			"type": "assistant", "timestamp": "2024-06-15T10:01:00.000Z", "sessionId": "s1",
+			"message": map[string]interface{}{"id": "msg_1", "role": "assistant", "usage": usage,
+				"content": []map[string]interface{}{{"type": "text", "text": "a"}}},
+		}),
+		jsonLine(t, map[string]interface{}{
+			"type": "assistant", "timestamp": "2024-06-15T10:01:01.000Z", "sessionId": "s1",
+			"message": map[string]interface{}{"id": "msg_1", "role": "assistant", "usage": usage,
+				"content": []map[string]interface{}{{"type": "text", "text": "b"}}},
+		}),
+		// Different date
+		jsonLine(t, map[string]interface{}{
+			"type": "assistant", "timestamp": "2024-06-16T10:00:00.000Z", "sessionId": "s2",
+			"message": map[string]interface{}{"id": "msg_2", "role": "assistant", "usage": usage,
+				"content": []map[string]interface{}{{"type": "text", "text": "c"}}},
+		}),
+	}
+	os.WriteFile(filepath.Join(tmp, "s1.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+
+	got := claudeUsageForDate([]claudeSource{{dir: tmp}}, "2024-06-15", time.UTC)
+	if len(got.Sessions) != 1 {
+		t.Errorf("sessions = %d, want 1", len(got.Sessions))
+	}
+	if got.InputTokens != 115 {
+		t.Errorf("input tokens = %d, want 115", got.InputTokens)
+	}
+	if got.OutputTokens != 50 {
+		t.Errorf("output tokens = %d, want 50", got.OutputTokens)
+	}
+}
+
+func TestClaudeSourcesForSubdir(t *testing.T) {
+	claudeDir := t.TempDir()
+	w := WatchEntry{Path: "/home/user/mono/services/api", Name: "api", Subdir: "services/api"}
+
+	sources := claudeSourcesFor(claudeDir, w)
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 sources, got %d", len(sources))
+	}
+	rootDir := filepath.Join(claudeDir, "-home-user-mono")
+	if sources[1].dir != rootDir || sources[1].cwd != w.Path {
+		t.Errorf("unexpected root source: %+v", sources[1])
+	}
+
+	// A session started at the repo root that moved into the subdirectory
+	os.MkdirAll(rootDir, 0o755)
+	lines := []string{
+		jsonLine(t, map[string]interface{}{
+			"type": "user", "timestamp": "2024-06-15T10:00:00.000Z", "cwd": "/home/user/mono",
+			"message": map[string]interface{}{"role": "user", "content": "root work"},
+		}),
+		jsonLine(t, map[string]interface{}{
+			"type": "user", "timestamp": "2024-06-15T10:05:00.000Z", "cwd": "/home/user/mono/services/api",
+			"message": map[string]interface{}{"role": "user", "content": "api work"},
+		}),
+		jsonLine(t, map[string]interface{}{
+			"type": "user", "timestamp": "2024-06-15T10:10:00.000Z", "cwd": "/home/user/mono/services/api-gateway",
+			"message": map[string]interface{}{"role": "user", "content": "gateway work"},
+		}),
+	}
+	os.WriteFile(filepath.Join(rootDir, "s1.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+
+	result, err := preprocessClaudeCodeSessions(sources, "2024-06-15", time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "api work") {
+		t.Error("missing entry in subdirectory")
+	}
+	if strings.Contains(result, "root work") || strings.Contains(result, "gateway work") {
+		t.Errorf("entries outside subdirectory should be filtered: %q", result)
+	}
+}
@@ -0,0 +1,121 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+)
+
+// Main runs the devlog command line in os.Args. It is the whole of the
+// devlog binary, which is a thin wrapper around it.
+func Main() {
+	profile, args, err := takeProfileFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if profile != "" {
+		// Set in the environment so that the server, hooks, and other
+		// devlog processes started from here use the same profile.
+		os.Setenv("DEVLOG_PROFILE", profile)
+		os.Args = append(os.Args[:1], args...)
+	}
+	if err := validateProfileName(activeProfile()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) < 2 {
+		cmdNote()
+		return
+	}
+	switch os.Args[1] {
+	case "note":
+		cmdNote()
+	case "gen":
+		cmdGen()
+	case "gen-prompt":
+		cmdGenPrompt()
+	case "post":
+		cmdPost()
+	case "publish":
+		cmdPublish()
+	case "standup":
+		cmdStandup()
+	case "resume":
+		cmdResume()
+	case "now":
+		cmdNow()
+	case "review":
+		cmdReview()
+	case "changelog":
+		cmdChangelog()
+	case "diff-summary":
+		cmdDiffSummary()
+	case "bugs":
+		cmdBugs()
+	case "clip":
+		cmdClip()
+	case "menu":
+		cmdMenu()
+	case "notes":
+		cmdNotes()
+	case "highlights":
+		cmdHighlights()
+	case "search":
+		cmdSearch()
+	case "ask":
+		cmdAsk()
+	case "export":
+		cmdExport()
+	case "import":
+		cmdImport()
+	case "archive":
+		cmdArchive()
+	case "db":
+		cmdDB()
+	case "stats":
+		cmdStats()
+	case "heatmap":
+		cmdHeatmap()
+	case "focus":
+		cmdFocus()
+	case "session":
+		cmdSession()
+	case "time":
+		cmdTime()
+	case "invoice":
+		cmdInvoice()
+	case "todo":
+		cmdTodo()
+	case "watch":
+		cmdWatch()
+	case "unwatch":
+		cmdUnwatch()
+	case "rename":
+		cmdRename()
+	case "install-hooks":
+		cmdInstallHooks()
+	case "start":
+		cmdStart()
+	case "stop":
+		cmdStop()
+	case "status":
+		cmdStatus()
+	case "jobs":
+		cmdJobs()
+	case "health":
+		cmdHealth()
+	case "statusbar":
+		cmdStatusbar()
+	case "web":
+		cmdWeb()
+	case "mcp":
+		cmdMCP()
+	case "reload":
+		cmdReload()
+	case "config":
+		cmdConfig()
+	default:
+		cmdNote()
+	}
+}
@@ -0,0 +1,62 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands returns the commands that may print the clipboard, in
+// order of preference for the current session.
+func clipboardCommands(goos string, getenv func(string) string) [][]string {
+	if goos == "darwin" {
+		return [][]string{{"pbpaste"}}
+	}
+	var cmds [][]string
+	if getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"wl-paste", "--no-newline"})
+	}
+	return append(cmds,
+		[]string{"xclip", "-selection", "clipboard", "-o"},
+		[]string{"xsel", "--clipboard", "--output"},
+	)
+}
+
+// readClipboard returns the clipboard contents using the first available
+// clipboard tool.
+func readClipboard() (string, error) {
+	cmds := clipboardCommands(runtime.GOOS, os.Getenv)
+	for _, args := range cmds {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", args[0], err)
+		}
+		return string(out), nil
+	}
+	var names []string
+	for _, args := range cmds {
+		names = append(names, args[0])
+	}
+	return "", fmt.Errorf("no clipboard tool found (tried %s)", strings.Join(names, ", "))
+}
+
+// fenceCode wraps text in a Markdown code block, using a fence longer than
+// any run of backticks in text so pasted Markdown can't close it early.
+func fenceCode(text string) string {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "\n" + text + "\n" + fence
+}
@@ -0,0 +1,31 @@
+package devlog
+
+import "testing"
+
+func TestClipboardCommands(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+
+	cmds := clipboardCommands("linux", env(map[string]string{"WAYLAND_DISPLAY": "wayland-0"}))
+	if cmds[0][0] != "wl-paste" {
+		t.Errorf("expected wl-paste first under Wayland, got %v", cmds)
+	}
+	cmds = clipboardCommands("linux", env(nil))
+	if cmds[0][0] != "xclip" {
+		t.Errorf("expected xclip first under X11, got %v", cmds)
+	}
+	cmds = clipboardCommands("darwin", env(nil))
+	if len(cmds) != 1 || cmds[0][0] != "pbpaste" {
+		t.Errorf("expected pbpaste on macOS, got %v", cmds)
+	}
+}
+
+func TestFenceCode(t *testing.T) {
+	if got := fenceCode("panic: oops"); got != "```\npanic: oops\n```" {
+		t.Errorf("unexpected fence: %q", got)
+	}
+	if got := fenceCode("see ```go\nx\n```"); got != "````\nsee ```go\nx\n```\n````" {
+		t.Errorf("fence should be longer than backtick runs: %q", got)
+	}
+}
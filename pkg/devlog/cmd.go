@@ -0,0 +1,3136 @@
+package devlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func cmdNote() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "note" {
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("note", flag.ExitOnError)
+	msg := fs.String("m", "", "note message")
+	gui := fs.Bool("g", false, "use GUI dialog for input")
+	code := fs.String("c", "", "code block")
+	proj := fs.String("p", "", "project name (comma-separated for several)")
+	tagList := fs.String("t", "", "note tags, comma-separated: "+strings.Join(semanticNoteTags, ", "))
+	pin := fs.Bool("pin", false, "pin the note as a highlight to keep in the summary")
+	atFlag := fs.String("at", "", "record the note at this time (HH:MM)")
+	dateFlag := fs.String("date", "", "record the note on this date (YYYY-MM-DD)")
+	var attachments []string
+	fs.Func("attach", "copy a file into the raw data and reference it in the note (repeatable)", func(s string) error {
+		attachments = append(attachments, s)
+		return nil
+	})
+	args, pick := takeBareProjectFlag(args)
+	fs.Parse(args)
+
+	if *msg != "" && *gui {
+		fmt.Fprintln(os.Stderr, "Error: -m and -g are mutually exclusive")
+		os.Exit(1)
+	}
+
+	at, err := noteTime(time.Now(), *dateFlag, *atFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, a := range attachments {
+		if info, err := os.Stat(a); err != nil || !info.Mode().IsRegular() {
+			fmt.Fprintf(os.Stderr, "Error: cannot attach %s: not a readable file\n", a)
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if pick {
+		*proj = pickNoteProject(cfg)
+	}
+	projects, err := resolveNoteProjects(cfg, *proj)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	projectName := strings.Join(projects, ", ")
+
+	var tags []string
+	if *tagList != "" {
+		for _, t := range strings.Split(*tagList, ",") {
+			t = strings.TrimPrefix(strings.TrimSpace(t), "#")
+			if !containsString(semanticNoteTags, t) {
+				fmt.Fprintf(os.Stderr, "Error: unknown tag %q (valid tags: %s)\n", t, strings.Join(semanticNoteTags, ", "))
+				os.Exit(1)
+			}
+			tags = append(tags, t)
+		}
+	}
+	if *pin && !containsString(tags, pinTag) {
+		tags = append(tags, pinTag)
+	}
+
+	notesFile := notesPathFor(cfg, at.Format("2006-01-02"), projects)
+
+	var msgText string
+	if *msg != "" {
+		msgText = *msg
+	} else if *gui {
+		msgText, err = kdialogInput(projectName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if msgText == "" {
+			fmt.Println("Note cancelled (empty message)")
+			return
+		}
+	} else if stdinIsPiped() {
+		msgText, err = readNoteText(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if msgText == "" {
+			fmt.Println("Note cancelled (empty message)")
+			return
+		}
+	} else {
+		msgText, err = editNote(cfg, projectName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if msgText == "" {
+			fmt.Println("Note cancelled (empty message)")
+			return
+		}
+	}
+
+	var noteText string
+	if *code != "" {
+		noteText = msgText + "\n```\n" + *code + "\n```"
+	} else {
+		noteText = msgText
+	}
+	for _, a := range attachments {
+		name, err := copyAttachment(cfg, at.Format("2006-01-02"), a)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		noteText += "\n" + attachmentPrefix + name
+	}
+
+	if err := writeNote(cfg.noteHeadings(), notesFile, at, noteText, append(projects, tags...)...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if projectName != "" {
+		fmt.Printf("Logged note for %s.\n", projectName)
+	} else {
+		fmt.Println("Logged note.")
+	}
+}
+
+// resolveNoteProjects returns the projects to tag a note with: the
+// comma-separated -p list if given, with likely typos of known projects
+// corrected (see correctProjectTypo), otherwise the watched entry or repo
+// containing the current directory. Outside a repo it returns none.
+func resolveNoteProjects(cfg Config, proj string) ([]string, error) {
+	state, _ := loadState()
+	if proj != "" {
+		known := knownProjects(cfg, state)
+		var projects []string
+		for _, p := range strings.Split(proj, ",") {
+			p = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(p), "#"))
+			if err := validateProjectName(p); err != nil {
+				return nil, err
+			}
+			projects = append(projects, correctProjectTypo(p, known, os.Stdin, os.Stderr, !stdinIsPiped()))
+		}
+		return projects, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if w, ok := containingEntry(cwd, state.Watched); ok {
+		return []string{w.Name}, nil
+	}
+	if repoRoot, err := resolveRepoRoot(cwd); err == nil {
+		return []string{projectNameForRepo(repoRoot, state, "")}, nil
+	}
+	return nil, nil
+}
+
+func editNote(cfg Config, projectName string) (string, error) {
+	editor := resolveEditor(cfg)
+
+	tmp, err := os.CreateTemp("", "devlog-note-*.md")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	displayProject := projectName
+	if displayProject == "" {
+		displayProject = "N/A"
+	}
+	template := fmt.Sprintf("# Project: %s\n# Enter your note below. Lines starting with # are ignored.\n", displayProject)
+	tmp.WriteString(template)
+	tmp.Close()
+
+	cmd := exec.Command(editor, tmpPath)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("reading note: %w", err)
+	}
+
+	// Strip comment lines and trim
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a
+// terminal.
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// readNoteText reads a note body from r, dropping leading blank lines and
+// trailing whitespace but keeping the indentation of piped output.
+func readNoteText(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("reading stdin: %w", err)
+	}
+	text := strings.TrimRight(string(data), " \t\r\n")
+	for strings.HasPrefix(text, "\n") || strings.HasPrefix(text, "\r\n") {
+		text = strings.TrimPrefix(strings.TrimPrefix(text, "\r"), "\n")
+	}
+	return text, nil
+}
+
+// noteTime returns the time to record a note at: now, moved to date
+// (YYYY-MM-DD) and clock time at (HH:MM) when those are given.
+func noteTime(now time.Time, date, at string) (time.Time, error) {
+	y, mo, d := now.Date()
+	h, mi := now.Hour(), now.Minute()
+	if date != "" {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", date)
+		}
+		y, mo, d = t.Date()
+	}
+	if at != "" {
+		t, err := time.Parse("15:04", at)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q (expected HH:MM)", at)
+		}
+		h, mi = t.Hour(), t.Minute()
+	}
+	return time.Date(y, mo, d, h, mi, 0, 0, now.Location()), nil
+}
+
+// writeNote records a note with a heading for time at. Notes are kept in
+// chronological order: a note is appended unless it is backdated before
+// existing entries, in which case it is inserted before the first later one.
+func writeNote(headings *noteHeadingFormat, notesFile string, at time.Time, text string, tags ...string) error {
+	if err := os.MkdirAll(filepath.Dir(notesFile), 0o755); err != nil {
+		return fmt.Errorf("creating raw dir: %w", err)
+	}
+
+	clock := at.Format("15:04")
+	entry := formatNoteHeading(headings, at.Format("2006-01-02"), clock, tags...) + "\n" + text + "\n\n"
+
+	data, err := os.ReadFile(notesFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading notes file: %w", err)
+	}
+	if pos := noteInsertPos(headings, string(data), clock); pos < len(data) {
+		content := string(data[:pos]) + entry + string(data[pos:])
+		if err := os.WriteFile(notesFile, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("writing note: %w", err)
+		}
+		return nil
+	}
+
+	f, err := os.OpenFile(notesFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening notes file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(entry); err != nil {
+		return fmt.Errorf("writing note: %w", err)
+	}
+	return nil
+}
+
+// noteInsertPos returns the byte offset of the first note heading in content
+// later than clock (HH:MM), or len(content) if there is none.
+func noteInsertPos(headings *noteHeadingFormat, content, clock string) int {
+	pos := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if c, _, ok := parseNoteHeading(headings, line); ok && c > clock {
+			return pos
+		}
+		pos += len(line)
+	}
+	return len(content)
+}
+
+func kdialogInput(project string) (string, error) {
+	displayProject := project
+	if displayProject == "" {
+		displayProject = "N/A"
+	}
+	cmd := exec.Command("kdialog", "--textinputbox", fmt.Sprintf("Enter note for %s", displayProject))
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 means the user pushed Cancel or hit Escape.
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func cmdGen() {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "show what would be generated without running any AI commands")
+	verbose := fs.Bool("v", false, "print progress and timing while generating")
+	stream := fs.Bool("stream", false, "stream the summaries to stderr as they are generated (implies -v)")
+	notify := fs.Bool("notify", false, "send a desktop notification when done (for scheduled runs)")
+	post := fs.Bool("post", false, "post the summary to notify_webhook when done")
+	proj := fs.String("p", "", "only summarize these projects, comma-separated, keeping the rest of an existing summary")
+	redacted := fs.Bool("redacted", false, "also write a shareable summary without project names, file paths, or clients")
+	soFar := fs.Bool("so-far", false, "summarize today's data so far into a partial summary, leaving the day's summary alone")
+	cancelRuns := fs.Bool("cancel", false, "stop the generations in progress, in the server or in other devlog gen commands")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the date, e.g. "devlog gen 2024-01-15 -p foo".
+	date := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	if *cancelRuns {
+		n, err := cancelGens()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if n == 0 {
+			fmt.Println("No generation in progress")
+		} else {
+			fmt.Printf("Cancelled %s\n", plural(n, "generation"))
+		}
+		return
+	}
+
+	state, _ := loadState()
+
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if !isValidDate(date) {
+		fmt.Fprintln(os.Stderr, "Error: invalid date format, expected YYYY-MM-DD")
+		os.Exit(1)
+	}
+	var only []string
+	for _, p := range strings.Split(*proj, ",") {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			only = append(only, p)
+		}
+	}
+	if *soFar && date != time.Now().Format("2006-01-02") {
+		fmt.Fprintln(os.Stderr, "Error: --so-far summarizes today; it takes no other date")
+		os.Exit(1)
+	}
+	if *soFar && (*dryRun || *redacted || *post) {
+		fmt.Fprintln(os.Stderr, "Error: --so-far cannot be combined with --dry-run, --redacted, or --post")
+		os.Exit(1)
+	}
+	if err := restoreRawDates(cfg, []string{date}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *dryRun {
+		if err := runGenDryRun(cfg, state, date, only); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var progress *genProgress
+	if *verbose || *stream {
+		progress = newGenProgress(os.Stderr)
+		progress.stream = *stream
+	}
+
+	if cfg.ServerGen && !*soFar && !*verbose && !*stream {
+		if n, ok, err := runGenInServer(date, only); ok {
+			if *notify {
+				notifyGenResult(date, n, err)
+			}
+			exitIfGenCancelled(err)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			genFinish(cfg, state, date, n, *redacted, *post, false)
+			return
+		}
+	}
+
+	ctx, stop := startGenRun()
+	defer stop()
+
+	if *soFar {
+		path, n, err := runGenSoFar(ctx, cfg, state, date, only, progress)
+		stop()
+		exitIfGenCancelled(err)
+		if *notify {
+			notifyGenResult(date, n, err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if path != "" {
+			fmt.Printf("Partial summary written to %s\n", path)
+		}
+		return
+	}
+
+	n, err := runGenProjects(ctx, cfg, state, date, only, progress)
+	stop()
+	exitIfGenCancelled(err)
+	if *notify {
+		notifyGenResult(date, n, err)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	genFinish(cfg, state, date, n, *redacted, *post, true)
+}
+
+// genFinish does what `devlog gen` does after a generation of n projects on
+// date: update the todo list, unless the server did, and write the redacted
+// summary and post the summary if asked to.
+func genFinish(cfg Config, state State, date string, n int, redacted, post, todos bool) {
+	if todos {
+		if _, err := syncTodos(cfg, []string{date}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: updating todo list: %v\n", err)
+		}
+	}
+	if redacted {
+		path, err := runRedact(cfg, state, date)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Redacted summary written to %s\n", path)
+	}
+	if post && n > 0 {
+		if cfg.NotifyWebhook == "" {
+			fmt.Fprintln(os.Stderr, "Warning: --post: notify_webhook is not set in config.toml")
+		} else if err := postSummary(cfg, cfg.NotifyWebhook, date, cfg.WebhookProjects); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			fmt.Println("Summary posted to webhook")
+		}
+	}
+}
+
+// exitIfGenCancelled exits if err is errGenCancelled.
+func exitIfGenCancelled(err error) {
+	if !errors.Is(err, errGenCancelled) {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Generation cancelled, the summary was left as it was")
+	os.Exit(1)
+}
+
+func cmdPost() {
+	fs := flag.NewFlagSet("post", flag.ExitOnError)
+	proj := fs.String("p", "", "only post these projects, comma-separated (default: webhook_projects, or all)")
+	webhookURL := fs.String("url", "", "webhook URL (default: notify_webhook)")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the date, e.g. "devlog post 2024-01-15 -p foo".
+	date := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if !isValidDate(date) {
+		fmt.Fprintln(os.Stderr, "Error: invalid date format, expected YYYY-MM-DD")
+		os.Exit(1)
+	}
+	target := *webhookURL
+	if target == "" {
+		target = cfg.NotifyWebhook
+	}
+	if target == "" {
+		fmt.Fprintln(os.Stderr, "Error: no webhook URL; set notify_webhook in config.toml or pass --url")
+		os.Exit(1)
+	}
+	projects := cfg.WebhookProjects
+	if *proj != "" {
+		projects = nil
+		for _, p := range strings.Split(*proj, ",") {
+			if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+				projects = append(projects, p)
+			}
+		}
+	}
+
+	if err := postSummary(cfg, target, date, projects); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Posted summary for %s\n", date)
+}
+
+func cmdPublish() {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	proj := fs.String("p", "", "only publish these projects, comma-separated")
+	database := fs.String("database", "", "Notion database ID (default: notion_database)")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the target and range.
+	var args []string
+	for fs.NArg() > 0 {
+		args = append(args, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	if len(args) < 1 || len(args) > 2 || args[0] != "notion" {
+		fmt.Fprintln(os.Stderr, "Usage: devlog publish notion [-p <project>[,...]] [--database <id>] [<range>]")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	token := resolveNotionToken(cfg)
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "Error: no Notion token; set notion_token in config.toml or $NOTION_TOKEN")
+		os.Exit(1)
+	}
+	db := *database
+	if db == "" {
+		db = cfg.NotionDatabase
+	}
+	if db == "" {
+		fmt.Fprintln(os.Stderr, "Error: no Notion database; set notion_database in config.toml or pass --database")
+		os.Exit(1)
+	}
+	var projects []string
+	for _, p := range strings.Split(*proj, ",") {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			projects = append(projects, p)
+		}
+	}
+
+	dates := []string{time.Now().Format("2006-01-02")}
+	if len(args) == 2 {
+		if dates, err = parseDateRange(args[1], time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	client := newNotionClient(token)
+	published := 0
+	for _, date := range dates {
+		content, err := os.ReadFile(summaryPath(cfg, date))
+		if err != nil {
+			continue
+		}
+		sections := selectSummarySections(string(content), projects)
+		if len(sections) == 0 {
+			continue
+		}
+		created, err := publishNotion(client, db, date, sections)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: publishing %s: %v\n", date, err)
+			os.Exit(1)
+		}
+		if created {
+			fmt.Printf("Created Notion page for %s\n", date)
+		} else {
+			fmt.Printf("Updated Notion page for %s\n", date)
+		}
+		published++
+	}
+	if published == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no summaries to publish in the given dates")
+		os.Exit(1)
+	}
+}
+
+func cmdStandup() {
+	fs := flag.NewFlagSet("standup", flag.ExitOnError)
+	proj := fs.String("p", "", "only report these projects, comma-separated")
+	promptOnly := fs.Bool("prompt", false, "print the prompt instead of running gen_cmd")
+	verbose := fs.Bool("v", false, "print progress while compressing today's data")
+	fs.Parse(os.Args[2:])
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state, _ := loadState()
+
+	var projects []string
+	for _, p := range strings.Split(*proj, ",") {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			projects = append(projects, p)
+		}
+	}
+
+	var progress *genProgress
+	if *verbose {
+		progress = newGenProgress(os.Stderr)
+	}
+
+	prompt, err := standupPrompt(cfg, state, time.Now().Format("2006-01-02"), projects, progress)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *promptOnly {
+		fmt.Print(prompt)
+		return
+	}
+
+	update, _, err := runGenCmd(context.Background(), cfg, prompt, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(update)
+}
+
+func cmdResume() {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	notes := fs.Int("notes", defaultResumeNotes, "number of recent notes to show (0 for none)")
+	all := fs.Bool("all", false, "show the latest summary of every project instead of the current one")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the project, e.g. "devlog resume foo --notes 5".
+	project := strings.TrimPrefix(fs.Arg(0), "#")
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if project == "" && !*all {
+		if projects, err := resolveNoteProjects(cfg, ""); err == nil && len(projects) > 0 {
+			project = projects[0]
+		}
+	}
+
+	r, err := collectResume(cfg, project, *notes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	writeResume(os.Stdout, r, time.Now())
+}
+
+func cmdNow() {
+	fs := flag.NewFlagSet("now", flag.ExitOnError)
+	proj := fs.String("p", "", "only recap this project")
+	minutes := fs.Int("minutes", int(defaultNowWindow.Minutes()), "how many minutes back to look")
+	promptOnly := fs.Bool("prompt", false, "print the prompt instead of running comp_cmd")
+	fs.Parse(os.Args[2:])
+	if fs.NArg() > 0 || *minutes <= 0 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog now [-p <project>] [--minutes <n>] [--prompt]")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state, _ := loadState()
+
+	window := time.Duration(*minutes) * time.Minute
+	prompt := nowPrompt(cfg, state, strings.TrimPrefix(*proj, "#"), window, time.Now())
+	if prompt == "" {
+		fmt.Fprintf(os.Stderr, "No activity in the last %d minutes\n", *minutes)
+		return
+	}
+	if *promptOnly {
+		fmt.Print(prompt)
+		return
+	}
+
+	recap, err := runPromptCmd(context.Background(), "comp_cmd", cfg.CompCmd, prompt, timeoutMinutes(cfg.CompTimeout))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(recap)
+}
+
+func cmdReview() {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	from := fs.String("from", "", "first date of the review period (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "last date of the review period (YYYY-MM-DD)")
+	proj := fs.String("project", "", "only include these projects, comma-separated")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	verbose := fs.Bool("v", false, "print progress while condensing months")
+	fs.Parse(os.Args[2:])
+
+	if *from == "" {
+		fmt.Fprintln(os.Stderr, "Usage: devlog review --from <date> [--to <date>] [--project <name>[,<name>...]] [-o <file>]")
+		os.Exit(1)
+	}
+	dates, err := parseDateRange(*from+".."+*to, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var projects []string
+	for _, p := range strings.Split(*proj, ",") {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			projects = append(projects, p)
+		}
+	}
+
+	var progress *genProgress
+	if *verbose {
+		progress = newGenProgress(os.Stderr)
+	}
+	digest := func(month, prompt string) (string, error) {
+		progress.printf("condensing %s (~%d tokens)…", month, estimateTokens(prompt))
+		out, _, err := runGenCmd(context.Background(), cfg, prompt, nil)
+		return out, err
+	}
+
+	prompt, err := reviewPrompt(cfg, dates, projects, digest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	progress.printf("writing review (~%d tokens)…", estimateTokens(prompt))
+	doc, _, err := runGenCmd(context.Background(), cfg, prompt, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		fmt.Println(doc)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(doc+"\n"), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote review for %s to %s to %s\n", dates[0], dates[len(dates)-1], *output)
+}
+
+func cmdChangelog() {
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	since := fs.String("since", "", "first date to include (YYYY-MM-DD)")
+	until := fs.String("until", time.Now().Format("2006-01-02"), "last date to include (YYYY-MM-DD)")
+	byWeek := fs.Bool("by-week", false, "group entries by week, newest first")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the project, e.g. "devlog changelog foo --since ...".
+	project := strings.TrimPrefix(fs.Arg(0), "#")
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	if project == "" || *since == "" {
+		fmt.Fprintln(os.Stderr, "Usage: devlog changelog <project> --since <date> [--until <date>] [--by-week] [-o <file>]")
+		os.Exit(1)
+	}
+	dates, err := parseDateRange(*since+".."+*until, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	run := func(prompt string) (string, error) {
+		out, _, err := runGenCmd(context.Background(), cfg, prompt, nil)
+		return out, err
+	}
+	doc, err := changelog(cfg, project, dates, *byWeek, run)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		fmt.Print(doc)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(doc), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote changelog for %s to %s\n", project, *output)
+}
+
+func cmdBugs() {
+	fs := flag.NewFlagSet("bugs", flag.ExitOnError)
+	promptOnly := fs.Bool("prompt", false, "print the prompt instead of running gen_cmd")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the arguments, e.g. "devlog bugs foo 90d -o bugs.md".
+	var args []string
+	for fs.NArg() > 0 {
+		args = append(args, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog bugs <project> [<range>] [--prompt] [-o <file>]")
+		os.Exit(1)
+	}
+	project := strings.TrimPrefix(args[0], "#")
+	rangeArg := defaultBugRange
+	if len(args) == 2 {
+		rangeArg = args[1]
+	}
+
+	dates, err := parseDateRange(rangeArg, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state, _ := loadState()
+
+	prompt, err := bugJournalPrompt(cfg, state, dates, project)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *promptOnly {
+		fmt.Print(prompt)
+		return
+	}
+
+	doc, _, err := runGenCmd(context.Background(), cfg, prompt, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		fmt.Println(doc)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(doc+"\n"), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote bug journal for %s to %s\n", project, *output)
+}
+
+func cmdDiffSummary() {
+	fs := flag.NewFlagSet("diff-summary", flag.ExitOnError)
+	proj := fs.String("p", "", "only compare these projects, comma-separated")
+	promptOnly := fs.Bool("prompt", false, "print the prompt instead of running gen_cmd")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the periods, e.g. "devlog diff-summary 7d 14d -p foo".
+	var periods []string
+	for fs.NArg() > 0 {
+		periods = append(periods, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	if len(periods) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog diff-summary <range> <range> [-p <projects>] [--prompt] [-o <file>]")
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	earlier, err := parseDateRange(periods[0], now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	later, err := parseDateRange(periods[1], now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var projects []string
+	for _, p := range strings.Split(*proj, ",") {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			projects = append(projects, p)
+		}
+	}
+
+	prompt, err := comparePrompt(cfg, earlier, later, projects)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *promptOnly {
+		fmt.Print(prompt)
+		return
+	}
+
+	doc, _, err := runGenCmd(context.Background(), cfg, prompt, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		fmt.Println(doc)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(doc+"\n"), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote comparison to %s\n", *output)
+}
+
+// notifyGenResult sends a desktop notification for a finished generation of
+// n projects, or for its error. Generations with nothing to do are silent.
+func notifyGenResult(date string, n int, genErr error) {
+	var summary, body string
+	urgency := notifyUrgencyNormal
+	switch {
+	case genErr != nil:
+		summary = "devlog: summary for " + date + " failed"
+		body = genErr.Error()
+		urgency = notifyUrgencyCritical
+	case n == 0:
+		return
+	case n == 1:
+		summary = "devlog: summary for " + date + " is ready"
+		body = "1 project summarized"
+	default:
+		summary = "devlog: summary for " + date + " is ready"
+		body = fmt.Sprintf("%d projects summarized", n)
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: desktop notification: %v\n", err)
+		return
+	}
+	defer conn.Close()
+	if err := newNotifier(conn).send(summary, body, urgency); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: desktop notification: %v\n", err)
+	}
+}
+
+func isValidDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+// parseDateRange expands a date range argument into the dates it covers, in
+// order. Accepted forms are "" (today), "YYYY-MM-DD", "YYYY-MM-DD..YYYY-MM-DD"
+// (inclusive), "YYYY-MM" (a month), and "Nd" (the last N days, including
+// today).
+func parseDateRange(s string, now time.Time) ([]string, error) {
+	today := now.Format("2006-01-02")
+	var from, to string
+	switch {
+	case s == "":
+		from, to = today, today
+	case strings.HasSuffix(s, "d") && !strings.Contains(s, "-"):
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid date range %q", s)
+		}
+		from = now.AddDate(0, 0, -(n - 1)).Format("2006-01-02")
+		to = today
+	case strings.Contains(s, ".."):
+		from, to, _ = strings.Cut(s, "..")
+	case len(s) == len("2006-01"):
+		month, err := time.Parse("2006-01", s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date format %q, expected YYYY-MM-DD or YYYY-MM", s)
+		}
+		from = month.Format("2006-01-02")
+		to = month.AddDate(0, 1, -1).Format("2006-01-02")
+	default:
+		from, to = s, s
+	}
+
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format %q, expected YYYY-MM-DD", from)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format %q, expected YYYY-MM-DD", to)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("invalid date range %q: end is before start", s)
+	}
+
+	var dates []string
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+	return dates, nil
+}
+
+func cmdGenPrompt() {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	state, _ := loadState()
+
+	date := time.Now().Format("2006-01-02")
+	if len(os.Args) >= 3 && os.Args[1] == "gen-prompt" {
+		date = os.Args[2]
+		if !isValidDate(date) {
+			fmt.Fprintln(os.Stderr, "Error: invalid date format, expected YYYY-MM-DD")
+			os.Exit(1)
+		}
+	}
+	if err := restoreRawDates(cfg, []string{date}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := runGenPrompt(cfg, state, date); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func cmdClip() {
+	fs := flag.NewFlagSet("clip", flag.ExitOnError)
+	comment := fs.String("m", "", "one-line comment to put above the clipboard contents")
+	proj := fs.String("p", "", "project name (comma-separated for several)")
+	args, pick := takeBareProjectFlag(os.Args[2:])
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if pick {
+		*proj = pickNoteProject(cfg)
+	}
+	projects, err := resolveNoteProjects(cfg, *proj)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	clip, err := readClipboard()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	clip = strings.TrimRight(clip, " \t\r\n")
+	if strings.TrimSpace(clip) == "" {
+		fmt.Println("Note cancelled (clipboard is empty)")
+		return
+	}
+
+	noteText := fenceCode(clip)
+	if c := strings.TrimSpace(*comment); c != "" {
+		noteText = c + "\n" + noteText
+	}
+
+	now := time.Now()
+	if err := writeNote(cfg.noteHeadings(), notesPathFor(cfg, now.Format("2006-01-02"), projects), now, noteText, projects...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(projects) > 0 {
+		fmt.Printf("Logged clipboard for %s.\n", strings.Join(projects, ", "))
+	} else {
+		fmt.Println("Logged clipboard.")
+	}
+}
+
+func cmdMenu() {
+	fs := flag.NewFlagSet("menu", flag.ExitOnError)
+	launcherCmd := fs.String("cmd", "", "dmenu-compatible launcher command (default: rofi, fuzzel, wofi, or dmenu)")
+	list := fs.Bool("list", false, "print the project choices and exit")
+	fs.Parse(os.Args[2:])
+
+	state, _ := loadState()
+	lines := menuLines(state.Watched)
+	if *list {
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	launcher, err := resolveMenuLauncher(*launcherCmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The first menu takes either a project or "#project note text" typed in
+	// full; a bare project is followed by a second menu for the note text.
+	selection, err := launcher.choose("devlog", lines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	project, content := parseKRunnerQuery(selection)
+	if project == "" {
+		fmt.Println("Note cancelled (no project selected)")
+		return
+	}
+	if err := validateProjectName(project); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if content == "" {
+		content, err = launcher.choose("#"+project, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if content == "" {
+			fmt.Println("Note cancelled (empty message)")
+			return
+		}
+	}
+
+	now := time.Now()
+	if err := writeNote(cfg.noteHeadings(), notesPathFor(cfg, now.Format("2006-01-02"), []string{project}), now, content, project); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Logged note for %s.\n", project)
+}
+
+func cmdNotes() {
+	fs := flag.NewFlagSet("notes", flag.ExitOnError)
+	proj := fs.String("p", "", "only show notes for this project (\"general\" for notes without one)")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the date, e.g. "devlog notes 7d -p foo".
+	dateArg := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dates, err := parseDateRange(dateArg, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	project := strings.TrimPrefix(*proj, "#")
+	days := collectNotes(cfg, dates, project)
+	if len(days) == 0 {
+		what := "notes"
+		if project != "" {
+			what = "notes for " + project
+		}
+		if len(dates) == 1 {
+			fmt.Fprintf(os.Stderr, "No %s on %s\n", what, dates[0])
+		} else {
+			fmt.Fprintf(os.Stderr, "No %s from %s to %s\n", what, dates[0], dates[len(dates)-1])
+		}
+		return
+	}
+	printNotes(os.Stdout, days)
+}
+
+func cmdHighlights() {
+	fs := flag.NewFlagSet("highlights", flag.ExitOnError)
+	proj := fs.String("p", "", "only show highlights for this project (\"general\" for notes without one)")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the range, e.g. "devlog highlights 30d -p foo".
+	dateArg := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dates, err := parseDateRange(dateArg, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	project := strings.TrimPrefix(*proj, "#")
+	days := collectHighlights(cfg, dates, project)
+	if len(days) == 0 {
+		what := "pinned notes"
+		if project != "" {
+			what = "pinned notes for " + project
+		}
+		if len(dates) == 1 {
+			fmt.Fprintf(os.Stderr, "No %s on %s\n", what, dates[0])
+		} else {
+			fmt.Fprintf(os.Stderr, "No %s from %s to %s\n", what, dates[0], dates[len(dates)-1])
+		}
+		return
+	}
+	printNotes(os.Stdout, days)
+}
+
+func cmdSearch() {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	semantic := fs.Bool("semantic", false, "rank by meaning using embed_cmd instead of matching keywords")
+	raw := fs.Bool("raw", false, "find where a string appears in the raw data, oldest first")
+	proj := fs.String("p", "", "only search this project (\"general\" for notes without one)")
+	limit := fs.Int("n", 10, "show at most this many days (with --raw, matches)")
+	rangeArg := fs.String("range", "", "only search these dates (as for devlog notes; default: all)")
+	reindex := fs.Bool("reindex", false, "discard the embedding index and embed everything again")
+	verbose := fs.Bool("v", false, "print progress while embedding")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the query, e.g. "devlog search websocket race -p foo".
+	var terms []string
+	for fs.NArg() > 0 {
+		terms = append(terms, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	query := strings.Join(terms, " ")
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "Usage: devlog search [--semantic | --raw] [-p <project>] [-n <days>] [--range <range>] <query>")
+		os.Exit(1)
+	}
+	if *semantic && *raw {
+		fmt.Fprintln(os.Stderr, "Error: --semantic and --raw cannot be combined")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dates := rawDataDates(cfg)
+	if *rangeArg != "" {
+		if dates, err = parseDateRange(*rangeArg, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := restoreRawDates(cfg, dates); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *reindex {
+		path := resolveEmbeddingIndexPath()
+		if *raw {
+			path = resolveRawIndexPath()
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *raw {
+		idx, err := refreshRawIndex(cfg, rawDataDates(cfg))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		matches, err := searchRaw(cfg.noteHeadings(), idx, query, dates, strings.TrimPrefix(*proj, "#"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "No matches for %q\n", query)
+			return
+		}
+		if *limit > 0 && len(matches) > *limit {
+			matches = matches[:*limit]
+		}
+		printRawMatches(os.Stdout, matches)
+		return
+	}
+
+	var progress *genProgress
+	if *verbose {
+		progress = newGenProgress(os.Stderr)
+	}
+	hits, err := searchHistory(cfg, query, dates, strings.TrimPrefix(*proj, "#"), *semantic, progress)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	days := rankDays(hits, *limit)
+	if len(days) == 0 {
+		fmt.Fprintf(os.Stderr, "No matches for %q\n", query)
+		return
+	}
+	printSearchResults(os.Stdout, cfg.noteHeadings(), days, query, *semantic)
+}
+
+func cmdAsk() {
+	fs := flag.NewFlagSet("ask", flag.ExitOnError)
+	keyword := fs.Bool("keyword", false, "retrieve by keywords even if embed_cmd is set")
+	proj := fs.String("p", "", "only use this project's summaries and notes")
+	sources := fs.Int("n", askSources, "number of excerpts to give the model")
+	rangeArg := fs.String("range", "", "only use these dates (as for devlog notes; default: all)")
+	promptOnly := fs.Bool("prompt", false, "print the prompt instead of running gen_cmd")
+	verbose := fs.Bool("v", false, "print progress while embedding")
+	fs.Parse(os.Args[2:])
+	var words []string
+	for fs.NArg() > 0 {
+		words = append(words, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	question := strings.Join(words, " ")
+	if question == "" {
+		fmt.Fprintln(os.Stderr, "Usage: devlog ask [--keyword] [-p <project>] [-n <excerpts>] [--range <range>] [--prompt] <question>")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dates := rawDataDates(cfg)
+	if *rangeArg != "" {
+		if dates, err = parseDateRange(*rangeArg, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var progress *genProgress
+	if *verbose {
+		progress = newGenProgress(os.Stderr)
+	}
+	semantic := cfg.EmbedCmd != "" && !*keyword
+	prompt, used, err := askPrompt(cfg, question, dates, strings.TrimPrefix(*proj, "#"), semantic, *sources, progress)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *promptOnly {
+		fmt.Print(prompt)
+		return
+	}
+
+	answer, _, err := runGenCmd(context.Background(), cfg, prompt, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(answer)
+	fmt.Println("\nSources:")
+	for _, d := range used {
+		fmt.Printf("  %s\n", sourceLabel(d))
+	}
+}
+
+func cmdExport() {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	site := fs.String("site", "", "write a static site content tree to this directory")
+	format := fs.String("format", "hugo", "front matter flavor: "+strings.Join(siteFormats, ", "))
+	archive := fs.String("archive", "", "write raw data, summaries, and state to this .tar.zst, .tar.gz, or .tar file")
+	pdf := fs.String("pdf", "", "write a PDF report of the summaries to this file")
+	rangeFlag := fs.String("range", "", "dates to export, in place of the <range> argument")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the range, e.g. "devlog export 30d --site content".
+	rangeArg := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+	if *rangeFlag != "" {
+		rangeArg = *rangeFlag
+	}
+
+	modes := 0
+	for _, out := range []string{*site, *archive, *pdf} {
+		if out != "" {
+			modes++
+		}
+	}
+	if modes != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog export (--site <dir> [--format hugo|zola] | --archive <file> | --pdf <file>) [<range>]")
+		os.Exit(1)
+	}
+	if !containsString(siteFormats, *format) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (valid formats: %s)\n", *format, strings.Join(siteFormats, ", "))
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Without a range, export every date.
+	dates := rawDataDates(cfg)
+	if rangeArg != "" {
+		dates, err = parseDateRange(rangeArg, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *archive != "" {
+		n, err := createArchive(cfg, *archive, dates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported %d files to %s\n", n, *archive)
+		return
+	}
+	if *pdf != "" {
+		n, err := exportPDF(cfg, *pdf, dates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported %d days to %s\n", n, *pdf)
+		return
+	}
+
+	n, err := exportSite(cfg, *site, *format, dates)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d days to %s\n", n, *site)
+}
+
+func cmdImport() {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	force := fs.Bool("force", false, "overwrite files that already exist")
+	format := fs.String("format", "", "import a journal in this format (jrnl, dayone, or markdown) as notes")
+	proj := fs.String("p", "", "tag imported journal entries with these projects (comma-separated)")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the archive and range.
+	var args []string
+	for fs.NArg() > 0 {
+		args = append(args, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog import [--force] <archive> [<range>]")
+		fmt.Fprintln(os.Stderr, "       devlog import --format jrnl|dayone|markdown [-p <project>[,...]] <file> [<range>]")
+		os.Exit(1)
+	}
+	if *format != "" && !containsString(journalFormats, *format) {
+		fmt.Fprintf(os.Stderr, "Error: unknown journal format %q (valid formats: %s)\n", *format, strings.Join(journalFormats, ", "))
+		os.Exit(1)
+	}
+	var projects []string
+	if *proj != "" {
+		if *format == "" {
+			fmt.Fprintln(os.Stderr, "Error: -p only applies with --format")
+			os.Exit(1)
+		}
+		for _, p := range strings.Split(*proj, ",") {
+			if err := validateProjectName(p); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			projects = append(projects, p)
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Without a range, import every date in the archive.
+	var dates []string
+	if len(args) == 2 {
+		if dates, err = parseDateRange(args[1], time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *format != "" {
+		entries, err := readJournal(*format, args[0], time.Local)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		res, err := importJournal(cfg, entries, projects, dates)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %d entries from %s as notes\n", res.Imported, args[0])
+		if res.Skipped > 0 {
+			fmt.Printf("Skipped %d entries that were already imported\n", res.Skipped)
+		}
+		return
+	}
+
+	res, err := importArchive(cfg, args[0], dates, *force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %d files from %s\n", res.Imported, args[0])
+	if res.Skipped > 0 {
+		fmt.Printf("Skipped %d files that already exist (use --force to overwrite)\n", res.Skipped)
+	}
+}
+
+func cmdArchive() {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("archive", flag.ExitOnError)
+	days := fs.Int("days", cfg.ArchiveAfterDays, "archive raw data older than this many days (default: archive_after_days)")
+	fs.Parse(os.Args[2:])
+	if *days <= 0 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog archive [--days <n>]")
+		fmt.Fprintln(os.Stderr, "Set archive_after_days in config.toml or pass --days.")
+		os.Exit(1)
+	}
+
+	archived, err := archiveOldRaw(context.Background(), cfg, *days, time.Now())
+	if len(archived) > 0 {
+		fmt.Printf("Archived raw data of %d days to %s\n", len(archived), rawArchiveDir(cfg))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(archived) == 0 {
+		fmt.Println("No raw data to archive")
+	}
+}
+
+func cmdDB() {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: devlog db import [<range>]")
+		fmt.Fprintln(os.Stderr, "       devlog db query [--range <range>] [-p <project>] [--kind git|notes|term] [--full] [<text>]")
+		fmt.Fprintln(os.Stderr, "       devlog db prune [--days <n>]")
+		os.Exit(1)
+	}
+	if len(os.Args) < 3 {
+		usage()
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("db "+os.Args[2], flag.ExitOnError)
+	var rangeArg, proj, kind *string
+	var full *bool
+	var days *int
+	switch os.Args[2] {
+	case "import":
+	case "query":
+		rangeArg = fs.String("range", "", "only entries on these dates (e.g. 7d, 2024-01-01..2024-01-31)")
+		proj = fs.String("p", "", "only entries of this project (\"general\" for notes without one)")
+		kind = fs.String("kind", "", "only entries of this kind: git, notes, or term")
+		full = fs.Bool("full", false, "print whole entries instead of their first lines")
+	case "prune":
+		days = fs.Int("days", cfg.RawDBKeepDays, "delete entries older than this many days (default: raw_db_keep_days)")
+	default:
+		usage()
+	}
+	fs.Parse(os.Args[3:])
+	// Allow flags after the arguments.
+	var args []string
+	for fs.NArg() > 0 {
+		args = append(args, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+
+	db, err := openRawDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	switch os.Args[2] {
+	case "import":
+		if len(args) > 1 {
+			usage()
+		}
+		// Without a range, import every date with raw data.
+		dates := rawDataDates(cfg)
+		if len(args) == 1 {
+			if dates, err = parseDateRange(args[0], time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := restoreRawDates(cfg, dates); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		n, err := importRawDB(cfg, db, dates)
+		fmt.Printf("Imported %d files into %s\n", n, resolveRawDBPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "query":
+		if !containsString([]string{"", "git", "notes", "term"}, *kind) {
+			fmt.Fprintf(os.Stderr, "Error: unknown kind %q (want git, notes, or term)\n", *kind)
+			os.Exit(1)
+		}
+		q := rawDBQuery{Kind: *kind, Project: *proj, Text: strings.Join(args, " ")}
+		if *rangeArg != "" {
+			if q.Dates, err = parseDateRange(*rangeArg, time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		entries, err := queryRawDB(db, q)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			fmt.Println("No matching entries")
+			return
+		}
+		printRawDBEntries(os.Stdout, entries, *full)
+	case "prune":
+		if *days <= 0 || len(args) > 0 {
+			fmt.Fprintln(os.Stderr, "Usage: devlog db prune [--days <n>]")
+			fmt.Fprintln(os.Stderr, "Set raw_db_keep_days in config.toml or pass --days.")
+			os.Exit(1)
+		}
+		n, err := pruneRawDB(db, *days, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted %d entries older than %d days\n", n, *days)
+	}
+}
+
+func cmdTodo() {
+	fs := flag.NewFlagSet("todo", flag.ExitOnError)
+	proj := fs.String("p", "", "only show items for this project (\"general\" for items without one)")
+	all := fs.Bool("all", false, "include items that are done")
+	fs.Parse(os.Args[2:])
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	items, err := syncTodos(cfg, rawDataDates(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if fs.Arg(0) == "done" {
+		if fs.NArg() < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: devlog todo done <n>...")
+			os.Exit(1)
+		}
+		for _, arg := range fs.Args()[1:] {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 || n > len(items) {
+				fmt.Fprintf(os.Stderr, "Error: no todo item %s\n", arg)
+				os.Exit(1)
+			}
+			items[n-1].Done = true
+			fmt.Printf("Done: %s\n", items[n-1].Text)
+		}
+		if err := saveTodos(resolveTodoPath(cfg), items); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog todo [-p <project>] [--all] | devlog todo done <n>...")
+		os.Exit(1)
+	}
+
+	if printTodos(os.Stdout, items, strings.TrimPrefix(*proj, "#"), *all) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to do")
+	}
+}
+
+func cmdStats() {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print stats as JSON")
+	languages := fs.Bool("languages", false, "break down changed lines by language")
+	fs.Parse(os.Args[2:])
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	state, _ := loadState()
+
+	dates, err := parseDateRange(fs.Arg(0), time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := collectStats(cfg, state, dates)
+	if *languages {
+		addLanguageStats(cfg, dates, &report)
+	}
+
+	if *asJSON {
+		if err := printStatsJSON(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(report.Projects) == 0 {
+		if report.From == report.To {
+			fmt.Fprintf(os.Stderr, "No raw data for %s\n", report.From)
+		} else {
+			fmt.Fprintf(os.Stderr, "No raw data for %s to %s\n", report.From, report.To)
+		}
+		return
+	}
+	printStatsTable(os.Stdout, report)
+	if *languages {
+		fmt.Println()
+		printLanguageTable(os.Stdout, report)
+	}
+}
+
+func cmdHeatmap() {
+	fs := flag.NewFlagSet("heatmap", flag.ExitOnError)
+	proj := fs.String("p", "", "only show this project")
+	svg := fs.String("svg", "", "also write the heatmaps to this SVG file")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the year, e.g. "devlog heatmap 2024 -p foo".
+	yearArg := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state, _ := loadState()
+
+	year := time.Now().Year()
+	if yearArg != "" {
+		if year, err = strconv.Atoi(yearArg); err != nil || year < 1 || year > 9999 {
+			fmt.Fprintln(os.Stderr, "Error: invalid year, expected YYYY")
+			os.Exit(1)
+		}
+	}
+
+	maps := collectHeatmaps(cfg, state, year, *proj)
+	if len(maps[0].Counts) == 0 {
+		fmt.Fprintf(os.Stderr, "No raw data for %d\n", year)
+		return
+	}
+	for i, h := range maps {
+		if i > 0 {
+			fmt.Println()
+		}
+		printHeatmap(os.Stdout, h, year)
+	}
+	if *svg != "" {
+		f, err := os.Create(*svg)
+		if err == nil {
+			err = writeHeatmapSVG(f, maps, year)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: writing SVG: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nHeatmaps written to %s\n", *svg)
+	}
+}
+
+// cmdFocus reports how often work on a date switched between projects.
+func cmdFocus() {
+	fs := flag.NewFlagSet("focus", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the date, e.g. "devlog focus 2024-01-15 --json".
+	date := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if !isValidDate(date) {
+		fmt.Fprintln(os.Stderr, "Error: invalid date format, expected YYYY-MM-DD")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state, _ := loadState()
+
+	report := collectFocus(cfg, state, date)
+	if *asJSON {
+		if err := printFocusJSON(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(report.Blocks) == 0 {
+		fmt.Fprintf(os.Stderr, "No activity for %s\n", date)
+		return
+	}
+	printFocusTable(os.Stdout, report)
+}
+
+// cmdSession records the start or stop of an explicit work session on a
+// project.
+func cmdSession() {
+	sub := ""
+	if len(os.Args) > 2 {
+		sub = os.Args[2]
+	}
+	if sub != "start" && sub != "stop" {
+		fmt.Fprintln(os.Stderr, "Usage: devlog session start|stop [-p project] [-m reason]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("session", flag.ExitOnError)
+	proj := fs.String("p", "", "project name (default: project of the current directory)")
+	msg := fs.String("m", "", "reason for starting or stopping the session")
+	args, pick := takeBareProjectFlag(os.Args[3:])
+	fs.Parse(args)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if pick {
+		*proj = pickNoteProject(cfg)
+	}
+	projects, err := resolveNoteProjects(cfg, *proj)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(projects) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: a session is for one project; use -p")
+		os.Exit(1)
+	}
+	project := projects[0]
+
+	now := time.Now()
+	open, isOpen := openSession(cfg, project, now)
+	switch sub {
+	case "start":
+		if isOpen {
+			fmt.Fprintf(os.Stderr, "Error: a session for %s is already open since %s\n", project, open.Start.Format("15:04"))
+			os.Exit(1)
+		}
+	case "stop":
+		if !isOpen {
+			fmt.Fprintf(os.Stderr, "Error: no open session for %s\n", project)
+			os.Exit(1)
+		}
+	}
+	if err := appendSessionEvent(cfg, now, sub, project, *msg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if sub == "start" {
+		fmt.Printf("Session for %s started at %s\n", project, now.Format("15:04"))
+	} else {
+		fmt.Printf("Session for %s stopped at %s (%s)\n", project, now.Format("15:04"), formatSessionDuration(now.Sub(open.Start)))
+	}
+}
+
+func cmdTime() {
+	fs := flag.NewFlagSet("time", flag.ExitOnError)
+	idle := fs.Int("idle", int(defaultIdleGap.Minutes()), "longest pause in minutes that still counts as work")
+	proj := fs.String("p", "", "only show this project")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(os.Args[2:])
+	// Allow flags after the range, e.g. "devlog time 7d -p foo".
+	rangeArg := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+	if *idle < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --idle must not be negative")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	state, _ := loadState()
+
+	dates, err := parseDateRange(rangeArg, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := collectTime(cfg, state, dates, time.Duration(*idle)*time.Minute)
+	if project := strings.TrimPrefix(*proj, "#"); project != "" {
+		var entries []TimeEntry
+		for _, e := range report.Entries {
+			if e.Project == project {
+				entries = append(entries, e)
+			}
+		}
+		report.Entries = append([]TimeEntry{}, entries...)
+	}
+
+	if *asJSON {
+		if err := printTimeJSON(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(report.Entries) == 0 {
+		if report.From == report.To {
+			fmt.Fprintf(os.Stderr, "No activity for %s\n", report.From)
+		} else {
+			fmt.Fprintf(os.Stderr, "No activity for %s to %s\n", report.From, report.To)
+		}
+		return
+	}
+	printTimeTable(os.Stdout, report)
+}
+
+func cmdInvoice() {
+	fs := flag.NewFlagSet("invoice", flag.ExitOnError)
+	month := fs.String("month", time.Now().Format("2006-01"), "month to bill (YYYY-MM)")
+	proj := fs.String("project", "", "projects to bill, comma-separated")
+	client := fs.String("client", "", "bill every watched project of this client")
+	format := fs.String("format", "md", "output format: md or csv")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	idle := fs.Int("idle", int(defaultIdleGap.Minutes()), "longest pause in minutes that still counts as work")
+	fs.Parse(os.Args[2:])
+
+	if *format != "md" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (valid formats: md, csv)\n", *format)
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state, _ := loadState()
+
+	var named []string
+	for _, p := range strings.Split(*proj, ",") {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			named = append(named, p)
+		}
+	}
+	projects := invoiceProjects(state, named, *client)
+	if len(projects) == 0 {
+		if *client != "" {
+			fmt.Fprintf(os.Stderr, "Error: no watched projects have client %q (set it with devlog watch --client)\n", *client)
+		} else {
+			fmt.Fprintln(os.Stderr, "Usage: devlog invoice [--month YYYY-MM] (--project <name>[,<name>...] | --client <client>) [--format md|csv] [-o <file>]")
+		}
+		os.Exit(1)
+	}
+
+	inv, err := collectInvoice(cfg, state, *month, projects, time.Duration(*idle)*time.Minute)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	inv.Title = *client
+	if inv.Title == "" {
+		inv.Title = strings.Join(projects, ", ")
+	}
+	if len(inv.Lines) == 0 {
+		fmt.Fprintf(os.Stderr, "No activity for %s in %s\n", inv.Title, *month)
+		return
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	write := writeInvoiceMarkdown
+	if *format == "csv" {
+		write = writeInvoiceCSV
+	}
+	if err := write(w, inv); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *output != "" {
+		fmt.Printf("Wrote %s hours for %s to %s\n", formatHours(inv.totalMinutes()), inv.Title, *output)
+	}
+}
+
+// stringsFlag is a flag that can be given several times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string     { return strings.Join(*f, ", ") }
+func (f *stringsFlag) Set(s string) error { *f = append(*f, s); return nil }
+
+func cmdWatch() {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	name := fs.String("name", "", "override project name")
+	plain := fs.Bool("plain", false, "watch a directory that is not a git repo")
+	subdir := fs.String("subdir", "", "watch only this subdirectory of the repo (relative to its root)")
+	list := fs.Bool("list", false, "list watched repos")
+	fromFile := fs.String("from-file", "", "watch every repo listed in a file")
+	scan := fs.String("scan", "", "find git repos under a directory and choose which to watch")
+	prune := fs.Bool("prune", false, "choose stale repos to stop watching")
+	staleDays := fs.Int("days", 0, "with --prune, days without a snapshot after which a repo is stale (default: stale_watch_days)")
+	client := fs.String("client", "", "client the work is billed to (also updates an already watched repo)")
+	billingCode := fs.String("billing-code", "", "billing code for invoices (also updates an already watched repo)")
+	var settings stringsFlag
+	fs.Var(&settings, "set", "set metadata key=value (tags, client, billing_code, default_branch, vcs, interval, exclude_gen); repeatable, also updates an already watched repo")
+	fs.Parse(os.Args[2:])
+	set, err := parseWatchSettings(settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *list {
+		state, err := loadState()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printWatchedState(state)
+		return
+	}
+	if *fromFile != "" {
+		if *name != "" || *subdir != "" {
+			fmt.Fprintln(os.Stderr, "Error: --name and --subdir cannot be used with --from-file")
+			os.Exit(1)
+		}
+		watchFromFile(*fromFile, *plain)
+		return
+	}
+	if *scan != "" {
+		if *name != "" || *subdir != "" || *plain || fs.NArg() > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --scan cannot be used with a path, --name, --subdir, or --plain")
+			os.Exit(1)
+		}
+		watchScan(*scan)
+		return
+	}
+	if *prune {
+		if *name != "" || *subdir != "" || *plain || fs.NArg() > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --prune cannot be used with a path, --name, --subdir, or --plain")
+			os.Exit(1)
+		}
+		watchPrune(*staleDays)
+		return
+	}
+
+	var repoPath string
+	if fs.NArg() > 0 {
+		repoPath = fs.Arg(0)
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		repoPath = cwd
+	}
+
+	entry, err := resolveWatchEntry(WatchArgs{Path: repoPath, Plain: *plain, Subdir: *subdir})
+	if err != nil {
+		if *plain || *subdir != "" {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else {
+			fmt.Fprintln(os.Stderr, "Error: not in a git repository (use --plain to watch a plain directory)")
+		}
+		os.Exit(1)
+	}
+
+	entry.Client, entry.BillingCode = *client, *billingCode
+	args, _ := json.Marshal(WatchArgs{Path: entry.repoRoot(), Name: *name, Plain: entry.Plain, Subdir: entry.Subdir,
+		Client: *client, BillingCode: *billingCode, Set: set})
+	resp, err := ipcSend(IPCRequest{Command: "watch", Args: json.RawMessage(args)})
+	if err != nil {
+		if isServerNotRunning(err) {
+			watchOffline(entry, *name, set)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	printWatchedList(resp.Data)
+}
+
+func watchOffline(entry WatchEntry, nameOverride string, set map[string]string) {
+	repoRoot := entry.Path
+	state, err := loadState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	projectName := nameOverride
+	if projectName == "" {
+		projectName = filepath.Base(repoRoot)
+	}
+
+	// Check if already watched
+	for _, w := range state.Watched {
+		if w.Path == repoRoot {
+			billed := setBilling(state.Watched, repoRoot, entry.Client, entry.BillingCode)
+			updated, err := updateWatched(state.Watched, repoRoot, set)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if billed || updated {
+				if err := saveState(state); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Updated %s (%s)\n", w.Name, w.Path)
+			} else {
+				fmt.Printf("Already watching %s (%s)\n", w.Name, w.Path)
+			}
+			printWatchedState(state)
+			fmt.Println("(server is not running; snapshot collection will begin when it starts)")
+			return
+		}
+	}
+
+	entry.Name = projectName
+	if _, err := applyWatchSettings(&entry, set); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state.Watched, _, err = addWatched(state.Watched, entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := saveState(state); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printWatchedState(state)
+	fmt.Println("(server is not running; snapshot collection will begin when it starts)")
+}
+
+func cmdUnwatch() {
+	fs := flag.NewFlagSet("unwatch", flag.ExitOnError)
+	all := fs.Bool("all", false, "stop watching every repo")
+	fromFile := fs.String("from-file", "", "stop watching every repo listed in a file")
+	fs.Parse(os.Args[2:])
+
+	if *all || *fromFile != "" {
+		unwatchBatch(*fromFile, *all)
+		return
+	}
+
+	var repoPath string
+	if fs.NArg() > 0 {
+		repoPath = fs.Arg(0)
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		repoPath = cwd
+	}
+
+	state, _ := loadState()
+	repoRoot, err := resolveUnwatchPath(repoPath, state.Watched)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: not in a git repository or watched directory")
+		os.Exit(1)
+	}
+
+	args, _ := json.Marshal(UnwatchArgs{Path: repoRoot})
+	resp, err := ipcSend(IPCRequest{Command: "unwatch", Args: json.RawMessage(args)})
+	if err != nil {
+		if isServerNotRunning(err) {
+			unwatchOffline(repoRoot)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	printWatchedList(resp.Data)
+}
+
+func unwatchOffline(repoRoot string) {
+	state, err := loadState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	newWatched, found := removeWatched(state.Watched, repoRoot)
+	if !found {
+		fmt.Printf("Not watching %s\n", repoRoot)
+		printWatchedState(state)
+		return
+	}
+
+	state.Watched = newWatched
+	if err := saveState(state); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printWatchedState(state)
+}
+
+// watchFromFile watches every repo listed in file (see watchRepoList).
+func watchFromFile(file string, plain bool) {
+	list, err := readRepoList(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	watchRepoList(list, plain)
+}
+
+// watchScan lists the git repos under dir that are not watched yet, most
+// recently active first, asks which to watch, and watches them with
+// watchRepoList. Repos active within scanRecent are selected by default.
+func watchScan(dir string) {
+	if strings.HasPrefix(dir, "~/") {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, dir[2:])
+	}
+	state, err := loadState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	found, err := scanRepos(dir, state.Watched)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var repos []scannedRepo
+	watched := 0
+	for _, r := range found {
+		if r.Watched {
+			watched++
+		} else {
+			repos = append(repos, r)
+		}
+	}
+	if len(repos) == 0 {
+		fmt.Printf("No unwatched git repos under %s (%d already watched)\n", dir, watched)
+		return
+	}
+
+	now := time.Now()
+	var defaults []int
+	fmt.Printf("Git repos under %s:\n", dir)
+	for i, r := range repos {
+		mark := " "
+		if !r.LastActive.IsZero() && now.Sub(r.LastActive) < scanRecent {
+			mark = "*"
+			defaults = append(defaults, i)
+		}
+		fmt.Printf("  %s %3d  %-40s %s\n", mark, i+1, r.Path, formatAge(r.LastActive, now))
+	}
+	if watched > 0 {
+		fmt.Printf("(%d already watched, not listed)\n", watched)
+	}
+
+	fmt.Print("Watch which repos? Numbers or ranges (e.g. 1,3-5), all, or none [recently active, marked *]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	selected, err := parseRepoSelection(answer, len(repos), defaults)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(selected) == 0 {
+		fmt.Println("No repos selected")
+		return
+	}
+	list := make([]repoListEntry, len(selected))
+	for i, idx := range selected {
+		list[i] = repoListEntry{Path: repos[idx].Path}
+	}
+	watchRepoList(list, false)
+}
+
+// watchRepoList watches every repo of list with a single watch_batch
+// command, or a single state.json update when the server is not running.
+// Repos that cannot be watched are reported and make the command exit 1.
+func watchRepoList(list []repoListEntry, plain bool) {
+	var errs []BatchError
+	var batch WatchBatchArgs
+	var entries []WatchEntry
+	for _, item := range list {
+		entry, err := resolveWatchEntry(WatchArgs{Path: item.Path, Plain: plain})
+		if err != nil {
+			errs = append(errs, BatchError{Path: item.Path, Error: err.Error()})
+			continue
+		}
+		entry.Name = item.Name
+		if entry.Name == "" {
+			entry.Name = filepath.Base(entry.Path)
+		}
+		entries = append(entries, entry)
+		batch.Repos = append(batch.Repos, WatchArgs{Path: entry.Path, Name: entry.Name, Plain: entry.Plain})
+	}
+
+	args, _ := json.Marshal(batch)
+	resp, err := ipcSend(IPCRequest{Command: "watch_batch", Args: json.RawMessage(args)})
+	if err != nil {
+		if !isServerNotRunning(err) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		state, err := loadState()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, entry := range entries {
+			if state.Watched, _, err = addWatched(state.Watched, entry); err != nil {
+				errs = append(errs, BatchError{Path: entry.Path, Error: err.Error()})
+			}
+		}
+		if err := saveState(state); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printWatchedState(state)
+		fmt.Println("(server is not running; snapshot collection will begin when it starts)")
+		exitOnBatchErrors(errs)
+		return
+	}
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	printWatchedList(resp.Data)
+	var wd WatchResponseData
+	json.Unmarshal(resp.Data, &wd)
+	exitOnBatchErrors(append(errs, wd.Errors...))
+}
+
+// unwatchBatch stops watching every repo (all) or every repo listed in file
+// with a single unwatch_batch command or state.json update.
+func unwatchBatch(file string, all bool) {
+	state, err := loadState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var errs []BatchError
+	batch := UnwatchBatchArgs{All: all}
+	if !all {
+		list, err := readRepoList(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, item := range list {
+			repoRoot, err := resolveUnwatchPath(item.Path, state.Watched)
+			if err != nil {
+				errs = append(errs, BatchError{Path: item.Path, Error: err.Error()})
+				continue
+			}
+			batch.Paths = append(batch.Paths, repoRoot)
+		}
+	}
+	sendUnwatchBatch(state, batch, errs)
+}
+
+// watchPrune lists the watched entries with no snapshot in the last days
+// days (stale_watch_days if days is 0), asks which to stop watching, and
+// unwatches them with sendUnwatchBatch.
+func watchPrune(days int) {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if days == 0 {
+		days = cfg.StaleWatchDays
+	}
+	if days <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: no staleness threshold; pass --days or set stale_watch_days")
+		os.Exit(1)
+	}
+	state, err := loadState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	stale := staleWatched(cfg, state.Watched, days, time.Now())
+	if len(stale) == 0 {
+		fmt.Printf("No watched repos without snapshots in the last %d days\n", days)
+		return
+	}
+	fmt.Printf("Watched repos without snapshots in the last %d days:\n", days)
+	for i, r := range stale {
+		fmt.Printf("  %3d  %s\n", i+1, formatStaleRepo(r))
+	}
+	fmt.Print("Stop watching which repos? Numbers or ranges (e.g. 1,3-5), all, or none [none]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	selected, err := parseRepoSelection(answer, len(stale), nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(selected) == 0 {
+		fmt.Println("No repos selected")
+		return
+	}
+	var batch UnwatchBatchArgs
+	for _, idx := range selected {
+		batch.Paths = append(batch.Paths, stale[idx].Path)
+	}
+	sendUnwatchBatch(state, batch, nil)
+}
+
+// sendUnwatchBatch sends batch as one unwatch_batch command, or applies it
+// to state in one state.json update when the server is not running. errs
+// are the repos already found not to be watched; they are reported with
+// those the server could not unwatch, and make the command exit 1.
+func sendUnwatchBatch(state State, batch UnwatchBatchArgs, errs []BatchError) {
+	args, _ := json.Marshal(batch)
+	resp, err := ipcSend(IPCRequest{Command: "unwatch_batch", Args: json.RawMessage(args)})
+	if err != nil {
+		if !isServerNotRunning(err) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if batch.All {
+			state.Watched = nil
+		}
+		for _, p := range batch.Paths {
+			state.Watched, _ = removeWatched(state.Watched, p)
+		}
+		if err := saveState(state); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printWatchedState(state)
+		exitOnBatchErrors(errs)
+		return
+	}
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	printWatchedList(resp.Data)
+	var wd WatchResponseData
+	json.Unmarshal(resp.Data, &wd)
+	exitOnBatchErrors(append(errs, wd.Errors...))
+}
+
+func exitOnBatchErrors(errs []BatchError) {
+	if len(errs) == 0 {
+		return
+	}
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "Error: %s: %s\n", e.Path, e.Error)
+	}
+	os.Exit(1)
+}
+
+// repoListEntry is one line of a --from-file repo list.
+type repoListEntry struct {
+	Path string
+	Name string
+}
+
+// readRepoList reads a repo list file: one path per line, optionally followed
+// by whitespace and a project name. Blank lines and lines starting with # are
+// ignored. A leading ~/ is expanded, and relative paths are resolved against
+// the directory containing the file.
+func readRepoList(file string) ([]repoListEntry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading repo list: %w", err)
+	}
+
+	var list []repoListEntry
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: expected a path and an optional name", file, i+1)
+		}
+		item := repoListEntry{Path: fields[0]}
+		if len(fields) == 2 {
+			item.Name = fields[1]
+		}
+		if strings.HasPrefix(item.Path, "~/") {
+			home, _ := os.UserHomeDir()
+			item.Path = filepath.Join(home, item.Path[2:])
+		} else if !filepath.IsAbs(item.Path) {
+			item.Path = filepath.Join(filepath.Dir(file), item.Path)
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+func cmdInstallHooks() {
+	fs := flag.NewFlagSet("install-hooks", flag.ExitOnError)
+	proj := fs.String("p", "", "project name for the notes (default: the watched name or repo basename)")
+	checkout := fs.Bool("checkout", false, "also log branch switches with a post-checkout hook")
+	fs.Parse(os.Args[2:])
+
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	repoRoot, err := resolveRepoRoot(absDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	project := strings.TrimPrefix(*proj, "#")
+	if project == "" {
+		state, _ := loadState()
+		if w, ok := containingEntry(absDir, state.Watched); ok {
+			project = w.Name
+		} else {
+			project = projectNameForRepo(repoRoot, state, "")
+		}
+	}
+	if err := validateProjectName(project); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	hooksDir, err := resolveHooksDir(repoRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	hooks := []string{"post-commit"}
+	if *checkout {
+		hooks = append(hooks, "post-checkout")
+	}
+	devlog := devlogCommand()
+	for _, hook := range hooks {
+		if err := installHook(hooksDir, hook, hookScript(hook, devlog, project)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Installed %s hook in %s (project %s)\n", hook, hooksDir, project)
+	}
+}
+
+func cmdRename() {
+	fs := flag.NewFlagSet("rename", flag.ExitOnError)
+	fs.Parse(os.Args[2:])
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: devlog rename <old> <new>")
+		os.Exit(1)
+	}
+	oldName, newName := fs.Arg(0), fs.Arg(1)
+	if err := validateProjectName(newName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if oldName == newName {
+		fmt.Println("Nothing to rename")
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	state, err := loadState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Check the data migration for conflicts before changing anything.
+	plan, err := planProjectRename(cfg, state, oldName, newName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot rename %s to %s: %v\n", oldName, newName, err)
+		os.Exit(1)
+	}
+
+	args, _ := json.Marshal(RenameArgs{OldName: oldName, NewName: newName})
+	resp, err := ipcSend(IPCRequest{Command: "rename", Args: json.RawMessage(args)})
+	if err != nil {
+		if !isServerNotRunning(err) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := renameWatchEntry(state.Watched, oldName, newName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := saveState(state); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	retagged, err := plan.apply()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Renamed %s to %s: %d files renamed, %d notes retagged across %d dates\n",
+		oldName, newName, len(plan.moves), retagged, len(plan.dates))
+}
+
+func cmdStart() {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	s := newServer(cfg)
+	if err := s.run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func cmdStop() {
+	resp, err := ipcSend(IPCRequest{Command: "stop"})
+	if err != nil {
+		if isServerNotRunning(err) {
+			fmt.Println("devlog server is not running")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	// Wait for server to exit (check PID file removal), which can take
+	// until in-flight work finishes and the final snapshot is taken.
+	deadline := time.Now().Add(shutdownTimeout + 5*time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(pidFilePath()); os.IsNotExist(err) {
+			fmt.Println("devlog server stopped.")
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	fmt.Println("devlog server stopped.")
+}
+
+func cmdStatus() {
+	resp, err := ipcSend(IPCRequest{Command: "status"})
+	if err != nil {
+		if isServerNotRunning(err) {
+			fmt.Println("devlog server is not running")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	var status StatusData
+	if err := json.Unmarshal(resp.Data, &status); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: parsing status: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("devlog server running (PID %d)\n", status.PID)
+	if status.LogPath != "" {
+		fmt.Printf("Logging to %s\n", status.LogPath)
+	}
+	if status.OnBattery {
+		fmt.Println("On battery power")
+	}
+	if len(status.Watched) == 0 {
+		fmt.Println("No repos being watched")
+	} else {
+		lastErrors := make(map[string]string)
+		for _, rs := range status.Repos {
+			lastErrors[rs.Path] = rs.LastError
+		}
+		fmt.Println("Watched repos:")
+		for _, w := range status.Watched {
+			fmt.Printf("  %s\n", formatWatchEntry(w))
+			if e := lastErrors[w.Path]; e != "" {
+				fmt.Printf("    last snapshot failed: %s\n", e)
+			}
+		}
+	}
+	if len(status.Unwatched) > 0 {
+		now := time.Now()
+		fmt.Println("Unwatched repos with recent Claude Code sessions:")
+		for _, r := range status.Unwatched {
+			fmt.Printf("  %s (last session %s)\n", r.Path, formatAge(r.LastSession, now))
+		}
+		fmt.Println("Watch them with `devlog watch <path>`, or set auto_watch_claude = true to watch them automatically.")
+	}
+	if len(status.Stale) > 0 {
+		fmt.Println("Stale repos, with no snapshots in stale_watch_days:")
+		for _, r := range status.Stale {
+			fmt.Printf("  %s\n", formatStaleRepo(r))
+		}
+		fmt.Println("Unwatch them with `devlog watch --prune`.")
+	}
+}
+
+func cmdJobs() {
+	jobs, err := listJobs()
+	if err != nil {
+		if isServerNotRunning(err) {
+			fmt.Println("devlog server is not running")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No generation jobs")
+		return
+	}
+	printJobs(os.Stdout, jobs, time.Now())
+}
+
+// formatStaleRepo formats a stale watched entry for listing.
+func formatStaleRepo(r StaleRepo) string {
+	last := "no snapshots"
+	if r.LastActive != "" {
+		last = "last active " + r.LastActive
+	}
+	return fmt.Sprintf("%s (%s, %s)", r.Name, r.Path, last)
+}
+
+func cmdReload() {
+	resp, err := ipcSend(IPCRequest{Command: "reload"})
+	if err != nil {
+		if isServerNotRunning(err) {
+			fmt.Println("devlog server is not running")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !resp.OK {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", resp.Error)
+		os.Exit(1)
+	}
+
+	fmt.Println("Configuration reloaded.")
+}
+
+// cmdConfig checks the config file for problems or prints the effective
+// config.
+func cmdConfig() {
+	sub := ""
+	if len(os.Args) > 2 {
+		sub = os.Args[2]
+	}
+	switch sub {
+	case "check":
+		path := configFilePath()
+		problems := checkConfig()
+		if len(problems) == 0 {
+			fmt.Printf("%s: OK\n", path)
+			return
+		}
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, p)
+		}
+		os.Exit(1)
+	case "show":
+		cfg, err := loadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := writeEffectiveConfig(os.Stdout, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: devlog config check | devlog config show")
+		os.Exit(1)
+	}
+}
+
+// cmdHealth exits 0 if the server is running and healthy, 1 if it is running
+// but unhealthy, and 2 if it is not running or cannot be reached.
+func cmdHealth() {
+	resp, err := ipcSend(IPCRequest{Command: "ping"})
+	if err != nil {
+		if isServerNotRunning(err) {
+			fmt.Println("unhealthy: devlog server is not running")
+		} else {
+			fmt.Printf("unhealthy: %v\n", err)
+		}
+		os.Exit(2)
+	}
+	if !resp.OK {
+		fmt.Printf("unhealthy: %s\n", resp.Error)
+		os.Exit(2)
+	}
+
+	var ping PingData
+	if err := json.Unmarshal(resp.Data, &ping); err != nil {
+		fmt.Printf("unhealthy: parsing ping response: %v\n", err)
+		os.Exit(2)
+	}
+
+	problems := evaluateHealth(ping, time.Now())
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Printf("unhealthy: %s\n", p)
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("healthy (PID %d, %d repos)\n", ping.PID, len(ping.Repos))
+}
+
+func cmdStatusbar() {
+	fs := flag.NewFlagSet("statusbar", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print waybar custom module JSON")
+	interval := fs.Int("interval", 0, "keep running, printing a new status every N seconds")
+	fs.Parse(os.Args[2:])
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for {
+		now := time.Now()
+		st := collectBarStatus(cfg, now)
+		if *asJSON {
+			fmt.Println(string(st.waybarJSON(now)))
+		} else {
+			fmt.Println(st.text(now))
+		}
+		if *interval <= 0 {
+			return
+		}
+		time.Sleep(time.Duration(*interval) * time.Second)
+	}
+}
+
+func cmdWeb() {
+	fs := flag.NewFlagSet("web", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8377", "address to listen on")
+	allowGen := fs.Bool("allow-gen", false, "allow regenerating summaries from the browser")
+	fs.Parse(os.Args[2:])
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !isLoopbackAddr(*addr) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is not a loopback address; anyone on the network with the link can read your logs\n", *addr)
+	}
+
+	ui, err := newWebUI(cfg, *addr, *allowGen, newLogger(cfg, os.Stderr, slog.LevelInfo))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// The token in the link is kept in a cookie; other requests are refused.
+	fmt.Printf("Serving devlog on http://%s/?token=%s\n", *addr, ui.token)
+	if err := http.ListenAndServe(*addr, ui.routes()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func cmdMCP() {
+	fs := flag.NewFlagSet("mcp", flag.ExitOnError)
+	fs.Parse(os.Args[2:])
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	m := &mcpServer{cfg: cfg, now: time.Now}
+	if err := m.serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// evaluateHealth returns a description of each failed health check: the
+// snapshot loop must have completed a cycle within twice the snapshot
+// interval, and the last snapshot of every repo must have succeeded.
+func evaluateHealth(ping PingData, now time.Time) []string {
+	var problems []string
+
+	maxAge := 2 * time.Duration(ping.SnapshotInterval) * time.Second
+	if ping.LastTickAt == nil {
+		problems = append(problems, "snapshot loop has not completed a cycle yet")
+	} else if age := now.Sub(*ping.LastTickAt); age > maxAge {
+		problems = append(problems, fmt.Sprintf("snapshot loop last ran %s ago (limit %s)",
+			age.Round(time.Second), maxAge))
+	}
+
+	for _, rs := range ping.Repos {
+		if rs.LastError != "" {
+			problems = append(problems, fmt.Sprintf("last snapshot of %s failed: %s", rs.Name, rs.LastError))
+		}
+	}
+	return problems
+}
+
+func printWatchedList(data json.RawMessage) {
+	var wd WatchResponseData
+	if err := json.Unmarshal(data, &wd); err != nil {
+		return
+	}
+
+	if len(wd.Watched) == 0 {
+		fmt.Println("No repos being watched")
+	} else {
+		fmt.Println("Watched repos:")
+		for _, w := range wd.Watched {
+			fmt.Printf("  %s\n", formatWatchEntry(w))
+		}
+	}
+}
+
+func printWatchedState(state State) {
+	if len(state.Watched) == 0 {
+		fmt.Println("No repos being watched")
+	} else {
+		fmt.Println("Watched repos:")
+		for _, w := range state.Watched {
+			fmt.Printf("  %s\n", formatWatchEntry(w))
+		}
+	}
+}
+
+func formatWatchEntry(w WatchEntry) string {
+	details := []string{w.Path}
+	switch {
+	case w.Plain:
+		details = append(details, "plain")
+	case w.Subdir != "":
+		details = append(details, "subdir of "+w.repoRoot())
+	}
+	if w.Client != "" {
+		details = append(details, "client "+w.Client)
+	}
+	if w.BillingCode != "" {
+		details = append(details, "billing code "+w.BillingCode)
+	}
+	if len(w.Tags) > 0 {
+		details = append(details, "tags "+strings.Join(w.Tags, " "))
+	}
+	if w.DefaultBranch != "" {
+		details = append(details, "default branch "+w.DefaultBranch)
+	}
+	if w.Interval > 0 {
+		details = append(details, fmt.Sprintf("every %ds", w.Interval))
+	}
+	if w.ExcludeGen {
+		details = append(details, "excluded from gen")
+	}
+	return fmt.Sprintf("%s (%s)", w.Name, strings.Join(details, ", "))
+}
@@ -0,0 +1,368 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteNote(t *testing.T) {
+	notesFile := filepath.Join(t.TempDir(), "2024-01-15", "notes.md")
+
+	err := writeNote(nil, notesFile, time.Now(), "Testing the note command", "myproject")
+	if err != nil {
+		t.Fatalf("writeNote: %v", err)
+	}
+
+	content, err := os.ReadFile(notesFile)
+	if err != nil {
+		t.Fatalf("reading notes: %v", err)
+	}
+
+	s := string(content)
+	if !strings.Contains(s, "#myproject") {
+		t.Error("missing project hashtag in header")
+	}
+	if !strings.Contains(s, "Testing the note command") {
+		t.Error("missing note text")
+	}
+	if !strings.HasSuffix(s, "\n\n") {
+		t.Error("note should end with blank line")
+	}
+}
+
+func TestWriteNoteMultiple(t *testing.T) {
+	notesFile := filepath.Join(t.TempDir(), "2024-01-15", "notes.md")
+
+	writeNote(nil, notesFile, time.Now(), "First note", "myproject")
+	writeNote(nil, notesFile, time.Now(), "Second note", "myproject")
+
+	content, _ := os.ReadFile(notesFile)
+
+	count := strings.Count(string(content), "### At")
+	if count != 2 {
+		t.Errorf("expected 2 notes, got %d", count)
+	}
+}
+
+func TestWriteNoteNoProject(t *testing.T) {
+	notesFile := filepath.Join(t.TempDir(), "2024-01-15", "notes.md")
+
+	err := writeNote(nil, notesFile, time.Now(), "A general note", "")
+	if err != nil {
+		t.Fatalf("writeNote: %v", err)
+	}
+
+	content, err := os.ReadFile(notesFile)
+	if err != nil {
+		t.Fatalf("reading notes: %v", err)
+	}
+
+	s := string(content)
+	if !strings.Contains(s, "### At") {
+		t.Error("missing note header")
+	}
+	if strings.Contains(s, "#") && !strings.Contains(s, "### ") {
+		t.Error("note without project should not contain a hashtag")
+	}
+	if strings.Count(s, "#") != 3 { // only the ### heading
+		t.Errorf("expected only ### in header, got content: %q", s)
+	}
+}
+
+func TestWriteNoteMultipleProjects(t *testing.T) {
+	notesFile := filepath.Join(t.TempDir(), "2024-01-15", "notes.md")
+
+	if err := writeNote(nil, notesFile, time.Now(), "Shared library change", "alpha", "beta"); err != nil {
+		t.Fatalf("writeNote: %v", err)
+	}
+
+	content, _ := os.ReadFile(notesFile)
+	tags, ok := noteHeadingTags(nil, strings.SplitN(string(content), "\n", 2)[0])
+	if !ok || len(tags) != 2 || tags[0] != "alpha" || tags[1] != "beta" {
+		t.Errorf("expected heading tagged #alpha #beta, got %q", content)
+	}
+}
+
+func TestWriteNoteBackdated(t *testing.T) {
+	notesFile := filepath.Join(t.TempDir(), "2024-01-15", "notes.md")
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+
+	writeNote(nil, notesFile, day.Add(9*time.Hour), "Morning", "p")
+	writeNote(nil, notesFile, day.Add(16*time.Hour), "Afternoon", "p")
+	writeNote(nil, notesFile, day.Add(14*time.Hour+30*time.Minute), "Forgotten", "p")
+	writeNote(nil, notesFile, day.Add(17*time.Hour), "Evening", "p")
+
+	content, _ := os.ReadFile(notesFile)
+	want := "### At 09:00 #p\nMorning\n\n" +
+		"### At 14:30 #p\nForgotten\n\n" +
+		"### At 16:00 #p\nAfternoon\n\n" +
+		"### At 17:00 #p\nEvening\n\n"
+	if string(content) != want {
+		t.Errorf("notes not in chronological order:\n%s", content)
+	}
+}
+
+func TestNoteTime(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 5, 0, 0, time.Local)
+
+	got, err := noteTime(now, "2024-01-14", "14:30")
+	if err != nil || !got.Equal(time.Date(2024, 1, 14, 14, 30, 0, 0, time.Local)) {
+		t.Errorf("noteTime = %v, %v", got, err)
+	}
+	got, _ = noteTime(now, "", "")
+	if !got.Equal(now) {
+		t.Errorf("expected now, got %v", got)
+	}
+	if _, err := noteTime(now, "", "2:30pm"); err == nil {
+		t.Error("expected error for invalid time")
+	}
+	if _, err := noteTime(now, "yesterday", ""); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
+
+func TestProjectNameFromState(t *testing.T) {
+	state := State{
+		Watched: []WatchEntry{
+			{Path: "/home/user/dev/foo", Name: "custom-foo"},
+		},
+	}
+
+	// When repo is in state, use state name
+	name := projectNameForRepo("/home/user/dev/foo", state, "")
+	if name != "custom-foo" {
+		t.Errorf("expected custom-foo, got %q", name)
+	}
+
+	// When repo is not in state, use basename
+	name = projectNameForRepo("/home/user/dev/bar", state, "")
+	if name != "bar" {
+		t.Errorf("expected bar, got %q", name)
+	}
+}
+
+func TestWatchOffline(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	// Watch a repo offline
+	watchOffline(WatchEntry{Path: "/home/user/dev/foo"}, "", nil)
+	state, err := loadState()
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if len(state.Watched) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(state.Watched))
+	}
+	if state.Watched[0].Path != "/home/user/dev/foo" || state.Watched[0].Name != "foo" {
+		t.Errorf("unexpected entry: %+v", state.Watched[0])
+	}
+
+	// Watch a second repo with a name override
+	watchOffline(WatchEntry{Path: "/home/user/dev/bar"}, "custom-bar", nil)
+	state, _ = loadState()
+	if len(state.Watched) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(state.Watched))
+	}
+	if state.Watched[1].Name != "custom-bar" {
+		t.Errorf("expected custom-bar, got %q", state.Watched[1].Name)
+	}
+
+	// Watching the same repo again should not add a duplicate
+	watchOffline(WatchEntry{Path: "/home/user/dev/foo"}, "", nil)
+	state, _ = loadState()
+	if len(state.Watched) != 2 {
+		t.Errorf("expected 2 entries (no duplicate), got %d", len(state.Watched))
+	}
+}
+
+func TestWatchOfflineNameCollision(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	// Set up an existing watched repo
+	saveState(State{Watched: []WatchEntry{{Path: "/home/user/dev/foo", Name: "foo"}}})
+
+	// Trying to watch a different repo with the same name should fail.
+	// watchOffline calls os.Exit(1) on collision, so we can't test it
+	// directly in-process. Instead, test the collision logic extracted
+	// to a helper.
+	state, _ := loadState()
+	projectName := "foo"
+	hasCollision := false
+	for _, w := range state.Watched {
+		if w.Path != "/home/user/dev/other" && w.Name == projectName {
+			hasCollision = true
+			break
+		}
+	}
+	if !hasCollision {
+		t.Error("expected name collision to be detected")
+	}
+}
+
+func TestUnwatchOffline(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	// Set up watched repos
+	saveState(State{Watched: []WatchEntry{
+		{Path: "/home/user/dev/foo", Name: "foo"},
+		{Path: "/home/user/dev/bar", Name: "bar"},
+	}})
+
+	// Unwatch one
+	unwatchOffline("/home/user/dev/foo")
+	state, _ := loadState()
+	if len(state.Watched) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(state.Watched))
+	}
+	if state.Watched[0].Path != "/home/user/dev/bar" {
+		t.Errorf("wrong entry remaining: %+v", state.Watched[0])
+	}
+}
+
+func TestUnwatchOfflineNotWatched(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	// Unwatch from empty state — should not error
+	unwatchOffline("/home/user/dev/foo")
+	state, _ := loadState()
+	if len(state.Watched) != 0 {
+		t.Errorf("expected empty watched list, got %d", len(state.Watched))
+	}
+}
+
+func TestIsValidDate(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"2024-01-15", true},
+		{"2024-12-31", true},
+		{"2024-1-15", false},
+		{"01-15-2024", false},
+		{"not-a-date", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got := isValidDate(tt.input)
+		if got != tt.valid {
+			t.Errorf("isValidDate(%q) = %v, want %v", tt.input, got, tt.valid)
+		}
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		input string
+		want  []string
+		ok    bool
+	}{
+		{"", []string{"2024-01-15"}, true},
+		{"2024-01-10", []string{"2024-01-10"}, true},
+		{"2024-01-10..2024-01-12", []string{"2024-01-10", "2024-01-11", "2024-01-12"}, true},
+		{"3d", []string{"2024-01-13", "2024-01-14", "2024-01-15"}, true},
+		{"2024-01-12..2024-01-10", nil, false},
+		{"0d", nil, false},
+		{"yesterday", nil, false},
+		{"2024-13", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDateRange(tt.input, now)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseDateRange(%q) error = %v, want ok=%v", tt.input, err, tt.ok)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("parseDateRange(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	if got, err := parseDateRange("2024-02", now); err != nil || len(got) != 29 || got[0] != "2024-02-01" || got[28] != "2024-02-29" {
+		t.Errorf("parseDateRange(\"2024-02\") = %v, %v; want the 29 days of February", got, err)
+	}
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-5 * time.Minute)
+	stale := now.Add(-11 * time.Minute)
+
+	healthy := PingData{
+		SnapshotInterval: 300,
+		LastTickAt:       &recent,
+		Repos:            []RepoStatus{{Path: "/a", Name: "a", LastSnapshotAt: &recent}},
+	}
+	if problems := evaluateHealth(healthy, now); len(problems) != 0 {
+		t.Errorf("expected healthy, got %v", problems)
+	}
+
+	noTick := PingData{SnapshotInterval: 300}
+	if problems := evaluateHealth(noTick, now); len(problems) != 1 {
+		t.Errorf("expected 1 problem without a tick, got %v", problems)
+	}
+
+	staleTick := PingData{SnapshotInterval: 300, LastTickAt: &stale}
+	if problems := evaluateHealth(staleTick, now); len(problems) != 1 {
+		t.Errorf("expected 1 problem for stale tick, got %v", problems)
+	}
+
+	failed := PingData{
+		SnapshotInterval: 300,
+		LastTickAt:       &recent,
+		Repos:            []RepoStatus{{Path: "/a", Name: "a", LastSnapshotAt: &recent, LastError: "git diff: exit status 128"}},
+	}
+	problems := evaluateHealth(failed, now)
+	if len(problems) != 1 || !strings.Contains(problems[0], "a failed") {
+		t.Errorf("expected failed snapshot problem, got %v", problems)
+	}
+}
+
+func TestReadRepoList(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "repos.txt")
+	os.WriteFile(file, []byte("# work repos\n/abs/foo\n\nrel/bar custom-bar\n"), 0o644)
+
+	list, err := readRepoList(file)
+	if err != nil {
+		t.Fatalf("readRepoList: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(list))
+	}
+	if list[0].Path != "/abs/foo" || list[0].Name != "" {
+		t.Errorf("unexpected first entry: %+v", list[0])
+	}
+	if list[1].Path != filepath.Join(dir, "rel/bar") || list[1].Name != "custom-bar" {
+		t.Errorf("unexpected second entry: %+v", list[1])
+	}
+
+	os.WriteFile(file, []byte("/a b c\n"), 0o644)
+	if _, err := readRepoList(file); err == nil {
+		t.Error("expected error for line with too many fields")
+	}
+}
+
+func TestReadNoteText(t *testing.T) {
+	got, err := readNoteText(strings.NewReader("\n\n  indented output\nsecond line\n\n"))
+	if err != nil {
+		t.Fatalf("readNoteText: %v", err)
+	}
+	if got != "  indented output\nsecond line" {
+		t.Errorf("unexpected note text: %q", got)
+	}
+
+	got, _ = readNoteText(strings.NewReader(" \n\t\n"))
+	if got != "" {
+		t.Errorf("expected empty note for blank input, got %q", got)
+	}
+}
@@ -0,0 +1,151 @@
+package devlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CollectCmd is a collect_cmds entry: a command run before generation whose
+// output becomes an extra data source of each project.
+type CollectCmd struct {
+	Name     string `toml:"name"`      // names the raw file
+	Cmd      string `toml:"cmd"`       // run with sh -c
+	DataType string `toml:"data_type"` // groups outputs for compression
+}
+
+// builtinDataTypes are the data types devlog collects itself, which
+// collectors may not use.
+var builtinDataTypes = []string{"git", "term", "claude"}
+
+// collectNameRe matches valid collector names and data types, which are
+// used in file names.
+var collectNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+// resolveCollectPath returns the raw file holding the output of collector
+// name for project on date.
+func resolveCollectPath(cfg Config, date, name, project string) string {
+	return filepath.Join(resolveRawDir(cfg), date, "collect-"+name+"-"+project+".txt")
+}
+
+// checkCollectCmds reports the problems with the collect_cmds setting.
+func checkCollectCmds(cmds []CollectCmd) []string {
+	var problems []string
+	seen := make(map[string]bool)
+	for i, c := range cmds {
+		setting := fmt.Sprintf("collect_cmds[%d]", i)
+		switch {
+		case !collectNameRe.MatchString(c.Name):
+			problems = append(problems, fmt.Sprintf("%s: invalid name %q (use lowercase letters, digits, and _)", setting, c.Name))
+		case seen[c.Name]:
+			problems = append(problems, fmt.Sprintf("%s: duplicate name %q", setting, c.Name))
+		}
+		seen[c.Name] = true
+		if c.Cmd == "" {
+			problems = append(problems, setting+": cmd is empty")
+		}
+		switch {
+		case !collectNameRe.MatchString(c.DataType):
+			problems = append(problems, fmt.Sprintf("%s: invalid data_type %q (use lowercase letters, digits, and _)", setting, c.DataType))
+		case containsString(builtinDataTypes, c.DataType):
+			problems = append(problems, fmt.Sprintf("%s: data_type %q is reserved", setting, c.DataType))
+		}
+	}
+	return problems
+}
+
+// runCollectors runs each collect_cmds command for each of projects and
+// saves its output for date. The command gets $DEVLOG_DATE,
+// $DEVLOG_PROJECT, and, for a watched project, $DEVLOG_REPO. A file is only
+// rewritten when the output changed, so that its compressed form stays
+// cached; empty output removes it. A failing command is reported and its
+// previous output, if any, is kept.
+func runCollectors(cfg Config, state State, date string, projects []string, p *genProgress) {
+	for _, c := range cfg.CollectCmds {
+		for _, proj := range projects {
+			p.printf("collecting %s for %s", c.Name, proj)
+			cmd := exec.Command("sh", "-c", c.Cmd)
+			cmd.Env = append(os.Environ(), "DEVLOG_DATE="+date, "DEVLOG_PROJECT="+proj)
+			for _, w := range state.Watched {
+				if w.Name == proj {
+					cmd.Env = append(cmd.Env, "DEVLOG_REPO="+w.Path)
+					cmd.Dir = w.Path
+					break
+				}
+			}
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			out, err := cmd.Output()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: collector %s for %s: %v: %s\n", c.Name, proj, err, bytes.TrimSpace(stderr.Bytes()))
+				continue
+			}
+
+			path := resolveCollectPath(cfg, date, c.Name, proj)
+			if len(bytes.TrimSpace(out)) == 0 {
+				os.Remove(path)
+				continue
+			}
+			if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, out) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: collector %s: creating raw dir: %v\n", c.Name, err)
+				continue
+			}
+			if err := os.WriteFile(path, out, 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: collector %s: %v\n", c.Name, err)
+			}
+		}
+	}
+}
+
+// hasCollectorData reports whether files, keyed by file name, include
+// collector or plugin data or its compressed form.
+func hasCollectorData(files map[string]string) bool {
+	for name := range files {
+		if strings.HasPrefix(name, "collect-") || strings.HasPrefix(name, "plugin-") {
+			return true
+		}
+		if rest, ok := strings.CutPrefix(name, "comp-"); ok {
+			dataType, _, _ := strings.Cut(rest, "-")
+			if !containsString(builtinDataTypes, dataType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectorSources returns the saved collector outputs of project on date as
+// bulk sources, one per data type.
+func collectorSources(cfg Config, project, date string) []bulkSource {
+	byType := make(map[string]*bulkSource)
+	var types []string
+	for _, c := range cfg.CollectCmds {
+		path := resolveCollectPath(cfg, date, c.Name, project)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		src, ok := byType[c.DataType]
+		if !ok {
+			src = &bulkSource{dataType: c.DataType, files: make(map[string]string)}
+			byType[c.DataType] = src
+			types = append(types, c.DataType)
+		}
+		src.files[filepath.Base(path)] = string(data)
+		src.sourcePaths = append(src.sourcePaths, path)
+	}
+	sort.Strings(types)
+	var sources []bulkSource
+	for _, t := range types {
+		sources = append(sources, *byType[t])
+	}
+	return sources
+}
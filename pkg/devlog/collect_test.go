@@ -0,0 +1,76 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCollectCmds(t *testing.T) {
+	problems := checkCollectCmds([]CollectCmd{
+		{Name: "calendar", Cmd: "gcalcli agenda", DataType: "calendar"},
+		{Name: "calendar", Cmd: "", DataType: "git"},
+		{Name: "Bad Name", Cmd: "true", DataType: "x-y"},
+	})
+	want := []string{
+		`collect_cmds[1]: duplicate name "calendar"`,
+		"collect_cmds[1]: cmd is empty",
+		`collect_cmds[1]: data_type "git" is reserved`,
+		`collect_cmds[2]: invalid name "Bad Name" (use lowercase letters, digits, and _)`,
+		`collect_cmds[2]: invalid data_type "x-y" (use lowercase letters, digits, and _)`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRunCollectors(t *testing.T) {
+	rawDir := filepath.Join(t.TempDir(), "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	repo := t.TempDir()
+	cfg := Config{CollectCmds: []CollectCmd{
+		{Name: "tickets", Cmd: `echo "$DEVLOG_PROJECT $DEVLOG_DATE $(basename "$PWD")"`, DataType: "issues"},
+		{Name: "prs", Cmd: `echo "PR for $DEVLOG_PROJECT" | tr a-z A-Z`, DataType: "issues"},
+		{Name: "empty", Cmd: "true", DataType: "calendar"},
+		{Name: "broken", Cmd: "exit 3", DataType: "calendar"},
+	}}
+	state := State{Watched: []WatchEntry{{Name: "alpha", Path: repo}}}
+
+	runCollectors(cfg, state, "2024-01-15", []string{"alpha"}, nil)
+	data, err := os.ReadFile(resolveCollectPath(cfg, "2024-01-15", "tickets", "alpha"))
+	if err != nil || string(data) != "alpha 2024-01-15 "+filepath.Base(repo)+"\n" {
+		t.Errorf("unexpected tickets output %q (%v)", data, err)
+	}
+
+	// Unchanged output leaves the file alone, so its compressed form stays
+	// fresh.
+	path := resolveCollectPath(cfg, "2024-01-15", "prs", "alpha")
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+	runCollectors(cfg, state, "2024-01-15", []string{"alpha"}, nil)
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(old) {
+		t.Error("expected unchanged output not to rewrite the file")
+	}
+
+	sources := collectorSources(cfg, "alpha", "2024-01-15")
+	if len(sources) != 1 || sources[0].dataType != "issues" || len(sources[0].files) != 2 {
+		t.Fatalf("unexpected sources %+v", sources)
+	}
+	if got := sources[0].files["collect-prs-alpha.txt"]; got != "PR FOR ALPHA\n" {
+		t.Errorf("unexpected prs output %q", got)
+	}
+
+	prompt := assembleCompPrompt("issues", sources[0].files)
+	if !strings.Contains(prompt, `"issues" data for the day from commands or plugins`) {
+		t.Error("expected a generic description of collector data")
+	}
+	files := map[string]string{"comp-issues-alpha.md": "x", "comp-git-alpha.md": "y"}
+	if !hasCollectorData(files) || hasCollectorData(map[string]string{"comp-git-alpha.md": "y"}) {
+		t.Error("unexpected hasCollectorData")
+	}
+	if !strings.Contains(assemblePrompt(nil, "alpha", "2024-01-15", files, "", ""), "Data gathered by commands or plugins") {
+		t.Error("expected collector data to be described in the summary prompt")
+	}
+}
@@ -0,0 +1,86 @@
+package devlog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// periodLabel describes a range of dates as "YYYY-MM-DD" or "YYYY-MM-DD to
+// YYYY-MM-DD".
+func periodLabel(dates []string) string {
+	if len(dates) == 1 {
+		return dates[0]
+	}
+	return dates[0] + " to " + dates[len(dates)-1]
+}
+
+// assembleComparePrompt builds the prompt that compares the summaries of an
+// earlier period with those of a later one. The files are keyed
+// "earlier/<date>.md" and "later/<date>.md".
+func assembleComparePrompt(earlier, later string, files map[string]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are comparing two periods of a software engineer's work for a\n"+
+		"retrospective: the earlier period, %s, and the later period, %s.\n\n"+
+		"Below are the daily work summaries of both periods: earlier/<date>.md for\n"+
+		"the earlier one and later/<date>.md for the later one. Each has one\n"+
+		"\"## <project>\" section per project.\n", earlier, later)
+	writeSortedFiles(&b, files)
+	b.WriteString(`
+Task: Describe how the work changed from the earlier period to the later one.
+
+Use exactly these Markdown sections:
+
+## Progressed
+Work that moved forward or was finished in the later period, and how far it
+got compared to where it stood in the earlier one.
+
+## Stalled
+Work that was under way or planned in the earlier period but saw little or
+no progress in the later one.
+
+## Newly blocked
+Problems, dependencies, or open questions that block work in the later
+period and did not in the earlier one.
+
+Guidelines:
+- Group bullets by project within each section, naming the project.
+- Be specific: name the features, bugs, and systems, and point out where the
+  same piece of work appears in both periods.
+- Only report what the summaries support; do not guess at why work stalled.
+- Leave a section out if nothing belongs in it.
+- Write in first person.
+
+Output only the comparison, nothing else.
+`)
+	return b.String()
+}
+
+// comparePrompt returns the prompt comparing the summaries of the earlier
+// and later ranges of dates, limited to projects if set.
+func comparePrompt(cfg Config, earlier, later, projects []string) (string, error) {
+	if earlier[0] > later[0] {
+		earlier, later = later, earlier
+	}
+	if later[0] <= earlier[len(earlier)-1] {
+		return "", fmt.Errorf("%s and %s overlap", periodLabel(earlier), periodLabel(later))
+	}
+
+	files := make(map[string]string)
+	for _, period := range []struct {
+		name  string
+		dates []string
+	}{{"earlier", earlier}, {"later", later}} {
+		summaries := collectSummaries(cfg, period.dates, projects)
+		if len(summaries) == 0 {
+			return "", fmt.Errorf("no summaries for %s", periodLabel(period.dates))
+		}
+		for name, content := range summaries {
+			files[period.name+"/"+name] = content
+		}
+	}
+
+	counts := applyTokenBudget(files, cfg.TokenBudget)
+	warnTruncated("comparison prompt", counts, cfg.TokenBudget)
+	return assembleComparePrompt(periodLabel(earlier), periodLabel(later), files), nil
+}
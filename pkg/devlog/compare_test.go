@@ -0,0 +1,45 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComparePrompt(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir()}
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nStarted caching.\n\n## beta\n\nBeta work.\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-22.md"), []byte("# 2024-01-22\n\n## alpha\n\nShipped caching.\n"), 0o644)
+	now := time.Now()
+	jan15, _ := parseDateRange("2024-01-15", now)
+	week, _ := parseDateRange("2024-01-22..2024-01-28", now)
+
+	// The periods may be given in either order.
+	prompt, err := comparePrompt(cfg, week, jan15, []string{"alpha"})
+	if err != nil {
+		t.Fatalf("comparePrompt: %v", err)
+	}
+	for _, want := range []string{
+		"the earlier period, 2024-01-15, and the later period, 2024-01-22 to 2024-01-28",
+		"--- earlier/2024-01-15.md ---\n## alpha\n\nStarted caching.\n",
+		"--- later/2024-01-22.md ---\n## alpha\n\nShipped caching.\n",
+		"## Newly blocked",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Beta work") {
+		t.Error("prompt includes a project that was not asked for")
+	}
+
+	if _, err := comparePrompt(cfg, jan15, week, []string{"beta"}); err == nil || !strings.Contains(err.Error(), "no summaries for 2024-01-22 to 2024-01-28") {
+		t.Errorf("expected an error for a period without summaries, got %v", err)
+	}
+	overlapping, _ := parseDateRange("2024-01-10..2024-01-22", now)
+	if _, err := comparePrompt(cfg, overlapping, week, nil); err == nil {
+		t.Error("expected an error for overlapping periods")
+	}
+}
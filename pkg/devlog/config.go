@@ -0,0 +1,751 @@
+package devlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+type Config struct {
+	LogDir           string   `toml:"log_dir"`
+	RawDir           string   `toml:"raw_dir"`
+	SnapshotInterval int      `toml:"snapshot_interval"`
+	IgnoreWhitespace bool     `toml:"snapshot_ignore_whitespace"`
+	CollapseHunks    bool     `toml:"snapshot_collapse_hunks"`
+	SnapshotDelta    bool     `toml:"snapshot_delta"`
+	KeyframeEvery    int      `toml:"snapshot_keyframe_every"`
+	Editor           string   `toml:"editor"`
+	GenCmd           string   `toml:"-"` // the first command of gen_cmd
+	GenFallback      []string `toml:"-"` // the other commands of a gen_cmd list
+	CompCmd          string   `toml:"comp_cmd"`
+	GitPath          string   `toml:"git_path"`
+	NotesPath        string   `toml:"notes_path"`
+	TermPath         string   `toml:"term_path"`
+	ClaudeCodeDir    *string  `toml:"claude_code_dir"`
+	TokenBudget      int      `toml:"token_budget"`
+	LogLevel         string   `toml:"log_level"`
+	LogFormat        string   `toml:"log_format"`
+	ServerLog        string   `toml:"server_log"`
+	ServerLogMaxMB   int      `toml:"server_log_max_mb"`
+	ServerLogKeep    int      `toml:"server_log_keep"`
+	Notify           bool     `toml:"notify"`
+	APIAddr          string   `toml:"api_addr"`
+	NotifyWebhook    string   `toml:"notify_webhook"`
+	WebhookProjects  []string `toml:"webhook_projects"`
+	EmbedCmd         string   `toml:"embed_cmd"`
+	ContextDays      int      `toml:"context_days"`
+	TrackQuestions   bool     `toml:"track_questions"`
+	GenExclude       []string `toml:"gen_exclude"`
+	IndexRaw         bool     `toml:"index_raw"`
+	RawStore         string   `toml:"raw_store"`
+	RawDBKeepDays    int      `toml:"raw_db_keep_days"`
+	LogseqDir        string   `toml:"logseq_dir"`
+	LogseqNotes      bool     `toml:"logseq_notes"`
+	NotionToken      string   `toml:"notion_token"`
+	NotionDatabase   string   `toml:"notion_database"`
+	SummaryStats     bool     `toml:"summary_stats"`
+	TrackDecisions   bool     `toml:"track_decisions"`
+	SummaryFocus     bool     `toml:"summary_focus"`
+	ArchiveAfterDays int      `toml:"archive_after_days"`
+	NotesPerProject  bool     `toml:"notes_per_project"`
+	NoteHeading      string   `toml:"note_heading"`
+	NormalizeSummary bool     `toml:"normalize_summary"`
+	SummaryWrap      int      `toml:"summary_wrap"`
+	SummaryBullet    string   `toml:"summary_bullet"`
+	UpdateSummaries  bool     `toml:"update_summaries"`
+	GenTimeout       int      `toml:"gen_timeout"`  // minutes; 0 for none
+	CompTimeout      int      `toml:"comp_timeout"` // minutes; 0 for none
+	ServerGen        bool     `toml:"server_gen"`
+	AutoWatchClaude  bool     `toml:"auto_watch_claude"`
+	StaleWatchDays   int      `toml:"stale_watch_days"`
+
+	// GenCmdList is gen_cmd as written: a command, or a list of commands to
+	// try in order. loadConfig splits it into GenCmd and GenFallback.
+	GenCmdList commandList `toml:"gen_cmd"`
+
+	// CollectCmds are run before generation to add data sources.
+	CollectCmds []CollectCmd `toml:"collect_cmds"`
+	// Plugins are executables that act as data sources.
+	Plugins []PluginConfig `toml:"plugins"`
+
+	// RedactMap replaces names in `devlog gen --redacted` summaries.
+	RedactMap map[string]string `toml:"redact_map"`
+
+	// TokenPrices are the prices of gen_cmd commands, by command, for the
+	// cost estimates in summary frontmatter.
+	TokenPrices map[string]TokenPrice `toml:"token_prices"`
+
+	// Schedule holds the tasks the server runs at set times, by name.
+	Schedule map[string]ScheduleTask `toml:"schedule"`
+
+	// BatterySnapshotInterval replaces SnapshotInterval while on battery
+	// power; 0 keeps it. BatteryDefer holds back the server's scheduled
+	// tasks and archiving while on battery. See startPowerMonitor.
+	BatterySnapshotInterval int  `toml:"battery_snapshot_interval"`
+	BatteryDefer            bool `toml:"battery_defer"`
+
+	Projects map[string]ProjectConfig `toml:"projects"`
+
+	// noteFormat is NoteHeading compiled by loadConfig, see noteHeadings.
+	noteFormat *noteHeadingFormat
+}
+
+// commandList is a setting that is a command or a list of commands.
+type commandList []string
+
+func (l *commandList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*l = commandList{v}
+		return nil
+	case []any:
+		cmds := make(commandList, 0, len(v))
+		for _, c := range v {
+			s, ok := c.(string)
+			if !ok {
+				return fmt.Errorf("expected a command or a list of commands, got %v", v)
+			}
+			cmds = append(cmds, s)
+		}
+		*l = cmds
+		return nil
+	}
+	return fmt.Errorf("expected a command or a list of commands, got %v", v)
+}
+
+// MarshalTOML writes a single command as a string, as it is usually set.
+func (l commandList) MarshalTOML() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]string(l))
+}
+
+// genCmds returns the commands of gen_cmd in the order they are tried.
+func genCmds(cfg Config) []string {
+	return append([]string{cfg.GenCmd}, cfg.GenFallback...)
+}
+
+// ProjectConfig holds the settings of a [projects.<name>] section, which
+// override or add to the global settings for that project.
+type ProjectConfig struct {
+	GitPath string   `toml:"git_path"`
+	Ignore  []string `toml:"ignore"`  // pathspecs left out of snapshots
+	Exclude bool     `toml:"exclude"` // not summarized by devlog gen
+	Prompt  string   `toml:"prompt"`  // added to the summary guidelines
+}
+
+// activeProfile returns the profile selected with --profile or
+// $DEVLOG_PROFILE, or "" for the default profile.
+func activeProfile() string {
+	return os.Getenv("DEVLOG_PROFILE")
+}
+
+// appName is the name of devlog's config, data, and state directories and of
+// its socket and PID file: "devlog", or "devlog-<profile>" in a profile, so
+// that profiles share nothing.
+func appName() string {
+	if p := activeProfile(); p != "" {
+		return "devlog-" + p
+	}
+	return "devlog"
+}
+
+// validateProfileName rejects profile names that cannot be used in file
+// names.
+func validateProfileName(name string) error {
+	if strings.ContainsAny(name, " \t\n/\\") || name == "." || name == ".." {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
+
+// takeProfileFlag removes a leading "--profile <name>" or "--profile=<name>"
+// from args and returns the name ("" if there is none) and the rest.
+func takeProfileFlag(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+	if name, ok := strings.CutPrefix(args[0], "--profile="); ok {
+		if name == "" {
+			return "", nil, fmt.Errorf("--profile needs a name")
+		}
+		return name, args[1:], nil
+	}
+	if args[0] == "--profile" {
+		if len(args) < 2 || args[1] == "" {
+			return "", nil, fmt.Errorf("--profile needs a name")
+		}
+		return args[1], args[2:], nil
+	}
+	return "", args, nil
+}
+
+func configFilePath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, appName(), "config.toml")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", appName(), "config.toml")
+}
+
+func loadConfig() (Config, error) {
+	cfg := Config{
+		SnapshotInterval: 300,
+		KeyframeEvery:    12,
+		GenCmd:           "claude -p",
+		CompCmd:          "gemini --model gemini-3-flash",
+		TokenBudget:      150000,
+		ServerLogMaxMB:   10,
+		ServerLogKeep:    3,
+		Notify:           true,
+		StaleWatchDays:   30,
+		GenTimeout:       15,
+		CompTimeout:      15,
+	}
+
+	path := configFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("reading config: %w", err)
+	}
+
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing config: %w", err)
+	}
+
+	for _, p := range []*string{&cfg.LogDir, &cfg.RawDir, &cfg.GitPath, &cfg.NotesPath, &cfg.TermPath, &cfg.ServerLog, &cfg.LogseqDir} {
+		*p = expandPath(*p)
+	}
+	if cfg.ClaudeCodeDir != nil {
+		dir := expandPath(*cfg.ClaudeCodeDir)
+		cfg.ClaudeCodeDir = &dir
+	}
+	for name, pc := range cfg.Projects {
+		pc.GitPath = expandPath(pc.GitPath)
+		cfg.Projects[name] = pc
+	}
+
+	if len(cfg.GenCmdList) > 0 {
+		cfg.GenCmd, cfg.GenFallback = cfg.GenCmdList[0], cfg.GenCmdList[1:]
+	}
+	if cfg.SnapshotInterval <= 0 {
+		cfg.SnapshotInterval = 300
+	}
+	if cfg.KeyframeEvery <= 0 {
+		cfg.KeyframeEvery = 12
+	}
+	if cfg.ServerLogMaxMB <= 0 {
+		cfg.ServerLogMaxMB = 10
+	}
+	if cfg.ServerLogKeep < 0 {
+		cfg.ServerLogKeep = 0
+	}
+
+	if _, err := parseLogLevel(cfg.LogLevel); err != nil {
+		return cfg, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := validateLogFormat(cfg.LogFormat); err != nil {
+		return cfg, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := validateSummaryBullet(cfg.SummaryBullet); err != nil {
+		return cfg, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := validateRawStore(cfg.RawStore); err != nil {
+		return cfg, fmt.Errorf("parsing config: %w", err)
+	}
+	if cfg.noteFormat, err = newNoteHeadingFormat(cfg.NoteHeading); err != nil {
+		return cfg, fmt.Errorf("parsing config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func xdgDataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share")
+}
+
+func resolveLogDir(cfg Config) string {
+	if dir := os.Getenv("DEVLOG_LOG_DIR"); dir != "" {
+		return dir
+	}
+	if cfg.LogDir != "" {
+		return cfg.LogDir
+	}
+	return filepath.Join(xdgDataHome(), appName(), "log")
+}
+
+func resolveRawDir(cfg Config) string {
+	if dir := os.Getenv("DEVLOG_RAW_DIR"); dir != "" {
+		return dir
+	}
+	if cfg.RawDir != "" {
+		return cfg.RawDir
+	}
+	return filepath.Join(xdgDataHome(), appName(), "raw")
+}
+
+func resolveStatePath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, appName(), "state.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "state", appName(), "state.json")
+}
+
+// resolveAPITokenPath returns the file holding the bearer token for the HTTP
+// API, created by the server on first use.
+func resolveAPITokenPath() string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "api-token")
+}
+
+// resolvePlainCacheDir returns the private git directory that holds devlog's
+// cached copy of a watched plain directory, keyed by a hash of its path.
+func resolvePlainCacheDir(dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(filepath.Dir(resolveStatePath()), "plain", hex.EncodeToString(sum[:8])+".git")
+}
+
+// currentUID returns the user's ID, a SID on Windows, for per-user runtime
+// file names.
+func currentUID() string {
+	u, _ := user.Current()
+	if u == nil {
+		return "1000"
+	}
+	return u.Uid
+}
+
+// runtimePath returns the path of the server runtime file with extension
+// ext: in $XDG_RUNTIME_DIR, or else in runtimeFallbackDir.
+func runtimePath(ext string) string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, appName()+ext)
+	}
+	return filepath.Join(runtimeFallbackDir(), appName()+ext)
+}
+
+// runtimeFallbackDir returns the user's directory for runtime files in /tmp
+// (the user's temp dir on Windows). Anyone can create a directory with that
+// name first, so prepareRuntimeDir checks that it is private before use.
+func runtimeFallbackDir() string {
+	dir := "/tmp"
+	if runtime.GOOS == "windows" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, appName()+"-"+currentUID())
+}
+
+// prepareRuntimeDir creates the directory dir of the runtime files with mode
+// 0700 and checks it with checkRuntimeDir.
+func prepareRuntimeDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating runtime dir: %w", err)
+	}
+	return checkRuntimeDir(dir)
+}
+
+// checkRuntimeDir checks the directory dir of the runtime files before they
+// are created or used. The fallback directory must be owned by the user and
+// not accessible to anyone else, or another user could plant a socket or PID
+// file in it.
+func checkRuntimeDir(dir string) error {
+	if dir != runtimeFallbackDir() {
+		return nil
+	}
+	return checkPrivateDir(dir)
+}
+
+func pidFilePath() string {
+	return runtimePath(".pid")
+}
+
+func resolveEditor(cfg Config) string {
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	if cfg.Editor != "" {
+		return cfg.Editor
+	}
+	return "vi"
+}
+
+func readPidFile() (int, error) {
+	if err := checkRuntimeDir(filepath.Dir(pidFilePath())); err != nil {
+		return 0, err
+	}
+	data, err := os.ReadFile(pidFilePath())
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid PID file: %w", err)
+	}
+	return pid, nil
+}
+
+// Default path templates for raw data files.
+const (
+	defaultGitPath   = "<raw_dir>/<date>/git-<project>.log"
+	defaultNotesPath = "<raw_dir>/<date>/notes.md"
+	defaultTermPath  = "<raw_dir>/<date>/term-<project>*.log"
+)
+
+// pathTemplateVarRe matches the variables of a path template.
+var pathTemplateVarRe = regexp.MustCompile(`<(raw_dir|date|year|month|day|hostname|project|project_path_hash)>`)
+
+// resolvePathTemplate expands the environment variables and path template
+// variables in tmpl. A date or project of "*" turns the variables derived
+// from it into wildcards, for globbing.
+func resolvePathTemplate(tmpl, rawDir, date, project string) string {
+	year, month, day := "*", "*", "*"
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		year, month, day = t.Format("2006"), t.Format("01"), t.Format("02")
+	}
+	return filepath.FromSlash(pathTemplateVarRe.ReplaceAllStringFunc(os.ExpandEnv(tmpl), func(v string) string {
+		switch v {
+		case "<raw_dir>":
+			return rawDir
+		case "<date>":
+			return date
+		case "<year>":
+			return year
+		case "<month>":
+			return month
+		case "<day>":
+			return day
+		case "<hostname>":
+			return shortHostname()
+		case "<project>":
+			return project
+		case "<project_path_hash>":
+			if project == "*" {
+				return "*"
+			}
+			return projectPathHash(project)
+		}
+		return v
+	}))
+}
+
+// shortHostname returns the host name up to its first dot.
+func shortHostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	host, _, _ = strings.Cut(host, ".")
+	return host
+}
+
+// projectPathHash returns a short hash of the path of the watched repo named
+// project, or of the name itself if no watched repo has it. It keeps raw data
+// apart for same-named repos on different machines or paths.
+func projectPathHash(project string) string {
+	path := project
+	if state, err := loadState(); err == nil {
+		for _, w := range state.Watched {
+			if w.Name == project {
+				path = w.Path
+				break
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:4])
+}
+
+func resolveGitPath(cfg Config, date, project string) string {
+	tmpl := cfg.GitPath
+	if pc := cfg.Projects[project]; pc.GitPath != "" {
+		tmpl = pc.GitPath
+	}
+	if tmpl == "" {
+		tmpl = defaultGitPath
+	}
+	return resolvePathTemplate(tmpl, resolveRawDir(cfg), date, project)
+}
+
+func resolveNotesPath(cfg Config, date string) string {
+	tmpl := cfg.NotesPath
+	if tmpl == "" {
+		tmpl = defaultNotesPath
+	}
+	return resolvePathTemplate(tmpl, resolveRawDir(cfg), date, "")
+}
+
+// resolveProjectNotesPath returns the notes file of project on date with
+// notes_per_project set: the notes path with "-<project>" before its
+// extension, e.g. notes-api.md.
+func resolveProjectNotesPath(cfg Config, date, project string) string {
+	path := resolveNotesPath(cfg, date)
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + project + ext
+}
+
+func resolveTermGlob(cfg Config, date, project string) string {
+	tmpl := cfg.TermPath
+	if tmpl == "" {
+		tmpl = defaultTermPath
+	}
+	return resolvePathTemplate(tmpl, resolveRawDir(cfg), date, project)
+}
+
+func discoverProjects(cfg Config, date string) []string {
+	seen := make(map[string]bool)
+	rawDir := resolveRawDir(cfg)
+
+	gitTmpl := cfg.GitPath
+	if gitTmpl == "" {
+		gitTmpl = defaultGitPath
+	}
+	for _, path := range globForTemplate(gitTmpl, rawDir, date) {
+		if p := extractProjectFromPath(path, gitTmpl, rawDir, date); p != "" {
+			seen[p] = true
+		}
+	}
+	for _, path := range projectGitPaths(cfg, date) {
+		seen[path.project] = true
+	}
+
+	for _, p := range discoverProjectsFromNotes(cfg, date) {
+		seen[p] = true
+	}
+
+	projects := make([]string, 0, len(seen))
+	for p := range seen {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// projectGitPath is an existing git snapshot file of a project that has its
+// own git_path.
+type projectGitPath struct {
+	project, path string
+}
+
+// projectGitPaths returns the git snapshot files on date of the projects
+// whose [projects.<name>] section sets git_path, in project order.
+func projectGitPaths(cfg Config, date string) []projectGitPath {
+	var paths []projectGitPath
+	for name, pc := range cfg.Projects {
+		if pc.GitPath == "" {
+			continue
+		}
+		path := resolveGitPath(cfg, date, name)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, projectGitPath{project: name, path: path})
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].project < paths[j].project })
+	return paths
+}
+
+// genExcluded reports whether project is excluded from summaries by
+// gen_exclude, its [projects.<name>] section, or its watch entry's
+// exclude_gen.
+func genExcluded(cfg Config, state State, project string) bool {
+	if cfg.Projects[project].Exclude || containsString(cfg.GenExclude, project) {
+		return true
+	}
+	for _, w := range state.Watched {
+		if w.Name == project && w.ExcludeGen {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutExcluded returns projects without those excluded from summaries.
+func withoutExcluded(cfg Config, state State, projects []string) []string {
+	var kept []string
+	for _, p := range projects {
+		if !genExcluded(cfg, state, p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func discoverProjectsFromNotes(cfg Config, date string) []string {
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(readGenNotes(cfg, date), "\n") {
+		tags, _ := noteHeadingTags(cfg.noteHeadings(), line)
+		for _, tag := range tags {
+			seen[tag] = true
+		}
+	}
+
+	projects := make([]string, 0, len(seen))
+	for p := range seen {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+func globForTemplate(tmpl, rawDir, date string) []string {
+	pattern := resolvePathTemplate(tmpl, rawDir, date, "*")
+	matches, _ := filepath.Glob(pattern)
+	return matches
+}
+
+// pathTemplateRegexp returns a regexp matching the paths tmpl resolves to.
+// The date variables are fixed to date unless it is "", and the project,
+// date, year, month, and day are captured in named groups. A "*" in the
+// template matches within a path element, as for globbing.
+func pathTemplateRegexp(tmpl, rawDir, date string) *regexp.Regexp {
+	// A path element ends at "/", or on Windows also at "\".
+	notSep := `[^/]`
+	if filepath.Separator != '/' {
+		notSep = `[^/` + regexp.QuoteMeta(string(filepath.Separator)) + `]`
+	}
+	patterns := map[string]string{
+		"date":              `(?P<date>\d{4}-\d{2}-\d{2})`,
+		"year":              `(?P<year>\d{4})`,
+		"month":             `(?P<month>\d{2})`,
+		"day":               `(?P<day>\d{2})`,
+		"project":           `(?P<project>` + notSep + `+)`,
+		"project_path_hash": `[0-9a-f]+`,
+	}
+	quote := func(s string) string {
+		return strings.ReplaceAll(regexp.QuoteMeta(s), `\*`, notSep+`*`)
+	}
+
+	tmpl = filepath.FromSlash(os.ExpandEnv(tmpl))
+	var b strings.Builder
+	b.WriteString("^")
+	seen := make(map[string]bool)
+	last := 0
+	for _, m := range pathTemplateVarRe.FindAllStringSubmatchIndex(tmpl, -1) {
+		b.WriteString(quote(tmpl[last:m[0]]))
+		last = m[1]
+		name := tmpl[m[2]:m[3]]
+		pattern, ok := patterns[name]
+		if !ok || date != "" && name != "project" && name != "project_path_hash" {
+			b.WriteString(regexp.QuoteMeta(resolvePathTemplate(tmpl[m[0]:m[1]], rawDir, date, "")))
+			continue
+		}
+		if seen[name] {
+			// A variable used twice must match the same way, but Go
+			// regexps have no backreferences; match it loosely instead.
+			_, pattern, _ = strings.Cut(pattern, ">")
+			pattern = "(?:" + pattern
+		}
+		seen[name] = true
+		b.WriteString(pattern)
+	}
+	b.WriteString(quote(tmpl[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func extractProjectFromPath(path, tmpl, rawDir, date string) string {
+	re := pathTemplateRegexp(tmpl, rawDir, date)
+	m := re.FindStringSubmatch(path)
+	if m == nil {
+		return ""
+	}
+	if i := re.SubexpIndex("project"); i >= 0 {
+		return m[i]
+	}
+	return ""
+}
+
+// templateDates returns the dates of the files that tmpl resolves to, for
+// templates that do not keep each day's files under <raw_dir>/<date>.
+func templateDates(tmpl, rawDir string) []string {
+	re := pathTemplateRegexp(tmpl, rawDir, "")
+	var dates []string
+	for _, path := range globForTemplate(tmpl, rawDir, "*") {
+		m := re.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		group := func(name string) string {
+			if i := re.SubexpIndex(name); i >= 0 {
+				return m[i]
+			}
+			return ""
+		}
+		date := group("date")
+		if date == "" && group("year") != "" && group("month") != "" && group("day") != "" {
+			date = group("year") + "-" + group("month") + "-" + group("day")
+		}
+		if isValidDate(date) && !containsString(dates, date) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// resolveClaudeCodeDir returns the directory of Claude Code's session logs,
+// ~/.claude/projects by default (%USERPROFILE%\.claude\projects on Windows).
+func resolveClaudeCodeDir(cfg Config) string {
+	if cfg.ClaudeCodeDir != nil {
+		dir := *cfg.ClaudeCodeDir
+		if dir == "" {
+			return ""
+		}
+		return expandPath(dir)
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// windowsEnvRe matches a %VAR% environment variable reference, as written in
+// Windows paths.
+var windowsEnvRe = regexp.MustCompile(`%(\w+)%`)
+
+// expandPath expands environment variables and a leading "~" in a path from
+// the config file, so that one config works across home directory layouts.
+// On Windows, %VAR% references are expanded too.
+func expandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if runtime.GOOS == "windows" {
+		path = windowsEnvRe.ReplaceAllStringFunc(path, func(v string) string {
+			if val, ok := os.LookupEnv(v[1 : len(v)-1]); ok {
+				return val
+			}
+			return v
+		})
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// claudeDirReplacer turns the separators of a repo path, including a
+// Windows drive's colon, into the dashes of Claude Code's directory names.
+var claudeDirReplacer = strings.NewReplacer("/", "-", `\`, "-", ":", "-")
+
+// repoPathToClaudeDir returns the name of the directory Claude Code keeps the
+// sessions started in repoPath in, e.g. "-home-me-repo" for /home/me/repo or
+// "C--Users-me-repo" for C:\Users\me\repo.
+func repoPathToClaudeDir(repoPath string) string {
+	return claudeDirReplacer.Replace(repoPath)
+}
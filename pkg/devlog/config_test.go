@@ -0,0 +1,693 @@
+package devlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigMissing(t *testing.T) {
+	// Point XDG_CONFIG_HOME at an empty dir so no config file is found.
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SnapshotInterval != 300 {
+		t.Errorf("expected default interval 300, got %d", cfg.SnapshotInterval)
+	}
+	if cfg.LogDir != "" {
+		t.Errorf("expected empty LogDir, got %q", cfg.LogDir)
+	}
+	if cfg.CompCmd != "gemini --model gemini-3-flash" {
+		t.Errorf("expected default CompCmd %q, got %q", "gemini --model gemini-3-flash", cfg.CompCmd)
+	}
+	if cfg.TokenBudget != 150000 {
+		t.Errorf("expected default TokenBudget 150000, got %d", cfg.TokenBudget)
+	}
+}
+
+func TestLoadConfigPartial(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+
+	dir := filepath.Join(tmp, "devlog")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`
+log_dir = "/my/logs"
+snapshot_interval = 60
+`), 0o644)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LogDir != "/my/logs" {
+		t.Errorf("expected /my/logs, got %q", cfg.LogDir)
+	}
+	if cfg.SnapshotInterval != 60 {
+		t.Errorf("expected 60, got %d", cfg.SnapshotInterval)
+	}
+	if cfg.RawDir != "" {
+		t.Errorf("expected empty RawDir, got %q", cfg.RawDir)
+	}
+}
+
+func TestLoadConfigGenCmdList(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+
+	dir := filepath.Join(tmp, "devlog")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`
+gen_cmd = ["claude -p", "ollama run llama3"]
+`), 0o644)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GenCmd != "claude -p" {
+		t.Errorf("expected GenCmd claude -p, got %q", cfg.GenCmd)
+	}
+	if want := []string{"ollama run llama3"}; !reflect.DeepEqual(cfg.GenFallback, want) {
+		t.Errorf("expected GenFallback %v, got %v", want, cfg.GenFallback)
+	}
+
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`gen_cmd = 42`), 0o644)
+	if _, err := loadConfig(); err == nil {
+		t.Error("expected an error for a gen_cmd that is not a command")
+	}
+}
+
+func TestLoadConfigExpandsPaths(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("DEVLOG_TEST_DATA", "/mnt/data")
+
+	dir := filepath.Join(tmp, "devlog")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`
+log_dir = "~/notes/devlog"
+raw_dir = "${DEVLOG_TEST_DATA}/raw"
+git_path = "$DEVLOG_TEST_DATA/<date>/git-<project>.log"
+claude_code_dir = "~"
+`), 0o644)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LogDir != "/home/me/notes/devlog" {
+		t.Errorf("log_dir: got %q", cfg.LogDir)
+	}
+	if cfg.RawDir != "/mnt/data/raw" {
+		t.Errorf("raw_dir: got %q", cfg.RawDir)
+	}
+	if cfg.GitPath != "/mnt/data/<date>/git-<project>.log" {
+		t.Errorf("git_path: got %q", cfg.GitPath)
+	}
+	if got := resolveClaudeCodeDir(cfg); got != "/home/me" {
+		t.Errorf("claude_code_dir: got %q", got)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("DEVLOG_TEST_DIR", "work")
+	tests := []struct{ in, want string }{
+		{"", ""},
+		{"/abs/path", "/abs/path"},
+		{"~", "/home/me"},
+		{"~/x/y", "/home/me/x/y"},
+		{"~other/x", "~other/x"},
+		{"~/$DEVLOG_TEST_DIR/log", "/home/me/work/log"},
+		{"/data/${DEVLOG_TEST_DIR}", "/data/work"},
+	}
+	for _, tt := range tests {
+		if got := expandPath(tt.in); got != tt.want {
+			t.Errorf("expandPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigProjects(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+	t.Setenv("HOME", "/home/me")
+
+	dir := filepath.Join(tmp, "devlog")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`
+[projects.web]
+git_path = "~/raw/<date>/web.log"
+ignore = ["*.lock", "dist/"]
+prompt = "Mention the ticket number."
+
+[projects.scratch]
+exclude = true
+`), 0o644)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	web := cfg.Projects["web"]
+	if web.GitPath != "/home/me/raw/<date>/web.log" {
+		t.Errorf("git_path: got %q", web.GitPath)
+	}
+	if !reflect.DeepEqual(web.Ignore, []string{"*.lock", "dist/"}) {
+		t.Errorf("ignore: got %v", web.Ignore)
+	}
+	if web.Prompt != "Mention the ticket number." {
+		t.Errorf("prompt: got %q", web.Prompt)
+	}
+	if !cfg.Projects["scratch"].Exclude {
+		t.Error("scratch should be excluded")
+	}
+
+	if got := resolveGitPath(cfg, "2024-01-15", "web"); got != "/home/me/raw/2024-01-15/web.log" {
+		t.Errorf("resolveGitPath(web) = %q", got)
+	}
+	t.Setenv("DEVLOG_RAW_DIR", "/data/raw")
+	if got := resolveGitPath(cfg, "2024-01-15", "other"); got != "/data/raw/2024-01-15/git-other.log" {
+		t.Errorf("resolveGitPath(other) = %q", got)
+	}
+	if got := withoutExcluded(cfg, State{}, []string{"scratch", "web", "other"}); !reflect.DeepEqual(got, []string{"web", "other"}) {
+		t.Errorf("withoutExcluded = %v", got)
+	}
+	state := State{Watched: []WatchEntry{{Path: "/other", Name: "other", ExcludeGen: true}}}
+	if got := withoutExcluded(cfg, state, []string{"scratch", "web", "other"}); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("withoutExcluded with exclude_gen = %v", got)
+	}
+}
+
+func TestResolveLogDirPrecedence(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tmp)
+
+	cfg := Config{}
+
+	// Default: XDG_DATA_HOME based
+	got := resolveLogDir(cfg)
+	want := filepath.Join(tmp, "devlog", "log")
+	if got != want {
+		t.Errorf("default: got %q, want %q", got, want)
+	}
+
+	// Config overrides default
+	cfg.LogDir = "/config/logs"
+	got = resolveLogDir(cfg)
+	if got != "/config/logs" {
+		t.Errorf("config: got %q, want /config/logs", got)
+	}
+
+	// Env var overrides config
+	t.Setenv("DEVLOG_LOG_DIR", "/env/logs")
+	got = resolveLogDir(cfg)
+	if got != "/env/logs" {
+		t.Errorf("env: got %q, want /env/logs", got)
+	}
+}
+
+func TestResolveRawDirPrecedence(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", tmp)
+
+	cfg := Config{}
+
+	// Default
+	got := resolveRawDir(cfg)
+	want := filepath.Join(tmp, "devlog", "raw")
+	if got != want {
+		t.Errorf("default: got %q, want %q", got, want)
+	}
+
+	// Config overrides
+	cfg.RawDir = "/config/raw"
+	got = resolveRawDir(cfg)
+	if got != "/config/raw" {
+		t.Errorf("config: got %q, want /config/raw", got)
+	}
+
+	// Env overrides
+	t.Setenv("DEVLOG_RAW_DIR", "/env/raw")
+	got = resolveRawDir(cfg)
+	if got != "/env/raw" {
+		t.Errorf("env: got %q, want /env/raw", got)
+	}
+}
+
+func TestResolveStatePath(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	got := resolveStatePath()
+	want := filepath.Join(tmp, "devlog", "state.json")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", tmp)
+
+	got := socketPath()
+	want := filepath.Join(tmp, "devlog.sock")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSocketPathFallback(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	got := socketPath()
+	// Should be /tmp/devlog-<uid>/devlog.sock
+	if got == "" {
+		t.Error("expected non-empty socket path")
+	}
+	if want := "/tmp/devlog-" + currentUID(); filepath.Dir(got) != want {
+		t.Errorf("expected %s dir, got %q", want, filepath.Dir(got))
+	}
+}
+
+func TestPidFilePath(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", tmp)
+
+	got := pidFilePath()
+	want := filepath.Join(tmp, "devlog.pid")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveEditor(t *testing.T) {
+	t.Setenv("EDITOR", "")
+
+	// Default
+	cfg := Config{}
+	got := resolveEditor(cfg)
+	if got != "vi" {
+		t.Errorf("default: got %q, want vi", got)
+	}
+
+	// Config
+	cfg.Editor = "nano"
+	got = resolveEditor(cfg)
+	if got != "nano" {
+		t.Errorf("config: got %q, want nano", got)
+	}
+
+	// Env overrides
+	t.Setenv("EDITOR", "emacs")
+	got = resolveEditor(cfg)
+	if got != "emacs" {
+		t.Errorf("env: got %q, want emacs", got)
+	}
+}
+
+func TestResolvePathTemplate(t *testing.T) {
+	got := resolvePathTemplate("<raw_dir>/<date>/git-<project>.log", "/data/raw", "2024-01-15", "myproject")
+	want := "/data/raw/2024-01-15/git-myproject.log"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolvePathTemplateDateParts(t *testing.T) {
+	got := resolvePathTemplate("<raw_dir>/<year>/<month>/<day>/git-<project>.log", "/data/raw", "2024-01-05", "myproject")
+	want := "/data/raw/2024/01/05/git-myproject.log"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got = resolvePathTemplate("<raw_dir>/<year>/<month>/<day>/git-<project>.log", "/data/raw", "*", "*")
+	want = "/data/raw/*/*/*/git-*.log"
+	if got != want {
+		t.Errorf("glob: got %q, want %q", got, want)
+	}
+}
+
+func TestResolvePathTemplateHostAndEnv(t *testing.T) {
+	t.Setenv("DEVLOG_TEST_SHARE", "/mnt/share")
+	host := shortHostname()
+	got := resolvePathTemplate("$DEVLOG_TEST_SHARE/<hostname>/<date>/notes.md", "/data/raw", "2024-01-15", "")
+	want := "/mnt/share/" + host + "/2024-01-15/notes.md"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if strings.Contains(host, ".") {
+		t.Errorf("hostname %q is not shortened", host)
+	}
+}
+
+func TestResolvePathTemplateProjectPathHash(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if err := saveState(State{Watched: []WatchEntry{{Path: "/home/me/src/app", Name: "app"}}}); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := "<raw_dir>/<date>/git-<project>-<project_path_hash>.log"
+	got := resolvePathTemplate(tmpl, "/data/raw", "2024-01-15", "app")
+	sum := sha256.Sum256([]byte("/home/me/src/app"))
+	want := "/data/raw/2024-01-15/git-app-" + hex.EncodeToString(sum[:4]) + ".log"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if p := extractProjectFromPath(got, tmpl, "/data/raw", "2024-01-15"); p != "app" {
+		t.Errorf("extractProjectFromPath = %q, want app", p)
+	}
+	if got := resolvePathTemplate(tmpl, "/data/raw", "2024-01-15", "*"); got != "/data/raw/2024-01-15/git-*-*.log" {
+		t.Errorf("glob: got %q", got)
+	}
+	// Unwatched projects hash their name.
+	sum = sha256.Sum256([]byte("other"))
+	if got := projectPathHash("other"); got != hex.EncodeToString(sum[:4]) {
+		t.Errorf("unwatched: got %q", got)
+	}
+}
+
+func TestTemplateDates(t *testing.T) {
+	tmp := t.TempDir()
+	for _, p := range []string{"2024/01/15/git-a.log", "2024/01/16/git-b.log", "2024/01/16/git-c.log", "2024/13/01/git-d.log", "notes/x.log"} {
+		path := filepath.Join(tmp, p)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte("x"), 0o644)
+	}
+	got := templateDates("<raw_dir>/<year>/<month>/<day>/git-<project>.log", tmp)
+	want := []string{"2024-01-15", "2024-01-16"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+	t.Setenv("DEVLOG_LOG_DIR", t.TempDir())
+	cfg := Config{GitPath: "<raw_dir>/<year>/<month>/<day>/git-<project>.log"}
+	if got := rawDataDates(cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("rawDataDates: got %v, want %v", got, want)
+	}
+	if got := discoverProjects(cfg, "2024-01-16"); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("discoverProjects: got %v", got)
+	}
+}
+
+func TestResolveGitPathDefault(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	cfg := Config{}
+	got := resolveGitPath(cfg, "2024-01-15", "myproject")
+	want := filepath.Join(tmp, "2024-01-15", "git-myproject.log")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveGitPathCustom(t *testing.T) {
+	cfg := Config{GitPath: "/custom/<date>/<project>-git.log"}
+	got := resolveGitPath(cfg, "2024-01-15", "myproject")
+	want := "/custom/2024-01-15/myproject-git.log"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveNotesPathDefault(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	cfg := Config{}
+	got := resolveNotesPath(cfg, "2024-01-15")
+	want := filepath.Join(tmp, "2024-01-15", "notes.md")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveNotesPathCustom(t *testing.T) {
+	cfg := Config{NotesPath: "/notes/<date>/notes.md"}
+	got := resolveNotesPath(cfg, "2024-01-15")
+	want := "/notes/2024-01-15/notes.md"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDiscoverProjects(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	// Create files in default template locations
+	dateDir := filepath.Join(tmp, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-foo.log"), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "git-bar.log"), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"), []byte("### At 10:00 #baz\nsome note\n\n"), 0o644)
+
+	cfg := Config{}
+	projects := discoverProjects(cfg, "2024-01-15")
+
+	if len(projects) != 3 {
+		t.Fatalf("expected 3 projects, got %d: %v", len(projects), projects)
+	}
+	if projects[0] != "bar" || projects[1] != "baz" || projects[2] != "foo" {
+		t.Errorf("expected [bar baz foo], got %v", projects)
+	}
+}
+
+func TestDiscoverProjectsCustomTemplate(t *testing.T) {
+	tmp := t.TempDir()
+
+	// Create files in custom locations
+	dateDir := filepath.Join(tmp, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "myproject-git.log"), []byte("x"), 0o644)
+
+	cfg := Config{GitPath: tmp + "/<date>/<project>-git.log"}
+	projects := discoverProjects(cfg, "2024-01-15")
+
+	if len(projects) != 1 || projects[0] != "myproject" {
+		t.Errorf("expected [myproject], got %v", projects)
+	}
+}
+
+func TestResolveTermGlobDefault(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	cfg := Config{}
+	got := resolveTermGlob(cfg, "2024-01-15", "myproject")
+	want := filepath.Join(tmp, "2024-01-15", "term-myproject*.log")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveTermGlobCustom(t *testing.T) {
+	cfg := Config{TermPath: "/custom/<date>/terminal-<project>*.txt"}
+	got := resolveTermGlob(cfg, "2024-01-15", "myproject")
+	want := "/custom/2024-01-15/terminal-myproject*.txt"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveClaudeCodeDirDefault(t *testing.T) {
+	cfg := Config{}
+	got := resolveClaudeCodeDir(cfg)
+	home, _ := os.UserHomeDir()
+	want := filepath.Join(home, ".claude", "projects")
+	if got != want {
+		t.Errorf("default: got %q, want %q", got, want)
+	}
+}
+
+func TestResolveClaudeCodeDirCustom(t *testing.T) {
+	custom := "/custom/claude/dir"
+	cfg := Config{ClaudeCodeDir: &custom}
+	got := resolveClaudeCodeDir(cfg)
+	if got != custom {
+		t.Errorf("custom: got %q, want %q", got, custom)
+	}
+}
+
+func TestResolveClaudeCodeDirDisabled(t *testing.T) {
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty}
+	got := resolveClaudeCodeDir(cfg)
+	if got != "" {
+		t.Errorf("disabled: got %q, want empty string", got)
+	}
+}
+
+func TestResolveClaudeCodeDirTilde(t *testing.T) {
+	tilde := "~/.claude/custom"
+	cfg := Config{ClaudeCodeDir: &tilde}
+	got := resolveClaudeCodeDir(cfg)
+	home, _ := os.UserHomeDir()
+	want := filepath.Join(home, ".claude", "custom")
+	if got != want {
+		t.Errorf("tilde: got %q, want %q", got, want)
+	}
+}
+
+func TestExtractProjectFromPath(t *testing.T) {
+	tests := []struct {
+		path, tmpl, rawDir, date, want string
+	}{
+		{
+			"/data/raw/2024-01-15/git-foo.log",
+			"<raw_dir>/<date>/git-<project>.log",
+			"/data/raw", "2024-01-15", "foo",
+		},
+		{
+			"/custom/2024-01-15/myproject-git.log",
+			"/custom/<date>/<project>-git.log",
+			"/data/raw", "2024-01-15", "myproject",
+		},
+		{
+			"/data/raw/2024/01/15/git-foo.log",
+			"<raw_dir>/<year>/<month>/<day>/git-<project>.log",
+			"/data/raw", "2024-01-15", "foo",
+		},
+		{
+			"/data/raw/2024/01/16/git-foo.log",
+			"<raw_dir>/<year>/<month>/<day>/git-<project>.log",
+			"/data/raw", "2024-01-15", "",
+		},
+	}
+	for _, tt := range tests {
+		got := extractProjectFromPath(tt.path, tt.tmpl, tt.rawDir, tt.date)
+		if got != tt.want {
+			t.Errorf("extractProjectFromPath(%q, %q, %q, %q) = %q, want %q",
+				tt.path, tt.tmpl, tt.rawDir, tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoverProjectsFromNotes(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	dateDir := filepath.Join(tmp, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"), []byte(
+		"### At 09:00 #alpha\nfirst note\n\n"+
+			"### At 10:00\nunaffiliated note\n\n"+
+			"### At 11:00 #beta\nsecond note\n\n"+
+			"### At 12:00 #alpha\nanother alpha note\n\n",
+	), 0o644)
+
+	cfg := Config{}
+	projects := discoverProjectsFromNotes(cfg, "2024-01-15")
+
+	if len(projects) != 2 {
+		t.Fatalf("expected 2 projects, got %d: %v", len(projects), projects)
+	}
+	if projects[0] != "alpha" || projects[1] != "beta" {
+		t.Errorf("expected [alpha beta], got %v", projects)
+	}
+}
+
+func TestDiscoverProjectsFromNotesMultipleTags(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	dateDir := filepath.Join(tmp, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"), []byte(
+		"### At 09:00 #alpha #beta\nshared library change\n\n",
+	), 0o644)
+
+	projects := discoverProjectsFromNotes(Config{}, "2024-01-15")
+	if len(projects) != 2 || projects[0] != "alpha" || projects[1] != "beta" {
+		t.Errorf("expected [alpha beta], got %v", projects)
+	}
+}
+
+func TestDiscoverProjectsFromNotesNoFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	cfg := Config{}
+	projects := discoverProjectsFromNotes(cfg, "2024-01-15")
+	if len(projects) != 0 {
+		t.Errorf("expected no projects, got %v", projects)
+	}
+}
+
+func TestProfilePaths(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmp, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Join(tmp, "run"))
+	t.Setenv("DEVLOG_LOG_DIR", "")
+	t.Setenv("DEVLOG_RAW_DIR", "")
+
+	t.Setenv("DEVLOG_PROFILE", "")
+	if got := configFilePath(); got != filepath.Join(tmp, "config", "devlog", "config.toml") {
+		t.Errorf("default config path: got %q", got)
+	}
+
+	t.Setenv("DEVLOG_PROFILE", "work")
+	tests := []struct{ name, got, want string }{
+		{"config", configFilePath(), filepath.Join(tmp, "config", "devlog-work", "config.toml")},
+		{"log", resolveLogDir(Config{}), filepath.Join(tmp, "data", "devlog-work", "log")},
+		{"raw", resolveRawDir(Config{}), filepath.Join(tmp, "data", "devlog-work", "raw")},
+		{"state", resolveStatePath(), filepath.Join(tmp, "state", "devlog-work", "state.json")},
+		{"socket", socketPath(), filepath.Join(tmp, "run", "devlog-work.sock")},
+		{"pid", pidFilePath(), filepath.Join(tmp, "run", "devlog-work.pid")},
+		{"shadow index", shadowIndexName(), "devlog_work_shadow_index"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestTakeProfileFlag(t *testing.T) {
+	tests := []struct {
+		args        []string
+		wantProfile string
+		wantArgs    []string
+		wantErr     bool
+	}{
+		{[]string{"gen"}, "", []string{"gen"}, false},
+		{[]string{"--profile", "work", "gen", "-v"}, "work", []string{"gen", "-v"}, false},
+		{[]string{"--profile=home", "note", "-m", "x"}, "home", []string{"note", "-m", "x"}, false},
+		{[]string{"--profile"}, "", nil, true},
+		{[]string{"--profile="}, "", nil, true},
+		{[]string{"-m", "--profile"}, "", []string{"-m", "--profile"}, false},
+	}
+	for _, tt := range tests {
+		profile, args, err := takeProfileFlag(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("takeProfileFlag(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if profile != tt.wantProfile || !tt.wantErr && !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("takeProfileFlag(%q) = %q, %q, want %q, %q", tt.args, profile, args, tt.wantProfile, tt.wantArgs)
+		}
+	}
+
+	for _, name := range []string{"", "work", "client-a"} {
+		if err := validateProfileName(name); err != nil {
+			t.Errorf("validateProfileName(%q): %v", name, err)
+		}
+	}
+	for _, name := range []string{"a/b", "..", "my work"} {
+		if err := validateProfileName(name); err == nil {
+			t.Errorf("validateProfileName(%q) should fail", name)
+		}
+	}
+}
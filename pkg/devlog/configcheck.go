@@ -0,0 +1,197 @@
+package devlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// templateVarNameRe matches anything that looks like a path template
+// variable, known or not.
+var templateVarNameRe = regexp.MustCompile(`<([a-z_]+)>`)
+
+// checkPathTemplate reports the problems with the path template tmpl of
+// setting: unknown variables, no date, and no <project> if one is required.
+func checkPathTemplate(setting, tmpl string, needProject bool) []string {
+	var problems []string
+	for _, m := range templateVarNameRe.FindAllStringSubmatch(tmpl, -1) {
+		if !pathTemplateVarRe.MatchString(m[0]) {
+			problems = append(problems, fmt.Sprintf("%s: unknown variable %s in %q", setting, m[0], tmpl))
+		}
+	}
+	hasDate := strings.Contains(tmpl, "<date>") ||
+		strings.Contains(tmpl, "<year>") && strings.Contains(tmpl, "<month>") && strings.Contains(tmpl, "<day>")
+	if !hasDate {
+		problems = append(problems, fmt.Sprintf("%s: %q has no <date> (or <year>, <month>, and <day>)", setting, tmpl))
+	}
+	if needProject && !strings.Contains(tmpl, "<project>") {
+		problems = append(problems, fmt.Sprintf("%s: %q has no <project>", setting, tmpl))
+	}
+	return problems
+}
+
+// checkCommand reports a problem if command, the value of setting, is empty
+// or its program is not on $PATH.
+func checkCommand(setting, command string) []string {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return []string{setting + " is empty"}
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return []string{fmt.Sprintf("%s: command %q not found on $PATH", setting, args[0])}
+	}
+	return nil
+}
+
+// checkConfig parses the config file and returns the problems with it:
+// parse errors, unknown keys, bad values and path templates, and commands
+// that are not installed. A missing config file is not a problem.
+func checkConfig() []string {
+	var problems []string
+	data, err := os.ReadFile(configFilePath())
+	if err != nil && !os.IsNotExist(err) {
+		return []string{fmt.Sprintf("reading config: %v", err)}
+	}
+	if err == nil {
+		md, err := toml.Decode(string(data), &Config{})
+		if err != nil {
+			return []string{fmt.Sprintf("parsing config: %v", err)}
+		}
+		for _, key := range md.Undecoded() {
+			problems = append(problems, fmt.Sprintf("unknown key %q", key.String()))
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	if cfg.TokenBudget < 0 {
+		problems = append(problems, fmt.Sprintf("token_budget: must not be negative, got %d", cfg.TokenBudget))
+	}
+	if cfg.ContextDays < 0 {
+		problems = append(problems, fmt.Sprintf("context_days: must not be negative, got %d", cfg.ContextDays))
+	}
+	if cfg.StaleWatchDays < 0 {
+		problems = append(problems, fmt.Sprintf("stale_watch_days: must not be negative, got %d", cfg.StaleWatchDays))
+	}
+	if cfg.GenTimeout < 0 {
+		problems = append(problems, fmt.Sprintf("gen_timeout: must not be negative, got %d", cfg.GenTimeout))
+	}
+	if cfg.CompTimeout < 0 {
+		problems = append(problems, fmt.Sprintf("comp_timeout: must not be negative, got %d", cfg.CompTimeout))
+	}
+	if cfg.SummaryWrap < 0 {
+		problems = append(problems, fmt.Sprintf("summary_wrap: must not be negative, got %d", cfg.SummaryWrap))
+	}
+	if cfg.BatterySnapshotInterval < 0 {
+		problems = append(problems, fmt.Sprintf("battery_snapshot_interval: must not be negative, got %d", cfg.BatterySnapshotInterval))
+	}
+	cmds := make([]string, 0, len(cfg.TokenPrices))
+	for cmd := range cfg.TokenPrices {
+		cmds = append(cmds, cmd)
+	}
+	sort.Strings(cmds)
+	for _, cmd := range cmds {
+		if price := cfg.TokenPrices[cmd]; price.Input < 0 || price.Output < 0 {
+			problems = append(problems, fmt.Sprintf("token_prices: prices of %q must not be negative", cmd))
+		}
+	}
+	if cfg.RawDBKeepDays < 0 {
+		problems = append(problems, fmt.Sprintf("raw_db_keep_days: must not be negative, got %d", cfg.RawDBKeepDays))
+	}
+	if cfg.ArchiveAfterDays < 0 {
+		problems = append(problems, fmt.Sprintf("archive_after_days: must not be negative, got %d", cfg.ArchiveAfterDays))
+	}
+
+	problems = append(problems, checkPathTemplate("git_path", orDefault(cfg.GitPath, defaultGitPath), true)...)
+	problems = append(problems, checkPathTemplate("notes_path", orDefault(cfg.NotesPath, defaultNotesPath), false)...)
+	problems = append(problems, checkPathTemplate("term_path", orDefault(cfg.TermPath, defaultTermPath), true)...)
+	names := make([]string, 0, len(cfg.Projects))
+	for name := range cfg.Projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := validateProjectName(name); err != nil {
+			problems = append(problems, fmt.Sprintf("projects.%s: %v", name, err))
+		}
+		if tmpl := cfg.Projects[name].GitPath; tmpl != "" {
+			problems = append(problems, checkPathTemplate("projects."+name+".git_path", tmpl, false)...)
+		}
+	}
+
+	problems = append(problems, checkCollectCmds(cfg.CollectCmds)...)
+	problems = append(problems, checkSchedule(cfg.Schedule)...)
+	problems = append(problems, checkPlugins(cfg.Plugins, cfg.CollectCmds)...)
+
+	for i, c := range genCmds(cfg) {
+		setting := "gen_cmd"
+		if len(cfg.GenFallback) > 0 {
+			setting = fmt.Sprintf("gen_cmd[%d]", i)
+		}
+		problems = append(problems, checkCommand(setting, c)...)
+	}
+	problems = append(problems, checkCommand("comp_cmd", cfg.CompCmd)...)
+	if cfg.EmbedCmd != "" {
+		problems = append(problems, checkCommand("embed_cmd", cfg.EmbedCmd)...)
+	}
+	return problems
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// effectiveConfig returns cfg with every setting that has a default or an
+// environment override resolved to the value devlog actually uses.
+func effectiveConfig(cfg Config) Config {
+	cfg.LogDir = resolveLogDir(cfg)
+	cfg.RawDir = resolveRawDir(cfg)
+	cfg.Editor = resolveEditor(cfg)
+	cfg.GitPath = orDefault(cfg.GitPath, defaultGitPath)
+	cfg.NotesPath = orDefault(cfg.NotesPath, defaultNotesPath)
+	cfg.TermPath = orDefault(cfg.TermPath, defaultTermPath)
+	cfg.GenCmdList = genCmds(cfg)
+	claudeDir := resolveClaudeCodeDir(cfg)
+	cfg.ClaudeCodeDir = &claudeDir
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = "text"
+	}
+	return cfg
+}
+
+// writeEffectiveConfig writes the effective config as TOML, preceded by
+// comments saying where it came from and where devlog keeps its other files.
+func writeEffectiveConfig(w io.Writer, cfg Config) error {
+	path := configFilePath()
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(w, "# Config file: %s\n", path)
+	} else {
+		fmt.Fprintf(w, "# Config file: %s (not found; using defaults)\n", path)
+	}
+	if p := activeProfile(); p != "" {
+		fmt.Fprintf(w, "# Profile: %s\n", p)
+	}
+	for _, env := range []string{"DEVLOG_LOG_DIR", "DEVLOG_RAW_DIR", "EDITOR"} {
+		if v := os.Getenv(env); v != "" {
+			fmt.Fprintf(w, "# $%s=%s overrides the config file\n", env, v)
+		}
+	}
+	fmt.Fprintf(w, "# State file: %s\n", resolveStatePath())
+	fmt.Fprintf(w, "# Socket: %s\n", socketPath())
+	fmt.Fprintf(w, "# PID file: %s\n\n", pidFilePath())
+	return toml.NewEncoder(w).Encode(effectiveConfig(cfg))
+}
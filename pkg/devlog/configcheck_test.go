@@ -0,0 +1,139 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func writeTestConfig(t *testing.T, content string) {
+	t.Helper()
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+	os.MkdirAll(filepath.Join(tmp, "devlog"), 0o755)
+	os.WriteFile(filepath.Join(tmp, "devlog", "config.toml"), []byte(content), 0o644)
+}
+
+func TestCheckPathTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl        string
+		needProject bool
+		want        []string // substrings of the problems, in order
+	}{
+		{"<raw_dir>/<date>/git-<project>.log", true, nil},
+		{"<raw_dir>/<year>/<month>/<day>/git-<project>.log", true, nil},
+		{"<raw_dir>/<date>/notes.md", false, nil},
+		{"<raw_dir>/<year>/<month>/git-<project>.log", true, []string{"has no <date>"}},
+		{"<raw_dir>/<date>/git.log", true, []string{"has no <project>"}},
+		{"<raw_dir>/<dat>/git-<project>.log", true, []string{"unknown variable <dat>", "has no <date>"}},
+	}
+	for _, tt := range tests {
+		got := checkPathTemplate("git_path", tt.tmpl, tt.needProject)
+		if len(got) != len(tt.want) {
+			t.Errorf("checkPathTemplate(%q) = %q, want %d problems", tt.tmpl, got, len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			if !strings.Contains(got[i], w) || !strings.HasPrefix(got[i], "git_path: ") {
+				t.Errorf("checkPathTemplate(%q)[%d] = %q, want it to contain %q", tt.tmpl, i, got[i], w)
+			}
+		}
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "summarize"), []byte("#!/bin/sh\n"), 0o755)
+	t.Setenv("PATH", bin)
+
+	writeTestConfig(t, `
+gen_cmd = "summarize"
+comp_cmd = "summarize --fast"
+log_dir = "/tmp/log"
+token_budget = 0
+token_prices = { summarize = { input = 3, output = 15 } }
+`)
+	if problems := checkConfig(); len(problems) != 0 {
+		t.Errorf("expected no problems, got %q", problems)
+	}
+
+	writeTestConfig(t, `
+gen_cmd = "summarize"
+comp_cmd = "missing-compressor"
+log_dri = "/tmp/log"
+git_path = "<raw_dir>/<date>/git.log"
+log_level = "loud"
+token_prices = { summarize = { input = -3, output = 15 } }
+
+[projects.web]
+git_pth = "x"
+
+[projects."bad name"]
+`)
+	problems := checkConfig()
+	want := []string{
+		`unknown key "log_dri"`,
+		`unknown key "projects.web.git_pth"`,
+		`invalid log_level "loud"`,
+		`token_prices: prices of "summarize" must not be negative`,
+		`git_path: "<raw_dir>/<date>/git.log" has no <project>`,
+		`projects.bad name: invalid project name`,
+		`comp_cmd: command "missing-compressor" not found`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems %q, want %d", len(problems), problems, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i], w) {
+			t.Errorf("problem %d = %q, want it to contain %q", i, problems[i], w)
+		}
+	}
+
+	writeTestConfig(t, "gen_cmd = \n")
+	if problems := checkConfig(); len(problems) != 1 || !strings.HasPrefix(problems[0], "parsing config") {
+		t.Errorf("expected a parse error, got %q", problems)
+	}
+}
+
+func TestWriteEffectiveConfig(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmp, "data"))
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+	t.Setenv("DEVLOG_RAW_DIR", "")
+	t.Setenv("EDITOR", "")
+	writeTestConfig(t, `
+log_dir = "/ignored/log"
+editor = "nano"
+`)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := writeEffectiveConfig(&b, cfg); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if !strings.Contains(out, "# $DEVLOG_LOG_DIR="+filepath.Join(tmp, "log")+" overrides the config file") {
+		t.Errorf("output should note the DEVLOG_LOG_DIR override:\n%s", out)
+	}
+
+	var got Config
+	if _, err := toml.Decode(out, &got); err != nil {
+		t.Fatalf("output is not valid TOML: %v\n%s", err, out)
+	}
+	if got.LogDir != filepath.Join(tmp, "log") {
+		t.Errorf("log_dir = %q", got.LogDir)
+	}
+	if got.RawDir != filepath.Join(tmp, "data", "devlog", "raw") {
+		t.Errorf("raw_dir = %q", got.RawDir)
+	}
+	if got.GitPath != defaultGitPath || got.Editor != "nano" || !reflect.DeepEqual(got.GenCmdList, commandList{"claude -p"}) || got.LogLevel != "info" {
+		t.Errorf("unexpected effective config: %+v", got)
+	}
+}
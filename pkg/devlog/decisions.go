@@ -0,0 +1,179 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// decisionsFile is the name under which a project's earlier decisions are
+// given to the summarizer.
+const decisionsFile = "decisions.md"
+
+// decisionContextLimit is the number of earlier decisions given to the
+// summarizer as context.
+const decisionContextLimit = 20
+
+// decisionDay is the decisions a project's summary recorded for one date.
+type decisionDay struct {
+	Date  string
+	Items []string // "<decision> — <rationale>", one line each
+}
+
+// decisionLog is a project's decision log, kept in Markdown at
+// resolveDecisionsPath: a "## YYYY-MM-DD" section per date, oldest first,
+// with a bullet per decision.
+type decisionLog struct {
+	Project string
+	Days    []decisionDay
+}
+
+// resolveDecisionsPath returns the decision log of project.
+func resolveDecisionsPath(cfg Config, project string) string {
+	return filepath.Join(resolveLogDir(cfg), "decisions", project+".md")
+}
+
+var decisionDateRe = regexp.MustCompile(`^## (\d{4}-\d{2}-\d{2})\s*$`)
+
+// parseDecisionLog reads a decision log. Bullets may span several lines;
+// anything outside a date section is dropped.
+func parseDecisionLog(project, content string) decisionLog {
+	log := decisionLog{Project: project}
+	var day *decisionDay
+	for _, line := range strings.Split(content, "\n") {
+		if m := decisionDateRe.FindStringSubmatch(line); m != nil {
+			log.Days = append(log.Days, decisionDay{Date: m[1]})
+			day = &log.Days[len(log.Days)-1]
+			continue
+		}
+		if day == nil || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if item, ok := strings.CutPrefix(line, "- "); ok {
+			day.Items = append(day.Items, strings.TrimSpace(item))
+		} else if len(day.Items) > 0 {
+			day.Items[len(day.Items)-1] += " " + strings.TrimSpace(line)
+		}
+	}
+	return log
+}
+
+func loadDecisions(cfg Config, project string) (decisionLog, error) {
+	data, err := os.ReadFile(resolveDecisionsPath(cfg, project))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return decisionLog{Project: project}, nil
+		}
+		return decisionLog{}, fmt.Errorf("reading decision log: %w", err)
+	}
+	return parseDecisionLog(project, string(data)), nil
+}
+
+func saveDecisions(cfg Config, log decisionLog) error {
+	path := resolveDecisionsPath(cfg, log.Project)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating decisions dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(log.String()), 0o644); err != nil {
+		return fmt.Errorf("writing decision log: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing decision log: %w", err)
+	}
+	return nil
+}
+
+// String formats the log as Markdown.
+func (l decisionLog) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Decisions: %s\n", l.Project)
+	for _, d := range l.Days {
+		fmt.Fprintf(&b, "\n## %s\n\n", d.Date)
+		for _, item := range d.Items {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+	return b.String()
+}
+
+// set records the decisions of the summary of date, replacing any recorded
+// for it before, so that regenerating a summary does not duplicate them. A
+// date without decisions has no section.
+func (l *decisionLog) set(date string, items []string) {
+	kept := l.Days[:0]
+	for _, d := range l.Days {
+		if d.Date != date {
+			kept = append(kept, d)
+		}
+	}
+	l.Days = kept
+	if len(items) > 0 {
+		l.Days = append(l.Days, decisionDay{Date: date, Items: items})
+	}
+	sort.SliceStable(l.Days, func(i, j int) bool { return l.Days[i].Date < l.Days[j].Date })
+}
+
+// formatEarlierDecisions lists the last limit decisions recorded before date
+// for the summary prompt, e.g. "- 2024-01-10: Use SQLite for the index —
+// a single file is easier to back up".
+func formatEarlierDecisions(l decisionLog, date string, limit int) string {
+	var lines []string
+	for _, d := range l.Days {
+		if d.Date >= date {
+			continue
+		}
+		for _, item := range d.Items {
+			lines = append(lines, fmt.Sprintf("- %s: %s\n", d.Date, item))
+		}
+	}
+	if len(lines) == 0 {
+		return "None.\n"
+	}
+	if len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	return strings.Join(lines, "")
+}
+
+var decisionsLineRe = regexp.MustCompile(`(?i)^\**decisions:?\**:?\s*$`)
+
+// parseDecisionTrailer reads the "Decisions:" list that the summary prompt
+// asks for when decisions are tracked. It returns the summary without the
+// list and the decisions in it.
+func parseDecisionTrailer(summary string) (string, []string) {
+	lines := strings.Split(strings.TrimRight(summary, "\n"), "\n")
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if decisionsLineRe.MatchString(strings.TrimSpace(lines[i])) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return strings.TrimSpace(summary), nil
+	}
+
+	var items []string
+	end := start + 1
+	for ; end < len(lines); end++ {
+		line := strings.TrimSpace(lines[end])
+		if line == "" {
+			continue
+		}
+		text, ok := strings.CutPrefix(line, "- ")
+		if !ok {
+			break
+		}
+		text = strings.TrimSpace(text)
+		if text != "" && !strings.EqualFold(strings.TrimRight(text, "."), "none") {
+			items = append(items, text)
+		}
+	}
+	rest := append(lines[:start:start], lines[end:]...)
+	return strings.TrimSpace(strings.Join(rest, "\n")), items
+}
@@ -0,0 +1,75 @@
+package devlog
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecisionLog(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir()}
+	log, err := loadDecisions(cfg, "alpha")
+	if err != nil || len(log.Days) != 0 {
+		t.Fatalf("loadDecisions on a missing file = %+v, %v", log, err)
+	}
+	log.set("2024-01-16", []string{"Use SQLite for the index — one file to back up"})
+	log.set("2024-01-15", []string{"Drop Python 3.8 — nobody uses it"})
+	log.set("2024-01-17", nil)
+	if err := saveDecisions(cfg, log); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(resolveDecisionsPath(cfg, "alpha"))
+	want := "# Decisions: alpha\n\n## 2024-01-15\n\n- Drop Python 3.8 — nobody uses it\n\n" +
+		"## 2024-01-16\n\n- Use SQLite for the index — one file to back up\n"
+	if string(data) != want {
+		t.Errorf("decision log:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Hand edits survive, and regenerating a date replaces its decisions.
+	os.WriteFile(resolveDecisionsPath(cfg, "alpha"), []byte(strings.Replace(string(data), "nobody uses it", "nobody\n  uses it", 1)), 0o644)
+	log, _ = loadDecisions(cfg, "alpha")
+	if log.Days[0].Items[0] != "Drop Python 3.8 — nobody uses it" {
+		t.Errorf("continuation line not joined: %q", log.Days[0].Items)
+	}
+	log.set("2024-01-16", []string{"Use Postgres after all — SQLite locks under load"})
+	if len(log.Days) != 2 || log.Days[1].Items[0] != "Use Postgres after all — SQLite locks under load" {
+		t.Errorf("after set: %+v", log.Days)
+	}
+
+	if got := formatEarlierDecisions(log, "2024-01-16", 20); got != "- 2024-01-15: Drop Python 3.8 — nobody uses it\n" {
+		t.Errorf("earlier decisions = %q", got)
+	}
+	if got := formatEarlierDecisions(log, "2024-01-20", 1); got != "- 2024-01-16: Use Postgres after all — SQLite locks under load\n" {
+		t.Errorf("limited decisions = %q", got)
+	}
+	if got := formatEarlierDecisions(log, "2024-01-15", 20); got != "None.\n" {
+		t.Errorf("no earlier decisions = %q", got)
+	}
+}
+
+func TestParseDecisionTrailer(t *testing.T) {
+	summary := "Worked on the index.\n\nNext steps:\n- Benchmark\n\n" +
+		"**Decisions:**\n- Use SQLite — simple\n- Batch writes — fewer fsyncs\n\n" +
+		"Open questions:\n- Which page size?\n\nResolved: none"
+	text, items := parseDecisionTrailer(summary)
+	if !reflect.DeepEqual(items, []string{"Use SQLite — simple", "Batch writes — fewer fsyncs"}) {
+		t.Errorf("items = %q", items)
+	}
+	if want := "Worked on the index.\n\nNext steps:\n- Benchmark\n\nOpen questions:\n- Which page size?\n\nResolved: none"; text != want {
+		t.Errorf("summary = %q, want %q", text, want)
+	}
+
+	text, items = parseDecisionTrailer("Did things.\n\nDecisions:\n- None\n")
+	if text != "Did things." || len(items) != 0 {
+		t.Errorf("parseDecisionTrailer with none = %q, %q", text, items)
+	}
+
+	prompt := assemblePrompt(nil, "alpha", "2024-01-16", map[string]string{decisionsFile: "None.\n"}, "", "")
+	if !strings.Contains(prompt, `add a line "Decisions:"`) || !strings.Contains(prompt, "--- decisions.md ---") {
+		t.Error("prompt does not ask for decisions")
+	}
+	if strings.Contains(assemblePrompt(nil, "alpha", "2024-01-16", map[string]string{}, "", ""), `"Decisions:"`) {
+		t.Error("prompt asks for decisions when they are not tracked")
+	}
+}
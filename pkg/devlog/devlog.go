@@ -0,0 +1,114 @@
+// Package devlog records development activity and summarizes it: snapshots
+// of watched repos, notes, terminal logs, and Claude Code sessions are kept
+// as raw data per day, and gen_cmd turns a day's raw data into a summary.
+//
+// The devlog command is a thin wrapper around Main. Other tools, such as
+// editor daemons and bots, can use the functions in this file, which are the
+// stable API of the package: they read the same config and state as the
+// devlog command and write the same files. Everything else in the package
+// may change between releases.
+package devlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LoadConfig reads the config file of the active profile, filling in the
+// defaults for unset settings.
+func LoadConfig() (Config, error) {
+	return loadConfig()
+}
+
+// LoadState reads the watched repos. It returns an empty State if nothing has
+// been watched yet.
+func LoadState() (State, error) {
+	return loadState()
+}
+
+// ErrServerRunning is returned by SaveState while the server is running.
+var ErrServerRunning = errors.New("devlog server is running; watch and unwatch repos through it")
+
+// SaveState writes s as the watched repos. The server keeps the watched repos
+// in memory and writes them back on each change, so SaveState refuses with
+// ErrServerRunning while it is running: use the devlog watch and unwatch
+// commands then.
+func SaveState(s State) error {
+	if _, err := ipcSend(IPCRequest{Command: "ping"}); err == nil {
+		return ErrServerRunning
+	} else if !isServerNotRunning(err) {
+		return err
+	}
+	return saveState(s)
+}
+
+// LogDir returns the directory summaries are written to.
+func LogDir(cfg Config) string {
+	return resolveLogDir(cfg)
+}
+
+// RawDir returns the directory raw data is collected in.
+func RawDir(cfg Config) string {
+	return resolveRawDir(cfg)
+}
+
+// Snapshot takes a snapshot of the uncommitted changes of entry and appends
+// it to the project's raw git file for date, as the server does on each
+// tick. Unless prevHash is "", a snapshot whose DiffHash is prevHash is not
+// written again. It returns the diff, which is "" if there are no changes.
+func Snapshot(cfg Config, entry WatchEntry, date, prevHash string) (string, error) {
+	opts := snapshotOptions{
+		Ignore:           cfg.Projects[entry.Name].Ignore,
+		IgnoreWhitespace: cfg.IgnoreWhitespace,
+	}
+	return snapshotEntry(entry, resolveGitPath(cfg, date, entry.Name), prevHash, date, opts)
+}
+
+// DiffHash returns the hash Snapshot compares with prevHash.
+func DiffHash(diff string) string {
+	return diffHash(diff)
+}
+
+// Generate writes the summary for date and returns the number of projects
+// summarized, as `devlog gen` does.
+func Generate(cfg Config, state State, date string) (int, error) {
+	return runGen(context.Background(), cfg, state, date, nil)
+}
+
+// GenerateProject summarizes project's activity on date and returns the
+// summary section without writing it.
+func GenerateProject(cfg Config, state State, project, date string) (string, error) {
+	summary, _, err := generateProjectSummary(context.Background(), cfg, state, project, date, nil)
+	return summary, err
+}
+
+// Projects returns the projects with activity on date.
+func Projects(cfg Config, state State, date string) []string {
+	return discoverAllProjects(cfg, state, date)
+}
+
+// SummaryPath returns the path of the summary for date.
+func SummaryPath(cfg Config, date string) string {
+	return summaryPath(cfg, date)
+}
+
+// ReadSummary returns the summary for date.
+func ReadSummary(cfg Config, date string) (string, error) {
+	return readSummary(cfg, date)
+}
+
+// ClaudeTranscript returns the condensed transcript of the Claude Code
+// sessions in entry on date, in local time, as it is given to gen_cmd. It
+// returns "" if there were none or claude_code_dir is "".
+func ClaudeTranscript(cfg Config, entry WatchEntry, date string) (string, error) {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return "", fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	claudeDir := resolveClaudeCodeDir(cfg)
+	if claudeDir == "" {
+		return "", nil
+	}
+	return preprocessClaudeCodeSessions(claudeSourcesFor(claudeDir, entry), date, time.Local)
+}
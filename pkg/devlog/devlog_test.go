@@ -0,0 +1,85 @@
+package devlog
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotAPI(t *testing.T) {
+	t.Setenv("DEVLOG_RAW_DIR", t.TempDir())
+	repo := initTestRepo(t)
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+	cfg := Config{}
+	entry := WatchEntry{Path: repo, Name: "proj"}
+
+	diff, err := Snapshot(cfg, entry, "2024-01-15", "")
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if !strings.Contains(diff, "main.go") {
+		t.Errorf("diff should mention main.go, got %q", diff)
+	}
+	data, err := os.ReadFile(resolveGitPath(cfg, "2024-01-15", "proj"))
+	if err != nil {
+		t.Fatalf("reading raw git file: %v", err)
+	}
+	if !strings.Contains(string(data), "=== SNAPSHOT") {
+		t.Error("raw git file is missing the snapshot")
+	}
+
+	// The same changes again are not written twice.
+	if _, err := Snapshot(cfg, entry, "2024-01-15", DiffHash(diff)); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	again, _ := os.ReadFile(resolveGitPath(cfg, "2024-01-15", "proj"))
+	if len(again) != len(data) {
+		t.Error("unchanged snapshot was written again")
+	}
+}
+
+func TestClaudeTranscriptAPI(t *testing.T) {
+	empty := ""
+	got, err := ClaudeTranscript(Config{ClaudeCodeDir: &empty}, WatchEntry{Path: "/repo", Name: "proj"}, "2024-01-15")
+	if err != nil || got != "" {
+		t.Errorf("with claude_code_dir disabled: got %q, %v", got, err)
+	}
+	if _, err := ClaudeTranscript(Config{}, WatchEntry{Path: "/repo"}, "01/15/2024"); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+}
+
+func TestSaveStateWhileServerRuns(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	state := State{Watched: []WatchEntry{{Path: "/home/user/dev/foo", Name: "foo"}}}
+	if err := SaveState(state); err != nil {
+		t.Fatalf("SaveState without a server: %v", err)
+	}
+
+	s := newServer(Config{SnapshotInterval: 300})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	ln, err := listenIPC(socketPath())
+	if err != nil {
+		t.Fatalf("listenIPC: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handleConn(conn)
+		}
+	}()
+	if err := SaveState(State{}); err != ErrServerRunning {
+		t.Errorf("SaveState with a server running: got %v, want ErrServerRunning", err)
+	}
+	if got, _ := LoadState(); len(got.Watched) != 1 {
+		t.Errorf("state changed while the server was running: %+v", got)
+	}
+}
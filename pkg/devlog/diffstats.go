@@ -0,0 +1,163 @@
+package devlog
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dayDiff is how a project's code changed over a day, from its git snapshot
+// log and the commits made in its repo that day.
+type dayDiff struct {
+	Snapshots    int
+	Commits      int
+	FilesTouched int // files in any snapshot or commit of the day
+	NetAdded     int // lines added from the first snapshot to the last, plus by commits
+	NetRemoved   int
+	LinesAdded   int // diff lines added as they appear, see snapshotChurn
+	LinesRemoved int
+}
+
+// Churn is the number of diff lines added and removed over the day,
+// including lines that were rewritten or reverted.
+func (d dayDiff) Churn() int {
+	return d.LinesAdded + d.LinesRemoved
+}
+
+// collectDayDiff computes the code changes of proj on date. Commits are read
+// from the project's watched repo, if it has one and git can read it; a
+// missing snapshot log or repo leaves those parts zero.
+func collectDayDiff(cfg Config, state State, date, proj string) dayDiff {
+	var d dayDiff
+	files := make(map[string]bool)
+	if data, err := readGitLog(resolveGitPath(cfg, date, proj)); err == nil {
+		d.Snapshots, d.LinesAdded, d.LinesRemoved = snapshotChurn(data)
+		d.NetAdded, d.NetRemoved = snapshotNetDiff(data, files)
+	}
+	for _, w := range state.Watched {
+		if w.Name != proj || w.Plain {
+			continue
+		}
+		if commits, added, removed, err := commitNumstat(w, date, files); err == nil {
+			d.Commits = commits
+			d.NetAdded += added
+			d.NetRemoved += removed
+		}
+		break
+	}
+	d.FilesTouched = len(files)
+	return d
+}
+
+// snapshotNetDiff compares the last snapshot in a git snapshot log with the
+// first and counts the diff lines added and removed between them, per file.
+// Lines that leave the diff were committed or reverted, and are not counted:
+// commits are counted from the repo instead. The files in any snapshot are
+// added to files.
+func snapshotNetDiff(content string, files map[string]bool) (added, removed int) {
+	var first, last map[string]int
+	for _, chunk := range splitSnapshots(content) {
+		if !strings.HasPrefix(chunk, snapshotHeaderPrefix) {
+			continue
+		}
+		cur := make(map[string]int)
+		file := ""
+		for _, line := range strings.Split(chunk, "\n") {
+			if name, ok := diffFileName(line); ok {
+				file = name
+				files[name] = true
+				continue
+			}
+			if isDiffFileHeader(line) {
+				continue
+			}
+			if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+				cur[file+"\n"+line]++
+			}
+		}
+		if first == nil {
+			first = cur
+		}
+		last = cur
+	}
+	for key, n := range last {
+		if extra := n - first[key]; extra > 0 {
+			if key[strings.IndexByte(key, '\n')+1] == '+' {
+				added += extra
+			} else {
+				removed += extra
+			}
+		}
+	}
+	return added, removed
+}
+
+// diffFileName returns the file named by a "--- a/" or "+++ b/" line of a
+// diff.
+func diffFileName(line string) (string, bool) {
+	for _, prefix := range []string{"--- a/", "+++ b/"} {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSuffix(line[len(prefix):], "\t"), true
+		}
+	}
+	return "", false
+}
+
+// commitNumstat counts the commits made in w's repo on date by the repo's
+// configured user, and the lines they added and removed. For a monorepo
+// sub-project, only changes under its subdirectory count. The files the
+// commits touched are added to files.
+func commitNumstat(w WatchEntry, date string, files map[string]bool) (commits, added, removed int, err error) {
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	root := w.repoRoot()
+	args := []string{"-C", root, "log", "--no-merges", "--format=%x00", "--numstat",
+		"--since=" + day.Format(time.RFC3339), "--until=" + day.AddDate(0, 0, 1).Format(time.RFC3339)}
+	if out, err := exec.Command("git", "-C", root, "config", "user.email").Output(); err == nil {
+		if email := strings.TrimSpace(string(out)); email != "" {
+			args = append(args, "--author=<"+regexp.QuoteMeta(email)+">")
+		}
+	}
+	if w.Subdir != "" {
+		args = append(args, "--", w.Subdir)
+	}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("git log in %s: %w", root, err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "\x00" {
+			commits++
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files have "-" for both counts.
+		a, _ := strconv.Atoi(fields[0])
+		r, _ := strconv.Atoi(fields[1])
+		added += a
+		removed += r
+		files[fields[2]] = true
+	}
+	return commits, added, removed, nil
+}
+
+// diffFooter returns the stats line appended to a project's summary section
+// when summary_stats is set, or "" if the project changed no code that day.
+func diffFooter(d dayDiff) string {
+	if d.FilesTouched == 0 && d.Commits == 0 {
+		return ""
+	}
+	footer := fmt.Sprintf("Diff stats: +%d/-%d lines in %s", d.NetAdded, d.NetRemoved, plural(d.FilesTouched, "file"))
+	if d.Commits > 0 {
+		footer += ", " + plural(d.Commits, "commit")
+	}
+	return footer + fmt.Sprintf(", %d lines of churn.", d.Churn())
+}
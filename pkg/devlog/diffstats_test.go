@@ -0,0 +1,88 @@
+package devlog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotNetDiff(t *testing.T) {
+	content := "=== SNAPSHOT 10:00 ===\n" +
+		"--- a/main.go\n+++ b/main.go\n+line one\n-old line\n\n" +
+		"=== SNAPSHOT 10:05 ===\n" +
+		"--- a/main.go\n+++ b/main.go\n+line one\n+line two\n+line three\n-old line\n" +
+		"--- a/gone.go\n+++ /dev/null\n-deleted\n\n" +
+		"=== SNAPSHOT 11:00 ===\n" +
+		"--- /dev/null\n+++ b/new.go\n+line one\n\n"
+
+	files := make(map[string]bool)
+	added, removed := snapshotNetDiff(content, files)
+	// main.go's changes left the diff (committed), so only new.go counts.
+	if added != 1 || removed != 0 {
+		t.Errorf("net diff = +%d/-%d, want +1/-0", added, removed)
+	}
+	if len(files) != 3 || !files["main.go"] || !files["gone.go"] || !files["new.go"] {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestCommitNumstat(t *testing.T) {
+	repo := initTestRepo(t)
+	commit := func(name, content, date, email string) {
+		os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644)
+		exec.Command("git", "-C", repo, "add", "-A").Run()
+		cmd := exec.Command("git", "-C", repo, "-c", "user.email="+email, "commit", "-m", name)
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("commit: %s: %v", out, err)
+		}
+	}
+	noon := func(date string) string {
+		d, _ := time.ParseInLocation("2006-01-02 15:04", date+" 12:00", time.Local)
+		return d.Format(time.RFC3339)
+	}
+	commit("a.go", "one\ntwo\n", noon("2024-01-15"), "test@test.com")
+	commit("a.go", "one\n", noon("2024-01-15"), "test@test.com")
+	commit("b.go", "x\n", noon("2024-01-15"), "someone@else.com")
+	commit("c.go", "y\n", noon("2024-01-16"), "test@test.com")
+
+	files := make(map[string]bool)
+	commits, added, removed, err := commitNumstat(WatchEntry{Path: repo, Name: "proj"}, "2024-01-15", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commits != 2 || added != 2 || removed != 1 {
+		t.Errorf("commitNumstat = %d commits, +%d/-%d, want 2 commits, +2/-1", commits, added, removed)
+	}
+	if len(files) != 1 || !files["a.go"] {
+		t.Errorf("files = %v", files)
+	}
+
+	rawDir := filepath.Join(t.TempDir(), "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	os.MkdirAll(filepath.Join(rawDir, "2024-01-15"), 0o755)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "git-proj.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\n--- a/d.go\n+++ b/d.go\n+wip\n\n"), 0o644)
+	d := collectDayDiff(Config{}, State{Watched: []WatchEntry{{Path: repo, Name: "proj"}}}, "2024-01-15", "proj")
+	if d.Snapshots != 1 || d.Commits != 2 || d.FilesTouched != 2 || d.NetAdded != 2 || d.NetRemoved != 1 || d.Churn() != 1 {
+		t.Errorf("collectDayDiff = %+v", d)
+	}
+}
+
+func TestDiffFooter(t *testing.T) {
+	for _, tt := range []struct {
+		d    dayDiff
+		want string
+	}{
+		{dayDiff{}, ""},
+		{dayDiff{FilesTouched: 1, NetAdded: 3, LinesAdded: 5}, "Diff stats: +3/-0 lines in 1 file, 5 lines of churn."},
+		{dayDiff{Commits: 2, FilesTouched: 4, NetAdded: 30, NetRemoved: 8, LinesAdded: 40, LinesRemoved: 12},
+			"Diff stats: +30/-8 lines in 4 files, 2 commits, 52 lines of churn."},
+	} {
+		if got := diffFooter(tt.d); got != tt.want {
+			t.Errorf("diffFooter(%+v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
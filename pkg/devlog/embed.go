@@ -0,0 +1,177 @@
+package devlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// embeddingIndex caches the embedding of each searchable document, keyed by
+// a hash of its text, so that only new or changed documents are embedded
+// again. Vectors from different commands are not comparable, so the index
+// is rebuilt when embed_cmd changes.
+type embeddingIndex struct {
+	Command string               `json:"command"`
+	Vectors map[string][]float32 `json:"vectors"`
+}
+
+// resolveEmbeddingIndexPath returns the file holding the embedding index.
+func resolveEmbeddingIndexPath() string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "embeddings.json")
+}
+
+func docKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:16])
+}
+
+// loadEmbeddingIndex reads the index at path, returning an empty index for
+// command if there is none or it was built by another command.
+func loadEmbeddingIndex(path, command string) (embeddingIndex, error) {
+	idx := embeddingIndex{Command: command, Vectors: make(map[string][]float32)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return idx, fmt.Errorf("reading embedding index: %w", err)
+	}
+	var stored embeddingIndex
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return idx, fmt.Errorf("parsing embedding index: %w", err)
+	}
+	if stored.Command != command || stored.Vectors == nil {
+		return idx, nil
+	}
+	return stored, nil
+}
+
+func saveEmbeddingIndex(path string, idx embeddingIndex) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating index dir: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("marshaling embedding index: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing embedding index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing embedding index: %w", err)
+	}
+	return nil
+}
+
+// parseEmbedding reads a vector from the output of embed_cmd: a JSON array
+// of numbers, or the response of a common embeddings API ({"embedding": [...]}
+// from Ollama, {"embeddings": [[...]]}, or {"data": [{"embedding": [...]}]}
+// from OpenAI-compatible servers).
+func parseEmbedding(out []byte) ([]float32, error) {
+	var vec []float32
+	if err := json.Unmarshal(out, &vec); err == nil && len(vec) > 0 {
+		return vec, nil
+	}
+	var resp struct {
+		Embedding  []float32   `json:"embedding"`
+		Embeddings [][]float32 `json:"embeddings"`
+		Data       []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, errors.New("output is not a JSON array of numbers or an embeddings response")
+	}
+	switch {
+	case len(resp.Embedding) > 0:
+		return resp.Embedding, nil
+	case len(resp.Embeddings) > 0 && len(resp.Embeddings[0]) > 0:
+		return resp.Embeddings[0], nil
+	case len(resp.Data) > 0 && len(resp.Data[0].Embedding) > 0:
+		return resp.Data[0].Embedding, nil
+	}
+	return nil, errors.New("no embedding in output")
+}
+
+// embedText runs command with text on stdin and returns the vector it
+// prints.
+func embedText(command, text string) ([]float32, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, errors.New("embed_cmd is not set in config.toml")
+	}
+	out, err := runPromptCmd(context.Background(), "embed_cmd", command, text, 0)
+	if err != nil {
+		return nil, err
+	}
+	vec, err := parseEmbedding([]byte(out))
+	if err != nil {
+		return nil, fmt.Errorf("embed_cmd: %w", err)
+	}
+	return vec, nil
+}
+
+// updateIndex embeds the documents that are not in idx yet and drops the
+// vectors of documents that no longer exist, returning the number embedded.
+// On error, the vectors embedded so far are kept.
+func updateIndex(idx *embeddingIndex, docs []searchDoc, embed func(string) ([]float32, error), p *genProgress) (int, error) {
+	live := make(map[string]bool, len(docs))
+	added := 0
+	for _, d := range docs {
+		key := docKey(d.Text)
+		live[key] = true
+		if _, ok := idx.Vectors[key]; ok {
+			continue
+		}
+		p.printf("embedding %s %s…", d.Date, d.Kind)
+		vec, err := embed(d.Text)
+		if err != nil {
+			return added, err
+		}
+		idx.Vectors[key] = vec
+		added++
+	}
+	for key := range idx.Vectors {
+		if !live[key] {
+			delete(idx.Vectors, key)
+		}
+	}
+	return added, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// semanticSearch scores each indexed document by the cosine similarity of
+// its embedding to query's.
+func semanticSearch(idx embeddingIndex, docs []searchDoc, query []float32) []searchHit {
+	var hits []searchHit
+	for _, d := range docs {
+		if vec, ok := idx.Vectors[docKey(d.Text)]; ok {
+			hits = append(hits, searchHit{Doc: d, Score: cosineSimilarity(vec, query)})
+		}
+	}
+	return hits
+}
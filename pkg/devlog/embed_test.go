@@ -0,0 +1,104 @@
+package devlog
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEmbedding(t *testing.T) {
+	for _, out := range []string{
+		`[0.5, -1, 2]`,
+		`{"embedding": [0.5, -1, 2]}`,
+		`{"embeddings": [[0.5, -1, 2]]}`,
+		`{"data": [{"embedding": [0.5, -1, 2]}], "model": "x"}`,
+	} {
+		vec, err := parseEmbedding([]byte(out))
+		if err != nil || len(vec) != 3 || vec[1] != -1 {
+			t.Errorf("parseEmbedding(%s) = %v, %v", out, vec, err)
+		}
+	}
+	for _, out := range []string{`not json`, `{"other": 1}`, `[]`} {
+		if _, err := parseEmbedding([]byte(out)); err == nil {
+			t.Errorf("expected error for %s", out)
+		}
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := cosineSimilarity([]float32{1, 0}, []float32{2, 0}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("parallel vectors: got %f", got)
+	}
+	if got := cosineSimilarity([]float32{1, 0}, []float32{0, 3}); got != 0 {
+		t.Errorf("orthogonal vectors: got %f", got)
+	}
+	if got := cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0}); got != 0 {
+		t.Errorf("mismatched lengths: got %f", got)
+	}
+}
+
+func TestUpdateIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	idx, err := loadEmbeddingIndex(path, "embed-a")
+	if err != nil {
+		t.Fatalf("loadEmbeddingIndex: %v", err)
+	}
+
+	calls := 0
+	embed := func(text string) ([]float32, error) {
+		calls++
+		return []float32{float32(len(text)), 1}, nil
+	}
+	docs := []searchDoc{{Date: "2024-01-15", Text: "one"}, {Date: "2024-01-16", Text: "three"}}
+	if n, err := updateIndex(&idx, docs, embed, nil); err != nil || n != 2 {
+		t.Fatalf("updateIndex = %d, %v", n, err)
+	}
+	saveEmbeddingIndex(path, idx)
+
+	// Unchanged documents are not embedded again, and removed ones are dropped.
+	idx, _ = loadEmbeddingIndex(path, "embed-a")
+	docs = []searchDoc{{Date: "2024-01-15", Text: "one"}, {Date: "2024-01-17", Text: "four"}}
+	if n, _ := updateIndex(&idx, docs, embed, nil); n != 1 || calls != 3 || len(idx.Vectors) != 2 {
+		t.Errorf("expected one new embedding and 2 vectors, got %d (calls %d, vectors %d)", n, calls, len(idx.Vectors))
+	}
+
+	// Another command starts over.
+	if idx, _ := loadEmbeddingIndex(path, "embed-b"); len(idx.Vectors) != 0 {
+		t.Errorf("expected an empty index for another command, got %d vectors", len(idx.Vectors))
+	}
+
+	failing := func(string) ([]float32, error) { return nil, errors.New("down") }
+	if _, err := updateIndex(&idx, []searchDoc{{Text: "new"}}, failing, nil); err == nil {
+		t.Error("expected embedding error")
+	}
+}
+
+func TestSemanticSearchHistory(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := writeSearchFixtures(t, tmp)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+
+	// A toy embedder: texts mentioning "race" point one way, others another.
+	script := filepath.Join(tmp, "embed.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\nif grep -q race; then echo '[1, 0.1]'; else echo '[0, 1]'; fi\n"), 0o755)
+	cfg.EmbedCmd = script
+
+	hits, err := searchHistory(cfg, "concurrency race condition", []string{"2024-01-15", "2024-01-16"}, "", true, nil)
+	if err != nil {
+		t.Fatalf("searchHistory: %v", err)
+	}
+	days := rankDays(hits, 1)
+	if len(days) != 1 || days[0].Doc.Date != "2024-01-16" || days[0].Doc.Kind != "note" {
+		t.Errorf("unexpected best match %+v", days)
+	}
+	if _, err := os.Stat(resolveEmbeddingIndexPath()); err != nil {
+		t.Errorf("expected the index to be saved: %v", err)
+	}
+
+	cfg.EmbedCmd = ""
+	if _, err := searchHistory(cfg, "race", []string{"2024-01-16"}, "", true, nil); err == nil {
+		t.Error("expected error without embed_cmd")
+	}
+}
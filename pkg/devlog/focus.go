@@ -0,0 +1,167 @@
+package devlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// FocusBlock is a stretch of uninterrupted work on one project.
+type FocusBlock struct {
+	Project string `json:"project"`
+	Start   string `json:"start"` // HH:MM
+	End     string `json:"end"`   // HH:MM
+	Minutes int    `json:"minutes"`
+
+	start, end time.Time
+}
+
+// FocusReport is the output of `devlog focus`.
+type FocusReport struct {
+	Date     string       `json:"date"`
+	Projects []string     `json:"projects"`
+	Switches int          `json:"switches"`
+	Blocks   []FocusBlock `json:"blocks"`
+}
+
+// projectSpan is a span of activity on a project.
+type projectSpan struct {
+	Project string
+	timeSpan
+}
+
+// focusBlocks orders the activity in spans by start and joins activity on
+// the same project separated by at most idle into blocks. Activity on
+// another project in between starts a new block.
+func focusBlocks(spans []projectSpan, idle time.Duration) []FocusBlock {
+	sorted := append([]projectSpan(nil), spans...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	var blocks []FocusBlock
+	for _, s := range sorted {
+		if n := len(blocks); n > 0 && blocks[n-1].Project == s.Project && s.Start.Sub(blocks[n-1].end) <= idle {
+			if s.End.After(blocks[n-1].end) {
+				blocks[n-1].end = s.End
+			}
+			continue
+		}
+		blocks = append(blocks, FocusBlock{Project: s.Project, start: s.Start, end: s.End})
+	}
+	for i := range blocks {
+		b := &blocks[i]
+		b.Start, b.End = b.start.Format("15:04"), b.end.Format("15:04")
+		b.Minutes = int(b.end.Sub(b.start).Round(time.Minute).Minutes())
+	}
+	return blocks
+}
+
+// countSwitches counts the changes of project between consecutive blocks.
+// Starting on another project after a pause of workdayGap or more is a new
+// start rather than an interruption, and is not counted.
+func countSwitches(blocks []FocusBlock) int {
+	n := 0
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].Project != blocks[i-1].Project && blocks[i].start.Sub(blocks[i-1].end) < workdayGap {
+			n++
+		}
+	}
+	return n
+}
+
+// collectFocus analyzes how work on date moved between projects. Projects
+// are discovered as for summary generation; unaffiliated notes are left
+// out, since they belong to no project to switch from.
+func collectFocus(cfg Config, state State, date string) FocusReport {
+	report := FocusReport{Date: date, Projects: []string{}, Blocks: []FocusBlock{}}
+	var spans []projectSpan
+	for _, proj := range withoutExcluded(cfg, state, discoverAllProjects(cfg, state, date)) {
+		activity := activitySpans(cfg, state, date, proj)
+		if len(activity) == 0 {
+			continue
+		}
+		report.Projects = append(report.Projects, proj)
+		for _, s := range activity {
+			spans = append(spans, projectSpan{Project: proj, timeSpan: s})
+		}
+	}
+	if blocks := focusBlocks(spans, defaultIdleGap); len(blocks) > 0 {
+		report.Blocks = blocks
+	}
+	report.Switches = countSwitches(report.Blocks)
+	return report
+}
+
+// longestBlock returns the longest block of the report, the earliest if
+// several are as long.
+func (r FocusReport) longestBlock() FocusBlock {
+	var longest FocusBlock
+	for _, b := range r.Blocks {
+		if b.Minutes > longest.Minutes {
+			longest = b
+		}
+	}
+	return longest
+}
+
+// String describes the fragmentation of the day, e.g. "7 context switches
+// between 3 projects; longest focus block 1h30m on alpha".
+func (r FocusReport) String() string {
+	switches := "1 context switch"
+	if r.Switches != 1 {
+		switches = fmt.Sprintf("%d context switches", r.Switches)
+	}
+	longest := r.longestBlock()
+	return fmt.Sprintf("%s between %s; longest focus block %s on %s", switches, plural(len(r.Projects), "project"),
+		formatSessionDuration(time.Duration(longest.Minutes)*time.Minute), longest.Project)
+}
+
+// focusHeader returns the line under a summary's date heading on how
+// fragmented the day was, or "" if fewer than two projects had activity.
+func focusHeader(cfg Config, state State, date string) string {
+	report := collectFocus(cfg, state, date)
+	if len(report.Projects) < 2 {
+		return ""
+	}
+	return "Focus: " + report.String() + "."
+}
+
+func printFocusJSON(w io.Writer, report FocusReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// printFocusTable prints the blocks of the day in order, followed by the
+// time and number of blocks per project.
+func printFocusTable(w io.Writer, report FocusReport) {
+	fmt.Fprintf(w, "Focus on %s: %s\n\n", report.Date, report)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tPROJECT\tMINUTES")
+	for _, b := range report.Blocks {
+		fmt.Fprintf(tw, "%s–%s\t%s\t%d\n", b.Start, b.End, b.Project, b.Minutes)
+	}
+	tw.Flush()
+
+	if len(report.Projects) < 2 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tBLOCKS\tMINUTES\tLONGEST")
+	for _, proj := range report.Projects {
+		blocks, minutes, longest := 0, 0, 0
+		for _, b := range report.Blocks {
+			if b.Project == proj {
+				blocks++
+				minutes += b.Minutes
+				longest = max(longest, b.Minutes)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", proj, blocks, minutes, longest)
+	}
+	tw.Flush()
+}
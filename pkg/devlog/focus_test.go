@@ -0,0 +1,79 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFocusBlocks(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		tm, _ := clockOn("2024-01-15", hhmm, time.Local)
+		return tm
+	}
+	span := func(proj, start, end string) projectSpan {
+		return projectSpan{Project: proj, timeSpan: timeSpan{at(start), at(end)}}
+	}
+	blocks := focusBlocks([]projectSpan{
+		span("alpha", "09:00", "09:05"),
+		span("alpha", "09:15", "10:30"),
+		span("beta", "10:35", "10:40"), // interrupts alpha
+		span("alpha", "10:45", "11:00"),
+		span("beta", "14:00", "15:00"), // after lunch: a new start
+	}, 15*time.Minute)
+
+	var got []string
+	for _, b := range blocks {
+		got = append(got, b.Project+" "+b.Start+"–"+b.End)
+	}
+	want := []string{"alpha 09:00–10:30", "beta 10:35–10:40", "alpha 10:45–11:00", "beta 14:00–15:00"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("blocks = %v, want %v", got, want)
+	}
+	if blocks[0].Minutes != 90 {
+		t.Errorf("expected 90 minutes for the first block, got %d", blocks[0].Minutes)
+	}
+	if n := countSwitches(blocks); n != 2 {
+		t.Errorf("expected 2 switches, got %d", n)
+	}
+}
+
+func TestCollectFocus(t *testing.T) {
+	rawDir := filepath.Join(t.TempDir(), "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	dateDir := filepath.Join(rawDir, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"), []byte(
+		"=== SNAPSHOT 09:05 ===\n+a\n\n=== SNAPSHOT 09:15 ===\n+b\n\n=== SNAPSHOT 10:00 ===\n+c\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "git-beta.log"), []byte(
+		"=== SNAPSHOT 09:30 ===\n+a\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"), []byte("### At 09:45\nunaffiliated\n"), 0o644)
+
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty}
+	report := collectFocus(cfg, State{}, "2024-01-15")
+	if report.Switches != 2 || len(report.Blocks) != 3 || len(report.Projects) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if got, want := report.String(), "2 context switches between 2 projects; longest focus block 15m on alpha"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := focusHeader(cfg, State{}, "2024-01-15"); !strings.HasPrefix(got, "Focus: 2 context switches") {
+		t.Errorf("unexpected focus header %q", got)
+	}
+
+	var buf bytes.Buffer
+	printFocusTable(&buf, report)
+	out := buf.String()
+	if !strings.Contains(out, "09:25–09:30  beta     5") || !strings.Contains(out, "alpha    2       20       15") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+
+	cfg.GenExclude = []string{"beta"}
+	if got := focusHeader(cfg, State{}, "2024-01-15"); got != "" {
+		t.Errorf("expected no focus header for one project, got %q", got)
+	}
+}
@@ -0,0 +1,1375 @@
+package devlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// semanticNoteTags are hashtags that classify a note rather than associate
+// it with a project.
+var semanticNoteTags = []string{"bug", "decision", "meeting", "blocked", "pin"}
+
+// noteHeadingTags reports whether line is a notes entry heading and returns
+// the projects it is tagged with, e.g. "### At 10:00 #alpha #beta #bug"
+// returns ["alpha", "beta"]. Semantic tags are not projects and are left out.
+func noteHeadingTags(headings *noteHeadingFormat, line string) ([]string, bool) {
+	_, rest, ok := parseNoteHeading(headings, line)
+	if !ok {
+		return nil, false
+	}
+	var tags []string
+	for _, f := range strings.Fields(rest) {
+		if len(f) > 1 && f[0] == '#' && !containsString(semanticNoteTags, f[1:]) {
+			tags = append(tags, f[1:])
+		}
+	}
+	return tags, true
+}
+
+func filterNotesForProject(headings *noteHeadingFormat, content, project string) string {
+	lines := strings.Split(content, "\n")
+	var result []string
+	var inMatch bool
+
+	for _, line := range lines {
+		if tags, ok := noteHeadingTags(headings, line); ok {
+			inMatch = containsString(tags, project)
+		}
+		if inMatch {
+			result = append(result, line)
+		}
+	}
+	return strings.TrimRight(strings.Join(result, "\n"), "\n")
+}
+
+func filterUnaffiliatedNotes(headings *noteHeadingFormat, content string) string {
+	lines := strings.Split(content, "\n")
+	var result []string
+	var inMatch bool
+
+	for _, line := range lines {
+		if tags, ok := noteHeadingTags(headings, line); ok {
+			inMatch = len(tags) == 0
+		}
+		if inMatch {
+			result = append(result, line)
+		}
+	}
+	return strings.TrimRight(strings.Join(result, "\n"), "\n")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// assemblePrompt builds the summary prompt for project on date from files.
+// timeline, from projectTimeline, is given before the data if it is set.
+// instructions, from the project's prompt setting, are added to the
+// guidelines.
+func assemblePrompt(headings *noteHeadingFormat, project, date string, files map[string]string, timeline, instructions string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are summarizing a day of software engineering work on the project\n"+
+		"%q for the date %s.\n\n", project, date)
+	if timeline != "" {
+		b.WriteString("Activity timeline, inferred from the times of snapshots, terminal\n" +
+			"sessions, notes, and Claude Code messages (approximate; use it for when\n" +
+			"the work happened, not as exact hours):\n" + timeline + "\n")
+	}
+	b.WriteString("Below is the data collected during the day.\n")
+
+	// Sort filenames for deterministic output
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n", name, files[name])
+	}
+
+	if todos := openTodoTexts(headings, files["notes.md"]); len(todos) > 0 {
+		b.WriteString("\nOpen TODO items recorded in the notes:\n")
+		for _, t := range todos {
+			fmt.Fprintf(&b, "- %s\n", t)
+		}
+	}
+
+	b.WriteString(`
+Description of data sources:
+
+- notes.md: Manually logged notes and snippets with timestamps. These can be
+  developer notes expressing intent, observations, and decisions. They can
+  also be snippets captured from code, docs, the web, or terminal sessions.
+  Besides project hashtags, note headings may carry tags that classify the
+  note: #decision (a decision that was made), #blocked (something blocking
+  progress), #bug (a bug found or fixed), #meeting (notes from a meeting),
+  and #pin (a highlight the developer pinned to keep in the summary).
+  A line "Attachment: <filename>" means a file such as a screenshot was
+  attached to the note; refer to it by filename where it helps (e.g. "see
+  layout-bug.png").
+
+- comp-git-` + project + `.md: AI-compressed summary of time-stamped snapshots of
+  uncommitted code changes, taken every 5 minutes. Describes the evolution of
+  the code over the day, including approaches that were tried and abandoned.
+
+- comp-term-` + project + `.md: AI-compressed summary of terminal session
+  recordings. Describes the developer's terminal activity: commands run, test
+  output, debugging sessions, REPL interactions, etc.
+
+- comp-claude-` + project + `.md: AI-compressed summary of Claude Code session
+  transcripts for the day. Describes the developer's interactions with an AI
+  coding assistant, what the developer was trying to accomplish, what
+  approaches were discussed, and what changes were made.
+`)
+	if hasCollectorData(files) {
+		b.WriteString(`
+- comp-<type>-` + project + `.md, collect-<name>-` + project + `.txt, plugin-<name>-` + project + `.txt:
+  Data gathered by commands or plugins the developer configured,
+  AI-compressed (comp-) or raw (collect-, plugin-). The <type> or <name>
+  says what kind of data it is.
+`)
+	}
+
+	if hasPreviousSummaries(files) {
+		b.WriteString(`
+- summary-<date>.md: The summary of this project from an earlier day, given
+  for context only. Do not summarize it again. Where today's work continues
+  it, say so briefly (e.g. "continued the refactor of the parser"), so that
+  the summaries read as a continuing narrative.
+`)
+	}
+	if _, ok := files[sessionsFile]; ok {
+		b.WriteString(`
+- ` + sessionsFile + `: Work sessions the developer explicitly started and stopped on
+  this project, with their times and the reasons given. Where they are
+  present, use them for when the work happened and what it was for.
+`)
+	}
+	_, trackQuestions := files[openQuestionsFile]
+	if trackQuestions {
+		b.WriteString(`
+- ` + openQuestionsFile + `: Open questions and unfinished work reported by earlier
+  summaries of this project and not yet resolved, each with an ID like [q3].
+`)
+	}
+	_, trackDecisions := files[decisionsFile]
+	if trackDecisions {
+		b.WriteString(`
+- ` + decisionsFile + `: The most recent decisions recorded for this project on earlier
+  days, with their dates, for context only.
+`)
+	}
+
+	b.WriteString(`
+Not all sources may be present. Work with whatever is available.
+
+Task: Write a concise summary of the day's work on this project. The summary
+should allow someone to read it and immediately resume working on the project,
+even after a long absence.
+
+Guidelines:
+- Describe what was being worked on and why.
+- Explain the approaches tried, including dead ends and pivots. Explain what
+  went wrong and what eventually worked.
+- Summarize key code changes by functional impact, not just file names.
+- Identify unfinished work and open questions.
+- End with a line "Next steps:" followed by a bulleted list of concrete next
+  steps. Include the open TODO items from the notes, if any, unless the data
+  shows they were done.
+- Make decisions (#decision) and blockers (#blocked) prominent: state each
+  decision with its rationale, and each blocker with whether it was resolved.
+- Keep every pinned note (#pin) in the summary, as close to its original
+  wording as the prose allows: keep its specifics (names, numbers, quotes)
+  rather than folding it into a general statement.
+- Do NOT include timestamps in the summary.
+- Do NOT use headings. Write flowing prose, with bullet points where
+  appropriate for lists of items.
+- Write in first person.
+`)
+
+	if trackDecisions {
+		b.WriteString(`- After the next steps, add a line "Decisions:" followed by a bulleted list
+  of the decisions made during the day, one line each in the form
+  "<decision> — <rationale>", or "- None". Include decisions from the notes
+  (#decision) and decisions stated or agreed on in the Claude Code sessions,
+  such as choosing a library, a design, or an approach over another. Do not
+  repeat the earlier decisions in ` + decisionsFile + ` unless the day's work
+  revisits them; a reversed decision is a new decision.
+`)
+	}
+
+	if trackQuestions {
+		b.WriteString(`- After the next steps, add a line "Open questions:" followed by a bulleted
+  list of the questions left open and the work left unfinished at the end
+  of the day, one line each, or "- None". Leave out earlier open questions
+  that are still open; they are carried over separately.
+- End with a line "Resolved:" followed by the IDs of the earlier open
+  questions that the day's work answered or finished, comma-separated (e.g.
+  "Resolved: q3, q7"), or "Resolved: none".
+`)
+	}
+
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		fmt.Fprintf(&b, "\nAdditional guidelines for this project:\n%s\n", instructions)
+	}
+
+	b.WriteString(`
+Output only the summary text, nothing else.
+`)
+
+	return b.String()
+}
+
+// projectInstructions returns the project-specific guidelines for the
+// summary prompt: what the project's watch entry says about it, then its
+// prompt setting.
+func projectInstructions(cfg Config, state State, project string) string {
+	var lines []string
+	for _, w := range state.Watched {
+		if w.Name != project {
+			continue
+		}
+		if len(w.Tags) > 0 {
+			lines = append(lines, fmt.Sprintf("- The project is tagged %s; use the tags as context for what the work is.", strings.Join(w.Tags, ", ")))
+		}
+		if w.DefaultBranch != "" {
+			lines = append(lines, fmt.Sprintf("- The repo's default branch is %q; mention it when work happened on another branch.", w.DefaultBranch))
+		}
+	}
+	if prompt := strings.TrimSpace(cfg.Projects[project].Prompt); prompt != "" {
+		lines = append(lines, prompt)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func assembleCompPrompt(dataType string, files map[string]string) string {
+	var b strings.Builder
+
+	b.WriteString("You are summarizing data automatically logged during a software engineering\nsession.\n\nDescription of the data:\n\n")
+
+	switch dataType {
+	case "git":
+		b.WriteString("- Time-stamped snapshots of uncommitted code changes, taken every 5 minutes.\n" +
+			"  These show the evolution of the code over the day, including approaches that\n" +
+			"  were tried and abandoned. A hunk whose body is \"" + collapsedHunkNote + "\"\n" +
+			"  repeats a hunk shown in full in an earlier snapshot.\n")
+	case "term":
+		b.WriteString("- Terminal session recordings captured with tools like `script`. These show the\n" +
+			"  developer's terminal activity: commands run, test output, debugging sessions,\n" +
+			"  REPL interactions, etc. May contain ANSI escape codes which should be\n" +
+			"  ignored.\n")
+	case "claude":
+		b.WriteString("- Preprocessed transcripts of Claude Code sessions for the day, showing the\n" +
+			"  developer's interactions with an AI coding assistant. Contains user prompts,\n" +
+			"  assistant responses, and tool use summaries. This reveals what the developer\n" +
+			"  was trying to accomplish, what approaches were discussed, and what changes\n" +
+			"  were made through the AI assistant.\n")
+	default:
+		b.WriteString("- \"" + dataType + "\" data for the day from commands or plugins the developer\n" +
+			"  configured: the output of collector commands (collect-<name>-<project>.txt)\n" +
+			"  or the files a plugin provided (plugin-<name>-<project>.txt), which begin\n" +
+			"  with the plugin's description of the data. Work out what the data is from\n" +
+			"  its content, and keep what bears on the work done.\n")
+	}
+
+	b.WriteString("\nBelow is the raw data collected during the day.\n")
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n", name, files[name])
+	}
+
+	b.WriteString(`
+Task: Write a concise summary of the work done in the logs, such that someone
+could read the summary and have a complete understanding without reading the
+raw data at all. In other words, the summary should be a high fidelity
+compression of the raw data.
+
+Guidelines:
+- Describe what was being worked on and why.
+- Explain the approaches tried, including dead ends and pivots. Explain what
+  went wrong and what eventually worked.
+- Correlate summarized events by timestamp or timestamp range.
+
+Output only the summary text, nothing else.
+`)
+
+	return b.String()
+}
+
+// compCachePath returns the path of the compressed artifact for a data type.
+func compCachePath(cfg Config, dataType, project, date string) string {
+	return filepath.Join(resolveRawDir(cfg), date, "comp-"+dataType+"-"+project+".md")
+}
+
+// compFingerprint identifies how compressed artifacts of dataType are made:
+// by comp_cmd, which names the model, from the compression prompt without
+// the data.
+func compFingerprint(cfg Config, dataType string) string {
+	sum := sha256.Sum256([]byte(cfg.CompCmd + "\x00" + assembleCompPrompt(dataType, nil)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// compFingerprintPath returns the file recording the fingerprint of the
+// compressed artifact at outPath.
+func compFingerprintPath(outPath string) string {
+	return outPath + ".fingerprint"
+}
+
+// compCacheFresh reports whether the compressed artifact at outPath exists,
+// was made with fingerprint, and is newer than all of its source files. An
+// artifact without a recorded fingerprint is stale.
+func compCacheFresh(outPath, fingerprint string, sourcePaths []string) bool {
+	outInfo, err := os.Stat(outPath)
+	if err != nil {
+		return false
+	}
+	if fp, err := os.ReadFile(compFingerprintPath(outPath)); err != nil || strings.TrimSpace(string(fp)) != fingerprint {
+		return false
+	}
+	outMtime := outInfo.ModTime()
+	for _, sp := range sourcePaths {
+		if info, err := os.Stat(sp); err == nil {
+			if info.ModTime().After(outMtime) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compressData(ctx context.Context, cfg Config, dataType, project, date string, files map[string]string, sourcePaths []string, p *genProgress) (string, error) {
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	outPath := compCachePath(cfg, dataType, project, date)
+	fingerprint := compFingerprint(cfg, dataType)
+
+	// Staleness check: if output exists, was made the same way, and is newer
+	// than all sources, use cache
+	if compCacheFresh(outPath, fingerprint, sourcePaths) {
+		data, err := os.ReadFile(outPath)
+		if err != nil {
+			return "", err
+		}
+		p.printf("using cached %s data for %s", dataType, project)
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	counts := applyTokenBudget(files, cfg.TokenBudget)
+	warnTruncated(dataType+" data for "+project, counts, cfg.TokenBudget)
+
+	prompt := assembleCompPrompt(dataType, files)
+
+	p.printf("compressing %s data for %s (%s)…", dataType, project, formatSize(len(prompt)))
+	start := time.Now()
+	defer func() { p.record(project, "compress "+dataType, time.Since(start)) }()
+
+	result, err := runPromptCmd(ctx, "comp_cmd", cfg.CompCmd, prompt, timeoutMinutes(cfg.CompTimeout))
+	if err != nil {
+		return "", err
+	}
+
+	// The cache is renamed into place, so that a generation stopped while
+	// writing it leaves the old one or none; it is only fresh once its
+	// fingerprint is written after it.
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return "", fmt.Errorf("creating comp dir: %w", err)
+	}
+	tmp := outPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(result), 0o644); err != nil {
+		return "", fmt.Errorf("writing comp file: %w", err)
+	}
+	if err := os.Rename(tmp, outPath); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("writing comp file: %w", err)
+	}
+	if err := os.WriteFile(compFingerprintPath(outPath), []byte(fingerprint+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("writing comp file: %w", err)
+	}
+
+	return result, nil
+}
+
+// bulkSource is the raw input for one compressible data type of a project.
+type bulkSource struct {
+	dataType    string // "git", "term", "claude", a collector's data_type, or a plugin name
+	files       map[string]string
+	sourcePaths []string
+}
+
+// collectBulkSources reads the raw git, terminal, Claude Code, collector, and
+// cached plugin data for a project. Data types with no source files are
+// omitted.
+func collectBulkSources(cfg Config, state State, project, date string) []bulkSource {
+	var sources []bulkSource
+
+	gitPath := resolveGitPath(cfg, date, project)
+	if data, err := readGitLog(gitPath); err == nil {
+		sources = append(sources, bulkSource{
+			dataType:    "git",
+			files:       map[string]string{filepath.Base(gitPath): data},
+			sourcePaths: []string{gitPath},
+		})
+	}
+
+	termPattern := resolveTermGlob(cfg, date, project)
+	if matches, err := filepath.Glob(termPattern); err == nil && len(matches) > 0 {
+		termFiles := make(map[string]string)
+		var termSourcePaths []string
+		for _, m := range matches {
+			if data, err := os.ReadFile(m); err == nil {
+				termFiles[filepath.Base(m)] = string(data)
+				termSourcePaths = append(termSourcePaths, m)
+			}
+		}
+		if len(termFiles) > 0 {
+			sources = append(sources, bulkSource{
+				dataType:    "term",
+				files:       termFiles,
+				sourcePaths: termSourcePaths,
+			})
+		}
+	}
+
+	claudeDir := resolveClaudeCodeDir(cfg)
+	if claudeDir != "" {
+		for _, w := range state.Watched {
+			if w.Name == project {
+				ccSources := claudeSourcesFor(claudeDir, w)
+				if transcript, err := preprocessClaudeCodeSessions(ccSources, date, time.Now().Location()); err == nil && transcript != "" {
+					// JSONL source files for the staleness check
+					sources = append(sources, bulkSource{
+						dataType:    "claude",
+						files:       map[string]string{"claude-code-sessions.txt": transcript},
+						sourcePaths: claudeSourceFiles(ccSources),
+					})
+				}
+				break
+			}
+		}
+	}
+
+	sources = append(sources, collectorSources(cfg, project, date)...)
+	return append(sources, pluginSources(cfg, project, date)...)
+}
+
+// collectProjectNotes returns the notes entries for a project, or the
+// unaffiliated entries for the "general" pseudo-project.
+func collectProjectNotes(cfg Config, project, date string) string {
+	notes := readGenNotes(cfg, date)
+	if project == "general" {
+		return filterUnaffiliatedNotes(cfg.noteHeadings(), notes)
+	}
+	return filterNotesForProject(cfg.noteHeadings(), notes, project)
+}
+
+func generateProjectSummary(ctx context.Context, cfg Config, state State, project, date string, p *genProgress) (summary string, meta projectMeta, err error) {
+	files := make(map[string]string)
+	refreshPlugins(cfg, project, date)
+
+	// Collect and compress bulk data, recording the sources as they are
+	// before compressing takes its time
+	sources := collectBulkSources(cfg, state, project, date)
+	meta = projectMeta{Project: project, Sources: bulkSourceMeta(cfg, sources)}
+	for _, src := range sources {
+		compressed, err := compressData(ctx, cfg, src.dataType, project, date, src.files, src.sourcePaths, p)
+		if err != nil {
+			return "", meta, fmt.Errorf("compressing %s data: %w", src.dataType, err)
+		}
+		if compressed != "" {
+			files["comp-"+src.dataType+"-"+project+".md"] = compressed
+		}
+	}
+
+	// Notes are included as-is (no compression)
+	notes := collectProjectNotes(cfg, project, date)
+	if notes != "" {
+		files["notes.md"] = notes
+	}
+	meta.Notes = notesHash(notes)
+
+	if len(files) == 0 {
+		return "", meta, nil
+	}
+	for name, content := range previousProjectSummaries(cfg, project, date, cfg.ContextDays) {
+		files[name] = content
+	}
+	if sessions := formatSessions(projectSessions(cfg, date, project, time.Now())); sessions != "" {
+		files[sessionsFile] = sessions
+	}
+	var questions questionLog
+	if cfg.TrackQuestions {
+		if questions, err = loadQuestions(project); err != nil {
+			return "", meta, err
+		}
+		files[openQuestionsFile] = formatOpenQuestions(questions.openBefore(date))
+	}
+	var decisions decisionLog
+	if cfg.TrackDecisions {
+		if decisions, err = loadDecisions(cfg, project); err != nil {
+			return "", meta, err
+		}
+		files[decisionsFile] = formatEarlierDecisions(decisions, date, decisionContextLimit)
+	}
+
+	counts := applyTokenBudget(files, cfg.TokenBudget)
+	warnTruncated("summary prompt for "+project, counts, cfg.TokenBudget)
+
+	prompt := assemblePrompt(cfg.noteHeadings(), project, date, files, projectTimeline(cfg, state, date, project), projectInstructions(cfg, state, project))
+
+	p.printf("summarizing %s (~%d tokens)…", project, estimateTokens(prompt))
+	start := time.Now()
+	defer func() { p.record(project, "summarize", time.Since(start)) }()
+
+	meta.Generated, meta.PromptTokens = time.Now(), estimateTokens(prompt)
+	summary, meta.GenCmd, err = runSummarizer(ctx, cfg, project, prompt, p)
+	if err != nil {
+		return "", meta, err
+	}
+	if cfg.TrackQuestions {
+		var opened []string
+		var resolved []int
+		summary, opened, resolved = parseQuestionTrailer(summary)
+		questions.apply(date, opened, resolved)
+		if err := saveQuestions(project, questions); err != nil {
+			return "", meta, err
+		}
+	}
+	if cfg.TrackDecisions {
+		var items []string
+		summary, items = parseDecisionTrailer(summary)
+		decisions.set(date, items)
+		if err := saveDecisions(cfg, decisions); err != nil {
+			return "", meta, err
+		}
+	}
+	if cfg.NormalizeSummary {
+		summary = normalizeSummary(cfg, summary)
+	}
+	meta.SummaryTokens = estimateTokens(summary)
+	meta.Cost = estimateCost(cfg, meta)
+	return summary, meta, nil
+}
+
+// compressedOrRaw returns the cached compressed artifact of src if it is
+// fresh, as compressData would reuse it, and otherwise src's raw files.
+func compressedOrRaw(cfg Config, project, date string, src bulkSource) map[string]string {
+	outPath := compCachePath(cfg, src.dataType, project, date)
+	if compCacheFresh(outPath, compFingerprint(cfg, src.dataType), src.sourcePaths) {
+		if data, err := os.ReadFile(outPath); err == nil {
+			return map[string]string{filepath.Base(outPath): strings.TrimSpace(string(data))}
+		}
+	}
+	return src.files
+}
+
+// commandWaitDelay is how long a command killed for running past its
+// timeout has to exit, and its children to let go of its output, before
+// devlog stops waiting for it.
+const commandWaitDelay = 5 * time.Second
+
+// timeoutMinutes returns the duration of a timeout setting of n minutes.
+func timeoutMinutes(n int) time.Duration {
+	return time.Duration(n) * time.Minute
+}
+
+// commandContext returns the context a command with timeout runs in: one
+// that ends with ctx, or after timeout unless timeout is 0.
+func commandContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError is the error of program killed for running past timeout.
+func timeoutError(program string, timeout time.Duration) error {
+	return fmt.Errorf("%s timed out after %s and was stopped", program, formatTimeout(timeout))
+}
+
+// formatTimeout formats a timeout setting for messages, e.g. "15 minutes".
+func formatTimeout(d time.Duration) string {
+	if d >= time.Minute && d%time.Minute == 0 {
+		return plural(int(d/time.Minute), "minute")
+	}
+	return d.String()
+}
+
+// runPromptCmd runs command, the value of the config setting named setting,
+// with prompt on stdin and returns its trimmed output. The command is killed
+// if it runs longer than timeout, unless timeout is 0, or when ctx is
+// cancelled, which fails with errGenCancelled.
+func runPromptCmd(ctx context.Context, setting, command, prompt string, timeout time.Duration) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("%s is empty", setting)
+	}
+
+	ctx, cancel := commandContext(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.WaitDelay = commandWaitDelay
+	cmd.Stdin = strings.NewReader(prompt)
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return "", errGenCancelled
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", timeoutError(args[0], timeout)
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("%s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("running %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runGenCmd runs the commands of gen_cmd on prompt in order until one
+// succeeds, and returns its output and the command that produced it. A
+// failing command is reported as a warning when there is another to try.
+// The output is streamed to stream as it is written, if it is not nil. No
+// fallback is tried once ctx is cancelled.
+func runGenCmd(ctx context.Context, cfg Config, prompt string, stream io.Writer) (out, used string, err error) {
+	cmds := genCmds(cfg)
+	for i, command := range cmds {
+		if out, err = runGenPromptCmd(ctx, command, prompt, stream, timeoutMinutes(cfg.GenTimeout)); err == nil {
+			return out, command, nil
+		}
+		if errors.Is(err, errGenCancelled) {
+			return "", "", err
+		}
+		if i < len(cmds)-1 {
+			fmt.Fprintf(os.Stderr, "Warning: %v; falling back to %q\n", err, cmds[i+1])
+		}
+	}
+	return "", "", err
+}
+
+func discoverAllProjects(cfg Config, state State, date string) []string {
+	projects := discoverProjects(cfg, date)
+	seen := make(map[string]bool)
+	for _, p := range projects {
+		seen[p] = true
+	}
+
+	claudeDir := resolveClaudeCodeDir(cfg)
+	if claudeDir != "" {
+		loc := time.Now().Location()
+		for _, w := range state.Watched {
+			if seen[w.Name] {
+				continue
+			}
+			if hasEntriesOnDate(claudeSourcesFor(claudeDir, w), date, loc) {
+				projects = append(projects, w.Name)
+				seen[w.Name] = true
+			}
+		}
+		sort.Strings(projects)
+	}
+
+	if len(cfg.Plugins) > 0 {
+		for _, p := range pluginProjects(cfg, date) {
+			if !seen[p] {
+				projects = append(projects, p)
+				seen[p] = true
+			}
+		}
+		sort.Strings(projects)
+	}
+
+	return projects
+}
+
+// contextLookback is how many days before a date previousProjectSummaries
+// searches, so that context survives weekends and holidays but not long
+// breaks.
+const contextLookback = 14
+
+// previousProjectSummaries returns project's sections of the n most recent
+// summaries before date, within contextLookback days, keyed
+// "summary-<date>.md".
+func previousProjectSummaries(cfg Config, project, date string, n int) map[string]string {
+	files := make(map[string]string)
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil || n <= 0 {
+		return files
+	}
+	for i := 1; i <= contextLookback && len(files) < n; i++ {
+		prev := d.AddDate(0, 0, -i).Format("2006-01-02")
+		content, err := readSummary(cfg, prev)
+		if err != nil {
+			continue
+		}
+		for _, s := range selectSummarySections(content, []string{project}) {
+			files["summary-"+prev+".md"] = s.Text
+		}
+	}
+	return files
+}
+
+func hasPreviousSummaries(files map[string]string) bool {
+	for name := range files {
+		if strings.HasPrefix(name, "summary-") {
+			return true
+		}
+	}
+	return false
+}
+
+// summaryPath returns the path of the generated summary for date.
+func summaryPath(cfg Config, date string) string {
+	return filepath.Join(resolveLogDir(cfg), date+".md")
+}
+
+// readSummary returns the generated summary for date, without its
+// frontmatter.
+func readSummary(cfg Config, date string) (string, error) {
+	data, err := os.ReadFile(summaryPath(cfg, date))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no summary for %s; run devlog gen %s first", date, date)
+		}
+		return "", fmt.Errorf("reading summary: %w", err)
+	}
+	return stripSummaryMeta(string(data)), nil
+}
+
+// latestSummaryPath returns the most recent generated summary, or "" if
+// there is none.
+func latestSummaryPath(cfg Config) string {
+	entries, err := os.ReadDir(resolveLogDir(cfg))
+	if err != nil {
+		return ""
+	}
+	// ReadDir sorts by name, so the last dated file is the latest.
+	for i := len(entries) - 1; i >= 0; i-- {
+		name := entries[i].Name()
+		if date := strings.TrimSuffix(name, ".md"); date != name && isValidDate(date) {
+			return filepath.Join(resolveLogDir(cfg), name)
+		}
+	}
+	return ""
+}
+
+// runGen generates the summary for date and returns the number of projects
+// summarized, which is 0 if there was nothing to do. Progress lines and a
+// timing breakdown are written through p, which may be nil. Cancelling ctx
+// stops the run with errGenCancelled, leaving the summary as it was.
+func runGen(ctx context.Context, cfg Config, state State, date string, p *genProgress) (int, error) {
+	return runGenProjects(ctx, cfg, state, date, nil, p)
+}
+
+// runGenProjects is runGen limited to the projects in only, if it is not
+// empty. Their sections of an existing summary are replaced, and the other
+// sections are kept. Without only, a stale summary's sections whose data has
+// not changed (see sectionUpToDate) are kept too, and only the others are
+// regenerated.
+func runGenProjects(ctx context.Context, cfg Config, state State, date string, only []string, p *genProgress) (int, error) {
+	logDir := resolveLogDir(cfg)
+
+	// Discover projects from raw data and Claude Code sessions
+	projects := withoutExcluded(cfg, state, discoverAllProjects(cfg, state, date))
+	if len(projects) == 0 {
+		fmt.Fprintf(os.Stderr, "No raw data for %s\n", date)
+		return 0, nil
+	}
+
+	// Staleness check
+	summaryPath := filepath.Join(logDir, date+".md")
+	var kept []summarySection
+	var keptMeta summaryMeta
+	var keptBody string
+	var keptAt time.Time
+	incremental := false
+	if m, body, at, err := readSummaryFile(date, summaryPath); err == nil {
+		if len(only) == 0 && summaryUpToDate(cfg, state, date, summaryPath) {
+			fmt.Println("Summary is up to date, no new data since last generation")
+			return 0, nil
+		}
+		keptMeta, keptBody, keptAt = m, body, at
+		kept = splitSummary(keptBody)
+		incremental = len(only) == 0
+	}
+	previous := make(map[string]string)
+	for _, s := range kept {
+		previous[s.Project] = s.Text
+	}
+
+	if err := checkGenCommands(cfg); err != nil {
+		return 0, err
+	}
+
+	// Generate summary for each project
+	var summaries []summarySection
+	var metas []projectMeta
+
+	// Unaffiliated notes → "general" pseudo-project
+	if hasUnaffiliatedNotes(cfg, date) && !genExcluded(cfg, state, "general") {
+		projects = append(projects, "general")
+	}
+	projects, err := selectProjects(projects, only, date)
+	if err != nil {
+		return 0, err
+	}
+	runCollectors(cfg, state, date, projects, p)
+
+	// A full run of a stale summary regenerates only the sections whose
+	// data changed; the others are carried over in the new order.
+	upToDate := make(map[string]summarySection)
+	if incremental {
+		for _, s := range kept {
+			if containsString(projects, s.Project) && sectionUpToDate(cfg, state, keptMeta, s.Project, date) {
+				upToDate[s.Project] = s
+			}
+		}
+		kept = nil
+		if len(upToDate) == len(projects) {
+			fmt.Println("Summary is up to date, no new data since last generation")
+			return 0, nil
+		}
+	}
+
+	generated := 0
+	for i, proj := range projects {
+		if ctx.Err() != nil {
+			return 0, errGenCancelled
+		}
+		if s, ok := upToDate[proj]; ok {
+			p.printf("keeping %s: no new data", proj)
+			pm, _ := keptMeta.project(proj)
+			summaries = append(summaries, s)
+			metas = append(metas, pm)
+			continue
+		}
+		p.printf("summarizing project %d/%d: %s", i+1, len(projects), proj)
+		var summary string
+		var meta projectMeta
+		updated := false
+		if old, ok := previous[proj]; ok && incremental && canUpdateSummaries(cfg) {
+			if summary, meta, updated, err = updateProjectSummary(ctx, cfg, state, proj, date, old, keptAt, p); err != nil {
+				return 0, fmt.Errorf("updating summary for %s: %w", proj, err)
+			}
+		}
+		if !updated {
+			if summary, meta, err = generateProjectSummary(ctx, cfg, state, proj, date, p); err != nil {
+				return 0, fmt.Errorf("generating summary for %s: %w", proj, err)
+			}
+		}
+		if summary == "" {
+			continue
+		}
+		metas = append(metas, meta)
+		if cfg.SummaryStats {
+			if footer := diffFooter(collectDayDiff(cfg, state, date, proj)); footer != "" {
+				summary += "\n\n" + footer
+			}
+		}
+		if old, ok := previous[proj]; ok && sameSummaryText(old, summary) {
+			// Keep the old text and when it was generated, so that an
+			// unchanged section does not show up as a change.
+			p.printf("keeping %s: summary unchanged", proj)
+			if pm, ok := keptMeta.project(proj); ok {
+				pm.Sources, pm.Notes = meta.Sources, meta.Notes
+				metas[len(metas)-1] = pm
+			}
+			summaries = append(summaries, summarySection{Project: proj, Text: old})
+			continue
+		}
+		summaries = append(summaries, summarySection{Project: proj, Text: summary})
+		generated++
+	}
+
+	if len(summaries) == 0 {
+		fmt.Fprintf(os.Stderr, "No raw data for %s\n", date)
+		return 0, nil
+	}
+
+	// Assemble output
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s\n", date)
+	if header := workdayHeader(cfg, state, date); header != "" {
+		fmt.Fprintf(&out, "\n%s\n", header)
+	}
+	if cfg.SummaryFocus {
+		if header := focusHeader(cfg, state, date); header != "" {
+			fmt.Fprintf(&out, "\n%s\n", header)
+		}
+	}
+	// Sections kept by -p keep their metadata.
+	meta := summaryMeta{Generated: time.Now(), CompCmd: cfg.CompCmd}
+	regenerated := summaryMeta{Projects: metas}
+	for _, s := range mergeSummarySections(kept, summaries) {
+		fmt.Fprintf(&out, "\n## %s\n\n%s\n", s.Project, s.Text)
+		pm, ok := regenerated.project(s.Project)
+		if !ok {
+			pm, ok = keptMeta.project(s.Project)
+		}
+		if ok {
+			meta.Projects = append(meta.Projects, pm)
+		}
+	}
+
+	// A summary whose sections all came out the same is left alone. The
+	// hashes of its new sources go in a file of their own, so that its
+	// sections count as up to date.
+	if keptBody != "" && out.String() == keptBody {
+		meta.Generated = keptMeta.Generated
+		if err := saveKeptMeta(date, meta, keptBody); err != nil {
+			return 0, err
+		}
+		p.printSummary()
+		fmt.Printf("Summary unchanged, kept %s\n", summaryPath)
+		return 0, nil
+	}
+
+	// The Logseq page goes first, so that the summary is newer than it.
+	if cfg.LogseqDir != "" {
+		if err := writeLogseqPage(cfg, date, out.String()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Write output atomically
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return 0, fmt.Errorf("creating log dir: %w", err)
+	}
+	tmp := summaryPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(formatSummaryMeta(meta)+out.String()), 0o644); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("writing summary: %w", err)
+	}
+	if err := os.Rename(tmp, summaryPath); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("writing summary: %w", err)
+	}
+	os.Remove(keptMetaPath(date))
+
+	p.printSummary()
+	fmt.Printf("Summary written to %s\n", summaryPath)
+	return generated, nil
+}
+
+// checkGenCommands checks that a summarizer (one of the gen_cmd commands)
+// and the compressor are on $PATH.
+func checkGenCommands(cfg Config) error {
+	var missing error
+	available := false
+	for _, command := range genCmds(cfg) {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			missing = fmt.Errorf("gen_cmd is empty")
+		} else if _, err := exec.LookPath(args[0]); err != nil {
+			missing = fmt.Errorf("summarizer command %q not found on $PATH", args[0])
+		} else {
+			available = true
+		}
+	}
+	if !available {
+		return missing
+	}
+
+	compArgs := strings.Fields(cfg.CompCmd)
+	if len(compArgs) == 0 {
+		return fmt.Errorf("comp_cmd is empty")
+	}
+	if _, err := exec.LookPath(compArgs[0]); err != nil {
+		return fmt.Errorf("compressor command %q not found on $PATH", compArgs[0])
+	}
+	return nil
+}
+
+// selectProjects returns the projects in only, in the order of projects, or
+// all of projects if only is empty. It fails if a project in only is not
+// among projects.
+func selectProjects(projects, only []string, date string) ([]string, error) {
+	if len(only) == 0 {
+		return projects, nil
+	}
+	for _, name := range only {
+		if !containsString(projects, name) {
+			if suggestion, ok := closestProject(name, projects); ok {
+				return nil, fmt.Errorf("no data to summarize for %s on %s; did you mean %s?", name, date, suggestion)
+			}
+			return nil, fmt.Errorf("no data to summarize for %s on %s", name, date)
+		}
+	}
+	var selected []string
+	for _, name := range projects {
+		if containsString(only, name) {
+			selected = append(selected, name)
+		}
+	}
+	return selected, nil
+}
+
+// sameSummaryText reports whether two summaries of a section are effectively
+// identical: the same words, whatever the whitespace between them.
+func sameSummaryText(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
+
+// mergeSummarySections replaces the sections of existing that have a new
+// summary in updated, keeping their place, and adds the other updated
+// sections at the end.
+func mergeSummarySections(existing, updated []summarySection) []summarySection {
+	merged := append([]summarySection(nil), existing...)
+	for _, u := range updated {
+		replaced := false
+		for i := range merged {
+			if merged[i].Project == u.Project {
+				merged[i] = u
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, u)
+		}
+	}
+	return merged
+}
+
+// runGenDryRun reports what runGen would do for date without invoking any AI
+// commands: the projects to be summarized, which compressed artifacts are
+// fresh or stale, the estimated prompt sizes, and the commands to be run.
+func runGenDryRun(cfg Config, state State, date string, only []string) error {
+	projects := discoverAllProjects(cfg, state, date)
+	if hasUnaffiliatedNotes(cfg, date) {
+		projects = append(projects, "general")
+	}
+	projects, err := selectProjects(withoutExcluded(cfg, state, projects), only, date)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		fmt.Fprintf(os.Stderr, "No raw data for %s\n", date)
+		return nil
+	}
+
+	fmt.Printf("Dry run for %s; no AI commands will be run.\n\n", date)
+
+	// Sections are kept, updated, or regenerated as runGenProjects would.
+	summaryPath := filepath.Join(resolveLogDir(cfg), date+".md")
+	keptMeta, keptBody, keptAt, err := readSummaryFile(date, summaryPath)
+	previous := make(map[string]string)
+	incremental := false
+	if err != nil {
+		fmt.Printf("Summary: %s (would be created)\n", summaryPath)
+	} else {
+		for _, s := range splitSummary(keptBody) {
+			previous[s.Project] = s.Text
+		}
+		incremental = len(only) == 0
+		if incremental && summaryUpToDate(cfg, state, date, summaryPath) {
+			fmt.Printf("Summary: %s (up to date; gen would exit without regenerating)\n", summaryPath)
+		} else {
+			fmt.Printf("Summary: %s (stale; would be regenerated)\n", summaryPath)
+		}
+	}
+	fmt.Printf("Compressor: %s\n", describeCommand(cfg.CompCmd))
+	for i, command := range genCmds(cfg) {
+		if i == 0 {
+			fmt.Printf("Summarizer: %s\n", describeCommand(command))
+		} else {
+			fmt.Printf("Summarizer fallback %d: %s\n", i, describeCommand(command))
+		}
+	}
+
+	for _, proj := range projects {
+		fmt.Printf("\n%s:\n", proj)
+
+		old, hasOld := previous[proj]
+		if hasOld && incremental && sectionUpToDate(cfg, state, keptMeta, proj, date) {
+			fmt.Println("  summary: no new data, would be kept")
+			continue
+		}
+		if hasOld && incremental && canUpdateSummaries(cfg) {
+			if files := updateActivity(cfg, state, proj, date, keptAt, time.Now()); files != nil {
+				names := make([]string, 0, len(files))
+				for name := range files {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					fmt.Printf("  %s: ~%d tokens since %s\n", name, estimateTokens(files[name]), keptAt.Format("15:04"))
+				}
+				counts := applyTokenBudget(files, cfg.TokenBudget)
+				prompt := assembleUpdatePrompt(proj, date, stripDiffFooter(old), files, keptAt, projectInstructions(cfg, state, proj))
+				fmt.Printf("  summary: would update the section with a ~%d token prompt%s\n", estimateTokens(prompt), truncationNote(counts))
+				continue
+			}
+		}
+
+		files := make(map[string]string)
+		pending := 0
+		for _, src := range collectBulkSources(cfg, state, proj, date) {
+			outPath := compCachePath(cfg, src.dataType, proj, date)
+			name := filepath.Base(outPath)
+			if compCacheFresh(outPath, compFingerprint(cfg, src.dataType), src.sourcePaths) {
+				if data, err := os.ReadFile(outPath); err == nil {
+					files[name] = strings.TrimSpace(string(data))
+				}
+				fmt.Printf("  %s: fresh, cached (~%d tokens)\n", name, estimateTokens(files[name]))
+				continue
+			}
+			counts := applyTokenBudget(src.files, cfg.TokenBudget)
+			prompt := assembleCompPrompt(src.dataType, src.files)
+			fmt.Printf("  %s: stale, would compress a ~%d token prompt%s\n",
+				name, estimateTokens(prompt), truncationNote(counts))
+			pending++
+		}
+
+		if notes := collectProjectNotes(cfg, proj, date); notes != "" {
+			files["notes.md"] = notes
+			fmt.Printf("  notes.md: ~%d tokens\n", estimateTokens(notes))
+		}
+
+		if len(files) == 0 && pending == 0 {
+			fmt.Println("  no data, would be skipped")
+			continue
+		}
+		for name, content := range previousProjectSummaries(cfg, proj, date, cfg.ContextDays) {
+			files[name] = content
+			fmt.Printf("  %s: context, ~%d tokens\n", name, estimateTokens(content))
+		}
+		if sessions := projectSessions(cfg, date, proj, time.Now()); len(sessions) > 0 {
+			files[sessionsFile] = formatSessions(sessions)
+			fmt.Printf("  %s: %d %s\n", sessionsFile, len(sessions), plural(len(sessions), "session"))
+		}
+		if cfg.TrackQuestions {
+			questions, _ := loadQuestions(proj)
+			open := questions.openBefore(date)
+			files[openQuestionsFile] = formatOpenQuestions(open)
+			fmt.Printf("  %s: %d carried over\n", openQuestionsFile, len(open))
+		}
+		if cfg.TrackDecisions {
+			decisions, _ := loadDecisions(cfg, proj)
+			files[decisionsFile] = formatEarlierDecisions(decisions, date, decisionContextLimit)
+			fmt.Printf("  %s: ~%d tokens\n", decisionsFile, estimateTokens(files[decisionsFile]))
+		}
+
+		counts := applyTokenBudget(files, cfg.TokenBudget)
+		prompt := assemblePrompt(cfg.noteHeadings(), proj, date, files, projectTimeline(cfg, state, date, proj), projectInstructions(cfg, state, proj))
+		verb := "summarize"
+		if hasOld {
+			verb = "regenerate the section from"
+		}
+		fmt.Printf("  summary: would %s a ~%d token prompt%s", verb, estimateTokens(prompt), truncationNote(counts))
+		if pending > 0 {
+			fmt.Printf(", plus the output of %d pending compression(s)", pending)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// describeCommand formats a configured command for display, noting when its
+// executable cannot be found.
+func describeCommand(command string) string {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "(empty)"
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return command + " (not found on $PATH)"
+	}
+	return command
+}
+
+func truncationNote(counts []sourceTokens) string {
+	for _, c := range counts {
+		if c.Truncated {
+			return " (truncated to fit token budget)"
+		}
+	}
+	return ""
+}
+
+// hasUnaffiliatedNotes reports whether the notes for date contain any
+// entries without a project hashtag.
+func hasUnaffiliatedNotes(cfg Config, date string) bool {
+	return filterUnaffiliatedNotes(cfg.noteHeadings(), readGenNotes(cfg, date)) != ""
+}
+
+func runGenPrompt(cfg Config, state State, date string) error {
+	projects := discoverAllProjects(cfg, state, date)
+
+	// Check for unaffiliated notes → "general" pseudo-project
+	notesData := readGenNotes(cfg, date)
+	hasGeneral := filterUnaffiliatedNotes(cfg.noteHeadings(), notesData) != ""
+
+	if len(projects) == 0 && !hasGeneral {
+		fmt.Fprintf(os.Stderr, "No raw data for %s\n", date)
+		return nil
+	}
+
+	allProjects := make([]string, len(projects))
+	copy(allProjects, projects)
+	if hasGeneral {
+		allProjects = append(allProjects, "general")
+	}
+	allProjects = withoutExcluded(cfg, state, allProjects)
+
+	multi := len(allProjects) > 1
+
+	for i, proj := range allProjects {
+		files := make(map[string]string)
+
+		// Reuse the compressed artifacts gen would reuse; data without a
+		// fresh one goes in raw.
+		for _, src := range collectBulkSources(cfg, state, proj, date) {
+			for name, content := range compressedOrRaw(cfg, proj, date, src) {
+				files[name] = content
+			}
+		}
+
+		if notesData != "" {
+			var filtered string
+			if proj == "general" {
+				filtered = filterUnaffiliatedNotes(cfg.noteHeadings(), notesData)
+			} else {
+				filtered = filterNotesForProject(cfg.noteHeadings(), notesData, proj)
+			}
+			if filtered != "" {
+				files["notes.md"] = filtered
+			}
+		}
+
+		if len(files) == 0 {
+			continue
+		}
+		for name, content := range previousProjectSummaries(cfg, proj, date, cfg.ContextDays) {
+			files[name] = content
+		}
+		if sessions := formatSessions(projectSessions(cfg, date, proj, time.Now())); sessions != "" {
+			files[sessionsFile] = sessions
+		}
+		if cfg.TrackQuestions {
+			questions, _ := loadQuestions(proj)
+			files[openQuestionsFile] = formatOpenQuestions(questions.openBefore(date))
+		}
+		if cfg.TrackDecisions {
+			decisions, _ := loadDecisions(cfg, proj)
+			files[decisionsFile] = formatEarlierDecisions(decisions, date, decisionContextLimit)
+		}
+
+		counts := applyTokenBudget(files, cfg.TokenBudget)
+		warnTruncated("summary prompt for "+proj, counts, cfg.TokenBudget)
+
+		if multi {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("=== %s ===\n", proj)
+		}
+
+		fmt.Print(assemblePrompt(cfg.noteHeadings(), proj, date, files, projectTimeline(cfg, state, date, proj), projectInstructions(cfg, state, proj)))
+	}
+
+	return nil
+}
+
+// summaryUpToDate reports whether the summary at summaryPath exists and was
+// generated, or last found unchanged (see saveKeptMeta), after all raw data
+// for the date.
+func summaryUpToDate(cfg Config, state State, date, summaryPath string) bool {
+	_, _, at, err := readSummaryFile(date, summaryPath)
+	if err != nil {
+		return false
+	}
+	maxRawMtime := collectRawFileMtime(cfg, state, date)
+	return !maxRawMtime.IsZero() && at.After(maxRawMtime)
+}
+
+func collectRawFileMtime(cfg Config, state State, date string) time.Time {
+	rawDir := resolveRawDir(cfg)
+	var maxMtime time.Time
+
+	gitTmpl := cfg.GitPath
+	if gitTmpl == "" {
+		gitTmpl = defaultGitPath
+	}
+	for _, path := range globForTemplate(gitTmpl, rawDir, date) {
+		if info, err := os.Stat(path); err == nil {
+			if info.ModTime().After(maxMtime) {
+				maxMtime = info.ModTime()
+			}
+		}
+	}
+
+	for _, pg := range projectGitPaths(cfg, date) {
+		if info, err := os.Stat(pg.path); err == nil {
+			if info.ModTime().After(maxMtime) {
+				maxMtime = info.ModTime()
+			}
+		}
+	}
+
+	for _, path := range genNotesPaths(cfg, date) {
+		if info, err := os.Stat(path); err == nil {
+			if info.ModTime().After(maxMtime) {
+				maxMtime = info.ModTime()
+			}
+		}
+	}
+
+	termTmpl := cfg.TermPath
+	if termTmpl == "" {
+		termTmpl = defaultTermPath
+	}
+	for _, path := range globForTemplate(termTmpl, rawDir, date) {
+		if info, err := os.Stat(path); err == nil {
+			if info.ModTime().After(maxMtime) {
+				maxMtime = info.ModTime()
+			}
+		}
+	}
+
+	// Check Claude Code JSONL mtimes
+	claudeDir := resolveClaudeCodeDir(cfg)
+	if claudeDir != "" {
+		for _, w := range state.Watched {
+			for _, m := range claudeSourceFiles(claudeSourcesFor(claudeDir, w)) {
+				if info, err := os.Stat(m); err == nil {
+					if info.ModTime().After(maxMtime) {
+						maxMtime = info.ModTime()
+					}
+				}
+			}
+		}
+	}
+
+	if t := pluginsMtime(cfg, date); t.After(maxMtime) {
+		maxMtime = t
+	}
+
+	return maxMtime
+}
+
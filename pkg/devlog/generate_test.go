@@ -0,0 +1,1341 @@
+package devlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssemblePrompt(t *testing.T) {
+	files := map[string]string{
+		"comp-git-myproject.md": "Compressed git summary\n",
+		"notes.md":              "### At 10:20 #myproject\nStarted work\n",
+	}
+
+	prompt := assemblePrompt(nil, "myproject", "2024-01-15", files, "", "")
+
+	// Check project name
+	if !strings.Contains(prompt, `"myproject"`) {
+		t.Error("prompt should contain project name")
+	}
+
+	// Check date
+	if !strings.Contains(prompt, "2024-01-15") {
+		t.Error("prompt should contain date")
+	}
+
+	// Check file sections
+	if !strings.Contains(prompt, "--- comp-git-myproject.md ---") {
+		t.Error("prompt should contain compressed git section")
+	}
+	if !strings.Contains(prompt, "--- notes.md ---") {
+		t.Error("prompt should contain notes section")
+	}
+	if !strings.Contains(prompt, "Compressed git summary") {
+		t.Error("prompt should contain file contents")
+	}
+
+	// Check updated descriptions
+	if !strings.Contains(prompt, "comp-git-myproject.md: AI-compressed summary of time-stamped snapshots") {
+		t.Error("prompt should contain compressed git description")
+	}
+	if !strings.Contains(prompt, "Below is the data collected") {
+		t.Error("prompt should use updated preamble")
+	}
+	if !strings.Contains(prompt, `"Attachment: <filename>"`) {
+		t.Error("prompt should describe note attachments")
+	}
+	if !strings.Contains(prompt, `End with a line "Next steps:"`) {
+		t.Error("prompt should ask for a next steps list")
+	}
+	if strings.Contains(prompt, "Open TODO items recorded") {
+		t.Error("prompt should not have a TODO section without TODO items")
+	}
+}
+
+func TestAssemblePromptGitOnly(t *testing.T) {
+	files := map[string]string{
+		"comp-git-myproject.md": "Compressed git summary\n",
+	}
+
+	prompt := assemblePrompt(nil, "myproject", "2024-01-15", files, "", "")
+
+	if !strings.Contains(prompt, "--- comp-git-myproject.md ---") {
+		t.Error("prompt should contain compressed git section")
+	}
+	if strings.Contains(prompt, "--- notes.md ---") {
+		t.Error("prompt should NOT contain notes section when notes don't exist")
+	}
+}
+
+func TestAssemblePromptNotesOnly(t *testing.T) {
+	files := map[string]string{
+		"notes.md": "### At 10:20 #myproject\nsome notes\n",
+	}
+
+	prompt := assemblePrompt(nil, "myproject", "2024-01-15", files, "", "")
+
+	if strings.Contains(prompt, "--- git-myproject.log ---") {
+		t.Error("prompt should NOT contain git log section when git log doesn't exist")
+	}
+	if !strings.Contains(prompt, "--- notes.md ---") {
+		t.Error("prompt should contain notes section")
+	}
+}
+
+func TestRunGenPrompt(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-myproject.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\ndiff content\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"),
+		[]byte("### At 10:20 #myproject\nStarted work\n"), 0o644)
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cfg := Config{}
+	err := runGenPrompt(cfg, State{}, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	if !strings.Contains(s, `"myproject"`) {
+		t.Error("output should contain project name")
+	}
+	if !strings.Contains(s, "2024-01-15") {
+		t.Error("output should contain date")
+	}
+	// Without comp files, falls back to raw git data
+	if !strings.Contains(s, "diff content") {
+		t.Error("output should contain git log data (raw fallback)")
+	}
+	if !strings.Contains(s, "Started work") {
+		t.Error("output should contain notes data")
+	}
+}
+
+func TestRunGenPromptWithCompFiles(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+
+	// Create both raw and comp files
+	cfg := Config{}
+	os.WriteFile(filepath.Join(dateDir, "git-myproject.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\nraw diff content\n"), 0o644)
+	compPath := filepath.Join(dateDir, "comp-git-myproject.md")
+	os.WriteFile(compPath, []byte("Compressed git summary"), 0o644)
+	os.WriteFile(compFingerprintPath(compPath), []byte(compFingerprint(cfg, "git")+"\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"),
+		[]byte("### At 10:20 #myproject\nStarted work\n"), 0o644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runGenPrompt(cfg, State{}, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	// Should use comp file, not raw
+	if !strings.Contains(s, "Compressed git summary") {
+		t.Error("output should contain compressed git data")
+	}
+	if strings.Contains(s, "raw diff content") {
+		t.Error("output should NOT contain raw git data when comp file exists")
+	}
+
+	// A comp file made by another comp_cmd is stale, as for gen.
+	cfg.CompCmd = "othercompressor"
+	r, w, _ = os.Pipe()
+	os.Stdout = w
+	err = runGenPrompt(cfg, State{}, date)
+	w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ = io.ReadAll(r)
+	if s := string(out); !strings.Contains(s, "raw diff content") || strings.Contains(s, "Compressed git summary") {
+		t.Errorf("output should contain raw git data when the comp file is stale:\n%s", s)
+	}
+}
+
+func TestRunGenPromptNoData(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", filepath.Join(tmp, "raw"))
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	cfg := Config{}
+	err := runGenPrompt(cfg, State{}, "2024-01-15")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunGenPromptMultipleProjects(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\nalpha diff\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "git-beta.log"),
+		[]byte("=== SNAPSHOT 11:00 ===\nbeta diff\n"), 0o644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cfg := Config{}
+	err := runGenPrompt(cfg, State{}, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	if !strings.Contains(s, "=== alpha ===") {
+		t.Error("output should contain alpha project header")
+	}
+	if !strings.Contains(s, "=== beta ===") {
+		t.Error("output should contain beta project header")
+	}
+	if !strings.Contains(s, "alpha diff") {
+		t.Error("output should contain alpha data")
+	}
+	if !strings.Contains(s, "beta diff") {
+		t.Error("output should contain beta data")
+	}
+}
+
+func TestRunGenPromptProjectConfig(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\nalpha diff\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "git-private.log"),
+		[]byte("=== SNAPSHOT 11:00 ===\nprivate diff\n"), 0o644)
+	os.MkdirAll(filepath.Join(tmp, "beta"), 0o755)
+	os.WriteFile(filepath.Join(tmp, "beta", date+".log"),
+		[]byte("=== SNAPSHOT 12:00 ===\nbeta diff\n"), 0o644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cfg := Config{Projects: map[string]ProjectConfig{
+		"alpha":   {Prompt: "Mention the ticket number."},
+		"beta":    {GitPath: filepath.Join(tmp, "beta", "<date>.log")},
+		"private": {Exclude: true},
+	}}
+	err := runGenPrompt(cfg, State{}, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	if !strings.Contains(s, "=== beta ===") || !strings.Contains(s, "beta diff") {
+		t.Error("output should contain beta data from its own git_path")
+	}
+	if strings.Contains(s, "private") {
+		t.Error("output should not contain the excluded project")
+	}
+	alpha, beta, _ := strings.Cut(s, "=== beta ===")
+	if !strings.Contains(alpha, "Additional guidelines for this project:\nMention the ticket number.") {
+		t.Error("alpha prompt should contain its prompt addition")
+	}
+	if strings.Contains(beta, "Additional guidelines") {
+		t.Error("beta prompt should not contain alpha's prompt addition")
+	}
+}
+
+func TestRunGenNoRawData(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", filepath.Join(tmp, "raw"))
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	cfg := Config{}
+	_, err := runGen(context.Background(), cfg, State{}, "2024-01-15", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRunGenStalenessCheck(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.MkdirAll(logDir, 0o755)
+
+	// Create raw data with old timestamp (template-resolved default path)
+	rawFile := filepath.Join(dateDir, "git-test.log")
+	os.WriteFile(rawFile, []byte("=== SNAPSHOT 10:00 ===\ndiff\n"), 0o644)
+	past := time.Now().Add(-1 * time.Hour)
+	os.Chtimes(rawFile, past, past)
+
+	// Create summary with newer timestamp
+	summaryPath := filepath.Join(logDir, date+".md")
+	os.WriteFile(summaryPath, []byte("# existing summary\n"), 0o644)
+
+	cfg := Config{}
+	_, err := runGen(context.Background(), cfg, State{}, date, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Summary should still exist (not regenerated)
+	content, _ := os.ReadFile(summaryPath)
+	if !strings.Contains(string(content), "existing summary") {
+		t.Error("summary should not have been regenerated")
+	}
+}
+
+func TestRunGenWithMockSummarizer(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	// Create mock summarizer and compressor scripts
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	mockSummarizer := filepath.Join(mockBin, "mysummarizer")
+	os.WriteFile(mockSummarizer, []byte("#!/bin/sh\necho 'This is a test summary.'\n"), 0o755)
+	mockCompressor := filepath.Join(mockBin, "mycompressor")
+	os.WriteFile(mockCompressor, []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-myproject.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\ndiff content\n\n"), 0o644)
+
+	cfg := Config{
+		GenCmd:  "mysummarizer",
+		CompCmd: "mycompressor",
+	}
+	n, err := runGen(context.Background(), cfg, State{}, date, nil)
+	if err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 project summarized, got %d", n)
+	}
+
+	summaryPath := filepath.Join(logDir, date+".md")
+	content, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("reading summary: %v", err)
+	}
+
+	s := string(content)
+	if !strings.Contains(s, "# 2024-01-15") {
+		t.Error("summary should contain date heading")
+	}
+	if !strings.Contains(s, "## myproject") {
+		t.Error("summary should contain project heading")
+	}
+	if !strings.Contains(s, "This is a test summary.") {
+		t.Error("summary should contain summarizer output")
+	}
+}
+
+func TestRunGenProjects(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	// The summarizer echoes the project name from the prompt.
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"),
+		[]byte("#!/bin/sh\nsed -n 's/^\"\\(.*\\)\" for the date.*/New summary of \\1./p'\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	for _, p := range []string{"alpha", "beta", "scratch"} {
+		os.WriteFile(filepath.Join(dateDir, "git-"+p+".log"), []byte("=== SNAPSHOT 10:00 ===\ndiff\n\n"), 0o644)
+	}
+	os.MkdirAll(logDir, 0o755)
+	summaryPath := filepath.Join(logDir, date+".md")
+	old := summaryMeta{Projects: []projectMeta{{Project: "alpha", GenCmd: "oldsummarizer"}, {Project: "beta", GenCmd: "oldsummarizer"}}}
+	os.WriteFile(summaryPath, []byte(formatSummaryMeta(old)+"# 2024-01-15\n\n## alpha\n\nOld alpha.\n\n## beta\n\nOld beta.\n"), 0o644)
+
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mycompressor", GenExclude: []string{"scratch"},
+		TokenPrices: map[string]TokenPrice{"mysummarizer": {Input: 1e6}}}
+	n, err := runGenProjects(context.Background(), cfg, State{}, date, []string{"beta"}, nil)
+	if err != nil {
+		t.Fatalf("runGenProjects: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 project summarized, got %d", n)
+	}
+	content, _ := os.ReadFile(summaryPath)
+	meta, body := splitSummaryMeta(string(content))
+	want := "# 2024-01-15\n\nWorked ~09:55–10:00.\n\n## alpha\n\nOld alpha.\n\n## beta\n\nNew summary of beta.\n"
+	if body != want {
+		t.Errorf("summary = %q, want %q", body, want)
+	}
+	if got := genCmdsOf(meta); got != "alpha=oldsummarizer beta=mysummarizer" {
+		t.Errorf("gen_cmd metadata = %q, want alpha's kept and beta's new", got)
+	}
+	if pm, _ := meta.project("beta"); len(pm.Sources) != 1 || pm.Sources[0].Path != "2024-01-15/git-beta.log" || pm.PromptTokens == 0 || pm.Cost != float64(pm.PromptTokens) {
+		t.Errorf("beta metadata = %+v", pm)
+	}
+
+	if _, err := runGenProjects(context.Background(), cfg, State{}, date, []string{"scratch"}, nil); err == nil {
+		t.Error("expected an error for an excluded project")
+	}
+	if _, err := runGenProjects(context.Background(), cfg, State{}, date, []string{"missing"}, nil); err == nil {
+		t.Error("expected an error for a project without data")
+	}
+
+	// A full run regenerates everything but the excluded project.
+	os.Remove(summaryPath)
+	if _, err := runGen(context.Background(), cfg, State{}, date, nil); err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+	content, _ = os.ReadFile(summaryPath)
+	meta, body = splitSummaryMeta(string(content))
+	want = "# 2024-01-15\n\nWorked ~09:55–10:00.\n\n## alpha\n\nNew summary of alpha.\n\n## beta\n\nNew summary of beta.\n"
+	if body != want {
+		t.Errorf("summary = %q, want %q", body, want)
+	}
+	if got := genCmdsOf(meta); got != "alpha=mysummarizer beta=mysummarizer" {
+		t.Errorf("gen_cmd metadata = %q", got)
+	}
+}
+
+func TestRunGenIncremental(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	// The summarizer logs the project it summarizes, and numbers its
+	// summaries so that a regenerated one differs.
+	calls := filepath.Join(tmp, "calls")
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"),
+		[]byte("#!/bin/sh\np=$(sed -n 's/^\"\\(.*\\)\" for the date.*/\\1/p')\necho \"$p\" >> "+calls+"\necho \"Summary of $p, call $(wc -l < "+calls+" | tr -d ' ').\"\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	for _, p := range []string{"alpha", "beta"} {
+		os.WriteFile(filepath.Join(dateDir, "git-"+p+".log"), []byte("=== SNAPSHOT 10:00 ===\ndiff\n\n"), 0o644)
+	}
+	os.WriteFile(filepath.Join(dateDir, "notes.md"), []byte("### At 10:05 #alpha\nStarted.\n"), 0o644)
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mycompressor"}
+	if _, err := runGen(context.Background(), cfg, State{}, date, nil); err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+
+	// New data arrives for beta only, after the summary.
+	summaryPath := filepath.Join(logDir, date+".md")
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(summaryPath, past, past)
+	os.WriteFile(calls, nil, 0o644)
+	f, _ := os.OpenFile(filepath.Join(dateDir, "git-beta.log"), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("=== SNAPSHOT 18:00 ===\nmore diff\n\n")
+	f.Close()
+	f, _ = os.OpenFile(filepath.Join(dateDir, "notes.md"), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("\n### At 18:05 #beta\nEvening.\n")
+	f.Close()
+
+	n, err := runGen(context.Background(), cfg, State{}, date, nil)
+	if err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+	if got, _ := os.ReadFile(calls); n != 1 || string(got) != "beta\n" {
+		t.Errorf("runGen summarized %d projects, calls %q; want only beta", n, got)
+	}
+	content, _ := os.ReadFile(summaryPath)
+	if sections := splitSummary(string(content)); len(sections) != 2 || sections[0].Text != "Summary of alpha, call 1." || sections[1].Text != "Summary of beta, call 1." {
+		t.Errorf("sections = %+v", sections)
+	}
+
+	// Nothing changed since: up to date, even if the summary looks stale.
+	os.Chtimes(summaryPath, past, past)
+	if n, _ := runGen(context.Background(), cfg, State{}, date, nil); n != 0 {
+		t.Errorf("runGen summarized %d projects without new data", n)
+	}
+}
+
+func TestRunGenUnchangedSummary(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+
+	// The summarizer writes the same summary whatever the data, give or
+	// take whitespace.
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"),
+		[]byte("#!/bin/sh\ncat > /dev/null\nif [ -f "+filepath.Join(tmp, "again")+" ]; then echo 'Fixed  the\n bug.  '; else echo 'Fixed the bug.'; fi\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"), []byte("=== SNAPSHOT 10:00 ===\ndiff\n\n"), 0o644)
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mycompressor"}
+	if _, err := runGen(context.Background(), cfg, State{}, date, nil); err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+	summaryPath := filepath.Join(logDir, date+".md")
+	before, _ := os.ReadFile(summaryPath)
+
+	// New data that makes no difference to the summary.
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(summaryPath, past, past)
+	os.WriteFile(filepath.Join(tmp, "again"), nil, 0o644)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"), []byte("=== SNAPSHOT 10:00 ===\nother diff\n\n"), 0o644)
+	os.Chtimes(filepath.Join(dateDir, "git-alpha.log"), past.Add(time.Minute), past.Add(time.Minute))
+
+	n, err := runGen(context.Background(), cfg, State{}, date, nil)
+	if err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("runGen reported %d projects summarized, want 0 for an unchanged summary", n)
+	}
+	after, _ := os.ReadFile(summaryPath)
+	if string(after) != string(before) {
+		t.Errorf("summary rewritten:\n%s\nwas:\n%s", after, before)
+	}
+	if info, _ := os.Stat(summaryPath); !info.ModTime().Equal(past) {
+		t.Errorf("summary mtime changed to %v", info.ModTime())
+	}
+	if !summaryUpToDate(cfg, State{}, date, summaryPath) {
+		t.Error("the kept summary should count as up to date")
+	}
+	// The section's sources are those of the new data, so that an update
+	// does not take it for stale.
+	beforeMeta, _ := splitSummaryMeta(string(before))
+	afterMeta, _, _, _ := readSummaryFile(date, summaryPath)
+	if !sectionUpToDate(cfg, State{}, afterMeta, "alpha", date) || !afterMeta.Generated.Equal(beforeMeta.Generated) {
+		t.Errorf("the kept section should record the new sources, got %+v", afterMeta)
+	}
+
+	// Once the summary is rewritten, the kept metadata no longer applies.
+	os.WriteFile(summaryPath, []byte(strings.Replace(string(before), "Fixed", "Fixed up", 1)), 0o644)
+	if m, _, _, _ := readSummaryFile(date, summaryPath); sectionUpToDate(cfg, State{}, m, "alpha", date) {
+		t.Error("kept metadata applied to a rewritten summary")
+	}
+}
+
+// genCmdsOf returns the gen_cmd recorded for each project of m.
+func genCmdsOf(m summaryMeta) string {
+	var parts []string
+	for _, p := range m.Projects {
+		parts = append(parts, p.Project+"="+p.GenCmd)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestRunGenCmdFallback(t *testing.T) {
+	mockBin := t.TempDir()
+	os.WriteFile(filepath.Join(mockBin, "ratelimited"), []byte("#!/bin/sh\necho 'rate limited' >&2\nexit 1\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "local"), []byte("#!/bin/sh\necho 'Local summary.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	cfg := Config{GenCmd: "ratelimited", GenFallback: []string{"missingcmd", "local --quiet"}}
+	out, used, err := runGenCmd(context.Background(), cfg, "Summarize.\n", nil)
+	if err != nil || out != "Local summary." || used != "local --quiet" {
+		t.Errorf("runGenCmd = %q, %q, %v; want the output of the last command", out, used, err)
+	}
+
+	cfg.GenFallback = []string{"missingcmd"}
+	if _, _, err := runGenCmd(context.Background(), cfg, "Summarize.\n", nil); err == nil {
+		t.Error("expected an error when every command fails")
+	}
+}
+
+func TestPromptCmdTimeout(t *testing.T) {
+	mockBin := t.TempDir()
+	os.WriteFile(filepath.Join(mockBin, "hung"), []byte("#!/bin/sh\nexec sleep 30\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	start := time.Now()
+	_, err := runPromptCmd(context.Background(), "comp_cmd", "hung", "Compress.\n", 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "hung timed out after 200ms") {
+		t.Errorf("runPromptCmd = %v, want a timeout", err)
+	}
+	var streamed strings.Builder
+	if _, err := runGenPromptCmd(context.Background(), "hung --output-format stream-json", "Summarize.\n", &streamed, 200*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("runGenPromptCmd = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("timed out commands took %v to stop", elapsed)
+	}
+	if got := formatTimeout(timeoutMinutes(15)); got != "15 minutes" {
+		t.Errorf("formatTimeout = %q", got)
+	}
+}
+
+func TestMergeSummarySections(t *testing.T) {
+	existing := []summarySection{{"a", "old a"}, {"b", "old b"}}
+	updated := []summarySection{{"c", "new c"}, {"a", "new a"}}
+	got := mergeSummarySections(existing, updated)
+	want := []summarySection{{"a", "new a"}, {"b", "old b"}, {"c", "new c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAssemblePromptWithTermLog(t *testing.T) {
+	files := map[string]string{
+		"comp-git-myproject.md":  "Compressed git summary\n",
+		"comp-term-myproject.md": "Compressed term summary with go test\n",
+	}
+
+	prompt := assemblePrompt(nil, "myproject", "2024-01-15", files, "", "")
+
+	if !strings.Contains(prompt, "--- comp-term-myproject.md ---") {
+		t.Error("prompt should contain compressed terminal section")
+	}
+	if !strings.Contains(prompt, "go test") {
+		t.Error("prompt should contain compressed terminal contents")
+	}
+	if !strings.Contains(prompt, "comp-term-myproject.md: AI-compressed summary of terminal session") {
+		t.Error("prompt should describe compressed terminal data source")
+	}
+}
+
+func TestRunGenPromptWithTermLog(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-myproject.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\ndiff content\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "term-myproject-session1.log"),
+		[]byte("$ make build\nok\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "term-myproject-session2.log"),
+		[]byte("$ go test\nPASS\n"), 0o644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cfg := Config{}
+	err := runGenPrompt(cfg, State{}, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	if !strings.Contains(s, "make build") {
+		t.Error("output should contain first terminal log data")
+	}
+	if !strings.Contains(s, "go test") {
+		t.Error("output should contain second terminal log data")
+	}
+}
+
+func TestRunGenPromptTermLogNotDiscovery(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	// Only terminal logs, no git or notes
+	os.WriteFile(filepath.Join(dateDir, "term-orphan-session1.log"),
+		[]byte("$ echo hello\nhello\n"), 0o644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cfg := Config{}
+	err := runGenPrompt(cfg, State{}, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	if strings.Contains(s, "orphan") {
+		t.Error("project with only terminal logs should not be discovered")
+	}
+}
+
+func TestCollectRawFileMtimeIncludesTermLogs(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", tmp)
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(tmp, date)
+	os.MkdirAll(dateDir, 0o755)
+
+	// Create a git log with old mtime
+	gitFile := filepath.Join(dateDir, "git-proj.log")
+	os.WriteFile(gitFile, []byte("diff"), 0o644)
+	past := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(gitFile, past, past)
+
+	// Create a terminal log with newer mtime
+	termFile := filepath.Join(dateDir, "term-proj-s1.log")
+	os.WriteFile(termFile, []byte("$ cmd"), 0o644)
+	recent := time.Now().Add(-10 * time.Minute)
+	os.Chtimes(termFile, recent, recent)
+
+	cfg := Config{}
+	maxMtime := collectRawFileMtime(cfg, State{}, date)
+
+	if maxMtime.Before(recent) {
+		t.Errorf("maxMtime should reflect terminal log time, got %v, want at least %v", maxMtime, recent)
+	}
+}
+
+func TestDiscoverAllProjectsIncludesClaudeCode(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	claudeDir := filepath.Join(tmp, "claude")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+
+	date := "2024-06-15"
+
+	// No raw data files — only Claude Code sessions
+	repoPath := "/home/user/dev/myproject"
+	projDir := filepath.Join(claudeDir, "-home-user-dev-myproject")
+	os.MkdirAll(projDir, 0o755)
+
+	lines := jsonLine(t, map[string]interface{}{
+		"type": "user", "timestamp": "2024-06-15T10:00:00.000Z",
+		"message": map[string]interface{}{"role": "user", "content": "hello"},
+	})
+	os.WriteFile(filepath.Join(projDir, "session.jsonl"), []byte(lines+"\n"), 0o644)
+
+	ccDir := claudeDir
+	cfg := Config{ClaudeCodeDir: &ccDir}
+	state := State{Watched: []WatchEntry{{Path: repoPath, Name: "myproject"}}}
+
+	projects := discoverAllProjects(cfg, state, date)
+
+	found := false
+	for _, p := range projects {
+		if p == "myproject" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected myproject in discovered projects, got %v", projects)
+	}
+}
+
+func TestRunGenPromptWithClaudeCode(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	claudeDir := filepath.Join(tmp, "claude")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-06-15"
+
+	// Create raw data so project is discovered
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-myproject.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\ndiff content\n"), 0o644)
+
+	// Create Claude Code session
+	repoPath := "/home/user/dev/myproject"
+	projDir := filepath.Join(claudeDir, "-home-user-dev-myproject")
+	os.MkdirAll(projDir, 0o755)
+
+	lines := []string{
+		jsonLine(t, map[string]interface{}{
+			"type": "user", "timestamp": "2024-06-15T10:30:00.000Z", "sessionId": "s1",
+			"message": map[string]interface{}{"role": "user", "content": "Fix the parser bug"},
+		}),
+		jsonLine(t, map[string]interface{}{
+			"type": "assistant", "timestamp": "2024-06-15T10:31:00.000Z", "sessionId": "s1",
+			"message": map[string]interface{}{
+				"role": "assistant", "content": []map[string]interface{}{
+					{"type": "text", "text": "I found the issue in parser.go."},
+				},
+			},
+		}),
+	}
+	os.WriteFile(filepath.Join(projDir, "session.jsonl"),
+		[]byte(strings.Join(lines, "\n")+"\n"), 0o644)
+
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	ccDir := claudeDir
+	cfg := Config{ClaudeCodeDir: &ccDir}
+	state := State{Watched: []WatchEntry{{Path: repoPath, Name: "myproject"}}}
+	err := runGenPrompt(cfg, state, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	if !strings.Contains(s, "--- claude-code-sessions.txt ---") {
+		t.Error("output should contain Claude Code sessions section")
+	}
+	if !strings.Contains(s, "Fix the parser bug") {
+		t.Error("output should contain user prompt from Claude Code session")
+	}
+	if !strings.Contains(s, "I found the issue in parser.go.") {
+		t.Error("output should contain assistant response from Claude Code session")
+	}
+}
+
+func TestAssemblePromptWithClaudeCode(t *testing.T) {
+	files := map[string]string{
+		"comp-git-myproject.md":    "Compressed git summary\n",
+		"comp-claude-myproject.md": "Compressed Claude summary about fixing tests\n",
+	}
+
+	prompt := assemblePrompt(nil, "myproject", "2024-06-15", files, "", "")
+
+	if !strings.Contains(prompt, "--- comp-claude-myproject.md ---") {
+		t.Error("prompt should contain compressed Claude Code section")
+	}
+	if !strings.Contains(prompt, "fixing tests") {
+		t.Error("prompt should contain compressed Claude Code content")
+	}
+	if !strings.Contains(prompt, "comp-claude-myproject.md: AI-compressed summary of Claude Code session") {
+		t.Error("prompt should contain compressed Claude Code data source description")
+	}
+}
+
+func TestCollectRawFileMtimeIncludesClaudeCode(t *testing.T) {
+	tmp := t.TempDir()
+	claudeDir := filepath.Join(tmp, "claude")
+	t.Setenv("DEVLOG_RAW_DIR", filepath.Join(tmp, "raw"))
+
+	date := "2024-06-15"
+
+	// Create Claude Code session file with recent mtime
+	repoPath := "/home/user/dev/proj"
+	projDir := filepath.Join(claudeDir, "-home-user-dev-proj")
+	os.MkdirAll(projDir, 0o755)
+	jsonlFile := filepath.Join(projDir, "session.jsonl")
+	os.WriteFile(jsonlFile, []byte(`{"type":"user"}`+"\n"), 0o644)
+	recent := time.Now().Add(-5 * time.Minute)
+	os.Chtimes(jsonlFile, recent, recent)
+
+	ccDir := claudeDir
+	cfg := Config{ClaudeCodeDir: &ccDir}
+	state := State{Watched: []WatchEntry{{Path: repoPath, Name: "proj"}}}
+	maxMtime := collectRawFileMtime(cfg, state, date)
+
+	if maxMtime.Before(recent) {
+		t.Errorf("maxMtime should include Claude Code JSONL time, got %v, want at least %v", maxMtime, recent)
+	}
+}
+
+func TestFilterNotesForProject(t *testing.T) {
+	content := "### At 09:00 #alpha\nalpha note 1\n\n" +
+		"### At 10:00 #beta\nbeta note\n\n" +
+		"### At 11:00 #alpha\nalpha note 2\n\n" +
+		"### At 12:00\nunaffiliated note\n\n"
+
+	got := filterNotesForProject(nil, content, "alpha")
+	if !strings.Contains(got, "alpha note 1") {
+		t.Error("should contain first alpha note")
+	}
+	if !strings.Contains(got, "alpha note 2") {
+		t.Error("should contain second alpha note")
+	}
+	if strings.Contains(got, "beta note") {
+		t.Error("should not contain beta note")
+	}
+	if strings.Contains(got, "unaffiliated note") {
+		t.Error("should not contain unaffiliated note")
+	}
+}
+
+func TestFilterNotesForProjectMultipleTags(t *testing.T) {
+	content := "### At 09:00 #alpha #beta\nshared note\n\n" +
+		"### At 10:00 #alphabet\nprefix note\n\n"
+
+	for _, project := range []string{"alpha", "beta"} {
+		got := filterNotesForProject(nil, content, project)
+		if !strings.Contains(got, "shared note") {
+			t.Errorf("%s: should contain note tagged with both projects", project)
+		}
+		if strings.Contains(got, "prefix note") {
+			t.Errorf("%s: should not match a tag that only shares a prefix", project)
+		}
+	}
+	if got := filterUnaffiliatedNotes(nil, content); got != "" {
+		t.Errorf("tagged notes should not be unaffiliated, got %q", got)
+	}
+}
+
+func TestFilterUnaffiliatedNotes(t *testing.T) {
+	content := "### At 09:00 #alpha\nalpha note\n\n" +
+		"### At 10:00\ngeneral note 1\n\n" +
+		"### At 11:00 #beta\nbeta note\n\n" +
+		"### At 12:00\ngeneral note 2\n\n"
+
+	got := filterUnaffiliatedNotes(nil, content)
+	if !strings.Contains(got, "general note 1") {
+		t.Error("should contain first unaffiliated note")
+	}
+	if !strings.Contains(got, "general note 2") {
+		t.Error("should contain second unaffiliated note")
+	}
+	if strings.Contains(got, "alpha note") {
+		t.Error("should not contain alpha note")
+	}
+	if strings.Contains(got, "beta note") {
+		t.Error("should not contain beta note")
+	}
+}
+
+func TestRunGenPromptGeneral(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"),
+		[]byte("### At 10:00\nA general note\n\n"), 0o644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cfg := Config{}
+	err := runGenPrompt(cfg, State{}, date)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	if !strings.Contains(s, `"general"`) {
+		t.Error("output should contain general pseudo-project")
+	}
+	if !strings.Contains(s, "A general note") {
+		t.Error("output should contain the unaffiliated note")
+	}
+}
+
+func TestAssembleCompPrompt(t *testing.T) {
+	files := map[string]string{
+		"git-myproject.log": "=== SNAPSHOT 10:15 ===\ndiff content\n",
+	}
+
+	for _, tc := range []struct {
+		dataType string
+		wantDesc string
+	}{
+		{"git", "Time-stamped snapshots of uncommitted code changes"},
+		{"term", "Terminal session recordings captured with tools like"},
+		{"claude", "Preprocessed transcripts of Claude Code sessions"},
+	} {
+		t.Run(tc.dataType, func(t *testing.T) {
+			prompt := assembleCompPrompt(tc.dataType, files)
+
+			if !strings.Contains(prompt, tc.wantDesc) {
+				t.Errorf("prompt should contain %q description", tc.dataType)
+			}
+			if !strings.Contains(prompt, "--- git-myproject.log ---") {
+				t.Error("prompt should contain file section")
+			}
+			if !strings.Contains(prompt, "high fidelity\ncompression") {
+				t.Error("prompt should contain compression task")
+			}
+			if !strings.Contains(prompt, "Correlate summarized events by timestamp") {
+				t.Error("prompt should contain timestamp guideline")
+			}
+		})
+	}
+}
+
+func TestCompressData(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+
+	// Create mock compressor
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	mockComp := filepath.Join(mockBin, "mockcomp")
+	os.WriteFile(mockComp, []byte("#!/bin/sh\necho 'Compressed output.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	// Create source file
+	srcPath := filepath.Join(dateDir, "git-proj.log")
+	os.WriteFile(srcPath, []byte("diff data"), 0o644)
+
+	cfg := Config{CompCmd: "mockcomp"}
+	files := map[string]string{"git-proj.log": "diff data"}
+
+	result, err := compressData(context.Background(), cfg, "git", "proj", date, files, []string{srcPath}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Compressed output." {
+		t.Errorf("expected %q, got %q", "Compressed output.", result)
+	}
+
+	// Verify comp file was written
+	compPath := filepath.Join(dateDir, "comp-git-proj.md")
+	data, err := os.ReadFile(compPath)
+	if err != nil {
+		t.Fatalf("comp file should exist: %v", err)
+	}
+	if string(data) != "Compressed output." {
+		t.Errorf("comp file content: got %q, want %q", string(data), "Compressed output.")
+	}
+}
+
+func TestCompressDataCaching(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+
+	// Create source file with old timestamp
+	srcPath := filepath.Join(dateDir, "git-proj.log")
+	os.WriteFile(srcPath, []byte("diff data"), 0o644)
+	past := time.Now().Add(-1 * time.Hour)
+	os.Chtimes(srcPath, past, past)
+
+	// Use a nonexistent command — if caching works, it won't be invoked
+	cfg := Config{CompCmd: "nonexistent-command-that-should-not-run"}
+	files := map[string]string{"git-proj.log": "diff data"}
+
+	// Create comp file with newer timestamp, made the same way
+	compPath := filepath.Join(dateDir, "comp-git-proj.md")
+	os.WriteFile(compPath, []byte("Cached compressed data"), 0o644)
+	os.WriteFile(compFingerprintPath(compPath), []byte(compFingerprint(cfg, "git")+"\n"), 0o644)
+
+	result, err := compressData(context.Background(), cfg, "git", "proj", date, files, []string{srcPath}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Cached compressed data" {
+		t.Errorf("expected cached data, got %q", result)
+	}
+}
+
+func TestCompressDataFingerprintChange(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Fresh output.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	srcPath := filepath.Join(dateDir, "git-proj.log")
+	os.WriteFile(srcPath, []byte("diff data"), 0o644)
+	past := time.Now().Add(-1 * time.Hour)
+	os.Chtimes(srcPath, past, past)
+
+	// A newer cache made with another model, and one with no fingerprint
+	old := Config{CompCmd: "mycompressor --model old"}
+	compPath := filepath.Join(dateDir, "comp-git-proj.md")
+	os.WriteFile(compPath, []byte("Old output."), 0o644)
+	os.WriteFile(compFingerprintPath(compPath), []byte(compFingerprint(old, "git")+"\n"), 0o644)
+	if compFingerprint(old, "git") == compFingerprint(old, "term") {
+		t.Error("data types should have different fingerprints")
+	}
+
+	cfg := Config{CompCmd: "mycompressor --model new"}
+	files := map[string]string{"git-proj.log": "diff data"}
+	for _, name := range []string{"other model", "no fingerprint"} {
+		result, err := compressData(context.Background(), cfg, "git", "proj", date, files, []string{srcPath}, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if result != "Fresh output." {
+			t.Errorf("%s: expected a new compression, got %q", name, result)
+		}
+		if fp, _ := os.ReadFile(compFingerprintPath(compPath)); strings.TrimSpace(string(fp)) != compFingerprint(cfg, "git") {
+			t.Errorf("%s: fingerprint not updated, got %q", name, fp)
+		}
+		os.WriteFile(compPath, []byte("Old output."), 0o644)
+		os.Remove(compFingerprintPath(compPath))
+	}
+}
+
+func TestCompressDataNoFiles(t *testing.T) {
+	cfg := Config{CompCmd: "anything"}
+	result, err := compressData(context.Background(), cfg, "git", "proj", "2024-01-15", map[string]string{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Errorf("expected empty string, got %q", result)
+	}
+}
+
+func TestRunGenDryRun(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	// Mock commands record that they were run
+	marker := filepath.Join(tmp, "ran")
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	script := []byte("#!/bin/sh\ntouch " + marker + "\necho output\n")
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"), script, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), script, 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+
+	// Git data with a fresh comp cache
+	gitFile := filepath.Join(dateDir, "git-myproject.log")
+	os.WriteFile(gitFile, []byte("=== SNAPSHOT 10:00 ===\ndiff\n"), 0o644)
+	past := time.Now().Add(-1 * time.Hour)
+	os.Chtimes(gitFile, past, past)
+	compPath := filepath.Join(dateDir, "comp-git-myproject.md")
+	os.WriteFile(compPath, []byte("Cached git summary"), 0o644)
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mycompressor"}
+	os.WriteFile(compFingerprintPath(compPath), []byte(compFingerprint(cfg, "git")+"\n"), 0o644)
+
+	// Term data with no comp cache
+	os.WriteFile(filepath.Join(dateDir, "term-myproject.log"), []byte("$ go test\nok\n"), 0o644)
+
+	os.WriteFile(filepath.Join(dateDir, "notes.md"),
+		[]byte("### At 10:20 #myproject\nStarted work\n\n### At 11:00\nGeneral note\n"), 0o644)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runGenDryRun(cfg, State{}, date, nil)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, _ := io.ReadAll(r)
+	s := string(out)
+
+	for _, want := range []string{
+		"would be created",
+		"Compressor: mycompressor",
+		"Summarizer: mysummarizer",
+		"myproject:",
+		"comp-git-myproject.md: fresh",
+		"comp-term-myproject.md: stale",
+		"notes.md: ~",
+		"1 pending compression",
+		"general:",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, s)
+		}
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("dry run should not invoke any AI commands")
+	}
+	if _, err := os.Stat(filepath.Join(logDir, date+".md")); err == nil {
+		t.Error("dry run should not write a summary")
+	}
+	if _, err := os.Stat(filepath.Join(dateDir, "comp-term-myproject.md")); err == nil {
+		t.Error("dry run should not write comp files")
+	}
+}
+
+func TestNoteHeadingSemanticTags(t *testing.T) {
+	tags, ok := noteHeadingTags(nil, "### At 10:00 #alpha #decision #blocked")
+	if !ok || len(tags) != 1 || tags[0] != "alpha" {
+		t.Errorf("expected [alpha], got %v (ok=%v)", tags, ok)
+	}
+
+	content := "### At 09:00 #alpha #decision\nuse sqlite\n\n" +
+		"### At 10:00 #meeting\nstandup notes\n\n"
+	if got := filterNotesForProject(nil, content, "alpha"); !strings.Contains(got, "#decision") {
+		t.Errorf("semantic tag should be preserved in filtered notes, got %q", got)
+	}
+	got := filterUnaffiliatedNotes(nil, content)
+	if !strings.Contains(got, "standup notes") || strings.Contains(got, "use sqlite") {
+		t.Errorf("note with only semantic tags should be unaffiliated, got %q", got)
+	}
+}
+
+func TestPreviousProjectSummaries(t *testing.T) {
+	logDir := t.TempDir()
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+	os.WriteFile(filepath.Join(logDir, "2024-01-10.md"), []byte("# 2024-01-10\n\n## myproject\n\nStarted the parser refactor.\n"), 0o644)
+	os.WriteFile(filepath.Join(logDir, "2024-01-12.md"), []byte("# 2024-01-12\n\n## other\n\nOther work.\n"), 0o644)
+	os.WriteFile(filepath.Join(logDir, "2024-01-14.md"), []byte("# 2024-01-14\n\n## myproject\n\nMoved the lexer.\n"), 0o644)
+
+	cfg := Config{}
+	if got := previousProjectSummaries(cfg, "myproject", "2024-01-15", 0); len(got) != 0 {
+		t.Errorf("expected no context when disabled, got %v", got)
+	}
+	got := previousProjectSummaries(cfg, "myproject", "2024-01-15", 1)
+	if len(got) != 1 || got["summary-2024-01-14.md"] != "Moved the lexer." {
+		t.Errorf("unexpected context %v", got)
+	}
+	// Days without a section for the project are skipped.
+	if got := previousProjectSummaries(cfg, "myproject", "2024-01-15", 3); len(got) != 2 || got["summary-2024-01-10.md"] == "" {
+		t.Errorf("unexpected context %v", got)
+	}
+	if got := previousProjectSummaries(cfg, "myproject", "2024-02-15", 3); len(got) != 0 {
+		t.Errorf("expected nothing beyond the lookback, got %v", got)
+	}
+
+	files := map[string]string{"notes.md": "### At 10:20 #myproject\nwork\n"}
+	if strings.Contains(assemblePrompt(nil, "myproject", "2024-01-15", files, "", ""), "summary-<date>.md") {
+		t.Error("prompt should describe previous summaries only when present")
+	}
+	files["summary-2024-01-14.md"] = "Moved the lexer."
+	prompt := assemblePrompt(nil, "myproject", "2024-01-15", files, "", "")
+	if !strings.Contains(prompt, "summary-<date>.md: The summary of this project from an earlier day") ||
+		!strings.Contains(prompt, "--- summary-2024-01-14.md ---\nMoved the lexer.") {
+		t.Errorf("prompt should include the previous summary:\n%s", prompt)
+	}
+}
+
+func TestProjectInstructions(t *testing.T) {
+	cfg := Config{Projects: map[string]ProjectConfig{"web": {Prompt: "Mention the ticket number."}}}
+	state := State{Watched: []WatchEntry{{Path: "/web", Name: "web", Tags: []string{"frontend", "acme"}, DefaultBranch: "main"}}}
+
+	got := projectInstructions(cfg, state, "web")
+	want := "- The project is tagged frontend, acme; use the tags as context for what the work is.\n" +
+		"- The repo's default branch is \"main\"; mention it when work happened on another branch.\n" +
+		"Mention the ticket number."
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if got := projectInstructions(cfg, State{}, "other"); got != "" {
+		t.Errorf("expected no instructions for a project without metadata, got %q", got)
+	}
+}
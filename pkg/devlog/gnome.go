@@ -0,0 +1,141 @@
+package devlog
+
+import (
+	"log/slog"
+	"os/exec"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	gnomeSearchBusName   = "org.chadnorvell.devlog.SearchProvider"
+	gnomeSearchPath      = "/org/chadnorvell/devlog/SearchProvider"
+	gnomeSearchInterface = "org.gnome.Shell.SearchProvider2"
+)
+
+// GnomeSearch implements the org.gnome.Shell.SearchProvider2 D-Bus interface,
+// offering the same "#project note" entry as KRunner in the GNOME Activities
+// overview.
+type GnomeSearch struct {
+	server *Server
+	logger *slog.Logger
+	// dialog reports whether kdialog is available, which "devlog -g" needs
+	// for notes typed without content.
+	dialog bool
+}
+
+// GetInitialResultSet returns result IDs for a new search.
+func (g *GnomeSearch) GetInitialResultSet(terms []string) ([]string, *dbus.Error) {
+	return g.results(terms), nil
+}
+
+// GetSubsearchResultSet refines a previous search. Results are cheap to
+// compute, so the search is simply run again.
+func (g *GnomeSearch) GetSubsearchResultSet(previous []string, terms []string) ([]string, *dbus.Error) {
+	return g.results(terms), nil
+}
+
+func (g *GnomeSearch) results(terms []string) []string {
+	query := strings.Join(terms, " ")
+	if !strings.HasPrefix(query, "#") {
+		return nil
+	}
+	var ids []string
+	for _, m := range matchNoteQuery(g.server.watchedSnapshot(), query) {
+		if m.Content == "" && !g.dialog {
+			continue
+		}
+		ids = append(ids, encodeMatchID(m.Project, m.Content))
+	}
+	return ids
+}
+
+// GetResultMetas describes results for display.
+func (g *GnomeSearch) GetResultMetas(ids []string) ([]map[string]dbus.Variant, *dbus.Error) {
+	watched := make(map[string]bool)
+	for _, w := range g.server.watchedSnapshot() {
+		watched[w.Name] = true
+	}
+	metas := make([]map[string]dbus.Variant, 0, len(ids))
+	for _, id := range ids {
+		project, content := decodeMatchID(id)
+		m := noteQueryMatch{Project: project, Content: content}
+
+		description := "Log a note"
+		if content == "" {
+			description = "Write a note in a dialog"
+		}
+		if !watched[project] {
+			description += " (unwatched project)"
+		}
+
+		metas = append(metas, map[string]dbus.Variant{
+			"id":          dbus.MakeVariant(id),
+			"name":        dbus.MakeVariant(m.text()),
+			"description": dbus.MakeVariant(description),
+			"gicon":       dbus.MakeVariant("document-edit"),
+		})
+	}
+	return metas, nil
+}
+
+// ActivateResult logs the note for the selected result.
+func (g *GnomeSearch) ActivateResult(id string, terms []string, timestamp uint32) *dbus.Error {
+	project, content := decodeMatchID(id)
+	if project == "" {
+		return nil
+	}
+	launchNote(g.logger, project, content)
+	return nil
+}
+
+// LaunchSearch is called when the provider's icon is clicked. There is no
+// app to show more results in, so it does nothing.
+func (g *GnomeSearch) LaunchSearch(terms []string, timestamp uint32) *dbus.Error {
+	return nil
+}
+
+const gnomeSearchIntrospectXML = `
+<node>
+  <interface name="org.gnome.Shell.SearchProvider2">
+    <method name="GetInitialResultSet">
+      <arg type="as" name="terms" direction="in"/>
+      <arg type="as" name="results" direction="out"/>
+    </method>
+    <method name="GetSubsearchResultSet">
+      <arg type="as" name="previous_results" direction="in"/>
+      <arg type="as" name="terms" direction="in"/>
+      <arg type="as" name="results" direction="out"/>
+    </method>
+    <method name="GetResultMetas">
+      <arg type="as" name="identifiers" direction="in"/>
+      <arg type="aa{sv}" name="metas" direction="out"/>
+    </method>
+    <method name="ActivateResult">
+      <arg type="s" name="identifier" direction="in"/>
+      <arg type="as" name="terms" direction="in"/>
+      <arg type="u" name="timestamp" direction="in"/>
+    </method>
+    <method name="LaunchSearch">
+      <arg type="as" name="terms" direction="in"/>
+      <arg type="u" name="timestamp" direction="in"/>
+    </method>
+  </interface>
+</node>
+`
+
+// startGnomeSearch attempts to register on the D-Bus session bus connection
+// conn as a GNOME Shell search provider. Returns a cleanup function, or nil if
+// D-Bus is unavailable (conn is nil). Unlike KRunner, kdialog is not
+// required: without it, only results with note content are offered.
+func startGnomeSearch(s *Server, conn *dbus.Conn) func() {
+	if conn == nil {
+		return nil
+	}
+	logger := s.logger.With("component", "gnome-search")
+
+	_, lookErr := exec.LookPath("kdialog")
+	gs := &GnomeSearch{server: s, logger: logger, dialog: lookErr == nil}
+	return exportDBusService(conn, logger, gnomeSearchBusName, gnomeSearchPath, gnomeSearchInterface, gs, gnomeSearchIntrospectXML)
+}
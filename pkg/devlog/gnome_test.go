@@ -0,0 +1,47 @@
+package devlog
+
+import "testing"
+
+func TestGnomeSearchResults(t *testing.T) {
+	s := &Server{
+		watched: []WatchEntry{
+			{Path: "/home/user/dev/devlog", Name: "devlog"},
+			{Path: "/home/user/dev/devtools", Name: "devtools"},
+		},
+	}
+
+	gs := &GnomeSearch{server: s, dialog: true}
+	ids, _ := gs.GetInitialResultSet([]string{"#dev"})
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 prefix results, got %v", ids)
+	}
+	ids, _ = gs.GetInitialResultSet([]string{"devlog"})
+	if len(ids) != 0 {
+		t.Errorf("expected no results without #, got %v", ids)
+	}
+
+	// Terms are rejoined into the note content.
+	ids, _ = gs.GetSubsearchResultSet(ids, []string{"#devlog", "fixed", "the", "parser"})
+	if len(ids) != 1 {
+		t.Fatalf("expected 1 result, got %v", ids)
+	}
+	if project, content := decodeMatchID(ids[0]); project != "devlog" || content != "fixed the parser" {
+		t.Errorf("unexpected result %q", ids[0])
+	}
+
+	// Without a dialog tool, only results with content are offered.
+	gs.dialog = false
+	if ids, _ := gs.GetInitialResultSet([]string{"#devlog"}); len(ids) != 0 {
+		t.Errorf("expected no bare-project results without kdialog, got %v", ids)
+	}
+
+	// Unwatched projects are offered when there is content.
+	ids, _ = gs.GetInitialResultSet([]string{"#newproj", "idea"})
+	if len(ids) != 1 {
+		t.Fatalf("expected unwatched fallback, got %v", ids)
+	}
+	metas, _ := gs.GetResultMetas(ids)
+	if metas[0]["name"].Value() != "#newproj idea" || metas[0]["description"].Value() != "Log a note (unwatched project)" {
+		t.Errorf("unexpected meta: %v", metas[0])
+	}
+}
@@ -0,0 +1,203 @@
+package devlog
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// heatmapShades are the terminal cells for activity levels 0 (none) to 4.
+var heatmapShades = []string{"·", "░", "▒", "▓", "█"}
+
+// heatmapWeekdays label the rows of the grid, Sunday first.
+var heatmapWeekdays = []string{"", "Mon", "", "Wed", "", "Fri", ""}
+
+// heatmapColors are the SVG cell colors for activity levels 0 to 4, those
+// of GitHub's contribution graph.
+var heatmapColors = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+// heatmap is the daily activity of a project, or of all of them, over a
+// year.
+type heatmap struct {
+	Title  string
+	Counts map[string]int // date -> activity
+}
+
+// heatmapActivity is the activity volume of a project on a day: its
+// snapshots, notes, terminal sessions, and Claude Code sessions.
+func heatmapActivity(ps ProjectStats) int {
+	return ps.Snapshots + ps.Notes + ps.TermSessions + ps.ClaudeSessions
+}
+
+// collectHeatmaps returns the heatmap of all projects in year and one for
+// each project active in it, or only the heatmap of project if it is set.
+func collectHeatmaps(cfg Config, state State, year int, project string) []heatmap {
+	total := heatmap{Title: "all projects", Counts: make(map[string]int)}
+	if project != "" {
+		total.Title = project
+	}
+	byProject := make(map[string]heatmap)
+	prefix := fmt.Sprintf("%04d-", year)
+	for _, date := range rawDataDates(cfg) {
+		if !strings.HasPrefix(date, prefix) {
+			continue
+		}
+		for _, ps := range collectStats(cfg, state, []string{date}).Projects {
+			n := heatmapActivity(ps)
+			if n == 0 || project != "" && ps.Project != project {
+				continue
+			}
+			total.Counts[date] += n
+			h, ok := byProject[ps.Project]
+			if !ok {
+				h = heatmap{Title: ps.Project, Counts: make(map[string]int)}
+				byProject[ps.Project] = h
+			}
+			h.Counts[date] += n
+		}
+	}
+
+	maps := []heatmap{total}
+	if project == "" {
+		names := make([]string, 0, len(byProject))
+		for name := range byProject {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			maps = append(maps, byProject[name])
+		}
+	}
+	return maps
+}
+
+// summary returns the total activity, the number of active days, and the
+// busiest day of h.
+func (h heatmap) summary() (total, days int, busiest string) {
+	for date, n := range h.Counts {
+		total += n
+		days++
+		if n > h.Counts[busiest] || n == h.Counts[busiest] && date < busiest {
+			busiest = date
+		}
+	}
+	return total, days, busiest
+}
+
+// heatmapLevel returns the activity level of n on a map whose busiest day
+// has peak, from 0 for none to 4 for the busiest days.
+func heatmapLevel(n, peak int) int {
+	if n <= 0 || peak <= 0 {
+		return 0
+	}
+	return min((4*n+peak-1)/peak, 4)
+}
+
+// heatmapGrid returns the first Sunday on or before January 1 of year and
+// the number of weeks from it to the end of the year. The grid has a column
+// per week and a row per weekday, Sunday first, as on GitHub.
+func heatmapGrid(year int) (time.Time, int) {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	start := jan1.AddDate(0, 0, -int(jan1.Weekday()))
+	dec31 := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	return start, int(dec31.Sub(start).Hours()/24)/7 + 1
+}
+
+// heatmapMonthLabels returns the label row of the grid: each month's name
+// above the week of its first day, where there is room.
+func heatmapMonthLabels(year int, start time.Time, weeks int) string {
+	row := []byte(strings.Repeat(" ", weeks))
+	end := 0
+	for m := time.January; m <= time.December; m++ {
+		first := time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+		col := int(first.Sub(start).Hours()/24) / 7
+		if col >= end && col+3 <= weeks {
+			copy(row[col:], first.Format("Jan"))
+			end = col + 4
+		}
+	}
+	return strings.TrimRight(string(row), " ")
+}
+
+// printHeatmap draws h for year in the terminal, a character per day.
+func printHeatmap(w io.Writer, h heatmap, year int) {
+	total, days, busiest := h.summary()
+	if days == 0 {
+		fmt.Fprintf(w, "%d, %s: no activity\n", year, h.Title)
+		return
+	}
+	fmt.Fprintf(w, "%d, %s: %d activities on %s, busiest %s (%d)\n",
+		year, h.Title, total, plural(days, "day"), busiest, h.Counts[busiest])
+
+	start, weeks := heatmapGrid(year)
+	peak := h.Counts[busiest]
+	fmt.Fprintf(w, "    %s\n", heatmapMonthLabels(year, start, weeks))
+	for day, label := range heatmapWeekdays {
+		var row strings.Builder
+		for week := 0; week < weeks; week++ {
+			d := start.AddDate(0, 0, week*7+day)
+			if d.Year() != year {
+				row.WriteString(" ")
+				continue
+			}
+			row.WriteString(heatmapShades[heatmapLevel(h.Counts[d.Format("2006-01-02")], peak)])
+		}
+		fmt.Fprintf(w, "%-3s %s\n", label, strings.TrimRight(row.String(), " "))
+	}
+	fmt.Fprintf(w, "    Less %s More\n", strings.Join(heatmapShades, " "))
+}
+
+// SVG heatmap geometry, in pixels.
+const (
+	heatmapCell   = 11
+	heatmapStep   = 13 // cell and gap
+	heatmapLeft   = 32 // weekday labels
+	heatmapHeight = 20 + 15 + 7*heatmapStep + 16
+)
+
+// writeHeatmapSVG draws the heatmaps for year one above the other as an SVG
+// image. Each cell has a tooltip with its date and activity.
+func writeHeatmapSVG(w io.Writer, maps []heatmap, year int) error {
+	start, weeks := heatmapGrid(year)
+	width := heatmapLeft + weeks*heatmapStep + 10
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"sans-serif\" font-size=\"10\" fill=\"#57606a\">\n",
+		width, len(maps)*heatmapHeight)
+	for i, h := range maps {
+		top := i * heatmapHeight
+		total, days, busiest := h.summary()
+		peak := h.Counts[busiest]
+		fmt.Fprintf(&b, "<text x=\"0\" y=\"%d\" font-size=\"12\" fill=\"#24292f\">%d, %s: %d activities on %s</text>\n",
+			top+12, year, html.EscapeString(h.Title), total, plural(days, "day"))
+		for m := time.January; m <= time.December; m++ {
+			first := time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+			col := int(first.Sub(start).Hours()/24) / 7
+			fmt.Fprintf(&b, "<text x=\"%d\" y=\"%d\">%s</text>\n", heatmapLeft+col*heatmapStep, top+30, first.Format("Jan"))
+		}
+		for day, label := range heatmapWeekdays {
+			if label == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "<text x=\"0\" y=\"%d\">%s</text>\n", top+35+day*heatmapStep+9, label)
+		}
+		for week := 0; week < weeks; week++ {
+			for day := 0; day < 7; day++ {
+				d := start.AddDate(0, 0, week*7+day)
+				if d.Year() != year {
+					continue
+				}
+				date := d.Format("2006-01-02")
+				n := h.Counts[date]
+				fmt.Fprintf(&b, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"2\" fill=\"%s\"><title>%s: %d</title></rect>\n",
+					heatmapLeft+week*heatmapStep, top+35+day*heatmapStep, heatmapCell, heatmapCell,
+					heatmapColors[heatmapLevel(n, peak)], date, n)
+			}
+		}
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
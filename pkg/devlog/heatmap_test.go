@@ -0,0 +1,95 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeatmapLevel(t *testing.T) {
+	for _, tt := range []struct{ n, peak, want int }{
+		{0, 10, 0}, {1, 10, 1}, {3, 10, 2}, {7, 10, 3}, {10, 10, 4}, {5, 0, 0},
+	} {
+		if got := heatmapLevel(tt.n, tt.peak); got != tt.want {
+			t.Errorf("heatmapLevel(%d, %d) = %d, want %d", tt.n, tt.peak, got, tt.want)
+		}
+	}
+}
+
+func TestPrintHeatmap(t *testing.T) {
+	// 2024-01-01 is a Monday, so the grid starts on Sunday 2023-12-31.
+	h := heatmap{Title: "api", Counts: map[string]int{"2024-01-01": 8, "2024-01-03": 2, "2024-12-31": 4}}
+	var b bytes.Buffer
+	printHeatmap(&b, h, 2024)
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("expected a title, a month row, 7 weekday rows, and a legend:\n%s", b.String())
+	}
+	if want := "2024, api: 14 activities on 3 days, busiest 2024-01-01 (8)"; lines[0] != want {
+		t.Errorf("title = %q, want %q", lines[0], want)
+	}
+	if !strings.HasPrefix(lines[1], "    Jan Feb Mar  Apr") {
+		t.Errorf("month row = %q", lines[1])
+	}
+	sunday := []rune(lines[2])
+	monday := []rune(lines[3])
+	wednesday := []rune(lines[5])
+	if sunday[4] != ' ' || monday[4] != '█' || wednesday[4] != '░' {
+		t.Errorf("first week: %q %q %q", string(sunday[4]), string(monday[4]), string(wednesday[4]))
+	}
+	if !strings.HasPrefix(lines[3], "Mon █·") {
+		t.Errorf("Monday row = %q", lines[3])
+	}
+	// December 31 is a Tuesday in the last column.
+	if tuesday := []rune(lines[4]); tuesday[len(tuesday)-1] != '▒' {
+		t.Errorf("Tuesday row = %q", lines[4])
+	}
+
+	b.Reset()
+	printHeatmap(&b, heatmap{Title: "web", Counts: map[string]int{}}, 2024)
+	if b.String() != "2024, web: no activity\n" {
+		t.Errorf("empty heatmap = %q", b.String())
+	}
+}
+
+func TestCollectHeatmaps(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+	cfg := Config{}
+	claudeDir := ""
+	cfg.ClaudeCodeDir = &claudeDir
+	for _, d := range []string{"2024-01-15", "2023-12-31"} {
+		os.MkdirAll(filepath.Join(rawDir, d), 0o755)
+		os.WriteFile(filepath.Join(rawDir, d, "git-api.log"), []byte("=== SNAPSHOT 09:00 ===\n+a\n\n=== SNAPSHOT 10:00 ===\n+b\n\n"), 0o644)
+	}
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "notes.md"), []byte("### At 10:00 #web\nx\n\n### At 11:00\ny\n"), 0o644)
+
+	maps := collectHeatmaps(cfg, State{}, 2024, "")
+	var got []string
+	for _, h := range maps {
+		total, days, _ := h.summary()
+		got = append(got, h.Title+" "+strings.Repeat("#", total)+" "+strings.Repeat("d", days))
+	}
+	want := []string{"all projects #### d", "api ## d", "general # d", "web # d"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("heatmaps:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	maps = collectHeatmaps(cfg, State{}, 2024, "web")
+	if len(maps) != 1 || maps[0].Title != "web" || maps[0].Counts["2024-01-15"] != 1 {
+		t.Errorf("project heatmap = %+v", maps)
+	}
+
+	var b bytes.Buffer
+	if err := writeHeatmapSVG(&b, maps, 2024); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+	if !strings.HasPrefix(svg, "<svg") || strings.Count(svg, "<rect") != 366 || !strings.Contains(svg, "<title>2024-01-15: 1</title>") {
+		t.Errorf("unexpected SVG:\n%.500s", svg)
+	}
+}
@@ -0,0 +1,40 @@
+package devlog
+
+import (
+	"strings"
+)
+
+// pinTag is the semantic tag of a note pinned as a highlight with `devlog
+// note --pin`.
+const pinTag = "pin"
+
+// noteHeadingHasTag reports whether a notes entry heading carries #tag.
+func noteHeadingHasTag(headings *noteHeadingFormat, heading, tag string) bool {
+	_, rest, ok := parseNoteHeading(headings, heading)
+	return ok && containsString(strings.Fields(rest), "#"+tag)
+}
+
+// pinnedNotes returns the entries of notes content that are pinned, joined
+// by blank lines.
+func pinnedNotes(headings *noteHeadingFormat, content string) string {
+	var pinned []string
+	for _, entry := range splitNoteEntries(headings, content) {
+		heading, _, _ := strings.Cut(entry, "\n")
+		if noteHeadingHasTag(headings, heading, pinTag) {
+			pinned = append(pinned, entry)
+		}
+	}
+	return strings.Join(pinned, "\n\n")
+}
+
+// collectHighlights returns the pinned notes of dates, filtered by project
+// as for collectNotes. Dates without any are left out.
+func collectHighlights(cfg Config, dates []string, project string) []dayNotes {
+	var days []dayNotes
+	for _, d := range collectNotes(cfg, dates, project) {
+		if pinned := pinnedNotes(cfg.noteHeadings(), d.Content); pinned != "" {
+			days = append(days, dayNotes{Date: d.Date, Content: pinned})
+		}
+	}
+	return days
+}
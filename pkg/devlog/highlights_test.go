@@ -0,0 +1,49 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinnedNotes(t *testing.T) {
+	content := "### At 09:00 #alpha #pin\nShipped v2 to 40% of users.\n\n" +
+		"### At 10:00 #alpha\nroutine\n\n" +
+		"### At 11:00 #pinned\nnot a pin\n\n" +
+		"### At 12:00 #pin\nGeneral highlight.\n"
+	want := "### At 09:00 #alpha #pin\nShipped v2 to 40% of users.\n\n### At 12:00 #pin\nGeneral highlight."
+	if got := pinnedNotes(nil, content); got != want {
+		t.Errorf("pinnedNotes = %q, want %q", got, want)
+	}
+	if tags, _ := noteHeadingTags(nil, "### At 09:00 #alpha #pin"); len(tags) != 1 || tags[0] != "alpha" {
+		t.Errorf("pin should not be a project, got %v", tags)
+	}
+}
+
+func TestCollectHighlights(t *testing.T) {
+	rawDir := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	cfg := Config{}
+	for date, content := range map[string]string{
+		"2024-01-15": "### At 09:00 #alpha #pin\nFirst.\n\n### At 10:00 #beta #pin\nSecond.\n",
+		"2024-01-16": "### At 09:00 #alpha\nNot pinned.\n",
+	} {
+		os.MkdirAll(filepath.Join(rawDir, date), 0o755)
+		os.WriteFile(filepath.Join(rawDir, date, "notes.md"), []byte(content), 0o644)
+	}
+	dates, _ := parseDateRange("2024-01-15..2024-01-16", time.Now())
+
+	days := collectHighlights(cfg, dates, "")
+	if len(days) != 1 || days[0].Date != "2024-01-15" || strings.Count(days[0].Content, "#pin") != 2 {
+		t.Errorf("highlights = %+v", days)
+	}
+	days = collectHighlights(cfg, dates, "beta")
+	if len(days) != 1 || days[0].Content != "### At 10:00 #beta #pin\nSecond." {
+		t.Errorf("beta highlights = %+v", days)
+	}
+	if prompt := assemblePrompt(nil, "alpha", "2024-01-15", map[string]string{"notes.md": "x"}, "", ""); !strings.Contains(prompt, "Keep every pinned note (#pin)") {
+		t.Error("prompt does not ask to keep pinned notes")
+	}
+}
@@ -0,0 +1,83 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hook scripts written by devlog, which may be
+// replaced on reinstall. Other hooks are never overwritten.
+const hookMarker = "# Installed by devlog install-hooks"
+
+// hookScript returns the script for a git hook ("post-commit" or
+// "post-checkout") that logs a note for project, in the active profile.
+// Failures are ignored so a missing devlog never breaks git.
+func hookScript(hook, devlog, project string) string {
+	note := shellQuote(devlog)
+	if p := activeProfile(); p != "" {
+		note += " --profile " + shellQuote(p)
+	}
+	note += " note -p " + shellQuote(project)
+	var body string
+	switch hook {
+	case "post-commit":
+		body = note + ` -m "commit: $(git log -1 --format=%s)"`
+	case "post-checkout":
+		// $3 is 1 for a branch checkout, 0 for a file checkout.
+		body = `[ "$3" = 1 ] || exit 0
+branch=$(git symbolic-ref --short -q HEAD) || branch="detached HEAD at $(git rev-parse --short HEAD)"
+` + note + ` -m "checkout: switched to $branch"`
+	}
+	return "#!/bin/sh\n" + hookMarker + "\n" + body + " >/dev/null 2>&1 || true\n"
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// resolveHooksDir returns the hooks directory of the repo at repoPath,
+// honoring core.hooksPath.
+func resolveHooksDir(repoPath string) (string, error) {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", repoPath)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return dir, nil
+}
+
+// installHook writes script as the named hook in hooksDir. An existing hook
+// not written by devlog is left alone and reported as an error.
+func installHook(hooksDir, hook, script string) error {
+	path := filepath.Join(hooksDir, hook)
+	if data, err := os.ReadFile(path); err == nil && !strings.Contains(string(data), hookMarker) {
+		return fmt.Errorf("%s already exists; add a call to devlog to it manually", path)
+	}
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return fmt.Errorf("creating hooks dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return fmt.Errorf("writing %s hook: %w", hook, err)
+	}
+	// WriteFile keeps the mode of an existing file.
+	return os.Chmod(path, 0o755)
+}
+
+// devlogCommand returns how hooks should invoke devlog: by name if it is on
+// PATH, otherwise by the absolute path of the running binary.
+func devlogCommand() string {
+	if _, err := exec.LookPath("devlog"); err == nil {
+		return "devlog"
+	}
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return "devlog"
+}
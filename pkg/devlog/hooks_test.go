@@ -0,0 +1,62 @@
+package devlog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallHook(t *testing.T) {
+	repo := initTestRepo(t)
+	hooksDir, err := resolveHooksDir(repo)
+	if err != nil {
+		t.Fatalf("resolveHooksDir: %v", err)
+	}
+
+	// The hook logs the commit subject through the devlog command it is given;
+	// a stand-in script records its arguments instead.
+	argsFile := filepath.Join(t.TempDir(), "args")
+	fake := filepath.Join(t.TempDir(), "devlog")
+	os.WriteFile(fake, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\" > "+shellQuote(argsFile)+"\n"), 0o755)
+
+	if err := installHook(hooksDir, "post-commit", hookScript("post-commit", fake, "my-proj")); err != nil {
+		t.Fatalf("installHook: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(hooksDir, "post-commit"))
+	if err != nil || info.Mode()&0o111 == 0 {
+		t.Fatalf("hook not installed as executable: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a\n"), 0o644)
+	exec.Command("git", "-C", repo, "add", "-A").Run()
+	if out, err := exec.Command("git", "-C", repo, "commit", "-m", "Fix the parser").CombinedOutput(); err != nil {
+		t.Fatalf("commit: %s: %v", out, err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if string(args) != "note\n-p\nmy-proj\n-m\ncommit: Fix the parser\n" {
+		t.Errorf("unexpected devlog invocation: %q", args)
+	}
+
+	// Reinstalling replaces devlog's own hook, but a foreign hook is kept.
+	if err := installHook(hooksDir, "post-commit", hookScript("post-commit", "devlog", "other")); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+	os.WriteFile(filepath.Join(hooksDir, "post-checkout"), []byte("#!/bin/sh\necho mine\n"), 0o755)
+	if err := installHook(hooksDir, "post-checkout", hookScript("post-checkout", "devlog", "other")); err == nil {
+		t.Error("expected error for existing foreign hook")
+	}
+	data, _ := os.ReadFile(filepath.Join(hooksDir, "post-checkout"))
+	if !strings.Contains(string(data), "echo mine") {
+		t.Error("foreign hook was overwritten")
+	}
+}
+
+func TestHookScriptProfile(t *testing.T) {
+	t.Setenv("DEVLOG_PROFILE", "work")
+	script := hookScript("post-commit", "devlog", "my-proj")
+	if !strings.Contains(script, "'devlog' --profile 'work' note -p 'my-proj'") {
+		t.Errorf("hook should pass the active profile, got:\n%s", script)
+	}
+}
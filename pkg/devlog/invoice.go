@@ -0,0 +1,140 @@
+package devlog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// invoiceExcerptLen is the longest work description taken from a summary
+// for an invoice line.
+const invoiceExcerptLen = 200
+
+// invoiceLine is the billable time on one project on one date.
+type invoiceLine struct {
+	Date        string
+	Project     string
+	Client      string
+	BillingCode string
+	Minutes     int
+	Description string
+}
+
+// invoice is the output of `devlog invoice`.
+type invoice struct {
+	Month string // YYYY-MM
+	Title string // client or project names
+	Lines []invoiceLine
+}
+
+func (inv invoice) totalMinutes() int {
+	total := 0
+	for _, l := range inv.Lines {
+		total += l.Minutes
+	}
+	return total
+}
+
+// monthDates returns every date in month ("YYYY-MM").
+func monthDates(month string) ([]string, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month %q, expected YYYY-MM", month)
+	}
+	var dates []string
+	for d := start; d.Month() == start.Month(); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+	return dates, nil
+}
+
+// invoiceProjects returns the projects to bill: those named, plus every
+// watched project of client.
+func invoiceProjects(state State, projects []string, client string) []string {
+	selected := append([]string(nil), projects...)
+	if client != "" {
+		for _, w := range state.Watched {
+			if w.Client == client && !containsString(selected, w.Name) {
+				selected = append(selected, w.Name)
+			}
+		}
+	}
+	return selected
+}
+
+// summaryExcerpt returns the first line of a project's summary section with
+// list markers and emphasis removed, shortened to max bytes.
+func summaryExcerpt(text string, max int) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*• "))
+		return shorten(strings.ReplaceAll(line, "**", ""), max)
+	}
+	return ""
+}
+
+// collectInvoice builds the invoice for projects over month from the
+// time-tracking report, describing each day's work with an excerpt of the
+// project's summary.
+func collectInvoice(cfg Config, state State, month string, projects []string, idle time.Duration) (invoice, error) {
+	dates, err := monthDates(month)
+	if err != nil {
+		return invoice{}, err
+	}
+	meta := make(map[string]WatchEntry)
+	for _, w := range state.Watched {
+		meta[w.Name] = w
+	}
+
+	inv := invoice{Month: month}
+	report := collectTime(cfg, state, dates, idle)
+	for _, e := range report.Entries {
+		if !containsString(projects, e.Project) {
+			continue
+		}
+		line := invoiceLine{
+			Date:        e.Date,
+			Project:     e.Project,
+			Client:      meta[e.Project].Client,
+			BillingCode: meta[e.Project].BillingCode,
+			Minutes:     e.Minutes,
+		}
+		if content, err := readSummary(cfg, e.Date); err == nil {
+			for _, s := range selectSummarySections(content, []string{e.Project}) {
+				line.Description = summaryExcerpt(s.Text, invoiceExcerptLen)
+			}
+		}
+		inv.Lines = append(inv.Lines, line)
+	}
+	return inv, nil
+}
+
+func writeInvoiceCSV(w io.Writer, inv invoice) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "project", "client", "billing_code", "hours", "description"})
+	for _, l := range inv.Lines {
+		cw.Write([]string{l.Date, l.Project, l.Client, l.BillingCode, formatHours(l.Minutes), l.Description})
+	}
+	cw.Write([]string{"total", "", "", "", formatHours(inv.totalMinutes()), ""})
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeInvoiceMarkdown(w io.Writer, inv invoice) error {
+	month, _ := time.Parse("2006-01", inv.Month)
+	fmt.Fprintf(w, "# %s: %s\n\n", inv.Title, month.Format("January 2006"))
+	fmt.Fprintln(w, "| Date | Project | Billing code | Hours | Work |")
+	fmt.Fprintln(w, "|------|---------|--------------|------:|------|")
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, l := range inv.Lines {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			l.Date, l.Project, cell.Replace(l.BillingCode), formatHours(l.Minutes), cell.Replace(l.Description))
+	}
+	_, err := fmt.Fprintf(w, "| **Total** | | | **%s** | |\n", formatHours(inv.totalMinutes()))
+	return err
+}
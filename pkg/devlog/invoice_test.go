@@ -0,0 +1,93 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonthDates(t *testing.T) {
+	dates, err := monthDates("2024-02")
+	if err != nil {
+		t.Fatalf("monthDates: %v", err)
+	}
+	if len(dates) != 29 || dates[0] != "2024-02-01" || dates[28] != "2024-02-29" {
+		t.Errorf("unexpected dates %v", dates)
+	}
+	if _, err := monthDates("2024-13"); err == nil {
+		t.Error("expected error for invalid month")
+	}
+}
+
+func TestSummaryExcerpt(t *testing.T) {
+	text := "### Work\n\n- Fixed the **connection pool** leak in the retry path\n- Other"
+	if got := summaryExcerpt(text, 200); got != "Fixed the connection pool leak in the retry path" {
+		t.Errorf("unexpected excerpt %q", got)
+	}
+	if got := summaryExcerpt(text, 24); got != "Fixed the connection…" {
+		t.Errorf("unexpected shortened excerpt %q", got)
+	}
+}
+
+func TestInvoiceProjects(t *testing.T) {
+	state := State{Watched: []WatchEntry{
+		{Name: "api", Client: "acme"},
+		{Name: "web", Client: "acme"},
+		{Name: "hobby"},
+	}}
+	got := invoiceProjects(state, []string{"api"}, "acme")
+	if strings.Join(got, ",") != "api,web" {
+		t.Errorf("unexpected projects %v", got)
+	}
+}
+
+func TestCollectInvoice(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+	os.MkdirAll(filepath.Join(rawDir, "2024-01-15"), 0o755)
+	os.MkdirAll(logDir, 0o755)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "git-api.log"),
+		[]byte("=== SNAPSHOT 09:10 ===\n+a\n\n=== SNAPSHOT 09:30 ===\n+b\n\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "git-hobby.log"),
+		[]byte("=== SNAPSHOT 20:00 ===\n+c\n\n"), 0o644)
+	os.WriteFile(filepath.Join(logDir, "2024-01-15.md"),
+		[]byte("# 2024-01-15\n\n## api\n\n- Added rate limiting, to the gateway\n"), 0o644)
+
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty}
+	state := State{Watched: []WatchEntry{{Path: "/src/api", Name: "api", Client: "acme", BillingCode: "ACME-7"}}}
+	inv, err := collectInvoice(cfg, state, "2024-01", []string{"api"}, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("collectInvoice: %v", err)
+	}
+	if len(inv.Lines) != 1 {
+		t.Fatalf("expected one line for api, got %+v", inv.Lines)
+	}
+	l := inv.Lines[0]
+	if l.Minutes != 25 || l.BillingCode != "ACME-7" || l.Description != "Added rate limiting, to the gateway" {
+		t.Errorf("unexpected line %+v", l)
+	}
+
+	inv.Title = "acme"
+	var buf bytes.Buffer
+	writeInvoiceCSV(&buf, inv)
+	want := "date,project,client,billing_code,hours,description\n" +
+		"2024-01-15,api,acme,ACME-7,0.42,\"Added rate limiting, to the gateway\"\n" +
+		"total,,,,0.42,\n"
+	if buf.String() != want {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	writeInvoiceMarkdown(&buf, inv)
+	out := buf.String()
+	if !strings.HasPrefix(out, "# acme: January 2024\n") || !strings.Contains(out, "| 2024-01-15 | api | ACME-7 | 0.42 | Added rate limiting, to the gateway |") {
+		t.Errorf("unexpected markdown:\n%s", out)
+	}
+}
@@ -0,0 +1,174 @@
+package devlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+type IPCRequest struct {
+	Command string          `json:"command"`
+	Args    json.RawMessage `json:"args,omitempty"`
+}
+
+type WatchArgs struct {
+	Path        string `json:"path"`
+	Name        string `json:"name,omitempty"`
+	Plain       bool   `json:"plain,omitempty"`
+	Subdir      string `json:"subdir,omitempty"`
+	Client      string `json:"client,omitempty"`
+	BillingCode string `json:"billing_code,omitempty"`
+	// Set is metadata to set on the entry, new or already watched; see
+	// setWatchField.
+	Set map[string]string `json:"set,omitempty"`
+}
+
+type UnwatchArgs struct {
+	Path string `json:"path"`
+}
+
+// WatchBatchArgs are the args of watch_batch, which watches several repos
+// and updates state once.
+type WatchBatchArgs struct {
+	Repos []WatchArgs `json:"repos"`
+}
+
+// UnwatchBatchArgs are the args of unwatch_batch. All unwatches every repo
+// and ignores Paths.
+type UnwatchBatchArgs struct {
+	Paths []string `json:"paths,omitempty"`
+	All   bool     `json:"all,omitempty"`
+}
+
+type RenameArgs struct {
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+// CancelGenData is the response data of cancel_gen: the number of
+// generations cancelled.
+type CancelGenData struct {
+	Cancelled int `json:"cancelled"`
+}
+
+type IPCResponse struct {
+	OK    bool            `json:"ok"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+type StatusData struct {
+	Watched []WatchEntry `json:"watched"`
+	PID     int          `json:"pid"`
+	LogPath string       `json:"log_path,omitempty"`
+	Repos   []RepoStatus `json:"repos"`
+	// Unwatched are the repos with recent Claude Code sessions that are not
+	// watched, see unwatchedClaudeRepos.
+	Unwatched []UnwatchedRepo `json:"unwatched,omitempty"`
+	// Stale are the watched entries with no snapshot in stale_watch_days.
+	Stale []StaleRepo `json:"stale,omitempty"`
+	// OnBattery is set while the machine runs on battery power, see
+	// startPowerMonitor.
+	OnBattery bool `json:"on_battery,omitempty"`
+}
+
+// StaleRepo is a watched entry with no snapshot in stale_watch_days days.
+// LastActive is the date of its last snapshot, see lastActive.
+type StaleRepo struct {
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	LastActive string `json:"last_active,omitempty"`
+}
+
+// UnwatchedRepo is a git repo with recent Claude Code sessions that devlog
+// does not watch.
+type UnwatchedRepo struct {
+	Path        string    `json:"path"`
+	LastSession time.Time `json:"last_session"`
+}
+
+// RepoStatus reports the outcome of the most recent snapshot of a watched
+// repo. LastSnapshotAt is nil until the first snapshot attempt completes.
+type RepoStatus struct {
+	Path           string     `json:"path"`
+	Name           string     `json:"name"`
+	LastSnapshotAt *time.Time `json:"last_snapshot_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	// ConsecutiveFailures counts failed snapshots since the last success.
+	ConsecutiveFailures int `json:"consecutive_failures,omitempty"`
+}
+
+// PingData is the response to the ping command, carrying what `devlog
+// health` needs to judge whether the server is working.
+type PingData struct {
+	PID              int          `json:"pid"`
+	SnapshotInterval int          `json:"snapshot_interval"` // in effect, see snapshotInterval
+	LastTickAt       *time.Time   `json:"last_tick_at,omitempty"`
+	Repos            []RepoStatus `json:"repos"`
+	OnBattery        bool         `json:"on_battery,omitempty"`
+}
+
+type WatchResponseData struct {
+	Watched []WatchEntry `json:"watched"`
+	Errors  []BatchError `json:"errors,omitempty"`
+}
+
+// BatchError reports a repo that a batch command could not process.
+type BatchError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// errServerRunning is returned by listenIPC when another server is
+// listening on the socket.
+var errServerRunning = errors.New("devlog server is already running")
+
+func ipcSend(req IPCRequest) (IPCResponse, error) {
+	conn, err := dialIPC(socketPath())
+	if err != nil {
+		return IPCResponse{}, fmt.Errorf("connecting to server: %w", err)
+	}
+	defer conn.Close()
+
+	// Requests and responses are one JSON value per line. A decoder reads a
+	// whole value however many reads it takes, so batch and status
+	// responses over many repos are not cut short.
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return IPCResponse{}, fmt.Errorf("writing request: %w", err)
+	}
+
+	var resp IPCResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return IPCResponse{}, fmt.Errorf("reading response: %w", err)
+	}
+	return resp, nil
+}
+
+func isServerNotRunning(err error) bool {
+	if err == nil {
+		return false
+	}
+	// A missing socket, or on Windows a missing named pipe
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		if errors.Is(opErr.Err, syscall.ECONNREFUSED) {
+			return true
+		}
+		// Also check for "no such file or directory" (socket doesn't exist)
+		if opErr.Err != nil {
+			var sysErr *os.SyscallError
+			if errors.As(opErr.Err, &sysErr) {
+				return errors.Is(sysErr.Err, syscall.ENOENT) || errors.Is(sysErr.Err, syscall.ECONNREFUSED)
+			}
+		}
+	}
+	return false
+}
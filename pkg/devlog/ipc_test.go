@@ -1,8 +1,10 @@
-package main
+package devlog
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestIPCRequestSerialization(t *testing.T) {
@@ -78,3 +80,35 @@ func TestIsServerNotRunning(t *testing.T) {
 		t.Errorf("expected isServerNotRunning=true for error: %v", err)
 	}
 }
+
+func TestPingDataSerialization(t *testing.T) {
+	tick := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	ping := PingData{
+		PID:              42,
+		SnapshotInterval: 300,
+		LastTickAt:       &tick,
+		Repos: []RepoStatus{
+			{Path: "/a", Name: "a", LastSnapshotAt: &tick},
+			{Path: "/b", Name: "b", LastSnapshotAt: &tick, LastError: "git add: failed"},
+		},
+	}
+
+	data, err := json.Marshal(ping)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"last_snapshot_at"`) || !strings.Contains(string(data), `"last_error"`) {
+		t.Errorf("expected snake_case health fields, got %s", data)
+	}
+
+	var decoded PingData
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.LastTickAt == nil || !decoded.LastTickAt.Equal(tick) {
+		t.Errorf("last tick did not round-trip: %v", decoded.LastTickAt)
+	}
+	if len(decoded.Repos) != 2 || decoded.Repos[1].LastError != "git add: failed" {
+		t.Errorf("repos did not round-trip: %+v", decoded.Repos)
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !windows
+
+package devlog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func socketPath() string {
+	return runtimePath(".sock")
+}
+
+// listenIPC listens on the unix socket at path, removing a stale socket left
+// by a server that did not shut down cleanly. Only the user may connect to
+// the socket.
+func listenIPC(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		// Socket exists — check if a server is listening
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			conn.Close()
+			return nil, errServerRunning
+		}
+		// Not listening — stale socket
+		os.Remove(path)
+	}
+	// The socket is created private, rather than chmodded after Listen,
+	// which would leave a moment for others to connect. The umask is the
+	// process's, but the server sets it up before starting anything else.
+	umask := syscall.Umask(0o177)
+	ln, err := net.Listen("unix", path)
+	syscall.Umask(umask)
+	return ln, err
+}
+
+func dialIPC(path string) (net.Conn, error) {
+	if err := checkRuntimeDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return net.Dial("unix", path)
+}
+
+// checkPrivateDir reports an error unless dir is a directory, not a symlink,
+// owned by the user and with no permissions for anyone else.
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("runtime dir %s is not a directory", dir)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("runtime dir %s is owned by uid %d, not %d", dir, st.Uid, os.Getuid())
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return fmt.Errorf("runtime dir %s is accessible by other users (mode %04o)", dir, perm)
+	}
+	return nil
+}
+
+func isProcessRunning(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Unix, FindProcess always succeeds. Send signal 0 to check.
+	err = proc.Signal(syscall.Signal(0))
+	return err == nil
+}
+
+// processStartTime returns when process pid started, to tell it from a later
+// process that was given the same PID: from /proc on Linux, and from ps
+// elsewhere.
+func processStartTime(pid int) (string, error) {
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		// starttime is the 22nd field, the 20th after the command, which is
+		// in parentheses and may contain spaces.
+		if i := strings.LastIndexByte(string(data), ')'); i >= 0 {
+			if fields := strings.Fields(string(data[i+1:])); len(fields) > 19 {
+				return fields[19], nil
+			}
+		}
+	}
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	start := strings.TrimSpace(string(out))
+	if start == "" {
+		return "", fmt.Errorf("no process %d", pid)
+	}
+	return start, nil
+}
+
+// killChildrenOnExit does nothing on Unix, where a generation stopped with
+// SIGTERM kills its commands itself.
+func killChildrenOnExit() {}
+
+// stopProcess asks process pid to stop with SIGTERM.
+func stopProcess(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Signal(syscall.SIGTERM)
+}
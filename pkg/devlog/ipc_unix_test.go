@@ -0,0 +1,151 @@
+//go:build !windows
+
+package devlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListenIPCSocketMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devlog.sock")
+	ln, err := listenIPC(path)
+	if err != nil {
+		t.Fatalf("listenIPC: %v", err)
+	}
+	defer ln.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %04o, want 0600", perm)
+	}
+
+	// A second server finds the first one listening.
+	if _, err := listenIPC(path); err != errServerRunning {
+		t.Errorf("second listenIPC: got %v, want errServerRunning", err)
+	}
+}
+
+func TestIPCLargeMessages(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	s := newServer(Config{SnapshotInterval: 300})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	for i := 0; i < 2000; i++ {
+		s.watched = append(s.watched, WatchEntry{Path: fmt.Sprintf("/home/user/dev/some-longer-repo-name-%04d", i), Name: fmt.Sprintf("repo-%04d", i)})
+	}
+	ln, err := listenIPC(socketPath())
+	if err != nil {
+		t.Fatalf("listenIPC: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handleConn(conn)
+		}
+	}()
+
+	// A response over 64KB arrives whole.
+	resp, err := ipcSend(IPCRequest{Command: "status"})
+	if err != nil || !resp.OK || len(resp.Data) < 64*1024 {
+		t.Fatalf("status = %d bytes, %v", len(resp.Data), err)
+	}
+	var status StatusData
+	if err := json.Unmarshal(resp.Data, &status); err != nil || len(status.Watched) != 2000 {
+		t.Errorf("status has %d watched repos, %v", len(status.Watched), err)
+	}
+
+	// So does a request over 64KB.
+	args, _ := json.Marshal(strings.Repeat("x", 100*1024))
+	resp, err = ipcSend(IPCRequest{Command: "nonsense", Args: args})
+	if err != nil || resp.Error != "unknown command: nonsense" {
+		t.Errorf("large request: %+v, %v", resp, err)
+	}
+}
+
+func TestCheckPeerSameUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devlog.sock")
+	ln, err := listenIPC(path)
+	if err != nil {
+		t.Fatalf("listenIPC: %v", err)
+	}
+	defer ln.Close()
+
+	client, err := dialIPC(path)
+	if err != nil {
+		t.Fatalf("dialIPC: %v", err)
+	}
+	defer client.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	defer conn.Close()
+	if err := checkPeer(conn); err != nil {
+		t.Errorf("checkPeer: %v", err)
+	}
+}
+
+func TestPrepareRuntimeDir(t *testing.T) {
+	tmp := t.TempDir()
+
+	// Directories other than the /tmp fallback are only created.
+	dir := filepath.Join(tmp, "run")
+	if err := prepareRuntimeDir(dir); err != nil {
+		t.Fatalf("prepareRuntimeDir: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("runtime dir not created with mode 0700: %v, %v", info, err)
+	}
+
+	if err := checkPrivateDir(dir); err != nil {
+		t.Errorf("checkPrivateDir(0700 dir): %v", err)
+	}
+	os.Chmod(dir, 0o755)
+	if err := checkPrivateDir(dir); err == nil {
+		t.Error("checkPrivateDir(0755 dir): expected an error")
+	}
+	link := filepath.Join(tmp, "link")
+	os.Symlink(dir, link)
+	if err := checkPrivateDir(link); err == nil {
+		t.Error("checkPrivateDir(symlink): expected an error")
+	}
+}
+
+func TestClientChecksRuntimeDir(t *testing.T) {
+	// A profile of its own keeps the /tmp fallback dir apart from others.
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	t.Setenv("DEVLOG_PROFILE", fmt.Sprintf("test%d", os.Getpid()))
+	dir := runtimeFallbackDir()
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(pidFilePath(), []byte("1\n"), 0o644)
+
+	if _, err := dialIPC(socketPath()); err == nil || !strings.Contains(err.Error(), "accessible by other users") {
+		t.Errorf("dialIPC in a shared runtime dir: got %v", err)
+	}
+	if _, err := readPidFile(); err == nil {
+		t.Error("readPidFile in a shared runtime dir: expected an error")
+	}
+
+	// Without the dir, the server is just not running.
+	os.RemoveAll(dir)
+	if _, err := dialIPC(socketPath()); !isServerNotRunning(err) {
+		t.Errorf("dialIPC without a runtime dir: got %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package devlog
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// On Windows the server listens on a named pipe instead of a unix socket.
+// The pipe functions that package syscall lacks are called from kernel32.
+
+var (
+	modkernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procCreateNamedPipeW = modkernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe = modkernel32.NewProc("ConnectNamedPipe")
+	procWaitNamedPipeW   = modkernel32.NewProc("WaitNamedPipeW")
+
+	procCreateJobObjectW         = modkernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = modkernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = modkernel32.NewProc("AssignProcessToJobObject")
+)
+
+const (
+	pipeAccessDuplex          = 0x3
+	fileFlagFirstPipeInstance = 0x80000
+	pipeRejectRemoteClients   = 0x8
+	pipeUnlimitedInstances    = 255
+	pipeBufferSize            = 64 * 1024
+	pipeBusyWaitMillis        = 5000
+
+	errorPipeBusy      syscall.Errno = 231
+	errorPipeConnected syscall.Errno = 535
+
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+
+	jobObjectExtendedLimitInformation = 9
+	jobObjectLimitKillOnJobClose      = 0x2000
+)
+
+// socketPath returns the server's named pipe. Pipe names are shared by all
+// users of the machine, so the name includes the user's SID.
+func socketPath() string {
+	return `\\.\pipe\` + appName() + "-" + currentUID()
+}
+
+// pipeAddr is the net.Addr of a named pipe.
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn is a connected named pipe instance. Handles are opened for
+// synchronous I/O, so deadlines are not supported.
+type pipeConn struct {
+	*os.File
+	addr   pipeAddr
+	server bool
+}
+
+func newPipeConn(h syscall.Handle, path string, server bool) *pipeConn {
+	return &pipeConn{File: os.NewFile(uintptr(h), path), addr: pipeAddr(path), server: server}
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.addr }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.addr }
+
+// Close closes the pipe. The server end first waits for the client to read
+// what was written, which closing would otherwise discard.
+func (c *pipeConn) Close() error {
+	if c.server {
+		syscall.FlushFileBuffers(syscall.Handle(c.Fd()))
+	}
+	return c.File.Close()
+}
+
+// createPipe creates an instance of the named pipe path. Only the first
+// instance may have first set, which fails if the pipe already exists.
+func createPipe(path string, first bool) (syscall.Handle, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return syscall.InvalidHandle, err
+	}
+	mode := uint32(pipeAccessDuplex)
+	if first {
+		mode |= fileFlagFirstPipeInstance
+	}
+	h, _, err := procCreateNamedPipeW.Call(uintptr(unsafe.Pointer(name)), uintptr(mode),
+		pipeRejectRemoteClients, pipeUnlimitedInstances, pipeBufferSize, pipeBufferSize, 0, 0)
+	if syscall.Handle(h) == syscall.InvalidHandle {
+		return syscall.InvalidHandle, os.NewSyscallError("CreateNamedPipe", err)
+	}
+	return syscall.Handle(h), nil
+}
+
+// pipeListener accepts connections on a named pipe. A pipe exists only while
+// it has instances, so one is always kept waiting for the next client.
+type pipeListener struct {
+	path string
+
+	mu     sync.Mutex
+	next   syscall.Handle // the instance the next client connects to
+	closed bool
+}
+
+// listenIPC listens on the named pipe path. If the pipe exists, another
+// server is listening on it: unlike a unix socket, a pipe cannot be stale.
+func listenIPC(path string) (net.Listener, error) {
+	h, err := createPipe(path, true)
+	if errors.Is(err, syscall.ERROR_ACCESS_DENIED) {
+		return nil, errServerRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pipeListener{path: path, next: h}, nil
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	h := l.next
+	l.next = syscall.InvalidHandle
+	l.mu.Unlock()
+
+	if h == syscall.InvalidHandle {
+		var err error
+		if h, err = createPipe(l.path, false); err != nil {
+			return nil, err
+		}
+	}
+	if r, _, err := procConnectNamedPipe.Call(uintptr(h), 0); r == 0 && err != errorPipeConnected {
+		syscall.CloseHandle(h)
+		return nil, os.NewSyscallError("ConnectNamedPipe", err)
+	}
+
+	// Create the next instance before handing this one out, so that the
+	// pipe does not disappear when the connection is closed. If this fails,
+	// the next Accept tries again.
+	next, err := createPipe(l.path, false)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		syscall.CloseHandle(h)
+		if err == nil {
+			syscall.CloseHandle(next)
+		}
+		return nil, net.ErrClosed
+	}
+	if err == nil {
+		l.next = next
+	}
+	return newPipeConn(h, l.path, true), nil
+}
+
+// Close stops the listener. An Accept blocked waiting for a client is
+// released by connecting to it.
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return net.ErrClosed
+	}
+	l.closed = true
+	next := l.next
+	l.next = syscall.InvalidHandle
+	l.mu.Unlock()
+
+	if next != syscall.InvalidHandle {
+		return syscall.CloseHandle(next)
+	}
+	if conn, err := dialIPC(l.path); err == nil {
+		conn.Close()
+	}
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.path)
+}
+
+// dialIPC connects to the named pipe path, waiting for a free instance if
+// all are busy.
+func dialIPC(path string) (net.Conn, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_EXISTING, 0, 0)
+		if err == nil {
+			return newPipeConn(h, path, false), nil
+		}
+		if err != errorPipeBusy {
+			return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: pipeAddr(path), Err: os.NewSyscallError("CreateFile", err)}
+		}
+		if r, _, err := procWaitNamedPipeW.Call(uintptr(unsafe.Pointer(name)), pipeBusyWaitMillis); r == 0 {
+			return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: pipeAddr(path), Err: os.NewSyscallError("WaitNamedPipe", err)}
+		}
+	}
+}
+
+// checkPrivateDir does nothing on Windows, where the runtime dir is in the
+// user's own temp dir.
+func checkPrivateDir(dir string) error {
+	return nil
+}
+
+// checkPeer does nothing on Windows. By the pipe's default security, other
+// users can only open it for reading, so they cannot send requests.
+func checkPeer(conn net.Conn) error {
+	return nil
+}
+
+// isProcessRunning reports whether process pid has not exited. Signal 0 is
+// not supported on Windows, so the process's exit code is checked instead.
+func isProcessRunning(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// The process exists but belongs to someone else.
+		return errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// stopProcess stops process pid. Windows has no SIGTERM to send, so the
+// process is terminated. The gen_cmd and comp_cmd commands of a generation
+// go with it, see killChildrenOnExit.
+func stopProcess(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Kill()
+}
+
+// processStartTime returns when process pid was created, to tell it from a
+// later process that was given the same PID.
+func processStartTime(pid int) (string, error) {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer syscall.CloseHandle(h)
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(created.Nanoseconds(), 10), nil
+}
+
+// jobObjectExtendedLimitInfo is JOBOBJECT_EXTENDED_LIMIT_INFORMATION.
+type jobObjectExtendedLimitInfo struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+	IOCounters              [6]uint64
+	ProcessMemoryLimit      uintptr
+	JobMemoryLimit          uintptr
+	PeakProcessMemoryUsed   uintptr
+	PeakJobMemoryUsed       uintptr
+}
+
+// killChildrenOnExit puts this process in a job object that kills the
+// processes in it when it is closed, which Windows does when the process
+// exits or is terminated. The child processes it starts join the job, so
+// that a generation stopped by stopProcess takes its commands with it,
+// without killing a process tree by PID.
+func killChildrenOnExit() {
+	job, _, _ := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return
+	}
+	info := jobObjectExtendedLimitInfo{LimitFlags: jobObjectLimitKillOnJobClose}
+	if r, _, _ := procSetInformationJobObject.Call(job, jobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info)); r == 0 {
+		syscall.CloseHandle(syscall.Handle(job))
+		return
+	}
+	self, _ := syscall.GetCurrentProcess()
+	if r, _, _ := procAssignProcessToJobObject.Call(job, uintptr(self)); r == 0 {
+		syscall.CloseHandle(syscall.Handle(job))
+	}
+	// The handle stays open until the process exits.
+}
@@ -0,0 +1,410 @@
+package devlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// Statuses of a GenJob.
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// maxFinishedJobs is how many finished jobs the server remembers for
+// `devlog jobs`.
+const maxFinishedJobs = 50
+
+// jobPollInterval is how often `devlog gen` checks on the job it queued.
+var jobPollInterval = 500 * time.Millisecond
+
+// GenArgs are the args of gen, which queues a generation in the server.
+type GenArgs struct {
+	Date     string   `json:"date"`
+	Projects []string `json:"projects,omitempty"`
+}
+
+// GenJob is a generation in the server's job queue. Requests counts the
+// gen requests coalesced into it, see genQueue.enqueue.
+type GenJob struct {
+	ID         int        `json:"id"`
+	Date       string     `json:"date"`
+	Projects   []string   `json:"projects,omitempty"`
+	Status     string     `json:"status"`
+	Requests   int        `json:"requests"`
+	QueuedAt   time.Time  `json:"queued_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Summarized is the number of projects summarized, once done.
+	Summarized int    `json:"summarized,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// finished reports whether the job has stopped, for good or ill.
+func (j GenJob) finished() bool {
+	return j.Status != jobQueued && j.Status != jobRunning
+}
+
+// JobsData is the response data of jobs: the queued and running jobs and
+// the last finished ones, oldest first.
+type JobsData struct {
+	Jobs []GenJob `json:"jobs"`
+}
+
+// genQueue is the server's queue of generations, which run one at a time
+// in the order they were queued.
+type genQueue struct {
+	mu     sync.Mutex
+	jobs   []*GenJob // oldest first
+	nextID int
+	done   map[int]chan struct{} // closed when the job finishes
+	wake   chan struct{}         // signals jobLoop that a job was queued
+}
+
+func newGenQueue() *genQueue {
+	return &genQueue{done: make(map[int]chan struct{}), wake: make(chan struct{}, 1)}
+}
+
+// enqueue queues a generation of date limited to projects, as for gen -p,
+// and returns its job and a channel closed when it finishes. A request for
+// the same date and projects as a job that is queued or running is
+// coalesced into that job rather than queued again.
+func (q *genQueue) enqueue(date string, projects []string) (GenJob, <-chan struct{}) {
+	projects = slices.Clone(projects)
+	slices.Sort(projects)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, j := range q.jobs {
+		if !j.finished() && j.Date == date && slices.Equal(j.Projects, projects) {
+			j.Requests++
+			return *j, q.done[j.ID]
+		}
+	}
+	q.nextID++
+	j := &GenJob{ID: q.nextID, Date: date, Projects: projects, Status: jobQueued, Requests: 1, QueuedAt: time.Now()}
+	q.jobs = append(q.jobs, j)
+	done := make(chan struct{})
+	q.done[j.ID] = done
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return *j, done
+}
+
+// start marks the oldest queued job as running and returns it, or reports
+// false if none is queued.
+func (q *genQueue) start() (GenJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, j := range q.jobs {
+		if j.Status == jobQueued {
+			now := time.Now()
+			j.Status, j.StartedAt = jobRunning, &now
+			return *j, true
+		}
+	}
+	return GenJob{}, false
+}
+
+// finish records the outcome of job id: n projects summarized, or err.
+func (q *genQueue) finish(id, n int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, j := range q.jobs {
+		if j.ID == id {
+			q.finishLocked(j, n, err)
+		}
+	}
+	q.trimLocked()
+}
+
+func (q *genQueue) finishLocked(j *GenJob, n int, err error) {
+	now := time.Now()
+	j.FinishedAt = &now
+	switch {
+	case errors.Is(err, errGenCancelled):
+		j.Status, j.Error = jobCancelled, err.Error()
+	case err != nil:
+		j.Status, j.Error = jobFailed, err.Error()
+	default:
+		j.Status, j.Summarized = jobDone, n
+	}
+	close(q.done[j.ID])
+	delete(q.done, j.ID)
+}
+
+// trimLocked forgets the oldest finished jobs beyond maxFinishedJobs.
+func (q *genQueue) trimLocked() {
+	finished := 0
+	for _, j := range q.jobs {
+		if j.finished() {
+			finished++
+		}
+	}
+	kept := q.jobs[:0]
+	for _, j := range q.jobs {
+		if j.finished() && finished > maxFinishedJobs {
+			finished--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	q.jobs = kept
+}
+
+// cancelQueued cancels the jobs that have not started and returns how many
+// it cancelled.
+func (q *genQueue) cancelQueued() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, j := range q.jobs {
+		if j.Status == jobQueued {
+			q.finishLocked(j, 0, errGenCancelled)
+			n++
+		}
+	}
+	q.trimLocked()
+	return n
+}
+
+// job returns job id, or reports false if the queue does not know it.
+func (q *genQueue) job(id int) (GenJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, j := range q.jobs {
+		if j.ID == id {
+			return *j, true
+		}
+	}
+	return GenJob{}, false
+}
+
+// list returns the jobs, oldest first.
+func (q *genQueue) list() []GenJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]GenJob, len(q.jobs))
+	for i, j := range q.jobs {
+		jobs[i] = *j
+	}
+	return jobs
+}
+
+// jobLoop runs the queued jobs one at a time until the server stops.
+func (s *Server) jobLoop() {
+	for {
+		for {
+			job, ok := s.jobs.start()
+			if !ok {
+				break
+			}
+			n, err := s.runJob(job)
+			s.jobs.finish(job.ID, n, err)
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.jobs.wake:
+		}
+	}
+}
+
+// runJob runs job as `devlog gen` would, and returns the number of projects
+// summarized.
+func (s *Server) runJob(job GenJob) (int, error) {
+	ctx, done := s.startGen(s.ctx)
+	defer done()
+	s.mu.RLock()
+	cfg := s.cfg
+	s.mu.RUnlock()
+	state := State{Watched: s.watchedSnapshot()}
+
+	s.logger.Info("generating summary", "job", job.ID, "date", job.Date, "projects", job.Projects)
+	start := time.Now()
+	if err := restoreRawDates(cfg, []string{job.Date}); err != nil {
+		return 0, err
+	}
+	n, err := runGenProjects(ctx, cfg, state, job.Date, job.Projects, nil)
+	switch {
+	case errors.Is(err, errGenCancelled):
+		s.logger.Info("generation cancelled", "job", job.ID, "date", job.Date)
+		return 0, err
+	case err != nil:
+		s.logger.Error("generation failed", "job", job.ID, "date", job.Date, "err", err)
+		return 0, err
+	}
+	if _, err := syncTodos(cfg, []string{job.Date}); err != nil {
+		s.logger.Warn("updating todo list failed", "date", job.Date, "err", err)
+	}
+	s.logger.Info("generation done", "job", job.ID, "date", job.Date, "projects", n, "duration", time.Since(start))
+	return n, nil
+}
+
+func (s *Server) handleGen(req IPCRequest) IPCResponse {
+	var args GenArgs
+	if err := json.Unmarshal(req.Args, &args); err != nil {
+		return IPCResponse{OK: false, Error: "invalid args: " + err.Error()}
+	}
+	if !isValidDate(args.Date) {
+		return IPCResponse{OK: false, Error: fmt.Sprintf("invalid date %q (expected YYYY-MM-DD)", args.Date)}
+	}
+	job, _ := s.jobs.enqueue(args.Date, args.Projects)
+	data, _ := json.Marshal(job)
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+func (s *Server) handleJobs() IPCResponse {
+	data, _ := json.Marshal(JobsData{Jobs: s.jobs.list()})
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+// queueGen queues a generation of date limited to projects in the server,
+// and returns its job.
+func queueGen(date string, projects []string) (GenJob, error) {
+	args, _ := json.Marshal(GenArgs{Date: date, Projects: projects})
+	resp, err := ipcSend(IPCRequest{Command: "gen", Args: json.RawMessage(args)})
+	if err != nil {
+		return GenJob{}, err
+	}
+	if !resp.OK {
+		return GenJob{}, errors.New(resp.Error)
+	}
+	var job GenJob
+	if err := json.Unmarshal(resp.Data, &job); err != nil {
+		return GenJob{}, fmt.Errorf("parsing response: %w", err)
+	}
+	return job, nil
+}
+
+// waitJob waits for the server's job to finish and returns it finished. It
+// returns early, with the job as it was, when ctx ends.
+func waitJob(ctx context.Context, job GenJob) (GenJob, error) {
+	for !job.finished() {
+		select {
+		case <-ctx.Done():
+			return job, nil
+		case <-time.After(jobPollInterval):
+		}
+		jobs, err := listJobs()
+		if err != nil {
+			return job, fmt.Errorf("checking on job %d: %w", job.ID, err)
+		}
+		i := slices.IndexFunc(jobs, func(j GenJob) bool { return j.ID == job.ID })
+		if i < 0 {
+			return job, fmt.Errorf("job %d is gone from the server's queue", job.ID)
+		}
+		job = jobs[i]
+	}
+	return job, nil
+}
+
+// runGenInServer is `devlog gen` with server_gen: it queues the generation
+// of date limited to only in the server and waits for the job to finish,
+// returning the number of projects summarized. It reports false if the
+// server is not running, for the generation to run here instead. Ctrl-C
+// stops waiting, and leaves the job to the server.
+func runGenInServer(date string, only []string) (n int, ok bool, err error) {
+	job, err := queueGen(date, only)
+	if isServerNotRunning(err) {
+		fmt.Fprintln(os.Stderr, "devlog server is not running, generating here")
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, true, err
+	}
+	if job.Requests > 1 {
+		fmt.Printf("Joined job %d for %s, already %s\n", job.ID, date, job.Status)
+	} else {
+		fmt.Printf("Queued job %d for %s\n", job.ID, date)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if job, err = waitJob(ctx, job); err != nil {
+		return 0, true, err
+	}
+	switch job.Status {
+	case jobDone:
+		fmt.Printf("Job %d done: %s summarized\n", job.ID, plural(job.Summarized, "project"))
+		return job.Summarized, true, nil
+	case jobCancelled:
+		return 0, true, errGenCancelled
+	case jobFailed:
+		return 0, true, fmt.Errorf("job %d failed: %s", job.ID, job.Error)
+	default:
+		return 0, true, fmt.Errorf("stopped waiting; job %d goes on in the server (see devlog jobs)", job.ID)
+	}
+}
+
+// listJobs returns the server's jobs, see JobsData.
+func listJobs() ([]GenJob, error) {
+	resp, err := ipcSend(IPCRequest{Command: "jobs"})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		return nil, errors.New(resp.Error)
+	}
+	var data JobsData
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	return data.Jobs, nil
+}
+
+// printJobs writes jobs as a table, with how long each waited in the queue
+// and how long it ran, as of now.
+func printJobs(w io.Writer, jobs []GenJob, now time.Time) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tSTATUS\tQUEUED\tWAITED\tTOOK\tRESULT")
+	for _, j := range jobs {
+		date := j.Date
+		if len(j.Projects) > 0 {
+			date += " -p " + strings.Join(j.Projects, ",")
+		}
+		status := j.Status
+		if j.Requests > 1 {
+			status += fmt.Sprintf(" (×%d)", j.Requests)
+		}
+		waited, took := "-", "-"
+		switch {
+		case j.StartedAt != nil:
+			waited = formatJobDuration(j.StartedAt.Sub(j.QueuedAt))
+			end := now
+			if j.FinishedAt != nil {
+				end = *j.FinishedAt
+			}
+			took = formatJobDuration(end.Sub(*j.StartedAt))
+		case j.Status == jobQueued:
+			waited = formatJobDuration(now.Sub(j.QueuedAt))
+		}
+		result := j.Error
+		if j.Status == jobDone {
+			result = plural(j.Summarized, "project") + " summarized"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", j.ID, date, status, j.QueuedAt.Format("15:04:05"), waited, took, result)
+	}
+	tw.Flush()
+}
+
+// formatJobDuration formats a job's time in the queue or running, to the
+// second.
+func formatJobDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
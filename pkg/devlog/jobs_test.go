@@ -0,0 +1,128 @@
+package devlog
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenQueue(t *testing.T) {
+	q := newGenQueue()
+	first, done := q.enqueue("2024-01-15", nil)
+	again, doneAgain := q.enqueue("2024-01-15", nil)
+	if again.ID != first.ID || again.Requests != 2 || doneAgain != done {
+		t.Errorf("a second request for the same date should be coalesced, got job %d with %d requests", again.ID, again.Requests)
+	}
+	other, _ := q.enqueue("2024-01-15", []string{"beta", "alpha"})
+	if other.ID == first.ID || strings.Join(other.Projects, ",") != "alpha,beta" {
+		t.Errorf("a request for other projects should be queued, got %+v", other)
+	}
+
+	job, ok := q.start()
+	if !ok || job.ID != first.ID || job.Status != jobRunning || job.StartedAt == nil {
+		t.Fatalf("start = %+v, %v; want job %d running", job, ok, first.ID)
+	}
+	if running, _ := q.enqueue("2024-01-15", nil); running.ID != first.ID || running.Requests != 3 {
+		t.Errorf("a request for a running job's date should be coalesced, got job %d", running.ID)
+	}
+	q.finish(job.ID, 2, nil)
+	select {
+	case <-done:
+	default:
+		t.Error("done should be closed when the job finishes")
+	}
+	if job, _ := q.job(first.ID); job.Status != jobDone || job.Summarized != 2 || job.FinishedAt == nil {
+		t.Errorf("finished job = %+v", job)
+	}
+	if next, _ := q.enqueue("2024-01-15", nil); next.ID == first.ID {
+		t.Error("a request after the job finished should be queued again")
+	}
+
+	if n := q.cancelQueued(); n != 2 {
+		t.Errorf("cancelQueued = %d, want 2", n)
+	}
+	if _, ok := q.start(); ok {
+		t.Error("no job should be left to start")
+	}
+	if job, _ := q.job(other.ID); job.Status != jobCancelled {
+		t.Errorf("queued job status = %q, want cancelled", job.Status)
+	}
+
+	job, _ = q.enqueue("2024-01-16", nil)
+	q.start()
+	q.finish(job.ID, 0, errors.New("gen_cmd failed"))
+	if job, _ := q.job(job.ID); job.Status != jobFailed || job.Error != "gen_cmd failed" {
+		t.Errorf("failed job = %+v", job)
+	}
+
+	for i := 0; i < maxFinishedJobs; i++ {
+		job, _ := q.enqueue("2024-02-01", nil)
+		q.start()
+		q.finish(job.ID, 1, nil)
+	}
+	if jobs := q.list(); len(jobs) != maxFinishedJobs || jobs[0].ID == first.ID {
+		t.Errorf("the queue should keep the last %d finished jobs, has %d", maxFinishedJobs, len(jobs))
+	}
+}
+
+func TestJobLoop(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"), []byte("#!/bin/sh\necho 'Worked on things.'\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := "2024-01-15"
+	os.MkdirAll(filepath.Join(rawDir, date), 0o755)
+	os.WriteFile(filepath.Join(rawDir, date, "git-alpha.log"), []byte("=== SNAPSHOT 10:00 ===\ndiff\n\n"), 0o644)
+
+	s := newServer(Config{GenCmd: "mysummarizer", CompCmd: "mycompressor"})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer s.cancel()
+	go s.jobLoop()
+
+	job, done := s.jobs.enqueue(date, nil)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the job did not finish")
+	}
+	if job, _ = s.jobs.job(job.ID); job.Status != jobDone || job.Summarized != 1 {
+		t.Errorf("job = %+v, want done with 1 project", job)
+	}
+	if _, err := os.Stat(filepath.Join(logDir, date+".md")); err != nil {
+		t.Errorf("the job should write the summary: %v", err)
+	}
+}
+
+func TestPrintJobs(t *testing.T) {
+	queued := time.Date(2024, 1, 15, 18, 0, 0, 0, time.Local)
+	started := queued.Add(5 * time.Second)
+	finished := started.Add(90 * time.Second)
+	jobs := []GenJob{
+		{ID: 1, Date: "2024-01-15", Status: jobDone, Requests: 2, QueuedAt: queued, StartedAt: &started, FinishedAt: &finished, Summarized: 2},
+		{ID: 2, Date: "2024-01-15", Projects: []string{"alpha"}, Status: jobFailed, Requests: 1, QueuedAt: queued, StartedAt: &finished, FinishedAt: &finished, Error: "gen_cmd failed"},
+		{ID: 3, Date: "2024-01-16", Status: jobQueued, Requests: 1, QueuedAt: finished},
+	}
+	var b strings.Builder
+	printJobs(&b, jobs, finished.Add(10*time.Second))
+	want := "ID  DATE                 STATUS     QUEUED    WAITED  TOOK   RESULT\n" +
+		"1   2024-01-15           done (×2)  18:00:00  5s      1m30s  2 projects summarized\n" +
+		"2   2024-01-15 -p alpha  failed     18:00:00  1m35s   0s     gen_cmd failed\n" +
+		"3   2024-01-16           queued     18:01:35  10s     -      \n"
+	if b.String() != want {
+		t.Errorf("printJobs:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,241 @@
+package devlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// journalFormats are the journals `devlog import --format` reads.
+var journalFormats = []string{"jrnl", "dayone", "markdown"}
+
+// journalEntry is an entry of an external journal.
+type journalEntry struct {
+	At   time.Time
+	Text string
+}
+
+var (
+	// jrnlEntryRe matches the first line of an entry in jrnl's plain text
+	// format, e.g. "[2024-01-15 10:30] Title" or "2024-01-15 09:30 PM Title".
+	jrnlEntryRe = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}) (\d{1,2}:\d{2})(?::\d{2})?(?: ?([AaPp][Mm]))?\]? ?(.*)$`)
+
+	// markdownDateHeadingRe matches a Markdown heading that starts an entry
+	// of a single-file journal, e.g. "## 2024-01-15" or "# 2024-01-15 14:30 Title".
+	markdownDateHeadingRe = regexp.MustCompile(`^#{1,6}\s+(\d{4}-\d{2}-\d{2})(?:[ T](\d{1,2}:\d{2}))?\s*(.*)$`)
+
+	// markdownDateFileRe matches the name of a daily note, e.g. "2024-01-15.md".
+	markdownDateFileRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}).*\.(md|markdown|txt)$`)
+
+	// dayOneEscapeRe matches the backslash escapes Day One puts before
+	// Markdown punctuation in its exports.
+	dayOneEscapeRe = regexp.MustCompile(`\\([\\!.()\-#*_\[\]+>` + "`" + `])`)
+)
+
+// readJournal reads the entries of the journal at path in format, with
+// times in loc where the journal does not record a time zone.
+func readJournal(format, path string, loc *time.Location) ([]journalEntry, error) {
+	if format == "markdown" {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return readMarkdownJournalDir(path, loc)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	switch format {
+	case "jrnl":
+		if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+			return parseJrnlJSON(data, loc)
+		}
+		return parseJrnlText(string(data), loc)
+	case "dayone":
+		return parseDayOne(data, loc)
+	case "markdown":
+		return parseMarkdownJournal(string(data), loc), nil
+	}
+	return nil, fmt.Errorf("unknown journal format %q (valid formats: %s)", format, strings.Join(journalFormats, ", "))
+}
+
+// journalTime parses a date and a clock time (H:MM, optionally with AM or
+// PM; midnight if empty) in loc.
+func journalTime(date, clock, ampm string, loc *time.Location) (time.Time, error) {
+	if clock == "" {
+		return time.ParseInLocation("2006-01-02", date, loc)
+	}
+	layout := "2006-01-02 15:04"
+	value := date + " " + clock
+	if ampm != "" {
+		layout = "2006-01-02 3:04 PM"
+		value += " " + strings.ToUpper(ampm)
+	}
+	return time.ParseInLocation(layout, value, loc)
+}
+
+// parseJrnlText reads jrnl's plain text export (`jrnl --export text`, or
+// the journal file itself): entries start with a "[date time] title" line.
+func parseJrnlText(content string, loc *time.Location) ([]journalEntry, error) {
+	var entries []journalEntry
+	for _, line := range strings.Split(content, "\n") {
+		if m := jrnlEntryRe.FindStringSubmatch(line); m != nil {
+			at, err := journalTime(m[1], m[2], m[3], loc)
+			if err == nil {
+				entries = append(entries, journalEntry{At: at, Text: m[4]})
+				continue
+			}
+		}
+		if len(entries) > 0 {
+			entries[len(entries)-1].Text += "\n" + line
+		}
+	}
+	for i := range entries {
+		entries[i].Text = strings.TrimSpace(entries[i].Text)
+	}
+	return entries, nil
+}
+
+// parseJrnlJSON reads jrnl's JSON export (`jrnl --export json`).
+func parseJrnlJSON(data []byte, loc *time.Location) ([]journalEntry, error) {
+	var export struct {
+		Entries []struct {
+			Date  string `json:"date"`
+			Time  string `json:"time"`
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parsing jrnl export: %w", err)
+	}
+	var entries []journalEntry
+	for _, e := range export.Entries {
+		at, err := journalTime(e.Date, e.Time, "", loc)
+		if err != nil {
+			return nil, fmt.Errorf("parsing jrnl export: entry %q: %w", e.Title, err)
+		}
+		entries = append(entries, journalEntry{At: at, Text: strings.TrimSpace(e.Title + "\n" + e.Body)})
+	}
+	return entries, nil
+}
+
+// parseDayOne reads a Day One JSON export (the Journal.json in the exported
+// zip file). Entry times are converted to loc.
+func parseDayOne(data []byte, loc *time.Location) ([]journalEntry, error) {
+	var export struct {
+		Entries []struct {
+			CreationDate time.Time `json:"creationDate"`
+			Text         string    `json:"text"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("parsing Day One export: %w", err)
+	}
+	var entries []journalEntry
+	for _, e := range export.Entries {
+		text := strings.TrimSpace(dayOneEscapeRe.ReplaceAllString(e.Text, "$1"))
+		if text != "" {
+			entries = append(entries, journalEntry{At: e.CreationDate.In(loc), Text: text})
+		}
+	}
+	return entries, nil
+}
+
+// parseMarkdownJournal reads a single Markdown file whose entries start with
+// a heading beginning with a date, and optionally a time.
+func parseMarkdownJournal(content string, loc *time.Location) []journalEntry {
+	var entries []journalEntry
+	for _, line := range strings.Split(content, "\n") {
+		if m := markdownDateHeadingRe.FindStringSubmatch(line); m != nil {
+			if at, err := journalTime(m[1], m[2], "", loc); err == nil {
+				entries = append(entries, journalEntry{At: at, Text: m[3]})
+				continue
+			}
+		}
+		if len(entries) > 0 {
+			entries[len(entries)-1].Text += "\n" + line
+		}
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Text = strings.TrimSpace(e.Text); e.Text != "" {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// readMarkdownJournalDir reads a directory of daily notes named by date
+// (e.g. 2024-01-15.md), one entry per file. A first-line heading that only
+// repeats the date is dropped.
+func readMarkdownJournalDir(dir string, loc *time.Location) ([]journalEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	var entries []journalEntry
+	for _, f := range files {
+		m := markdownDateFileRe.FindStringSubmatch(f.Name())
+		if m == nil || f.IsDir() {
+			continue
+		}
+		at, err := journalTime(m[1], "", "", loc)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading journal: %w", err)
+		}
+		text := strings.TrimSpace(string(data))
+		if first, rest, _ := strings.Cut(text, "\n"); strings.TrimLeft(first, "# ") == m[1] {
+			text = strings.TrimSpace(rest)
+		}
+		if text != "" {
+			entries = append(entries, journalEntry{At: at, Text: text})
+		}
+	}
+	return entries, nil
+}
+
+// importJournal writes entries as notes tagged with projects, leaving out
+// entries on dates not in dates (if it is not nil) and entries that were
+// already imported. It returns how many were written and how many were
+// already there.
+func importJournal(cfg Config, entries []journalEntry, projects, dates []string) (importResult, error) {
+	var res importResult
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	for _, e := range entries {
+		date := e.At.Format("2006-01-02")
+		if dates != nil && !containsString(dates, date) {
+			continue
+		}
+		text := escapeNoteHeadings(cfg.noteHeadings(), e.Text)
+		if strings.Contains(readNotes(cfg, date)+"\n", "\n"+text+"\n") {
+			res.Skipped++
+			continue
+		}
+		if err := writeNote(cfg.noteHeadings(), notesPathFor(cfg, date, projects), e.At, text, projects...); err != nil {
+			return res, err
+		}
+		res.Imported++
+	}
+	return res, nil
+}
+
+// escapeNoteHeadings indents lines of text that would otherwise be read as
+// the heading of another notes entry.
+func escapeNoteHeadings(headings *noteHeadingFormat, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if isNoteHeading(headings, line) {
+			lines[i] = " " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
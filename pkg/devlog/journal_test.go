@@ -0,0 +1,104 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJrnlText(t *testing.T) {
+	content := "[2024-01-15 09:30] Standup. Talked about the flaky test.\n\n[2024-01-15 09:45 PM] Late night\nFound the race.\n### At 10:00 looks like a heading\n"
+	entries, err := parseJrnlText(content, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	if got := entries[1].At.Format("2006-01-02 15:04"); got != "2024-01-15 21:45" {
+		t.Errorf("time = %s, want 2024-01-15 21:45", got)
+	}
+	if want := "Late night\nFound the race.\n### At 10:00 looks like a heading"; entries[1].Text != want {
+		t.Errorf("text = %q, want %q", entries[1].Text, want)
+	}
+}
+
+func TestParseJrnlJSON(t *testing.T) {
+	data := `{"tags": {}, "entries": [{"title": "Shipped it.", "body": "Finally.", "date": "2024-01-16", "time": "17:05", "tags": []}]}`
+	entries, err := readJournalData(t, "jrnl", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Text != "Shipped it.\nFinally." || entries[0].At.Format("15:04") != "17:05" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestParseDayOne(t *testing.T) {
+	data := `{"metadata": {"version": "1.0"}, "entries": [{"creationDate": "2024-01-15T15:00:00Z", "text": "Fixed the build\\. Yay\\!", "timeZone": "America/New_York"}]}`
+	loc := time.FixedZone("EST", -5*3600)
+	entries, err := parseDayOne([]byte(data), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Text != "Fixed the build. Yay!" || entries[0].At.Format("15:04") != "10:00" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestReadMarkdownJournal(t *testing.T) {
+	entries := parseMarkdownJournal("# Journal\n\n## 2024-01-15\n\nFirst day.\n\n## 2024-01-16 14:30 Afternoon\n\nSecond.\n\n## 2024-01-17\n", time.UTC)
+	if len(entries) != 2 || entries[0].Text != "First day." || entries[1].Text != "Afternoon\n\nSecond." {
+		t.Errorf("entries = %+v", entries)
+	}
+	if got := entries[1].At.Format("2006-01-02 15:04"); got != "2024-01-16 14:30" {
+		t.Errorf("time = %s", got)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "2024-01-15.md"), []byte("# 2024-01-15\n\nDaily note.\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a day\n"), 0o644)
+	entries, err := readJournal("markdown", dir, time.UTC)
+	if err != nil || len(entries) != 1 || entries[0].Text != "Daily note." {
+		t.Errorf("readJournal = %+v, %v", entries, err)
+	}
+}
+
+func TestImportJournal(t *testing.T) {
+	t.Setenv("DEVLOG_RAW_DIR", t.TempDir())
+	cfg := Config{}
+	notesFile := resolveNotesPath(cfg, "2024-01-15")
+	os.MkdirAll(filepath.Dir(notesFile), 0o755)
+	os.WriteFile(notesFile, []byte("### At 12:00\nexisting\n\n"), 0o644)
+
+	entries, _ := parseJrnlText("[2024-01-15 09:30] morning\n[2024-01-15 18:00] evening\n### At 10:00 x\n[2024-01-16 08:00] next day\n", time.UTC)
+	res, err := importJournal(cfg, entries, []string{"api"}, []string{"2024-01-15"})
+	if err != nil || res.Imported != 2 {
+		t.Fatalf("importJournal = %+v, %v; want 2 imported", res, err)
+	}
+	data, _ := os.ReadFile(notesFile)
+	want := "### At 09:30 #api\nmorning\n\n### At 12:00\nexisting\n\n### At 18:00 #api\nevening\n ### At 10:00 x\n\n"
+	if string(data) != want {
+		t.Errorf("notes:\n%s\nwant:\n%s", data, want)
+	}
+	if fileExists(resolveNotesPath(cfg, "2024-01-16")) {
+		t.Error("2024-01-16 is outside the imported range")
+	}
+
+	res, err = importJournal(cfg, entries, []string{"api"}, nil)
+	if err != nil || res.Imported != 1 || res.Skipped != 2 {
+		t.Errorf("re-import = %+v, %v; want 1 imported, 2 skipped", res, err)
+	}
+	if data, _ := os.ReadFile(notesFile); strings.Count(string(data), "morning") != 1 {
+		t.Error("re-import duplicated an entry")
+	}
+}
+
+func readJournalData(t *testing.T, format, data string) ([]journalEntry, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal")
+	os.WriteFile(path, []byte(data), 0o644)
+	return readJournal(format, path, time.UTC)
+}
@@ -0,0 +1,334 @@
+package devlog
+
+import (
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	krunnerBusName   = "org.chadnorvell.devlog.krunner"
+	krunnerPath      = "/krunner"
+	krunnerInterface = "org.kde.krunner1"
+)
+
+// KRunner implements the org.kde.krunner1 D-Bus interface.
+type KRunner struct {
+	server *Server
+	logger *slog.Logger
+}
+
+// RemoteMatch is a KRunner match result (D-Bus signature: sssida{sv}).
+type RemoteMatch struct {
+	ID                string
+	Text              string
+	IconName          string
+	CategoryRelevance int32
+	Relevance         float64
+	Properties        map[string]dbus.Variant
+}
+
+// RemoteAction is a KRunner action shown on a match (D-Bus signature: sss).
+type RemoteAction struct {
+	ID       string
+	Text     string
+	IconName string
+}
+
+const (
+	krunnerActionNotes   = "notes"
+	krunnerActionSummary = "summary"
+)
+
+// Actions returns the actions offered on matches without note content:
+// opening today's notes and the latest summary.
+func (k *KRunner) Actions() ([]RemoteAction, *dbus.Error) {
+	return []RemoteAction{
+		{ID: krunnerActionNotes, Text: "Show today's notes", IconName: "view-list-text"},
+		{ID: krunnerActionSummary, Text: "Show latest summary", IconName: "document-preview"},
+	}, nil
+}
+
+// Match responds to KRunner queries starting with #.
+func (k *KRunner) Match(query string) ([]RemoteMatch, *dbus.Error) {
+	if !strings.HasPrefix(query, "#") {
+		return nil, nil
+	}
+
+	var matches []RemoteMatch
+	for _, m := range matchNoteQuery(k.server.watchedSnapshot(), query) {
+		props := map[string]dbus.Variant{}
+		if m.Content != "" {
+			// Only bare "#project" matches offer the view actions.
+			props["actions"] = dbus.MakeVariant([]string{})
+		}
+
+		var catRelevance int32
+		var relevance float64
+		switch {
+		case !m.Watched:
+			catRelevance = 10
+			relevance = 0.3
+			props["subtext"] = dbus.MakeVariant("unwatched project")
+		case m.Exact:
+			// ExactMatch
+			catRelevance = 100
+			relevance = 1.0
+		default:
+			// PossibleMatch (prefix)
+			catRelevance = 10
+			relevance = 0.5
+		}
+
+		matches = append(matches, RemoteMatch{
+			ID:                encodeMatchID(m.Project, m.Content),
+			Text:              m.text(),
+			IconName:          "document-edit",
+			CategoryRelevance: catRelevance,
+			Relevance:         relevance,
+			Properties:        props,
+		})
+	}
+	return matches, nil
+}
+
+// noteQueryMatch is a candidate project for a "#project [content]" query
+// from a desktop search integration.
+type noteQueryMatch struct {
+	Project string
+	Content string
+	Exact   bool // Project is exactly the typed name
+	Watched bool // false for the fallback offered for unwatched projects
+}
+
+func (m noteQueryMatch) text() string {
+	if m.Content == "" {
+		return "#" + m.Project
+	}
+	return "#" + m.Project + " " + m.Content
+}
+
+// matchNoteQuery returns the watched projects whose names start with the
+// typed project, in watch order. If no watched project has exactly that name
+// and the query includes note content, the typed name is offered last so
+// users can log notes for unwatched projects. Without content no fallback is
+// offered, to avoid launching a dialog for a typo mid-autocomplete.
+func matchNoteQuery(watched []WatchEntry, query string) []noteQueryMatch {
+	project, content := parseKRunnerQuery(query)
+	if project == "" {
+		return nil
+	}
+
+	var matches []noteQueryMatch
+	exactFound := false
+	for _, w := range watched {
+		if !strings.HasPrefix(w.Name, project) {
+			continue
+		}
+		exact := w.Name == project
+		exactFound = exactFound || exact
+		matches = append(matches, noteQueryMatch{Project: w.Name, Content: content, Exact: exact, Watched: true})
+	}
+	if !exactFound && content != "" {
+		matches = append(matches, noteQueryMatch{Project: project, Content: content, Exact: true})
+	}
+	return matches
+}
+
+// launchNote starts "devlog -m <content> -p <project>", or "devlog -g -p
+// <project>" to open a dialog when there is no content.
+func launchNote(logger *slog.Logger, project, content string) {
+	executable, err := os.Executable()
+	if err != nil {
+		logger.Error("resolving executable failed", "err", err)
+		return
+	}
+
+	var cmd *exec.Cmd
+	if strings.TrimSpace(content) == "" {
+		cmd = exec.Command(executable, "-g", "-p", project)
+	} else {
+		cmd = exec.Command(executable, "-m", content, "-p", project)
+	}
+
+	if err := cmd.Start(); err != nil {
+		logger.Error("starting note command failed", "err", err)
+		return
+	}
+	go cmd.Wait()
+	logger.Info("launched note command", "project", project, "cmd", strings.Join(cmd.Args, " "))
+}
+
+// Run executes the selected match action: logging a note, or opening
+// today's notes or the latest summary.
+func (k *KRunner) Run(matchID string, actionID string) *dbus.Error {
+	project, content := decodeMatchID(matchID)
+	if project == "" {
+		return nil
+	}
+
+	switch actionID {
+	case krunnerActionNotes, krunnerActionSummary:
+		k.server.mu.RLock()
+		cfg := k.server.cfg
+		k.server.mu.RUnlock()
+		k.openFile(krunnerActionPath(cfg, actionID, time.Now().Format("2006-01-02")))
+		return nil
+	}
+
+	launchNote(k.logger, project, content)
+	return nil
+}
+
+// krunnerActionPath returns the file a view action opens, or "" if there is
+// none yet.
+func krunnerActionPath(cfg Config, actionID, today string) string {
+	var path string
+	if actionID == krunnerActionNotes {
+		path = resolveNotesPath(cfg, today)
+	} else {
+		path = latestSummaryPath(cfg)
+	}
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// openFile opens path with xdg-open.
+func (k *KRunner) openFile(path string) {
+	if path == "" {
+		k.logger.Info("nothing to open")
+		return
+	}
+	cmd := exec.Command("xdg-open", path)
+	if err := cmd.Start(); err != nil {
+		k.logger.Error("starting xdg-open failed", "err", err)
+		return
+	}
+	go cmd.Wait()
+	k.logger.Info("opened file", "path", path)
+}
+
+// Teardown is called when KRunner unloads the plugin.
+func (k *KRunner) Teardown() *dbus.Error {
+	return nil
+}
+
+// parseKRunnerQuery splits a #-prefixed query into project prefix and content.
+// Input: "#proj some content" -> ("proj", "some content")
+// Input: "#proj" -> ("proj", "")
+func parseKRunnerQuery(s string) (project, content string) {
+	if !strings.HasPrefix(s, "#") {
+		return "", ""
+	}
+	s = s[1:]
+	if s == "" {
+		return "", ""
+	}
+	idx := strings.IndexByte(s, ' ')
+	if idx < 0 {
+		return s, ""
+	}
+	return s[:idx], strings.TrimSpace(s[idx+1:])
+}
+
+func encodeMatchID(project, content string) string {
+	return project + ":" + content
+}
+
+func decodeMatchID(matchID string) (project, content string) {
+	idx := strings.IndexByte(matchID, ':')
+	if idx < 0 {
+		return matchID, ""
+	}
+	return matchID[:idx], matchID[idx+1:]
+}
+
+const krunnerIntrospectXML = `
+<node>
+  <interface name="org.kde.krunner1">
+    <method name="Actions">
+      <arg name="matches" type="a(sss)" direction="out"/>
+    </method>
+    <method name="Match">
+      <arg name="query" type="s" direction="in"/>
+      <arg name="matches" type="a(sssida{sv})" direction="out"/>
+    </method>
+    <method name="Run">
+      <arg name="matchId" type="s" direction="in"/>
+      <arg name="actionId" type="s" direction="in"/>
+    </method>
+    <method name="Teardown">
+    </method>
+  </interface>
+</node>
+`
+
+// startKRunner attempts to register on the D-Bus session bus connection conn
+// as a KRunner plugin. Returns a cleanup function, or nil if D-Bus (conn is
+// nil) or kdialog is unavailable.
+func startKRunner(s *Server, conn *dbus.Conn) func() {
+	logger := s.logger.With("component", "krunner")
+
+	if _, err := exec.LookPath("kdialog"); err != nil {
+		logger.Info("kdialog not found, skipping D-Bus registration")
+		return nil
+	}
+	if conn == nil {
+		return nil
+	}
+
+	kr := &KRunner{server: s, logger: logger}
+	return exportDBusService(conn, logger, krunnerBusName, krunnerPath, krunnerInterface, kr, krunnerIntrospectXML)
+}
+
+// exportDBusService exports v as iface at path on conn, with introspection
+// data, and requests busName. Returns a cleanup function that releases the
+// name and unexports the object, or nil on failure. The connection itself is
+// owned by the caller.
+func exportDBusService(conn *dbus.Conn, logger *slog.Logger, busName string, path dbus.ObjectPath, iface string, v interface{}, introspectXML string) func() {
+	unexport := func() {
+		conn.Export(nil, path, iface)
+		conn.Export(nil, path, "org.freedesktop.DBus.Introspectable")
+	}
+
+	if err := conn.Export(v, path, iface); err != nil {
+		logger.Error("failed to export interface", "err", err)
+		return nil
+	}
+
+	if err := conn.Export(introspect.Introspectable(introspectXML), path, "org.freedesktop.DBus.Introspectable"); err != nil {
+		logger.Error("failed to export introspection", "err", err)
+		unexport()
+		return nil
+	}
+
+	reply, err := conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		logger.Error("failed to request bus name", "err", err)
+		unexport()
+		return nil
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		logger.Warn("bus name already taken", "name", busName)
+		unexport()
+		return nil
+	}
+
+	logger.Info("registered on D-Bus", "name", busName)
+
+	return func() {
+		conn.ReleaseName(busName)
+		unexport()
+		logger.Info("unregistered from D-Bus")
+	}
+}
@@ -1,6 +1,10 @@
-package main
+package devlog
 
 import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 )
@@ -141,13 +145,58 @@ func TestKRunnerAvailableNoKdialog(t *testing.T) {
 	t.Setenv("PATH", emptyDir)
 
 	s := &Server{
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
 		mu:      sync.RWMutex{},
 		watched: []WatchEntry{},
 	}
 
-	cleanup := startKRunner(s)
+	cleanup := startKRunner(s, nil)
 	if cleanup != nil {
 		cleanup()
 		t.Error("startKRunner should return nil when kdialog is not available")
 	}
 }
+
+func TestKRunnerActions(t *testing.T) {
+	kr := &KRunner{server: &Server{watched: []WatchEntry{{Path: "/home/user/dev/devlog", Name: "devlog"}}}}
+
+	actions, _ := kr.Actions()
+	if len(actions) != 2 || actions[0].ID != krunnerActionNotes || actions[1].ID != krunnerActionSummary {
+		t.Fatalf("unexpected actions: %+v", actions)
+	}
+
+	matches, _ := kr.Match("#devlog")
+	if _, ok := matches[0].Properties["actions"]; ok {
+		t.Error("bare project match should offer all actions")
+	}
+	matches, _ = kr.Match("#devlog some note")
+	if v, ok := matches[0].Properties["actions"]; !ok || len(v.Value().([]string)) != 0 {
+		t.Error("match with content should offer no actions")
+	}
+}
+
+func TestKRunnerActionPath(t *testing.T) {
+	rawDir, logDir := t.TempDir(), t.TempDir()
+	cfg := Config{RawDir: rawDir, LogDir: logDir}
+
+	if p := krunnerActionPath(cfg, krunnerActionNotes, "2024-01-16"); p != "" {
+		t.Errorf("expected no notes path before any notes, got %q", p)
+	}
+	if p := krunnerActionPath(cfg, krunnerActionSummary, "2024-01-16"); p != "" {
+		t.Errorf("expected no summary path before any summaries, got %q", p)
+	}
+
+	notes := filepath.Join(rawDir, "2024-01-16", "notes.md")
+	os.MkdirAll(filepath.Dir(notes), 0o755)
+	os.WriteFile(notes, []byte("### At 10:00\nnote\n\n"), 0o644)
+	for _, name := range []string{"2024-01-14.md", "2024-01-15.md", "todo.md"} {
+		os.WriteFile(filepath.Join(logDir, name), []byte("summary\n"), 0o644)
+	}
+
+	if p := krunnerActionPath(cfg, krunnerActionNotes, "2024-01-16"); p != notes {
+		t.Errorf("notes path = %q, want %q", p, notes)
+	}
+	if p := krunnerActionPath(cfg, krunnerActionSummary, "2024-01-16"); p != filepath.Join(logDir, "2024-01-15.md") {
+		t.Errorf("summary path = %q, want latest summary", p)
+	}
+}
@@ -0,0 +1,221 @@
+package devlog
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// languageExts maps file extensions to languages.
+var languageExts = map[string]string{
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".cxx":   "C++",
+	".hh":    "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".dart":  "Dart",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".go":    "Go",
+	".hs":    "Haskell",
+	".html":  "HTML",
+	".htm":   "HTML",
+	".java":  "Java",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".json":  "JSON",
+	".kt":    "Kotlin",
+	".kts":   "Kotlin",
+	".lua":   "Lua",
+	".md":    "Markdown",
+	".nix":   "Nix",
+	".php":   "PHP",
+	".proto": "Protobuf",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".scala": "Scala",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".zsh":   "Shell",
+	".fish":  "Shell",
+	".sql":   "SQL",
+	".swift": "Swift",
+	".tf":    "Terraform",
+	".toml":  "TOML",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".vue":   "Vue",
+	".xml":   "XML",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".zig":   "Zig",
+}
+
+// languageNames maps file names without a telling extension to languages.
+var languageNames = map[string]string{
+	"Makefile":       "Makefile",
+	"GNUmakefile":    "Makefile",
+	"Dockerfile":     "Dockerfile",
+	"Containerfile":  "Dockerfile",
+	"CMakeLists.txt": "CMake",
+	"go.mod":         "Go",
+	"go.sum":         "Go",
+}
+
+// fileLanguage returns the language of a file from its name or extension,
+// or "Other".
+func fileLanguage(file string) string {
+	base := path.Base(file)
+	if lang, ok := languageNames[base]; ok {
+		return lang
+	}
+	if strings.HasPrefix(base, "Dockerfile.") {
+		return "Dockerfile"
+	}
+	if lang, ok := languageExts[strings.ToLower(path.Ext(base))]; ok {
+		return lang
+	}
+	return "Other"
+}
+
+// LanguageStats is the churn of a project's files in one language.
+type LanguageStats struct {
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+	Lines    int    `json:"lines"`
+}
+
+// snapshotFileChurn counts the diff lines added and removed over the day in
+// each file of a git snapshot log, counted as in snapshotChurn.
+func snapshotFileChurn(content string) map[string]int {
+	churn := make(map[string]int)
+	prev := make(map[string]int)
+	for _, chunk := range splitSnapshots(content) {
+		if !strings.HasPrefix(chunk, snapshotHeaderPrefix) {
+			continue
+		}
+		cur := make(map[string]int)
+		file := ""
+		for _, line := range strings.Split(chunk, "\n") {
+			if name, ok := diffFileName(line); ok {
+				file = name
+				continue
+			}
+			if isDiffFileHeader(line) {
+				continue
+			}
+			if file != "" && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) {
+				cur[file+"\n"+line]++
+			}
+		}
+		for key, n := range cur {
+			if extra := n - prev[key]; extra > 0 {
+				churn[key[:strings.IndexByte(key, '\n')]] += extra
+			}
+		}
+		prev = cur
+	}
+	return churn
+}
+
+// addLanguageStats sets the language breakdown of each project in report,
+// and of the total, from the snapshots of dates. A file changed on several
+// days is counted as one file.
+func addLanguageStats(cfg Config, dates []string, report *StatsReport) {
+	type tally struct {
+		files map[string]bool
+		lines int
+	}
+	count := func(m map[string]*tally, lang, file string, lines int) {
+		t, ok := m[lang]
+		if !ok {
+			t = &tally{files: make(map[string]bool)}
+			m[lang] = t
+		}
+		t.files[file] = true
+		t.lines += lines
+	}
+	byProject := make(map[string]map[string]*tally)
+	total := make(map[string]*tally)
+	for _, date := range dates {
+		for i := range report.Projects {
+			proj := report.Projects[i].Project
+			data, err := readGitLog(resolveGitPath(cfg, date, proj))
+			if err != nil {
+				continue
+			}
+			if byProject[proj] == nil {
+				byProject[proj] = make(map[string]*tally)
+			}
+			for file, lines := range snapshotFileChurn(data) {
+				lang := fileLanguage(file)
+				count(byProject[proj], lang, file, lines)
+				count(total, lang, proj+"/"+file, lines)
+			}
+		}
+	}
+
+	sorted := func(m map[string]*tally) []LanguageStats {
+		langs := []LanguageStats{}
+		for lang, t := range m {
+			langs = append(langs, LanguageStats{Language: lang, Files: len(t.files), Lines: t.lines})
+		}
+		sort.Slice(langs, func(i, j int) bool {
+			if langs[i].Lines != langs[j].Lines {
+				return langs[i].Lines > langs[j].Lines
+			}
+			return langs[i].Language < langs[j].Language
+		})
+		return langs
+	}
+	for i := range report.Projects {
+		report.Projects[i].Languages = sorted(byProject[report.Projects[i].Project])
+	}
+	report.Total.Languages = sorted(total)
+}
+
+// printLanguageTable prints the language breakdown of each project with
+// changed files, and of the total if there are several.
+func printLanguageTable(w io.Writer, report StatsReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tLANGUAGE\tFILES\tLINES\tSHARE")
+	rows := func(ps ProjectStats) {
+		sum := 0
+		for _, l := range ps.Languages {
+			sum += l.Lines
+		}
+		for i, l := range ps.Languages {
+			name := ps.Project
+			if i > 0 {
+				name = ""
+			}
+			share := 0.0
+			if sum > 0 {
+				share = 100 * float64(l.Lines) / float64(sum)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f%%\n", name, l.Language, l.Files, l.Lines, share)
+		}
+	}
+	active := 0
+	for _, ps := range report.Projects {
+		if len(ps.Languages) > 0 {
+			rows(ps)
+			active++
+		}
+	}
+	if active > 1 {
+		rows(report.Total)
+	}
+	tw.Flush()
+}
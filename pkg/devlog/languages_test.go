@@ -0,0 +1,75 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileLanguage(t *testing.T) {
+	for file, want := range map[string]string{
+		"main.go":                  "Go",
+		"deploy/values.YAML":       "YAML",
+		".github/workflows/ci.yml": "YAML",
+		"Makefile":                 "Makefile",
+		"docker/Dockerfile.dev":    "Dockerfile",
+		"web/src/App.tsx":          "TypeScript",
+		"LICENSE":                  "Other",
+		"assets/logo.png":          "Other",
+	} {
+		if got := fileLanguage(file); got != want {
+			t.Errorf("fileLanguage(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestSnapshotFileChurn(t *testing.T) {
+	content := "=== SNAPSHOT 10:00 ===\n" +
+		"--- a/main.go\n+++ b/main.go\n+line one\n-old line\n" +
+		"--- a/ci.yml\n+++ b/ci.yml\n+on: push\n\n" +
+		"=== SNAPSHOT 10:05 ===\n" +
+		"--- a/main.go\n+++ b/main.go\n+line one\n+line two\n-old line\n" +
+		"--- a/ci.yml\n+++ b/ci.yml\n+on: pull_request\n\n"
+
+	churn := snapshotFileChurn(content)
+	if churn["main.go"] != 3 || churn["ci.yml"] != 2 || len(churn) != 2 {
+		t.Errorf("unexpected churn: %v", churn)
+	}
+}
+
+func TestAddLanguageStats(t *testing.T) {
+	rawDir := filepath.Join(t.TempDir(), "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	write := func(date, proj, content string) {
+		os.MkdirAll(filepath.Join(rawDir, date), 0o755)
+		os.WriteFile(filepath.Join(rawDir, date, "git-"+proj+".log"), []byte(content), 0o644)
+	}
+	write("2024-01-15", "api", "=== SNAPSHOT 10:00 ===\n--- a/main.go\n+++ b/main.go\n+a\n+b\n+c\n"+
+		"--- a/k8s/app.yaml\n+++ b/k8s/app.yaml\n+x\n\n")
+	write("2024-01-16", "api", "=== SNAPSHOT 10:00 ===\n--- a/k8s/app.yaml\n+++ b/k8s/app.yaml\n+y\n+z\n\n")
+	write("2024-01-16", "infra", "=== SNAPSHOT 10:00 ===\n--- a/ci.yml\n+++ b/ci.yml\n+on: push\n\n")
+
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty}
+	dates := []string{"2024-01-15", "2024-01-16"}
+	report := collectStats(cfg, State{}, dates)
+	addLanguageStats(cfg, dates, &report)
+
+	api := report.Projects[0].Languages
+	if len(api) != 2 || api[0] != (LanguageStats{"Go", 1, 3}) || api[1] != (LanguageStats{"YAML", 1, 3}) {
+		t.Errorf("api languages = %+v", api)
+	}
+	if total := report.Total.Languages; len(total) != 2 || total[0] != (LanguageStats{"YAML", 2, 4}) {
+		t.Errorf("total languages = %+v", total)
+	}
+
+	var b bytes.Buffer
+	printLanguageTable(&b, report)
+	for _, want := range []string{"api      Go        1      3      50.0%", "total    YAML      2      4      57.1%"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("table lacks %q:\n%s", want, b.String())
+		}
+	}
+}
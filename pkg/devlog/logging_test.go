@@ -0,0 +1,133 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  slog.Level
+		ok    bool
+	}{
+		{"", slog.LevelInfo, true},
+		{"info", slog.LevelInfo, true},
+		{"DEBUG", slog.LevelDebug, true},
+		{"warn", slog.LevelWarn, true},
+		{"error", slog.LevelError, true},
+		{"verbose", slog.LevelInfo, false},
+	}
+	for _, tt := range tests {
+		got, err := parseLogLevel(tt.input)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseLogLevel(%q) error = %v, want ok=%v", tt.input, err, tt.ok)
+		}
+		if got != tt.want {
+			t.Errorf("parseLogLevel(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(Config{}, &buf, slog.LevelWarn)
+
+	logger.Info("hidden")
+	logger.Warn("shown", "project", "devlog")
+
+	s := buf.String()
+	if strings.Contains(s, "hidden") {
+		t.Error("info message should be filtered at warn level")
+	}
+	if !strings.Contains(s, "shown") || !strings.Contains(s, "project=devlog") {
+		t.Errorf("expected warn message with project field, got %q", s)
+	}
+}
+
+func TestNewLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(Config{LogFormat: "json"}, &buf, slog.LevelInfo)
+
+	logger.Info("snapshot written", "project", "devlog")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("output is not JSON: %v: %q", err, buf.String())
+	}
+	if entry["msg"] != "snapshot written" || entry["project"] != "devlog" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+}
+
+func TestLoadConfigInvalidLogSettings(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+
+	dir := filepath.Join(tmp, "devlog")
+	os.MkdirAll(dir, 0o755)
+
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`log_level = "loud"`), 0o644)
+	if _, err := loadConfig(); err == nil {
+		t.Error("expected error for invalid log_level")
+	}
+
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`log_format = "xml"`), 0o644)
+	if _, err := loadConfig(); err == nil {
+		t.Error("expected error for invalid log_format")
+	}
+}
+
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "server.log")
+
+	w, err := openRotatingWriter(path, 20, 2)
+	if err != nil {
+		t.Fatalf("openRotatingWriter: %v", err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"first line 0001\n", "second line 002\n", "third line 0003\n", "fourth line 004\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "fourth line 004\n" {
+		t.Errorf("current log = %q", current)
+	}
+	rotated1, _ := os.ReadFile(path + ".1")
+	if string(rotated1) != "third line 0003\n" {
+		t.Errorf("log.1 = %q", rotated1)
+	}
+	rotated2, _ := os.ReadFile(path + ".2")
+	if string(rotated2) != "second line 002\n" {
+		t.Errorf("log.2 = %q", rotated2)
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("only 2 rotated files should be kept")
+	}
+}
+
+func TestRotatingWriterAppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	os.WriteFile(path, []byte("existing\n"), 0o644)
+
+	w, err := openRotatingWriter(path, 1024, 1)
+	if err != nil {
+		t.Fatalf("openRotatingWriter: %v", err)
+	}
+	w.Write([]byte("appended\n"))
+	w.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "existing\nappended\n" {
+		t.Errorf("log = %q", data)
+	}
+}
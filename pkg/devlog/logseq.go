@@ -0,0 +1,249 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logseqMarker is the first line of the block devlog writes to a Logseq
+// journal page. The block, up to the next top-level block, is replaced each
+// time the summary is generated; the rest of the page is left alone.
+const logseqMarker = "- [[devlog]]"
+
+var (
+	// logseqListItemRe matches a Markdown list item and its indentation.
+	logseqListItemRe = regexp.MustCompile(`^(\s*)([-*+]|\d+\.) (.*)$`)
+
+	// logseqPlainTagRe matches a name that can be written as #name.
+	logseqPlainTagRe = regexp.MustCompile(`^[\w-]+$`)
+
+	// logseqTagRe matches a Logseq tag: #name or #[[name]].
+	logseqTagRe = regexp.MustCompile(`#\[\[([^\]]+)\]\]|(?:^|\s)#([\w.-]+)`)
+
+	// logseqPropertyRe matches a block property line, e.g. "id:: 65a...".
+	logseqPropertyRe = regexp.MustCompile(`^\s*[\w-]+:: `)
+
+	// logseqTimeRe matches a time at the start of a block, e.g. "10:30" or
+	// "**10:30**", as inserted by Logseq's timestamp command.
+	logseqTimeRe = regexp.MustCompile(`^(?:\*\*)?(\d{1,2}):(\d{2})(?:\*\*)?\s+`)
+)
+
+// logseqPagePath returns the Logseq journal page for date in the graph at
+// logseq_dir, e.g. journals/2024_01_15.md.
+func logseqPagePath(cfg Config, date string) string {
+	return filepath.Join(cfg.LogseqDir, "journals", strings.ReplaceAll(date, "-", "_")+".md")
+}
+
+// logseqTag returns project as a Logseq tag.
+func logseqTag(project string) string {
+	if logseqPlainTagRe.MatchString(project) {
+		return "#" + project
+	}
+	return "#[[" + project + "]]"
+}
+
+// logseqBlocks converts Markdown text to Logseq blocks at depth: each
+// paragraph and heading becomes a block, and list items become blocks
+// nested according to their indentation.
+func logseqBlocks(text string, depth int) []string {
+	type block struct {
+		depth int
+		lines []string
+	}
+	var blocks []*block
+	var cur *block
+	var indents []int
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch m := logseqListItemRe.FindStringSubmatch(strings.ReplaceAll(line, "\t", "    ")); {
+		case trimmed == "":
+			cur = nil
+		case m != nil:
+			indent := len(m[1])
+			for len(indents) > 0 && indents[len(indents)-1] > indent {
+				indents = indents[:len(indents)-1]
+			}
+			if len(indents) == 0 || indents[len(indents)-1] < indent {
+				indents = append(indents, indent)
+			}
+			item := m[3]
+			if m[2][0] >= '0' && m[2][0] <= '9' {
+				item = m[2] + " " + item
+			}
+			cur = &block{depth: depth + len(indents) - 1, lines: []string{item}}
+			blocks = append(blocks, cur)
+		case strings.HasPrefix(trimmed, "#") && strings.Contains(trimmed, "# "):
+			blocks = append(blocks, &block{depth: depth, lines: []string{trimmed}})
+			cur, indents = nil, nil
+		case cur != nil:
+			cur.lines = append(cur.lines, trimmed)
+		default:
+			cur = &block{depth: depth, lines: []string{trimmed}}
+			blocks = append(blocks, cur)
+			indents = nil
+		}
+	}
+
+	var out []string
+	for _, b := range blocks {
+		tabs := strings.Repeat("\t", b.depth)
+		out = append(out, tabs+"- "+b.lines[0])
+		for _, l := range b.lines[1:] {
+			out = append(out, tabs+"  "+l)
+		}
+	}
+	return out
+}
+
+// logseqSummaryBlock returns the devlog block for a summary: a block per
+// project, tagged with its name, holding the project's summary.
+func logseqSummaryBlock(summary string) string {
+	lines := []string{logseqMarker}
+	for _, s := range splitSummary(summary) {
+		lines = append(lines, "\t- "+logseqTag(s.Project))
+		lines = append(lines, logseqBlocks(s.Text, 2)...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// isLogseqTopBlock reports whether line starts a top-level block.
+func isLogseqTopBlock(line string) bool {
+	return line == "-" || strings.HasPrefix(line, "- ")
+}
+
+// replaceLogseqBlock replaces the devlog block of the page content with
+// block, or adds block at the end if the page has none.
+func replaceLogseqBlock(content, block string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.TrimRight(line, " ") != logseqMarker {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !isLogseqTopBlock(lines[end]) {
+			end++
+		}
+		if i > 0 {
+			block = strings.Join(lines[:i], "\n") + "\n" + block
+		}
+		return block + strings.Join(lines[end:], "\n")
+	}
+	content = strings.TrimRight(content, "\n ")
+	if content == "" || content == "-" {
+		return block
+	}
+	return content + "\n" + block
+}
+
+// writeLogseqPage writes the summary for date to its Logseq journal page,
+// replacing the devlog block written for an earlier summary.
+func writeLogseqPage(cfg Config, date, summary string) error {
+	path := logseqPagePath(cfg, date)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading Logseq page: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating Logseq journals dir: %w", err)
+	}
+	content := replaceLogseqBlock(string(data), logseqSummaryBlock(summary))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("writing Logseq page: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing Logseq page: %w", err)
+	}
+	// The summary is written after the page. Date the page back so that,
+	// with logseq_notes, it does not make the summary look stale even on
+	// file systems with coarse timestamps.
+	past := time.Now().Add(-time.Second)
+	os.Chtimes(path, past, past)
+	return nil
+}
+
+// logseqNoteEntries converts the top-level blocks of a Logseq journal page,
+// other than the devlog block, to notes entries. A block's tags become its
+// projects, and a time at its start becomes the entry's time; blocks
+// without one are placed at 00:00.
+func logseqNoteEntries(headings *noteHeadingFormat, content, date string) string {
+	var blocks [][]string
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case isLogseqTopBlock(line):
+			blocks = append(blocks, []string{strings.TrimPrefix(strings.TrimPrefix(line, "-"), " ")})
+		case len(blocks) > 0 && !logseqPropertyRe.MatchString(line):
+			if l, ok := strings.CutPrefix(line, "  "); ok {
+				line = l
+			} else {
+				line = strings.TrimPrefix(line, "\t")
+			}
+			blocks[len(blocks)-1] = append(blocks[len(blocks)-1], line)
+		}
+	}
+
+	var b strings.Builder
+	for _, lines := range blocks {
+		if "- "+lines[0] == logseqMarker {
+			continue
+		}
+		clock := "00:00"
+		if m := logseqTimeRe.FindStringSubmatch(lines[0]); m != nil {
+			clock = m[1] + ":" + m[2]
+			if len(m[1]) == 1 {
+				clock = "0" + clock
+			}
+			lines[0] = lines[0][len(m[0]):]
+		}
+		text := strings.TrimSpace(strings.Join(lines, "\n"))
+		if text == "" {
+			continue
+		}
+		heading := formatNoteHeading(headings, date, clock)
+		seen := make(map[string]bool)
+		for _, m := range logseqTagRe.FindAllStringSubmatch(text, -1) {
+			tag := m[1] + m[2]
+			if tag != "devlog" && !seen[tag] && validateProjectName(tag) == nil && !strings.ContainsAny(tag, " \t") {
+				seen[tag] = true
+				heading += " #" + tag
+			}
+		}
+		fmt.Fprintf(&b, "%s\n%s\n\n", heading, escapeNoteHeadings(headings, text))
+	}
+	return b.String()
+}
+
+// genNotesPaths returns the files readGenNotes reads for date.
+func genNotesPaths(cfg Config, date string) []string {
+	paths := notesPaths(cfg, date)
+	if cfg.LogseqNotes && cfg.LogseqDir != "" {
+		paths = append(paths, logseqPagePath(cfg, date))
+	}
+	return paths
+}
+
+// readGenNotes returns the notes entries summaries draw on for date: those
+// of the notes files (see readNotes) and, with logseq_notes set, those
+// converted from the Logseq journal page.
+func readGenNotes(cfg Config, date string) string {
+	notes := readNotes(cfg, date)
+	if !cfg.LogseqNotes || cfg.LogseqDir == "" {
+		return notes
+	}
+	page, err := os.ReadFile(logseqPagePath(cfg, date))
+	if err != nil {
+		return notes
+	}
+	if entries := logseqNoteEntries(cfg.noteHeadings(), string(page), date); entries != "" {
+		if notes != "" && !strings.HasSuffix(notes, "\n") {
+			notes += "\n"
+		}
+		notes += entries
+	}
+	return notes
+}
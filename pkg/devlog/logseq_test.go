@@ -0,0 +1,75 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogseqSummaryBlock(t *testing.T) {
+	summary := "# 2024-01-15\n\n## api\n\nFixed the retry loop.\nIt now backs off.\n\n- Added tests\n  - for timeouts\n- Bumped deps\n\n## my project\n\n### Notes\n\n1. First\n"
+	want := "- [[devlog]]\n" +
+		"\t- #api\n" +
+		"\t\t- Fixed the retry loop.\n" +
+		"\t\t  It now backs off.\n" +
+		"\t\t- Added tests\n" +
+		"\t\t\t- for timeouts\n" +
+		"\t\t- Bumped deps\n" +
+		"\t- #[[my project]]\n" +
+		"\t\t- ### Notes\n" +
+		"\t\t- 1. First\n"
+	if got := logseqSummaryBlock(summary); got != want {
+		t.Errorf("logseqSummaryBlock:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestReplaceLogseqBlock(t *testing.T) {
+	block := "- [[devlog]]\n\t- #api\n"
+	tests := []struct {
+		name, content, want string
+	}{
+		{"empty", "", block},
+		{"new page", "-\n", block},
+		{"append", "- my own note\n", "- my own note\n" + block},
+		{"replace", "- before\n- [[devlog]]\n\t- #old\n\t\t- stale\n- after\n", "- before\n" + block + "- after\n"},
+		{"replace first", "- [[devlog]]\n\t- #old\n", block},
+	}
+	for _, tt := range tests {
+		if got := replaceLogseqBlock(tt.content, block); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogseqNoteEntries(t *testing.T) {
+	page := "- **10:30** Paired on the #api rollout\n  with Sam\n\tid:: 65a1\n\t- follow up #[[web]]\n- Thinking about the roadmap\n- [[devlog]]\n\t- #api\n\t\t- summary\n"
+	want := "### At 10:30 #api #web\nPaired on the #api rollout\nwith Sam\n- follow up #[[web]]\n\n### At 00:00\nThinking about the roadmap\n\n"
+	if got := logseqNoteEntries(nil, page, "2024-01-15"); got != want {
+		t.Errorf("logseqNoteEntries:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestReadGenNotesLogseq(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", filepath.Join(tmp, "raw"))
+	cfg := Config{LogseqDir: filepath.Join(tmp, "graph")}
+	os.MkdirAll(filepath.Join(tmp, "raw", "2024-01-15"), 0o755)
+	os.WriteFile(resolveNotesPath(cfg, "2024-01-15"), []byte("### At 09:00 #api\nfrom devlog"), 0o644)
+	if err := writeLogseqPage(cfg, "2024-01-15", "# 2024-01-15\n\n## api\n\nDid things.\n"); err != nil {
+		t.Fatal(err)
+	}
+	page := filepath.Join(tmp, "graph", "journals", "2024_01_15.md")
+	data, _ := os.ReadFile(page)
+	os.WriteFile(page, append([]byte("- 14:00 from Logseq #web\n"), data...), 0o644)
+
+	if got := readGenNotes(cfg, "2024-01-15"); got != "### At 09:00 #api\nfrom devlog" {
+		t.Errorf("without logseq_notes: %q", got)
+	}
+	cfg.LogseqNotes = true
+	if got := collectProjectNotes(cfg, "web", "2024-01-15"); got != "### At 14:00 #web\nfrom Logseq #web" {
+		t.Errorf("collectProjectNotes = %q", got)
+	}
+	if got := discoverProjectsFromNotes(cfg, "2024-01-15"); len(got) != 2 {
+		t.Errorf("discoverProjectsFromNotes = %v, want api and web", got)
+	}
+}
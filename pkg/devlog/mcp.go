@@ -0,0 +1,330 @@
+package devlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision implemented by
+// `devlog mcp`.
+const mcpProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	jsonrpcParseError     = -32700
+	jsonrpcInvalidRequest = -32600
+	jsonrpcMethodNotFound = -32601
+	jsonrpcInvalidParams  = -32602
+)
+
+type mcpRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+// mcpServer answers MCP requests over stdio, giving AI assistants read-only
+// access to summaries and notes.
+type mcpServer struct {
+	cfg Config
+	now func() time.Time
+}
+
+// mcpSchema builds a JSON Schema object from property descriptions, all of
+// type string except those listed in ints.
+func mcpSchema(props map[string]string, required []string, ints ...string) map[string]any {
+	properties := make(map[string]any, len(props))
+	for name, desc := range props {
+		typ := "string"
+		if containsString(ints, name) {
+			typ = "integer"
+		}
+		properties[name] = map[string]any{"type": typ, "description": desc}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "get_summary",
+		Description: "Get the generated devlog summary of the work done on a date or range of dates, optionally for one project.",
+		InputSchema: mcpSchema(map[string]string{
+			"date":    `Date or range: "YYYY-MM-DD", "YYYY-MM-DD..YYYY-MM-DD", or "Nd" for the last N days. Default: today.`,
+			"project": "Only return this project's section of each summary.",
+		}, nil),
+	},
+	{
+		Name:        "search_notes",
+		Description: "Search the notes logged with devlog for entries containing every word of a query (case-insensitive).",
+		InputSchema: mcpSchema(map[string]string{
+			"query":   "Words to search for.",
+			"range":   `Dates to search: "YYYY-MM-DD", "YYYY-MM-DD..YYYY-MM-DD", or "Nd". Default: "30d".`,
+			"project": `Only search notes tagged with this project ("general" for notes without one).`,
+		}, []string{"query"}),
+	},
+	{
+		Name:        "list_recent_activity",
+		Description: "List what was done on a project recently, newest first: the summary for each day, or the day's notes when it has not been summarized.",
+		InputSchema: mcpSchema(map[string]string{
+			"project": "Project name.",
+			"days":    "Number of days to look back, including today. Default: 7.",
+		}, []string{"project"}, "days"),
+	},
+}
+
+// serve reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is exhausted.
+func (m *mcpServer) serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var resp *mcpResponse
+		var req mcpRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			resp = &mcpResponse{ID: json.RawMessage("null"), Error: &mcpError{Code: jsonrpcParseError, Message: "parse error"}}
+		} else {
+			resp = m.handle(req)
+		}
+		if resp == nil {
+			continue
+		}
+		resp.JSONRPC = "2.0"
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handle answers one request. Notifications (requests without an ID) get no
+// response.
+func (m *mcpServer) handle(req mcpRequest) *mcpResponse {
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := &mcpResponse{ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		resp.Result = map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "devlog", "version": "1.0"},
+		}
+	case "ping":
+		resp.Result = map[string]any{}
+	case "tools/list":
+		resp.Result = map[string]any{"tools": mcpTools}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &mcpError{Code: jsonrpcInvalidParams, Message: "invalid params: " + err.Error()}
+			break
+		}
+		result, err := m.callTool(params.Name, params.Arguments)
+		if errors.Is(err, errUnknownTool) {
+			resp.Error = &mcpError{Code: jsonrpcInvalidParams, Message: err.Error()}
+			break
+		}
+		if err != nil {
+			// Tool failures are reported to the model, not as protocol errors.
+			resp.Result = mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}
+			break
+		}
+		resp.Result = mcpToolResult{Content: []mcpContent{{Type: "text", Text: result}}}
+	case "":
+		resp.Error = &mcpError{Code: jsonrpcInvalidRequest, Message: "missing method"}
+	default:
+		resp.Error = &mcpError{Code: jsonrpcMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	return resp
+}
+
+var errUnknownTool = errors.New("unknown tool")
+
+// mcpToolArgs are the arguments of all tools; each tool uses a subset.
+type mcpToolArgs struct {
+	Date    string `json:"date"`
+	Project string `json:"project"`
+	Query   string `json:"query"`
+	Range   string `json:"range"`
+	Days    int    `json:"days"`
+}
+
+func (m *mcpServer) callTool(name string, raw json.RawMessage) (string, error) {
+	var args mcpToolArgs
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	switch name {
+	case "get_summary":
+		return m.getSummary(args.Date, args.Project)
+	case "search_notes":
+		return m.searchNotes(args.Query, args.Range, args.Project)
+	case "list_recent_activity":
+		return m.recentActivity(args.Project, args.Days)
+	default:
+		return "", fmt.Errorf("%w %q", errUnknownTool, name)
+	}
+}
+
+func (m *mcpServer) getSummary(dateRange, project string) (string, error) {
+	dates, err := parseDateRange(dateRange, m.now())
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, date := range dates {
+		text, ok := m.summary(date, project)
+		if ok {
+			parts = append(parts, fmt.Sprintf("# %s\n\n%s", date, text))
+		}
+	}
+	if len(parts) == 0 {
+		span := dates[0]
+		if len(dates) > 1 {
+			span += ".." + dates[len(dates)-1]
+		}
+		if project != "" {
+			return fmt.Sprintf("No summary for %s on %s.", project, span), nil
+		}
+		return fmt.Sprintf("No summary for %s.", span), nil
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// summary returns the summary for date without its date heading, or only
+// project's section if project is set.
+func (m *mcpServer) summary(date, project string) (string, bool) {
+	data, err := os.ReadFile(summaryPath(m.cfg, date))
+	if err != nil {
+		return "", false
+	}
+	var parts []string
+	for _, s := range splitSummary(string(data)) {
+		if project == "" {
+			parts = append(parts, "## "+s.Project+"\n\n"+s.Text)
+		} else if s.Project == project {
+			return s.Text, true
+		}
+	}
+	return strings.Join(parts, "\n\n"), len(parts) > 0
+}
+
+func (m *mcpServer) searchNotes(query, dateRange, project string) (string, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return "", errors.New("query is required")
+	}
+	if dateRange == "" {
+		dateRange = "30d"
+	}
+	dates, err := parseDateRange(dateRange, m.now())
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	matches := 0
+	for _, day := range collectNotes(m.cfg, dates, project) {
+		for _, entry := range splitNoteEntries(m.cfg.noteHeadings(), day.Content) {
+			lower := strings.ToLower(entry)
+			found := true
+			for _, w := range words {
+				if !strings.Contains(lower, w) {
+					found = false
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+			if matches > 0 {
+				b.WriteString("\n\n")
+			}
+			fmt.Fprintf(&b, "%s %s", day.Date, entry)
+			matches++
+		}
+	}
+	if matches == 0 {
+		return fmt.Sprintf("No notes matching %q.", query), nil
+	}
+	return b.String(), nil
+}
+
+func (m *mcpServer) recentActivity(project string, days int) (string, error) {
+	if project == "" {
+		return "", errors.New("project is required")
+	}
+	if days <= 0 {
+		days = 7
+	}
+	dates, err := parseDateRange(fmt.Sprintf("%dd", days), m.now())
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for i := len(dates) - 1; i >= 0; i-- {
+		date := dates[i]
+		if text, ok := m.summary(date, project); ok {
+			parts = append(parts, fmt.Sprintf("# %s\n\n%s", date, text))
+			continue
+		}
+		if notes := collectNotes(m.cfg, []string{date}, project); len(notes) > 0 {
+			parts = append(parts, fmt.Sprintf("# %s (not summarized yet; notes)\n\n%s", date, notes[0].Content))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("No activity recorded for %s in the last %d days.", project, days), nil
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
@@ -0,0 +1,124 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestMCP(t *testing.T) *mcpServer {
+	t.Helper()
+	cfg := Config{RawDir: t.TempDir(), LogDir: t.TempDir()}
+	for date, notes := range map[string]string{
+		"2024-01-14": "### At 09:00 #alpha\nTried bumping the pool size.\n\n### At 10:00\nLunch with the team.\n\n",
+		"2024-01-15": "### At 11:00 #alpha #bug\nConnection pool leak found in the retry path.\n\n",
+	} {
+		os.MkdirAll(filepath.Join(cfg.RawDir, date), 0o755)
+		os.WriteFile(filepath.Join(cfg.RawDir, date, "notes.md"), []byte(notes), 0o644)
+	}
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-14.md"),
+		[]byte("# 2024-01-14\n\n## alpha\n\nInvestigated pool exhaustion.\n\n## beta\n\nBeta work.\n"), 0o644)
+	now := time.Date(2024, 1, 15, 18, 0, 0, 0, time.Local)
+	return &mcpServer{cfg: cfg, now: func() time.Time { return now }}
+}
+
+func TestSplitNoteEntries(t *testing.T) {
+	entries := splitNoteEntries(nil, "preamble\n### At 09:00 #alpha\nFirst\n\n### At 10:00\nSecond\n\n")
+	if len(entries) != 2 || entries[0] != "### At 09:00 #alpha\nFirst" || entries[1] != "### At 10:00\nSecond" {
+		t.Errorf("unexpected entries: %q", entries)
+	}
+}
+
+func TestMCPServe(t *testing.T) {
+	m := newTestMCP(t)
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_summary","arguments":{"date":"2024-01-14","project":"alpha"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+		`not json`,
+	}, "\n")
+	var out bytes.Buffer
+	if err := m.serve(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 responses (none for the notification), got %d:\n%s", len(lines), out.String())
+	}
+	var resps []map[string]any
+	for _, l := range lines {
+		var r map[string]any
+		json.Unmarshal([]byte(l), &r)
+		resps = append(resps, r)
+	}
+	if v := resps[0]["result"].(map[string]any)["protocolVersion"]; v != mcpProtocolVersion {
+		t.Errorf("unexpected protocol version %v", v)
+	}
+	if tools := resps[1]["result"].(map[string]any)["tools"].([]any); len(tools) != len(mcpTools) {
+		t.Errorf("expected %d tools, got %d", len(mcpTools), len(tools))
+	}
+	if !strings.Contains(lines[2], "Investigated pool exhaustion.") || strings.Contains(lines[2], "Beta work.") {
+		t.Errorf("expected alpha summary only: %s", lines[2])
+	}
+	if code := resps[3]["error"].(map[string]any)["code"].(float64); code != jsonrpcMethodNotFound {
+		t.Errorf("expected method not found, got %v", code)
+	}
+	if code := resps[4]["error"].(map[string]any)["code"].(float64); code != jsonrpcParseError {
+		t.Errorf("expected parse error, got %v", code)
+	}
+}
+
+func TestMCPSearchNotes(t *testing.T) {
+	m := newTestMCP(t)
+
+	out, err := m.searchNotes("POOL leak", "", "")
+	if err != nil {
+		t.Fatalf("searchNotes: %v", err)
+	}
+	if !strings.Contains(out, "2024-01-15 ### At 11:00 #alpha #bug\nConnection pool leak") || strings.Contains(out, "bumping") {
+		t.Errorf("unexpected matches:\n%s", out)
+	}
+
+	out, _ = m.searchNotes("pool", "2024-01-14", "alpha")
+	if !strings.Contains(out, "bumping") || strings.Contains(out, "leak") {
+		t.Errorf("expected only the 2024-01-14 match:\n%s", out)
+	}
+
+	if _, err := m.searchNotes(" ", "", ""); err == nil {
+		t.Error("expected error for empty query")
+	}
+}
+
+func TestMCPRecentActivity(t *testing.T) {
+	m := newTestMCP(t)
+
+	out, err := m.recentActivity("alpha", 0)
+	if err != nil {
+		t.Fatalf("recentActivity: %v", err)
+	}
+	// Newest first; the unsummarized day falls back to its notes.
+	newer := strings.Index(out, "# 2024-01-15 (not summarized yet; notes)")
+	older := strings.Index(out, "# 2024-01-14\n\nInvestigated pool exhaustion.")
+	if newer < 0 || older < 0 || newer > older {
+		t.Errorf("unexpected activity:\n%s", out)
+	}
+
+	out, _ = m.recentActivity("gamma", 3)
+	if !strings.Contains(out, "No activity recorded for gamma") {
+		t.Errorf("expected no activity, got:\n%s", out)
+	}
+
+	if _, err := m.callTool("list_recent_activity", json.RawMessage(`{}`)); err == nil {
+		t.Error("expected error without project")
+	}
+	if _, err := m.callTool("delete_everything", nil); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
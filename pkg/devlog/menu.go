@@ -0,0 +1,79 @@
+package devlog
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// menuLauncher is a dmenu-compatible program: it reads choices on stdin, one
+// per line, and prints the selected or typed line.
+type menuLauncher struct {
+	args       []string
+	promptFlag string
+}
+
+// menuLaunchers are tried in order when no launcher is given.
+var menuLaunchers = []menuLauncher{
+	{args: []string{"rofi", "-dmenu"}, promptFlag: "-p"},
+	{args: []string{"fuzzel", "--dmenu"}, promptFlag: "--prompt"},
+	{args: []string{"wofi", "--dmenu"}, promptFlag: "--prompt"},
+	{args: []string{"dmenu"}, promptFlag: "-p"},
+}
+
+// resolveMenuLauncher returns the launcher for cmdline, or the first
+// installed known launcher if cmdline is empty. A custom command gets a prompt
+// flag only if it is one of the known launchers.
+func resolveMenuLauncher(cmdline string) (menuLauncher, error) {
+	if cmdline != "" {
+		args := strings.Fields(cmdline)
+		l := menuLauncher{args: args}
+		for _, known := range menuLaunchers {
+			if filepath.Base(args[0]) == known.args[0] {
+				l.promptFlag = known.promptFlag
+			}
+		}
+		return l, nil
+	}
+	var names []string
+	for _, l := range menuLaunchers {
+		if _, err := exec.LookPath(l.args[0]); err == nil {
+			return l, nil
+		}
+		names = append(names, l.args[0])
+	}
+	return menuLauncher{}, fmt.Errorf("no menu launcher found (tried %s); use --cmd", strings.Join(names, ", "))
+}
+
+// choose shows lines in the launcher with prompt and returns the selected or
+// typed line, or "" if the menu was dismissed.
+func (l menuLauncher) choose(prompt string, lines []string) (string, error) {
+	args := append([]string{}, l.args[1:]...)
+	if l.promptFlag != "" {
+		args = append(args, l.promptFlag, prompt)
+	}
+	cmd := exec.Command(l.args[0], args...)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n"))
+	out, err := cmd.Output()
+	if err != nil {
+		// dmenu-style launchers exit non-zero when dismissed with Escape.
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil
+		}
+		return "", fmt.Errorf("%s: %w", l.args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// menuLines returns the "#project" choices offered by devlog menu: the
+// watched projects, sorted.
+func menuLines(watched []WatchEntry) []string {
+	var lines []string
+	for _, w := range watched {
+		lines = append(lines, "#"+w.Name)
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,48 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMenuLines(t *testing.T) {
+	lines := menuLines([]WatchEntry{{Path: "/dev/web", Name: "web"}, {Path: "/dev/api", Name: "api"}})
+	if len(lines) != 2 || lines[0] != "#api" || lines[1] != "#web" {
+		t.Errorf("unexpected menu lines: %v", lines)
+	}
+}
+
+func TestResolveMenuLauncher(t *testing.T) {
+	l, _ := resolveMenuLauncher("/usr/bin/rofi -dmenu -i")
+	if l.promptFlag != "-p" || len(l.args) != 3 {
+		t.Errorf("expected rofi prompt flag for custom rofi command, got %+v", l)
+	}
+	l, _ = resolveMenuLauncher("my-picker")
+	if l.promptFlag != "" {
+		t.Errorf("expected no prompt flag for unknown launcher, got %q", l.promptFlag)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := resolveMenuLauncher(""); err == nil {
+		t.Error("expected error when no launcher is installed")
+	}
+}
+
+func TestMenuLauncherChoose(t *testing.T) {
+	// A stand-in launcher that picks the last line offered.
+	picker := filepath.Join(t.TempDir(), "picker")
+	os.WriteFile(picker, []byte("#!/bin/sh\ntail -n 1\n"), 0o755)
+	l := menuLauncher{args: []string{picker}}
+
+	got, err := l.choose("devlog", []string{"#api", "#web"})
+	if err != nil || got != "#web" {
+		t.Errorf("choose = %q, %v", got, err)
+	}
+
+	// Dismissing the menu (non-zero exit) is not an error.
+	l = menuLauncher{args: []string{"false"}}
+	if got, err := l.choose("devlog", nil); err != nil || got != "" {
+		t.Errorf("expected empty selection on dismiss, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,155 @@
+package devlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultNoteHeading is the note_heading template used when none is set.
+const defaultNoteHeading = "### At <time>"
+
+// noteHeadingVarRe matches the variables of a note_heading template.
+var noteHeadingVarRe = regexp.MustCompile(`<[a-z_]+>`)
+
+// noteHeadingFormat is a note_heading template, tmpl, that notes entries are
+// written with, and re, which matches the headings written with it or with
+// the default template, so that notes written before note_heading was
+// changed are still read. The first non-empty group of re is the heading's
+// time. The note helpers take the format of the config (see
+// Config.noteHeadings); nil is the default.
+type noteHeadingFormat struct {
+	tmpl string
+	re   *regexp.Regexp
+}
+
+var defaultNoteHeadingFormat = &noteHeadingFormat{
+	tmpl: defaultNoteHeading,
+	re:   mustNoteHeadingRe(defaultNoteHeading),
+}
+
+// newNoteHeadingFormat returns the format of the note_heading template tmpl;
+// "" is the default.
+func newNoteHeadingFormat(tmpl string) (*noteHeadingFormat, error) {
+	if tmpl == "" || tmpl == defaultNoteHeading {
+		return defaultNoteHeadingFormat, nil
+	}
+	re, err := noteHeadingPattern(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return &noteHeadingFormat{tmpl: tmpl, re: re}, nil
+}
+
+// noteHeadings returns the note heading format of cfg. loadConfig compiles
+// it; for a Config made otherwise, it is compiled from NoteHeading here.
+func (cfg Config) noteHeadings() *noteHeadingFormat {
+	tmpl := orDefault(cfg.NoteHeading, defaultNoteHeading)
+	if cfg.noteFormat != nil && cfg.noteFormat.tmpl == tmpl {
+		return cfg.noteFormat
+	}
+	if f, err := newNoteHeadingFormat(tmpl); err == nil {
+		return f
+	}
+	// loadConfig rejects an invalid note_heading.
+	return defaultNoteHeadingFormat
+}
+
+func (f *noteHeadingFormat) orDefault() *noteHeadingFormat {
+	if f == nil {
+		return defaultNoteHeadingFormat
+	}
+	return f
+}
+
+// isNoteHeading reports whether line is the heading of a notes entry.
+func isNoteHeading(headings *noteHeadingFormat, line string) bool {
+	return headings.orDefault().re.MatchString(line)
+}
+
+// compileNoteHeading returns the pattern of the headings written with tmpl,
+// with the time as its only group. tmpl must hold <time> once, may hold
+// <date>, and must fit on one line.
+func compileNoteHeading(tmpl string) (string, error) {
+	if strings.TrimSpace(tmpl) == "" || strings.ContainsAny(tmpl, "\r\n") {
+		return "", fmt.Errorf("invalid note_heading %q: must be a single non-empty line", tmpl)
+	}
+	if strings.Count(tmpl, "<time>") != 1 {
+		return "", fmt.Errorf("invalid note_heading %q: must contain <time> once", tmpl)
+	}
+	var pattern strings.Builder
+	last := 0
+	for _, loc := range noteHeadingVarRe.FindAllStringIndex(tmpl, -1) {
+		pattern.WriteString(regexp.QuoteMeta(tmpl[last:loc[0]]))
+		switch v := tmpl[loc[0]:loc[1]]; v {
+		case "<time>":
+			pattern.WriteString(`(\d{2}:\d{2})`)
+		case "<date>":
+			pattern.WriteString(`\d{4}-\d{2}-\d{2}`)
+		default:
+			return "", fmt.Errorf("invalid note_heading %q: unknown variable %s (want <time> or <date>)", tmpl, v)
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(strings.TrimRight(tmpl[last:], " \t")))
+	return pattern.String(), nil
+}
+
+func mustNoteHeadingRe(tmpl string) *regexp.Regexp {
+	re, err := noteHeadingPattern(tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return re
+}
+
+// noteHeadingPattern returns the regexp matching headings written with tmpl
+// or the default template, followed by whitespace or the end of the line.
+func noteHeadingPattern(tmpl string) (*regexp.Regexp, error) {
+	pattern, err := compileNoteHeading(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl != defaultNoteHeading {
+		def, _ := compileNoteHeading(defaultNoteHeading)
+		pattern = pattern + "|" + def
+	}
+	return regexp.Compile(`^(?:` + pattern + `)(\s|$)`)
+}
+
+// formatNoteHeading returns the heading of a notes entry logged at clock
+// (HH:MM) on date, tagged with tags.
+func formatNoteHeading(headings *noteHeadingFormat, date, clock string, tags ...string) string {
+	heading := strings.NewReplacer("<time>", clock, "<date>", date).Replace(headings.orDefault().tmpl)
+	heading = strings.TrimRight(heading, " \t")
+	for _, t := range tags {
+		if t != "" {
+			heading += " #" + t
+		}
+	}
+	return heading
+}
+
+// parseNoteHeading reports whether line is a notes entry heading and returns
+// its time (HH:MM) and the text after it, which holds its hashtags.
+func parseNoteHeading(headings *noteHeadingFormat, line string) (clock, rest string, ok bool) {
+	m := headings.orDefault().re.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	for _, g := range m[1 : len(m)-1] {
+		if g != "" {
+			clock = g
+			break
+		}
+	}
+	return clock, line[len(m[0]):], true
+}
+
+// noteEntryClock returns the time (HH:MM) of a notes entry, or "" if it does
+// not start with a heading.
+func noteEntryClock(headings *noteHeadingFormat, entry string) string {
+	line, _, _ := strings.Cut(entry, "\n")
+	clock, _, _ := parseNoteHeading(headings, line)
+	return clock
+}
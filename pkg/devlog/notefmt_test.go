@@ -0,0 +1,76 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNoteHeading(t *testing.T) {
+	h, err := newNoteHeadingFormat("## 📝 <date>T<time>")
+	if err != nil {
+		t.Fatalf("newNoteHeadingFormat: %v", err)
+	}
+
+	notesFile := filepath.Join(t.TempDir(), "notes.md")
+	// A note written with the default heading before note_heading was set.
+	os.WriteFile(notesFile, []byte("### At 08:00 #api\nOld heading\n\n"), 0o644)
+	at := func(clock string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", "2024-01-15 "+clock, time.Local)
+		return t
+	}
+	writeNote(h, notesFile, at("10:00"), "Later", "api", "bug")
+	writeNote(h, notesFile, at("09:00"), "Backdated", "web")
+
+	data, _ := os.ReadFile(notesFile)
+	want := "### At 08:00 #api\nOld heading\n\n" +
+		"## 📝 2024-01-15T09:00 #web\nBackdated\n\n" +
+		"## 📝 2024-01-15T10:00 #api #bug\nLater\n\n"
+	if string(data) != want {
+		t.Fatalf("notes file:\n%q\nwant:\n%q", data, want)
+	}
+
+	if got := filterNotesForProject(h, string(data), "api"); got != "### At 08:00 #api\nOld heading\n\n## 📝 2024-01-15T10:00 #api #bug\nLater" {
+		t.Errorf("filterNotesForProject = %q", got)
+	}
+	units, err := readRawUnits(h, rawSource{Path: notesFile, Date: "2024-01-15", Kind: "notes"})
+	if err != nil || len(units) != 3 || units[0].Time != "08:00" || units[2].Time != "10:00" {
+		t.Errorf("readRawUnits = %+v, %v; want the entries' times", units, err)
+	}
+	if tags, ok := noteHeadingTags(h, "## 📝 2024-01-15T10:00 #api #bug"); !ok || len(tags) != 1 || tags[0] != "api" {
+		t.Errorf("noteHeadingTags = %v, %v; want [api]", tags, ok)
+	}
+	if clock := noteEntryClock(h, "## 📝 2024-01-15T10:00 #api\nLater"); clock != "10:00" {
+		t.Errorf("noteEntryClock = %q, want 10:00", clock)
+	}
+	if _, _, ok := parseNoteHeading(h, "## 📝 Jan 15 10:00"); ok {
+		t.Error("a heading not in either format should not match")
+	}
+	if got, n := retagNotes(h, "## 📝 2024-01-15T10:00 #api #bug\nLater", "api", "core"); n != 1 || got != "## 📝 2024-01-15T10:00 #core #bug\nLater" {
+		t.Errorf("retagNotes = %q, %d", got, n)
+	}
+
+	for _, tmpl := range []string{"### At", "<time> <time>", "### <when> <time>", "### <time>\nnext", "  "} {
+		if _, err := newNoteHeadingFormat(tmpl); err == nil {
+			t.Errorf("newNoteHeadingFormat(%q): expected an error", tmpl)
+		}
+	}
+	if got := formatNoteHeading(h, "2024-01-15", "10:00"); got != "## 📝 2024-01-15T10:00" {
+		t.Errorf("formatNoteHeading = %q", got)
+	}
+
+	// The format belongs to the config it was loaded with.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatNoteHeading(cfg.noteHeadings(), "2024-01-15", "10:00"); got != "### At 10:00" {
+		t.Errorf("without a config file the heading should be the default, got %q", got)
+	}
+	cfg.NoteHeading = "## 📝 <date>T<time>"
+	if got := formatNoteHeading(cfg.noteHeadings(), "2024-01-15", "10:00"); got != "## 📝 2024-01-15T10:00" {
+		t.Errorf("a Config with NoteHeading set should use it, got %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package devlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dayNotes is the notes content recorded on one date.
+type dayNotes struct {
+	Date    string
+	Content string
+}
+
+// collectNotes reads the notes files for dates, keeping only the notes tagged
+// with project if it is set ("general" selects notes with no project tag).
+// Dates with no matching notes are left out.
+func collectNotes(cfg Config, dates []string, project string) []dayNotes {
+	var days []dayNotes
+	for _, date := range dates {
+		content := strings.TrimRight(readNotes(cfg, date), "\n")
+		switch project {
+		case "":
+		case "general":
+			content = filterUnaffiliatedNotes(cfg.noteHeadings(), content)
+		default:
+			content = filterNotesForProject(cfg.noteHeadings(), content, project)
+		}
+		if strings.TrimSpace(content) != "" {
+			days = append(days, dayNotes{Date: date, Content: content})
+		}
+	}
+	return days
+}
+
+// printNotes writes notes to w. When they span several dates, each date's
+// notes are preceded by a date heading.
+func printNotes(w io.Writer, days []dayNotes) {
+	for i, d := range days {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if len(days) > 1 {
+			fmt.Fprintf(w, "## %s\n\n", d.Date)
+		}
+		fmt.Fprintln(w, d.Content)
+	}
+}
+
+// projectNotesFiles returns the per-project notes files of date written
+// with notes_per_project (see resolveProjectNotesPath), by project.
+func projectNotesFiles(cfg Config, date string) map[string]string {
+	shared := resolveNotesPath(cfg, date)
+	ext := filepath.Ext(shared)
+	prefix := filepath.Base(strings.TrimSuffix(shared, ext)) + "-"
+	files := make(map[string]string)
+	entries, _ := os.ReadDir(filepath.Dir(shared))
+	for _, e := range entries {
+		name := e.Name()
+		project, ok := strings.CutPrefix(strings.TrimSuffix(name, ext), prefix)
+		if ok && e.Type().IsRegular() && strings.HasSuffix(name, ext) && validateProjectName(project) == nil {
+			files[project] = filepath.Join(filepath.Dir(shared), name)
+		}
+	}
+	return files
+}
+
+// notesPaths returns the notes files of date that exist: the shared notes
+// file, then the per-project files by project name. Both layouts are read
+// whatever notes_per_project is set to, so switching it keeps older notes.
+func notesPaths(cfg Config, date string) []string {
+	var paths []string
+	if shared := resolveNotesPath(cfg, date); fileExists(shared) {
+		paths = append(paths, shared)
+	}
+	files := projectNotesFiles(cfg, date)
+	projects := make([]string, 0, len(files))
+	for p := range files {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	for _, p := range projects {
+		paths = append(paths, files[p])
+	}
+	return paths
+}
+
+// notesPathFor returns the file a note tagged with projects on date goes
+// to: with notes_per_project, the project's own file if there is exactly one
+// project, and otherwise the shared notes file.
+func notesPathFor(cfg Config, date string, projects []string) string {
+	if cfg.NotesPerProject && len(projects) == 1 && projects[0] != "" {
+		return resolveProjectNotesPath(cfg, date, projects[0])
+	}
+	return resolveNotesPath(cfg, date)
+}
+
+// readNotes returns the notes entries of date from all its notes files (see
+// notesPaths), in time order, so that the readers of the single notes file
+// work with either layout. Notes keep their project hashtags in both.
+func readNotes(cfg Config, date string) string {
+	paths := notesPaths(cfg, date)
+	if len(paths) == 1 {
+		data, _ := os.ReadFile(paths[0])
+		return string(data)
+	}
+	var entries []string
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil {
+			entries = append(entries, splitNoteEntries(cfg.noteHeadings(), string(data))...)
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return noteEntryClock(cfg.noteHeadings(), entries[i]) < noteEntryClock(cfg.noteHeadings(), entries[j])
+	})
+	return strings.Join(entries, "\n\n") + "\n"
+}
+
+// splitNoteEntries splits a notes file into its entries, each starting with
+// its heading (see note_heading). Text before the first heading is dropped.
+func splitNoteEntries(headings *noteHeadingFormat, content string) []string {
+	var entries []string
+	for _, line := range strings.Split(content, "\n") {
+		if isNoteHeading(headings, line) {
+			entries = append(entries, line)
+			continue
+		}
+		if len(entries) > 0 {
+			entries[len(entries)-1] += "\n" + line
+		}
+	}
+	for i := range entries {
+		entries[i] = strings.TrimRight(entries[i], "\n ")
+	}
+	return entries
+}
+
+// attachmentPrefix starts the line that references an attached file in a
+// note, e.g. "Attachment: layout-bug.png".
+const attachmentPrefix = "Attachment: "
+
+// attachmentsDir is where files attached to notes on date are stored.
+func attachmentsDir(cfg Config, date string) string {
+	return filepath.Join(resolveRawDir(cfg), date, "attachments")
+}
+
+// copyAttachment copies src into the attachments directory for date and
+// returns its file name there. If a file of that name was already attached,
+// a numeric suffix is added rather than overwriting it.
+func copyAttachment(cfg Config, date, src string) (string, error) {
+	dir := attachmentsDir(cfg, date)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating attachments dir: %w", err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("opening attachment: %w", err)
+	}
+	defer in.Close()
+
+	base := filepath.Base(src)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	name := base
+	var out *os.File
+	for i := 2; ; i++ {
+		out, err = os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !os.IsExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+	if err != nil {
+		return "", fmt.Errorf("creating attachment: %w", err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return "", fmt.Errorf("copying attachment: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("copying attachment: %w", err)
+	}
+	return name, nil
+}
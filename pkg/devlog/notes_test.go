@@ -0,0 +1,117 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectNotes(t *testing.T) {
+	rawDir := t.TempDir()
+	cfg := Config{RawDir: rawDir}
+	for date, content := range map[string]string{
+		"2024-01-14": "### At 09:00 #alpha\nAlpha work\n\n### At 10:00\nGeneral note\n\n",
+		"2024-01-15": "### At 11:00 #beta\nBeta work\n\n",
+	} {
+		os.MkdirAll(filepath.Join(rawDir, date), 0o755)
+		os.WriteFile(filepath.Join(rawDir, date, "notes.md"), []byte(content), 0o644)
+	}
+	dates := []string{"2024-01-13", "2024-01-14", "2024-01-15"}
+
+	days := collectNotes(cfg, dates, "")
+	if len(days) != 2 {
+		t.Fatalf("expected notes for 2 dates, got %+v", days)
+	}
+
+	days = collectNotes(cfg, dates, "alpha")
+	if len(days) != 1 || days[0].Date != "2024-01-14" || days[0].Content != "### At 09:00 #alpha\nAlpha work" {
+		t.Errorf("unexpected alpha notes: %+v", days)
+	}
+
+	days = collectNotes(cfg, dates, "general")
+	if len(days) != 1 || !strings.Contains(days[0].Content, "General note") || strings.Contains(days[0].Content, "Alpha") {
+		t.Errorf("unexpected general notes: %+v", days)
+	}
+
+	var buf bytes.Buffer
+	printNotes(&buf, collectNotes(cfg, dates, ""))
+	out := buf.String()
+	if !strings.Contains(out, "## 2024-01-14\n\n### At 09:00 #alpha") || !strings.Contains(out, "## 2024-01-15\n\n### At 11:00 #beta") {
+		t.Errorf("expected date headings in range output:\n%s", out)
+	}
+}
+
+func TestCopyAttachment(t *testing.T) {
+	cfg := Config{RawDir: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "layout-bug.png")
+	os.WriteFile(src, []byte("png data"), 0o644)
+
+	name, err := copyAttachment(cfg, "2024-01-15", src)
+	if err != nil {
+		t.Fatalf("copyAttachment: %v", err)
+	}
+	if name != "layout-bug.png" {
+		t.Errorf("expected layout-bug.png, got %q", name)
+	}
+	data, _ := os.ReadFile(filepath.Join(attachmentsDir(cfg, "2024-01-15"), name))
+	if string(data) != "png data" {
+		t.Errorf("attachment not copied: %q", data)
+	}
+
+	name, err = copyAttachment(cfg, "2024-01-15", src)
+	if err != nil || name != "layout-bug-2.png" {
+		t.Errorf("expected layout-bug-2.png for second copy, got %q, %v", name, err)
+	}
+}
+
+func TestNotesPerProject(t *testing.T) {
+	rawDir := t.TempDir()
+	cfg := Config{RawDir: rawDir, NotesPerProject: true}
+	date := "2024-01-15"
+	day := filepath.Join(rawDir, date)
+	os.MkdirAll(day, 0o755)
+	// A note written before notes_per_project was set.
+	os.WriteFile(filepath.Join(day, "notes.md"), []byte("### At 08:00 #api\nOld layout\n\n"), 0o644)
+
+	at := func(clock string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, time.Local)
+		return t
+	}
+	for _, n := range []struct {
+		clock    string
+		projects []string
+	}{
+		{"10:00", []string{"api"}},
+		{"09:00", nil},
+		{"11:00", []string{"api", "web"}},
+	} {
+		if err := writeNote(nil, notesPathFor(cfg, date, n.projects), at(n.clock), "At "+n.clock, n.projects...); err != nil {
+			t.Fatalf("writeNote: %v", err)
+		}
+	}
+
+	own, _ := os.ReadFile(filepath.Join(day, "notes-api.md"))
+	if string(own) != "### At 10:00 #api\nAt 10:00\n\n" {
+		t.Errorf("notes-api.md = %q, want the single-project note", own)
+	}
+	if got := notesPaths(cfg, date); len(got) != 2 || got[0] != filepath.Join(day, "notes.md") {
+		t.Errorf("notesPaths = %q, want notes.md then notes-api.md", got)
+	}
+
+	var clocks []string
+	for _, e := range splitNoteEntries(nil, readNotes(cfg, date)) {
+		clocks = append(clocks, strings.Fields(e)[2])
+	}
+	if strings.Join(clocks, " ") != "08:00 09:00 10:00 11:00" {
+		t.Errorf("readNotes entries at %v, want both files in time order", clocks)
+	}
+	if api := filterNotesForProject(nil, readGenNotes(cfg, date), "api"); strings.Count(api, "### At") != 3 {
+		t.Errorf("api notes = %q, want the 08:00, 10:00, and 11:00 notes", api)
+	}
+	if got := discoverProjectsFromNotes(cfg, date); strings.Join(got, ",") != "api,web" {
+		t.Errorf("discoverProjectsFromNotes = %v, want api and web", got)
+	}
+}
@@ -0,0 +1,47 @@
+package devlog
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notifyUrgencyNormal   byte = 1
+	notifyUrgencyCritical byte = 2
+
+	// snapshotFailureNotifyThreshold is the number of consecutive failed
+	// snapshots of a repo after which the server sends a notification.
+	snapshotFailureNotifyThreshold = 3
+)
+
+// notifier sends desktop notifications through org.freedesktop.Notifications.
+// A nil notifier sends nothing, so callers need not check whether D-Bus is
+// available.
+type notifier struct {
+	conn *dbus.Conn
+}
+
+func newNotifier(conn *dbus.Conn) *notifier {
+	if conn == nil {
+		return nil
+	}
+	return &notifier{conn: conn}
+}
+
+// send shows a notification with the given summary line, body, and urgency.
+func (n *notifier) send(summary, body string, urgency byte) error {
+	if n == nil {
+		return nil
+	}
+	obj := n.conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	call := obj.Call("org.freedesktop.Notifications.Notify", 0,
+		"devlog",        // app_name
+		uint32(0),       // replaces_id
+		"document-edit", // app_icon
+		summary,
+		body,
+		[]string{}, // actions
+		map[string]dbus.Variant{"urgency": dbus.MakeVariant(urgency)},
+		int32(-1), // expire_timeout: server default
+	)
+	return call.Err
+}
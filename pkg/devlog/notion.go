@@ -0,0 +1,375 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// notionVersion is the Notion API version requests are made against.
+const notionVersion = "2022-06-28"
+
+// Notion API limits: a rich text object holds at most 2000 characters, and
+// a request adds at most 100 blocks.
+const (
+	notionTextLimit  = 2000
+	notionBlockLimit = 100
+)
+
+// notionAPI is the base URL of the Notion API; tests point it elsewhere.
+var notionAPI = "https://api.notion.com/v1"
+
+var (
+	// mdListItemRe matches a bulleted or numbered list item; the number is
+	// in the first group.
+	mdListItemRe = regexp.MustCompile(`^\s*(?:(\d+\.)|([-*+]))\s+(.*)$`)
+
+	// mdInlineRe matches inline Markdown: **bold**, `code`, or [text](url).
+	mdInlineRe = regexp.MustCompile("\\*\\*(.+?)\\*\\*|`([^`]+)`|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)")
+)
+
+// resolveNotionToken returns the Notion integration token: $NOTION_TOKEN, or
+// notion_token from the config.
+func resolveNotionToken(cfg Config) string {
+	if token := os.Getenv("NOTION_TOKEN"); token != "" {
+		return token
+	}
+	return cfg.NotionToken
+}
+
+// notionClient makes requests to the Notion API.
+type notionClient struct {
+	token string
+	http  *http.Client
+}
+
+func newNotionClient(token string) *notionClient {
+	return &notionClient{token: token, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// do sends a request with body, if it is not nil, as JSON, and decodes the
+// response into out, if it is not nil.
+func (c *notionClient) do(method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, notionAPI+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Notion-Version", notionVersion)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("Notion API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("Notion API returned %s: %s", resp.Status, apiErr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// databaseProperties returns the names of the title property of a database
+// and of its first date property, if it has one.
+func (c *notionClient) databaseProperties(db string) (string, string, error) {
+	var resp struct {
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := c.do("GET", "/databases/"+db, nil, &resp); err != nil {
+		return "", "", err
+	}
+	names := make([]string, 0, len(resp.Properties))
+	for name := range resp.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var title, date string
+	for _, name := range names {
+		switch resp.Properties[name].Type {
+		case "title":
+			title = name
+		case "date":
+			if date == "" {
+				date = name
+			}
+		}
+	}
+	if title == "" {
+		return "", "", fmt.Errorf("Notion database %s has no title property", db)
+	}
+	return title, date, nil
+}
+
+// findPage returns the ID of the page in db whose title is title, or "" if
+// there is none.
+func (c *notionClient) findPage(db, titleProp, title string) (string, error) {
+	var resp struct {
+		Results []struct {
+			ID string `json:"id"`
+		} `json:"results"`
+	}
+	query := map[string]any{
+		"filter": map[string]any{"property": titleProp, "title": map[string]any{"equals": title}},
+	}
+	if err := c.do("POST", "/databases/"+db+"/query", query, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Results) == 0 {
+		return "", nil
+	}
+	return resp.Results[0].ID, nil
+}
+
+// clearPage deletes the blocks of a page.
+func (c *notionClient) clearPage(page string) error {
+	for {
+		var resp struct {
+			Results []struct {
+				ID string `json:"id"`
+			} `json:"results"`
+		}
+		if err := c.do("GET", "/blocks/"+page+"/children?page_size=100", nil, &resp); err != nil {
+			return err
+		}
+		if len(resp.Results) == 0 {
+			return nil
+		}
+		for _, b := range resp.Results {
+			if err := c.do("DELETE", "/blocks/"+b.ID, nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// appendBlocks adds blocks to the end of a page, as many per request as
+// the API allows.
+func (c *notionClient) appendBlocks(page string, blocks []map[string]any) error {
+	for len(blocks) > 0 {
+		n := min(len(blocks), notionBlockLimit)
+		if err := c.do("PATCH", "/blocks/"+page+"/children", map[string]any{"children": blocks[:n]}, nil); err != nil {
+			return err
+		}
+		blocks = blocks[n:]
+	}
+	return nil
+}
+
+// publishNotion creates or updates the page for date in db with the
+// summary sections, and reports whether it created the page. An existing
+// page keeps its ID, so links to it keep working, and its content is
+// replaced.
+func publishNotion(c *notionClient, db, date string, sections []summarySection) (bool, error) {
+	titleProp, dateProp, err := c.databaseProperties(db)
+	if err != nil {
+		return false, err
+	}
+	blocks := notionBlocks(sections)
+	page, err := c.findPage(db, titleProp, date)
+	if err != nil {
+		return false, err
+	}
+	if page != "" {
+		if err := c.clearPage(page); err != nil {
+			return false, err
+		}
+		return false, c.appendBlocks(page, blocks)
+	}
+
+	props := map[string]any{titleProp: map[string]any{"title": notionRichText(date)}}
+	if dateProp != "" {
+		props[dateProp] = map[string]any{"date": map[string]any{"start": date}}
+	}
+	first := blocks[:min(len(blocks), notionBlockLimit)]
+	var resp struct {
+		ID string `json:"id"`
+	}
+	req := map[string]any{
+		"parent":     map[string]any{"database_id": db},
+		"properties": props,
+		"children":   first,
+	}
+	if err := c.do("POST", "/pages", req, &resp); err != nil {
+		return false, err
+	}
+	return true, c.appendBlocks(resp.ID, blocks[len(first):])
+}
+
+// notionBlock returns a block of the given type holding text.
+func notionBlock(kind, text string) map[string]any {
+	content := map[string]any{"rich_text": notionRichText(text)}
+	if kind == "code" {
+		content = map[string]any{"rich_text": notionPlainText(text), "language": "plain text"}
+	}
+	return map[string]any{"object": "block", "type": kind, kind: content}
+}
+
+// mdBlock is a block of a summary's Markdown.
+type mdBlock struct {
+	Kind   string // "paragraph", "heading", "bullet", "numbered", or "code"
+	Text   string // joined into one line, except for code
+	Number string // the number of a numbered list item, e.g. "2."
+}
+
+// splitMarkdownBlocks splits the Markdown of a summary into paragraphs,
+// headings, list items, and code blocks. Lines of a paragraph or list item
+// are joined, and nested list items are flattened.
+func splitMarkdownBlocks(text string) []mdBlock {
+	var blocks []mdBlock
+	var cur *mdBlock
+	flush := func() {
+		if cur != nil {
+			blocks = append(blocks, *cur)
+		}
+		cur = nil
+	}
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			flush()
+			if !inCode {
+				cur = &mdBlock{Kind: "code"}
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			if cur.Text != "" {
+				cur.Text += "\n"
+			}
+			cur.Text += line
+			continue
+		}
+		if m := mdHeadingRe.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			blocks = append(blocks, mdBlock{Kind: "heading", Text: m[1]})
+			continue
+		}
+		if m := mdListItemRe.FindStringSubmatch(line); m != nil {
+			flush()
+			if m[1] != "" {
+				cur = &mdBlock{Kind: "numbered", Text: m[3], Number: m[1]}
+			} else {
+				cur = &mdBlock{Kind: "bullet", Text: m[3]}
+			}
+			continue
+		}
+		switch {
+		case trimmed == "":
+			flush()
+		case cur != nil:
+			cur.Text += " " + trimmed
+		default:
+			cur = &mdBlock{Kind: "paragraph", Text: trimmed}
+		}
+	}
+	flush()
+	return blocks
+}
+
+// notionBlockTypes maps Markdown block kinds to Notion block types.
+var notionBlockTypes = map[string]string{
+	"paragraph": "paragraph",
+	"heading":   "heading_3",
+	"bullet":    "bulleted_list_item",
+	"numbered":  "numbered_list_item",
+	"code":      "code",
+}
+
+// notionBlocks converts summary sections to Notion blocks: a heading for
+// each project, followed by its summary's paragraphs, headings, list items,
+// and code blocks. Nested list items are flattened.
+func notionBlocks(sections []summarySection) []map[string]any {
+	var blocks []map[string]any
+	for _, s := range sections {
+		blocks = append(blocks, notionBlock("heading_2", s.Project))
+		for _, b := range splitMarkdownBlocks(s.Text) {
+			blocks = append(blocks, notionBlock(notionBlockTypes[b.Kind], b.Text))
+		}
+	}
+	return blocks
+}
+
+// notionRichText converts a line of Markdown to rich text, keeping **bold**,
+// `code`, and [links](url).
+func notionRichText(s string) []map[string]any {
+	var out []map[string]any
+	add := func(text string, annotations map[string]any, link string) {
+		for _, chunk := range splitRunes(text, notionTextLimit) {
+			t := map[string]any{"content": chunk}
+			if link != "" {
+				t["link"] = map[string]any{"url": link}
+			}
+			rt := map[string]any{"type": "text", "text": t}
+			if annotations != nil {
+				rt["annotations"] = annotations
+			}
+			out = append(out, rt)
+		}
+	}
+	last := 0
+	for _, m := range mdInlineRe.FindAllStringSubmatchIndex(s, -1) {
+		add(s[last:m[0]], nil, "")
+		switch {
+		case m[2] >= 0:
+			add(s[m[2]:m[3]], map[string]any{"bold": true}, "")
+		case m[4] >= 0:
+			add(s[m[4]:m[5]], map[string]any{"code": true}, "")
+		default:
+			add(s[m[6]:m[7]], nil, s[m[8]:m[9]])
+		}
+		last = m[1]
+	}
+	add(s[last:], nil, "")
+	return out
+}
+
+// notionPlainText converts text to rich text without any formatting.
+func notionPlainText(s string) []map[string]any {
+	var out []map[string]any
+	for _, chunk := range splitRunes(s, notionTextLimit) {
+		out = append(out, map[string]any{"type": "text", "text": map[string]any{"content": chunk}})
+	}
+	return out
+}
+
+// splitRunes splits s into parts of at most n characters.
+func splitRunes(s string, n int) []string {
+	var parts []string
+	r := []rune(s)
+	for len(r) > n {
+		parts = append(parts, string(r[:n]))
+		r = r[n:]
+	}
+	if len(r) > 0 {
+		parts = append(parts, string(r))
+	}
+	return parts
+}
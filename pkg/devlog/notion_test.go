@@ -0,0 +1,153 @@
+package devlog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotionBlocks(t *testing.T) {
+	sections := []summarySection{{Project: "api", Text: "Fixed the **retry** loop\nin `client.go`.\n\n### Details\n\n- See [the PR](https://example.com/1)\n  - nested\n1. First\n\n```\ngo test\n```"}}
+	var got []string
+	for _, b := range notionBlocks(sections) {
+		kind := b["type"].(string)
+		var texts []string
+		for _, rt := range b[kind].(map[string]any)["rich_text"].([]map[string]any) {
+			text := rt["text"].(map[string]any)
+			s := text["content"].(string)
+			if a, ok := rt["annotations"].(map[string]any); ok {
+				for k := range a {
+					s = k + ":" + s
+				}
+			}
+			if link, ok := text["link"].(map[string]any); ok {
+				s += "<" + link["url"].(string) + ">"
+			}
+			texts = append(texts, s)
+		}
+		got = append(got, kind+" "+strings.Join(texts, "|"))
+	}
+	want := []string{
+		"heading_2 api",
+		"paragraph Fixed the |bold:retry| loop in |code:client.go|.",
+		"heading_3 Details",
+		"bulleted_list_item See |the PR<https://example.com/1>",
+		"bulleted_list_item nested",
+		"numbered_list_item First",
+		"code go test",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("blocks:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSplitRunes(t *testing.T) {
+	if got := splitRunes("héllo", 2); strings.Join(got, ",") != "hé,ll,o" {
+		t.Errorf("splitRunes = %q", got)
+	}
+}
+
+// fakeNotion is a Notion API with one database, "db", holding pages titled
+// by date.
+type fakeNotion struct {
+	pages    map[string]string // title -> page ID
+	children map[string]int    // page ID -> number of blocks
+	requests []string
+}
+
+func (f *fakeNotion) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") != notionVersion {
+		http.Error(w, `{"message": "API token is invalid."}`, http.StatusUnauthorized)
+		return
+	}
+	var body map[string]any
+	data, _ := io.ReadAll(r.Body)
+	json.Unmarshal(data, &body)
+	path := r.URL.Path
+	switch {
+	case r.Method == "GET" && path == "/databases/db":
+		io.WriteString(w, `{"properties": {"Name": {"type": "title"}, "Day": {"type": "date"}, "Tags": {"type": "multi_select"}}}`)
+	case r.Method == "POST" && path == "/databases/db/query":
+		title := body["filter"].(map[string]any)["title"].(map[string]any)["equals"].(string)
+		if id, ok := f.pages[title]; ok {
+			io.WriteString(w, `{"results": [{"id": "`+id+`"}]}`)
+		} else {
+			io.WriteString(w, `{"results": []}`)
+		}
+	case r.Method == "POST" && path == "/pages":
+		props := body["properties"].(map[string]any)
+		title := props["Name"].(map[string]any)["title"].([]any)[0].(map[string]any)["text"].(map[string]any)["content"].(string)
+		if props["Day"].(map[string]any)["date"].(map[string]any)["start"] != title {
+			http.Error(w, `{"message": "bad date"}`, http.StatusBadRequest)
+			return
+		}
+		id := "page-" + title
+		f.pages[title] = id
+		f.children[id] = len(body["children"].([]any))
+		io.WriteString(w, `{"id": "`+id+`"}`)
+	case r.Method == "GET" && strings.HasSuffix(path, "/children"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/blocks/"), "/children")
+		var results []string
+		for i := 0; i < min(f.children[id], 100); i++ {
+			results = append(results, `{"id": "`+id+`-block"}`)
+		}
+		io.WriteString(w, `{"results": [`+strings.Join(results, ",")+`]}`)
+	case r.Method == "DELETE":
+		for id := range f.children {
+			if strings.HasPrefix(strings.TrimPrefix(path, "/blocks/"), id) {
+				f.children[id]--
+			}
+		}
+		io.WriteString(w, `{}`)
+	case r.Method == "PATCH" && strings.HasSuffix(path, "/children"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/blocks/"), "/children")
+		f.children[id] += len(body["children"].([]any))
+		io.WriteString(w, `{}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestPublishNotion(t *testing.T) {
+	fake := &fakeNotion{pages: map[string]string{}, children: map[string]int{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	old := notionAPI
+	notionAPI = srv.URL
+	defer func() { notionAPI = old }()
+
+	// 150 list items need a second request.
+	long := strings.Repeat("- item\n", 150)
+	sections := []summarySection{{Project: "api", Text: long}}
+	c := newNotionClient("secret")
+	created, err := publishNotion(c, "db", "2024-01-15", sections)
+	if err != nil || !created {
+		t.Fatalf("publishNotion = %v, %v; want created", created, err)
+	}
+	if n := fake.children["page-2024-01-15"]; n != 151 {
+		t.Errorf("page has %d blocks, want 151", n)
+	}
+
+	fake.requests = nil
+	created, err = publishNotion(c, "db", "2024-01-15", []summarySection{{Project: "api", Text: "Shorter."}})
+	if err != nil || created {
+		t.Fatalf("publishNotion = %v, %v; want updated", created, err)
+	}
+	if n := fake.children["page-2024-01-15"]; n != 2 {
+		t.Errorf("page has %d blocks after the update, want 2", n)
+	}
+	for _, r := range fake.requests {
+		if r == "POST /pages" {
+			t.Error("updating should not create a page")
+		}
+	}
+
+	_, err = publishNotion(newNotionClient("wrong"), "db", "2024-01-16", sections)
+	if err == nil || !strings.Contains(err.Error(), "API token is invalid.") {
+		t.Errorf("expected the API error message, got %v", err)
+	}
+}
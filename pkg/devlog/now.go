@@ -0,0 +1,215 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultNowWindow is how far back `devlog now` looks.
+const defaultNowWindow = 90 * time.Minute
+
+// nowTermTail is how much of the end of a terminal log `devlog now` reads:
+// the recent output is what matters, and a log has no times inside.
+const nowTermTail = 16 * 1024
+
+// nowTokenBudget caps the recap prompt, which goes to comp_cmd and should be
+// quick. A smaller token_budget applies instead.
+const nowTokenBudget = 30000
+
+// windowDates returns the dates from since to now, in order.
+func windowDates(since, now time.Time) []string {
+	last := now.Format("2006-01-02")
+	var dates []string
+	for d := since; ; d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		dates = append(dates, date)
+		if date >= last {
+			return dates
+		}
+	}
+}
+
+// snapshotsSince returns the snapshots of the git log of date taken at or
+// after since. Snapshots are in time order, so this is the end of the log.
+func snapshotsSince(log, date string, since time.Time, loc *time.Location) string {
+	for _, m := range snapshotTimeRe.FindAllStringSubmatchIndex(log, -1) {
+		if t, ok := clockOn(date, log[m[2]:m[3]], loc); ok && !t.Before(since) {
+			return log[m[0]:]
+		}
+	}
+	return ""
+}
+
+// notesSince returns the notes entries of date logged at or after since.
+func notesSince(headings *noteHeadingFormat, notes, date string, since time.Time, loc *time.Location) string {
+	var kept []string
+	for _, entry := range splitNoteEntries(headings, notes) {
+		if t, ok := clockOn(date, noteEntryClock(headings, entry), loc); ok && !t.Before(since) {
+			kept = append(kept, strings.TrimSpace(entry))
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// tailFile returns the last n bytes of the file at path, from the start of a
+// line.
+func tailFile(path string, n int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := info.Size() - n
+	if offset <= 0 {
+		data, err := os.ReadFile(path)
+		return string(data), err
+	}
+	buf := make([]byte, n)
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		return "", err
+	}
+	s := string(buf)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return s, nil
+}
+
+// recentActivity returns the data of project, or of every project if
+// project is "", from since to now: the git snapshots taken, the ends of the
+// terminal logs written, the Claude Code messages, and the notes. Keys are
+// file names like those of the summary prompt, one per project and kind.
+func recentActivity(cfg Config, state State, project string, since, now time.Time) map[string]string {
+	loc := now.Location()
+	dates := windowDates(since, now)
+	projects := []string{project}
+	if project == "" {
+		seen := make(map[string]bool)
+		projects = nil
+		for _, date := range dates {
+			for _, p := range discoverAllProjects(cfg, state, date) {
+				if !seen[p] {
+					seen[p] = true
+					projects = append(projects, p)
+				}
+			}
+		}
+		if hasUnaffiliatedNotes(cfg, dates[len(dates)-1]) {
+			projects = append(projects, "general")
+		}
+	}
+
+	files := make(map[string]string)
+	add := func(name, content string) {
+		if content = strings.TrimSpace(content); content != "" {
+			if files[name] != "" {
+				content = files[name] + "\n\n" + content
+			}
+			files[name] = content
+		}
+	}
+	claudeDir := resolveClaudeCodeDir(cfg)
+	for _, proj := range projects {
+		for _, date := range dates {
+			if proj != "general" {
+				if log, err := readGitLog(resolveGitPath(cfg, date, proj)); err == nil {
+					add("git-"+proj+".log", snapshotsSince(log, date, since, loc))
+				}
+				matches, _ := filepath.Glob(resolveTermGlob(cfg, date, proj))
+				sort.Strings(matches)
+				for _, m := range matches {
+					if info, err := os.Stat(m); err != nil || info.ModTime().Before(since) {
+						continue
+					}
+					if tail, err := tailFile(m, nowTermTail); err == nil {
+						add("term-"+proj+".log", tail)
+					}
+				}
+			}
+			notes := readGenNotes(cfg, date)
+			if proj == "general" {
+				notes = filterUnaffiliatedNotes(cfg.noteHeadings(), notes)
+			} else {
+				notes = filterNotesForProject(cfg.noteHeadings(), notes, proj)
+			}
+			add("notes-"+proj+".md", notesSince(cfg.noteHeadings(), notes, date, since, loc))
+		}
+		if claudeDir == "" {
+			continue
+		}
+		for _, w := range state.Watched {
+			if w.Name == proj {
+				if transcript, err := preprocessClaudeCodeSessionsSince(claudeSourcesFor(claudeDir, w), since, loc); err == nil {
+					add("claude-"+proj+".txt", transcript)
+				}
+				break
+			}
+		}
+	}
+	return files
+}
+
+// assembleNowPrompt returns the prompt asking for a recap of files, the
+// activity from since to now.
+func assembleNowPrompt(files map[string]string, since, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are helping a software developer get back to work after an\n"+
+		"interruption. Below is what they did from %s to %s.\n", since.Format("15:04"), now.Format("15:04"))
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n", name, files[name])
+	}
+
+	b.WriteString(`
+Description of data sources (<project> is the project's name):
+
+- git-<project>.log: Time-stamped snapshots of the uncommitted code changes,
+  each a full diff; the last one is the current state of the code.
+- term-<project>.log: The end of terminal session recordings: commands run
+  and their output, including test runs and errors.
+- claude-<project>.txt: Conversations with an AI coding assistant.
+- notes-<project>.md: Notes the developer logged, with their times.
+
+Task: Write a recap of at most 5 short lines, addressed to the developer, so
+that they can pick up where they left off:
+
+- what they were doing ("You were ..."),
+- the last error or failing test they ran into, if there was one, and
+- what they were about to do next.
+
+Be specific: name the files, functions, commands, and error messages. Output
+only the recap, without a heading or preamble.
+`)
+	return b.String()
+}
+
+// nowPrompt returns the recap prompt for project's activity (or all
+// activity, if project is "") in the window before now, or "" if there was
+// none.
+func nowPrompt(cfg Config, state State, project string, window time.Duration, now time.Time) string {
+	since := now.Add(-window)
+	files := recentActivity(cfg, state, project, since, now)
+	if len(files) == 0 {
+		return ""
+	}
+	budget := nowTokenBudget
+	if cfg.TokenBudget > 0 && cfg.TokenBudget < budget {
+		budget = cfg.TokenBudget
+	}
+	counts := applyTokenBudget(files, budget)
+	warnTruncated("recap prompt", counts, budget)
+	return assembleNowPrompt(files, since, now)
+}
@@ -0,0 +1,61 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecentActivity(t *testing.T) {
+	rawDir := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	cfg := Config{ClaudeCodeDir: new(string)}
+	now := time.Date(2024, 1, 15, 11, 0, 0, 0, time.Local)
+	date := "2024-01-15"
+	dayDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dayDir, 0o755)
+
+	os.WriteFile(filepath.Join(dayDir, "git-api.log"),
+		[]byte("=== SNAPSHOT 08:00 ===\nold diff\n\n=== SNAPSHOT 10:30 ===\nnew diff\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dayDir, "notes.md"),
+		[]byte("### At 08:05 #api\nMorning.\n\n### At 10:40 #api\nTrying a smaller pool.\n\n### At 10:45 #web\nOther project.\n"), 0o644)
+	recent, old := filepath.Join(dayDir, "term-api-1.log"), filepath.Join(dayDir, "term-api-2.log")
+	os.WriteFile(recent, []byte("$ go test ./...\nFAIL: TestPool\n"), 0o644)
+	os.WriteFile(old, []byte("$ make\n"), 0o644)
+	os.Chtimes(recent, now.Add(-10*time.Minute), now.Add(-10*time.Minute))
+	os.Chtimes(old, now.Add(-3*time.Hour), now.Add(-3*time.Hour))
+
+	got := recentActivity(cfg, State{}, "api", now.Add(-90*time.Minute), now)
+	want := map[string]string{
+		"git-api.log":  "=== SNAPSHOT 10:30 ===\nnew diff",
+		"notes-api.md": "### At 10:40 #api\nTrying a smaller pool.",
+		"term-api.log": "$ go test ./...\nFAIL: TestPool",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recentActivity = %q, want %q", got, want)
+	}
+
+	all := recentActivity(cfg, State{}, "", now.Add(-90*time.Minute), now)
+	if all["notes-web.md"] == "" || all["git-api.log"] == "" {
+		t.Errorf("recentActivity of every project = %q", all)
+	}
+
+	prompt := assembleNowPrompt(got, now.Add(-90*time.Minute), now)
+	if !strings.Contains(prompt, "from 09:30 to 11:00") || !strings.Contains(prompt, "--- term-api.log ---\n$ go test") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "term.log")
+	os.WriteFile(path, []byte("first line\nsecond line\nthird\n"), 0o644)
+	if got, _ := tailFile(path, 10); got != "third\n" {
+		t.Errorf("tailFile = %q, want the last whole line", got)
+	}
+	if got, _ := tailFile(path, 100); got != "first line\nsecond line\nthird\n" {
+		t.Errorf("tailFile = %q, want the whole file", got)
+	}
+}
@@ -0,0 +1,86 @@
+package devlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// resolvePartialPath returns the path of the partial summary for date.
+func resolvePartialPath(cfg Config, date string) string {
+	return filepath.Join(resolveLogDir(cfg), "partial", date+".md")
+}
+
+// runGenSoFar summarizes the data of date collected up to now into the
+// partial summary, a scratch file marked as partial, and returns its path
+// and the number of projects summarized. The day's summary is left alone,
+// and the partial summary is regenerated each time, without a staleness
+// check.
+func runGenSoFar(ctx context.Context, cfg Config, state State, date string, only []string, p *genProgress) (string, int, error) {
+	now := time.Now()
+	projects := withoutExcluded(cfg, state, discoverAllProjects(cfg, state, date))
+	if hasUnaffiliatedNotes(cfg, date) && !genExcluded(cfg, state, "general") {
+		projects = append(projects, "general")
+	}
+	if len(projects) == 0 {
+		fmt.Fprintf(os.Stderr, "No raw data for %s\n", date)
+		return "", 0, nil
+	}
+	projects, err := selectProjects(projects, only, date)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := checkGenCommands(cfg); err != nil {
+		return "", 0, err
+	}
+	runCollectors(cfg, state, date, projects, p)
+
+	var sections []summarySection
+	for i, proj := range projects {
+		if ctx.Err() != nil {
+			return "", 0, errGenCancelled
+		}
+		p.printf("summarizing project %d/%d: %s", i+1, len(projects), proj)
+		summary, _, err := generateProjectSummary(ctx, cfg, state, proj, date, p)
+		if err != nil {
+			return "", 0, fmt.Errorf("generating summary for %s: %w", proj, err)
+		}
+		if summary != "" {
+			sections = append(sections, summarySection{Project: proj, Text: summary})
+		}
+	}
+	if len(sections) == 0 {
+		fmt.Fprintf(os.Stderr, "No raw data for %s\n", date)
+		return "", 0, nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s (partial, as of %s)\n", date, now.Format("15:04"))
+	fmt.Fprintf(&out, "\n> Partial summary of the data collected up to %s. The day's summary is\n"+
+		"> written by `devlog gen`; this file is not part of the log.\n", now.Format("15:04"))
+	if header := workdayHeader(cfg, state, date); header != "" {
+		fmt.Fprintf(&out, "\n%s\n", header)
+	}
+	for _, s := range sections {
+		fmt.Fprintf(&out, "\n## %s\n\n%s\n", s.Project, s.Text)
+	}
+
+	path := resolvePartialPath(cfg, date)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", 0, fmt.Errorf("creating partial dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(out.String()), 0o644); err != nil {
+		os.Remove(tmp)
+		return "", 0, fmt.Errorf("writing partial summary: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", 0, fmt.Errorf("writing partial summary: %w", err)
+	}
+	p.printSummary()
+	return path, len(sections), nil
+}
@@ -0,0 +1,51 @@
+package devlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunGenSoFar(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"), []byte("#!/bin/sh\necho 'Worked on the parser.'\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	date := time.Now().Format("2006-01-02")
+	os.MkdirAll(filepath.Join(rawDir, date), 0o755)
+	os.WriteFile(filepath.Join(rawDir, date, "git-api.log"), []byte("=== SNAPSHOT 10:00 ===\ndiff\n\n"), 0o644)
+	os.MkdirAll(logDir, 0o755)
+	summary := "# " + date + "\n\n## api\n\nOld summary.\n"
+	os.WriteFile(summaryPath(Config{}, date), []byte(summary), 0o644)
+
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mycompressor"}
+	path, n, err := runGenSoFar(context.Background(), cfg, State{}, date, nil, nil)
+	if err != nil {
+		t.Fatalf("runGenSoFar: %v", err)
+	}
+	if n != 1 || path != resolvePartialPath(cfg, date) {
+		t.Errorf("runGenSoFar = %q, %d; want the partial summary of 1 project", path, n)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.HasPrefix(content, "# "+date+" (partial, as of ") {
+		t.Errorf("partial summary should be marked as partial:\n%s", content)
+	}
+	if !strings.Contains(content, "## api\n\nWorked on the parser.\n") {
+		t.Errorf("partial summary should have the api section:\n%s", content)
+	}
+	if got, _ := os.ReadFile(summaryPath(cfg, date)); string(got) != summary {
+		t.Errorf("the day's summary should be left alone, got:\n%s", got)
+	}
+}
@@ -0,0 +1,384 @@
+package devlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PDF page geometry, in points: A4 with 2 cm margins.
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 57.0
+)
+
+// PDF fonts: the standard Type 1 fonts every reader has, so none are
+// embedded.
+const (
+	pdfRegular = "F1" // Helvetica
+	pdfBold    = "F2" // Helvetica-Bold
+	pdfMono    = "F3" // Courier
+)
+
+// helveticaWidths are the widths of the printable ASCII characters in
+// Helvetica, in thousandths of the font size, from its AFM file.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+// winAnsi maps the characters outside ASCII that summaries commonly use to
+// their WinAnsiEncoding bytes and Helvetica widths.
+var winAnsi = map[rune]struct {
+	b byte
+	w int
+}{
+	'•': {0x95, 350}, '–': {0x96, 556}, '—': {0x97, 1000}, '…': {0x85, 1000},
+	'‘': {0x91, 222}, '’': {0x92, 222}, '“': {0x93, 333}, '”': {0x94, 333},
+	'é': {0xe9, 556}, 'è': {0xe8, 556}, 'ü': {0xfc, 556}, 'ö': {0xf6, 556},
+	'ä': {0xe4, 556}, '×': {0xd7, 584}, '→': {'>', 584},
+}
+
+// plainMarkdown removes inline Markdown from a line: **bold** and `code`
+// keep their text, and links become "text (url)".
+func plainMarkdown(s string) string {
+	return mdInlineRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdInlineRe.FindStringSubmatch(m)
+		switch {
+		case sub[1] != "":
+			return sub[1]
+		case sub[2] != "":
+			return sub[2]
+		}
+		return sub[3] + " (" + sub[4] + ")"
+	})
+}
+
+// pdfEncode encodes s in WinAnsiEncoding, replacing characters it cannot
+// represent with "?".
+func pdfEncode(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		switch {
+		case r >= 32 && r < 127:
+			b = append(b, byte(r))
+		case r == '\t':
+			b = append(b, ' ', ' ', ' ', ' ')
+		default:
+			if c, ok := winAnsi[r]; ok {
+				b = append(b, c.b)
+			} else {
+				b = append(b, '?')
+			}
+		}
+	}
+	return b
+}
+
+// pdfTextWidth returns the width of encoded text in font at size. Bold text
+// is measured as 10% wider than regular, which slightly overestimates it.
+func pdfTextWidth(text []byte, font string, size float64) float64 {
+	if font == pdfMono {
+		return float64(len(text)) * 0.6 * size
+	}
+	total := 0
+	for _, c := range text {
+		w := 556
+		if c >= 32 && c < 127 {
+			w = helveticaWidths[c-32]
+		} else {
+			for _, e := range winAnsi {
+				if e.b == c {
+					w = e.w
+				}
+			}
+		}
+		total += w
+	}
+	width := float64(total) / 1000 * size
+	if font == pdfBold {
+		width *= 1.1
+	}
+	return width
+}
+
+// pdfWrap breaks encoded text into lines no wider than width, between
+// words where possible. Runs of spaces are collapsed, except in code.
+func pdfWrap(text []byte, font string, size, width float64) [][]byte {
+	var lines [][]byte
+	if font == pdfMono {
+		// Keep the spacing of code and break it anywhere.
+		n := max(int(width/(0.6*size)), 1)
+		for len(text) > n {
+			lines = append(lines, text[:n])
+			text = text[n:]
+		}
+		return append(lines, text)
+	}
+	var line []byte
+	for _, word := range bytes.Fields(text) {
+		next := word
+		if len(line) > 0 {
+			next = append(append(append([]byte(nil), line...), ' '), word...)
+		}
+		if len(line) > 0 && pdfTextWidth(next, font, size) > width {
+			lines = append(lines, line)
+			next = word
+		}
+		// Break words too long for a line on their own.
+		for pdfTextWidth(next, font, size) > width && len(next) > 1 {
+			n := len(next) - 1
+			for n > 1 && pdfTextWidth(next[:n], font, size) > width {
+				n--
+			}
+			lines = append(lines, next[:n])
+			next = next[n:]
+		}
+		line = next
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfEscape escapes encoded text for a PDF string literal.
+func pdfEscape(text []byte) string {
+	var b strings.Builder
+	for _, c := range text {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// pdfReport lays out text on pages: it keeps the content stream of each
+// page and the position of the next line.
+type pdfReport struct {
+	header string // printed at the top of every page but the first
+	pages  []*bytes.Buffer
+	y      float64
+}
+
+func (r *pdfReport) newPage() {
+	r.pages = append(r.pages, new(bytes.Buffer))
+	r.y = pdfPageHeight - pdfMargin
+	if len(r.pages) > 1 && r.header != "" {
+		r.text(pdfMargin, pdfPageHeight-pdfMargin/2, pdfRegular, 8, pdfEncode(r.header))
+		r.y -= 8
+	}
+}
+
+// need starts a new page unless height points are left on this one.
+func (r *pdfReport) need(height float64) {
+	if len(r.pages) == 0 || r.y-height < pdfMargin {
+		r.newPage()
+	}
+}
+
+func (r *pdfReport) text(x, y float64, font string, size float64, text []byte) {
+	fmt.Fprintf(r.pages[len(r.pages)-1], "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(text))
+}
+
+// paragraph writes text wrapped at the right margin, starting at indent
+// from the left margin. If marker is set, it is put before the first line,
+// in the indent, as for list items. keep is the height that must fit below
+// the first line, e.g. to keep a heading with what follows it.
+func (r *pdfReport) paragraph(text, marker, font string, size, indent, spaceAfter, keep float64) {
+	leading := size * 1.35
+	lines := pdfWrap(pdfEncode(text), font, size, pdfPageWidth-2*pdfMargin-indent)
+	for i, line := range lines {
+		if i == 0 {
+			r.need(leading + keep)
+		} else {
+			r.need(leading)
+		}
+		r.y -= leading
+		if i == 0 && marker != "" {
+			r.text(pdfMargin+indent-pdfTextWidth(pdfEncode(marker), font, size)-4, r.y, font, size, pdfEncode(marker))
+		}
+		r.text(pdfMargin+indent, r.y, font, size, line)
+	}
+	r.y -= spaceAfter
+}
+
+// summary writes the Markdown of a summary section.
+func (r *pdfReport) summary(text string) {
+	for _, b := range splitMarkdownBlocks(text) {
+		switch b.Kind {
+		case "heading":
+			r.paragraph(plainMarkdown(b.Text), "", pdfBold, 11, 0, 3, 14)
+		case "bullet":
+			r.paragraph(plainMarkdown(b.Text), "•", pdfRegular, 10.5, 14, 2, 0)
+		case "numbered":
+			r.paragraph(plainMarkdown(b.Text), b.Number, pdfRegular, 10.5, 18, 2, 0)
+		case "code":
+			for _, line := range strings.Split(b.Text, "\n") {
+				r.paragraph(line, "", pdfMono, 8.5, 10, 0, 0)
+			}
+			r.y -= 6
+		default:
+			r.paragraph(plainMarkdown(b.Text), "", pdfRegular, 10.5, 0, 6, 0)
+		}
+	}
+}
+
+// pdfRangeLabel describes the dates of a report: a month ("January 2024")
+// if they are all of one, otherwise the first and last date.
+func pdfRangeLabel(dates []string) string {
+	first, last := dates[0], dates[len(dates)-1]
+	if first[:7] == last[:7] {
+		start, _ := time.Parse("2006-01-02", first)
+		if start.Day() == 1 && start.AddDate(0, 1, -1).Format("2006-01-02") == last {
+			return start.Format("January 2006")
+		}
+	}
+	if first == last {
+		return first
+	}
+	return first + " – " + last
+}
+
+// buildPDFReport lays out the summaries of dates: a title page listing the
+// projects, then a section per day with a subsection per project. It
+// returns the page content streams and the number of days in the report.
+func buildPDFReport(cfg Config, dates []string, now time.Time) ([]*bytes.Buffer, int, error) {
+	type day struct {
+		date     string
+		sections []summarySection
+	}
+	var days []day
+	projectDays := make(map[string]int)
+	for _, date := range dates {
+		data, err := os.ReadFile(summaryPath(cfg, date))
+		if err != nil {
+			continue
+		}
+		sections := splitSummary(string(data))
+		if len(sections) == 0 {
+			continue
+		}
+		days = append(days, day{date, sections})
+		for _, s := range sections {
+			projectDays[s.Project]++
+		}
+	}
+	if len(days) == 0 {
+		return nil, 0, fmt.Errorf("no summaries in the given dates")
+	}
+
+	var reported []string
+	for _, d := range days {
+		reported = append(reported, d.date)
+	}
+	label := pdfRangeLabel(dates)
+	r := &pdfReport{header: "Development log, " + label}
+
+	r.newPage()
+	r.y = pdfPageHeight * 0.62
+	r.paragraph("Development log", "", pdfBold, 28, 0, 10, 0)
+	r.paragraph(label, "", pdfRegular, 16, 0, 4, 0)
+	generated := fmt.Sprintf("%d days summarized (%s to %s). Generated %s.",
+		len(days), reported[0], reported[len(reported)-1], now.Format("2006-01-02"))
+	r.paragraph(generated, "", pdfRegular, 10, 0, 24, 0)
+	r.paragraph("Projects", "", pdfBold, 12, 0, 4, 0)
+	projects := make([]string, 0, len(projectDays))
+	for p := range projectDays {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	for _, p := range projects {
+		r.paragraph(p+": "+plural(projectDays[p], "day"), "•", pdfRegular, 10.5, 14, 2, 0)
+	}
+
+	for i, d := range days {
+		if i == 0 {
+			r.newPage()
+		} else {
+			r.y -= 14
+		}
+		t, _ := time.Parse("2006-01-02", d.date)
+		r.paragraph(t.Format("Monday, January 2, 2006"), "", pdfBold, 16, 0, 6, 40)
+		for _, s := range d.sections {
+			r.paragraph(s.Project, "", pdfBold, 13, 0, 3, 30)
+			r.summary(s.Text)
+			r.y -= 4
+		}
+	}
+	return r.pages, len(days), nil
+}
+
+// writePDF writes a PDF document with the given page content streams to w,
+// adding page numbers at the foot of every page but the first.
+func writePDF(w io.Writer, pages []*bytes.Buffer) error {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// Objects 1-5 are the catalog, the page tree, and the fonts; each page
+	// is then a page object followed by its content stream.
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 6+2*i))
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	for _, font := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		obj(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+	}
+	for i, page := range pages {
+		content := page.Bytes()
+		if i > 0 {
+			var foot bytes.Buffer
+			label := pdfEncode(fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+			fmt.Fprintf(&foot, "BT /%s 8.0 Tf %.2f %.2f Td (%s) Tj ET\n", pdfRegular,
+				pdfPageWidth-pdfMargin-pdfTextWidth(label, pdfRegular, 8), pdfMargin/2, pdfEscape(label))
+			content = append(append([]byte(nil), content...), foot.Bytes()...)
+		}
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// exportPDF writes the summaries of dates to a PDF report at path and
+// returns the number of days in it.
+func exportPDF(cfg Config, path string, dates []string) (int, error) {
+	pages, n, err := buildPDFReport(cfg, dates, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	var b bytes.Buffer
+	if err := writePDF(&b, pages); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		return 0, fmt.Errorf("writing PDF: %w", err)
+	}
+	return n, nil
+}
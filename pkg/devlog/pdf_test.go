@@ -0,0 +1,115 @@
+package devlog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPDFWrap(t *testing.T) {
+	lines := pdfWrap(pdfEncode("the quick brown fox jumps over the lazy dog"), pdfRegular, 10, 100)
+	for _, l := range lines {
+		if w := pdfTextWidth(l, pdfRegular, 10); w > 100 {
+			t.Errorf("line %q is %.1f wide", l, w)
+		}
+	}
+	if got := string(bytes.Join(lines, []byte(" "))); got != "the quick brown fox jumps over the lazy dog" {
+		t.Errorf("wrapped text = %q", got)
+	}
+	if lines := pdfWrap(bytes.Repeat([]byte("x"), 100), pdfRegular, 10, 100); len(lines) < 2 {
+		t.Errorf("expected a long word to be broken, got %q", lines)
+	}
+	if lines := pdfWrap([]byte("a  b"), pdfMono, 10, 30); string(lines[0]) != "a  b" {
+		t.Errorf("code spacing not kept: %q", lines)
+	}
+}
+
+func TestPDFEncode(t *testing.T) {
+	if got := pdfEncode("a—b • “c” 日"); !bytes.Equal(got, []byte("a\x97b \x95 \x93c\x94 ?")) {
+		t.Errorf("pdfEncode = %q", got)
+	}
+	if got := pdfEscape([]byte(`f(x) \ y`)); got != `f\(x\) \\ y` {
+		t.Errorf("pdfEscape = %q", got)
+	}
+}
+
+func TestPDFRangeLabel(t *testing.T) {
+	jan, _ := parseDateRange("2024-01", time.Now())
+	for _, tt := range []struct {
+		dates []string
+		want  string
+	}{
+		{jan, "January 2024"},
+		{jan[:10], "2024-01-01 – 2024-01-10"},
+		{[]string{"2024-01-15"}, "2024-01-15"},
+	} {
+		if got := pdfRangeLabel(tt.dates); got != tt.want {
+			t.Errorf("pdfRangeLabel = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestExportPDF(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_LOG_DIR", tmp)
+	cfg := Config{}
+	long := strings.Repeat("A long paragraph that needs wrapping across several lines. ", 40)
+	os.WriteFile(summaryPath(cfg, "2024-01-15"), []byte("# 2024-01-15\n\n## api\n\n"+long+"\n\n- a (list) item\n\n```\ngo test ./...\n```\n"), 0o644)
+	os.WriteFile(summaryPath(cfg, "2024-01-16"), []byte("# 2024-01-16\n\n## api\n\nMore.\n\n## web\n\nCSS.\n"), 0o644)
+
+	dates, _ := parseDateRange("2024-01", time.Now())
+	pages, n, err := buildPDFReport(cfg, dates, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || n != 2 {
+		t.Fatalf("buildPDFReport = %d days, %v", n, err)
+	}
+	if len(pages) < 2 {
+		t.Fatalf("expected a title page and a report page, got %d pages", len(pages))
+	}
+	title := pages[0].String()
+	for _, want := range []string{"(Development log)", "(January 2024)", "(api: 2 days)", "(web: 1 day)"} {
+		if !strings.Contains(title, want) {
+			t.Errorf("title page lacks %s:\n%s", want, title)
+		}
+	}
+	body := pages[1].String()
+	for _, want := range []string{"(Monday, January 15, 2024)", `(a \(list\) item)`, "/F3 8.5 Tf"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("report page lacks %s", want)
+		}
+	}
+
+	path := filepath.Join(tmp, "report.pdf")
+	if _, err := exportPDF(cfg, path, dates); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF file")
+	}
+	// Every xref entry must point at its object.
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := strings.Split(string(data[xref:]), "\n")[3:]
+	for i, e := range entries {
+		if !strings.HasSuffix(e, " n ") {
+			break
+		}
+		off, _ := strconv.Atoi(e[:10])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[off:off+10])
+		}
+	}
+	if !bytes.Contains(data, []byte(fmt.Sprintf("(Page 2 of %d)", len(pages)))) {
+		t.Error("missing page numbers")
+	}
+
+	if _, _, err := buildPDFReport(cfg, []string{"2024-02-01"}, time.Now()); err == nil {
+		t.Error("expected an error for dates without summaries")
+	}
+}
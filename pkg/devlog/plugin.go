@@ -0,0 +1,221 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pluginProtocol is the version of the plugin protocol, sent with each
+// request.
+const pluginProtocol = 1
+
+// PluginConfig is a plugins entry: an executable that acts as a data source.
+type PluginConfig struct {
+	Name string `toml:"name"` // also the data type of its data
+	Cmd  string `toml:"cmd"`
+}
+
+// pluginRequest is written as JSON to a plugin's stdin. Method is
+// "discover" (the projects with data on Date), "data" (Project's data on
+// Date), or "mtime" (when the data on Date, of Project if it is set, last
+// changed).
+type pluginRequest struct {
+	Protocol int    `json:"protocol"`
+	Method   string `json:"method"`
+	Date     string `json:"date"`
+	Project  string `json:"project,omitempty"`
+}
+
+// pluginResponse is read as JSON from a plugin's stdout. Only the fields of
+// the method called are used; a plugin that cannot answer sets Error.
+type pluginResponse struct {
+	Projects    []string          `json:"projects"`    // discover
+	Description string            `json:"description"` // data: what the data is
+	Files       map[string]string `json:"files"`       // data: name -> content
+	Mtime       time.Time         `json:"mtime"`       // mtime: RFC 3339
+	Error       string            `json:"error"`
+}
+
+// callPlugin runs plugin with req on stdin and decodes its response.
+func callPlugin(pl PluginConfig, req pluginRequest) (pluginResponse, error) {
+	args := strings.Fields(pl.Cmd)
+	if len(args) == 0 {
+		return pluginResponse{}, fmt.Errorf("plugin %s: cmd is empty", pl.Name)
+	}
+	req.Protocol = pluginProtocol
+	in, err := json.Marshal(req)
+	if err != nil {
+		return pluginResponse{}, err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return pluginResponse{}, fmt.Errorf("plugin %s: %s: %v: %s", pl.Name, req.Method, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var resp pluginResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return pluginResponse{}, fmt.Errorf("plugin %s: %s: parsing response: %w", pl.Name, req.Method, err)
+	}
+	if resp.Error != "" {
+		return pluginResponse{}, fmt.Errorf("plugin %s: %s: %s", pl.Name, req.Method, resp.Error)
+	}
+	return resp, nil
+}
+
+// checkPlugins reports the problems with the plugins setting.
+func checkPlugins(plugins []PluginConfig, collectors []CollectCmd) []string {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, c := range collectors {
+		seen[c.DataType] = true
+	}
+	for i, pl := range plugins {
+		setting := fmt.Sprintf("plugins[%d]", i)
+		switch {
+		case !collectNameRe.MatchString(pl.Name):
+			problems = append(problems, fmt.Sprintf("%s: invalid name %q (use lowercase letters, digits, and _)", setting, pl.Name))
+		case containsString(builtinDataTypes, pl.Name):
+			problems = append(problems, fmt.Sprintf("%s: name %q is reserved", setting, pl.Name))
+		case seen[pl.Name]:
+			problems = append(problems, fmt.Sprintf("%s: name %q is already a plugin or collector data_type", setting, pl.Name))
+		}
+		seen[pl.Name] = true
+		problems = append(problems, checkCommand(setting+".cmd", pl.Cmd)...)
+	}
+	return problems
+}
+
+// pluginProjects returns the projects the plugins report data for on date.
+// A failing plugin is reported and skipped.
+func pluginProjects(cfg Config, date string) []string {
+	var projects []string
+	for _, pl := range cfg.Plugins {
+		resp, err := callPlugin(pl, pluginRequest{Method: "discover", Date: date})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		for _, p := range resp.Projects {
+			if validateProjectName(p) == nil && !containsString(projects, p) {
+				projects = append(projects, p)
+			}
+		}
+	}
+	return projects
+}
+
+// resolvePluginPath returns the raw file caching the data plugin name gave
+// for project on date.
+func resolvePluginPath(cfg Config, date, name, project string) string {
+	return filepath.Join(resolveRawDir(cfg), date, "plugin-"+name+"-"+project+".txt")
+}
+
+// formatPluginData renders a data response as the text of its cache file:
+// the plugin's description, then each file under a "=== <name> ===" line.
+func formatPluginData(resp pluginResponse) string {
+	var b strings.Builder
+	if resp.Description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", strings.TrimSpace(resp.Description))
+	}
+	names := make([]string, 0, len(resp.Files))
+	for name := range resp.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n=== %s ===\n%s\n", name, strings.TrimRight(resp.Files[name], "\n"))
+	}
+	return b.String()
+}
+
+// pluginMtime asks plugin when the data on date, of project if it is set,
+// last changed. It returns the zero time if the plugin cannot say.
+func pluginMtime(pl PluginConfig, date, project string) time.Time {
+	resp, err := callPlugin(pl, pluginRequest{Method: "mtime", Date: date, Project: project})
+	if err != nil {
+		return time.Time{}
+	}
+	return resp.Mtime
+}
+
+// refreshPluginData fetches project's data on date from plugin into its
+// cache file, unless the cache is newer than the plugin's mtime. As for
+// collectors, the file is only rewritten when the data changed, and a
+// response without files removes it.
+func refreshPluginData(cfg Config, pl PluginConfig, project, date string) error {
+	path := resolvePluginPath(cfg, date, pl.Name, project)
+	if info, err := os.Stat(path); err == nil {
+		if mtime := pluginMtime(pl, date, project); !mtime.IsZero() && info.ModTime().After(mtime) {
+			return nil
+		}
+	}
+
+	resp, err := callPlugin(pl, pluginRequest{Method: "data", Date: date, Project: project})
+	if err != nil {
+		return err
+	}
+	if len(resp.Files) == 0 {
+		os.Remove(path)
+		return nil
+	}
+	data := []byte(formatPluginData(resp))
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating raw dir: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// refreshPlugins refreshes the data of each plugin for project on date. A
+// failing plugin is reported, and its cached data, if any, is kept.
+func refreshPlugins(cfg Config, project, date string) {
+	for _, pl := range cfg.Plugins {
+		if err := refreshPluginData(cfg, pl, project, date); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// pluginSources returns the cached data of each plugin for project on date,
+// one bulk source per plugin.
+func pluginSources(cfg Config, project, date string) []bulkSource {
+	var sources []bulkSource
+	for _, pl := range cfg.Plugins {
+		path := resolvePluginPath(cfg, date, pl.Name, project)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		sources = append(sources, bulkSource{
+			dataType:    pl.Name,
+			files:       map[string]string{filepath.Base(path): string(data)},
+			sourcePaths: []string{path},
+		})
+	}
+	return sources
+}
+
+// pluginsMtime returns the latest time the plugins report their data on
+// date changed, for the staleness check.
+func pluginsMtime(cfg Config, date string) time.Time {
+	var latest time.Time
+	for _, pl := range cfg.Plugins {
+		if t := pluginMtime(pl, date, ""); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
@@ -0,0 +1,89 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestPlugin writes a plugin that answers each method with a canned
+// response and logs the methods it was called with.
+func writeTestPlugin(t *testing.T, dir, discover, data, mtime string) string {
+	t.Helper()
+	path := filepath.Join(dir, "myplugin")
+	script := "#!/bin/sh\nreq=$(cat)\n" +
+		"echo \"$req\" >> " + filepath.Join(dir, "calls") + "\n" +
+		"case \"$req\" in\n" +
+		"*'\"method\":\"discover\"'*) printf '%s\\n' '" + discover + "' ;;\n" +
+		"*'\"method\":\"data\"'*) printf '%s\\n' '" + data + "' ;;\n" +
+		"*'\"method\":\"mtime\"'*) printf '%s\\n' '" + mtime + "' ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginDataSource(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	plugin := writeTestPlugin(t, tmp,
+		`{"projects": ["alpha", "bad name", "beta"]}`,
+		`{"description": "Jira issues", "files": {"issues.md": "PROJ-1 moved to Done\n"}}`,
+		`{"mtime": "2000-01-01T00:00:00Z"}`)
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty, Plugins: []PluginConfig{{Name: "jira", Cmd: plugin}}}
+
+	if got := discoverAllProjects(cfg, State{}, "2024-01-15"); strings.Join(got, ",") != "alpha,beta" {
+		t.Errorf("discoverAllProjects = %v", got)
+	}
+
+	refreshPlugins(cfg, "alpha", "2024-01-15")
+	sources := pluginSources(cfg, "alpha", "2024-01-15")
+	if len(sources) != 1 || sources[0].dataType != "jira" {
+		t.Fatalf("unexpected sources %+v", sources)
+	}
+	want := "Description: Jira issues\n\n=== issues.md ===\nPROJ-1 moved to Done\n"
+	if got := sources[0].files["plugin-jira-alpha.txt"]; got != want {
+		t.Errorf("plugin data = %q, want %q", got, want)
+	}
+
+	// The cache is newer than the plugin's mtime, so data is not asked for
+	// again.
+	os.Remove(filepath.Join(tmp, "calls"))
+	refreshPlugins(cfg, "alpha", "2024-01-15")
+	calls, _ := os.ReadFile(filepath.Join(tmp, "calls"))
+	if strings.Contains(string(calls), `"method":"data"`) || !strings.Contains(string(calls), `"protocol":1`) {
+		t.Errorf("unexpected calls:\n%s", calls)
+	}
+
+	if got := pluginsMtime(cfg, "2024-01-15"); !got.Equal(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("pluginsMtime = %v", got)
+	}
+}
+
+func TestPluginErrors(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", filepath.Join(tmp, "raw"))
+	plugin := writeTestPlugin(t, tmp, `{"error": "not configured"}`, `not json`, `{}`)
+	pl := PluginConfig{Name: "jira", Cmd: plugin}
+
+	if _, err := callPlugin(pl, pluginRequest{Method: "discover", Date: "2024-01-15"}); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("expected the plugin's error, got %v", err)
+	}
+	if err := refreshPluginData(Config{}, pl, "alpha", "2024-01-15"); err == nil || !strings.Contains(err.Error(), "parsing response") {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+	if !pluginMtime(pl, "2024-01-15", "").IsZero() {
+		t.Error("expected no mtime")
+	}
+
+	problems := checkPlugins([]PluginConfig{{Name: "git", Cmd: plugin}, {Name: "cal", Cmd: plugin}},
+		[]CollectCmd{{Name: "c", Cmd: "true", DataType: "cal"}})
+	if len(problems) != 2 || !strings.Contains(problems[0], "reserved") || !strings.Contains(problems[1], "already") {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
@@ -0,0 +1,130 @@
+package devlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// preambleRe matches an opening line a summarizer adds before the summary
+// itself, e.g. "Here is the summary of my day:", "Sure! Here's a summary.",
+// or "Certainly!".
+var preambleRe = regexp.MustCompile(`(?i)^\**(((sure|certainly|okay|ok|of course)[!,.]?\s*)?(here('s| is| are)|below is|the following is)\b.*(:|\bsummary\b.*\.)|(sure|certainly|okay|ok|of course)[!,.]?)\**$`)
+
+// closingRe matches a closing line a summarizer adds after the summary, e.g.
+// "Let me know if you'd like any changes."
+var closingRe = regexp.MustCompile(`(?i)^(let me know\b|i hope this\b|feel free to\b|if you('d| would) like\b|would you like\b|is there anything else\b)`)
+
+// atxHeadingRe matches a Markdown heading line, e.g. "## Next steps". Its
+// group is the heading text.
+var atxHeadingRe = regexp.MustCompile(`^ {0,3}#{1,6}\s+(.*?)(\s+#+)?\s*$`)
+
+// setextUnderlineRe matches the line under a setext heading.
+var setextUnderlineRe = regexp.MustCompile(`^ {0,3}(=+|-{2,})\s*$`)
+
+// bulletRe matches a bullet list item. Its groups are the indentation, the
+// bullet, and the item text.
+var bulletRe = regexp.MustCompile(`^(\s*)([-*+•])\s+(.*)$`)
+
+// validateSummaryBullet returns an error if bullet is not a valid
+// summary_bullet.
+func validateSummaryBullet(bullet string) error {
+	switch bullet {
+	case "", "-", "*", "+":
+		return nil
+	default:
+		return fmt.Errorf("invalid summary_bullet %q (want -, *, or +)", bullet)
+	}
+}
+
+// normalizeSummary is the formatting pass normalize_summary applies to a
+// project summary from gen_cmd. It strips the summarizer's opening and
+// closing boilerplate, turns headings into plain lines (the prompt asks for
+// none), writes bullets with summary_bullet, and wraps lines longer than
+// summary_wrap. Code blocks are left as they are.
+func normalizeSummary(cfg Config, summary string) string {
+	lines := strings.Split(strings.TrimSpace(summary), "\n")
+	lines = trimBoilerplate(lines)
+
+	var out []string
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			out = append(out, line)
+			continue
+		}
+		if inCode {
+			out = append(out, line)
+			continue
+		}
+		if m := atxHeadingRe.FindStringSubmatch(line); m != nil {
+			line = strings.TrimSpace(m[1])
+		} else if setextUnderlineRe.MatchString(line) && i > 0 && strings.TrimSpace(lines[i-1]) != "" &&
+			!bulletRe.MatchString(lines[i-1]) {
+			continue
+		}
+		if m := bulletRe.FindStringSubmatch(line); m != nil && cfg.SummaryBullet != "" {
+			line = m[1] + cfg.SummaryBullet + " " + m[3]
+		}
+		if cfg.SummaryWrap > 0 {
+			out = append(out, wrapLine(line, cfg.SummaryWrap)...)
+		} else {
+			out = append(out, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// trimBoilerplate drops the opening and closing lines of lines that talk
+// about the summary rather than being part of it, and the blank lines left
+// next to them.
+func trimBoilerplate(lines []string) []string {
+	for len(lines) > 0 && (strings.TrimSpace(lines[0]) == "" || preambleRe.MatchString(strings.TrimSpace(lines[0]))) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 {
+		last := strings.TrimSpace(lines[len(lines)-1])
+		if last != "" && !closingRe.MatchString(last) {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// wrapLine breaks line at spaces into lines of at most width characters
+// where it can; a word longer than width is not broken. Lines continuing a
+// bullet item are indented to its text.
+func wrapLine(line string, width int) []string {
+	if len([]rune(line)) <= width || strings.HasPrefix(strings.TrimSpace(line), "|") {
+		return []string{line}
+	}
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	cont := indent
+	if m := bulletRe.FindStringSubmatch(line); m != nil {
+		cont = indent + strings.Repeat(" ", len([]rune(m[2]))+1)
+	}
+
+	var wrapped []string
+	cur := indent
+	curLen := len([]rune(cur))
+	first := true
+	for _, word := range strings.Fields(line) {
+		n := len([]rune(word))
+		switch {
+		case first:
+			cur += word
+			curLen += n
+			first = false
+		case curLen+1+n > width:
+			wrapped = append(wrapped, cur)
+			cur = cont + word
+			curLen = len([]rune(cont)) + n
+		default:
+			cur += " " + word
+			curLen += 1 + n
+		}
+	}
+	return append(wrapped, cur)
+}
@@ -0,0 +1,58 @@
+package devlog
+
+import "testing"
+
+func TestNormalizeSummary(t *testing.T) {
+	summary := "Sure! Here is the summary of my day:\n\n" +
+		"## Work on the parser\n\n" +
+		"I rewrote the tokenizer so that it handles nested quotes, which fixed the crash on config files with escaped strings.\n\n" +
+		"Next steps\n----------\n" +
+		"* Add tests for the new tokenizer\n" +
+		"  + Cover escaped quotes\n\n" +
+		"```\n* not a bullet in code\n```\n\n" +
+		"Let me know if you'd like any changes."
+	cfg := Config{SummaryWrap: 40, SummaryBullet: "-"}
+	want := "Work on the parser\n\n" +
+		"I rewrote the tokenizer so that it\nhandles nested quotes, which fixed the\ncrash on config files with escaped\nstrings.\n\n" +
+		"Next steps\n" +
+		"- Add tests for the new tokenizer\n" +
+		"  - Cover escaped quotes\n\n" +
+		"```\n* not a bullet in code\n```"
+	if got := normalizeSummary(cfg, summary); got != want {
+		t.Errorf("normalizeSummary:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Without summary_wrap or summary_bullet, only boilerplate and headings go.
+	if got := normalizeSummary(Config{}, "# Title\n* Keep * bullets and a very long line as it is"); got != "Title\n* Keep * bullets and a very long line as it is" {
+		t.Errorf("normalizeSummary without options = %q", got)
+	}
+	// A first line that is part of the summary stays.
+	if got := normalizeSummary(Config{}, "Here is where I left off: the cache.\nMore."); got != "Here is where I left off: the cache.\nMore." {
+		t.Errorf("normalizeSummary dropped a content line: %q", got)
+	}
+}
+
+func TestWrapLine(t *testing.T) {
+	for _, tc := range []struct {
+		line  string
+		width int
+		want  []string
+	}{
+		{"short line", 20, []string{"short line"}},
+		{"- a bullet item that wraps", 14, []string{"- a bullet", "  item that", "  wraps"}},
+		{"averyveryverylongword and more", 10, []string{"averyveryverylongword", "and more"}},
+		{"| a | table | row | stays |", 10, []string{"| a | table | row | stays |"}},
+	} {
+		got := wrapLine(tc.line, tc.width)
+		if len(got) != len(tc.want) {
+			t.Errorf("wrapLine(%q, %d) = %q, want %q", tc.line, tc.width, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("wrapLine(%q, %d) = %q, want %q", tc.line, tc.width, got, tc.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package devlog
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	upowerBusName   = "org.freedesktop.UPower"
+	upowerPath      = "/org/freedesktop/UPower"
+	upowerInterface = "org.freedesktop.UPower"
+)
+
+// startPowerMonitor follows whether the machine runs on battery power, from
+// UPower's OnBattery property on the system bus, for the server to stretch
+// the snapshot interval (battery_snapshot_interval) and defer its background
+// work (battery_defer) while on battery. It returns a cleanup function that
+// closes the connection, or nil without UPower, in which case the machine is
+// taken to be on AC power.
+func startPowerMonitor(s *Server) func() {
+	logger := s.logger.With("component", "power")
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		logger.Info("D-Bus system bus unavailable, power-aware throttling disabled", "err", err)
+		return nil
+	}
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(upowerPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+	); err != nil {
+		logger.Info("cannot follow UPower, power-aware throttling disabled", "err", err)
+		conn.Close()
+		return nil
+	}
+	ch := make(chan *dbus.Signal, 8)
+	conn.Signal(ch)
+
+	v, err := conn.Object(upowerBusName, upowerPath).GetProperty(upowerInterface + ".OnBattery")
+	if err != nil {
+		logger.Info("UPower unavailable, power-aware throttling disabled", "err", err)
+		conn.Close()
+		return nil
+	}
+	if onBattery, ok := v.Value().(bool); ok {
+		s.setOnBattery(onBattery)
+	}
+
+	// The channel is closed when the connection is.
+	go func() {
+		for sig := range ch {
+			if onBattery, ok := upowerOnBattery(sig); ok {
+				s.setOnBattery(onBattery)
+			}
+		}
+	}()
+	return func() { conn.Close() }
+}
+
+// upowerOnBattery returns the OnBattery value of a UPower PropertiesChanged
+// signal, and whether the signal has one.
+func upowerOnBattery(sig *dbus.Signal) (onBattery, ok bool) {
+	if sig.Path != upowerPath || sig.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(sig.Body) < 2 {
+		return false, false
+	}
+	if iface, _ := sig.Body[0].(string); iface != upowerInterface {
+		return false, false
+	}
+	changed, _ := sig.Body[1].(map[string]dbus.Variant)
+	v, found := changed["OnBattery"]
+	if !found {
+		return false, false
+	}
+	onBattery, ok = v.Value().(bool)
+	return onBattery, ok
+}
+
+// setOnBattery records whether the machine runs on battery power and has the
+// snapshot loop pick up the snapshot interval for it.
+func (s *Server) setOnBattery(onBattery bool) {
+	s.mu.Lock()
+	if s.onBattery == onBattery {
+		s.mu.Unlock()
+		return
+	}
+	s.onBattery = onBattery
+	interval := s.snapshotInterval()
+	s.mu.Unlock()
+
+	if onBattery {
+		s.logger.Info("on battery power", "snapshot_interval", interval)
+	} else {
+		s.logger.Info("on AC power", "snapshot_interval", interval)
+	}
+	s.wakeSnapshotLoop()
+}
+
+// deferWork reports whether the server's background work, its scheduled
+// tasks and archiving, waits for AC power: while on battery, with
+// battery_defer set.
+func (s *Server) deferWork() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.onBattery && s.cfg.BatteryDefer
+}
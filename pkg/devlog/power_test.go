@@ -0,0 +1,115 @@
+package devlog
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestUpowerOnBattery(t *testing.T) {
+	changed := func(iface string, props map[string]dbus.Variant) *dbus.Signal {
+		return &dbus.Signal{
+			Path: upowerPath,
+			Name: "org.freedesktop.DBus.Properties.PropertiesChanged",
+			Body: []interface{}{iface, props, []string{}},
+		}
+	}
+	tests := []struct {
+		name          string
+		sig           *dbus.Signal
+		onBattery, ok bool
+	}{
+		{"on battery", changed(upowerInterface, map[string]dbus.Variant{"OnBattery": dbus.MakeVariant(true)}), true, true},
+		{"on AC", changed(upowerInterface, map[string]dbus.Variant{"OnBattery": dbus.MakeVariant(false), "LidIsClosed": dbus.MakeVariant(true)}), false, true},
+		{"other property", changed(upowerInterface, map[string]dbus.Variant{"LidIsClosed": dbus.MakeVariant(true)}), false, false},
+		{"other interface", changed("org.freedesktop.UPower.Device", map[string]dbus.Variant{"OnBattery": dbus.MakeVariant(true)}), false, false},
+		{"other signal", &dbus.Signal{Path: upowerPath, Name: "org.freedesktop.UPower.DeviceAdded"}, false, false},
+	}
+	for _, tt := range tests {
+		onBattery, ok := upowerOnBattery(tt.sig)
+		if onBattery != tt.onBattery || ok != tt.ok {
+			t.Errorf("%s: upowerOnBattery = %v, %v; want %v, %v", tt.name, onBattery, ok, tt.onBattery, tt.ok)
+		}
+	}
+}
+
+func TestServerOnBattery(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	calls := filepath.Join(tmp, "calls")
+	exe := filepath.Join(tmp, "devlog")
+	os.WriteFile(exe, []byte("#!/bin/sh\necho \"$@\" >> "+calls+"\n"), 0o755)
+	orig := scheduleExecutable
+	scheduleExecutable = func() (string, error) { return exe, nil }
+	defer func() { scheduleExecutable = orig }()
+
+	s := newServer(Config{
+		SnapshotInterval:        300,
+		BatterySnapshotInterval: 900,
+		BatteryDefer:            true,
+		Schedule:                map[string]ScheduleTask{"nightly": {At: "23:30", Run: "gen <date>"}},
+	})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer s.cancel()
+
+	interval := func() int {
+		var ping PingData
+		json.Unmarshal(s.handlePing().Data, &ping)
+		return ping.SnapshotInterval
+	}
+	s.setOnBattery(true)
+	select {
+	case <-s.reloadCh:
+	default:
+		t.Error("going on battery should wake the snapshot loop")
+	}
+	if got := interval(); got != 900 {
+		t.Errorf("snapshot interval on battery = %d, want 900", got)
+	}
+
+	// A due task waits for AC power, and is still due when it comes.
+	now := time.Date(2024, 1, 16, 23, 31, 0, 0, time.Local)
+	last := time.Date(2024, 1, 15, 23, 30, 0, 0, time.Local)
+	marks := scheduleMarks{LastRun: map[string]time.Time{"nightly": last}}
+	warned := map[string]bool{}
+	s.runSchedule(&marks, now, warned)
+	if _, err := os.Stat(calls); !os.IsNotExist(err) || !marks.LastRun["nightly"].Equal(last) {
+		t.Error("a scheduled task should be deferred while on battery")
+	}
+
+	s.setOnBattery(false)
+	if got := interval(); got != 300 {
+		t.Errorf("snapshot interval on AC = %d, want 300", got)
+	}
+	s.runSchedule(&marks, now.Add(time.Minute), warned)
+	if data, _ := os.ReadFile(calls); string(data) != "gen 2024-01-16\n" {
+		t.Errorf("runs on AC = %q, want the deferred one", data)
+	}
+}
+
+func TestSnapshotWait(t *testing.T) {
+	tests := []struct {
+		from, to, sinceLast time.Duration
+		now                 bool
+		wait                time.Duration
+	}{
+		// Back on AC with the AC interval already passed: snapshot now.
+		{15 * time.Minute, 5 * time.Minute, 8 * time.Minute, true, 5 * time.Minute},
+		// Not yet passed: the next snapshot is due 5 minutes after the last.
+		{15 * time.Minute, 5 * time.Minute, 2 * time.Minute, false, 3 * time.Minute},
+		// On battery: the longer interval starts afresh.
+		{5 * time.Minute, 15 * time.Minute, 4 * time.Minute, false, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		now, wait := snapshotWait(tt.from, tt.to, tt.sinceLast)
+		if now != tt.now || wait != tt.wait {
+			t.Errorf("snapshotWait(%v, %v, %v) = %v, %v; want %v, %v", tt.from, tt.to, tt.sinceLast, now, wait, tt.now, tt.wait)
+		}
+	}
+}
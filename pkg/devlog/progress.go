@@ -0,0 +1,98 @@
+package devlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// genProgress reports generation progress and records how long each stage
+// of each project took. A nil *genProgress is valid and does nothing, so
+// callers can pass nil when verbose output is off.
+type genProgress struct {
+	w       io.Writer
+	start   time.Time
+	timings []stageTiming
+	stream  bool // copy the summaries to w as gen_cmd writes them
+}
+
+type stageTiming struct {
+	project  string
+	stage    string
+	duration time.Duration
+}
+
+func newGenProgress(w io.Writer) *genProgress {
+	return &genProgress{w: w, start: time.Now()}
+}
+
+func (p *genProgress) printf(format string, args ...any) {
+	if p == nil {
+		return
+	}
+	fmt.Fprintf(p.w, format+"\n", args...)
+}
+
+// streamTo returns the writer summaries are streamed to, or nil if they are
+// not.
+func (p *genProgress) streamTo() io.Writer {
+	if p == nil || !p.stream {
+		return nil
+	}
+	return p.w
+}
+
+func (p *genProgress) record(project, stage string, d time.Duration) {
+	if p == nil {
+		return
+	}
+	p.timings = append(p.timings, stageTiming{project: project, stage: stage, duration: d})
+}
+
+// printSummary writes the per-project, per-stage timing breakdown.
+func (p *genProgress) printSummary() {
+	if p == nil || len(p.timings) == 0 {
+		return
+	}
+
+	var order []string
+	byProject := make(map[string][]stageTiming)
+	for _, st := range p.timings {
+		if _, ok := byProject[st.project]; !ok {
+			order = append(order, st.project)
+		}
+		byProject[st.project] = append(byProject[st.project], st)
+	}
+
+	fmt.Fprintln(p.w, "Timing:")
+	for _, proj := range order {
+		var total time.Duration
+		var parts []string
+		for _, st := range byProject[proj] {
+			total += st.duration
+			parts = append(parts, fmt.Sprintf("%s %s", st.stage, formatDuration(st.duration)))
+		}
+		fmt.Fprintf(p.w, "  %s: %s (%s)\n", proj, formatDuration(total), strings.Join(parts, ", "))
+	}
+	fmt.Fprintf(p.w, "  total: %s\n", formatDuration(time.Since(p.start)))
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// formatSize formats a byte count for display, e.g. "512B", "34KB", "1.2MB".
+func formatSize(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%dB", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%dKB", n/1024)
+	default:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1024*1024))
+	}
+}
@@ -0,0 +1,56 @@
+package devlog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{512, "512B"},
+		{34 * 1024, "34KB"},
+		{1536 * 1024, "1.5MB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestGenProgressNil(t *testing.T) {
+	var p *genProgress
+	// None of these should panic
+	p.printf("hello %s", "world")
+	p.record("proj", "summarize", time.Second)
+	p.printSummary()
+}
+
+func TestGenProgressSummary(t *testing.T) {
+	var buf bytes.Buffer
+	p := newGenProgress(&buf)
+
+	p.printf("summarizing project %d/%d: %s", 1, 2, "alpha")
+	p.record("alpha", "compress git", 2*time.Second)
+	p.record("alpha", "summarize", 3*time.Second)
+	p.record("beta", "summarize", time.Second)
+	p.printSummary()
+
+	s := buf.String()
+	for _, want := range []string{
+		"summarizing project 1/2: alpha\n",
+		"Timing:",
+		"alpha: 5s (compress git 2s, summarize 3s)",
+		"beta: 1s (summarize 1s)",
+		"total:",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, s)
+		}
+	}
+}
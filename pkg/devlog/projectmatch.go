@@ -0,0 +1,160 @@
+package devlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// knownProjects returns the project names devlog knows of: the watched
+// entries and the projects of config.toml, sorted.
+func knownProjects(cfg Config, state State) []string {
+	var names []string
+	for _, w := range state.Watched {
+		if !containsString(names, w.Name) {
+			names = append(names, w.Name)
+		}
+	}
+	for name := range cfg.Projects {
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// editDistance returns the number of single-character insertions,
+// deletions, substitutions, and transpositions of adjacent characters that
+// turn a into b (the optimal string alignment distance), so that a swapped
+// pair like "devlgo" is one typo away from "devlog".
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// closestProject returns the name of known that name is most likely a typo
+// of, ignoring case: one within an edit distance of a third of its length
+// (at least 1). It reports false if name is known or nothing is close.
+func closestProject(name string, known []string) (string, bool) {
+	if containsString(known, name) {
+		return "", false
+	}
+	limit := max(len([]rune(name))/3, 1)
+	best, bestDist := "", limit+1
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best, best != ""
+}
+
+// takeBareProjectFlag removes a -p given without a value from args: the last
+// argument, or one followed by another flag. Such a -p asks for the project
+// to be picked from a list (see pickProject).
+func takeBareProjectFlag(args []string) ([]string, bool) {
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		if a != "-p" && a != "--p" {
+			continue
+		}
+		if i+1 == len(args) || (strings.HasPrefix(args[i+1], "-") && args[i+1] != "-") {
+			return append(args[:i:i], args[i+1:]...), true
+		}
+	}
+	return args, false
+}
+
+// pickProject lists names, numbered, on out and returns the one chosen on
+// in, by number or name.
+func pickProject(names []string, in io.Reader, out io.Writer) (string, error) {
+	if len(names) == 0 {
+		return "", fmt.Errorf("no watched projects to pick from; give -p a project name")
+	}
+	fmt.Fprintln(out, "Projects:")
+	for i, name := range names {
+		fmt.Fprintf(out, "  %3d  %s\n", i+1, name)
+	}
+	fmt.Fprint(out, "Project (number or name): ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.TrimPrefix(strings.TrimSpace(answer), "#")
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(names) {
+			return "", fmt.Errorf("no project numbered %d", n)
+		}
+		return names[n-1], nil
+	}
+	if answer == "" {
+		return "", fmt.Errorf("no project chosen")
+	}
+	return answer, nil
+}
+
+// pickNoteProject returns the project chosen from the known projects for a
+// bare -p, exiting if there is no terminal to ask on or nothing is chosen.
+func pickNoteProject(cfg Config) string {
+	if stdinIsPiped() {
+		fmt.Fprintln(os.Stderr, "Error: -p needs a project name when stdin is not a terminal")
+		os.Exit(1)
+	}
+	state, _ := loadState()
+	name, err := pickProject(knownProjects(cfg, state), os.Stdin, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return name
+}
+
+// correctProjectTypo returns the project a note given -p name is for. If name
+// is not a known project but close to one, it asks whether that one was
+// meant when stdin is a terminal, and warns otherwise.
+func correctProjectTypo(name string, known []string, in io.Reader, out io.Writer, interactive bool) string {
+	suggestion, ok := closestProject(name, known)
+	if !ok {
+		return name
+	}
+	if !interactive {
+		fmt.Fprintf(out, "Warning: unknown project %q; did you mean %q?\n", name, suggestion)
+		return name
+	}
+	fmt.Fprintf(out, "Unknown project %q. Did you mean %q? [Y/n] ", name, suggestion)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil {
+		// No answer, e.g. stdin is /dev/null as for notes from KRunner.
+		fmt.Fprintln(out)
+		return name
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return suggestion
+	}
+	return name
+}
@@ -0,0 +1,112 @@
+package devlog
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"devlog", "devlog", 0},
+		{"devlgo", "devlog", 1},
+		{"devog", "devlog", 1},
+		{"devlogs", "devlog", 1},
+		{"api", "web", 3},
+		{"", "api", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestClosestProject(t *testing.T) {
+	known := []string{"api", "devlog", "frontend"}
+	for _, tc := range []struct {
+		name, want string
+		ok         bool
+	}{
+		{"devlgo", "devlog", true},
+		{"DevLog", "devlog", true},
+		{"frnotend", "frontend", true},
+		{"apj", "api", true},
+		{"devlog", "", false},
+		{"backend", "", false},
+		{"xy", "", false},
+	} {
+		got, ok := closestProject(tc.name, known)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("closestProject(%q) = %q, %v; want %q, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestTakeBareProjectFlag(t *testing.T) {
+	for _, tc := range []struct {
+		args, want []string
+		pick       bool
+	}{
+		{[]string{"-m", "hi", "-p"}, []string{"-m", "hi"}, true},
+		{[]string{"-p", "-m", "hi"}, []string{"-m", "hi"}, true},
+		{[]string{"-p", "api", "-m", "hi"}, []string{"-p", "api", "-m", "hi"}, false},
+		{[]string{"-p=api"}, []string{"-p=api"}, false},
+		{[]string{"-m", "-p"}, []string{"-m"}, true},
+	} {
+		args := append([]string(nil), tc.args...)
+		got, pick := takeBareProjectFlag(args)
+		if !reflect.DeepEqual(got, tc.want) || pick != tc.pick {
+			t.Errorf("takeBareProjectFlag(%q) = %q, %v; want %q, %v", tc.args, got, pick, tc.want, tc.pick)
+		}
+	}
+}
+
+func TestPickProject(t *testing.T) {
+	names := []string{"api", "devlog"}
+	var out bytes.Buffer
+	if got, err := pickProject(names, strings.NewReader("2\n"), &out); err != nil || got != "devlog" {
+		t.Errorf("pick by number = %q, %v; want devlog", got, err)
+	}
+	if !strings.Contains(out.String(), "  2  devlog") {
+		t.Errorf("list not shown: %q", out.String())
+	}
+	if got, err := pickProject(names, strings.NewReader("#web\n"), &out); err != nil || got != "web" {
+		t.Errorf("pick by name = %q, %v; want web", got, err)
+	}
+	for _, answer := range []string{"3\n", "\n"} {
+		if _, err := pickProject(names, strings.NewReader(answer), &out); err == nil {
+			t.Errorf("pickProject(%q) should fail", answer)
+		}
+	}
+	if _, err := pickProject(nil, strings.NewReader("api\n"), &out); err == nil {
+		t.Error("pickProject with no projects should fail")
+	}
+}
+
+func TestCorrectProjectTypo(t *testing.T) {
+	known := []string{"api", "devlog"}
+	var out bytes.Buffer
+	if got := correctProjectTypo("devlgo", known, strings.NewReader("\n"), &out, true); got != "devlog" {
+		t.Errorf("accepted suggestion = %q, want devlog", got)
+	}
+	if !strings.Contains(out.String(), `Did you mean "devlog"?`) {
+		t.Errorf("no suggestion shown: %q", out.String())
+	}
+	if got := correctProjectTypo("devlgo", known, strings.NewReader("n\n"), &out, true); got != "devlgo" {
+		t.Errorf("declined suggestion = %q, want devlgo", got)
+	}
+	out.Reset()
+	if got := correctProjectTypo("devlgo", known, strings.NewReader(""), &out, false); got != "devlgo" || !strings.Contains(out.String(), "Warning") {
+		t.Errorf("non-interactive = %q with %q, want the name kept and a warning", got, out.String())
+	}
+	if got := correctProjectTypo("devlgo", known, strings.NewReader(""), &out, true); got != "devlgo" {
+		t.Errorf("no answer = %q, want devlgo", got)
+	}
+	if got := correctProjectTypo("newproj", known, strings.NewReader(""), &out, true); got != "newproj" {
+		t.Errorf("unrelated name = %q, want it kept", got)
+	}
+}
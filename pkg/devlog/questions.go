@@ -0,0 +1,185 @@
+package devlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// openQuestionsFile is the name under which a project's unresolved open
+// questions are given to the summarizer.
+const openQuestionsFile = "open-questions.md"
+
+// openQuestion is an open question or piece of unfinished work reported by
+// a summary, carried over into later summaries until one resolves it.
+type openQuestion struct {
+	ID       int    `json:"id"`
+	Text     string `json:"text"`
+	Opened   string `json:"opened"`             // date of the summary that reported it
+	Resolved string `json:"resolved,omitempty"` // date of the summary that resolved it
+}
+
+// questionLog is the open-question history of one project.
+type questionLog struct {
+	NextID    int            `json:"next_id"`
+	Questions []openQuestion `json:"questions"`
+}
+
+// resolveQuestionsPath returns the file holding project's open questions.
+func resolveQuestionsPath(project string) string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "questions", project+".json")
+}
+
+func loadQuestions(project string) (questionLog, error) {
+	var log questionLog
+	data, err := os.ReadFile(resolveQuestionsPath(project))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return log, nil
+		}
+		return log, fmt.Errorf("reading open questions: %w", err)
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		return log, fmt.Errorf("parsing open questions: %w", err)
+	}
+	return log, nil
+}
+
+func saveQuestions(project string, log questionLog) error {
+	path := resolveQuestionsPath(project)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating questions dir: %w", err)
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling open questions: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing open questions: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing open questions: %w", err)
+	}
+	return nil
+}
+
+// openBefore returns the questions opened before date that were still open
+// at the start of it.
+func (l questionLog) openBefore(date string) []openQuestion {
+	var open []openQuestion
+	for _, q := range l.Questions {
+		if q.Opened < date && (q.Resolved == "" || q.Resolved >= date) {
+			open = append(open, q)
+		}
+	}
+	return open
+}
+
+// apply records the summary of date: the questions it opened and the IDs of
+// those it resolved. Anything recorded for date before is replaced first,
+// so that regenerating a summary does not duplicate its questions.
+func (l *questionLog) apply(date string, opened []string, resolved []int) {
+	kept := l.Questions[:0]
+	for _, q := range l.Questions {
+		if q.Opened == date {
+			continue
+		}
+		if q.Resolved == date {
+			q.Resolved = ""
+		}
+		kept = append(kept, q)
+	}
+	l.Questions = kept
+
+	for i, q := range l.Questions {
+		if q.Opened < date && q.Resolved == "" && containsInt(resolved, q.ID) {
+			l.Questions[i].Resolved = date
+		}
+	}
+	for _, text := range opened {
+		l.NextID++
+		l.Questions = append(l.Questions, openQuestion{ID: l.NextID, Text: text, Opened: date})
+	}
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// formatOpenQuestions lists questions for the summary prompt, e.g.
+// "- [q3] Decide on the cache eviction policy (open since 2024-01-10)".
+func formatOpenQuestions(questions []openQuestion) string {
+	if len(questions) == 0 {
+		return "None.\n"
+	}
+	var b strings.Builder
+	for _, q := range questions {
+		fmt.Fprintf(&b, "- [q%d] %s (open since %s)\n", q.ID, q.Text, q.Opened)
+	}
+	return b.String()
+}
+
+var (
+	openQuestionsLineRe = regexp.MustCompile(`(?i)^\**open questions:?\**:?\s*$`)
+	resolvedLineRe      = regexp.MustCompile(`(?i)^\**resolved:?\**:?\s*(.*)$`)
+	questionIDRe        = regexp.MustCompile(`(?i)\bq(\d+)\b`)
+)
+
+// parseQuestionTrailer reads the "Open questions:" list and the final
+// "Resolved:" line that the summary prompt asks for when open questions are
+// tracked. It returns the summary without the "Resolved:" line, the text of
+// each open question, and the resolved question IDs.
+func parseQuestionTrailer(summary string) (string, []string, []int) {
+	lines := strings.Split(strings.TrimRight(summary, "\n"), "\n")
+
+	var resolved []int
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if m := resolvedLineRe.FindStringSubmatch(line); m != nil {
+			for _, id := range questionIDRe.FindAllStringSubmatch(m[1], -1) {
+				if n, err := strconv.Atoi(id[1]); err == nil {
+					resolved = append(resolved, n)
+				}
+			}
+			lines = lines[:i]
+		}
+		break
+	}
+
+	var opened []string
+	for i := len(lines) - 1; i >= 0; i-- {
+		if !openQuestionsLineRe.MatchString(strings.TrimSpace(lines[i])) {
+			continue
+		}
+		for _, line := range lines[i+1:] {
+			text, ok := strings.CutPrefix(strings.TrimSpace(line), "- ")
+			if !ok {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				break
+			}
+			text = strings.TrimSpace(text)
+			if text != "" && !strings.EqualFold(strings.TrimRight(text, "."), "none") {
+				opened = append(opened, text)
+			}
+		}
+		break
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), opened, resolved
+}
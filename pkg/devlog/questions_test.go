@@ -0,0 +1,98 @@
+package devlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseQuestionTrailer(t *testing.T) {
+	summary := "Worked on the parser.\n\nNext steps:\n- Finish the lexer\n\n" +
+		"Open questions:\n- Should comments be tokens?\n- Error recovery is unfinished\n\nResolved: q2, Q5\n"
+	clean, opened, resolved := parseQuestionTrailer(summary)
+	if strings.Contains(clean, "Resolved") || !strings.HasSuffix(clean, "- Error recovery is unfinished") {
+		t.Errorf("unexpected summary %q", clean)
+	}
+	if len(opened) != 2 || opened[0] != "Should comments be tokens?" {
+		t.Errorf("unexpected opened %q", opened)
+	}
+	if fmt.Sprint(resolved) != "[2 5]" {
+		t.Errorf("unexpected resolved %v", resolved)
+	}
+
+	_, opened, resolved = parseQuestionTrailer("Text.\n\n**Open questions:**\n- None\n\nResolved: none")
+	if len(opened) != 0 || len(resolved) != 0 {
+		t.Errorf("expected nothing, got %q and %v", opened, resolved)
+	}
+	if clean, _, _ := parseQuestionTrailer("Just a summary."); clean != "Just a summary." {
+		t.Errorf("unexpected summary without a trailer %q", clean)
+	}
+}
+
+func TestQuestionLogApply(t *testing.T) {
+	var log questionLog
+	log.apply("2024-01-10", []string{"cache policy?", "retry logic unfinished"}, nil)
+	log.apply("2024-01-11", []string{"flaky test"}, []int{1})
+
+	open := log.openBefore("2024-01-12")
+	if len(open) != 2 || open[0].ID != 2 || open[1].ID != 3 {
+		t.Errorf("unexpected open questions %+v", open)
+	}
+	// Question 1 was still open at the start of the day that resolved it.
+	if open := log.openBefore("2024-01-11"); len(open) != 2 || open[0].ID != 1 {
+		t.Errorf("unexpected open questions on 2024-01-11 %+v", open)
+	}
+
+	// Regenerating a day replaces what it recorded.
+	log.apply("2024-01-11", []string{"flaky test"}, []int{2})
+	if len(log.Questions) != 3 || log.Questions[0].Resolved != "" || log.Questions[1].Resolved != "2024-01-11" {
+		t.Errorf("unexpected questions after regenerating %+v", log.Questions)
+	}
+	if got := formatOpenQuestions(log.openBefore("2024-01-12")); got != "- [q1] cache policy? (open since 2024-01-10)\n- [q4] flaky test (open since 2024-01-11)\n" {
+		t.Errorf("unexpected formatting %q", got)
+	}
+}
+
+func TestRunGenTracksQuestions(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", filepath.Join(tmp, "log"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+
+	// The summarizer saves its prompt and resolves q1 if it was offered.
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"), []byte("#!/bin/sh\ncat > "+filepath.Join(tmp, "prompt")+"\n"+
+		"echo 'Did work.'\necho\necho 'Open questions:'\necho '- Why is CI slow?'\necho\n"+
+		"if grep -q '\\[q1\\]' "+filepath.Join(tmp, "prompt")+"; then echo 'Resolved: q1'; else echo 'Resolved: none'; fi\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mysummarizer", TrackQuestions: true}
+	for _, date := range []string{"2024-01-15", "2024-01-16"} {
+		os.MkdirAll(filepath.Join(rawDir, date), 0o755)
+		os.WriteFile(filepath.Join(rawDir, date, "notes.md"), []byte("### At 10:00 #myproject\nwork\n"), 0o644)
+		if _, err := runGen(context.Background(), cfg, State{}, date, nil); err != nil {
+			t.Fatalf("runGen %s: %v", date, err)
+		}
+	}
+
+	prompt, _ := os.ReadFile(filepath.Join(tmp, "prompt"))
+	if !strings.Contains(string(prompt), "--- open-questions.md ---\n- [q1] Why is CI slow? (open since 2024-01-15)") {
+		t.Errorf("second prompt should carry over q1:\n%s", prompt)
+	}
+	content, _ := os.ReadFile(filepath.Join(tmp, "log", "2024-01-16.md"))
+	if strings.Contains(string(content), "Resolved:") || !strings.Contains(string(content), "Open questions:") {
+		t.Errorf("unexpected summary:\n%s", content)
+	}
+	log, err := loadQuestions("myproject")
+	if err != nil {
+		t.Fatalf("loadQuestions: %v", err)
+	}
+	if len(log.Questions) != 2 || log.Questions[0].Resolved != "2024-01-16" || log.Questions[1].Resolved != "" {
+		t.Errorf("unexpected question log %+v", log.Questions)
+	}
+}
@@ -0,0 +1,226 @@
+package devlog
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rawArchiveGrace is how long a raw date dir must go unchanged before it is
+// archived, so that a command reading restored data does not lose it.
+const rawArchiveGrace = time.Hour
+
+// rawArchiveDir returns the directory old raw date dirs are archived in.
+func rawArchiveDir(cfg Config) string {
+	return filepath.Join(resolveRawDir(cfg), "archive")
+}
+
+// rawArchivePath returns the archive of the raw date dir of date.
+func rawArchivePath(cfg Config, date string) string {
+	return filepath.Join(rawArchiveDir(cfg), date+".tar.zst")
+}
+
+// archivedRawDates returns the dates whose raw date dir is archived, in
+// order.
+func archivedRawDates(cfg Config) []string {
+	entries, _ := os.ReadDir(rawArchiveDir(cfg))
+	var dates []string
+	for _, e := range entries {
+		if date, ok := strings.CutSuffix(e.Name(), ".tar.zst"); ok && isValidDate(date) {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// rawDatesToArchive returns the dates before the last days days whose raw
+// date dir exists and has not changed within rawArchiveGrace of now.
+func rawDatesToArchive(cfg Config, days int, now time.Time) []string {
+	cutoff := now.AddDate(0, 0, -days).Format("2006-01-02")
+	entries, _ := os.ReadDir(resolveRawDir(cfg))
+	var dates []string
+	for _, e := range entries {
+		if !e.IsDir() || !isValidDate(e.Name()) || e.Name() >= cutoff {
+			continue
+		}
+		if info, err := e.Info(); err != nil || now.Sub(info.ModTime()) < rawArchiveGrace {
+			continue
+		}
+		dates = append(dates, e.Name())
+	}
+	return dates
+}
+
+// archiveOldRaw archives the raw date dirs older than days days (see
+// rawDatesToArchive) and returns the dates archived. It stops between dates
+// when ctx is done.
+func archiveOldRaw(ctx context.Context, cfg Config, days int, now time.Time) ([]string, error) {
+	var archived []string
+	for _, date := range rawDatesToArchive(cfg, days, now) {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := archiveRawDate(cfg, date); err != nil {
+			return archived, err
+		}
+		archived = append(archived, date)
+	}
+	return archived, nil
+}
+
+// archiveRawDate moves the raw date dir of date into its archive. If the
+// date was archived before, the archive's files missing from the dir are
+// restored first, so that nothing is lost; if nothing in the dir is newer
+// than the archive, the dir is only removed.
+func archiveRawDate(cfg Config, date string) error {
+	dayDir := filepath.Join(resolveRawDir(cfg), date)
+	path := rawArchivePath(cfg, date)
+	var archived time.Time
+	if info, err := os.Stat(path); err == nil {
+		if err := extractRawArchive(cfg, date); err != nil {
+			return err
+		}
+		archived = info.ModTime()
+	}
+
+	var files []string
+	changed := archived.IsZero()
+	err := filepath.WalkDir(dayDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		files = append(files, p)
+		if info, err := d.Info(); err == nil && info.ModTime().After(archived) {
+			changed = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", dayDir, err)
+	}
+	if changed {
+		if err := writeRawArchive(cfg, date, files); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(dayDir); err != nil {
+		return fmt.Errorf("removing %s: %w", dayDir, err)
+	}
+	return nil
+}
+
+// writeRawArchive writes files, which are in the raw date dir of date, to
+// its archive. The archive is replaced only once it is complete.
+func writeRawArchive(cfg Config, date string, files []string) error {
+	path := rawArchivePath(cfg, date)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	defer f.Close()
+
+	w, err := zstdWriter(f)
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	rawDir := resolveRawDir(cfg)
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		rel, _ := filepath.Rel(rawDir, file)
+		if err = addTarFile(tw, archiveEntry{Name: filepath.ToSlash(rel), Path: file}); err != nil {
+			break
+		}
+	}
+	if closeErr := tw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing archive of %s: %w", date, err)
+	}
+	return nil
+}
+
+// extractRawArchive extracts the archive of the raw date dir of date into
+// the raw dir, keeping the files that already exist and the modification
+// times of the others.
+func extractRawArchive(cfg Config, date string) error {
+	f, err := os.Open(rawArchivePath(cfg, date))
+	if err != nil {
+		return fmt.Errorf("opening archive of %s: %w", date, err)
+	}
+	defer f.Close()
+	r, err := zstdReader(f)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	rawDir := resolveRawDir(cfg)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive of %s: %w", date, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		rel := filepath.FromSlash(hdr.Name)
+		if !strings.HasPrefix(hdr.Name, date+"/") || !filepath.IsLocal(rel) {
+			return fmt.Errorf("unexpected entry %q in archive of %s", hdr.Name, date)
+		}
+		target := filepath.Join(rawDir, rel)
+		if fileExists(target) {
+			continue
+		}
+		if err := extractTarFile(tr, target, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+}
+
+// restoreRawDates extracts the archived raw data of those of dates that
+// have an archive, so that it can be read like any other. The restored
+// dirs are archived again by the next archiving pass after
+// rawArchiveGrace.
+func restoreRawDates(cfg Config, dates []string) error {
+	archived := make(map[string]bool)
+	for _, d := range archivedRawDates(cfg) {
+		archived[d] = true
+	}
+	for _, date := range dates {
+		if !archived[date] {
+			continue
+		}
+		if err := extractRawArchive(cfg, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package devlog
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// useZstd makes a zstd command available, standing in cat for it if zstd
+// is not installed.
+func useZstd(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("zstd"); err == nil {
+		return
+	}
+	mockBin := t.TempDir()
+	os.WriteFile(filepath.Join(mockBin, "zstd"), []byte("#!/bin/sh\nexec cat\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+}
+
+// writeRawDay writes files into the raw date dir of date and backdates
+// them and the dir to modTime.
+func writeRawDay(t *testing.T, cfg Config, date string, files map[string]string, modTime time.Time) {
+	t.Helper()
+	dayDir := filepath.Join(resolveRawDir(cfg), date)
+	for name, content := range files {
+		path := filepath.Join(dayDir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+	os.Chtimes(dayDir, modTime, modTime)
+}
+
+func TestRawDatesToArchive(t *testing.T) {
+	t.Setenv("DEVLOG_RAW_DIR", t.TempDir())
+	cfg := Config{}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	old := now.Add(-48 * time.Hour)
+	writeRawDay(t, cfg, "2024-01-15", map[string]string{"notes.md": "x"}, old)
+	writeRawDay(t, cfg, "2024-02-25", map[string]string{"notes.md": "x"}, old)
+	// Changed just now, e.g. restored for devlog gen.
+	writeRawDay(t, cfg, "2024-01-16", map[string]string{"notes.md": "x"}, now.Add(-time.Minute))
+	os.MkdirAll(rawArchiveDir(cfg), 0o755)
+
+	got := rawDatesToArchive(cfg, 30, now)
+	if want := []string{"2024-01-15"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rawDatesToArchive = %v, want %v", got, want)
+	}
+}
+
+func TestArchiveAndRestoreRaw(t *testing.T) {
+	useZstd(t)
+	t.Setenv("DEVLOG_RAW_DIR", t.TempDir())
+	cfg := Config{}
+	date := "2024-01-15"
+	modTime := time.Date(2024, 1, 15, 18, 0, 0, 0, time.Local)
+	writeRawDay(t, cfg, date, map[string]string{
+		"notes.md":              "### At 10:00 #api\nfixed it\n",
+		"git-api.log":           "=== SNAPSHOT 10:00 ===\ndiff\n",
+		"attachments/trace.txt": "trace",
+	}, modTime)
+
+	archived, err := archiveOldRaw(context.Background(), cfg, 30, time.Now())
+	if err != nil {
+		t.Fatalf("archiveOldRaw: %v", err)
+	}
+	if !reflect.DeepEqual(archived, []string{date}) {
+		t.Fatalf("archived %v, want [%s]", archived, date)
+	}
+	if _, err := os.Stat(filepath.Join(resolveRawDir(cfg), date)); !os.IsNotExist(err) {
+		t.Error("raw date dir should be removed once archived")
+	}
+	if dates := rawDataDates(cfg); !reflect.DeepEqual(dates, []string{date}) {
+		t.Errorf("rawDataDates = %v, want archived date", dates)
+	}
+
+	if err := restoreRawDates(cfg, []string{date, "2024-01-16"}); err != nil {
+		t.Fatalf("restoreRawDates: %v", err)
+	}
+	if got := discoverProjects(cfg, date); !reflect.DeepEqual(got, []string{"api"}) {
+		t.Errorf("projects of restored date = %v, want [api]", got)
+	}
+	info, err := os.Stat(filepath.Join(resolveRawDir(cfg), date, "attachments", "trace.txt"))
+	if err != nil {
+		t.Fatalf("restored file: %v", err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("restored mod time = %v, want %v", info.ModTime(), modTime)
+	}
+
+	// Archiving an unchanged restored dir only removes it.
+	archivePath := rawArchivePath(cfg, date)
+	before, _ := os.Stat(archivePath)
+	if err := archiveRawDate(cfg, date); err != nil {
+		t.Fatalf("archiveRawDate: %v", err)
+	}
+	if after, _ := os.Stat(archivePath); !after.ModTime().Equal(before.ModTime()) {
+		t.Error("unchanged date should not be archived again")
+	}
+
+	// A file added to an archived date is merged into the archive.
+	os.MkdirAll(filepath.Join(resolveRawDir(cfg), date), 0o755)
+	os.WriteFile(filepath.Join(resolveRawDir(cfg), date, "comp-git-api.md"), []byte("compressed"), 0o644)
+	if err := archiveRawDate(cfg, date); err != nil {
+		t.Fatalf("archiveRawDate: %v", err)
+	}
+	if err := restoreRawDates(cfg, []string{date}); err != nil {
+		t.Fatalf("restoreRawDates: %v", err)
+	}
+	for _, name := range []string{"notes.md", "git-api.log", "comp-git-api.md"} {
+		if !fileExists(filepath.Join(resolveRawDir(cfg), date, name)) {
+			t.Errorf("%s missing after re-archiving", name)
+		}
+	}
+}
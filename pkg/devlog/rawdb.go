@@ -0,0 +1,367 @@
+package devlog
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// The raw data database keeps the snapshots, notes entries, and terminal
+// logs of the raw data files in one SQLite database, for indexed queries and
+// a retention of its own (raw_db_keep_days). The files stay what devlog
+// reads and writes; the database is loaded from them by `devlog db import`
+// and, with raw_store = "sqlite", kept up to date by the server after each
+// snapshot cycle. Entries outlive their files, so raw data archived or
+// deleted from the raw dir can still be queried.
+//
+// The SQLite driver is only linked into builds with the sqlite tag (see
+// rawdb_sqlite.go), so that it does not grow every devlog binary.
+
+// errNoRawDB is returned by openRawDB in builds without the sqlite tag.
+var errNoRawDB = errors.New("devlog was built without SQLite support; rebuild it with -tags sqlite")
+
+// rawDBSchema creates the tables of the raw data database. files records the
+// size and mtime of each imported file, so that unchanged files are skipped,
+// and the last bytes of a git or terminal log before size, so that a log
+// that was only appended to can have just its new data imported; entries
+// holds their units (see readRawUnits), with the projects of a notes entry
+// joined by commas.
+const rawDBSchema = `
+CREATE TABLE IF NOT EXISTS files (
+	path  TEXT PRIMARY KEY,
+	size  INTEGER NOT NULL,
+	mtime INTEGER NOT NULL,
+	tail  BLOB
+);
+CREATE TABLE IF NOT EXISTS entries (
+	id       INTEGER PRIMARY KEY,
+	source   TEXT NOT NULL,
+	date     TEXT NOT NULL,
+	time     TEXT NOT NULL,
+	kind     TEXT NOT NULL,
+	projects TEXT NOT NULL,
+	content  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entries_by_date ON entries (date, kind);
+CREATE INDEX IF NOT EXISTS entries_by_source ON entries (source);
+`
+
+// validateRawStore checks a raw_store setting.
+func validateRawStore(s string) error {
+	switch s {
+	case "", "files":
+		return nil
+	case "sqlite":
+		if !rawDBSupported {
+			return fmt.Errorf("invalid raw_store %q: %w", s, errNoRawDB)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid raw_store %q (want files or sqlite)", s)
+	}
+}
+
+func resolveRawDBPath() string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "raw.db")
+}
+
+// openRawDB opens the raw data database, creating it if needed.
+func openRawDB() (*sql.DB, error) {
+	if !rawDBSupported {
+		return nil, errNoRawDB
+	}
+	path := resolveRawDBPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating state dir: %w", err)
+	}
+	// The server and commands may use the database at once.
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("opening raw data database: %w", err)
+	}
+	if _, err := db.Exec(rawDBSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening raw data database: %w", err)
+	}
+	return db, nil
+}
+
+// rawDBTailSize is how many of the last bytes of an imported git or terminal
+// log the files table keeps.
+const rawDBTailSize = 256
+
+// importRawDB loads the raw data files on dates into db, skipping those
+// unchanged since they were imported. A git or terminal log that has grown
+// and still has, before its imported size, the bytes recorded then has only
+// its new data imported, so the server's import after each snapshot cycle
+// does not re-read the day; any other changed file replaces its entries.
+// Each file is imported in one transaction, so a query never sees a file half
+// imported. It returns the number of files imported.
+func importRawDB(cfg Config, db *sql.DB, dates []string) (int, error) {
+	n := 0
+	for _, date := range dates {
+		for _, src := range rawSources(cfg, date) {
+			info, err := os.Stat(src.Path)
+			if err != nil {
+				continue
+			}
+			var size, mtime int64
+			var tail []byte
+			err = db.QueryRow(`SELECT size, mtime, tail FROM files WHERE path = ?`, src.Path).Scan(&size, &mtime, &tail)
+			if err == nil && size == info.Size() && mtime == info.ModTime().UnixNano() {
+				continue
+			}
+			if err != nil && err != sql.ErrNoRows {
+				return n, fmt.Errorf("reading raw data database: %w", err)
+			}
+			if err := importRawSource(cfg, db, src, info, size, tail); err != nil {
+				return n, fmt.Errorf("importing %s: %w", src.Path, err)
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// importRawSource imports src, last imported at size bytes ending with tail
+// (0 and nil if never).
+func importRawSource(cfg Config, db *sql.DB, src rawSource, info os.FileInfo, size int64, tail []byte) error {
+	if src.Kind == "notes" {
+		// Notes entries can be inserted anywhere (backdated) or retagged,
+		// so the file is always imported whole.
+		units, err := readRawUnits(cfg.noteHeadings(), src)
+		if err != nil {
+			return err
+		}
+		return importRawFile(db, src, info, units)
+	}
+	if size > info.Size() {
+		size, tail = 0, nil
+	}
+	appended, err := importRawLog(db, src, info, size, tail)
+	if err == nil && !appended {
+		_, err = importRawLog(db, src, info, 0, nil)
+	}
+	return err
+}
+
+// importRawFile replaces the entries of src with units.
+func importRawFile(db *sql.DB, src rawSource, info os.FileInfo, units []rawUnit) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM entries WHERE source = ?`, src.Path); err != nil {
+		return err
+	}
+	if err := insertRawUnits(tx, src, units); err != nil {
+		return err
+	}
+	if err := saveRawFile(tx, src, info, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// importRawLog imports the git or terminal log src from byte from up to its
+// size in info; from 0 replaces its entries. Otherwise the bytes before from
+// must be tail, as recorded when the log was imported up to from: it reports
+// false, leaving db unchanged, if they are not, if a git log's new data does
+// not start with a snapshot, or if the log's earlier entries were pruned.
+func importRawLog(db *sql.DB, src rawSource, info os.FileInfo, from int64, tail []byte) (bool, error) {
+	f, err := os.Open(src.Path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	// Read no further than info's size, which is recorded as imported, even
+	// if the log has grown since.
+	start := from - int64(len(tail))
+	data := make([]byte, info.Size()-start)
+	if _, err := f.ReadAt(data, start); err != nil {
+		return false, err
+	}
+	if !bytes.Equal(data[:len(tail)], tail) {
+		return false, nil
+	}
+	added := string(data[len(tail):])
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	var last string
+	if from == 0 {
+		if _, err := tx.Exec(`DELETE FROM entries WHERE source = ?`, src.Path); err != nil {
+			return false, err
+		}
+	} else {
+		err := tx.QueryRow(`SELECT content FROM entries WHERE source = ? ORDER BY id DESC LIMIT 1`, src.Path).Scan(&last)
+		if err == sql.ErrNoRows {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+	switch {
+	case src.Kind == "git":
+		if from > 0 && !strings.HasPrefix(added, snapshotHeaderPrefix) {
+			return false, nil
+		}
+		// A delta snapshot stands for the one before it with its changes, so
+		// the new snapshots are expanded after the last imported one.
+		units := gitRawUnits(src.Project, expandSnapshotDeltas(last+added))
+		if from > 0 {
+			units = units[1:]
+		}
+		if err := insertRawUnits(tx, src, units); err != nil {
+			return false, err
+		}
+	case from == 0:
+		if err := insertRawUnits(tx, src, []rawUnit{{rawIndexUnit: rawIndexUnit{Projects: []string{src.Project}}, Text: added}}); err != nil {
+			return false, err
+		}
+	default:
+		if _, err := tx.Exec(`UPDATE entries SET content = content || ? WHERE source = ?`, added, src.Path); err != nil {
+			return false, err
+		}
+	}
+	if err := saveRawFile(tx, src, info, data[max(0, len(data)-rawDBTailSize):]); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// insertRawUnits adds units as entries of src.
+func insertRawUnits(tx *sql.Tx, src rawSource, units []rawUnit) error {
+	for _, u := range units {
+		if _, err := tx.Exec(`INSERT INTO entries (source, date, time, kind, projects, content) VALUES (?, ?, ?, ?, ?, ?)`,
+			src.Path, src.Date, u.Time, src.Kind, strings.Join(u.Projects, ","), u.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveRawFile records src as imported at info's size and mtime, with tail,
+// the last bytes of a git or terminal log before that size.
+func saveRawFile(tx *sql.Tx, src rawSource, info os.FileInfo, tail []byte) error {
+	_, err := tx.Exec(`INSERT OR REPLACE INTO files (path, size, mtime, tail) VALUES (?, ?, ?, ?)`,
+		src.Path, info.Size(), info.ModTime().UnixNano(), tail)
+	return err
+}
+
+// pruneRawDB deletes the entries dated more than days days before now, and
+// returns how many it deleted. Their files are imported again only if they
+// change.
+func pruneRawDB(db *sql.DB, days int, now time.Time) (int64, error) {
+	cutoff := now.AddDate(0, 0, -days).Format("2006-01-02")
+	res, err := db.Exec(`DELETE FROM entries WHERE date < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("pruning raw data database: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// rawDBEntry is an entry of the raw data database.
+type rawDBEntry struct {
+	Date, Time, Kind string
+	Projects         []string
+	Content          string
+}
+
+// rawDBQuery selects entries of the raw data database: those on dates, of
+// kind and project if set ("general" for notes without a project), and
+// containing text, case insensitively, if set.
+type rawDBQuery struct {
+	Dates         []string
+	Kind, Project string
+	Text          string
+}
+
+// queryRawDB returns the entries matching q, oldest first.
+func queryRawDB(db *sql.DB, q rawDBQuery) ([]rawDBEntry, error) {
+	where := []string{"1"}
+	var args []interface{}
+	if len(q.Dates) > 0 {
+		where = append(where, "date BETWEEN ? AND ?")
+		args = append(args, q.Dates[0], q.Dates[len(q.Dates)-1])
+	}
+	if q.Kind != "" {
+		where = append(where, "kind = ?")
+		args = append(args, q.Kind)
+	}
+	if q.Project != "" {
+		where = append(where, "instr(',' || projects || ',', ',' || ? || ',') > 0")
+		args = append(args, q.Project)
+	}
+	if q.Text != "" {
+		where = append(where, "instr(lower(content), lower(?)) > 0")
+		args = append(args, q.Text)
+	}
+	rows, err := db.Query(`SELECT date, time, kind, projects, content FROM entries WHERE `+
+		strings.Join(where, " AND ")+` ORDER BY date, time, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying raw data database: %w", err)
+	}
+	defer rows.Close()
+	var entries []rawDBEntry
+	for rows.Next() {
+		var e rawDBEntry
+		var projects string
+		if err := rows.Scan(&e.Date, &e.Time, &e.Kind, &projects, &e.Content); err != nil {
+			return nil, fmt.Errorf("querying raw data database: %w", err)
+		}
+		if projects != "" {
+			e.Projects = strings.Split(projects, ",")
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// printRawDBEntries prints entries as a table with the first line of each,
+// or in full with full set.
+func printRawDBEntries(w io.Writer, entries []rawDBEntry, full bool) {
+	if full {
+		for _, e := range entries {
+			fmt.Fprintf(w, "=== %s %s %s %s ===\n%s\n\n", e.Date, e.Time, e.Kind, strings.Join(e.Projects, ","), strings.TrimSpace(e.Content))
+		}
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tTIME\tKIND\tPROJECTS\tFIRST LINE")
+	for _, e := range entries {
+		line, _, _ := strings.Cut(strings.TrimSpace(e.Content), "\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Date, orDefault(e.Time, "-"), e.Kind, strings.Join(e.Projects, ","), line)
+	}
+	tw.Flush()
+}
+
+// syncRawDB imports the raw data of date into the raw data database and
+// applies raw_db_keep_days, for the server with raw_store = "sqlite".
+func syncRawDB(cfg Config, date string, now time.Time) error {
+	db, err := openRawDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := importRawDB(cfg, db, []string{date}); err != nil {
+		return err
+	}
+	if cfg.RawDBKeepDays > 0 {
+		if _, err := pruneRawDB(db, cfg.RawDBKeepDays, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
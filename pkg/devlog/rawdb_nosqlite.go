@@ -0,0 +1,6 @@
+//go:build !sqlite
+
+package devlog
+
+// rawDBSupported reports whether the raw data database is available.
+const rawDBSupported = false
@@ -0,0 +1,17 @@
+//go:build !sqlite
+
+package devlog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRawDBWithoutSQLite(t *testing.T) {
+	if _, err := openRawDB(); !errors.Is(err, errNoRawDB) {
+		t.Errorf("openRawDB = %v, want errNoRawDB", err)
+	}
+	if err := validateRawStore("sqlite"); !errors.Is(err, errNoRawDB) {
+		t.Errorf("validateRawStore(sqlite) = %v, want errNoRawDB", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build sqlite
+
+package devlog
+
+import _ "modernc.org/sqlite"
+
+// rawDBSupported reports whether the raw data database is available.
+const rawDBSupported = true
@@ -0,0 +1,150 @@
+//go:build sqlite
+
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRawDB(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+	cfg := Config{}
+	for _, d := range []string{"2024-01-15", "2024-01-16"} {
+		os.MkdirAll(filepath.Join(rawDir, d), 0o755)
+	}
+	gitLog := filepath.Join(rawDir, "2024-01-15", "git-api.log")
+	os.WriteFile(gitLog, []byte("=== SNAPSHOT 09:00 ===\n+retry()\n\n=== SNAPSHOT 14:30 ===\n+// read: ECONNRESET\n\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "notes.md"), []byte("### At 10:00 #web #api\nUpstream keeps resetting\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "notes.md"), []byte("### At 08:15\nStill seeing econnreset\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "git-web.log"), []byte("=== SNAPSHOT 09:00 ===\n+timeout: 5s\n\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "term-web-1.log"), []byte("$ curl upstream\n"), 0o644)
+
+	db, err := openRawDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dates := []string{"2024-01-15", "2024-01-16"}
+	if n, err := importRawDB(cfg, db, dates); err != nil || n != 5 {
+		t.Fatalf("importRawDB = %d, %v; want 5 files", n, err)
+	}
+	if n, _ := importRawDB(cfg, db, dates); n != 0 {
+		t.Errorf("importing unchanged files again imported %d", n)
+	}
+
+	query := func(q rawDBQuery) string {
+		entries, err := queryRawDB(db, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Date+" "+e.Time+" "+e.Kind+" "+strings.Join(e.Projects, ","))
+		}
+		return strings.Join(got, "; ")
+	}
+	if got, want := query(rawDBQuery{}), "2024-01-15 09:00 git api; 2024-01-15 10:00 notes web,api; 2024-01-15 14:30 git api; 2024-01-16  term web; 2024-01-16 08:15 notes general; 2024-01-16 09:00 git web"; got != want {
+		t.Errorf("all entries = %q, want %q", got, want)
+	}
+	if got, want := query(rawDBQuery{Project: "api"}), "2024-01-15 09:00 git api; 2024-01-15 10:00 notes web,api; 2024-01-15 14:30 git api"; got != want {
+		t.Errorf("project api = %q, want %q", got, want)
+	}
+	if got, want := query(rawDBQuery{Text: "econnreset", Kind: "notes"}), "2024-01-16 08:15 notes general"; got != want {
+		t.Errorf("text in notes = %q, want %q", got, want)
+	}
+	if got, want := query(rawDBQuery{Dates: []string{"2024-01-16"}, Kind: "term"}), "2024-01-16  term web"; got != want {
+		t.Errorf("dates = %q, want %q", got, want)
+	}
+
+	// A changed file replaces its entries.
+	os.WriteFile(gitLog, []byte("=== SNAPSHOT 09:00 ===\n+retry()\n\n"), 0o644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(gitLog, later, later)
+	if n, _ := importRawDB(cfg, db, dates); n != 1 {
+		t.Errorf("importing a changed file imported %d files", n)
+	}
+	if got, want := query(rawDBQuery{Kind: "git", Project: "api"}), "2024-01-15 09:00 git api"; got != want {
+		t.Errorf("after the change = %q, want %q", got, want)
+	}
+
+	// Entries outlive their files, until they are pruned.
+	os.RemoveAll(filepath.Join(rawDir, "2024-01-15"))
+	importRawDB(cfg, db, dates)
+	if got := query(rawDBQuery{Dates: []string{"2024-01-15"}}); got == "" {
+		t.Error("entries of deleted files should be kept")
+	}
+	n, err := pruneRawDB(db, 1, time.Date(2024, 1, 17, 12, 0, 0, 0, time.Local))
+	if err != nil || n != 2 {
+		t.Errorf("pruneRawDB = %d, %v; want the 2 entries of 2024-01-15", n, err)
+	}
+	if got, want := query(rawDBQuery{}), "2024-01-16  term web; 2024-01-16 08:15 notes general; 2024-01-16 09:00 git web"; got != want {
+		t.Errorf("after pruning = %q, want %q", got, want)
+	}
+}
+
+func TestRawDBAppend(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+	cfg := Config{}
+	dateDir := filepath.Join(rawDir, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	gitLog := filepath.Join(dateDir, "git-api.log")
+	termLog := filepath.Join(dateDir, "term-api-1.log")
+	os.WriteFile(gitLog, []byte("=== SNAPSHOT 09:00 ===\ndiff --git a/a.go b/a.go\n+one\n\n"), 0o644)
+	os.WriteFile(termLog, []byte("$ make\n"), 0o644)
+
+	db, err := openRawDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dates := []string{"2024-01-15"}
+	importRawDB(cfg, db, dates)
+	firstID := func() int64 {
+		var id int64
+		db.QueryRow(`SELECT id FROM entries WHERE kind = 'git' AND time = '09:00'`).Scan(&id)
+		return id
+	}
+	id := firstID()
+	appendTo := func(path, data string) {
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		f.WriteString(data)
+		f.Close()
+	}
+
+	// Appended snapshots are added, a delta expanded from the last one,
+	// without importing the earlier ones again.
+	appendTo(gitLog, "=== SNAPSHOT 10:00 ===\n"+deltaMarker+"\ndiff --git a/b.go b/b.go\n+two\n\n")
+	appendTo(termLog, "ok\n")
+	if n, err := importRawDB(cfg, db, dates); err != nil || n != 2 {
+		t.Fatalf("importRawDB = %d, %v; want 2 files", n, err)
+	}
+	if firstID() != id {
+		t.Error("the earlier snapshot should not be imported again")
+	}
+	entries, _ := queryRawDB(db, rawDBQuery{Kind: "git"})
+	if len(entries) != 2 || !strings.Contains(entries[1].Content, "+one") || !strings.Contains(entries[1].Content, "+two") {
+		t.Errorf("git entries = %+v; want the delta expanded", entries)
+	}
+	entries, _ = queryRawDB(db, rawDBQuery{Kind: "term"})
+	if len(entries) != 1 || entries[0].Content != "$ make\nok\n" {
+		t.Errorf("term entries = %+v; want the appended output added", entries)
+	}
+
+	// A log that was rewritten, not appended to, is imported whole.
+	os.WriteFile(gitLog, []byte("=== SNAPSHOT 09:30 ===\ndiff --git a/c.go b/c.go\n+three and more\n\n=== SNAPSHOT 11:00 ===\n+four\n\n"), 0o644)
+	importRawDB(cfg, db, dates)
+	entries, _ = queryRawDB(db, rawDBQuery{Kind: "git"})
+	if len(entries) != 2 || entries[0].Time != "09:30" || entries[1].Time != "11:00" {
+		t.Errorf("git entries after a rewrite = %+v", entries)
+	}
+}
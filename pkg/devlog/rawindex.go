@@ -0,0 +1,376 @@
+package devlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// rawIndex is a full-text index of the raw data files: for each file, the
+// searchable units in it (snapshots, notes entries, terminal logs) and the
+// words each contains. Files are indexed again when their size or
+// modification time changes, so the index can be kept up to date cheaply.
+type rawIndex struct {
+	Files map[string]rawIndexFile `json:"files"` // path -> index of the file
+}
+
+// rawIndexFile is the index of one raw data file.
+type rawIndexFile struct {
+	Date    string           `json:"date"`
+	Kind    string           `json:"kind"`              // "git", "notes", or "term"
+	Project string           `json:"project,omitempty"` // "" for the notes file
+	Size    int64            `json:"size"`
+	ModTime time.Time        `json:"mtime"`
+	Units   []rawIndexUnit   `json:"units"`
+	Words   map[string][]int `json:"words"` // word -> indexes into Units
+}
+
+// rawIndexUnit is a searchable unit of a raw data file.
+type rawIndexUnit struct {
+	Time     string   `json:"time,omitempty"` // HH:MM, if known
+	Projects []string `json:"projects,omitempty"`
+}
+
+// rawSource is a raw data file to index.
+type rawSource struct {
+	Path, Date, Kind, Project string // Project is "" for the notes file
+}
+
+// rawUnit is a searchable unit of a raw data file and its text.
+type rawUnit struct {
+	rawIndexUnit
+	Text string
+}
+
+// rawMatch is a unit of raw data that contains a searched string.
+type rawMatch struct {
+	Date     string
+	Time     string
+	Kind     string
+	Projects []string
+	Line     string // the first line containing the string
+}
+
+// resolveRawIndexPath returns the file holding the raw data index.
+func resolveRawIndexPath() string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "rawindex.json")
+}
+
+func loadRawIndex() (rawIndex, error) {
+	idx := rawIndex{Files: make(map[string]rawIndexFile)}
+	data, err := os.ReadFile(resolveRawIndexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return idx, fmt.Errorf("reading raw data index: %w", err)
+	}
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return idx, fmt.Errorf("parsing raw data index: %w", err)
+	}
+	if idx.Files == nil {
+		idx.Files = make(map[string]rawIndexFile)
+	}
+	return idx, nil
+}
+
+func saveRawIndex(idx rawIndex) error {
+	path := resolveRawIndexPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating index dir: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("marshaling raw data index: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing raw data index: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing raw data index: %w", err)
+	}
+	return nil
+}
+
+// rawSources returns the git snapshot logs, notes file, and terminal logs
+// on date.
+func rawSources(cfg Config, date string) []rawSource {
+	var sources []rawSource
+	seen := make(map[string]bool)
+	for _, p := range discoverProjects(cfg, date) {
+		if path := resolveGitPath(cfg, date, p); fileExists(path) {
+			sources = append(sources, rawSource{Path: path, Date: date, Kind: "git", Project: p})
+		}
+		matches, _ := filepath.Glob(resolveTermGlob(cfg, date, p))
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				sources = append(sources, rawSource{Path: path, Date: date, Kind: "term", Project: p})
+			}
+		}
+	}
+	for _, path := range notesPaths(cfg, date) {
+		sources = append(sources, rawSource{Path: path, Date: date, Kind: "notes"})
+	}
+	return sources
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readRawUnits reads the searchable units of a raw data file: each snapshot
+// of a git log, each entry of a notes file, or a whole terminal log.
+func readRawUnits(headings *noteHeadingFormat, src rawSource) ([]rawUnit, error) {
+	var units []rawUnit
+	switch src.Kind {
+	case "git":
+		content, err := readGitLog(src.Path)
+		if err != nil {
+			return nil, err
+		}
+		units = gitRawUnits(src.Project, content)
+	case "notes":
+		data, err := os.ReadFile(src.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range splitNoteEntries(headings, string(data)) {
+			heading, _, _ := strings.Cut(entry, "\n")
+			tags, _ := noteHeadingTags(headings, heading)
+			if len(tags) == 0 {
+				tags = []string{"general"}
+			}
+			units = append(units, rawUnit{rawIndexUnit: rawIndexUnit{Time: noteEntryClock(headings, entry), Projects: tags}, Text: entry})
+		}
+	default:
+		data, err := os.ReadFile(src.Path)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, rawUnit{rawIndexUnit: rawIndexUnit{Projects: []string{src.Project}}, Text: string(data)})
+	}
+	return units, nil
+}
+
+// gitRawUnits returns the snapshots of the git log content of project as
+// units.
+func gitRawUnits(project, content string) []rawUnit {
+	var units []rawUnit
+	for _, chunk := range splitSnapshots(content) {
+		u := rawUnit{rawIndexUnit: rawIndexUnit{Projects: []string{project}}, Text: chunk}
+		if m := snapshotTimeRe.FindStringSubmatch(chunk); m != nil {
+			u.Time = m[1]
+		}
+		units = append(units, u)
+	}
+	return units
+}
+
+// rawWords returns the distinct words of text, lowercased: runs of letters,
+// digits, and underscores at least two characters long.
+func rawWords(text string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(w) >= 2 && !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// indexRawFile returns the index of src.
+func indexRawFile(headings *noteHeadingFormat, src rawSource, info os.FileInfo) (rawIndexFile, error) {
+	units, err := readRawUnits(headings, src)
+	if err != nil {
+		return rawIndexFile{}, err
+	}
+	f := rawIndexFile{
+		Date:    src.Date,
+		Kind:    src.Kind,
+		Project: src.Project,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Words:   make(map[string][]int),
+	}
+	for i, u := range units {
+		f.Units = append(f.Units, u.rawIndexUnit)
+		for _, w := range rawWords(u.Text) {
+			f.Words[w] = append(f.Words[w], i)
+		}
+	}
+	return f, nil
+}
+
+// updateRawIndex indexes the raw data files on dates that are new or have
+// changed since they were indexed, and drops files that no longer exist. It
+// returns the number of files indexed or dropped.
+func updateRawIndex(cfg Config, idx *rawIndex, dates []string) (int, error) {
+	n := 0
+	for path := range idx.Files {
+		if !fileExists(path) {
+			delete(idx.Files, path)
+			n++
+		}
+	}
+	for _, date := range dates {
+		for _, src := range rawSources(cfg, date) {
+			info, err := os.Stat(src.Path)
+			if err != nil {
+				continue
+			}
+			if f, ok := idx.Files[src.Path]; ok && f.Size == info.Size() && f.ModTime.Equal(info.ModTime()) {
+				continue
+			}
+			f, err := indexRawFile(cfg.noteHeadings(), src, info)
+			if err != nil {
+				return n, err
+			}
+			idx.Files[src.Path] = f
+			n++
+		}
+	}
+	return n, nil
+}
+
+// refreshRawIndex brings the saved raw data index up to date for dates.
+func refreshRawIndex(cfg Config, dates []string) (rawIndex, error) {
+	idx, err := loadRawIndex()
+	if err != nil {
+		return idx, err
+	}
+	n, err := updateRawIndex(cfg, &idx, dates)
+	if n > 0 || err != nil {
+		if saveErr := saveRawIndex(idx); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	return idx, err
+}
+
+// searchRaw returns the units of the indexed raw data on dates that contain
+// query, case insensitively, oldest first. The index narrows the search to
+// the units containing every word of query; those are then read to check
+// for the exact string. With project set, only that project's units are
+// searched ("general" for notes without a project).
+func searchRaw(headings *noteHeadingFormat, idx rawIndex, query string, dates []string, project string) ([]rawMatch, error) {
+	words := rawWords(query)
+	if len(words) == 0 {
+		return nil, errors.New("query has no words to search for")
+	}
+	inRange := make(map[string]bool)
+	for _, d := range dates {
+		inRange[d] = true
+	}
+	needle := strings.ToLower(strings.TrimSpace(query))
+
+	var matches []rawMatch
+	for path, f := range idx.Files {
+		if !inRange[f.Date] {
+			continue
+		}
+		candidates := f.Words[words[0]]
+		for _, w := range words[1:] {
+			candidates = intersectSorted(candidates, f.Words[w])
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		units, err := readRawUnits(headings, rawSource{Path: path, Date: f.Date, Kind: f.Kind, Project: f.Project})
+		if err != nil {
+			continue
+		}
+		for _, i := range candidates {
+			if i >= len(units) || project != "" && !containsString(units[i].Projects, project) {
+				continue
+			}
+			if line, ok := lineContaining(units[i].Text, needle); ok {
+				matches = append(matches, rawMatch{
+					Date:     f.Date,
+					Time:     units[i].Time,
+					Kind:     f.Kind,
+					Projects: units[i].Projects,
+					Line:     line,
+				})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		// Terminal logs have no time; put them after the rest of the day.
+		if (a.Time == "") != (b.Time == "") {
+			return b.Time == ""
+		}
+		if a.Time != b.Time {
+			return a.Time < b.Time
+		}
+		return strings.Join(a.Projects, ",") < strings.Join(b.Projects, ",")
+	})
+	return matches, nil
+}
+
+func intersectSorted(a, b []int) []int {
+	var out []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// lineContaining returns the first line of text that contains needle, which
+// must be lowercase, case insensitively.
+func lineContaining(text, needle string) (string, bool) {
+	if !strings.Contains(strings.ToLower(text), needle) {
+		return "", false
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.Contains(strings.ToLower(line), needle) {
+			return strings.TrimSpace(line), true
+		}
+	}
+	// The string spans lines.
+	return strings.TrimSpace(strings.SplitN(text, "\n", 2)[0]), true
+}
+
+// printRawMatches writes one entry per match: where it is, followed by the
+// line containing the string. The first is where the string first appeared.
+func printRawMatches(w io.Writer, matches []rawMatch) {
+	for i, m := range matches {
+		where := m.Date
+		if m.Time != "" {
+			where += " " + m.Time
+		}
+		kind := map[string]string{"git": "snapshot", "notes": "note", "term": "terminal log"}[m.Kind]
+		first := ""
+		if i == 0 {
+			first = "  (first)"
+		}
+		fmt.Fprintf(w, "%s  %s %s%s\n    %s\n", where, strings.Join(m.Projects, ", "), kind, first, shorten(m.Line, searchSnippetLen))
+	}
+}
@@ -0,0 +1,94 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRawWords(t *testing.T) {
+	got := strings.Join(rawWords("read tcp: ECONNRESET (read_timeout=5s) a read"), " ")
+	if want := "read tcp econnreset read_timeout 5s"; got != want {
+		t.Errorf("rawWords = %q, want %q", got, want)
+	}
+}
+
+func TestSearchRaw(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+	cfg := Config{}
+	for _, d := range []string{"2024-01-15", "2024-01-16"} {
+		os.MkdirAll(filepath.Join(rawDir, d), 0o755)
+	}
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "git-api.log"),
+		[]byte("=== SNAPSHOT 09:00 ===\n+retry()\n\n=== SNAPSHOT 14:30 ===\n+// read: ECONNRESET from upstream\n\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "notes.md"),
+		[]byte("### At 10:00 #web\nupstream keeps resetting\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "notes.md"),
+		[]byte("### At 08:15\nStill seeing econnreset from upstream\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "git-web.log"),
+		[]byte("=== SNAPSHOT 09:00 ===\n+timeout: 5s\n\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "term-web-1.log"),
+		[]byte("$ curl upstream\ncurl: (56) Recv failure: ECONNRESET from upstream\n"), 0o644)
+
+	dates := []string{"2024-01-15", "2024-01-16"}
+	idx, err := refreshRawIndex(cfg, dates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Files) != 5 {
+		t.Fatalf("expected 5 indexed files, got %d", len(idx.Files))
+	}
+
+	matches, err := searchRaw(nil, idx, "ECONNRESET from upstream", dates, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range matches {
+		got = append(got, m.Date+" "+m.Time+" "+m.Kind+" "+strings.Join(m.Projects, ","))
+	}
+	want := []string{"2024-01-15 14:30 git api", "2024-01-16 08:15 notes general", "2024-01-16  term web"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("matches:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if matches[0].Line != "+// read: ECONNRESET from upstream" {
+		t.Errorf("line = %q", matches[0].Line)
+	}
+
+	// Every word matches the 10:00 note, but not the string.
+	if matches, _ := searchRaw(nil, idx, "upstream resetting keeps", dates, ""); len(matches) != 0 {
+		t.Errorf("expected the exact string to be required, got %+v", matches)
+	}
+	if matches, _ := searchRaw(nil, idx, "econnreset", dates, "web"); len(matches) != 1 || matches[0].Kind != "term" {
+		t.Errorf("expected only the web terminal log, got %+v", matches)
+	}
+	if matches, _ := searchRaw(nil, idx, "econnreset", []string{"2024-01-16"}, ""); len(matches) != 2 {
+		t.Errorf("expected the date range to apply, got %+v", matches)
+	}
+
+	var b bytes.Buffer
+	printRawMatches(&b, matches[:1])
+	if want := "2024-01-15 14:30  api snapshot  (first)\n    +// read: ECONNRESET from upstream\n"; b.String() != want {
+		t.Errorf("printRawMatches = %q, want %q", b.String(), want)
+	}
+
+	// Only changed files are indexed again, and removed ones are dropped.
+	later := time.Now().Add(time.Minute)
+	notes := filepath.Join(rawDir, "2024-01-16", "notes.md")
+	os.WriteFile(notes, []byte("### At 08:15\nfixed\n"), 0o644)
+	os.Chtimes(notes, later, later)
+	os.Remove(filepath.Join(rawDir, "2024-01-16", "term-web-1.log"))
+	n, err := updateRawIndex(cfg, &idx, dates)
+	if err != nil || n != 2 {
+		t.Errorf("updateRawIndex = %d, %v; want 2 files", n, err)
+	}
+	if matches, _ := searchRaw(nil, idx, "econnreset", dates, ""); len(matches) != 1 {
+		t.Errorf("expected 1 match after the update, got %+v", matches)
+	}
+}
@@ -0,0 +1,149 @@
+package devlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redaction replaces a name in a summary with a neutral placeholder.
+type redaction struct {
+	From, To string
+}
+
+// resolveRedactedPath returns the path of the redacted summary for date.
+func resolveRedactedPath(cfg Config, date string) string {
+	return filepath.Join(resolveLogDir(cfg), "redacted", date+".md")
+}
+
+// redactions returns the replacements for the names in summary that would
+// identify the work: the redact_map settings, then each other project with
+// a section in summary as "Project A", "Project B", ..., and each other
+// client of a watched project as "a client". Longer names come first, so
+// that a name is replaced before any name it contains.
+func redactions(cfg Config, state State, summary string) []redaction {
+	var reds []redaction
+	mapped := make(map[string]bool)
+	for from, to := range cfg.RedactMap {
+		reds = append(reds, redaction{From: from, To: to})
+		mapped[strings.ToLower(from)] = true
+	}
+	n := 0
+	for _, s := range splitSummary(summary) {
+		if s.Project == "general" || mapped[strings.ToLower(s.Project)] {
+			continue
+		}
+		reds = append(reds, redaction{From: s.Project, To: "Project " + projectLetter(n)})
+		mapped[strings.ToLower(s.Project)] = true
+		n++
+	}
+	for _, w := range state.Watched {
+		if w.Client != "" && !mapped[strings.ToLower(w.Client)] {
+			reds = append(reds, redaction{From: w.Client, To: "a client"})
+			mapped[strings.ToLower(w.Client)] = true
+		}
+	}
+	sort.SliceStable(reds, func(i, j int) bool {
+		if len(reds[i].From) != len(reds[j].From) {
+			return len(reds[i].From) > len(reds[j].From)
+		}
+		return reds[i].From < reds[j].From
+	})
+	return reds
+}
+
+// projectLetter returns the n'th placeholder letter: "A" to "Z", then "AA",
+// "AB", and so on.
+func projectLetter(n int) string {
+	if n < 26 {
+		return string(rune('A' + n))
+	}
+	return projectLetter(n/26-1) + projectLetter(n%26)
+}
+
+var (
+	wordStartRe = regexp.MustCompile(`^\w`)
+	wordEndRe   = regexp.MustCompile(`\w$`)
+)
+
+// redactionRe matches name as a whole word, ignoring case.
+func redactionRe(name string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(name)
+	if wordStartRe.MatchString(name) {
+		pattern = `\b` + pattern
+	}
+	if wordEndRe.MatchString(name) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+// applyRedactions replaces each name of reds in text with its placeholder.
+func applyRedactions(text string, reds []redaction) string {
+	for _, r := range reds {
+		if r.From == "" {
+			continue
+		}
+		text = redactionRe(r.From).ReplaceAllLiteralString(text, r.To)
+	}
+	return text
+}
+
+// assembleRedactPrompt builds the prompt that rewrites a summary, whose
+// known names are already replaced, so that it can be shared.
+func assembleRedactPrompt(date, summary string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Below is a summary of a software engineer's work on %s. It is to be\n"+
+		"shared publicly or with a mentor, so it must not reveal what the work was\n"+
+		"for. Known project and client names have already been replaced with\n"+
+		"placeholders such as \"Project A\" and \"a client\".\n\n"+
+		"--- summary.md ---\n%s\n", date, strings.TrimSpace(summary))
+	b.WriteString(`
+Task: Rewrite the summary with everything else that identifies the work
+removed.
+
+Guidelines:
+- Remove file paths, file names, repository names, package and module names,
+  hostnames, URLs, ticket numbers, and the names of clients, companies,
+  products, internal systems, and people other than the author. Replace them
+  with generic descriptions (e.g. "the config loader", "a colleague").
+- Keep the placeholders exactly as they are.
+- Keep the technical substance: the problems, approaches, dead ends, and
+  what was learned, described in general terms.
+- Keep the Markdown structure, including the "# " and "## " headings.
+
+Output only the rewritten summary, nothing else.
+`)
+	return b.String()
+}
+
+// runRedact writes a shareable version of the summary for date to
+// resolveRedactedPath and returns its path. The names redactions finds are
+// replaced before and after the gen_cmd pass, so that they do not appear in
+// the output even if the summarizer brings them back.
+func runRedact(cfg Config, state State, date string) (string, error) {
+	summary, err := readSummary(cfg, date)
+	if err != nil {
+		return "", err
+	}
+	reds := redactions(cfg, state, summary)
+	out, _, err := runGenCmd(context.Background(), cfg, assembleRedactPrompt(date, applyRedactions(summary, reds)), nil)
+	if err != nil {
+		return "", fmt.Errorf("redacting summary: %w", err)
+	}
+	out = applyRedactions(out, reds)
+
+	path := resolveRedactedPath(cfg, date)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("creating redacted dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(out+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("writing redacted summary: %w", err)
+	}
+	return path, nil
+}
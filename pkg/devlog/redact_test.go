@@ -0,0 +1,57 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyRedactions(t *testing.T) {
+	summary := "# 2024-01-15\n\n## alpha\n\nFixed alpha's parser for Acme Corp.\n\n## alpha-web\n\nStyled the alphabet page.\n\n## general\n\nRead.\n"
+	cfg := Config{RedactMap: map[string]string{"Acme Corp": "a client", "parser": "input handling"}}
+	state := State{Watched: []WatchEntry{{Name: "alpha", Client: "Initech"}}}
+
+	reds := redactions(cfg, state, summary)
+	got := applyRedactions(summary+"Billed to initech.\n", reds)
+	want := "# 2024-01-15\n\n## Project A\n\nFixed Project A's input handling for a client.\n\n## Project B\n\nStyled the alphabet page.\n\n## general\n\nRead.\nBilled to a client.\n"
+	if got != want {
+		t.Errorf("applyRedactions:\n%s\nwant:\n%s", got, want)
+	}
+
+	if projectLetter(0) != "A" || projectLetter(25) != "Z" || projectLetter(26) != "AA" {
+		t.Error("unexpected placeholder letters")
+	}
+}
+
+func TestRunRedact(t *testing.T) {
+	tmp := t.TempDir()
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+	os.MkdirAll(logDir, 0o755)
+	os.WriteFile(filepath.Join(logDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nFixed alpha/main.go.\n"), 0o644)
+
+	// The summarizer checks that the names were replaced in the prompt, and
+	// brings one back.
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"), []byte(
+		"#!/bin/sh\ngrep -q 'Fixed Project A/main.go' || exit 1\nprintf '# 2024-01-15\\n\\n## Project A\\n\\nFixed the entry point of alpha.\\n'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	path, err := runRedact(Config{GenCmd: "mysummarizer"}, State{}, "2024-01-15")
+	if err != nil {
+		t.Fatalf("runRedact: %v", err)
+	}
+	if path != filepath.Join(logDir, "redacted", "2024-01-15.md") {
+		t.Errorf("unexpected path %s", path)
+	}
+	data, _ := os.ReadFile(path)
+	if got := string(data); strings.Contains(got, "alpha") || !strings.Contains(got, "entry point of Project A.") {
+		t.Errorf("unexpected redacted summary:\n%s", got)
+	}
+
+	if _, err := runRedact(Config{GenCmd: "mysummarizer"}, State{}, "2024-01-16"); err == nil {
+		t.Error("expected an error without a summary")
+	}
+}
@@ -0,0 +1,249 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// projectRename is the set of changes needed to move a project's history to
+// a new name: raw and compressed files to rename, and notes files whose
+// headings are tagged with the old name.
+type projectRename struct {
+	oldName, newName string
+	moves            []fileMove
+	notesFiles       []string
+	dates            map[string]bool
+	headings         *noteHeadingFormat
+}
+
+type fileMove struct {
+	from, to string
+}
+
+// renameWatchEntry renames the watched entry named oldName. It reports
+// whether such an entry exists, and fails if newName is used by another.
+func renameWatchEntry(watched []WatchEntry, oldName, newName string) (bool, error) {
+	idx := -1
+	for i, w := range watched {
+		if w.Name == newName && newName != oldName {
+			return false, fmt.Errorf("name conflict: %q is already used by %s", newName, w.Path)
+		}
+		if w.Name == oldName {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return false, nil
+	}
+	watched[idx].Name = newName
+	return true, nil
+}
+
+// validateProjectName rejects names that cannot be used in hashtags or file
+// names, and the semantic note tags.
+func validateProjectName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n/#") {
+		return fmt.Errorf("invalid project name %q", name)
+	}
+	if containsString(semanticNoteTags, name) {
+		return fmt.Errorf("invalid project name %q: reserved for note tags", name)
+	}
+	return nil
+}
+
+// rawDataDates returns every date that has a raw data directory (or its
+// archive), a notes file or git snapshot (wherever their path templates put
+// them), or a summary, in order.
+func rawDataDates(cfg Config) []string {
+	seen := make(map[string]bool)
+	rawDir := resolveRawDir(cfg)
+	if entries, err := os.ReadDir(rawDir); err == nil {
+		for _, e := range entries {
+			if e.IsDir() && isValidDate(e.Name()) {
+				seen[e.Name()] = true
+			}
+		}
+	}
+	for _, date := range archivedRawDates(cfg) {
+		seen[date] = true
+	}
+	for _, tmpl := range []string{cfg.NotesPath, cfg.GitPath} {
+		if tmpl == "" {
+			continue
+		}
+		for _, date := range templateDates(tmpl, rawDir) {
+			seen[date] = true
+		}
+	}
+	if entries, err := os.ReadDir(resolveLogDir(cfg)); err == nil {
+		for _, e := range entries {
+			if date := strings.TrimSuffix(e.Name(), ".md"); isValidDate(date) {
+				seen[date] = true
+			}
+		}
+	}
+
+	dates := make([]string, 0, len(seen))
+	for d := range seen {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// planProjectRename finds the files to change for renaming oldName to
+// newName on every date with raw data. It fails without changing anything if
+// a destination file already exists.
+func planProjectRename(cfg Config, state State, oldName, newName string) (projectRename, error) {
+	r := projectRename{oldName: oldName, newName: newName, dates: make(map[string]bool), headings: cfg.noteHeadings()}
+	add := func(date, from, to string) {
+		if _, err := os.Stat(from); err == nil {
+			r.moves = append(r.moves, fileMove{from: from, to: to})
+			r.dates[date] = true
+		}
+	}
+
+	addComp := func(date, dataType string) {
+		from, to := compCachePath(cfg, dataType, oldName, date), compCachePath(cfg, dataType, newName, date)
+		add(date, from, to)
+		add(date, compFingerprintPath(from), compFingerprintPath(to))
+	}
+
+	for _, date := range rawDataDates(cfg) {
+		add(date, resolveGitPath(cfg, date, oldName), resolveGitPath(cfg, date, newName))
+		for _, dataType := range []string{"git", "term", "claude"} {
+			addComp(date, dataType)
+		}
+		collectTypes := make(map[string]bool)
+		for _, c := range cfg.CollectCmds {
+			add(date, resolveCollectPath(cfg, date, c.Name, oldName), resolveCollectPath(cfg, date, c.Name, newName))
+			if !collectTypes[c.DataType] {
+				collectTypes[c.DataType] = true
+				addComp(date, c.DataType)
+			}
+		}
+		for _, m := range termFilesForProject(cfg, state, date, oldName) {
+			add(date, m, renameTermFile(cfg, date, m, oldName, newName))
+		}
+
+		// A per-project notes file moves with its project, and its notes are
+		// retagged once moved.
+		oldNotes, newNotes := resolveProjectNotesPath(cfg, date, oldName), resolveProjectNotesPath(cfg, date, newName)
+		add(date, oldNotes, newNotes)
+		for _, notesPath := range notesPaths(cfg, date) {
+			if data, err := os.ReadFile(notesPath); err == nil {
+				if _, n := retagNotes(r.headings, string(data), oldName, newName); n > 0 {
+					if notesPath == oldNotes {
+						notesPath = newNotes
+					}
+					r.notesFiles = append(r.notesFiles, notesPath)
+					r.dates[date] = true
+				}
+			}
+		}
+	}
+
+	for _, mv := range r.moves {
+		if _, err := os.Stat(mv.to); err == nil {
+			return r, fmt.Errorf("%s already exists", mv.to)
+		}
+	}
+	return r, nil
+}
+
+// apply renames the planned files and rewrites note hashtags. It returns the
+// number of notes retagged.
+func (r projectRename) apply() (int, error) {
+	for _, mv := range r.moves {
+		if err := os.Rename(mv.from, mv.to); err != nil {
+			return 0, fmt.Errorf("renaming %s: %w", mv.from, err)
+		}
+	}
+
+	retagged := 0
+	for _, path := range r.notesFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return retagged, fmt.Errorf("reading notes: %w", err)
+		}
+		content, n := retagNotes(r.headings, string(data), r.oldName, r.newName)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return retagged, fmt.Errorf("writing notes: %w", err)
+		}
+		retagged += n
+	}
+	return retagged, nil
+}
+
+// termFilesForProject returns the terminal logs of project on date. The
+// term_path glob for "foo" also matches logs of a project named "foo-bar",
+// so files that belong to another known project with a longer name are
+// excluded.
+func termFilesForProject(cfg Config, state State, date, project string) []string {
+	matches, _ := filepath.Glob(resolveTermGlob(cfg, date, project))
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var others []string
+	for _, w := range state.Watched {
+		if w.Name != project && strings.HasPrefix(w.Name, project) {
+			others = append(others, w.Name)
+		}
+	}
+	for _, p := range discoverProjects(cfg, date) {
+		if p != project && strings.HasPrefix(p, project) {
+			others = append(others, p)
+		}
+	}
+
+	var files []string
+outer:
+	for _, m := range matches {
+		for _, other := range others {
+			if ok, _ := filepath.Match(resolveTermGlob(cfg, date, other), m); ok {
+				continue outer
+			}
+		}
+		files = append(files, m)
+	}
+	return files
+}
+
+// renameTermFile returns the path of terminal log path after renaming its
+// project, keeping the suffix matched by the term_path wildcard.
+func renameTermFile(cfg Config, date, path, oldName, newName string) string {
+	oldPrefix := strings.SplitN(resolveTermGlob(cfg, date, oldName), "*", 2)[0]
+	newPrefix := strings.SplitN(resolveTermGlob(cfg, date, newName), "*", 2)[0]
+	return newPrefix + strings.TrimPrefix(path, oldPrefix)
+}
+
+// retagNotes replaces the #oldName hashtag with #newName in notes headings.
+// It returns the new content and the number of headings changed.
+func retagNotes(headings *noteHeadingFormat, content, oldName, newName string) (string, int) {
+	lines := strings.Split(content, "\n")
+	count := 0
+	for i, line := range lines {
+		_, rest, ok := parseNoteHeading(headings, line)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		changed := false
+		for j, f := range fields {
+			if f == "#"+oldName {
+				fields[j] = "#" + newName
+				changed = true
+			}
+		}
+		if changed {
+			heading := strings.TrimRight(line[:len(line)-len(rest)], " \t")
+			lines[i] = heading + " " + strings.Join(fields, " ")
+			count++
+		}
+	}
+	return strings.Join(lines, "\n"), count
+}
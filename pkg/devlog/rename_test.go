@@ -0,0 +1,109 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameWatchEntry(t *testing.T) {
+	watched := []WatchEntry{
+		{Path: "/home/user/dev/foo", Name: "foo"},
+		{Path: "/home/user/dev/bar", Name: "bar"},
+	}
+
+	if _, err := renameWatchEntry(watched, "foo", "bar"); err == nil {
+		t.Error("expected name conflict")
+	}
+
+	found, err := renameWatchEntry(watched, "foo", "baz")
+	if err != nil || !found {
+		t.Fatalf("renameWatchEntry = %v, %v", found, err)
+	}
+	if watched[0].Name != "baz" {
+		t.Errorf("expected baz, got %q", watched[0].Name)
+	}
+
+	found, _ = renameWatchEntry(watched, "missing", "other")
+	if found {
+		t.Error("expected no entry for unwatched project")
+	}
+}
+
+func TestRetagNotes(t *testing.T) {
+	content := "### At 10:00 #foo\nWorked on #foo stuff\n\n### At 11:00 #foobar\nOther\n\n### At 12:00\nGeneral\n"
+	got, n := retagNotes(nil, content, "foo", "baz")
+	if n != 1 {
+		t.Errorf("expected 1 heading retagged, got %d", n)
+	}
+	if !strings.Contains(got, "### At 10:00 #baz\n") {
+		t.Error("heading not retagged")
+	}
+	if !strings.Contains(got, "Worked on #foo stuff") {
+		t.Error("note body should not be changed")
+	}
+	if !strings.Contains(got, "### At 11:00 #foobar") {
+		t.Error("other project's heading should not be changed")
+	}
+}
+
+func TestProjectRename(t *testing.T) {
+	rawDir := t.TempDir()
+	cfg := Config{RawDir: rawDir, LogDir: t.TempDir()}
+	state := State{Watched: []WatchEntry{{Path: "/dev/foo-web", Name: "foo-web"}}}
+
+	day := filepath.Join(rawDir, "2024-01-15")
+	os.MkdirAll(day, 0o755)
+	for _, name := range []string{"git-foo.log", "comp-git-foo.md", "comp-git-foo.md.fingerprint", "term-foo-1.log", "term-foo-web.log", "git-foo-web.log"} {
+		os.WriteFile(filepath.Join(day, name), []byte("data\n"), 0o644)
+	}
+	os.WriteFile(filepath.Join(day, "notes.md"), []byte("### At 10:00 #foo\nNote\n\n"), 0o644)
+	os.WriteFile(filepath.Join(day, "notes-foo.md"), []byte("### At 11:00 #foo\nOwn note\n\n"), 0o644)
+
+	plan, err := planProjectRename(cfg, state, "foo", "bar")
+	if err != nil {
+		t.Fatalf("planProjectRename: %v", err)
+	}
+	retagged, err := plan.apply()
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if retagged != 2 {
+		t.Errorf("expected 2 notes retagged, got %d", retagged)
+	}
+	if own, err := os.ReadFile(filepath.Join(day, "notes-bar.md")); err != nil || !strings.Contains(string(own), "#bar") {
+		t.Errorf("per-project notes not moved and retagged: %q, %v", own, err)
+	}
+
+	for _, name := range []string{"git-bar.log", "comp-git-bar.md", "comp-git-bar.md.fingerprint", "term-bar-1.log", "term-foo-web.log", "git-foo-web.log"} {
+		if _, err := os.Stat(filepath.Join(day, name)); err != nil {
+			t.Errorf("expected %s to exist", name)
+		}
+	}
+	for _, name := range []string{"git-foo.log", "comp-git-foo.md", "comp-git-foo.md.fingerprint", "term-foo-1.log"} {
+		if _, err := os.Stat(filepath.Join(day, name)); err == nil {
+			t.Errorf("expected %s to be renamed", name)
+		}
+	}
+	notes, _ := os.ReadFile(filepath.Join(day, "notes.md"))
+	if !strings.Contains(string(notes), "#bar") {
+		t.Errorf("notes not retagged: %q", notes)
+	}
+}
+
+func TestProjectRenameConflict(t *testing.T) {
+	rawDir := t.TempDir()
+	cfg := Config{RawDir: rawDir, LogDir: t.TempDir()}
+	day := filepath.Join(rawDir, "2024-01-15")
+	os.MkdirAll(day, 0o755)
+	os.WriteFile(filepath.Join(day, "git-foo.log"), []byte("old\n"), 0o644)
+	os.WriteFile(filepath.Join(day, "git-bar.log"), []byte("existing\n"), 0o644)
+
+	if _, err := planProjectRename(cfg, State{}, "foo", "bar"); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if _, err := os.Stat(filepath.Join(day, "git-foo.log")); err != nil {
+		t.Error("files should be untouched after a conflict")
+	}
+}
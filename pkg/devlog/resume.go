@@ -0,0 +1,171 @@
+package devlog
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultResumeNotes is how many recent notes `devlog resume` shows.
+const defaultResumeNotes = 3
+
+// unfinishedHeadingRe matches the lines that start the unfinished-work part
+// of a summary: its next steps and open questions.
+var unfinishedHeadingRe = regexp.MustCompile(`(?i)^\**(next steps|open questions|unfinished work)\b`)
+
+// datedNote is a notes entry and the date it was logged on.
+type datedNote struct {
+	Date  string
+	Entry string
+}
+
+// resumeInfo is what `devlog resume` shows to get back into a project.
+type resumeInfo struct {
+	Project    string // "" for every project
+	Date       string // date of the latest summary
+	Summary    string // the summary up to its unfinished work
+	Unfinished string // the next steps and open questions
+	Open       []openQuestion
+	Notes      []datedNote
+}
+
+// latestProjectSummary returns the most recent date with a summary of
+// project, or with any summary if project is "", and the summary text.
+func latestProjectSummary(cfg Config, project string) (string, string, bool) {
+	dates := rawDataDates(cfg)
+	for i := len(dates) - 1; i >= 0; i-- {
+		content, err := readSummary(cfg, dates[i])
+		if err != nil {
+			continue
+		}
+		if project == "" {
+			_, body, _ := strings.Cut(content, "\n")
+			return dates[i], strings.TrimSpace(body), true
+		}
+		for _, s := range selectSummarySections(content, []string{project}) {
+			return dates[i], s.Text, true
+		}
+	}
+	return "", "", false
+}
+
+// splitUnfinished splits a project's summary at its first "Next steps:" or
+// "Open questions:" line.
+func splitUnfinished(text string) (string, string) {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if unfinishedHeadingRe.MatchString(strings.TrimSpace(line)) {
+			return strings.TrimSpace(strings.Join(lines[:i], "\n")), strings.TrimSpace(strings.Join(lines[i:], "\n"))
+		}
+	}
+	return strings.TrimSpace(text), ""
+}
+
+// recentNotes returns the last n notes entries tagged with project (or any
+// notes if project is ""), oldest first.
+func recentNotes(cfg Config, project string, n int) []datedNote {
+	var notes []datedNote
+	dates := rawDataDates(cfg)
+	for i := len(dates) - 1; i >= 0 && len(notes) < n; i-- {
+		for _, day := range collectNotes(cfg, []string{dates[i]}, project) {
+			entries := splitNoteEntries(cfg.noteHeadings(), day.Content)
+			for j := len(entries) - 1; j >= 0 && len(notes) < n; j-- {
+				notes = append(notes, datedNote{Date: day.Date, Entry: entries[j]})
+			}
+		}
+	}
+	for i, j := 0, len(notes)-1; i < j; i, j = i+1, j-1 {
+		notes[i], notes[j] = notes[j], notes[i]
+	}
+	return notes
+}
+
+// collectResume gathers the latest summary of project, its unresolved open
+// questions if they are tracked, and its most recent notes entries (up to
+// notes of them). It fails if the project has never been summarized.
+func collectResume(cfg Config, project string, notes int) (resumeInfo, error) {
+	r := resumeInfo{Project: project}
+	date, text, ok := latestProjectSummary(cfg, project)
+	if !ok {
+		if project == "" {
+			return r, fmt.Errorf("no summaries in %s", resolveLogDir(cfg))
+		}
+		return r, fmt.Errorf("no summary of %s in %s", project, resolveLogDir(cfg))
+	}
+	r.Date = date
+	if project == "" {
+		r.Summary = text
+	} else {
+		r.Summary, r.Unfinished = splitUnfinished(text)
+	}
+
+	if cfg.TrackQuestions && project != "" {
+		questions, err := loadQuestions(project)
+		if err != nil {
+			return r, err
+		}
+		// Questions opened on date are already in its summary.
+		for _, q := range questions.openBefore(date) {
+			if q.Resolved != date {
+				r.Open = append(r.Open, q)
+			}
+		}
+	}
+	if notes > 0 {
+		r.Notes = recentNotes(cfg, project, notes)
+	}
+	return r, nil
+}
+
+func writeResume(w io.Writer, r resumeInfo, now time.Time) {
+	name := r.Project
+	if name == "" {
+		name = "All projects"
+	}
+	fmt.Fprintf(w, "# %s, last summarized %s", name, r.Date)
+	if d, err := time.ParseInLocation("2006-01-02", r.Date, now.Location()); err == nil {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		switch days := int(today.Sub(d).Hours() / 24); {
+		case days == 0:
+			fmt.Fprint(w, " (today)")
+		case days == 1:
+			fmt.Fprint(w, " (yesterday)")
+		case days > 1:
+			fmt.Fprintf(w, " (%d days ago)", days)
+		}
+	}
+	fmt.Fprintln(w)
+
+	if r.Unfinished != "" || len(r.Open) > 0 {
+		fmt.Fprint(w, "\n## Where you left off\n\n")
+		if r.Unfinished != "" {
+			fmt.Fprintln(w, r.Unfinished)
+		}
+		if len(r.Open) > 0 {
+			if r.Unfinished != "" {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintln(w, "Still open from earlier days:")
+			for _, q := range r.Open {
+				fmt.Fprintf(w, "- %s (since %s)\n", q.Text, q.Opened)
+			}
+		}
+	}
+
+	if r.Summary != "" {
+		fmt.Fprintf(w, "\n## Summary\n\n%s\n", r.Summary)
+	}
+
+	if len(r.Notes) > 0 {
+		fmt.Fprint(w, "\n## Recent notes\n")
+		for _, n := range r.Notes {
+			heading, body, _ := strings.Cut(n.Entry, "\n")
+			fmt.Fprintf(w, "\n%s (%s)\n", heading, n.Date)
+			if body = strings.TrimSpace(body); body != "" {
+				fmt.Fprintln(w, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitUnfinished(t *testing.T) {
+	body, unfinished := splitUnfinished("Refactored the parser.\n\n**Next steps:**\n- Finish the lexer\n\nOpen questions:\n- None")
+	if body != "Refactored the parser." || !strings.HasPrefix(unfinished, "**Next steps:**\n- Finish the lexer") ||
+		!strings.HasSuffix(unfinished, "Open questions:\n- None") {
+		t.Errorf("got %q and %q", body, unfinished)
+	}
+	if body, unfinished := splitUnfinished("Only prose."); body != "Only prose." || unfinished != "" {
+		t.Errorf("got %q and %q", body, unfinished)
+	}
+}
+
+func TestCollectResume(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+	cfg := Config{LogDir: filepath.Join(tmp, "log"), TrackQuestions: true}
+	os.MkdirAll(cfg.LogDir, 0o755)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-10.md"), []byte("# 2024-01-10\n\n## alpha\n\nOld work.\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-12.md"),
+		[]byte("# 2024-01-12\n\n## alpha\n\nMoved the lexer.\n\nNext steps:\n- Wire up errors\n\n## beta\n\nBeta work.\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## beta\n\nMore beta.\n"), 0o644)
+	for date, notes := range map[string]string{
+		"2024-01-11": "### At 09:00 #alpha\nfirst\n\n### At 10:00 #alpha\nsecond\n",
+		"2024-01-12": "### At 11:00 #alpha\nthird\n\n### At 12:00 #beta\nbeta note\n",
+	} {
+		os.MkdirAll(filepath.Join(rawDir, date), 0o755)
+		os.WriteFile(filepath.Join(rawDir, date, "notes.md"), []byte(notes), 0o644)
+	}
+	var log questionLog
+	log.apply("2024-01-10", []string{"cache policy?"}, nil)
+	log.apply("2024-01-12", []string{"error format?"}, nil)
+	saveQuestions("alpha", log)
+
+	r, err := collectResume(cfg, "alpha", 2)
+	if err != nil {
+		t.Fatalf("collectResume: %v", err)
+	}
+	if r.Date != "2024-01-12" || r.Summary != "Moved the lexer." || r.Unfinished != "Next steps:\n- Wire up errors" {
+		t.Errorf("unexpected resume %+v", r)
+	}
+	if len(r.Open) != 1 || r.Open[0].Text != "cache policy?" {
+		t.Errorf("expected the question carried into the summary date, got %+v", r.Open)
+	}
+	if len(r.Notes) != 2 || r.Notes[0].Entry != "### At 10:00 #alpha\nsecond" || r.Notes[1].Date != "2024-01-12" {
+		t.Errorf("unexpected notes %+v", r.Notes)
+	}
+
+	var buf bytes.Buffer
+	writeResume(&buf, r, time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local))
+	out := buf.String()
+	for _, want := range []string{
+		"# alpha, last summarized 2024-01-12 (3 days ago)\n",
+		"## Where you left off\n\nNext steps:\n- Wire up errors\n\nStill open from earlier days:\n- cache policy? (since 2024-01-10)\n",
+		"## Summary\n\nMoved the lexer.\n",
+		"### At 11:00 #alpha (2024-01-12)\nthird\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if r, _ := collectResume(cfg, "", 0); r.Date != "2024-01-15" || !strings.Contains(r.Summary, "## beta") {
+		t.Errorf("unexpected resume of all projects %+v", r)
+	}
+	if _, err := collectResume(cfg, "gamma", 0); err == nil {
+		t.Error("expected error for a project without summaries")
+	}
+}
@@ -0,0 +1,165 @@
+package devlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// collectSummaries returns the summaries for dates, keyed by file name
+// ("<date>.md") so that sorted names are in date order. With projects set,
+// each summary is reduced to those projects' sections, and dates without one
+// are left out.
+func collectSummaries(cfg Config, dates, projects []string) map[string]string {
+	files := make(map[string]string)
+	for _, date := range dates {
+		content, err := readSummary(cfg, date)
+		if err != nil {
+			continue
+		}
+		var parts []string
+		for _, s := range selectSummarySections(content, projects) {
+			parts = append(parts, "## "+s.Project+"\n\n"+s.Text)
+		}
+		if len(parts) > 0 {
+			files[date+".md"] = strings.Join(parts, "\n\n")
+		}
+	}
+	return files
+}
+
+// groupSummariesByMonth splits summaries keyed "<date>.md" by month
+// ("YYYY-MM").
+func groupSummariesByMonth(files map[string]string) map[string]map[string]string {
+	months := make(map[string]map[string]string)
+	for name, content := range files {
+		month := name[:7]
+		if months[month] == nil {
+			months[month] = make(map[string]string)
+		}
+		months[month][name] = content
+	}
+	return months
+}
+
+// writeSortedFiles writes each file as a "--- name ---" block, in name order.
+func writeSortedFiles(b *strings.Builder, files map[string]string) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(b, "\n--- %s ---\n%s\n", name, files[name])
+	}
+}
+
+// assembleMonthDigestPrompt builds the prompt that condenses a month of
+// daily summaries when the whole review period does not fit in one prompt.
+func assembleMonthDigestPrompt(month string, files map[string]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are condensing a month of daily work summaries written by a software\n"+
+		"engineer, for %s. Each file is one day's summary, with one \"## <project>\"\n"+
+		"section per project.\n", month)
+	writeSortedFiles(&b, files)
+	b.WriteString(`
+Task: Write a digest of the month's accomplishments that will be combined
+with other months into a performance review.
+
+Guidelines:
+- List what was built or shipped, bugs and incidents resolved, and new
+  technologies, tools, or areas of the codebase learned.
+- Keep concrete details that show impact or difficulty: names of features
+  and systems, numbers, and problems solved.
+- Leave out dead ends and day-to-day detail that does not amount to an
+  accomplishment.
+- Group by project.
+
+Output only the digest, nothing else.
+`)
+	return b.String()
+}
+
+// assembleReviewPrompt builds the prompt for a brag document covering from
+// to to, from either daily summaries ("<date>.md") or monthly digests
+// ("month-YYYY-MM.md").
+func assembleReviewPrompt(from, to string, files map[string]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are writing a brag document for a software engineer's performance\n"+
+		"review, covering %s to %s.\n\n"+
+		"Below are records of the engineer's work over the period: either daily\n"+
+		"summaries (<date>.md) or monthly digests of them (month-<YYYY-MM>.md).\n", from, to)
+	writeSortedFiles(&b, files)
+	b.WriteString(`
+Task: Turn these records into an accomplishment-oriented document the
+engineer can draw on when writing a self-review.
+
+Use exactly these Markdown sections:
+
+## Highlights
+Three to five bullets with the most significant accomplishments.
+
+## Shipped
+Features, improvements, and projects delivered, grouped by project.
+
+## Incidents and bugs resolved
+Production issues, hard bugs, and regressions fixed, with their impact.
+
+## Skills grown
+Technologies, tools, domains, and practices learned or deepened, with the
+work that shows it.
+
+Guidelines:
+- Lead each bullet with the outcome, then how it was achieved.
+- Be specific: name the features, systems, and problems, and keep any
+  numbers that show scale or impact. Do not invent impact that the records
+  do not support.
+- Merge work that continued over several days or months into one bullet.
+- Leave out dead ends and routine work unless they led somewhere.
+- Leave a section out if nothing belongs in it.
+- Write in first person.
+
+Output only the document, nothing else.
+`)
+	return b.String()
+}
+
+// reviewPrompt returns the prompt for a brag document covering dates,
+// limited to projects if set. If the summaries exceed the token budget, each
+// month is first condensed by calling digest with its prompt, and the review
+// is written from the monthly digests.
+func reviewPrompt(cfg Config, dates, projects []string, digest func(month, prompt string) (string, error)) (string, error) {
+	from, to := dates[0], dates[len(dates)-1]
+	files := collectSummaries(cfg, dates, projects)
+	if len(files) == 0 {
+		return "", fmt.Errorf("no summaries between %s and %s", from, to)
+	}
+
+	if cfg.TokenBudget > 0 && totalTokens(files) > cfg.TokenBudget {
+		byMonth := groupSummariesByMonth(files)
+		months := make([]string, 0, len(byMonth))
+		for month := range byMonth {
+			months = append(months, month)
+		}
+		sort.Strings(months)
+
+		digests := make(map[string]string)
+		for _, month := range months {
+			monthFiles := byMonth[month]
+			counts := applyTokenBudget(monthFiles, cfg.TokenBudget)
+			warnTruncated("summaries for "+month, counts, cfg.TokenBudget)
+			d, err := digest(month, assembleMonthDigestPrompt(month, monthFiles))
+			if err != nil {
+				return "", fmt.Errorf("condensing %s: %w", month, err)
+			}
+			digests["month-"+month+".md"] = d
+		}
+		files = digests
+	}
+
+	counts := applyTokenBudget(files, cfg.TokenBudget)
+	warnTruncated("review prompt", counts, cfg.TokenBudget)
+	return assembleReviewPrompt(from, to, files), nil
+}
@@ -0,0 +1,61 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectSummaries(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir()}
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nAlpha work.\n\n## beta\n\nBeta work.\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-16.md"), []byte("# 2024-01-16\n\n## beta\n\nMore beta.\n"), 0o644)
+
+	dates := []string{"2024-01-15", "2024-01-16", "2024-01-17"}
+	if files := collectSummaries(cfg, dates, nil); len(files) != 2 {
+		t.Errorf("expected 2 summaries, got %v", files)
+	}
+	files := collectSummaries(cfg, dates, []string{"alpha"})
+	if len(files) != 1 || files["2024-01-15.md"] != "## alpha\n\nAlpha work." {
+		t.Errorf("unexpected summaries for alpha: %q", files)
+	}
+}
+
+func TestReviewPrompt(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir(), TokenBudget: 150000}
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nShipped caching.\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-02-20.md"), []byte("# 2024-02-20\n\n## alpha\n\nFixed the outage.\n"), 0o644)
+	dates, _ := parseDateRange("2024-01-01..2024-02-29", time.Now())
+
+	var digested []string
+	digest := func(month, prompt string) (string, error) {
+		digested = append(digested, month)
+		return "digest of " + month, nil
+	}
+
+	prompt, err := reviewPrompt(cfg, dates, nil, digest)
+	if err != nil {
+		t.Fatalf("reviewPrompt: %v", err)
+	}
+	if len(digested) != 0 || !strings.Contains(prompt, "--- 2024-01-15.md ---\n## alpha\n\nShipped caching.") ||
+		!strings.Contains(prompt, "covering 2024-01-01 to 2024-02-29") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+
+	// Over budget, each month is condensed first.
+	cfg.TokenBudget = 12
+	prompt, err = reviewPrompt(cfg, dates, nil, digest)
+	if err != nil {
+		t.Fatalf("reviewPrompt: %v", err)
+	}
+	if fmt.Sprint(digested) != "[2024-01 2024-02]" || !strings.Contains(prompt, "--- month-2024-02.md ---\ndigest of 2024-02") {
+		t.Errorf("digested %v, prompt:\n%s", digested, prompt)
+	}
+
+	if _, err := reviewPrompt(cfg, dates, []string{"gamma"}, digest); err == nil {
+		t.Error("expected error with no summaries")
+	}
+}
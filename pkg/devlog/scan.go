@@ -0,0 +1,182 @@
+package devlog
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scanMaxDepth is how many directories deep `devlog watch --scan` looks for
+// repos below the directory it is given.
+const scanMaxDepth = 4
+
+// scanRecent is how recent a repo's last activity must be for `devlog watch
+// --scan` to show it as active and select it by default.
+const scanRecent = 14 * 24 * time.Hour
+
+// scanSkipDirs are directories that hold dependencies or build output rather
+// than repos of their own.
+var scanSkipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"build":        true,
+	"dist":         true,
+}
+
+// scannedRepo is a git repo found by `devlog watch --scan`.
+type scannedRepo struct {
+	Path       string
+	LastActive time.Time // zero if the repo has no commits
+	Watched    bool
+}
+
+// scanRepos returns the git repos under root, most recently active first.
+// Repos nested inside a repo, hidden directories, and dependency directories
+// are not searched. Repos whose root is in watched are marked as watched.
+func scanRepos(root string, watched []WatchEntry) ([]scannedRepo, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+	isWatched := make(map[string]bool)
+	for _, w := range watched {
+		isWatched[w.repoRoot()] = true
+	}
+
+	var repos []scannedRepo
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root {
+			name := d.Name()
+			if strings.HasPrefix(name, ".") || scanSkipDirs[name] {
+				return fs.SkipDir
+			}
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			repos = append(repos, scannedRepo{Path: path, LastActive: repoLastActive(path), Watched: isWatched[path]})
+			return fs.SkipDir
+		}
+		if rel, _ := filepath.Rel(root, path); rel != "." && strings.Count(rel, string(filepath.Separator))+1 >= scanMaxDepth {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return repos[i].LastActive.After(repos[j].LastActive)
+	})
+	return repos, nil
+}
+
+// repoLastActive returns when the repo at path was last worked on: the later
+// of its last commit and the last change to its index, which staging,
+// checkouts, and `git status` update.
+func repoLastActive(path string) time.Time {
+	var last time.Time
+	if out, err := exec.Command("git", "-C", path, "log", "-1", "--format=%ct").Output(); err == nil {
+		if secs, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			last = time.Unix(secs, 0)
+		}
+	}
+	if out, err := exec.Command("git", "-C", path, "rev-parse", "--git-path", "index").Output(); err == nil {
+		index := strings.TrimSpace(string(out))
+		if !filepath.IsAbs(index) {
+			index = filepath.Join(path, index)
+		}
+		if info, err := os.Stat(index); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last
+}
+
+// formatAge formats how long ago t was for the scan list, e.g. "3 hours
+// ago". The zero time is "no commits".
+func formatAge(t, now time.Time) string {
+	if t.IsZero() {
+		return "no commits"
+	}
+	d := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit + " ago"
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case d < time.Hour:
+		return "just now"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	case d < 60*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day")
+	default:
+		return plural(int(d/(30*24*time.Hour)), "month")
+	}
+}
+
+// parseRepoSelection parses the answer to the prompts of `devlog watch
+// --scan` and `devlog watch --prune`: comma- or space-separated numbers and
+// ranges ("1,3-5"), "all", or "none". An empty answer selects the defaults.
+// It returns the selected indexes into a list of n repos, in order.
+func parseRepoSelection(answer string, n int, defaults []int) ([]int, error) {
+	answer = strings.TrimSpace(strings.ToLower(answer))
+	switch answer {
+	case "":
+		return defaults, nil
+	case "none", "n":
+		return nil, nil
+	case "all", "a":
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+
+	selected := make([]bool, n)
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' }) {
+		lo, hi, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(lo)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(hi)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q: expected numbers, ranges like 1-3, all, or none", field)
+		}
+		if first < 1 || last > n || first > last {
+			return nil, fmt.Errorf("invalid selection %q: repos are numbered 1 to %d", field, n)
+		}
+		for i := first; i <= last; i++ {
+			selected[i-1] = true
+		}
+	}
+	var indexes []int
+	for i, ok := range selected {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
@@ -0,0 +1,90 @@
+package devlog
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanRepos(t *testing.T) {
+	root := t.TempDir()
+	gitInit := func(rel string) string {
+		path := filepath.Join(root, rel)
+		if out, err := exec.Command("git", "init", "-q", path).CombinedOutput(); err != nil {
+			t.Fatalf("git init %s: %s: %v", rel, out, err)
+		}
+		return path
+	}
+	// A repo with a commit, one without, and repos that are not searched: one
+	// nested in a repo, one in a dependency directory, one in a hidden one.
+	active := gitInit("active")
+	exec.Command("git", "-C", active, "-c", "user.email=t@t", "-c", "user.name=T", "commit", "-q", "--allow-empty", "-m", "init").Run()
+	empty := gitInit("group/empty")
+	gitInit("active/nested")
+	gitInit("node_modules/dep")
+	gitInit(".cache/repo")
+
+	repos, err := scanRepos(root, []WatchEntry{{Path: filepath.Join(active, "sub"), Subdir: "sub"}})
+	if err != nil {
+		t.Fatalf("scanRepos: %v", err)
+	}
+	if len(repos) != 2 || repos[0].Path != active || repos[1].Path != empty {
+		t.Fatalf("scanRepos = %+v, want active then group/empty", repos)
+	}
+	if !repos[0].Watched || repos[1].Watched {
+		t.Errorf("watched = %v, %v; want only the repo with a watched subdir", repos[0].Watched, repos[1].Watched)
+	}
+	if repos[0].LastActive.IsZero() || time.Since(repos[0].LastActive) > time.Hour {
+		t.Errorf("LastActive of active repo = %v, want about now", repos[0].LastActive)
+	}
+
+	if _, err := scanRepos(filepath.Join(root, "missing"), nil); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestParseScanSelection(t *testing.T) {
+	defaults := []int{0, 2}
+	for _, tc := range []struct {
+		answer string
+		want   []int
+		ok     bool
+	}{
+		{"", []int{0, 2}, true},
+		{"none", nil, true},
+		{"all", []int{0, 1, 2, 3, 4}, true},
+		{"2", []int{1}, true},
+		{"5, 1-2", []int{0, 1, 4}, true},
+		{"1 3-4 3", []int{0, 2, 3}, true},
+		{"6", nil, false},
+		{"0", nil, false},
+		{"3-1", nil, false},
+		{"api", nil, false},
+	} {
+		got, err := parseRepoSelection(tc.answer, 5, defaults)
+		if (err == nil) != tc.ok || (tc.ok && !reflect.DeepEqual(got, tc.want)) {
+			t.Errorf("parseRepoSelection(%q) = %v, %v; want %v (ok %v)", tc.answer, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Time{}, "no commits"},
+		{now.Add(-10 * time.Minute), "just now"},
+		{now.Add(-time.Hour), "1 hour ago"},
+		{now.Add(-5 * time.Hour), "5 hours ago"},
+		{now.AddDate(0, 0, -3), "3 days ago"},
+		{now.AddDate(0, -4, 0), "4 months ago"},
+	} {
+		if got := formatAge(tc.t, now); got != tc.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tc.t, got, tc.want)
+		}
+	}
+}
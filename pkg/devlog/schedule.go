@@ -0,0 +1,398 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScheduleTask is a task of the [schedule] section: a devlog command the
+// server runs at the times given by At, see parseScheduleAt.
+type ScheduleTask struct {
+	At  string `toml:"at"`
+	Run string `toml:"run"` // devlog arguments, e.g. "gen <date>"
+	// CatchUp is what to do about the times missed while the server was not
+	// running or the machine was asleep: "once" (the default), "all", or
+	// "none".
+	CatchUp string `toml:"catch_up"`
+}
+
+// scheduleTick is how often the server checks for due tasks.
+const scheduleTick = 30 * time.Second
+
+// scheduleGrace is how late a task may be found due and still count as on
+// time rather than missed.
+const scheduleGrace = 5 * time.Minute
+
+// maxCatchUpRuns bounds the missed times a catch_up = "all" task is run for.
+const maxCatchUpRuns = 31
+
+// scheduleVarRe matches the variables of a task's run.
+var scheduleVarRe = regexp.MustCompile(`<[a-z_]+>`)
+
+// scheduleExecutable returns the devlog binary that runs scheduled tasks.
+// It is a variable for tests.
+var scheduleExecutable = os.Executable
+
+// scheduleSpec is a parsed at of a task: a time of day, every day, on a day
+// of the week, or on a day of the month.
+type scheduleSpec struct {
+	period       string // "daily", "weekly", or "monthly"
+	weekday      time.Weekday
+	day          int // day of the month, for monthly
+	hour, minute int
+}
+
+// parseScheduleAt parses the at of a task: "HH:MM" or "daily HH:MM",
+// "weekly <weekday> HH:MM" (e.g. "weekly Sun 18:00"), or "monthly <day>
+// HH:MM" (e.g. "monthly 1 03:00"). A monthly day past the end of a month
+// falls on its last day.
+func parseScheduleAt(at string) (scheduleSpec, error) {
+	fields := strings.Fields(strings.ToLower(at))
+	var spec scheduleSpec
+	if len(fields) == 1 {
+		fields = append([]string{"daily"}, fields...)
+	}
+	want := 0
+	if len(fields) > 0 {
+		spec.period = fields[0]
+		want = map[string]int{"daily": 2, "weekly": 3, "monthly": 3}[spec.period]
+	}
+	if want == 0 || len(fields) != want {
+		return spec, fmt.Errorf("invalid at %q (want \"HH:MM\", \"weekly <weekday> HH:MM\", or \"monthly <day> HH:MM\")", at)
+	}
+	switch spec.period {
+	case "weekly":
+		wd, ok := parseWeekday(fields[1])
+		if !ok {
+			return spec, fmt.Errorf("invalid at %q: unknown weekday %q", at, fields[1])
+		}
+		spec.weekday = wd
+	case "monthly":
+		day, err := strconv.Atoi(fields[1])
+		if err != nil || day < 1 || day > 31 {
+			return spec, fmt.Errorf("invalid at %q: day of the month must be 1-31", at)
+		}
+		spec.day = day
+	}
+	clock, err := time.Parse("15:04", fields[len(fields)-1])
+	if err != nil {
+		return spec, fmt.Errorf("invalid at %q: time must be HH:MM", at)
+	}
+	spec.hour, spec.minute = clock.Hour(), clock.Minute()
+	return spec, nil
+}
+
+// parseWeekday parses a weekday name, full or abbreviated to three letters.
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// onDay reports whether the task runs on the day of t.
+func (s scheduleSpec) onDay(t time.Time) bool {
+	switch s.period {
+	case "weekly":
+		return t.Weekday() == s.weekday
+	case "monthly":
+		last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		return t.Day() == min(s.day, last)
+	default:
+		return true
+	}
+}
+
+// next returns the first time the task runs after t.
+func (s scheduleSpec) next(t time.Time) time.Time {
+	for i := 0; ; i++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()+i, s.hour, s.minute, 0, 0, t.Location())
+		if s.onDay(day) && day.After(t) {
+			return day
+		}
+	}
+}
+
+// dueTimes returns the times the task ran at after last, up to now, oldest
+// first; only the last maxCatchUpRuns are kept.
+func (s scheduleSpec) dueTimes(last, now time.Time) []time.Time {
+	var due []time.Time
+	for t := s.next(last); !t.After(now); t = s.next(t) {
+		due = append(due, t)
+		if len(due) > maxCatchUpRuns {
+			due = due[1:]
+		}
+	}
+	return due
+}
+
+// scheduledRuns returns which of due, the times a task was due at since it
+// last ran, to run it for at now, as its catch_up setting says. A time
+// within scheduleGrace of now is on time and is always run.
+func scheduledRuns(catchUp string, due []time.Time, now time.Time) []time.Time {
+	if len(due) == 0 {
+		return nil
+	}
+	latest := due[len(due)-1]
+	switch {
+	case catchUp == "all":
+		return due
+	case catchUp == "none" && now.Sub(latest) > scheduleGrace:
+		return nil
+	default:
+		return due[len(due)-1:]
+	}
+}
+
+// scheduleArgs returns the devlog arguments of run for the time at: run
+// split at whitespace, with its variables replaced and a leading "~"
+// expanded. <date> is the date of at, <yesterday> the day before,
+// <week_start> the Monday of its week, and <month_start> the first of its
+// month.
+func scheduleArgs(run string, at time.Time) []string {
+	weekday := (int(at.Weekday()) + 6) % 7 // days since Monday
+	r := strings.NewReplacer(
+		"<date>", at.Format("2006-01-02"),
+		"<yesterday>", at.AddDate(0, 0, -1).Format("2006-01-02"),
+		"<week_start>", at.AddDate(0, 0, -weekday).Format("2006-01-02"),
+		"<month_start>", time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location()).Format("2006-01-02"),
+	)
+	args := strings.Fields(run)
+	for i, a := range args {
+		a = r.Replace(a)
+		if strings.HasPrefix(a, "~") {
+			a = expandPath(a)
+		}
+		args[i] = a
+	}
+	return args
+}
+
+// checkSchedule reports the problems with the [schedule] section.
+func checkSchedule(tasks map[string]ScheduleTask) []string {
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var problems []string
+	for _, name := range names {
+		task := tasks[name]
+		setting := "schedule." + name
+		if _, err := parseScheduleAt(task.At); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.at: %v", setting, err))
+		}
+		args := strings.Fields(task.Run)
+		if len(args) == 0 {
+			problems = append(problems, setting+".run: is empty")
+		} else if args[0] == "start" {
+			problems = append(problems, setting+".run: must not start the server")
+		}
+		for _, v := range scheduleVarRe.FindAllString(task.Run, -1) {
+			switch v {
+			case "<date>", "<yesterday>", "<week_start>", "<month_start>":
+			default:
+				problems = append(problems, fmt.Sprintf("%s.run: unknown variable %s (want <date>, <yesterday>, <week_start>, or <month_start>)", setting, v))
+			}
+		}
+		switch task.CatchUp {
+		case "", "once", "all", "none":
+		default:
+			problems = append(problems, fmt.Sprintf("%s.catch_up: invalid value %q (want once, all, or none)", setting, task.CatchUp))
+		}
+	}
+	return problems
+}
+
+// scheduleMarks records when each task was last due, so that the times
+// missed while the server was not running are caught up on.
+type scheduleMarks struct {
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+func resolveScheduleMarksPath() string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "schedule.json")
+}
+
+func loadScheduleMarks() (scheduleMarks, error) {
+	m := scheduleMarks{LastRun: make(map[string]time.Time)}
+	data, err := os.ReadFile(resolveScheduleMarksPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return m, fmt.Errorf("reading schedule marks: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parsing schedule marks: %w", err)
+	}
+	if m.LastRun == nil {
+		m.LastRun = make(map[string]time.Time)
+	}
+	return m, nil
+}
+
+func saveScheduleMarks(m scheduleMarks) error {
+	path := resolveScheduleMarksPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling schedule marks: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing schedule marks: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing schedule marks: %w", err)
+	}
+	return nil
+}
+
+// scheduleLoop runs the tasks of the [schedule] section when they are due
+// until the server stops.
+func (s *Server) scheduleLoop() {
+	marks, err := loadScheduleMarks()
+	if err != nil {
+		s.logger.Warn("starting schedule afresh", "err", err)
+	}
+	warned := make(map[string]bool)
+	ticker := time.NewTicker(scheduleTick)
+	defer ticker.Stop()
+	for {
+		s.runSchedule(&marks, time.Now(), warned)
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runSchedule runs the tasks due at now, one at a time, and records them in
+// marks. A task new to marks is not due until its next time. Tasks with an
+// invalid at are skipped, with a warning the first time; while deferWork
+// holds, due tasks are left due, to run on AC power.
+func (s *Server) runSchedule(marks *scheduleMarks, now time.Time, warned map[string]bool) {
+	s.mu.RLock()
+	tasks := s.cfg.Schedule
+	s.mu.RUnlock()
+
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	changed := false
+	for name := range marks.LastRun {
+		if _, ok := tasks[name]; !ok {
+			delete(marks.LastRun, name)
+			changed = true
+		}
+	}
+tasks:
+	for _, name := range names {
+		task := tasks[name]
+		spec, err := parseScheduleAt(task.At)
+		if err != nil {
+			if !warned[name+"\x00"+task.At] {
+				s.logger.Warn("skipping scheduled task", "task", name, "err", err)
+				warned[name+"\x00"+task.At] = true
+			}
+			continue
+		}
+		last, ok := marks.LastRun[name]
+		if !ok {
+			marks.LastRun[name] = now
+			changed = true
+			continue
+		}
+		due := spec.dueTimes(last, now)
+		if len(due) == 0 {
+			continue
+		}
+		if s.deferWork() {
+			if !warned[name+"\x00battery"] {
+				s.logger.Info("deferring scheduled task while on battery", "task", name)
+				warned[name+"\x00battery"] = true
+			}
+			continue
+		}
+		delete(warned, name+"\x00battery")
+		runs := scheduledRuns(task.CatchUp, due, now)
+		if skipped := len(due) - len(runs); skipped > 0 {
+			s.logger.Info("skipping missed runs of scheduled task", "task", name, "missed", skipped)
+		}
+		for _, at := range runs {
+			s.runTask(name, task, at)
+			if s.ctx.Err() != nil {
+				// Stopped mid-task: it is run again after a restart.
+				break tasks
+			}
+			marks.LastRun[name] = at
+			if err := saveScheduleMarks(*marks); err != nil {
+				s.logger.Warn("saving schedule marks failed", "err", err)
+			}
+		}
+		marks.LastRun[name] = due[len(due)-1]
+		changed = true
+	}
+	if changed {
+		if err := saveScheduleMarks(*marks); err != nil {
+			s.logger.Warn("saving schedule marks failed", "err", err)
+		}
+	}
+}
+
+// runTask runs task, due at at, as a devlog command, and logs its outcome.
+// A task still running when the server stops is stopped as `devlog gen
+// --cancel` stops a generation.
+func (s *Server) runTask(name string, task ScheduleTask, at time.Time) {
+	args := scheduleArgs(task.Run, at)
+	exe, err := scheduleExecutable()
+	if err != nil {
+		s.logger.Error("scheduled task failed", "task", name, "err", err)
+		return
+	}
+	s.logger.Info("running scheduled task", "task", name, "due", at.Format("2006-01-02 15:04"), "run", strings.Join(args, " "))
+	start := time.Now()
+	cmd := exec.CommandContext(s.ctx, exe, args...)
+	cmd.Cancel = func() error { return stopProcess(cmd.Process.Pid) }
+	cmd.WaitDelay = commandWaitDelay
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		s.logger.Error("scheduled task failed", "task", name, "err", err, "output", lastLines(out.String(), 5))
+		return
+	}
+	s.logger.Info("scheduled task done", "task", name, "duration", time.Since(start).Round(time.Second), "output", lastLines(out.String(), 1))
+}
+
+// lastLines returns the last n non-empty lines of s, joined by " | ".
+func lastLines(s string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, " | ")
+}
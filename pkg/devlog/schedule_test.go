@@ -0,0 +1,181 @@
+package devlog
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleAt(t *testing.T) {
+	valid := map[string]scheduleSpec{
+		"23:30":              {period: "daily", hour: 23, minute: 30},
+		"daily 07:05":        {period: "daily", hour: 7, minute: 5},
+		"weekly Sun 18:00":   {period: "weekly", weekday: time.Sunday, hour: 18},
+		"weekly friday 9:00": {period: "weekly", weekday: time.Friday, hour: 9},
+		"monthly 1 03:00":    {period: "monthly", day: 1, hour: 3},
+	}
+	for at, want := range valid {
+		got, err := parseScheduleAt(at)
+		if err != nil || got != want {
+			t.Errorf("parseScheduleAt(%q) = %+v, %v; want %+v", at, got, err, want)
+		}
+	}
+	for _, at := range []string{"", "25:00", "noon", "weekly 18:00", "weekly Sol 18:00", "monthly 32 03:00", "hourly 10:00", "daily Sun 10:00"} {
+		if _, err := parseScheduleAt(at); err == nil {
+			t.Errorf("parseScheduleAt(%q) should fail", at)
+		}
+	}
+}
+
+func TestScheduleDueTimes(t *testing.T) {
+	day := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		at        string
+		last, now string
+		want      []string
+	}{
+		{"23:30", "2024-01-15 23:30", "2024-01-15 23:59", nil},
+		{"23:30", "2024-01-15 23:30", "2024-01-18 08:00", []string{"2024-01-16 23:30", "2024-01-17 23:30"}},
+		{"weekly Sun 18:00", "2024-01-10 12:00", "2024-01-21 18:00", []string{"2024-01-14 18:00", "2024-01-21 18:00"}},
+		// A day past the end of the month falls on its last day.
+		{"monthly 31 03:00", "2024-01-31 03:00", "2024-04-01 00:00", []string{"2024-02-29 03:00", "2024-03-31 03:00"}},
+	}
+	for _, tt := range tests {
+		spec, err := parseScheduleAt(tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, due := range spec.dueTimes(day(tt.last), day(tt.now)) {
+			got = append(got, due.Format("2006-01-02 15:04"))
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q from %s to %s: due %v, want %v", tt.at, tt.last, tt.now, got, tt.want)
+		}
+	}
+
+	spec, _ := parseScheduleAt("12:00")
+	if due := spec.dueTimes(day("2023-01-01 00:00"), day("2024-01-01 00:00")); len(due) != maxCatchUpRuns || due[len(due)-1] != day("2023-12-31 12:00") {
+		t.Errorf("a year of missed runs should keep the last %d, got %d", maxCatchUpRuns, len(due))
+	}
+}
+
+func TestScheduledRuns(t *testing.T) {
+	now := time.Date(2024, 1, 18, 8, 0, 0, 0, time.Local)
+	missed := []time.Time{now.Add(-56 * time.Hour), now.Add(-32 * time.Hour), now.Add(-8 * time.Hour)}
+	onTime := []time.Time{now.Add(-time.Minute)}
+	tests := []struct {
+		catchUp string
+		due     []time.Time
+		want    int
+	}{
+		{"", missed, 1},
+		{"once", missed, 1},
+		{"all", missed, 3},
+		{"none", missed, 0},
+		{"none", onTime, 1},
+		{"once", nil, 0},
+	}
+	for _, tt := range tests {
+		runs := scheduledRuns(tt.catchUp, tt.due, now)
+		if len(runs) != tt.want {
+			t.Errorf("scheduledRuns(%q) ran %d times, want %d", tt.catchUp, len(runs), tt.want)
+		}
+		if len(runs) > 0 && runs[len(runs)-1] != tt.due[len(tt.due)-1] {
+			t.Errorf("scheduledRuns(%q) should run the latest time", tt.catchUp)
+		}
+	}
+}
+
+func TestScheduleArgs(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	at := time.Date(2024, 3, 1, 18, 0, 0, 0, time.Local) // a Friday
+	got := scheduleArgs("review --from <week_start> --to <date>  -o ~/reviews/<month_start>.md gen <yesterday>", at)
+	want := "review --from 2024-02-26 --to 2024-03-01 -o /home/me/reviews/2024-03-01.md gen 2024-02-29"
+	if strings.Join(got, " ") != want {
+		t.Errorf("scheduleArgs = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestCheckSchedule(t *testing.T) {
+	problems := checkSchedule(map[string]ScheduleTask{
+		"ok":    {At: "weekly Sun 18:00", Run: "gen <date>", CatchUp: "all"},
+		"bad":   {At: "sometime", Run: "gen <today>", CatchUp: "twice"},
+		"empty": {At: "03:00"},
+		"start": {At: "03:00", Run: "start"},
+	})
+	want := []string{
+		`schedule.bad.at: invalid at "sometime"`,
+		"schedule.bad.run: unknown variable <today>",
+		`schedule.bad.catch_up: invalid value "twice"`,
+		"schedule.empty.run: is empty",
+		"schedule.start.run: must not start the server",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("checkSchedule = %q, want %d problems", problems, len(want))
+	}
+	for i, p := range problems {
+		if !strings.HasPrefix(p, want[i]) {
+			t.Errorf("problem %d = %q, want it to start with %q", i, p, want[i])
+		}
+	}
+}
+
+func TestRunSchedule(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	// The mock devlog records the arguments of each run.
+	calls := filepath.Join(tmp, "calls")
+	exe := filepath.Join(tmp, "devlog")
+	os.WriteFile(exe, []byte("#!/bin/sh\necho \"$@\" >> "+calls+"\n"), 0o755)
+	orig := scheduleExecutable
+	scheduleExecutable = func() (string, error) { return exe, nil }
+	defer func() { scheduleExecutable = orig }()
+
+	s := newServer(Config{Schedule: map[string]ScheduleTask{
+		"nightly":  {At: "23:30", Run: "gen <date>"},
+		"backfill": {At: "23:30", Run: "gen <date>", CatchUp: "all"},
+		"fresh":    {At: "23:30", Run: "gen <date>"},
+	}})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer s.cancel()
+
+	now := time.Date(2024, 1, 18, 8, 0, 0, 0, time.Local)
+	last := time.Date(2024, 1, 15, 23, 30, 0, 0, time.Local)
+	marks := scheduleMarks{LastRun: map[string]time.Time{"nightly": last, "backfill": last, "removed": last}}
+	s.runSchedule(&marks, now, map[string]bool{})
+
+	data, _ := os.ReadFile(calls)
+	want := "gen 2024-01-16\ngen 2024-01-17\ngen 2024-01-17\n"
+	if string(data) != want {
+		t.Errorf("runs:\n%s\nwant:\n%s", data, want)
+	}
+	if _, ok := marks.LastRun["removed"]; ok {
+		t.Error("the mark of a removed task should be dropped")
+	}
+	if !marks.LastRun["fresh"].Equal(now) {
+		t.Errorf("a new task should be marked as of now, got %v", marks.LastRun["fresh"])
+	}
+
+	// The marks are saved, and nothing is due again until the next time.
+	saved, err := loadScheduleMarks()
+	if err != nil || !saved.LastRun["nightly"].Equal(last.AddDate(0, 0, 2)) {
+		t.Errorf("saved marks = %+v, %v", saved, err)
+	}
+	os.Remove(calls)
+	s.runSchedule(&saved, now.Add(time.Hour), map[string]bool{})
+	if _, err := os.Stat(calls); !os.IsNotExist(err) {
+		t.Error("no task should run before its next time")
+	}
+}
@@ -0,0 +1,187 @@
+package devlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// searchSnippetLen is the longest snippet shown for a search result.
+const searchSnippetLen = 120
+
+// searchDoc is one searchable piece of history: a project's section of a
+// day's summary, or a note entry.
+type searchDoc struct {
+	Date     string
+	Kind     string   // "summary" or "note"
+	Projects []string // the section's project, or the note's project tags
+	Text     string
+}
+
+// searchHit is a document that matched a query, with its relevance.
+type searchHit struct {
+	Doc   searchDoc
+	Score float64
+}
+
+// collectSearchDocs returns the summary sections and note entries on dates,
+// limited to project if it is set ("general" selects untagged notes).
+func collectSearchDocs(cfg Config, dates []string, project string) []searchDoc {
+	var docs []searchDoc
+	for _, date := range dates {
+		if content, err := readSummary(cfg, date); err == nil {
+			for _, s := range splitSummary(content) {
+				if project == "" || s.Project == project {
+					docs = append(docs, searchDoc{Date: date, Kind: "summary", Projects: []string{s.Project}, Text: s.Text})
+				}
+			}
+		}
+		for _, day := range collectNotes(cfg, []string{date}, project) {
+			for _, entry := range splitNoteEntries(cfg.noteHeadings(), day.Content) {
+				heading, _, _ := strings.Cut(entry, "\n")
+				tags, _ := noteHeadingTags(cfg.noteHeadings(), heading)
+				docs = append(docs, searchDoc{Date: date, Kind: "note", Projects: tags, Text: entry})
+			}
+		}
+	}
+	return docs
+}
+
+// keywordSearch returns the documents containing every word of query, case
+// insensitively, scored by how often the words occur.
+func keywordSearch(docs []searchDoc, query string) []searchHit {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil
+	}
+	var hits []searchHit
+	for _, d := range docs {
+		lower := strings.ToLower(d.Text)
+		score := 0
+		for _, w := range words {
+			n := strings.Count(lower, w)
+			if n == 0 {
+				score = 0
+				break
+			}
+			score += n
+		}
+		if score > 0 {
+			hits = append(hits, searchHit{Doc: d, Score: float64(score)})
+		}
+	}
+	return hits
+}
+
+// rankDays keeps the best hit of each date and returns at most n of them,
+// most relevant first. Ties go to the later date.
+func rankDays(hits []searchHit, n int) []searchHit {
+	best := make(map[string]searchHit)
+	for _, h := range hits {
+		if b, ok := best[h.Doc.Date]; !ok || h.Score > b.Score {
+			best[h.Doc.Date] = h
+		}
+	}
+	days := make([]searchHit, 0, len(best))
+	for _, h := range best {
+		days = append(days, h)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].Score != days[j].Score {
+			return days[i].Score > days[j].Score
+		}
+		return days[i].Doc.Date > days[j].Doc.Date
+	})
+	if n > 0 && len(days) > n {
+		days = days[:n]
+	}
+	return days
+}
+
+// searchHistory finds the documents on dates, limited to project if set,
+// that match query. Semantic search first brings the embedding index up to
+// date with all of history, embedding new documents with embed_cmd.
+func searchHistory(cfg Config, query string, dates []string, project string, semantic bool, p *genProgress) ([]searchHit, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("query is required")
+	}
+	docs := collectSearchDocs(cfg, dates, project)
+	if !semantic {
+		return keywordSearch(docs, query), nil
+	}
+
+	embed := func(text string) ([]float32, error) { return embedText(cfg.EmbedCmd, text) }
+	path := resolveEmbeddingIndexPath()
+	idx, err := loadEmbeddingIndex(path, cfg.EmbedCmd)
+	if err != nil {
+		return nil, err
+	}
+	added, err := updateIndex(&idx, collectSearchDocs(cfg, rawDataDates(cfg), ""), embed, p)
+	if added > 0 || err != nil {
+		if saveErr := saveEmbeddingIndex(path, idx); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	vec, err := embed(query)
+	if err != nil {
+		return nil, err
+	}
+	return semanticSearch(idx, docs, vec), nil
+}
+
+// searchSnippet returns the first line of text that contains a word of
+// query, or else its first line of content, shortened for display.
+func searchSnippet(headings *noteHeadingFormat, text, query string) string {
+	words := strings.Fields(strings.ToLower(query))
+	var first string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isNoteHeading(headings, line) {
+			continue
+		}
+		if first == "" {
+			first = line
+		}
+		lower := strings.ToLower(line)
+		for _, w := range words {
+			if strings.Contains(lower, w) {
+				return shorten(line, searchSnippetLen)
+			}
+		}
+	}
+	return shorten(first, searchSnippetLen)
+}
+
+// shorten cuts s to at most max bytes at a word boundary, marking the cut
+// with an ellipsis.
+func shorten(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := strings.LastIndex(s[:max], " ")
+	if cut <= 0 {
+		cut = max
+	}
+	return s[:cut] + "…"
+}
+
+// printSearchResults writes one entry per day: the date, score, and where
+// the best match is, followed by a snippet of it.
+func printSearchResults(w io.Writer, headings *noteHeadingFormat, days []searchHit, query string, semantic bool) {
+	for _, h := range days {
+		where := h.Doc.Kind
+		if len(h.Doc.Projects) > 0 {
+			where = strings.Join(h.Doc.Projects, ", ") + " " + where
+		}
+		score := fmt.Sprintf("%.0f", h.Score)
+		if semantic {
+			score = fmt.Sprintf("%.2f", h.Score)
+		}
+		fmt.Fprintf(w, "%s  %s  %s\n    %s\n", h.Doc.Date, score, where, searchSnippet(headings, h.Doc.Text, query))
+	}
+}
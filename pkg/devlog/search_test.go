@@ -0,0 +1,80 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSearchFixtures writes summaries and notes for two days under tmp.
+func writeSearchFixtures(t *testing.T, tmp string) Config {
+	t.Helper()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	cfg := Config{LogDir: filepath.Join(tmp, "log")}
+	os.MkdirAll(cfg.LogDir, 0o755)
+	os.MkdirAll(filepath.Join(rawDir, "2024-01-16"), 0o755)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"),
+		[]byte("# 2024-01-15\n\n## alpha\n\nFixed the migration ordering bug.\nMigrations now sort by timestamp.\n\n## beta\n\nTuned the websocket reconnect backoff.\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "notes.md"),
+		[]byte("### At 10:00 #beta #bug\nreconnect races with the websocket close handler\n\n### At 11:00\nlunch with the team\n"), 0o644)
+	return cfg
+}
+
+func TestCollectSearchDocs(t *testing.T) {
+	cfg := writeSearchFixtures(t, t.TempDir())
+	dates := []string{"2024-01-15", "2024-01-16"}
+
+	docs := collectSearchDocs(cfg, dates, "")
+	if len(docs) != 4 {
+		t.Fatalf("expected 4 documents, got %+v", docs)
+	}
+	if d := docs[2]; d.Kind != "note" || d.Date != "2024-01-16" || strings.Join(d.Projects, ",") != "beta" {
+		t.Errorf("unexpected note document %+v", d)
+	}
+	if docs := collectSearchDocs(cfg, dates, "beta"); len(docs) != 2 {
+		t.Errorf("expected 2 beta documents, got %+v", docs)
+	}
+}
+
+func TestKeywordSearch(t *testing.T) {
+	cfg := writeSearchFixtures(t, t.TempDir())
+	docs := collectSearchDocs(cfg, []string{"2024-01-15", "2024-01-16"}, "")
+
+	hits := keywordSearch(docs, "Websocket reconnect")
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %+v", hits)
+	}
+	days := rankDays(hits, 10)
+	// Both days score 2; the later day comes first.
+	if len(days) != 2 || days[0].Doc.Date != "2024-01-16" {
+		t.Errorf("unexpected ranking %+v", days)
+	}
+	if got := rankDays(hits, 1); len(got) != 1 {
+		t.Errorf("expected the limit to apply, got %d days", len(got))
+	}
+	if hits := keywordSearch(docs, "migration websocket"); len(hits) != 0 {
+		t.Errorf("expected every word to be required, got %+v", hits)
+	}
+
+	var buf bytes.Buffer
+	printSearchResults(&buf, nil, rankDays(keywordSearch(docs, "timestamp"), 10), "timestamp", false)
+	if buf.String() != "2024-01-15  1  alpha summary\n    Migrations now sort by timestamp.\n" {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	text := "### At 10:00 #beta\nfirst line\nthe websocket closed"
+	if got := searchSnippet(nil, text, "WEBSOCKET"); got != "the websocket closed" {
+		t.Errorf("got %q", got)
+	}
+	if got := searchSnippet(nil, text, "nothing"); got != "first line" {
+		t.Errorf("got %q", got)
+	}
+	if got := shorten("one two three", 8); got != "one two…" {
+		t.Errorf("shorten: got %q", got)
+	}
+}
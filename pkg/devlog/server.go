@@ -0,0 +1,966 @@
+package devlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type Server struct {
+	cfg       Config
+	logger    *slog.Logger
+	logLevel  *slog.LevelVar
+	logPath   string // server log file, or "" when logging to stderr
+	mu        sync.RWMutex
+	watched   []WatchEntry
+	prevHash  map[string]string          // repoPath -> diffHash of the last snapshot on lastDate
+	seenHunks map[string]map[string]bool // repoPath -> hunks written on lastDate, see collapseHunks
+	deltas    map[string]*snapshotDelta  // repoPath -> delta state on lastDate
+	lastDate  string
+	reloadCh  chan struct{}         // signals snapshotLoop to pick up a new interval
+	lastTick  time.Time             // end of the last completed snapshot cycle
+	repoState map[string]RepoStatus // repoPath -> last snapshot outcome
+	notifier  *notifier             // desktop notifications, nil without D-Bus
+	onBattery bool                  // see startPowerMonitor
+	unwatched []UnwatchedRepo       // repos of recent Claude Code sessions, see checkClaudeRepos
+	listener  net.Listener
+	inFlight  sync.WaitGroup             // the accept and snapshot loops and IPC handlers, see shutdown
+	gens      map[int]context.CancelFunc // in-flight generations, see startGen
+	nextGen   int
+	jobs      *genQueue
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// shutdownTimeout bounds how long shutdown waits for in-flight work.
+var shutdownTimeout = 10 * time.Second
+
+// maintenanceInterval is how often the server runs its maintenance tasks.
+const maintenanceInterval = time.Hour
+
+func newServer(cfg Config) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	level, _ := parseLogLevel(cfg.LogLevel)
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(level)
+
+	// Pick up dedup where a server that ran earlier today left off.
+	today := time.Now().Format("2006-01-02")
+	hashes := make(map[string]string)
+	if marks, err := loadSnapshotMarks(); err == nil && marks.Date == today && marks.Hashes != nil {
+		hashes = marks.Hashes
+	}
+
+	return &Server{
+		cfg:       cfg,
+		logger:    newLogger(cfg, os.Stderr, logLevel),
+		logLevel:  logLevel,
+		reloadCh:  make(chan struct{}, 1),
+		prevHash:  hashes,
+		seenHunks: make(map[string]map[string]bool),
+		deltas:    make(map[string]*snapshotDelta),
+		repoState: make(map[string]RepoStatus),
+		lastDate:  today,
+		jobs:      newGenQueue(),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+func (s *Server) run() error {
+	// Check PID file
+	if pid, err := readPidFile(); err == nil {
+		if isProcessRunning(pid) {
+			fmt.Fprintf(os.Stderr, "devlog server is already running (PID %d)\n", pid)
+			return nil
+		}
+		// Stale PID file
+		os.Remove(pidFilePath())
+	}
+
+	// Write PID file
+	pidPath := pidFilePath()
+	if err := prepareRuntimeDir(filepath.Dir(pidPath)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(pidPath, []byte(fmt.Sprintf("%d", os.Getpid())), 0o644); err != nil {
+		return fmt.Errorf("writing PID file: %w", err)
+	}
+	defer os.Remove(pidPath)
+
+	// Redirect logging to the server log file, if configured
+	if s.cfg.ServerLog != "" {
+		w, err := openRotatingWriter(s.cfg.ServerLog, int64(s.cfg.ServerLogMaxMB)*1024*1024, s.cfg.ServerLogKeep)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+		s.logger = newLogger(s.cfg, w, s.logLevel)
+		s.logPath = s.cfg.ServerLog
+	}
+
+	// Create socket, replacing a stale one
+	sockPath := socketPath()
+	listener, err := listenIPC(sockPath)
+	if errors.Is(err, errServerRunning) {
+		fmt.Fprintln(os.Stderr, "devlog server is already running")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("creating socket: %w", err)
+	}
+	s.listener = listener
+	defer func() {
+		listener.Close()
+		os.Remove(sockPath)
+	}()
+
+	// Load persisted state
+	state, _ := loadState()
+	s.mu.Lock()
+	s.watched = state.Watched
+	s.mu.Unlock()
+
+	s.logger.Info("devlog server started", "pid", os.Getpid(), "repos", len(s.watched))
+
+	// One session bus connection is shared by the desktop integrations.
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		s.logger.Info("D-Bus session bus unavailable, desktop integration disabled", "err", err)
+	} else {
+		defer conn.Close()
+	}
+	krunnerCleanup := startKRunner(s, conn)
+	gnomeCleanup := startGnomeSearch(s, conn)
+	s.notifier = newNotifier(conn)
+	powerCleanup := startPowerMonitor(s)
+
+	// Signal handling
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go s.reloadLoop(hupCh)
+
+	// Start socket listener goroutine. It is in flight itself, so that the
+	// handlers it starts are counted before shutdown waits.
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.acceptLoop()
+	}()
+
+	// Start snapshot ticker goroutine
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.snapshotLoop()
+	}()
+
+	// Start maintenance goroutine
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.maintenanceLoop()
+	}()
+
+	// Start the generation job queue
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.jobLoop()
+	}()
+
+	// Start the scheduler of [schedule] tasks
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		s.scheduleLoop()
+	}()
+
+	apiCleanup := startAPI(s)
+
+	// Wait for shutdown signal or context cancellation
+	select {
+	case sig := <-sigCh:
+		s.logger.Info("shutting down", "signal", sig.String())
+	case <-s.ctx.Done():
+		s.logger.Info("shutting down")
+	}
+	s.shutdown()
+
+	if krunnerCleanup != nil {
+		krunnerCleanup()
+	}
+	if gnomeCleanup != nil {
+		gnomeCleanup()
+	}
+	if apiCleanup != nil {
+		apiCleanup()
+	}
+	if powerCleanup != nil {
+		powerCleanup()
+	}
+	return nil
+}
+
+// shutdown stops the server without losing work: it stops accepting
+// connections, waits up to shutdownTimeout for the current snapshot pass and
+// IPC requests to finish, and takes a final snapshot so that the changes
+// since the last tick are recorded.
+func (s *Server) shutdown() {
+	s.cancel()
+	s.listener.Close()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		s.logger.Warn("in-flight work did not finish, skipping final snapshot", "timeout", shutdownTimeout)
+		return
+	}
+	s.logger.Info("taking final snapshot")
+	s.takeSnapshots(true)
+}
+
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.ctx.Done():
+				return
+			default:
+				s.logger.Error("accept failed", "err", err)
+				continue
+			}
+		}
+		s.inFlight.Add(1)
+		go func() {
+			defer s.inFlight.Done()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	enc := json.NewEncoder(conn)
+	if err := checkPeer(conn); err != nil {
+		s.logger.Warn("rejected IPC connection", "err", err)
+		enc.Encode(IPCResponse{OK: false, Error: "permission denied"})
+		return
+	}
+
+	var req IPCRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		if err != io.EOF {
+			enc.Encode(IPCResponse{OK: false, Error: "invalid request"})
+		}
+		return
+	}
+
+	var resp IPCResponse
+	switch req.Command {
+	case "watch":
+		resp = s.handleWatch(req)
+	case "unwatch":
+		resp = s.handleUnwatch(req)
+	case "watch_batch":
+		resp = s.handleWatchBatch(req)
+	case "unwatch_batch":
+		resp = s.handleUnwatchBatch(req)
+	case "rename":
+		resp = s.handleRename(req)
+	case "status":
+		resp = s.handleStatus()
+	case "ping":
+		resp = s.handlePing()
+	case "reload":
+		resp = s.handleReload()
+	case "stop":
+		resp = s.handleStop()
+	case "gen":
+		resp = s.handleGen(req)
+	case "jobs":
+		resp = s.handleJobs()
+	case "cancel_gen":
+		resp = s.handleCancelGen()
+	default:
+		resp = IPCResponse{OK: false, Error: "unknown command: " + req.Command}
+	}
+
+	enc.Encode(resp)
+}
+
+func (s *Server) handleWatch(req IPCRequest) IPCResponse {
+	var args WatchArgs
+	if err := json.Unmarshal(req.Args, &args); err != nil {
+		return IPCResponse{OK: false, Error: "invalid args: " + err.Error()}
+	}
+
+	// Resolve repo root
+	entry, err := resolveWatchEntry(args)
+	if err != nil {
+		return IPCResponse{OK: false, Error: err.Error()}
+	}
+	repoRoot := entry.Path
+
+	name := args.Name
+	if name == "" {
+		name = filepath.Base(repoRoot)
+	}
+	entry.Name = name
+	entry.Client = args.Client
+	entry.BillingCode = args.BillingCode
+	if _, err := applyWatchSettings(&entry, args.Set); err != nil {
+		return IPCResponse{OK: false, Error: err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watched, added, err := addWatched(s.watched, entry)
+	if err != nil {
+		return IPCResponse{OK: false, Error: err.Error()}
+	}
+	if !added {
+		// Already watched: only billing details and metadata can be updated.
+		if setBilling(s.watched, repoRoot, args.Client, args.BillingCode) {
+			s.persistState()
+			s.logger.Info("updated billing details", "path", repoRoot, "client", args.Client, "billing_code", args.BillingCode)
+		}
+		changed, err := updateWatched(s.watched, repoRoot, args.Set)
+		if err != nil {
+			return IPCResponse{OK: false, Error: err.Error()}
+		}
+		if changed {
+			s.persistState()
+			s.logger.Info("updated watch metadata", "path", repoRoot, "settings", args.Set)
+		}
+		return s.watchedResponse()
+	}
+
+	s.watched = watched
+	s.persistState()
+	s.logger.Info("watching repo", "project", name, "path", repoRoot, "plain", entry.Plain, "subdir", entry.Subdir)
+
+	return s.watchedResponse()
+}
+
+func (s *Server) handleWatchBatch(req IPCRequest) IPCResponse {
+	var args WatchBatchArgs
+	if err := json.Unmarshal(req.Args, &args); err != nil {
+		return IPCResponse{OK: false, Error: "invalid args: " + err.Error()}
+	}
+
+	// Resolve everything before taking the lock; this runs git commands.
+	var errs []BatchError
+	var entries []WatchEntry
+	for _, a := range args.Repos {
+		entry, err := resolveWatchEntry(a)
+		if err != nil {
+			errs = append(errs, BatchError{Path: a.Path, Error: err.Error()})
+			continue
+		}
+		entry.Name = a.Name
+		if entry.Name == "" {
+			entry.Name = filepath.Base(entry.Path)
+		}
+		entries = append(entries, entry)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for _, entry := range entries {
+		watched, added, err := addWatched(s.watched, entry)
+		if err != nil {
+			errs = append(errs, BatchError{Path: entry.Path, Error: err.Error()})
+			continue
+		}
+		if added {
+			s.watched = watched
+			changed = true
+			s.logger.Info("watching repo", "project", entry.Name, "path", entry.Path, "plain", entry.Plain, "subdir", entry.Subdir)
+		}
+	}
+	if changed {
+		s.persistState()
+	}
+
+	data, _ := json.Marshal(WatchResponseData{Watched: s.watched, Errors: errs})
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+func (s *Server) handleUnwatch(req IPCRequest) IPCResponse {
+	var args UnwatchArgs
+	if err := json.Unmarshal(req.Args, &args); err != nil {
+		return IPCResponse{OK: false, Error: "invalid args: " + err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repoRoot, err := resolveUnwatchPath(args.Path, s.watched)
+	if err != nil {
+		return IPCResponse{OK: false, Error: err.Error()}
+	}
+
+	if !s.unwatchLocked(repoRoot) {
+		return s.watchedResponse()
+	}
+
+	s.persistState()
+	return s.watchedResponse()
+}
+
+func (s *Server) handleUnwatchBatch(req IPCRequest) IPCResponse {
+	var args UnwatchBatchArgs
+	if err := json.Unmarshal(req.Args, &args); err != nil {
+		return IPCResponse{OK: false, Error: "invalid args: " + err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paths := args.Paths
+	if args.All {
+		paths = nil
+		for _, w := range s.watched {
+			paths = append(paths, w.Path)
+		}
+	}
+
+	var errs []BatchError
+	changed := false
+	for _, p := range paths {
+		repoRoot, err := resolveUnwatchPath(p, s.watched)
+		if err != nil {
+			errs = append(errs, BatchError{Path: p, Error: err.Error()})
+			continue
+		}
+		if s.unwatchLocked(repoRoot) {
+			changed = true
+		}
+	}
+	if changed {
+		s.persistState()
+	}
+
+	data, _ := json.Marshal(WatchResponseData{Watched: s.watched, Errors: errs})
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+// unwatchLocked stops watching repoRoot and reports whether it was watched.
+// The caller must hold s.mu and persist state.
+func (s *Server) unwatchLocked(repoRoot string) bool {
+	watched, found := removeWatched(s.watched, repoRoot)
+	if !found {
+		return false
+	}
+	s.watched = watched
+	delete(s.prevHash, repoRoot)
+	delete(s.seenHunks, repoRoot)
+	delete(s.deltas, repoRoot)
+	delete(s.repoState, repoRoot)
+	s.logger.Info("stopped watching repo", "path", repoRoot)
+	return true
+}
+
+func (s *Server) handleRename(req IPCRequest) IPCResponse {
+	var args RenameArgs
+	if err := json.Unmarshal(req.Args, &args); err != nil {
+		return IPCResponse{OK: false, Error: "invalid args: " + err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found, err := renameWatchEntry(s.watched, args.OldName, args.NewName)
+	if err != nil {
+		return IPCResponse{OK: false, Error: err.Error()}
+	}
+	if found {
+		s.persistState()
+		s.logger.Info("renamed project", "old", args.OldName, "new", args.NewName)
+	}
+	return s.watchedResponse()
+}
+
+func (s *Server) handleStatus() IPCResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := StatusData{
+		Watched: s.watched,
+		PID:     os.Getpid(),
+		LogPath: s.logPath,
+		Repos:   s.repoStatuses(),
+		// Repos watched since the last check are no longer suggested.
+		Unwatched: s.stillUnwatched(),
+		OnBattery: s.onBattery,
+	}
+	if s.cfg.StaleWatchDays > 0 {
+		status.Stale = staleWatched(s.cfg, s.watched, s.cfg.StaleWatchDays, time.Now())
+	}
+	data, _ := json.Marshal(status)
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+// stillUnwatched returns the repos of s.unwatched that are still not
+// watched. The caller must hold s.mu.
+func (s *Server) stillUnwatched() []UnwatchedRepo {
+	var repos []UnwatchedRepo
+	for _, r := range s.unwatched {
+		if !watchesPath(s.watched, r.Path) {
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+func (s *Server) handlePing() IPCResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ping := PingData{
+		PID:              os.Getpid(),
+		SnapshotInterval: s.snapshotInterval(),
+		Repos:            s.repoStatuses(),
+		OnBattery:        s.onBattery,
+	}
+	if !s.lastTick.IsZero() {
+		t := s.lastTick
+		ping.LastTickAt = &t
+	}
+	data, _ := json.Marshal(ping)
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+// repoStatuses returns the last snapshot outcome for each watched repo, in
+// watch order. The caller must hold s.mu.
+func (s *Server) repoStatuses() []RepoStatus {
+	statuses := make([]RepoStatus, 0, len(s.watched))
+	for _, w := range s.watched {
+		rs, ok := s.repoState[w.Path]
+		if !ok {
+			rs = RepoStatus{Path: w.Path, Name: w.Name}
+		}
+		statuses = append(statuses, rs)
+	}
+	return statuses
+}
+
+func (s *Server) handleReload() IPCResponse {
+	if err := s.reload(); err != nil {
+		return IPCResponse{OK: false, Error: err.Error()}
+	}
+	data, _ := json.Marshal(struct{}{})
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+func (s *Server) reloadLoop(hupCh <-chan os.Signal) {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-hupCh:
+			if err := s.reload(); err != nil {
+				s.logger.Error("reload failed, keeping previous configuration", "err", err)
+			}
+		}
+	}
+}
+
+// reload re-reads config.toml and applies it to the running server. The
+// snapshot interval, raw data paths, and log level take effect immediately;
+// dedup state is kept. Changes to log_format, server_log, and api_addr
+// settings only take effect after a restart.
+func (s *Server) reload() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	level, _ := parseLogLevel(cfg.LogLevel)
+
+	s.mu.Lock()
+	old := s.cfg
+	s.cfg = cfg
+	s.mu.Unlock()
+
+	s.logLevel.Set(level)
+	if cfg.LogFormat != old.LogFormat || cfg.ServerLog != old.ServerLog ||
+		cfg.ServerLogMaxMB != old.ServerLogMaxMB || cfg.ServerLogKeep != old.ServerLogKeep ||
+		cfg.APIAddr != old.APIAddr {
+		s.logger.Warn("log_format, server_log, and api_addr changes take effect after a restart")
+	}
+
+	s.wakeSnapshotLoop()
+	s.logger.Info("configuration reloaded", "snapshot_interval", cfg.SnapshotInterval)
+	return nil
+}
+
+func (s *Server) handleStop() IPCResponse {
+	// Schedule shutdown after responding
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.cancel()
+	}()
+	data, _ := json.Marshal(struct{}{})
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+func (s *Server) watchedResponse() IPCResponse {
+	data, _ := json.Marshal(WatchResponseData{Watched: s.watched})
+	return IPCResponse{OK: true, Data: json.RawMessage(data)}
+}
+
+// watchedSnapshot returns a copy of the watched list, for readers outside
+// the IPC handlers such as the D-Bus integrations.
+func (s *Server) watchedSnapshot() []WatchEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	watched := make([]WatchEntry, len(s.watched))
+	copy(watched, s.watched)
+	return watched
+}
+
+func (s *Server) persistState() {
+	state := State{Watched: s.watched}
+	if err := saveState(state); err != nil {
+		s.logger.Warn("failed to save state", "err", err)
+	}
+}
+
+// wakeSnapshotLoop has snapshotLoop pick up a new snapshot interval. It does
+// not block; one pending signal is enough.
+func (s *Server) wakeSnapshotLoop() {
+	select {
+	case s.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+// snapshotInterval returns the snapshot interval in effect, in seconds:
+// battery_snapshot_interval while on battery, if set, and snapshot_interval
+// otherwise. The caller must hold s.mu.
+func (s *Server) snapshotInterval() int {
+	if s.onBattery && s.cfg.BatterySnapshotInterval > 0 {
+		return s.cfg.BatterySnapshotInterval
+	}
+	return s.cfg.SnapshotInterval
+}
+
+func (s *Server) snapshotLoop() {
+	// Take an initial snapshot immediately
+	s.takeSnapshots(false)
+
+	s.mu.RLock()
+	interval := time.Duration(s.snapshotInterval()) * time.Second
+	s.mu.RUnlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	period := interval // of the ticker, which may be set to a first wait
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.reloadCh:
+			s.mu.RLock()
+			newInterval := time.Duration(s.snapshotInterval()) * time.Second
+			sinceLast := time.Since(s.lastTick)
+			s.mu.RUnlock()
+			if newInterval != interval {
+				now, wait := snapshotWait(interval, newInterval, sinceLast)
+				if now {
+					s.takeSnapshots(false)
+				}
+				interval, period = newInterval, wait
+				ticker.Reset(period)
+			}
+		case <-ticker.C:
+			s.takeSnapshots(false)
+			if period != interval {
+				period = interval
+				ticker.Reset(period)
+			}
+		}
+	}
+}
+
+// snapshotWait returns, for a snapshot interval changed from from to to,
+// sinceLast after the last snapshot cycle, whether to snapshot now and how
+// long to wait for the next snapshot. A shorter interval, e.g. back on AC
+// power, that has already passed is due now; otherwise the next snapshot is
+// the new interval after the last one. A longer one starts afresh.
+func snapshotWait(from, to, sinceLast time.Duration) (now bool, wait time.Duration) {
+	switch {
+	case to > from:
+		return false, to
+	case sinceLast >= to:
+		return true, to
+	default:
+		return false, to - sinceLast
+	}
+}
+
+// maintenanceLoop runs maintain at startup and every maintenanceInterval.
+func (s *Server) maintenanceLoop() {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+	for {
+		s.maintain()
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// maintain runs the maintenance tasks: looking for Claude Code sessions in
+// unwatched repos and archiving the raw data older than archive_after_days.
+func (s *Server) maintain() {
+	s.mu.RLock()
+	cfg := s.cfg
+	s.mu.RUnlock()
+	s.checkClaudeRepos(cfg)
+	if cfg.ArchiveAfterDays <= 0 {
+		return
+	}
+	if s.deferWork() {
+		s.logger.Info("deferring archiving of old raw data while on battery")
+		return
+	}
+	archived, err := archiveOldRaw(s.ctx, cfg, cfg.ArchiveAfterDays, time.Now())
+	if len(archived) > 0 {
+		s.logger.Info("archived old raw data", "days", len(archived), "dir", rawArchiveDir(cfg))
+	}
+	if err != nil {
+		s.logger.Warn("archiving old raw data failed", "err", err)
+	}
+}
+
+// checkClaudeRepos looks for repos with recent Claude Code sessions that are
+// not watched. With auto_watch_claude they are watched; otherwise they are
+// kept for `devlog status` to suggest.
+func (s *Server) checkClaudeRepos(cfg Config) {
+	claudeDir := resolveClaudeCodeDir(cfg)
+	if claudeDir == "" {
+		return
+	}
+	repos := unwatchedClaudeRepos(claudeDir, s.watchedSnapshot(), time.Now().Add(-claudeSuggestWindow))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !cfg.AutoWatchClaude {
+		s.unwatched = repos
+		return
+	}
+	var kept []UnwatchedRepo
+	changed := false
+	for _, r := range repos {
+		entry := WatchEntry{Path: r.Path, Name: filepath.Base(r.Path)}
+		watched, added, err := addWatched(s.watched, entry)
+		if err != nil {
+			s.logger.Warn("cannot watch repo of Claude Code sessions", "path", r.Path, "err", err)
+			kept = append(kept, r)
+			continue
+		}
+		if added {
+			s.watched = watched
+			changed = true
+			s.logger.Info("watching repo of Claude Code sessions", "project", entry.Name, "path", entry.Path)
+		}
+	}
+	if changed {
+		s.persistState()
+	}
+	s.unwatched = kept
+}
+
+// snapshotDedup returns the hash of entry's last snapshot today and the
+// snapshot options for it under cfg, with the dedup state it keeps across
+// snapshots.
+func (s *Server) snapshotDedup(cfg Config, entry WatchEntry) (string, snapshotOptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	opts := snapshotOptions{
+		Ignore:           cfg.Projects[entry.Name].Ignore,
+		IgnoreWhitespace: cfg.IgnoreWhitespace,
+	}
+	if cfg.CollapseHunks {
+		if s.seenHunks[entry.Path] == nil {
+			s.seenHunks[entry.Path] = make(map[string]bool)
+		}
+		opts.SeenHunks = s.seenHunks[entry.Path]
+	}
+	if cfg.SnapshotDelta {
+		if s.deltas[entry.Path] == nil {
+			s.deltas[entry.Path] = &snapshotDelta{}
+		}
+		opts.Delta = s.deltas[entry.Path]
+		opts.Delta.KeyframeEvery = cfg.KeyframeEvery
+	} else {
+		delete(s.deltas, entry.Path)
+	}
+	return s.prevHash[entry.Path], opts
+}
+
+// takeSnapshots snapshots each watched repo that is due (see snapshotDue),
+// or every one if all is set.
+func (s *Server) takeSnapshots(all bool) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+
+	// The dedup state is guarded by s.mu, since unwatching a repo from a
+	// connection handler deletes from it.
+	s.mu.Lock()
+	// Date boundary: reset dedup state
+	if today != s.lastDate {
+		s.prevHash = make(map[string]string)
+		s.seenHunks = make(map[string]map[string]bool)
+		s.deltas = make(map[string]*snapshotDelta)
+		s.lastDate = today
+	}
+	cfg := s.cfg
+	repos := make([]WatchEntry, len(s.watched))
+	copy(repos, s.watched)
+	tick := time.Duration(s.snapshotInterval()) * time.Second
+	s.mu.Unlock()
+
+	changed := false
+	for _, entry := range repos {
+		if !all && !s.snapshotDue(entry, tick, now) {
+			continue
+		}
+		gitFile := resolveGitPath(cfg, today, entry.Name)
+		logger := s.logger.With("project", entry.Name, "path", entry.Path)
+		prevHash, opts := s.snapshotDedup(cfg, entry)
+		diff, err := snapshotEntry(entry, gitFile, prevHash, today, opts)
+		failures := s.recordSnapshot(entry, err)
+		if err != nil {
+			logger.Warn("snapshot failed", "err", err, "consecutive_failures", failures)
+			if failures == snapshotFailureNotifyThreshold && cfg.Notify {
+				s.notify(fmt.Sprintf("devlog: snapshots failing for %s", entry.Name),
+					fmt.Sprintf("%d snapshots in a row failed: %v", failures, err), notifyUrgencyCritical)
+			}
+			continue
+		}
+		hash := diffHash(diff)
+		switch {
+		case diff == "":
+			logger.Debug("snapshot skipped, no uncommitted changes")
+		case hash == prevHash:
+			logger.Debug("snapshot skipped, unchanged since last snapshot")
+		default:
+			logger.Debug("snapshot written", "file", gitFile, "bytes", len(diff))
+			s.mu.Lock()
+			s.prevHash[entry.Path] = hash
+			s.mu.Unlock()
+			changed = true
+			if entry.LastActive != today {
+				s.markActive(entry.Path, today)
+			}
+		}
+	}
+	if changed {
+		s.mu.RLock()
+		hashes := make(map[string]string, len(s.prevHash))
+		for path, hash := range s.prevHash {
+			hashes[path] = hash
+		}
+		s.mu.RUnlock()
+		if err := saveSnapshotMarks(snapshotMarks{Date: today, Hashes: hashes}); err != nil {
+			s.logger.Warn("saving snapshot dedup state failed", "err", err)
+		}
+	}
+	if cfg.IndexRaw {
+		if _, err := refreshRawIndex(cfg, []string{today}); err != nil {
+			s.logger.Warn("updating raw data index failed", "err", err)
+		}
+	}
+	if cfg.RawStore == "sqlite" {
+		if err := syncRawDB(cfg, today, now); err != nil {
+			s.logger.Warn("updating raw data database failed", "err", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.lastTick = time.Now()
+	s.mu.Unlock()
+}
+
+// markActive records date as the last activity of the entry for path (see
+// lastActive) and saves the state, which happens once a day per entry.
+func (s *Server) markActive(path, date string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.watched {
+		if s.watched[i].Path == path && s.watched[i].LastActive != date {
+			s.watched[i].LastActive = date
+			s.persistState()
+		}
+	}
+}
+
+// snapshotDue reports whether entry, if it has its own interval, is due for a
+// snapshot. Half a tick of slack keeps it from slipping to the tick after.
+func (s *Server) snapshotDue(entry WatchEntry, tick time.Duration, now time.Time) bool {
+	if entry.Interval <= 0 {
+		return true
+	}
+	s.mu.RLock()
+	last := s.repoState[entry.Path].LastSnapshotAt
+	s.mu.RUnlock()
+	return last == nil || now.Sub(*last) >= time.Duration(entry.Interval)*time.Second-tick/2
+}
+
+// recordSnapshot stores the outcome of a snapshot attempt for health checks
+// and returns the number of consecutive failures of the repo.
+func (s *Server) recordSnapshot(entry WatchEntry, err error) int {
+	now := time.Now()
+	rs := RepoStatus{Path: entry.Path, Name: entry.Name, LastSnapshotAt: &now}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		rs.LastError = err.Error()
+		rs.ConsecutiveFailures = s.repoState[entry.Path].ConsecutiveFailures + 1
+	}
+	// Skip repos that were unwatched while the snapshot was running.
+	for _, w := range s.watched {
+		if w.Path == entry.Path {
+			s.repoState[entry.Path] = rs
+			break
+		}
+	}
+	return rs.ConsecutiveFailures
+}
+
+// notify sends a desktop notification, logging rather than returning errors.
+func (s *Server) notify(summary, body string, urgency byte) {
+	if err := s.notifier.send(summary, body, urgency); err != nil {
+		s.logger.Warn("desktop notification failed", "err", err)
+	}
+}
@@ -0,0 +1,299 @@
+package devlog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerReload(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmp)
+	dir := filepath.Join(tmp, "devlog")
+	os.MkdirAll(dir, 0o755)
+	configPath := filepath.Join(dir, "config.toml")
+
+	cfg, _ := loadConfig()
+	s := newServer(cfg)
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer s.cancel()
+
+	os.WriteFile(configPath, []byte(`
+snapshot_interval = 60
+log_level = "debug"
+`), 0o644)
+	if err := s.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if s.cfg.SnapshotInterval != 60 {
+		t.Errorf("expected interval 60, got %d", s.cfg.SnapshotInterval)
+	}
+	if s.logLevel.Level() != slog.LevelDebug {
+		t.Errorf("expected debug level, got %v", s.logLevel.Level())
+	}
+	select {
+	case <-s.reloadCh:
+	default:
+		t.Error("expected snapshot loop to be signalled")
+	}
+
+	// An invalid config is rejected and the previous one kept.
+	os.WriteFile(configPath, []byte(`log_level = "loud"`), 0o644)
+	if err := s.reload(); err == nil {
+		t.Fatal("expected error for invalid log_level")
+	}
+	if s.cfg.SnapshotInterval != 60 || s.cfg.LogLevel != "debug" {
+		t.Errorf("config changed after failed reload: %+v", s.cfg)
+	}
+}
+
+func TestHandleWatchBatch(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	repoA := initTestRepo(t)
+	repoB := initTestRepo(t)
+
+	s := newServer(Config{SnapshotInterval: 300})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer s.cancel()
+
+	args, _ := json.Marshal(WatchBatchArgs{Repos: []WatchArgs{
+		{Path: repoA, Name: "a"},
+		{Path: repoB, Name: "a"},
+		{Path: t.TempDir()},
+	}})
+	resp := s.handleWatchBatch(IPCRequest{Command: "watch_batch", Args: args})
+	if !resp.OK {
+		t.Fatalf("watch_batch failed: %s", resp.Error)
+	}
+	var wd WatchResponseData
+	json.Unmarshal(resp.Data, &wd)
+	if len(wd.Watched) != 1 || wd.Watched[0].Path != repoA {
+		t.Errorf("unexpected watched list: %+v", wd.Watched)
+	}
+	if len(wd.Errors) != 2 {
+		t.Errorf("expected 2 errors (name conflict, not a repo), got %+v", wd.Errors)
+	}
+
+	state, _ := loadState()
+	if len(state.Watched) != 1 {
+		t.Errorf("expected state to be persisted with 1 entry, got %d", len(state.Watched))
+	}
+
+	args, _ = json.Marshal(UnwatchBatchArgs{All: true})
+	resp = s.handleUnwatchBatch(IPCRequest{Command: "unwatch_batch", Args: args})
+	json.Unmarshal(resp.Data, &wd)
+	if !resp.OK || len(wd.Watched) != 0 {
+		t.Errorf("expected nothing watched after unwatch_batch all, got %+v", wd.Watched)
+	}
+}
+
+func TestSnapshotDue(t *testing.T) {
+	s := newServer(Config{SnapshotInterval: 300})
+	defer s.cancel()
+	entry := WatchEntry{Path: "/repo", Name: "repo", Interval: 900}
+	tick := 300 * time.Second
+	now := time.Now()
+
+	if !s.snapshotDue(entry, tick, now) {
+		t.Error("expected a repo never snapshotted to be due")
+	}
+	last := now.Add(-10 * time.Minute)
+	s.repoState[entry.Path] = RepoStatus{LastSnapshotAt: &last}
+	if s.snapshotDue(entry, tick, now) {
+		t.Error("expected no snapshot 10 minutes into a 15 minute interval")
+	}
+	// Within half a tick of the interval counts as due.
+	if !s.snapshotDue(entry, tick, now.Add(4*time.Minute)) {
+		t.Error("expected a snapshot near the end of the interval")
+	}
+	if entry.Interval = 0; !s.snapshotDue(entry, tick, now) {
+		t.Error("expected a repo without an interval to be due every tick")
+	}
+}
+
+func TestRecordSnapshotFailures(t *testing.T) {
+	entry := WatchEntry{Path: "/home/user/dev/foo", Name: "foo"}
+	s := newServer(Config{SnapshotInterval: 300})
+	defer s.cancel()
+	s.watched = []WatchEntry{entry}
+
+	for i := 1; i <= 3; i++ {
+		if n := s.recordSnapshot(entry, errors.New("git failed")); n != i {
+			t.Errorf("failure %d: consecutive failures = %d", i, n)
+		}
+	}
+	if n := s.recordSnapshot(entry, nil); n != 0 {
+		t.Errorf("expected count reset after success, got %d", n)
+	}
+	if s.repoState[entry.Path].ConsecutiveFailures != 0 {
+		t.Errorf("expected no failures recorded, got %+v", s.repoState[entry.Path])
+	}
+
+	// A nil notifier (no D-Bus) sends nothing.
+	if err := s.notifier.send("summary", "body", notifyUrgencyNormal); err != nil {
+		t.Errorf("nil notifier send: %v", err)
+	}
+}
+
+func TestSnapshotDedupAcrossRestart(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	rawDir := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	repo := initTestRepo(t)
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+	entry := WatchEntry{Path: repo, Name: "proj"}
+
+	for i := 0; i < 2; i++ {
+		s := newServer(Config{SnapshotInterval: 300})
+		s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		s.watched = []WatchEntry{entry}
+		s.takeSnapshots(false)
+		s.cancel()
+	}
+
+	gitFile := resolveGitPath(Config{}, time.Now().Format("2006-01-02"), "proj")
+	content, _ := os.ReadFile(gitFile)
+	if n := strings.Count(string(content), "=== SNAPSHOT"); n != 1 {
+		t.Errorf("expected 1 snapshot after a restart with no changes, got %d", n)
+	}
+
+	// Marks from another day are not used.
+	marks, err := loadSnapshotMarks()
+	if err != nil || marks.Hashes[repo] == "" {
+		t.Fatalf("expected the snapshot to be recorded, got %+v, %v", marks, err)
+	}
+	marks.Date = "2000-01-01"
+	saveSnapshotMarks(marks)
+	if s := newServer(Config{SnapshotInterval: 300}); len(s.prevHash) != 0 {
+		t.Errorf("expected stale marks to be ignored, got %v", s.prevHash)
+	}
+}
+
+func TestTakeSnapshotsWhileUnwatching(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("DEVLOG_RAW_DIR", t.TempDir())
+	var entries []WatchEntry
+	for _, name := range []string{"a", "b", "c"} {
+		repo := initTestRepo(t)
+		os.WriteFile(filepath.Join(repo, "main.go"), []byte("package "+name+"\n"), 0o644)
+		entries = append(entries, WatchEntry{Path: repo, Name: name})
+	}
+	s := newServer(Config{SnapshotInterval: 300})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s.watched = entries
+
+	// Run with -race: the dedup state is shared with connection handlers.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.takeSnapshots(true)
+	}()
+	for _, entry := range entries {
+		s.mu.Lock()
+		s.unwatchLocked(entry.Path)
+		s.mu.Unlock()
+	}
+	<-done
+	s.cancel()
+}
+
+func TestShutdownTakesFinalSnapshot(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("DEVLOG_RAW_DIR", t.TempDir())
+	repo := initTestRepo(t)
+	entry := WatchEntry{Path: repo, Name: "proj"}
+	gitFile := resolveGitPath(Config{}, time.Now().Format("2006-01-02"), "proj")
+
+	newTestServer := func() *Server {
+		s := newServer(Config{SnapshotInterval: 300})
+		s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		s.watched = []WatchEntry{entry}
+		ln, err := listenIPC(filepath.Join(t.TempDir(), "devlog.sock"))
+		if err != nil {
+			t.Fatalf("listenIPC: %v", err)
+		}
+		s.listener = ln
+		return s
+	}
+
+	// Work in flight past the timeout skips the final snapshot.
+	old := shutdownTimeout
+	shutdownTimeout = 100 * time.Millisecond
+	defer func() { shutdownTimeout = old }()
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+	s := newTestServer()
+	s.inFlight.Add(1)
+	defer s.inFlight.Done()
+	start := time.Now()
+	s.shutdown()
+	if time.Since(start) < shutdownTimeout {
+		t.Error("shutdown did not wait for in-flight work")
+	}
+	if _, err := os.Stat(gitFile); err == nil {
+		t.Error("final snapshot taken while work was still in flight")
+	}
+
+	s = newTestServer()
+	s.shutdown()
+	content, err := os.ReadFile(gitFile)
+	if err != nil || !strings.Contains(string(content), "main.go") {
+		t.Errorf("expected a final snapshot of main.go, got %q, %v", content, err)
+	}
+}
+
+func TestCheckClaudeRepos(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	claudeDir := t.TempDir()
+	session := func(cwd string, mtime time.Time) {
+		dir := filepath.Join(claudeDir, repoPathToClaudeDir(cwd))
+		os.MkdirAll(dir, 0o755)
+		path := filepath.Join(dir, "s.jsonl")
+		os.WriteFile(path, []byte(`{"type":"summary"}`+"\n"+`{"type":"user","cwd":"`+cwd+`"}`+"\n"), 0o644)
+		os.Chtimes(path, mtime, mtime)
+	}
+	watched, recent, old := initTestRepo(t), initTestRepo(t), initTestRepo(t)
+	os.MkdirAll(filepath.Join(recent, "cmd"), 0o755)
+	session(watched, time.Now())
+	session(filepath.Join(recent, "cmd"), time.Now())
+	session(old, time.Now().AddDate(0, 0, -30))
+
+	s := newServer(Config{SnapshotInterval: 300, ClaudeCodeDir: &claudeDir})
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer s.cancel()
+	s.watched = []WatchEntry{{Path: watched, Name: "watched"}}
+
+	s.checkClaudeRepos(s.cfg)
+	var status StatusData
+	json.Unmarshal(s.handleStatus().Data, &status)
+	if len(status.Unwatched) != 1 || status.Unwatched[0].Path != recent {
+		t.Fatalf("Unwatched = %+v, want only %s", status.Unwatched, recent)
+	}
+
+	// Watching it drops the suggestion before the next check.
+	s.watched = append(s.watched, WatchEntry{Path: recent, Name: "recent"})
+	status = StatusData{}
+	json.Unmarshal(s.handleStatus().Data, &status)
+	if len(status.Unwatched) != 0 {
+		t.Errorf("Unwatched after watching = %+v, want none", status.Unwatched)
+	}
+
+	// With auto_watch_claude, the repo is watched instead.
+	s.watched = s.watched[:1]
+	s.cfg.AutoWatchClaude = true
+	s.checkClaudeRepos(s.cfg)
+	if len(s.watched) != 2 || s.watched[1].Path != recent || s.watched[1].Name != filepath.Base(recent) {
+		t.Errorf("watched = %+v, want %s added", s.watched, recent)
+	}
+	if len(s.unwatched) != 0 {
+		t.Errorf("unwatched = %+v, want none", s.unwatched)
+	}
+	if state, _ := loadState(); len(state.Watched) != 2 {
+		t.Errorf("expected state to be persisted with 2 entries, got %d", len(state.Watched))
+	}
+}
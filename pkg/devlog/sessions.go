@@ -0,0 +1,205 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sessionsFile is the name under which a project's work sessions are given
+// to the summarizer.
+const sessionsFile = "sessions.md"
+
+// resolveSessionsPath returns the file recording the work sessions started
+// and stopped on date, one event per line: "HH:MM start <project> [reason]"
+// or "HH:MM stop <project> [reason]".
+func resolveSessionsPath(cfg Config, date string) string {
+	return filepath.Join(resolveRawDir(cfg), date, "sessions.log")
+}
+
+// sessionEvent is a line of a sessions file.
+type sessionEvent struct {
+	Clock   string // HH:MM
+	Kind    string // "start" or "stop"
+	Project string
+	Reason  string
+}
+
+// workSession is an explicit work session on a project within one date.
+type workSession struct {
+	Project     string
+	Start, End  time.Time
+	Reason      string // given when it was started
+	StopReason  string // given when it was stopped
+	Open        bool   // not stopped; End is the end of the day, or now
+	FromPrevDay bool   // stopped without a start on this date
+}
+
+// readSessionEvents reads the session events of date, in file order.
+// Malformed lines are skipped.
+func readSessionEvents(cfg Config, date string) []sessionEvent {
+	data, err := os.ReadFile(resolveSessionsPath(cfg, date))
+	if err != nil {
+		return nil
+	}
+	var events []sessionEvent
+	for _, line := range strings.Split(string(data), "\n") {
+		f := strings.SplitN(strings.TrimSpace(line), " ", 4)
+		if len(f) < 3 || f[1] != "start" && f[1] != "stop" {
+			continue
+		}
+		if _, err := time.Parse("15:04", f[0]); err != nil {
+			continue
+		}
+		e := sessionEvent{Clock: f[0], Kind: f[1], Project: f[2]}
+		if len(f) == 4 {
+			e.Reason = strings.TrimSpace(f[3])
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// appendSessionEvent records a session event at at.
+func appendSessionEvent(cfg Config, at time.Time, kind, project, reason string) error {
+	path := resolveSessionsPath(cfg, at.Format("2006-01-02"))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating raw dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening sessions file: %w", err)
+	}
+	defer f.Close()
+	line := at.Format("15:04") + " " + kind + " " + project
+	if reason = strings.Join(strings.Fields(reason), " "); reason != "" {
+		line += " " + reason
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		return fmt.Errorf("writing session: %w", err)
+	}
+	return nil
+}
+
+// dateSessions pairs the session events of date into sessions, in start
+// order. A stop without a start began at midnight, having been started the
+// day before; a start without a stop runs to midnight, or to now if date is
+// today. Starting a session that is already open keeps the first start.
+func dateSessions(cfg Config, date string, now time.Time) []workSession {
+	loc := now.Location()
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil
+	}
+	var sessions []workSession
+	open := make(map[string]int) // project -> index of its open session
+	for _, e := range readSessionEvents(cfg, date) {
+		t, _ := clockOn(date, e.Clock, loc)
+		i, isOpen := open[e.Project]
+		switch {
+		case e.Kind == "start" && !isOpen:
+			open[e.Project] = len(sessions)
+			sessions = append(sessions, workSession{Project: e.Project, Start: t, Reason: e.Reason, Open: true})
+		case e.Kind == "stop" && isOpen:
+			sessions[i].End, sessions[i].StopReason, sessions[i].Open = t, e.Reason, false
+			delete(open, e.Project)
+		case e.Kind == "stop":
+			sessions = append(sessions, workSession{Project: e.Project, Start: day, End: t, StopReason: e.Reason, FromPrevDay: true})
+		}
+	}
+	end := day.AddDate(0, 0, 1)
+	if date == now.Format("2006-01-02") {
+		end = now
+	}
+	for _, i := range open {
+		sessions[i].End = end
+	}
+	return sessions
+}
+
+// sessionProjects returns the projects with session events on date, in
+// order of first appearance.
+func sessionProjects(cfg Config, date string) []string {
+	var projects []string
+	for _, e := range readSessionEvents(cfg, date) {
+		if !containsString(projects, e.Project) {
+			projects = append(projects, e.Project)
+		}
+	}
+	return projects
+}
+
+// projectSessions returns the sessions of project on date.
+func projectSessions(cfg Config, date, project string, now time.Time) []workSession {
+	var out []workSession
+	for _, s := range dateSessions(cfg, date, now) {
+		if s.Project == project {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// openSession returns the session of project that is open at now: started
+// today or yesterday and not yet stopped.
+func openSession(cfg Config, project string, now time.Time) (workSession, bool) {
+	for _, date := range []string{now.Format("2006-01-02"), now.AddDate(0, 0, -1).Format("2006-01-02")} {
+		sessions := projectSessions(cfg, date, project, now)
+		if len(sessions) == 0 {
+			continue
+		}
+		last := sessions[len(sessions)-1]
+		if last.Open {
+			return last, true
+		}
+		// A stop today closes any session from yesterday.
+		return workSession{}, false
+	}
+	return workSession{}, false
+}
+
+// formatSessions lists sessions for the summary prompt, e.g. "- 09:10–12:30
+// (3h20m): Fix the login redirect". A session still open at the end of the
+// day ends at midnight, shown as 00:00.
+func formatSessions(sessions []workSession) string {
+	var b strings.Builder
+	for _, s := range sessions {
+		fmt.Fprintf(&b, "- %s–%s (%s", s.Start.Format("15:04"), s.End.Format("15:04"), formatSessionDuration(s.End.Sub(s.Start)))
+		switch {
+		case s.FromPrevDay:
+			b.WriteString(", started the day before")
+		case s.Open:
+			b.WriteString(", not stopped")
+		}
+		b.WriteString(")")
+		if s.Reason != "" {
+			fmt.Fprintf(&b, ": %s", s.Reason)
+		}
+		if s.StopReason != "" {
+			fmt.Fprintf(&b, " [stopped: %s]", s.StopReason)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatSessionDuration renders d in hours and minutes, e.g. "3h20m" or
+// "45m".
+func formatSessionDuration(d time.Duration) string {
+	m := int(d.Round(time.Minute).Minutes())
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh%02dm", m/60, m%60)
+}
+
+// sessionSpans returns the spans of project's sessions on date.
+func sessionSpans(cfg Config, date, project string, now time.Time) []timeSpan {
+	var spans []timeSpan
+	for _, s := range projectSessions(cfg, date, project, now) {
+		spans = append(spans, timeSpan{Start: s.Start, End: s.End})
+	}
+	return spans
+}
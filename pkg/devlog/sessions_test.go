@@ -0,0 +1,110 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDateSessions(t *testing.T) {
+	rawDir := filepath.Join(t.TempDir(), "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	os.MkdirAll(filepath.Join(rawDir, "2024-01-15"), 0o755)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-15", "sessions.log"), []byte(
+		"00:30 stop alpha late night\n"+
+			"09:10 start alpha Fix the login redirect\n"+
+			"10:00 start beta\n"+
+			"not a session\n"+
+			"12:30 stop alpha\n"+
+			"16:00 start alpha Review\n"), 0o644)
+
+	cfg := Config{}
+	now := time.Date(2024, 1, 20, 12, 0, 0, 0, time.Local)
+	sessions := dateSessions(cfg, "2024-01-15", now)
+	if len(sessions) != 4 {
+		t.Fatalf("expected 4 sessions, got %+v", sessions)
+	}
+	if s := sessions[0]; !s.FromPrevDay || s.Start.Format("15:04") != "00:00" || s.StopReason != "late night" {
+		t.Errorf("unexpected carried-over session %+v", s)
+	}
+	if s := sessions[1]; s.Open || s.Reason != "Fix the login redirect" || s.End.Format("15:04") != "12:30" {
+		t.Errorf("unexpected first alpha session %+v", s)
+	}
+	if s := sessions[3]; !s.Open || !s.End.Equal(time.Date(2024, 1, 16, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("expected the unstopped session to run to midnight, got %+v", s)
+	}
+
+	got := formatSessions(projectSessions(cfg, "2024-01-15", "alpha", now))
+	want := "- 00:00–00:30 (30m, started the day before) [stopped: late night]\n" +
+		"- 09:10–12:30 (3h20m): Fix the login redirect\n" +
+		"- 16:00–00:00 (8h00m, not stopped): Review\n"
+	if got != want {
+		t.Errorf("formatSessions:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOpenSession(t *testing.T) {
+	t.Setenv("DEVLOG_RAW_DIR", filepath.Join(t.TempDir(), "raw"))
+	cfg := Config{}
+	start := time.Date(2024, 1, 15, 22, 0, 0, 0, time.Local)
+	if err := appendSessionEvent(cfg, start, "start", "alpha", "  late\n fix "); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 16, 1, 0, 0, 0, time.Local)
+	s, ok := openSession(cfg, "alpha", now)
+	if !ok || !s.Start.Equal(start) || s.Reason != "late fix" {
+		t.Fatalf("expected yesterday's session to be open, got %+v %v", s, ok)
+	}
+	if _, ok := openSession(cfg, "beta", now); ok {
+		t.Error("expected no open session for beta")
+	}
+
+	appendSessionEvent(cfg, now, "stop", "alpha", "")
+	if _, ok := openSession(cfg, "alpha", now); ok {
+		t.Error("expected the session to be closed by today's stop")
+	}
+}
+
+func TestCollectTimePrefersSessions(t *testing.T) {
+	rawDir := filepath.Join(t.TempDir(), "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	dateDir := filepath.Join(rawDir, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"), []byte(
+		"=== SNAPSHOT 09:05 ===\n+a\n\n=== SNAPSHOT 14:00 ===\n+b\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "git-beta.log"), []byte(
+		"=== SNAPSHOT 09:05 ===\n+a\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "sessions.log"), []byte(
+		"09:00 start alpha\n11:00 stop alpha\n13:00 start gamma\n13:45 stop gamma\n"), 0o644)
+
+	empty := ""
+	report := collectTime(Config{ClaudeCodeDir: &empty}, State{}, []string{"2024-01-15"}, 15*time.Minute)
+	byProject := make(map[string]TimeEntry)
+	for _, e := range report.Entries {
+		byProject[e.Project] = e
+	}
+	if e := byProject["alpha"]; e.Minutes != 120 || e.Source != "sessions" || e.Last != "11:00" {
+		t.Errorf("expected alpha's session time, got %+v", e)
+	}
+	if e := byProject["beta"]; e.Source != "activity" {
+		t.Errorf("expected beta's inferred time, got %+v", e)
+	}
+	if e := byProject["gamma"]; e.Minutes != 45 {
+		t.Errorf("expected gamma's session without other data, got %+v", e)
+	}
+}
+
+func TestAssemblePromptSessions(t *testing.T) {
+	files := map[string]string{"notes.md": "n", sessionsFile: "- 09:10–12:30 (3h20m): Fix\n"}
+	prompt := assemblePrompt(nil, "alpha", "2024-01-15", files, "", "")
+	if !strings.Contains(prompt, "explicitly started and stopped") {
+		t.Error("expected the sessions file to be described")
+	}
+	delete(files, sessionsFile)
+	if strings.Contains(assemblePrompt(nil, "alpha", "2024-01-15", files, "", ""), "explicitly started") {
+		t.Error("expected no sessions description without sessions")
+	}
+}
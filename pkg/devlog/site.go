@@ -0,0 +1,119 @@
+package devlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// siteFormats are the static site generators `devlog export --site`
+// writes front matter for. Both read TOML front matter between "+++" lines
+// and _index.md section pages; they differ in where taxonomies go.
+var siteFormats = []string{"hugo", "zola"}
+
+// sitePage is one exported page.
+type sitePage struct {
+	Title    string
+	Date     string
+	Projects []string
+	Body     string
+}
+
+// render returns the page as Markdown with front matter for format. The
+// projects taxonomy must be declared in the site config to get index pages.
+func (p sitePage) render(format string) string {
+	var b strings.Builder
+	b.WriteString("+++\n")
+	fmt.Fprintf(&b, "title = %s\n", strconv.Quote(p.Title))
+	if p.Date != "" {
+		fmt.Fprintf(&b, "date = %s\n", p.Date)
+	}
+	if len(p.Projects) > 0 {
+		quoted := make([]string, len(p.Projects))
+		for i, proj := range p.Projects {
+			quoted[i] = strconv.Quote(proj)
+		}
+		if format == "zola" {
+			b.WriteString("\n[taxonomies]\n")
+		}
+		fmt.Fprintf(&b, "projects = [%s]\n", strings.Join(quoted, ", "))
+	}
+	b.WriteString("+++\n")
+	if p.Body != "" {
+		b.WriteString("\n" + p.Body + "\n")
+	}
+	return b.String()
+}
+
+// exportSite writes a content tree for a static site generator to dir: a
+// page per summarized date under daily/, and a page per project under
+// projects/ collecting its sections, newest first. Dates without a summary
+// are skipped. Returns the number of daily pages written.
+func exportSite(cfg Config, dir, format string, dates []string) (int, error) {
+	var days []sitePage
+	byProject := make(map[string][]string) // project -> "## date" sections, oldest first
+	latest := make(map[string]string)
+	for _, date := range dates {
+		data, err := os.ReadFile(summaryPath(cfg, date))
+		if err != nil {
+			continue
+		}
+		sections := splitSummary(string(data))
+		page := sitePage{Title: date, Date: date}
+		var body []string
+		for _, s := range sections {
+			page.Projects = append(page.Projects, s.Project)
+			body = append(body, "## "+s.Project+"\n\n"+s.Text)
+			byProject[s.Project] = append(byProject[s.Project], "## "+date+"\n\n"+s.Text)
+			latest[s.Project] = date
+		}
+		page.Body = strings.Join(body, "\n\n")
+		days = append(days, page)
+	}
+
+	write := func(rel string, page sitePage) error {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(page.render(format)), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		return nil
+	}
+
+	if err := write(filepath.Join("daily", "_index.md"), sitePage{Title: "Daily log"}); err != nil {
+		return 0, err
+	}
+	for _, page := range days {
+		if err := write(filepath.Join("daily", page.Date+".md"), page); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := write(filepath.Join("projects", "_index.md"), sitePage{Title: "Projects"}); err != nil {
+		return 0, err
+	}
+	projects := make([]string, 0, len(byProject))
+	for p := range byProject {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	for _, p := range projects {
+		if validateProjectName(p) != nil {
+			continue // not usable as a file name
+		}
+		sections := byProject[p]
+		for i, j := 0, len(sections)-1; i < j; i, j = i+1, j-1 {
+			sections[i], sections[j] = sections[j], sections[i]
+		}
+		page := sitePage{Title: p, Date: latest[p], Body: strings.Join(sections, "\n\n")}
+		if err := write(filepath.Join("projects", p+".md"), page); err != nil {
+			return 0, err
+		}
+	}
+	return len(days), nil
+}
@@ -0,0 +1,61 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSitePageRender(t *testing.T) {
+	page := sitePage{Title: "2024-01-15", Date: "2024-01-15", Projects: []string{"alpha", "beta"}, Body: "## alpha\n\nWork."}
+
+	hugo := page.render("hugo")
+	want := "+++\ntitle = \"2024-01-15\"\ndate = 2024-01-15\nprojects = [\"alpha\", \"beta\"]\n+++\n\n## alpha\n\nWork.\n"
+	if hugo != want {
+		t.Errorf("hugo front matter:\ngot:\n%s\nwant:\n%s", hugo, want)
+	}
+
+	zola := page.render("zola")
+	if !strings.Contains(zola, "date = 2024-01-15\n\n[taxonomies]\nprojects = [\"alpha\", \"beta\"]\n+++\n") {
+		t.Errorf("expected zola taxonomies table:\n%s", zola)
+	}
+}
+
+func TestExportSite(t *testing.T) {
+	cfg := Config{RawDir: t.TempDir(), LogDir: t.TempDir()}
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-14.md"), []byte("# 2024-01-14\n\n## alpha\n\nAlpha day one.\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nAlpha day two.\n\n## beta\n\nBeta work.\n"), 0o644)
+	dir := t.TempDir()
+
+	n, err := exportSite(cfg, dir, "hugo", []string{"2024-01-13", "2024-01-14", "2024-01-15"})
+	if err != nil {
+		t.Fatalf("exportSite: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 days exported, got %d", n)
+	}
+
+	day, _ := os.ReadFile(filepath.Join(dir, "daily", "2024-01-15.md"))
+	if !strings.Contains(string(day), "projects = [\"alpha\", \"beta\"]") || !strings.Contains(string(day), "## beta\n\nBeta work.") {
+		t.Errorf("unexpected daily page:\n%s", day)
+	}
+	if strings.Contains(string(day), "# 2024-01-15\n") {
+		t.Errorf("date heading should be replaced by the front matter title:\n%s", day)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "daily", "2024-01-13.md")); err == nil {
+		t.Error("expected no page for a date without a summary")
+	}
+
+	proj, _ := os.ReadFile(filepath.Join(dir, "projects", "alpha.md"))
+	got := string(proj)
+	if !strings.Contains(got, "date = 2024-01-15") ||
+		strings.Index(got, "## 2024-01-15\n\nAlpha day two.") > strings.Index(got, "## 2024-01-14\n\nAlpha day one.") {
+		t.Errorf("expected project page with newest day first:\n%s", got)
+	}
+	for _, index := range []string{"daily/_index.md", "projects/_index.md"} {
+		if _, err := os.Stat(filepath.Join(dir, index)); err != nil {
+			t.Errorf("expected %s: %v", index, err)
+		}
+	}
+}
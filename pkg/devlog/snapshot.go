@@ -0,0 +1,424 @@
+package devlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+func resolveRepoRoot(dir string) (string, error) {
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", dir)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// takeSnapshot captures the current state of a repo using the shadow index
+// technique. It returns the diff string and whether anything was written.
+// If the current diff hashes to prevHash (see diffHash), the snapshot is
+// skipped (dedup). logFile is the resolved path where the snapshot will be
+// appended.
+func takeSnapshot(repoPath, projectName, logFile, prevHash string) (diff string, err error) {
+	return snapshotRepo(repoPath, "", logFile, prevHash, snapshotOptions{})
+}
+
+// snapshotOptions are the settings that change what a snapshot records.
+type snapshotOptions struct {
+	Ignore           []string        // pathspecs left out, see snapshotPathspec
+	IgnoreWhitespace bool            // diff with -w
+	SeenHunks        map[string]bool // see collapseHunks; nil to write every hunk
+	Delta            *snapshotDelta  // see snapshotDelta; nil to write full diffs
+}
+
+// diffArgs returns the git diff arguments for a snapshot.
+func (o snapshotOptions) diffArgs() []string {
+	args := []string{"diff", "--no-color"}
+	if o.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	return append(args, "HEAD")
+}
+
+// snapshotRepo is takeSnapshot limited to the subdirectory subdir of the repo
+// (the whole repo when subdir is empty), with the given options.
+func snapshotRepo(repoPath, subdir, logFile, prevHash string, opts snapshotOptions) (string, error) {
+	shadowIndex := filepath.Join(repoPath, ".git", shadowIndexName())
+	pathspec := snapshotPathspec(subdir, opts.Ignore)
+
+	// Step 1: git add -A with shadow index
+	addCmd := exec.Command("git", append([]string{"-C", repoPath, "add", "-A"}, pathspec...)...)
+	addCmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+shadowIndex)
+	if out, err := addCmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git add: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+
+	// Step 2: git diff --no-color HEAD with shadow index
+	diffArgs := append([]string{"-C", repoPath}, opts.diffArgs()...)
+	diffCmd := exec.Command("git", append(diffArgs, pathspec...)...)
+	diffCmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+shadowIndex)
+	out, err := diffCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff: %w", err)
+	}
+
+	return appendSnapshot(logFile, string(out), prevHash, opts)
+}
+
+// shadowIndexName returns the file name of the shadow index in a repo's git
+// directory. Profiles use their own, so that two servers watching the same
+// repo do not race on it.
+func shadowIndexName() string {
+	return strings.ReplaceAll(appName(), "-", "_") + "_shadow_index"
+}
+
+// snapshotPathspec returns the git pathspec arguments that limit a snapshot
+// to subdir, if set, and leave out the ignore patterns (git pathspecs
+// relative to the watched directory, e.g. "*.lock" or "vendor/").
+func snapshotPathspec(subdir string, ignore []string) []string {
+	if subdir == "" && len(ignore) == 0 {
+		return nil
+	}
+	base := filepath.ToSlash(subdir)
+	if base == "" {
+		base = "."
+	}
+	spec := []string{"--", base}
+	for _, p := range ignore {
+		if subdir != "" {
+			p = base + "/" + p
+		}
+		spec = append(spec, ":(exclude)"+p)
+	}
+	return spec
+}
+
+// diffHash identifies a diff for deduplication without keeping all of it.
+func diffHash(diff string) string {
+	sum := sha256.Sum256([]byte(diff))
+	return hex.EncodeToString(sum[:])
+}
+
+// collapsedHunkNote replaces the body of a hunk that collapseHunks has
+// already seen.
+const collapsedHunkNote = "[unchanged since an earlier snapshot]"
+
+// collapseHunks returns diff with each hunk that is in seen cut down to its
+// "@@" line followed by collapsedHunkNote, and adds the hunks that are not
+// to seen. Hunks are identified by their file and content, not their line
+// numbers, so a hunk that only moved because of edits above it still counts
+// as seen.
+func collapseHunks(diff string, seen map[string]bool) string {
+	var b strings.Builder
+	var file string
+	var hunk []string
+	flush := func() {
+		if len(hunk) == 0 {
+			return
+		}
+		key := diffHash(file + "\n" + strings.Join(hunk[1:], "\n"))
+		if seen[key] {
+			b.WriteString(hunk[0] + "\n" + collapsedHunkNote + "\n")
+		} else {
+			seen[key] = true
+			b.WriteString(strings.Join(hunk, "\n") + "\n")
+		}
+		hunk = nil
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			file = line
+		case strings.HasPrefix(line, "@@ "):
+			flush()
+			hunk = []string{line}
+			continue
+		case hunk != nil:
+			hunk = append(hunk, line)
+			continue
+		}
+		b.WriteString(line + "\n")
+	}
+	flush()
+	return b.String()
+}
+
+// Delta snapshots start with deltaMarker, then list the files whose changes
+// are gone since the previous snapshot with removedFilePrefix, then hold the
+// diffs of the files that are new or changed.
+const (
+	deltaMarker       = "=== DELTA ==="
+	removedFilePrefix = "=== REMOVED "
+)
+
+// snapshotDelta is what the server remembers about a repo to write delta
+// snapshots: the per-file diffs of the last snapshot written, and how many
+// deltas have been written since the last full snapshot (the keyframe).
+type snapshotDelta struct {
+	Files         map[string]string // "diff --git" line -> that file's diff
+	Count         int
+	KeyframeEvery int
+}
+
+// next returns what to write for the snapshot diff: diff itself if a
+// keyframe is due, otherwise its delta against the previous snapshot.
+func (d *snapshotDelta) next(diff string) string {
+	prev := d.Files
+	d.Files = splitDiffFiles(diff)
+	if prev == nil || d.Count+1 >= d.KeyframeEvery {
+		d.Count = 0
+		return diff
+	}
+	d.Count++
+
+	var removed, changed []string
+	for key := range prev {
+		if _, ok := d.Files[key]; !ok {
+			removed = append(removed, removedFilePrefix+strings.TrimPrefix(key, "diff --git ")+" ===\n")
+		}
+	}
+	for key, section := range d.Files {
+		if prev[key] != section {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(changed)
+	var b strings.Builder
+	b.WriteString(deltaMarker + "\n")
+	b.WriteString(strings.Join(removed, ""))
+	for _, key := range changed {
+		b.WriteString(d.Files[key])
+	}
+	return b.String()
+}
+
+// splitDiffFiles splits a git diff into the diffs of each file, keyed by
+// their "diff --git" line.
+func splitDiffFiles(diff string) map[string]string {
+	files := make(map[string]string)
+	var key string
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			if key != "" {
+				files[key] = b.String()
+			}
+			key = strings.TrimSuffix(line, "\n")
+			b.Reset()
+		}
+		b.WriteString(line)
+	}
+	if key != "" {
+		files[key] = b.String()
+	}
+	return files
+}
+
+// expandSnapshotDeltas rewrites each delta snapshot in a git snapshot log as
+// the full diff it stands for, so that readers see every snapshot whole.
+func expandSnapshotDeltas(content string) string {
+	if !strings.Contains(content, "\n"+deltaMarker+"\n") {
+		return content
+	}
+	var b strings.Builder
+	files := make(map[string]string)
+	for _, chunk := range splitSnapshots(content) {
+		header, body, _ := strings.Cut(chunk, "\n")
+		if !strings.HasPrefix(header, snapshotHeaderPrefix) {
+			b.WriteString(chunk)
+			continue
+		}
+		rest, isDelta := strings.CutPrefix(body, deltaMarker+"\n")
+		if !isDelta {
+			files = splitDiffFiles(strings.TrimSuffix(body, "\n"))
+			b.WriteString(chunk)
+			continue
+		}
+		for strings.HasPrefix(rest, removedFilePrefix) {
+			line, after, _ := strings.Cut(rest, "\n")
+			delete(files, "diff --git "+strings.TrimSuffix(strings.TrimPrefix(line, removedFilePrefix), " ==="))
+			rest = after
+		}
+		for key, section := range splitDiffFiles(strings.TrimSuffix(rest, "\n")) {
+			files[key] = section
+		}
+		keys := make([]string, 0, len(files))
+		for key := range files {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString(header + "\n")
+		for _, key := range keys {
+			b.WriteString(files[key])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// readGitLog reads a git snapshot log with its delta snapshots expanded.
+func readGitLog(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return expandSnapshotDeltas(string(data)), nil
+}
+
+// appendSnapshot appends diff to logFile unless it is empty or hashes to
+// prevHash, and returns diff ("" when empty). The written copy is a delta if
+// opts.Delta is set and has its repeated hunks collapsed if opts.SeenHunks
+// is; the returned diff is always the full one.
+func appendSnapshot(logFile, diff, prevHash string, opts snapshotOptions) (string, error) {
+	// Empty diff: nothing to write
+	if strings.TrimSpace(diff) == "" {
+		return "", nil
+	}
+
+	// Dedup: skip if identical to previous
+	if diffHash(diff) == prevHash {
+		return diff, nil
+	}
+
+	// Write snapshot to raw file
+	if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil {
+		return "", fmt.Errorf("creating raw dir: %w", err)
+	}
+
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("opening log file: %w", err)
+	}
+	defer f.Close()
+
+	written := diff
+	if opts.Delta != nil {
+		written = opts.Delta.next(diff)
+	}
+	if opts.SeenHunks != nil {
+		written = collapseHunks(written, opts.SeenHunks)
+	}
+	now := time.Now()
+	header := fmt.Sprintf("=== SNAPSHOT %02d:%02d ===\n", now.Hour(), now.Minute())
+	if _, err := f.WriteString(header + written + "\n"); err != nil {
+		if opts.Delta != nil {
+			opts.Delta.Files = nil // the next snapshot must be a keyframe
+		}
+		return "", fmt.Errorf("writing snapshot: %w", err)
+	}
+
+	return diff, nil
+}
+
+// resolveWatchEntry resolves what args asks to watch: the git repo root
+// containing args.Path, a subdirectory of it, or for plain directories
+// args.Path itself. The returned entry has no name.
+func resolveWatchEntry(args WatchArgs) (WatchEntry, error) {
+	if args.Plain {
+		if args.Subdir != "" {
+			return WatchEntry{}, fmt.Errorf("plain directories cannot have a subdir")
+		}
+		abs, err := filepath.Abs(args.Path)
+		if err != nil {
+			return WatchEntry{}, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil || !info.IsDir() {
+			return WatchEntry{}, fmt.Errorf("not a directory: %s", args.Path)
+		}
+		return WatchEntry{Path: abs, Plain: true}, nil
+	}
+
+	root, err := resolveRepoRoot(args.Path)
+	if err != nil {
+		return WatchEntry{}, err
+	}
+	if args.Subdir == "" {
+		return WatchEntry{Path: root}, nil
+	}
+
+	subdir := filepath.Clean(args.Subdir)
+	if filepath.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, ".."+string(filepath.Separator)) {
+		return WatchEntry{}, fmt.Errorf("subdir must be a relative path inside the repo: %s", args.Subdir)
+	}
+	if subdir == "." {
+		return WatchEntry{Path: root}, nil
+	}
+	path := filepath.Join(root, subdir)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return WatchEntry{}, fmt.Errorf("not a directory: %s", path)
+	}
+	return WatchEntry{Path: path, Subdir: subdir}, nil
+}
+
+// snapshotEntry takes a snapshot of a watched repo, monorepo subdirectory,
+// or plain directory with the given options.
+func snapshotEntry(entry WatchEntry, logFile, prevHash, date string, opts snapshotOptions) (string, error) {
+	if entry.Plain {
+		return takePlainSnapshot(entry.Path, logFile, prevHash, date, opts)
+	}
+	return snapshotRepo(entry.repoRoot(), entry.Subdir, logFile, prevHash, opts)
+}
+
+const plainBaselinePrefix = "devlog baseline "
+
+// takePlainSnapshot snapshots a directory that is not a git repo. devlog
+// keeps a cached copy of the directory in a private git directory (see
+// resolvePlainCacheDir) whose HEAD is the directory's content as of the first
+// snapshot of date, and diffs the directory against it with the same shadow
+// index technique as takeSnapshot.
+func takePlainSnapshot(dir, logFile, prevHash, date string, opts snapshotOptions) (string, error) {
+	gitDir := resolvePlainCacheDir(dir)
+	git := func(env string, args ...string) *exec.Cmd {
+		base := []string{"--git-dir", gitDir, "--work-tree", dir,
+			"-c", "user.name=devlog", "-c", "user.email=devlog@localhost", "-c", "commit.gpgsign=false"}
+		cmd := exec.Command("git", append(base, args...)...)
+		cmd.Dir = dir
+		if env != "" {
+			cmd.Env = append(os.Environ(), env)
+		}
+		return cmd
+	}
+
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(gitDir), 0o755); err != nil {
+			return "", fmt.Errorf("creating plain cache dir: %w", err)
+		}
+		if out, err := git("", "init", "--quiet").CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git init: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+	}
+
+	// Roll the baseline forward on the first snapshot of each day.
+	subject, _ := git("", "log", "-1", "--format=%s").Output()
+	if strings.TrimSpace(string(subject)) != plainBaselinePrefix+date {
+		if out, err := git("", "add", "-A").CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git add: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+		commit := git("", "commit", "--quiet", "--allow-empty", "-m", plainBaselinePrefix+date)
+		if out, err := commit.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git commit: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+	}
+
+	shadowIndex := "GIT_INDEX_FILE=" + filepath.Join(gitDir, shadowIndexName())
+	pathspec := snapshotPathspec("", opts.Ignore)
+	if out, err := git(shadowIndex, append([]string{"add", "-A"}, pathspec...)...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git add: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	out, err := git(shadowIndex, append(opts.diffArgs(), pathspec...)...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff: %w", err)
+	}
+
+	return appendSnapshot(logFile, string(out), prevHash, opts)
+}
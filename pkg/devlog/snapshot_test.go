@@ -0,0 +1,470 @@
+package devlog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	cmds := [][]string{
+		{"git", "init", dir},
+		{"git", "-C", dir, "config", "user.email", "test@test.com"},
+		{"git", "-C", dir, "config", "user.name", "Test"},
+	}
+	for _, args := range cmds {
+		cmd := exec.Command(args[0], args[1:]...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("init cmd %v: %s: %v", args, out, err)
+		}
+	}
+
+	// Create initial commit so HEAD exists
+	readmePath := filepath.Join(dir, "README.md")
+	os.WriteFile(readmePath, []byte("# test\n"), 0o644)
+	exec.Command("git", "-C", dir, "add", "-A").Run()
+	exec.Command("git", "-C", dir, "commit", "-m", "initial").Run()
+
+	return dir
+}
+
+func TestResolveRepoRoot(t *testing.T) {
+	repo := initTestRepo(t)
+
+	// From repo root
+	got, err := resolveRepoRoot(repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != repo {
+		t.Errorf("got %q, want %q", got, repo)
+	}
+
+	// From subdirectory
+	sub := filepath.Join(repo, "subdir")
+	os.MkdirAll(sub, 0o755)
+	got, err = resolveRepoRoot(sub)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != repo {
+		t.Errorf("got %q, want %q", got, repo)
+	}
+
+	// From non-repo
+	notRepo := t.TempDir()
+	_, err = resolveRepoRoot(notRepo)
+	if err == nil {
+		t.Error("expected error for non-repo dir")
+	}
+}
+
+func TestSnapshotNewDiff(t *testing.T) {
+	repo := initTestRepo(t)
+	logFile := filepath.Join(t.TempDir(), "raw", "2024-01-15", "git-test-project.log")
+
+	// Make a change
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+
+	diff, err := takeSnapshot(repo, "test-project", logFile, "")
+	if err != nil {
+		t.Fatalf("takeSnapshot: %v", err)
+	}
+	if diff == "" {
+		t.Fatal("expected non-empty diff")
+	}
+
+	// Verify log file
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("reading log: %v", err)
+	}
+
+	s := string(content)
+	if !strings.Contains(s, "=== SNAPSHOT") {
+		t.Error("missing SNAPSHOT header")
+	}
+	if !strings.Contains(s, "main.go") {
+		t.Error("diff should mention main.go")
+	}
+}
+
+func TestSnapshotDedup(t *testing.T) {
+	repo := initTestRepo(t)
+	logFile := filepath.Join(t.TempDir(), "raw", "2024-01-15", "git-test-project.log")
+
+	// Make a change
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+
+	// First snapshot
+	diff1, err := takeSnapshot(repo, "test-project", logFile, "")
+	if err != nil {
+		t.Fatalf("first snapshot: %v", err)
+	}
+
+	// Second snapshot with the same diff — should dedup
+	diff2, err := takeSnapshot(repo, "test-project", logFile, diffHash(diff1))
+	if err != nil {
+		t.Fatalf("second snapshot: %v", err)
+	}
+	if diff2 != diff1 {
+		t.Error("expected same diff returned")
+	}
+
+	// Log file should only have one snapshot
+	content, _ := os.ReadFile(logFile)
+	count := strings.Count(string(content), "=== SNAPSHOT")
+	if count != 1 {
+		t.Errorf("expected 1 snapshot in log, got %d", count)
+	}
+}
+
+func TestSnapshotEmptyDiff(t *testing.T) {
+	repo := initTestRepo(t)
+	logFile := filepath.Join(t.TempDir(), "raw", "2024-01-15", "git-test-project.log")
+
+	// No changes — diff should be empty
+	diff, err := takeSnapshot(repo, "test-project", logFile, "")
+	if err != nil {
+		t.Fatalf("takeSnapshot: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("expected empty diff, got %q", diff)
+	}
+
+	// Log file should not exist
+	if _, err := os.Stat(logFile); !os.IsNotExist(err) {
+		t.Error("log file should not exist for empty diff")
+	}
+}
+
+func TestSnapshotUntrackedFiles(t *testing.T) {
+	repo := initTestRepo(t)
+	logFile := filepath.Join(t.TempDir(), "raw", "2024-01-15", "git-test-project.log")
+
+	// Create a new untracked file
+	os.WriteFile(filepath.Join(repo, "newfile.txt"), []byte("hello\n"), 0o644)
+
+	diff, err := takeSnapshot(repo, "test-project", logFile, "")
+	if err != nil {
+		t.Fatalf("takeSnapshot: %v", err)
+	}
+	if !strings.Contains(diff, "newfile.txt") {
+		t.Error("untracked file should appear in diff")
+	}
+}
+
+func TestSnapshotDoesNotDisturbRealIndex(t *testing.T) {
+	repo := initTestRepo(t)
+	logFile := filepath.Join(t.TempDir(), "raw", "2024-01-15", "git-test-project.log")
+
+	// Stage a file in the real index
+	os.WriteFile(filepath.Join(repo, "staged.go"), []byte("package main\n"), 0o644)
+	exec.Command("git", "-C", repo, "add", "staged.go").Run()
+
+	// Create another file but don't stage it
+	os.WriteFile(filepath.Join(repo, "unstaged.go"), []byte("package main\n"), 0o644)
+
+	// Take snapshot
+	_, err := takeSnapshot(repo, "test-project", logFile, "")
+	if err != nil {
+		t.Fatalf("takeSnapshot: %v", err)
+	}
+
+	// Verify real index still has only staged.go staged
+	cmd := exec.Command("git", "-C", repo, "diff", "--cached", "--name-only")
+	out, _ := cmd.Output()
+	staged := strings.TrimSpace(string(out))
+	if staged != "staged.go" {
+		t.Errorf("real index disturbed: staged files = %q", staged)
+	}
+
+	// Verify unstaged.go is still untracked
+	cmd = exec.Command("git", "-C", repo, "ls-files", "--others", "--exclude-standard")
+	out, _ = cmd.Output()
+	untracked := strings.TrimSpace(string(out))
+	if untracked != "unstaged.go" {
+		t.Errorf("real index disturbed: untracked = %q", untracked)
+	}
+}
+
+func TestSnapshotFormat(t *testing.T) {
+	repo := initTestRepo(t)
+	logFile := filepath.Join(t.TempDir(), "raw", "2024-01-15", "git-myproject.log")
+
+	os.WriteFile(filepath.Join(repo, "file.txt"), []byte("content\n"), 0o644)
+
+	takeSnapshot(repo, "myproject", logFile, "")
+
+	content, _ := os.ReadFile(logFile)
+	lines := strings.Split(string(content), "\n")
+
+	// First line should match === SNAPSHOT HH:MM ===
+	if len(lines) < 2 {
+		t.Fatal("log file too short")
+	}
+	if !strings.HasPrefix(lines[0], "=== SNAPSHOT ") || !strings.HasSuffix(lines[0], " ===") {
+		t.Errorf("header format wrong: %q", lines[0])
+	}
+	// Last non-empty content should be followed by a blank line
+	s := string(content)
+	if !strings.HasSuffix(s, "\n\n") {
+		t.Error("snapshot should end with blank line")
+	}
+}
+
+func TestPlainSnapshot(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	logFile := filepath.Join(t.TempDir(), "raw", "2024-01-15", "git-docs.log")
+	os.WriteFile(filepath.Join(dir, "paper.tex"), []byte("\\section{Intro}\n"), 0o644)
+
+	// The first snapshot of the day records the baseline; nothing has changed.
+	diff, err := takePlainSnapshot(dir, logFile, "", "2024-01-15", snapshotOptions{})
+	if err != nil {
+		t.Fatalf("takePlainSnapshot: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("expected empty diff against fresh baseline, got %q", diff)
+	}
+
+	os.WriteFile(filepath.Join(dir, "paper.tex"), []byte("\\section{Introduction}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "refs.bib"), []byte("@book{x}\n"), 0o644)
+	diff, err = takePlainSnapshot(dir, logFile, "", "2024-01-15", snapshotOptions{})
+	if err != nil {
+		t.Fatalf("takePlainSnapshot: %v", err)
+	}
+	if !strings.Contains(diff, "+\\section{Introduction}") || !strings.Contains(diff, "refs.bib") {
+		t.Errorf("diff missing changes: %q", diff)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		t.Error("plain snapshot should not create a .git directory in the watched dir")
+	}
+
+	// On a new day the baseline moves forward.
+	diff, err = takePlainSnapshot(dir, logFile, "", "2024-01-16", snapshotOptions{})
+	if err != nil {
+		t.Fatalf("takePlainSnapshot: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("expected empty diff after baseline rollover, got %q", diff)
+	}
+}
+
+func TestSnapshotSubdir(t *testing.T) {
+	repo := initTestRepo(t)
+	os.MkdirAll(filepath.Join(repo, "services", "api"), 0o755)
+	os.WriteFile(filepath.Join(repo, "services", "api", "handler.go"), []byte("package api\n"), 0o644)
+	os.WriteFile(filepath.Join(repo, "other.go"), []byte("package other\n"), 0o644)
+
+	entry, err := resolveWatchEntry(WatchArgs{Path: repo, Subdir: "services/api/"})
+	if err != nil {
+		t.Fatalf("resolveWatchEntry: %v", err)
+	}
+	if entry.Subdir != "services/api" || entry.repoRoot() != repo {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	logFile := filepath.Join(t.TempDir(), "git-api.log")
+	diff, err := snapshotEntry(entry, logFile, "", "2024-01-15", snapshotOptions{})
+	if err != nil {
+		t.Fatalf("snapshotEntry: %v", err)
+	}
+	if !strings.Contains(diff, "handler.go") {
+		t.Error("diff should include changes in the subdirectory")
+	}
+	if strings.Contains(diff, "other.go") {
+		t.Error("diff should not include changes outside the subdirectory")
+	}
+
+	for _, bad := range []string{"../elsewhere", "/abs", "missing"} {
+		if _, err := resolveWatchEntry(WatchArgs{Path: repo, Subdir: bad}); err == nil {
+			t.Errorf("expected error for subdir %q", bad)
+		}
+	}
+}
+
+func TestSnapshotIgnore(t *testing.T) {
+	repo := initTestRepo(t)
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+	os.WriteFile(filepath.Join(repo, "deps.lock"), []byte("locked\n"), 0o644)
+	os.MkdirAll(filepath.Join(repo, "vendor"), 0o755)
+	os.WriteFile(filepath.Join(repo, "vendor", "lib.go"), []byte("package lib\n"), 0o644)
+
+	entry := WatchEntry{Path: repo}
+	diff, err := snapshotEntry(entry, filepath.Join(t.TempDir(), "git.log"), "", "2024-01-15", snapshotOptions{Ignore: []string{"*.lock", "vendor/"}})
+	if err != nil {
+		t.Fatalf("snapshotEntry: %v", err)
+	}
+	if !strings.Contains(diff, "main.go") {
+		t.Error("diff should include main.go")
+	}
+	if strings.Contains(diff, "deps.lock") || strings.Contains(diff, "vendor/lib.go") {
+		t.Errorf("diff should leave out ignored paths, got:\n%s", diff)
+	}
+
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("one\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "build.log"), []byte("one\n"), 0o644)
+	takePlainSnapshot(dir, filepath.Join(t.TempDir(), "git.log"), "", "2024-01-15", snapshotOptions{Ignore: []string{"*.log"}})
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("two\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "build.log"), []byte("two\n"), 0o644)
+	diff, err = takePlainSnapshot(dir, filepath.Join(t.TempDir(), "git.log"), "", "2024-01-15", snapshotOptions{Ignore: []string{"*.log"}})
+	if err != nil {
+		t.Fatalf("takePlainSnapshot: %v", err)
+	}
+	if !strings.Contains(diff, "notes.txt") || strings.Contains(diff, "build.log") {
+		t.Errorf("plain diff should include notes.txt but not build.log, got:\n%s", diff)
+	}
+}
+
+func TestSnapshotIgnoreWhitespace(t *testing.T) {
+	repo := initTestRepo(t)
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("#  test  \n"), 0o644)
+	os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644)
+
+	opts := snapshotOptions{IgnoreWhitespace: true}
+	diff, err := snapshotEntry(WatchEntry{Path: repo}, filepath.Join(t.TempDir(), "git.log"), "", "2024-01-15", opts)
+	if err != nil {
+		t.Fatalf("snapshotEntry: %v", err)
+	}
+	if !strings.Contains(diff, "main.go") || strings.Contains(diff, "README.md") {
+		t.Errorf("diff should include main.go but not the whitespace-only README.md change, got:\n%s", diff)
+	}
+}
+
+func TestCollapseHunks(t *testing.T) {
+	diff1 := `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+-old
++new
+ same
+`
+	diff2 := diff1 + `@@ -10,1 +10,1 @@ func f()
+-x
++y
+`
+	// The first hunk moved down, which must not stop it matching.
+	diff3 := strings.Replace(diff2, "@@ -1,2 +1,2 @@", "@@ -3,2 +3,2 @@", 1)
+
+	seen := make(map[string]bool)
+	if got := collapseHunks(diff1, seen); got != diff1 {
+		t.Errorf("first diff should be written in full, got:\n%s", got)
+	}
+	want := strings.Replace(diff2, "-old\n+new\n same\n", collapsedHunkNote+"\n", 1)
+	if got := collapseHunks(diff2, seen); got != want {
+		t.Errorf("collapseHunks = %q, want %q", got, want)
+	}
+	if got := collapseHunks(diff3, seen); strings.Count(got, collapsedHunkNote) != 2 || !strings.Contains(got, "@@ -3,2 +3,2 @@") {
+		t.Errorf("both hunks should be collapsed, got:\n%s", got)
+	}
+
+	// The same hunk in another file is not a repeat.
+	other := strings.ReplaceAll(diff1, "a.go", "b.go")
+	if got := collapseHunks(other, seen); got != other {
+		t.Errorf("hunk of another file should not be collapsed, got:\n%s", got)
+	}
+}
+
+func TestSnapshotCollapseHunks(t *testing.T) {
+	repo := initTestRepo(t)
+	logFile := filepath.Join(t.TempDir(), "git.log")
+	opts := snapshotOptions{SeenHunks: make(map[string]bool)}
+
+	os.WriteFile(filepath.Join(repo, "a.go"), []byte("package a\n"), 0o644)
+	diff1, err := snapshotEntry(WatchEntry{Path: repo}, logFile, "", "2024-01-15", opts)
+	if err != nil {
+		t.Fatalf("snapshotEntry: %v", err)
+	}
+	os.WriteFile(filepath.Join(repo, "b.go"), []byte("package b\n"), 0o644)
+	diff2, err := snapshotEntry(WatchEntry{Path: repo}, logFile, diffHash(diff1), "2024-01-15", opts)
+	if err != nil {
+		t.Fatalf("snapshotEntry: %v", err)
+	}
+	if strings.Count(diff2, "+package a") != 1 {
+		t.Errorf("returned diff should be complete, got:\n%s", diff2)
+	}
+
+	content, _ := os.ReadFile(logFile)
+	if n := strings.Count(string(content), "+package a"); n != 1 {
+		t.Errorf("a.go hunk written %d times, want 1:\n%s", n, content)
+	}
+	if !strings.Contains(string(content), "+package b") || !strings.Contains(string(content), collapsedHunkNote) {
+		t.Errorf("second snapshot should have b.go and a collapsed a.go hunk:\n%s", content)
+	}
+}
+
+func TestSnapshotDelta(t *testing.T) {
+	repo := initTestRepo(t)
+	dir := t.TempDir()
+	fullLog, deltaLog := filepath.Join(dir, "full.log"), filepath.Join(dir, "delta.log")
+	delta := &snapshotDelta{KeyframeEvery: 4}
+
+	steps := []func(){
+		func() { os.WriteFile(filepath.Join(repo, "a.go"), []byte("package a\n"), 0o644) },
+		func() { os.WriteFile(filepath.Join(repo, "b.go"), []byte("package b\n"), 0o644) },
+		func() { os.WriteFile(filepath.Join(repo, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0o644) },
+		func() { os.Remove(filepath.Join(repo, "b.go")) },
+		func() { os.WriteFile(filepath.Join(repo, "README.md"), []byte("# changed\n"), 0o644) },
+	}
+	prev := ""
+	for _, step := range steps {
+		step()
+		diff, err := snapshotEntry(WatchEntry{Path: repo}, fullLog, prev, "2024-01-15", snapshotOptions{})
+		if err != nil {
+			t.Fatalf("snapshotEntry: %v", err)
+		}
+		if _, err := snapshotEntry(WatchEntry{Path: repo}, deltaLog, prev, "2024-01-15", snapshotOptions{Delta: delta}); err != nil {
+			t.Fatalf("snapshotEntry: %v", err)
+		}
+		prev = diffHash(diff)
+	}
+
+	raw, _ := os.ReadFile(deltaLog)
+	if n := strings.Count(string(raw), deltaMarker); n != 3 {
+		t.Errorf("got %d deltas, want 3 (keyframes at 1 and 5):\n%s", n, raw)
+	}
+	if !strings.Contains(string(raw), removedFilePrefix+"a/b.go b/b.go ===") {
+		t.Errorf("delta should record that b.go was removed:\n%s", raw)
+	}
+
+	// Snapshot times can differ between the two logs by a minute.
+	headerRe := regexp.MustCompile(`=== SNAPSHOT \d{2}:\d{2} ===`)
+	full, _ := os.ReadFile(fullLog)
+	got, err := readGitLog(deltaLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := headerRe.ReplaceAllString(got, ""), headerRe.ReplaceAllString(string(full), ""); got != want {
+		t.Errorf("expanded delta log:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSnapshotPathspec(t *testing.T) {
+	tests := []struct {
+		subdir string
+		ignore []string
+		want   []string
+	}{
+		{"", nil, nil},
+		{"services/api", nil, []string{"--", "services/api"}},
+		{"", []string{"*.lock"}, []string{"--", ".", ":(exclude)*.lock"}},
+		{"services/api", []string{"gen/"}, []string{"--", "services/api", ":(exclude)services/api/gen/"}},
+	}
+	for _, tt := range tests {
+		if got := snapshotPathspec(tt.subdir, tt.ignore); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("snapshotPathspec(%q, %v) = %q, want %q", tt.subdir, tt.ignore, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package devlog
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// lastActive returns the date of the last snapshot written for w, or the
+// date it was watched if none has been since. Entries watched before this
+// was recorded fall back to the last date with a git log for the project,
+// and "" if there is none.
+func lastActive(cfg Config, w WatchEntry) string {
+	if w.LastActive != "" {
+		return w.LastActive
+	}
+	dates := rawDataDates(cfg)
+	for i := len(dates) - 1; i >= 0; i-- {
+		if _, err := os.Stat(resolveGitPath(cfg, dates[i], w.Name)); err == nil {
+			return dates[i]
+		}
+	}
+	return ""
+}
+
+// staleWatched returns the entries of watched with no snapshot in the last
+// days days, least recently active first.
+func staleWatched(cfg Config, watched []WatchEntry, days int, now time.Time) []StaleRepo {
+	cutoff := now.AddDate(0, 0, -days).Format("2006-01-02")
+	var stale []StaleRepo
+	for _, w := range watched {
+		if last := lastActive(cfg, w); last <= cutoff {
+			stale = append(stale, StaleRepo{Path: w.Path, Name: w.Name, LastActive: last})
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].LastActive < stale[j].LastActive
+	})
+	return stale
+}
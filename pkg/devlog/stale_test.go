@@ -0,0 +1,51 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStaleWatched(t *testing.T) {
+	rawDir := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	os.MkdirAll(filepath.Join(rawDir, "2025-01-20"), 0o755)
+	os.WriteFile(filepath.Join(rawDir, "2025-01-20", "git-legacy.log"), []byte("diff\n"), 0o644)
+	os.MkdirAll(filepath.Join(rawDir, "2025-03-01"), 0o755)
+	os.WriteFile(filepath.Join(rawDir, "2025-03-01", "git-other.log"), []byte("diff\n"), 0o644)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	watched := []WatchEntry{
+		{Path: "/src/active", Name: "active", LastActive: "2025-03-09"},
+		{Path: "/src/edge", Name: "edge", LastActive: "2025-02-08"},
+		{Path: "/src/old", Name: "old", LastActive: "2024-11-02"},
+		{Path: "/src/legacy", Name: "legacy"}, // watched before last_active
+		{Path: "/src/never", Name: "never"},
+	}
+	got := staleWatched(Config{}, watched, 30, now)
+	want := []StaleRepo{
+		{Path: "/src/never", Name: "never"},
+		{Path: "/src/old", Name: "old", LastActive: "2024-11-02"},
+		{Path: "/src/legacy", Name: "legacy", LastActive: "2025-01-20"},
+		{Path: "/src/edge", Name: "edge", LastActive: "2025-02-08"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("staleWatched = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("staleWatched[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAddWatchedRecordsLastActive(t *testing.T) {
+	watched, added, err := addWatched(nil, WatchEntry{Path: "/src/api", Name: "api"})
+	if err != nil || !added {
+		t.Fatalf("addWatched = %v, %v", added, err)
+	}
+	if today := time.Now().Format("2006-01-02"); watched[0].LastActive != today {
+		t.Errorf("LastActive = %q, want %q", watched[0].LastActive, today)
+	}
+}
@@ -0,0 +1,178 @@
+package devlog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// standupLookback is how many days `devlog standup` looks back for the
+// previous summary, so that a Monday standup reports Friday's work.
+const standupLookback = 7
+
+// previousSummary returns the most recent summary before date within
+// standupLookback days and its date, or "" if there is none.
+func previousSummary(cfg Config, date string) (string, string) {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", ""
+	}
+	for i := 1; i <= standupLookback; i++ {
+		prev := d.AddDate(0, 0, -i).Format("2006-01-02")
+		if content, err := readSummary(cfg, prev); err == nil {
+			return prev, content
+		}
+	}
+	return "", ""
+}
+
+// collectStandupData gathers the data recorded so far on date for projects,
+// or for every project with data if projects is empty: the day's notes as
+// is, and each project's git, terminal, and Claude Code data compressed as
+// for summary generation.
+func collectStandupData(cfg Config, state State, date string, projects []string, p *genProgress) (map[string]string, error) {
+	files := make(map[string]string)
+
+	if notes := readNotes(cfg, date); notes != "" {
+		if len(projects) > 0 {
+			var parts []string
+			for _, proj := range projects {
+				if filtered := filterNotesForProject(cfg.noteHeadings(), notes, proj); filtered != "" {
+					parts = append(parts, filtered)
+				}
+			}
+			notes = strings.Join(parts, "\n")
+		}
+		if strings.TrimSpace(notes) != "" {
+			files["notes.md"] = notes
+		}
+	}
+
+	for _, proj := range discoverAllProjects(cfg, state, date) {
+		if len(projects) > 0 && !containsString(projects, proj) {
+			continue
+		}
+		for _, src := range collectBulkSources(cfg, state, proj, date) {
+			compressed, err := compressData(context.Background(), cfg, src.dataType, proj, date, src.files, src.sourcePaths, p)
+			if err != nil {
+				return nil, fmt.Errorf("compressing %s data for %s: %w", src.dataType, proj, err)
+			}
+			if compressed != "" {
+				files["comp-"+src.dataType+"-"+proj+".md"] = compressed
+			}
+		}
+	}
+	return files, nil
+}
+
+// assembleStandupPrompt builds the prompt for a standup update on date from
+// the summary of prevDate (which may be empty) and the data recorded so far
+// on date.
+func assembleStandupPrompt(headings *noteHeadingFormat, date, prevDate, summary string, files map[string]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are writing a software engineer's update for their daily standup\n"+
+		"meeting on %s.\n", date)
+
+	if prevDate != "" {
+		fmt.Fprintf(&b, "\nBelow is the summary of the previous working day, %s.\n", prevDate)
+		fmt.Fprintf(&b, "\n--- summary-%s.md ---\n%s\n", prevDate, strings.TrimSpace(summary))
+	} else {
+		b.WriteString("\nThere is no summary of the previous working day.\n")
+	}
+
+	if len(files) > 0 {
+		b.WriteString("\nBelow is the data collected so far today.\n")
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "\n--- %s ---\n%s\n", name, files[name])
+		}
+		if todos := openTodoTexts(headings, files["notes.md"]); len(todos) > 0 {
+			b.WriteString("\nOpen TODO items recorded in today's notes:\n")
+			for _, t := range todos {
+				fmt.Fprintf(&b, "- %s\n", t)
+			}
+		}
+	} else {
+		b.WriteString("\nNothing has been recorded yet today.\n")
+	}
+
+	b.WriteString(`
+Description of data sources:
+
+- summary-<date>.md: The generated summary of the previous working day, one
+  "## <project>" section per project. Each section ends with the next steps
+  planned at the end of that day.
+
+- notes.md: Manually logged notes from today. Headings may carry project
+  hashtags and tags such as #blocked (something blocking progress) and
+  #decision (a decision that was made).
+
+- comp-git-<project>.md, comp-term-<project>.md, comp-claude-<project>.md:
+  AI-compressed summaries of today's uncommitted code changes, terminal
+  sessions, and Claude Code sessions so far.
+
+Not all sources may be present. Work with whatever is available.
+
+Task: Write a short standup update with exactly these three sections:
+
+Yesterday:
+- What was done on the previous working day.
+
+Today:
+- What is planned for today: the previous day's next steps, adjusted by what
+  today's data shows is already under way or done.
+
+Blockers:
+- Anything blocking progress, including #blocked notes that are not
+  resolved. Write "- None" if there is nothing.
+
+Guidelines:
+- Use at most four bullets per section, one line each.
+- Name the project at the start of a bullet when there is more than one
+  project, e.g. "api: finished the retry logic".
+- Write in first person, for a teammate who knows the projects but not the
+  details of the code.
+- Do NOT use Markdown headings, bold, or timestamps. The update will be
+  pasted into a chat message as is.
+
+Output only the update, nothing else.
+`)
+
+	return b.String()
+}
+
+// standupPrompt collects the inputs for a standup update on date and
+// returns its prompt. It fails if there is neither a previous summary nor
+// any data today.
+func standupPrompt(cfg Config, state State, date string, projects []string, p *genProgress) (string, error) {
+	prevDate, summary := previousSummary(cfg, date)
+	if prevDate != "" && len(projects) > 0 {
+		var parts []string
+		for _, s := range selectSummarySections(summary, projects) {
+			parts = append(parts, "## "+s.Project+"\n\n"+s.Text)
+		}
+		if len(parts) == 0 {
+			prevDate = ""
+		}
+		summary = strings.Join(parts, "\n\n")
+	}
+
+	files, err := collectStandupData(cfg, state, date, projects, p)
+	if err != nil {
+		return "", err
+	}
+	if prevDate == "" && len(files) == 0 {
+		return "", fmt.Errorf("no summary in the %d days before %s and no data yet today", standupLookback, date)
+	}
+
+	counts := applyTokenBudget(files, cfg.TokenBudget)
+	warnTruncated("standup prompt", counts, cfg.TokenBudget)
+	return assembleStandupPrompt(cfg.noteHeadings(), date, prevDate, summary, files), nil
+}
@@ -0,0 +1,60 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviousSummary(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir()}
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-12.md"), []byte("# 2024-01-12\n\n## alpha\n\nFriday work.\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nMonday work.\n"), 0o644)
+
+	// A Monday standup reports Friday's work.
+	if date, content := previousSummary(cfg, "2024-01-15"); date != "2024-01-12" || !strings.Contains(content, "Friday work.") {
+		t.Errorf("got %q, %q", date, content)
+	}
+	if date, _ := previousSummary(cfg, "2024-01-16"); date != "2024-01-15" {
+		t.Errorf("expected the day before, got %q", date)
+	}
+	if date, _ := previousSummary(cfg, "2024-01-25"); date != "" {
+		t.Errorf("expected no summary beyond the lookback, got %q", date)
+	}
+}
+
+func TestStandupPrompt(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	cfg := Config{LogDir: filepath.Join(tmp, "log")}
+	os.MkdirAll(cfg.LogDir, 0o755)
+	os.MkdirAll(filepath.Join(rawDir, "2024-01-16"), 0o755)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"),
+		[]byte("# 2024-01-15\n\n## alpha\n\nAdded caching.\n\n## beta\n\nFixed the build.\n"), 0o644)
+	os.WriteFile(filepath.Join(rawDir, "2024-01-16", "notes.md"),
+		[]byte("### At 08:50 #alpha #blocked\nwaiting on API keys\n\n### At 09:00 #beta\nreleasing\n"), 0o644)
+
+	prompt, err := standupPrompt(cfg, State{}, "2024-01-16", []string{"alpha"}, nil)
+	if err != nil {
+		t.Fatalf("standupPrompt: %v", err)
+	}
+	for _, want := range []string{
+		"previous working day, 2024-01-15",
+		"## alpha\n\nAdded caching.",
+		"waiting on API keys",
+		"Blockers:",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Fixed the build.") || strings.Contains(prompt, "releasing") {
+		t.Errorf("prompt includes another project:\n%s", prompt)
+	}
+
+	if _, err := standupPrompt(cfg, State{}, "2024-03-01", nil, nil); err == nil {
+		t.Error("expected error with no summary and no data")
+	}
+}
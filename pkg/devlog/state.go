@@ -0,0 +1,352 @@
+package devlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WatchEntry struct {
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+	Plain  bool   `json:"plain,omitempty"`  // not a git repo; see takePlainSnapshot
+	Subdir string `json:"subdir,omitempty"` // Path relative to its git repo root, for monorepo sub-projects
+	// Client and BillingCode identify who the work is billed to, for
+	// `devlog invoice`.
+	Client      string `json:"client,omitempty"`
+	BillingCode string `json:"billing_code,omitempty"`
+	// The rest is metadata set with `devlog watch --set key=value`, see
+	// setWatchField.
+	Tags          []string `json:"tags,omitempty"`
+	DefaultBranch string   `json:"default_branch,omitempty"`
+	Interval      int      `json:"interval,omitempty"`    // seconds between snapshots, if longer than snapshot_interval
+	ExcludeGen    bool     `json:"exclude_gen,omitempty"` // not summarized by devlog gen
+	// LastActive is the date of the last snapshot written, or of when the
+	// entry was watched; see lastActive.
+	LastActive string `json:"last_active,omitempty"`
+}
+
+// repoRoot returns the root of the git repo containing the entry.
+func (w WatchEntry) repoRoot() string {
+	if w.Subdir == "" {
+		return w.Path
+	}
+	return strings.TrimSuffix(w.Path, string(filepath.Separator)+w.Subdir)
+}
+
+type State struct {
+	Watched []WatchEntry `json:"watched"`
+}
+
+func loadState() (State, error) {
+	path := resolveStatePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return State{}, nil
+		}
+		return State{}, fmt.Errorf("reading state: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return State{}, fmt.Errorf("parsing state: %w", err)
+	}
+	return s, nil
+}
+
+func saveState(s State) error {
+	path := resolveStatePath()
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling state: %w", err)
+	}
+	data = append(data, '\n')
+
+	// Atomic write: write to temp file in same dir, then rename.
+	tmp, err := os.CreateTemp(dir, "state-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
+func projectNameForRepo(repoPath string, state State, nameOverride string) string {
+	if nameOverride != "" {
+		return nameOverride
+	}
+	// Check state for an existing name mapping.
+	for _, w := range state.Watched {
+		if w.Path == repoPath {
+			return w.Name
+		}
+	}
+	// Fall back to basename of repo path.
+	return filepath.Base(repoPath)
+}
+
+// addWatched appends entry to watched unless its path is already watched,
+// recording today as its last activity. It reports whether the entry was
+// added, and fails if another watched entry uses the same project name.
+func addWatched(watched []WatchEntry, entry WatchEntry) ([]WatchEntry, bool, error) {
+	for _, w := range watched {
+		if w.Path == entry.Path {
+			return watched, false, nil
+		}
+	}
+	for _, w := range watched {
+		if w.Name == entry.Name {
+			return watched, false, fmt.Errorf("name conflict: %q is already used by %s", entry.Name, w.Path)
+		}
+	}
+	if entry.LastActive == "" {
+		entry.LastActive = time.Now().Format("2006-01-02")
+	}
+	return append(watched, entry), true, nil
+}
+
+// setBilling updates the client and billing code of the entry for path,
+// leaving a field unchanged when the new value is empty. It reports whether
+// the entry changed.
+func setBilling(watched []WatchEntry, path, client, code string) bool {
+	for i := range watched {
+		if watched[i].Path != path {
+			continue
+		}
+		changed := false
+		if client != "" && watched[i].Client != client {
+			watched[i].Client = client
+			changed = true
+		}
+		if code != "" && watched[i].BillingCode != code {
+			watched[i].BillingCode = code
+			changed = true
+		}
+		return changed
+	}
+	return false
+}
+
+// watchKeys are the keys of `devlog watch --set key=value`.
+var watchKeys = []string{"tags", "client", "billing_code", "default_branch", "vcs", "interval", "exclude_gen"}
+
+// parseWatchSettings parses key=value settings for setWatchField.
+func parseWatchSettings(settings []string) (map[string]string, error) {
+	set := make(map[string]string)
+	for _, s := range settings {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid setting %q (want key=value)", s)
+		}
+		key = strings.TrimSpace(key)
+		if !containsString(watchKeys, key) {
+			return nil, fmt.Errorf("unknown setting %q (known: %s)", key, strings.Join(watchKeys, ", "))
+		}
+		set[key] = strings.TrimSpace(value)
+	}
+	return set, nil
+}
+
+// setWatchField sets the metadata key of w to value. An empty value clears
+// it. vcs is "git" or "plain", the latter for the Plain field.
+func setWatchField(w *WatchEntry, key, value string) error {
+	switch key {
+	case "tags":
+		w.Tags = nil
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" && !containsString(w.Tags, t) {
+				w.Tags = append(w.Tags, t)
+			}
+		}
+	case "client":
+		w.Client = value
+	case "billing_code":
+		w.BillingCode = value
+	case "default_branch":
+		w.DefaultBranch = value
+	case "vcs":
+		switch value {
+		case "plain":
+			if w.Subdir != "" {
+				return fmt.Errorf("vcs: a monorepo subdirectory cannot be plain")
+			}
+			w.Plain = true
+		case "git":
+			if root, err := resolveRepoRoot(w.Path); err != nil || root != w.repoRoot() {
+				return fmt.Errorf("vcs: %s is not a git repo root", w.Path)
+			}
+			w.Plain = false
+		default:
+			return fmt.Errorf("vcs: want git or plain, got %q", value)
+		}
+	case "interval":
+		n := 0
+		if value != "" {
+			var err error
+			if n, err = strconv.Atoi(value); err != nil || n < 0 {
+				return fmt.Errorf("interval: want a number of seconds, got %q", value)
+			}
+		}
+		w.Interval = n
+	case "exclude_gen":
+		b := false
+		if value != "" {
+			var err error
+			if b, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("exclude_gen: want true or false, got %q", value)
+			}
+		}
+		w.ExcludeGen = b
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// applyWatchSettings applies set, from parseWatchSettings, to w in the
+// order of watchKeys. It reports whether w changed; on error, w is
+// unchanged.
+func applyWatchSettings(w *WatchEntry, set map[string]string) (bool, error) {
+	updated := *w
+	updated.Tags = append([]string(nil), w.Tags...)
+	for _, key := range watchKeys {
+		if value, ok := set[key]; ok {
+			if err := setWatchField(&updated, key, value); err != nil {
+				return false, err
+			}
+		}
+	}
+	if reflect.DeepEqual(updated, *w) {
+		return false, nil
+	}
+	*w = updated
+	return true, nil
+}
+
+// updateWatched applies set to the entry for path, if watched. It reports
+// whether the entry changed.
+func updateWatched(watched []WatchEntry, path string, set map[string]string) (bool, error) {
+	for i := range watched {
+		if watched[i].Path == path {
+			return applyWatchSettings(&watched[i], set)
+		}
+	}
+	return false, nil
+}
+
+// removeWatched removes the entry for path from watched and reports whether
+// it was present.
+func removeWatched(watched []WatchEntry, path string) ([]WatchEntry, bool) {
+	found := false
+	var newWatched []WatchEntry
+	for _, w := range watched {
+		if w.Path == path {
+			found = true
+			continue
+		}
+		newWatched = append(newWatched, w)
+	}
+	return newWatched, found
+}
+
+// containingEntry returns the watched plain directory or monorepo
+// subdirectory containing dir, if any. When entries are nested, the deepest
+// one wins.
+func containingEntry(dir string, watched []WatchEntry) (WatchEntry, bool) {
+	var best WatchEntry
+	found := false
+	for _, w := range watched {
+		if !w.Plain && w.Subdir == "" {
+			continue
+		}
+		if dir != w.Path && !strings.HasPrefix(dir, w.Path+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(w.Path) > len(best.Path) {
+			best, found = w, true
+		}
+	}
+	return best, found
+}
+
+// resolveUnwatchPath resolves dir to the path of a watched entry: the plain
+// directory or monorepo subdirectory containing it if there is one,
+// otherwise its git repo root.
+func resolveUnwatchPath(dir string, watched []WatchEntry) (string, error) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		if w, ok := containingEntry(abs, watched); ok {
+			return w.Path, nil
+		}
+	}
+	return resolveRepoRoot(dir)
+}
+
+// snapshotMarks records the last snapshot taken of each watched path on
+// Date, so that a restarted server does not append it again.
+type snapshotMarks struct {
+	Date   string            `json:"date"`
+	Hashes map[string]string `json:"hashes"` // path -> diffHash of the last snapshot
+}
+
+func resolveSnapshotMarksPath() string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "snapshots.json")
+}
+
+func loadSnapshotMarks() (snapshotMarks, error) {
+	var m snapshotMarks
+	data, err := os.ReadFile(resolveSnapshotMarksPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return m, fmt.Errorf("reading snapshot marks: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parsing snapshot marks: %w", err)
+	}
+	return m, nil
+}
+
+func saveSnapshotMarks(m snapshotMarks) error {
+	path := resolveSnapshotMarksPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling snapshot marks: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing snapshot marks: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing snapshot marks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	original := State{
+		Watched: []WatchEntry{
+			{Path: "/home/user/dev/foo", Name: "foo"},
+			{Path: "/home/user/dev/bar", Name: "my-bar"},
+		},
+	}
+
+	if err := saveState(original); err != nil {
+		t.Fatalf("saveState: %v", err)
+	}
+
+	loaded, err := loadState()
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+
+	if len(loaded.Watched) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(loaded.Watched))
+	}
+	if loaded.Watched[0].Path != "/home/user/dev/foo" || loaded.Watched[0].Name != "foo" {
+		t.Errorf("entry 0 mismatch: %+v", loaded.Watched[0])
+	}
+	if loaded.Watched[1].Path != "/home/user/dev/bar" || loaded.Watched[1].Name != "my-bar" {
+		t.Errorf("entry 1 mismatch: %+v", loaded.Watched[1])
+	}
+}
+
+func TestStateMissingFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	s, err := loadState()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Watched) != 0 {
+		t.Errorf("expected empty watched list, got %d", len(s.Watched))
+	}
+}
+
+func TestStateAtomicWrite(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", tmp)
+
+	s := State{Watched: []WatchEntry{{Path: "/a", Name: "a"}}}
+	if err := saveState(s); err != nil {
+		t.Fatalf("saveState: %v", err)
+	}
+
+	// Verify the file exists at expected path
+	path := filepath.Join(tmp, "devlog", "state.json")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("state file not found: %v", err)
+	}
+
+	// Verify no temp files left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if e.Name() != "state.json" {
+			t.Errorf("unexpected file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestProjectNameForRepo(t *testing.T) {
+	state := State{
+		Watched: []WatchEntry{
+			{Path: "/home/user/dev/foo", Name: "custom-name"},
+		},
+	}
+
+	// Repo in state uses state name
+	got := projectNameForRepo("/home/user/dev/foo", state, "")
+	if got != "custom-name" {
+		t.Errorf("expected custom-name, got %q", got)
+	}
+
+	// Override takes precedence
+	got = projectNameForRepo("/home/user/dev/foo", state, "override")
+	if got != "override" {
+		t.Errorf("expected override, got %q", got)
+	}
+
+	// Unknown repo falls back to basename
+	got = projectNameForRepo("/home/user/dev/bar", state, "")
+	if got != "bar" {
+		t.Errorf("expected bar, got %q", got)
+	}
+}
+
+func TestResolveUnwatchPathPlain(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "chapters")
+	os.MkdirAll(sub, 0o755)
+	watched := []WatchEntry{{Path: dir, Name: "book", Plain: true}}
+
+	got, err := resolveUnwatchPath(sub, watched)
+	if err != nil {
+		t.Fatalf("resolveUnwatchPath: %v", err)
+	}
+	if got != dir {
+		t.Errorf("expected %s, got %s", dir, got)
+	}
+
+	if _, err := resolveUnwatchPath(t.TempDir(), watched); err == nil {
+		t.Error("expected error for unwatched non-repo dir")
+	}
+}
+
+func TestSetBilling(t *testing.T) {
+	watched := []WatchEntry{{Path: "/a", Name: "a"}, {Path: "/b", Name: "b", Client: "acme"}}
+
+	if !setBilling(watched, "/a", "acme", "ACME-01") {
+		t.Error("expected change")
+	}
+	if watched[0].Client != "acme" || watched[0].BillingCode != "ACME-01" {
+		t.Errorf("unexpected entry %+v", watched[0])
+	}
+
+	// Empty values leave fields alone.
+	if setBilling(watched, "/b", "", "") || watched[1].Client != "acme" {
+		t.Errorf("expected no change, got %+v", watched[1])
+	}
+	if setBilling(watched, "/c", "acme", "X") {
+		t.Error("expected no change for an unwatched path")
+	}
+}
+
+func TestApplyWatchSettings(t *testing.T) {
+	if _, err := parseWatchSettings([]string{"color=red"}); err == nil {
+		t.Error("expected error for an unknown key")
+	}
+	if _, err := parseWatchSettings([]string{"tags"}); err == nil {
+		t.Error("expected error for a setting without =")
+	}
+
+	set, err := parseWatchSettings([]string{"tags=web, infra,web", "interval=900", "exclude_gen=true", "default_branch=main"})
+	if err != nil {
+		t.Fatalf("parseWatchSettings: %v", err)
+	}
+	watched := []WatchEntry{{Path: "/a", Name: "a"}}
+	changed, err := updateWatched(watched, "/a", set)
+	if err != nil || !changed {
+		t.Fatalf("updateWatched = %v, %v", changed, err)
+	}
+	want := WatchEntry{Path: "/a", Name: "a", Tags: []string{"web", "infra"}, DefaultBranch: "main", Interval: 900, ExcludeGen: true}
+	if !reflect.DeepEqual(watched[0], want) {
+		t.Errorf("got %+v, want %+v", watched[0], want)
+	}
+	if changed, _ := updateWatched(watched, "/a", set); changed {
+		t.Error("expected no change when setting the same values")
+	}
+
+	// A failing setting leaves the entry as it was.
+	if _, err := applyWatchSettings(&watched[0], map[string]string{"tags": "", "interval": "soon"}); err == nil {
+		t.Error("expected error for a bad interval")
+	}
+	if len(watched[0].Tags) != 2 {
+		t.Errorf("entry changed by a failed update: %+v", watched[0])
+	}
+	sub := WatchEntry{Path: "/repo/sub", Subdir: "sub"}
+	if _, err := applyWatchSettings(&sub, map[string]string{"vcs": "plain"}); err == nil {
+		t.Error("expected error for a plain subdirectory")
+	}
+
+	// Empty values clear.
+	if _, err := applyWatchSettings(&watched[0], map[string]string{"tags": "", "interval": "", "exclude_gen": ""}); err != nil {
+		t.Fatal(err)
+	}
+	if watched[0].Tags != nil || watched[0].Interval != 0 || watched[0].ExcludeGen {
+		t.Errorf("expected cleared fields, got %+v", watched[0])
+	}
+}
@@ -0,0 +1,286 @@
+package devlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ProjectStats is the activity recorded for one project over a date range,
+// computed from raw data without any AI calls.
+type ProjectStats struct {
+	Project            string `json:"project"`
+	Snapshots          int    `json:"snapshots"`
+	Commits            int    `json:"commits"`
+	FilesTouched       int    `json:"files_touched"`
+	NetAdded           int    `json:"net_added"`
+	NetRemoved         int    `json:"net_removed"`
+	LinesAdded         int    `json:"lines_added"`
+	LinesRemoved       int    `json:"lines_removed"`
+	Churn              int    `json:"churn"`
+	Notes              int    `json:"notes"`
+	TermSessions       int    `json:"term_sessions"`
+	TermMinutes        int    `json:"term_minutes"`
+	ClaudeSessions     int    `json:"claude_sessions"`
+	ClaudeInputTokens  int    `json:"claude_input_tokens"`
+	ClaudeOutputTokens int    `json:"claude_output_tokens"`
+
+	// Languages is set by `devlog stats --languages`; see addLanguageStats.
+	Languages []LanguageStats `json:"languages,omitempty"`
+}
+
+// StatsReport is the output of `devlog stats`.
+type StatsReport struct {
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Projects []ProjectStats `json:"projects"`
+	Total    ProjectStats   `json:"total"`
+}
+
+func (ps *ProjectStats) add(o ProjectStats) {
+	ps.Snapshots += o.Snapshots
+	ps.Commits += o.Commits
+	ps.FilesTouched += o.FilesTouched
+	ps.NetAdded += o.NetAdded
+	ps.NetRemoved += o.NetRemoved
+	ps.LinesAdded += o.LinesAdded
+	ps.LinesRemoved += o.LinesRemoved
+	ps.Churn += o.Churn
+	ps.Notes += o.Notes
+	ps.TermSessions += o.TermSessions
+	ps.TermMinutes += o.TermMinutes
+	ps.ClaudeSessions += o.ClaudeSessions
+	ps.ClaudeInputTokens += o.ClaudeInputTokens
+	ps.ClaudeOutputTokens += o.ClaudeOutputTokens
+}
+
+// collectStats computes per-project activity for each of dates. Projects
+// are discovered the same way as for summary generation, and unaffiliated
+// notes are counted under the "general" pseudo-project.
+func collectStats(cfg Config, state State, dates []string) StatsReport {
+	loc := time.Now().Location()
+	byProject := make(map[string]*ProjectStats)
+	get := func(name string) *ProjectStats {
+		if ps, ok := byProject[name]; ok {
+			return ps
+		}
+		ps := &ProjectStats{Project: name}
+		byProject[name] = ps
+		return ps
+	}
+
+	claudeDir := resolveClaudeCodeDir(cfg)
+	for _, date := range dates {
+		for _, proj := range discoverAllProjects(cfg, state, date) {
+			ps := get(proj)
+
+			d := collectDayDiff(cfg, state, date, proj)
+			ps.Snapshots += d.Snapshots
+			ps.Commits += d.Commits
+			ps.FilesTouched += d.FilesTouched
+			ps.NetAdded += d.NetAdded
+			ps.NetRemoved += d.NetRemoved
+			ps.LinesAdded += d.LinesAdded
+			ps.LinesRemoved += d.LinesRemoved
+			ps.Churn += d.Churn()
+
+			if matches, err := filepath.Glob(resolveTermGlob(cfg, date, proj)); err == nil {
+				for _, m := range matches {
+					ps.TermSessions++
+					ps.TermMinutes += termSessionMinutes(m)
+				}
+			}
+
+			if claudeDir != "" {
+				for _, w := range state.Watched {
+					if w.Name == proj {
+						usage := claudeUsageForDate(claudeSourcesFor(claudeDir, w), date, loc)
+						ps.ClaudeSessions += len(usage.Sessions)
+						ps.ClaudeInputTokens += usage.InputTokens
+						ps.ClaudeOutputTokens += usage.OutputTokens
+						break
+					}
+				}
+			}
+		}
+
+		for _, path := range notesPaths(cfg, date) {
+			for proj, n := range countNotes(cfg.noteHeadings(), path) {
+				get(proj).Notes += n
+			}
+		}
+	}
+
+	report := StatsReport{
+		From:     dates[0],
+		To:       dates[len(dates)-1],
+		Projects: []ProjectStats{},
+		Total:    ProjectStats{Project: "total"},
+	}
+	names := make([]string, 0, len(byProject))
+	for name := range byProject {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Projects = append(report.Projects, *byProject[name])
+		report.Total.add(*byProject[name])
+	}
+	return report
+}
+
+// snapshotChurn counts the snapshots in a git snapshot log and the diff lines
+// added and removed over the day. Each snapshot is a full diff against HEAD,
+// so a line is counted only when it first appears relative to the previous
+// snapshot; a line that is committed and later changed again counts again.
+func snapshotChurn(content string) (snapshots, added, removed int) {
+	prev := make(map[string]int)
+	for _, chunk := range splitSnapshots(content) {
+		if !strings.HasPrefix(chunk, snapshotHeaderPrefix) {
+			continue
+		}
+		snapshots++
+		cur := make(map[string]int)
+		for _, line := range strings.Split(chunk, "\n") {
+			if isDiffFileHeader(line) {
+				continue
+			}
+			if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+				cur[line]++
+			}
+		}
+		for line, n := range cur {
+			if extra := n - prev[line]; extra > 0 {
+				if line[0] == '+' {
+					added += extra
+				} else {
+					removed += extra
+				}
+			}
+		}
+		prev = cur
+	}
+	return snapshots, added, removed
+}
+
+func isDiffFileHeader(line string) bool {
+	for _, prefix := range []string{"--- a/", "+++ b/", "--- /dev/null", "+++ /dev/null"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var scriptTimeRe = regexp.MustCompile(`^Script (started|done) on (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+
+// termSessionMinutes estimates the length of a terminal recording from the
+// "Script started on" and "Script done on" lines written by util-linux
+// `script`. Recordings without both lines count as zero minutes.
+func termSessionMinutes(path string) int {
+	start, end, ok := termSessionSpan(path, time.Local)
+	if !ok {
+		return 0
+	}
+	return int(end.Sub(start).Round(time.Minute).Minutes())
+}
+
+// termSessionSpan returns the start and end of a terminal recording, read
+// from its `script` start and done lines in loc.
+func termSessionSpan(path string, loc *time.Location) (start, end time.Time, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return start, end, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		m := scriptTimeRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", m[2], loc)
+		if err != nil {
+			continue
+		}
+		if m[1] == "started" {
+			start = t
+		} else {
+			end = t
+		}
+	}
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return start, end, false
+	}
+	return start, end, true
+}
+
+// countNotes counts the notes entries in a notes file by project hashtag. An
+// entry tagged with several projects counts once for each, and entries
+// without a hashtag are counted under "general".
+func countNotes(headings *noteHeadingFormat, path string) map[string]int {
+	counts := make(map[string]int)
+	f, err := os.Open(path)
+	if err != nil {
+		return counts
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		tags, ok := noteHeadingTags(headings, scanner.Text())
+		if !ok {
+			continue
+		}
+		if len(tags) == 0 {
+			counts["general"]++
+		}
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+func printStatsTable(w io.Writer, report StatsReport) {
+	if report.From == report.To {
+		fmt.Fprintf(w, "Activity for %s\n\n", report.From)
+	} else {
+		fmt.Fprintf(w, "Activity for %s to %s\n\n", report.From, report.To)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tSNAPSHOTS\tCOMMITS\tFILES\t+LINES\t-LINES\tCHURN\tNOTES\tTERM\tTERM MIN\tCLAUDE\tCLAUDE TOKENS")
+	row := func(ps ProjectStats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			ps.Project, ps.Snapshots, ps.Commits, ps.FilesTouched, ps.NetAdded, ps.NetRemoved, ps.Churn, ps.Notes,
+			ps.TermSessions, ps.TermMinutes, ps.ClaudeSessions,
+			ps.ClaudeInputTokens+ps.ClaudeOutputTokens)
+	}
+	for _, ps := range report.Projects {
+		row(ps)
+	}
+	if len(report.Projects) > 1 {
+		row(report.Total)
+	}
+	tw.Flush()
+}
+
+func printStatsJSON(w io.Writer, report StatsReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
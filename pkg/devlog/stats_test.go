@@ -0,0 +1,117 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotChurn(t *testing.T) {
+	content := "=== SNAPSHOT 10:00 ===\n" +
+		"--- a/main.go\n+++ b/main.go\n+line one\n-old line\n\n" +
+		"=== SNAPSHOT 10:05 ===\n" +
+		"--- a/main.go\n+++ b/main.go\n+line one\n+line two\n-old line\n\n"
+
+	snaps, added, removed := snapshotChurn(content)
+	if snaps != 2 {
+		t.Errorf("snapshots = %d, want 2", snaps)
+	}
+	// "+line one" and "-old line" are counted once; "+line two" is new in the second snapshot
+	if added != 2 {
+		t.Errorf("added = %d, want 2", added)
+	}
+	if removed != 1 {
+		t.Errorf("removed = %d, want 1", removed)
+	}
+}
+
+func TestTermSessionMinutes(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "term-proj.log")
+	os.WriteFile(path, []byte("Script started on 2024-01-15 10:00:00+00:00 [TERM=\"xterm\"]\n"+
+		"$ go test\nok\n"+
+		"Script done on 2024-01-15 10:45:10+00:00 [COMMAND_EXIT_CODE=\"0\"]\n"), 0o644)
+	if got := termSessionMinutes(path); got != 45 {
+		t.Errorf("got %d minutes, want 45", got)
+	}
+
+	noHeaders := filepath.Join(dir, "term-other.log")
+	os.WriteFile(noHeaders, []byte("$ ls\n"), 0o644)
+	if got := termSessionMinutes(noHeaders); got != 0 {
+		t.Errorf("got %d minutes without headers, want 0", got)
+	}
+}
+
+func TestCountNotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("### At 10:00 #alpha\none\n\n### At 10:30 #alpha\ntwo\n\n"+
+		"### At 11:00\nunaffiliated\n\n### At 12:00 #beta\nthree\n\n### At 13:00 #alpha #beta\nshared\n"), 0o644)
+
+	counts := countNotes(nil, path)
+	if counts["alpha"] != 3 || counts["beta"] != 2 || counts["general"] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+}
+
+func TestCollectStats(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+
+	for _, date := range []string{"2024-01-15", "2024-01-16"} {
+		dateDir := filepath.Join(rawDir, date)
+		os.MkdirAll(dateDir, 0o755)
+		os.WriteFile(filepath.Join(dateDir, "git-myproject.log"),
+			[]byte("=== SNAPSHOT 10:00 ===\n+added\n\n"), 0o644)
+		os.WriteFile(filepath.Join(dateDir, "notes.md"),
+			[]byte("### At 10:20 #myproject\nnote\n\n### At 11:00\ngeneral note\n"), 0o644)
+	}
+
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty}
+	report := collectStats(cfg, State{}, []string{"2024-01-15", "2024-01-16"})
+
+	if report.From != "2024-01-15" || report.To != "2024-01-16" {
+		t.Errorf("unexpected range %s..%s", report.From, report.To)
+	}
+	if len(report.Projects) != 2 {
+		t.Fatalf("expected 2 projects, got %+v", report.Projects)
+	}
+	var proj ProjectStats
+	for _, ps := range report.Projects {
+		if ps.Project == "myproject" {
+			proj = ps
+		}
+	}
+	if proj.Snapshots != 2 || proj.LinesAdded != 2 || proj.Notes != 2 {
+		t.Errorf("unexpected myproject stats: %+v", proj)
+	}
+	if report.Total.Notes != 4 {
+		t.Errorf("total notes = %d, want 4", report.Total.Notes)
+	}
+
+	var table bytes.Buffer
+	printStatsTable(&table, report)
+	if !strings.Contains(table.String(), "Activity for 2024-01-15 to 2024-01-16") {
+		t.Errorf("table missing header:\n%s", table.String())
+	}
+	if !strings.Contains(table.String(), "myproject") || !strings.Contains(table.String(), "total") {
+		t.Errorf("table missing rows:\n%s", table.String())
+	}
+
+	var js bytes.Buffer
+	if err := printStatsJSON(&js, report); err != nil {
+		t.Fatalf("printStatsJSON: %v", err)
+	}
+	var decoded StatsReport
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded.Projects) != 2 {
+		t.Errorf("JSON should round-trip projects, got %+v", decoded.Projects)
+	}
+}
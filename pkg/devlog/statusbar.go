@@ -0,0 +1,105 @@
+package devlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// barStatus is what `devlog statusbar` shows.
+type barStatus struct {
+	Running      bool
+	Repos        int
+	LastSnapshot *time.Time // most recent snapshot attempt of any repo
+	Notes        int        // notes logged today
+	Problems     []string   // failed health checks
+}
+
+// collectBarStatus pings the server and counts today's notes. A server that
+// is not running (or not answering) is reported as down, not as an error.
+func collectBarStatus(cfg Config, now time.Time) barStatus {
+	var st barStatus
+	for _, line := range strings.Split(readNotes(cfg, now.Format("2006-01-02")), "\n") {
+		if isNoteHeading(cfg.noteHeadings(), line) {
+			st.Notes++
+		}
+	}
+
+	resp, err := ipcSend(IPCRequest{Command: "ping"})
+	if err != nil || !resp.OK {
+		return st
+	}
+	var ping PingData
+	if err := json.Unmarshal(resp.Data, &ping); err != nil {
+		return st
+	}
+	st.Running = true
+	st.Repos = len(ping.Repos)
+	for _, rs := range ping.Repos {
+		if rs.LastSnapshotAt != nil && (st.LastSnapshot == nil || rs.LastSnapshotAt.After(*st.LastSnapshot)) {
+			st.LastSnapshot = rs.LastSnapshotAt
+		}
+	}
+	st.Problems = evaluateHealth(ping, now)
+	return st
+}
+
+// text returns the one-line status, e.g. "devlog 3 repos 4m 2 notes".
+func (st barStatus) text(now time.Time) string {
+	notes := plural(st.Notes, "note")
+	if !st.Running {
+		return "devlog down " + notes
+	}
+	last := "-"
+	if st.LastSnapshot != nil {
+		last = fmt.Sprintf("%dm", int(now.Sub(*st.LastSnapshot).Minutes()))
+	}
+	line := fmt.Sprintf("devlog %s %s %s", plural(st.Repos, "repo"), last, notes)
+	if len(st.Problems) > 0 {
+		line += " !"
+	}
+	return line
+}
+
+// class is the waybar CSS class: "down", "warning", or "ok".
+func (st barStatus) class() string {
+	switch {
+	case !st.Running:
+		return "down"
+	case len(st.Problems) > 0:
+		return "warning"
+	default:
+		return "ok"
+	}
+}
+
+// waybarJSON returns the status in waybar's custom module JSON format.
+func (st barStatus) waybarJSON(now time.Time) []byte {
+	tooltip := "devlog server is not running"
+	if st.Running {
+		tooltip = fmt.Sprintf("Watching %s", plural(st.Repos, "repo"))
+		if st.LastSnapshot != nil {
+			tooltip += fmt.Sprintf("\nLast snapshot %s ago", now.Sub(*st.LastSnapshot).Round(time.Minute))
+		}
+		for _, p := range st.Problems {
+			tooltip += "\n" + p
+		}
+	}
+	tooltip += fmt.Sprintf("\n%s today", plural(st.Notes, "note"))
+
+	data, _ := json.Marshal(struct {
+		Text    string `json:"text"`
+		Tooltip string `json:"tooltip"`
+		Class   string `json:"class"`
+		Alt     string `json:"alt"`
+	}{Text: st.text(now), Tooltip: tooltip, Class: st.class(), Alt: st.class()})
+	return data
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
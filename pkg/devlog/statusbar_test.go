@@ -0,0 +1,55 @@
+package devlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBarStatusText(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	last := now.Add(-4 * time.Minute)
+
+	st := barStatus{Running: true, Repos: 3, LastSnapshot: &last, Notes: 1}
+	if got := st.text(now); got != "devlog 3 repos 4m 1 note" {
+		t.Errorf("text = %q", got)
+	}
+	if st.class() != "ok" {
+		t.Errorf("class = %q, want ok", st.class())
+	}
+
+	st.Problems = []string{"last snapshot of foo failed: boom"}
+	if got := st.text(now); got != "devlog 3 repos 4m 1 note !" || st.class() != "warning" {
+		t.Errorf("unhealthy status = %q (%s)", got, st.class())
+	}
+
+	down := barStatus{Notes: 2}
+	if got := down.text(now); got != "devlog down 2 notes" || down.class() != "down" {
+		t.Errorf("down status = %q (%s)", got, down.class())
+	}
+
+	var wb map[string]string
+	if err := json.Unmarshal(down.waybarJSON(now), &wb); err != nil {
+		t.Fatalf("waybar JSON: %v", err)
+	}
+	if wb["text"] != "devlog down 2 notes" || wb["class"] != "down" || wb["tooltip"] == "" {
+		t.Errorf("unexpected waybar output: %v", wb)
+	}
+}
+
+func TestCollectBarStatusServerDown(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	rawDir := t.TempDir()
+	cfg := Config{RawDir: rawDir}
+	now := time.Now()
+	notes := filepath.Join(rawDir, now.Format("2006-01-02"), "notes.md")
+	os.MkdirAll(filepath.Dir(notes), 0o755)
+	os.WriteFile(notes, []byte("### At 09:00 #a\none\n\n### At 10:00\ntwo\n\n"), 0o644)
+
+	st := collectBarStatus(cfg, now)
+	if st.Running || st.Notes != 2 {
+		t.Errorf("expected server down with 2 notes, got %+v", st)
+	}
+}
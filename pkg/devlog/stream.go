@@ -0,0 +1,187 @@
+package devlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// streamJSONCmd reports whether command prints the stream-json events of
+// `claude -p --output-format stream-json` instead of plain text.
+func streamJSONCmd(command string) bool {
+	args := strings.Fields(command)
+	for i, a := range args {
+		if a == "--output-format=stream-json" || a == "--output-format" && i+1 < len(args) && args[i+1] == "stream-json" {
+			return true
+		}
+	}
+	return false
+}
+
+// streamEvent is the part of a stream-json event that carries text: a
+// delta of a partial message (with --include-partial-messages), a whole
+// assistant message, or the final result.
+type streamEvent struct {
+	Type  string `json:"type"`
+	Event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+	} `json:"event"`
+	Message struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"message"`
+	Result  string `json:"result"`
+	IsError bool   `json:"is_error"`
+}
+
+// runGenPromptCmd is runPromptCmd for a command of gen_cmd. It copies the
+// summary to w as the command writes it, if w is not nil, and reads the
+// result out of the events of a stream-json command (see streamJSONCmd).
+func runGenPromptCmd(ctx context.Context, command, prompt string, w io.Writer, timeout time.Duration) (string, error) {
+	isJSON := streamJSONCmd(command)
+	if w == nil && !isJSON {
+		return runPromptCmd(ctx, "gen_cmd", command, prompt, timeout)
+	}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("gen_cmd is empty")
+	}
+
+	tw := &trailingWriter{w: w}
+	if w == nil {
+		tw.w = io.Discard
+	}
+	ctx, cancel := commandContext(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.WaitDelay = commandWaitDelay
+	cmd.Stdin = strings.NewReader(prompt)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	// The output is read as it is written; the command's stdout is a writer
+	// rather than a pipe so that Wait gives up on it after a timeout.
+	var out string
+	var readErr error
+	var done chan struct{}
+	if isJSON {
+		pr, pw := io.Pipe()
+		cmd.Stdout = pw
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			out, readErr = readStreamJSON(pr, tw)
+			io.Copy(io.Discard, pr)
+		}()
+		err := cmd.Run()
+		pw.Close()
+		<-done
+		if err != nil {
+			return "", promptCmdError(ctx, args[0], timeout, err, &stderr)
+		}
+	} else {
+		var b strings.Builder
+		cmd.Stdout = io.MultiWriter(&b, tw)
+		if err := cmd.Run(); err != nil {
+			return "", promptCmdError(ctx, args[0], timeout, err, &stderr)
+		}
+		out = b.String()
+	}
+	if tw.last != 0 && tw.last != '\n' {
+		fmt.Fprintln(tw.w)
+	}
+	if readErr != nil {
+		return "", fmt.Errorf("reading output of %s: %w", args[0], readErr)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// promptCmdError returns the error of program, run in ctx with timeout,
+// failing with err, as runPromptCmd reports it.
+func promptCmdError(ctx context.Context, program string, timeout time.Duration, err error, stderr *bytes.Buffer) error {
+	if ctx.Err() == context.Canceled {
+		return errGenCancelled
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return timeoutError(program, timeout)
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return fmt.Errorf("%s failed: %s", program, strings.TrimSpace(stderr.String()))
+	}
+	return fmt.Errorf("running %s: %w", program, err)
+}
+
+// trailingWriter is a writer that remembers the last byte written through
+// it, so that streamed output can be ended with a newline.
+type trailingWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (t *trailingWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.last = p[len(p)-1]
+	}
+	return t.w.Write(p)
+}
+
+// readStreamJSON reads stream-json events from r, writing the text of the
+// assistant's messages to w as it arrives, and returns the result. Lines
+// that are not events are ignored.
+func readStreamJSON(r io.Reader, w io.Writer) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var text strings.Builder
+	sawDelta, sawResult := false, false
+	var result string
+	for scanner.Scan() {
+		var ev streamEvent
+		if json.Unmarshal(scanner.Bytes(), &ev) != nil {
+			continue
+		}
+		switch ev.Type {
+		case "stream_event":
+			if ev.Event.Type == "content_block_delta" && ev.Event.Delta.Type == "text_delta" {
+				sawDelta = true
+				text.WriteString(ev.Event.Delta.Text)
+				io.WriteString(w, ev.Event.Delta.Text)
+			}
+		case "assistant":
+			for _, c := range ev.Message.Content {
+				if c.Type != "text" {
+					continue
+				}
+				if !sawDelta {
+					text.WriteString(c.Text)
+					io.WriteString(w, c.Text)
+				}
+			}
+		case "result":
+			if ev.IsError {
+				return "", fmt.Errorf("the result is an error: %s", strings.TrimSpace(ev.Result))
+			}
+			result, sawResult = ev.Result, true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if !sawResult {
+		// No result event, e.g. the command was cut short: the text
+		// streamed so far is all there is.
+		return text.String(), nil
+	}
+	return result, nil
+}
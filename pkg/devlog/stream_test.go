@@ -0,0 +1,55 @@
+package devlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStreamJSONCmd(t *testing.T) {
+	for cmd, want := range map[string]bool{
+		"claude -p --verbose --output-format stream-json": true,
+		"claude -p --output-format=stream-json":           true,
+		"claude -p --output-format json":                  false,
+		"claude -p":                                       false,
+	} {
+		if got := streamJSONCmd(cmd); got != want {
+			t.Errorf("streamJSONCmd(%q) = %v, want %v", cmd, got, want)
+		}
+	}
+}
+
+func TestRunGenPromptCmdStreams(t *testing.T) {
+	mockBin := t.TempDir()
+	os.WriteFile(filepath.Join(mockBin, "plain"), []byte("#!/bin/sh\ncat > /dev/null\nprintf 'I fixed\\nthe bug.'\n"), 0o755)
+	events := `{"type":"system","subtype":"init"}
+{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"I fixed "}}}
+{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"the bug."}}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"I fixed the bug."}]}}
+{"type":"result","subtype":"success","is_error":false,"result":"I fixed the bug."}
+`
+	os.WriteFile(filepath.Join(mockBin, "events"), []byte("#!/bin/sh\ncat > /dev/null\ncat <<'EOF'\n"+events+"EOF\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "failing"), []byte("#!/bin/sh\necho '{\"type\":\"result\",\"is_error\":true,\"result\":\"rate limited\"}'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	var streamed strings.Builder
+	out, err := runGenPromptCmd(context.Background(), "plain", "Summarize.\n", &streamed, 0)
+	if err != nil || out != "I fixed\nthe bug." || streamed.String() != "I fixed\nthe bug.\n" {
+		t.Errorf("plain: out %q, streamed %q, err %v", out, streamed.String(), err)
+	}
+
+	streamed.Reset()
+	out, err = runGenPromptCmd(context.Background(), "events --output-format stream-json", "Summarize.\n", &streamed, 0)
+	if err != nil || out != "I fixed the bug." || streamed.String() != "I fixed the bug.\n" {
+		t.Errorf("stream-json: out %q, streamed %q, err %v", out, streamed.String(), err)
+	}
+	// The result is read out of the events even when not streaming.
+	if out, err := runGenPromptCmd(context.Background(), "events --output-format stream-json", "Summarize.\n", nil, 0); err != nil || out != "I fixed the bug." {
+		t.Errorf("stream-json without streaming: out %q, err %v", out, err)
+	}
+	if _, err := runGenPromptCmd(context.Background(), "failing --output-format=stream-json", "Summarize.\n", nil, 0); err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("expected the error result, got %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package devlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// summaryMeta is how a summary was generated, written as YAML frontmatter at
+// the top of <date>.md.
+type summaryMeta struct {
+	Generated time.Time
+	CompCmd   string
+	Projects  []projectMeta
+}
+
+// projectMeta is how the section of one project was generated.
+type projectMeta struct {
+	Project       string
+	Generated     time.Time
+	GenCmd        string // the command of gen_cmd that wrote the section
+	PromptTokens  int    // estimated, see estimateTokens
+	SummaryTokens int
+	Cost          float64 // estimated from token_prices; 0 if GenCmd has none
+	Sources       []sourceMeta
+	Notes         string // hash of the project's notes entries, "" if none
+}
+
+// TokenPrice is the price of a command per million prompt (input) and
+// summary (output) tokens, in any currency.
+type TokenPrice struct {
+	Input  float64 `toml:"input"`
+	Output float64 `toml:"output"`
+}
+
+// estimateCost returns the estimated cost of generating the section with
+// metadata p, from the token_prices entry of its gen_cmd command.
+func estimateCost(cfg Config, p projectMeta) float64 {
+	price, ok := cfg.TokenPrices[p.GenCmd]
+	if !ok {
+		return 0
+	}
+	return (float64(p.PromptTokens)*price.Input + float64(p.SummaryTokens)*price.Output) / 1e6
+}
+
+// sourceMeta is a raw data file a section was generated from.
+type sourceMeta struct {
+	Path   string // relative to the raw dir if inside it
+	SHA256 string
+}
+
+// bulkSourceMeta returns the source files of sources that exist, with the
+// hashes of their contents.
+func bulkSourceMeta(cfg Config, sources []bulkSource) []sourceMeta {
+	rawDir := resolveRawDir(cfg)
+	var meta []sourceMeta
+	for _, src := range sources {
+		for _, path := range src.sourcePaths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			sum := sha256.Sum256(data)
+			if rel, err := filepath.Rel(rawDir, path); err == nil && filepath.IsLocal(rel) {
+				path = filepath.ToSlash(rel)
+			}
+			meta = append(meta, sourceMeta{Path: path, SHA256: hex.EncodeToString(sum[:])})
+		}
+	}
+	return meta
+}
+
+// notesHash returns the hash recorded for a project's notes entries. The
+// notes file is shared by all projects, so it is not a source of its own.
+func notesHash(notes string) string {
+	if notes == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(notes))
+	return hex.EncodeToString(sum[:])
+}
+
+// sectionUpToDate reports whether the section of project in the summary
+// with metadata m was generated from the data project has on date now: the
+// same comp_cmd, the same source files with the same contents, and the same
+// notes entries. A section without metadata is never up to date.
+func sectionUpToDate(cfg Config, state State, m summaryMeta, project, date string) bool {
+	pm, ok := m.project(project)
+	if !ok || m.CompCmd != cfg.CompCmd {
+		return false
+	}
+	return reflect.DeepEqual(pm.Sources, bulkSourceMeta(cfg, collectBulkSources(cfg, state, project, date))) &&
+		pm.Notes == notesHash(collectProjectNotes(cfg, project, date))
+}
+
+// project returns the metadata of project's section, if there is any.
+func (m summaryMeta) project(project string) (projectMeta, bool) {
+	for _, p := range m.Projects {
+		if p.Project == project {
+			return p, true
+		}
+	}
+	return projectMeta{}, false
+}
+
+// yamlString quotes s for YAML. A JSON string is a valid YAML one.
+func yamlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// formatSummaryMeta returns m as YAML frontmatter, including the "---"
+// delimiters.
+func formatSummaryMeta(m summaryMeta) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "generated: %s\n", m.Generated.Format(time.RFC3339))
+	fmt.Fprintf(&b, "comp_cmd: %s\n", yamlString(m.CompCmd))
+	var cost float64
+	for _, p := range m.Projects {
+		cost += p.Cost
+	}
+	if cost > 0 {
+		fmt.Fprintf(&b, "cost: %.4f\n", cost)
+	}
+	b.WriteString("projects:\n")
+	for _, p := range m.Projects {
+		fmt.Fprintf(&b, "  %s:\n", yamlString(p.Project))
+		fmt.Fprintf(&b, "    generated: %s\n", p.Generated.Format(time.RFC3339))
+		fmt.Fprintf(&b, "    gen_cmd: %s\n", yamlString(p.GenCmd))
+		fmt.Fprintf(&b, "    prompt_tokens: %d\n", p.PromptTokens)
+		fmt.Fprintf(&b, "    summary_tokens: %d\n", p.SummaryTokens)
+		if p.Cost > 0 {
+			fmt.Fprintf(&b, "    cost: %.4f\n", p.Cost)
+		}
+		if p.Notes != "" {
+			fmt.Fprintf(&b, "    notes: %s\n", yamlString(p.Notes))
+		}
+		if len(p.Sources) == 0 {
+			b.WriteString("    sources: {}\n")
+			continue
+		}
+		b.WriteString("    sources:\n")
+		for _, s := range p.Sources {
+			fmt.Fprintf(&b, "      %s: %s\n", yamlString(s.Path), yamlString(s.SHA256))
+		}
+	}
+	b.WriteString("---\n")
+	return b.String()
+}
+
+// keptMetaPath returns the file recording the metadata of date's summary
+// after a regeneration that changed its sources but not its text (see
+// saveKeptMeta).
+func keptMetaPath(date string) string {
+	return filepath.Join(filepath.Dir(resolveStatePath()), "summary-meta", date+".md")
+}
+
+// saveKeptMeta records m as the metadata of date's summary, whose text below
+// the frontmatter is body, leaving the summary file itself as it is: a
+// summary that regenerates the same is not touched, for the sake of sync
+// tools and git hooks watching the log dir. The file holds the frontmatter
+// followed by the hash of body, and its mtime is when the summary was last
+// found up to date.
+func saveKeptMeta(date string, m summaryMeta, body string) error {
+	path := keptMetaPath(date)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+	sum := sha256.Sum256([]byte(body))
+	if err := os.WriteFile(path, []byte(formatSummaryMeta(m)+hex.EncodeToString(sum[:])+"\n"), 0o644); err != nil {
+		return fmt.Errorf("saving summary metadata: %w", err)
+	}
+	return nil
+}
+
+// readKeptMeta returns the metadata recorded by saveKeptMeta for date's
+// summary and when it was recorded, if it was recorded for a summary whose
+// text below the frontmatter is body. Once the summary is rewritten, it no
+// longer applies.
+func readKeptMeta(date, body string) (summaryMeta, time.Time, bool) {
+	path := keptMetaPath(date)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return summaryMeta{}, time.Time{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return summaryMeta{}, time.Time{}, false
+	}
+	m, hash := splitSummaryMeta(string(data))
+	sum := sha256.Sum256([]byte(body))
+	if strings.TrimSpace(hash) != hex.EncodeToString(sum[:]) {
+		return summaryMeta{}, time.Time{}, false
+	}
+	return m, info.ModTime(), true
+}
+
+// readSummaryFile reads the summary of date at path, returning its
+// metadata (that of saveKeptMeta if it applies), its text below the
+// frontmatter, and when it was last generated or found up to date.
+func readSummaryFile(date, path string) (summaryMeta, string, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return summaryMeta{}, "", time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return summaryMeta{}, "", time.Time{}, err
+	}
+	m, body := splitSummaryMeta(string(data))
+	at := info.ModTime()
+	if km, keptAt, ok := readKeptMeta(date, body); ok {
+		m = km
+		if keptAt.After(at) {
+			at = keptAt
+		}
+	}
+	return m, body, at, nil
+}
+
+// splitSummaryMeta splits a summary file into its frontmatter, parsed, and
+// the summary after it. A summary without frontmatter, such as one generated
+// before it was written, has an empty summaryMeta.
+func splitSummaryMeta(content string) (summaryMeta, string) {
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return summaryMeta{}, content
+	}
+	front, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return summaryMeta{}, content
+	}
+	return parseSummaryMeta(front), body
+}
+
+// stripSummaryMeta returns a summary file without its frontmatter.
+func stripSummaryMeta(content string) string {
+	_, body := splitSummaryMeta(content)
+	return body
+}
+
+// parseSummaryMeta parses frontmatter written by formatSummaryMeta. Lines it
+// does not know are ignored.
+func parseSummaryMeta(front string) summaryMeta {
+	var m summaryMeta
+	var cur *projectMeta
+	for _, line := range strings.Split(front, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		key, value, ok := yamlKeyValue(trimmed)
+		if !ok {
+			continue
+		}
+		switch indent := len(line) - len(trimmed); {
+		case indent == 0:
+			cur = nil
+			switch key {
+			case "generated":
+				m.Generated, _ = time.Parse(time.RFC3339, value)
+			case "comp_cmd":
+				m.CompCmd = value
+			}
+		case indent == 2:
+			m.Projects = append(m.Projects, projectMeta{Project: key})
+			cur = &m.Projects[len(m.Projects)-1]
+		case indent == 4 && cur != nil:
+			switch key {
+			case "generated":
+				cur.Generated, _ = time.Parse(time.RFC3339, value)
+			case "gen_cmd":
+				cur.GenCmd = value
+			case "prompt_tokens":
+				cur.PromptTokens, _ = strconv.Atoi(value)
+			case "summary_tokens":
+				cur.SummaryTokens, _ = strconv.Atoi(value)
+			case "cost":
+				cur.Cost, _ = strconv.ParseFloat(value, 64)
+			case "notes":
+				cur.Notes = value
+			}
+		case indent == 6 && cur != nil:
+			cur.Sources = append(cur.Sources, sourceMeta{Path: key, SHA256: value})
+		}
+	}
+	return m
+}
+
+// yamlKeyValue splits a "key: value" line, unquoting a quoted key or value.
+func yamlKeyValue(line string) (key, value string, ok bool) {
+	if strings.HasPrefix(line, `"`) {
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil || json.Unmarshal([]byte(quoted), &key) != nil {
+			return "", "", false
+		}
+		value, ok = strings.CutPrefix(line[len(quoted):], ":")
+	} else {
+		key, value, ok = strings.Cut(line, ":")
+	}
+	if !ok {
+		return "", "", false
+	}
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, `"`) {
+		var s string
+		if json.Unmarshal([]byte(value), &s) == nil {
+			value = s
+		}
+	}
+	return key, value, true
+}
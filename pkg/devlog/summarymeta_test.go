@@ -0,0 +1,39 @@
+package devlog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummaryMetaRoundTrip(t *testing.T) {
+	generated := time.Date(2024, 1, 15, 18, 2, 3, 0, time.UTC)
+	m := summaryMeta{
+		Generated: generated,
+		CompCmd:   `gemini --model "flash"`,
+		Projects: []projectMeta{
+			{
+				Project: "api", Generated: generated, GenCmd: "claude -p", PromptTokens: 1200, SummaryTokens: 80, Cost: 0.0048,
+				Sources: []sourceMeta{{Path: "2024-01-15/git-api.log", SHA256: "ab12"}, {Path: "/home/me/.claude/projects/x/s: 1.jsonl", SHA256: "cd34"}},
+			},
+			{Project: "general", Generated: generated.Add(-time.Hour), GenCmd: "ollama run llama3", Notes: "ef56"},
+		},
+	}
+	body := "# 2024-01-15\n\n## api\n\nDid things.\n"
+
+	got, gotBody := splitSummaryMeta(formatSummaryMeta(m) + body)
+	if gotBody != body {
+		t.Errorf("body = %q, want %q", gotBody, body)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("parsed metadata = %+v, want %+v", got, m)
+	}
+}
+
+func TestSplitSummaryMetaWithoutFrontmatter(t *testing.T) {
+	content := "# 2024-01-15\n\n---\n\n## api\n\nDid things.\n"
+	m, body := splitSummaryMeta(content)
+	if body != content || len(m.Projects) != 0 {
+		t.Errorf("splitSummaryMeta = %+v, %q; want the content unchanged", m, body)
+	}
+}
@@ -0,0 +1,247 @@
+package devlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// defaultIdleGap is the longest pause between two pieces of activity
+	// that still counts as continuous work.
+	defaultIdleGap = 15 * time.Minute
+
+	// activityCredit is the work credited before each point in time with
+	// activity (a snapshot, note, or Claude message), so that an isolated
+	// event still counts for something.
+	activityCredit = 5 * time.Minute
+)
+
+// timeSpan is an interval of activity.
+type timeSpan struct {
+	Start, End time.Time
+}
+
+// TimeEntry is the time worked on a project on one date.
+type TimeEntry struct {
+	Date    string `json:"date"`
+	Project string `json:"project"`
+	Minutes int    `json:"minutes"`
+	First   string `json:"first"`  // HH:MM of the first activity
+	Last    string `json:"last"`   // HH:MM of the last activity
+	Source  string `json:"source"` // "sessions" or "activity"
+}
+
+// TimeReport is the output of `devlog time`.
+type TimeReport struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	IdleMinutes int         `json:"idle_minutes"`
+	Entries     []TimeEntry `json:"entries"`
+}
+
+var snapshotTimeRe = regexp.MustCompile(`(?m)^=== SNAPSHOT (\d{2}:\d{2}) ===$`)
+
+// clockOn returns the time hh:mm on date in loc.
+func clockOn(date, hhmm string, loc *time.Location) (time.Time, bool) {
+	t, err := time.ParseInLocation("2006-01-02 15:04", date+" "+hhmm, loc)
+	return t, err == nil
+}
+
+// pointSpan credits the activityCredit before t.
+func pointSpan(t time.Time) timeSpan {
+	return timeSpan{Start: t.Add(-activityCredit), End: t}
+}
+
+// projectActivity collects the activity of project on date: the times of its
+// git snapshots, notes, and Claude Code messages, and the spans of its
+// terminal sessions.
+func projectActivity(cfg Config, state State, date, project string, loc *time.Location) []timeSpan {
+	var spans []timeSpan
+
+	if data, err := os.ReadFile(resolveGitPath(cfg, date, project)); err == nil {
+		for _, m := range snapshotTimeRe.FindAllStringSubmatch(string(data), -1) {
+			if t, ok := clockOn(date, m[1], loc); ok {
+				spans = append(spans, pointSpan(t))
+			}
+		}
+	}
+
+	for _, line := range strings.Split(readNotes(cfg, date), "\n") {
+		tags, ok := noteHeadingTags(cfg.noteHeadings(), line)
+		if !ok {
+			continue
+		}
+		if project == "general" && len(tags) == 0 || containsString(tags, project) {
+			if t, ok := clockOn(date, noteEntryClock(cfg.noteHeadings(), line), loc); ok {
+				spans = append(spans, pointSpan(t))
+			}
+		}
+	}
+
+	if matches, err := filepath.Glob(resolveTermGlob(cfg, date, project)); err == nil {
+		for _, m := range matches {
+			if start, end, ok := termSessionSpan(m, loc); ok {
+				spans = append(spans, timeSpan{Start: start, End: end})
+			}
+		}
+	}
+
+	if claudeDir := resolveClaudeCodeDir(cfg); claudeDir != "" {
+		for _, w := range state.Watched {
+			if w.Name == project {
+				for _, t := range claudeUsageForDate(claudeSourcesFor(claudeDir, w), date, loc).Times {
+					spans = append(spans, pointSpan(t))
+				}
+				break
+			}
+		}
+	}
+	return spans
+}
+
+// mergeSpans sorts spans and joins those separated by at most idle, returning
+// the worked intervals.
+func mergeSpans(spans []timeSpan, idle time.Duration) []timeSpan {
+	if len(spans) == 0 {
+		return nil
+	}
+	sorted := append([]timeSpan(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	merged := []timeSpan{sorted[0]}
+	for _, s := range sorted[1:] {
+		cur := &merged[len(merged)-1]
+		if s.Start.Sub(cur.End) <= idle {
+			if s.End.After(cur.End) {
+				cur.End = s.End
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func spansDuration(spans []timeSpan) time.Duration {
+	var d time.Duration
+	for _, s := range spans {
+		d += s.End.Sub(s.Start)
+	}
+	return d
+}
+
+// collectTime estimates the time worked on each project on each of dates.
+// Projects are discovered as for summary generation, with unaffiliated notes
+// under "general". A project with explicit work sessions on a date (`devlog
+// session`) is credited with their time instead of its inferred activity.
+func collectTime(cfg Config, state State, dates []string, idle time.Duration) TimeReport {
+	now := time.Now()
+	loc := now.Location()
+	report := TimeReport{
+		From:        dates[0],
+		To:          dates[len(dates)-1],
+		IdleMinutes: int(idle.Minutes()),
+		Entries:     []TimeEntry{},
+	}
+	for _, date := range dates {
+		projects := discoverAllProjects(cfg, state, date)
+		if hasUnaffiliatedNotes(cfg, date) {
+			projects = append(projects, "general")
+		}
+		for _, proj := range sessionProjects(cfg, date) {
+			if !containsString(projects, proj) {
+				projects = append(projects, proj)
+			}
+		}
+		for _, proj := range projects {
+			source := "sessions"
+			worked := mergeSpans(sessionSpans(cfg, date, proj, now), 0)
+			if len(worked) == 0 {
+				source = "activity"
+				worked = mergeSpans(projectActivity(cfg, state, date, proj, loc), idle)
+			}
+			if len(worked) == 0 {
+				continue
+			}
+			report.Entries = append(report.Entries, TimeEntry{
+				Date:    date,
+				Project: proj,
+				Minutes: int(spansDuration(worked).Round(time.Minute).Minutes()),
+				First:   worked[0].Start.In(loc).Format("15:04"),
+				Last:    worked[len(worked)-1].End.In(loc).Format("15:04"),
+				Source:  source,
+			})
+		}
+	}
+	return report
+}
+
+// projectMinutes totals the report's minutes per project.
+func (r TimeReport) projectMinutes() map[string]int {
+	totals := make(map[string]int)
+	for _, e := range r.Entries {
+		totals[e.Project] += e.Minutes
+	}
+	return totals
+}
+
+// formatHours renders minutes as decimal hours, e.g. 90 -> "1.50".
+func formatHours(minutes int) string {
+	return fmt.Sprintf("%.2f", float64(minutes)/60)
+}
+
+func printTimeTable(w io.Writer, report TimeReport) {
+	if report.From == report.To {
+		fmt.Fprintf(w, "Time for %s", report.From)
+	} else {
+		fmt.Fprintf(w, "Time for %s to %s", report.From, report.To)
+	}
+	fmt.Fprintf(w, " (idle gap %dm)\n\n", report.IdleMinutes)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tPROJECT\tHOURS\tFIRST\tLAST\tSOURCE")
+	for _, e := range report.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Date, e.Project, formatHours(e.Minutes), e.First, e.Last, e.Source)
+	}
+	tw.Flush()
+
+	totals := report.projectMinutes()
+	if len(report.Entries) < 2 {
+		return
+	}
+	names := make([]string, 0, len(totals))
+	total := 0
+	for name, m := range totals {
+		names = append(names, name)
+		total += m
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tHOURS")
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%s\n", name, formatHours(totals[name]))
+	}
+	if len(names) > 1 {
+		fmt.Fprintf(tw, "total\t%s\n", formatHours(total))
+	}
+	tw.Flush()
+}
+
+func printTimeJSON(w io.Writer, report TimeReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,82 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeSpans(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		tm, _ := clockOn("2024-01-15", hhmm, time.UTC)
+		return tm
+	}
+	spans := []timeSpan{
+		{at("10:20"), at("10:25")},
+		{at("09:00"), at("09:30")},
+		{at("09:40"), at("09:45")}, // 10 minutes after the previous span: merged
+		{at("09:42"), at("09:44")}, // inside the previous span
+	}
+	merged := mergeSpans(spans, 15*time.Minute)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 intervals, got %+v", merged)
+	}
+	if !merged[0].Start.Equal(at("09:00")) || !merged[0].End.Equal(at("09:45")) {
+		t.Errorf("unexpected first interval %+v", merged[0])
+	}
+	if d := spansDuration(merged); d != 50*time.Minute {
+		t.Errorf("expected 50m worked, got %s", d)
+	}
+
+	if merged := mergeSpans(spans, 0); len(merged) != 3 {
+		t.Errorf("expected 3 intervals without an idle allowance, got %+v", merged)
+	}
+}
+
+func TestCollectTime(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	dateDir := filepath.Join(rawDir, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+
+	// Snapshots from 09:05 to 09:30 are one block (09:00-09:30); the 14:00
+	// snapshot is isolated and credited 5 minutes.
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"), []byte(
+		"=== SNAPSHOT 09:05 ===\n+a\n\n=== SNAPSHOT 09:15 ===\n+b\n\n=== SNAPSHOT 09:30 ===\n+c\n\n=== SNAPSHOT 14:00 ===\n+d\n\n"), 0o644)
+	// A terminal session overlapping the first block extends it to 10:00.
+	os.WriteFile(filepath.Join(dateDir, "term-alpha.log"), []byte(
+		"Script started on 2024-01-15 09:20:00+00:00\n$ make\nScript done on 2024-01-15 10:00:00+00:00\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"), []byte(
+		"### At 16:00 #alpha\nshipped\n\n### At 11:00\nplanning\n"), 0o644)
+
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty}
+	report := collectTime(cfg, State{}, []string{"2024-01-15"}, 15*time.Minute)
+
+	byProject := make(map[string]TimeEntry)
+	for _, e := range report.Entries {
+		byProject[e.Project] = e
+	}
+	alpha := byProject["alpha"]
+	// 09:00-10:00, 13:55-14:00, and 15:55-16:00 for the note.
+	if alpha.Minutes != 70 || alpha.First != "09:00" || alpha.Last != "16:00" {
+		t.Errorf("unexpected alpha entry %+v", alpha)
+	}
+	if general := byProject["general"]; general.Minutes != 5 {
+		t.Errorf("expected 5 minutes for the unaffiliated note, got %+v", general)
+	}
+	if report.IdleMinutes != 15 {
+		t.Errorf("expected idle 15, got %d", report.IdleMinutes)
+	}
+
+	var buf bytes.Buffer
+	printTimeTable(&buf, report)
+	out := buf.String()
+	if !strings.Contains(out, "2024-01-15  alpha    1.17") || !strings.Contains(out, "total    1.25") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+}
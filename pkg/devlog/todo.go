@@ -0,0 +1,193 @@
+package devlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// todoItem is an action item recorded in notes, either as a "TODO: ..." line
+// or a Markdown checkbox.
+type todoItem struct {
+	Date     string
+	Projects []string
+	Text     string
+	Done     bool
+}
+
+func (t todoItem) key() string {
+	return strings.Join(t.Projects, ",") + "\x00" + t.Text
+}
+
+var (
+	noteTodoRe     = regexp.MustCompile(`^\s*(?:[-*]\s+)?TODO:\s*(.+)$`)
+	noteCheckboxRe = regexp.MustCompile(`^\s*[-*]\s+\[([ xX])\]\s+(.+)$`)
+	todoLineRe     = regexp.MustCompile(`^- \[([ xX])\] (\d{4}-\d{2}-\d{2})((?: #\S+)*) (.+)$`)
+)
+
+// extractTodos returns the action items in a notes file for date, tagged with
+// the projects of the note they appear in. Checked boxes are returned as done
+// so that ticking an item in the notes resolves it.
+func extractTodos(headings *noteHeadingFormat, content, date string) []todoItem {
+	var items []todoItem
+	var projects []string
+	for _, line := range strings.Split(content, "\n") {
+		if tags, ok := noteHeadingTags(headings, line); ok {
+			projects = tags
+			continue
+		}
+		if m := noteCheckboxRe.FindStringSubmatch(line); m != nil {
+			items = append(items, todoItem{Date: date, Projects: projects, Text: strings.TrimSpace(m[2]), Done: m[1] != " "})
+		} else if m := noteTodoRe.FindStringSubmatch(line); m != nil {
+			items = append(items, todoItem{Date: date, Projects: projects, Text: strings.TrimSpace(m[1])})
+		}
+	}
+	return items
+}
+
+// openTodoTexts returns the text of the unchecked items in notes, for the
+// summary prompt.
+func openTodoTexts(headings *noteHeadingFormat, notes string) []string {
+	var texts []string
+	for _, t := range extractTodos(headings, notes, "") {
+		if !t.Done {
+			texts = append(texts, t.Text)
+		}
+	}
+	return texts
+}
+
+func resolveTodoPath(cfg Config) string {
+	return filepath.Join(resolveLogDir(cfg), "todo.md")
+}
+
+// loadTodos reads todo.md. A missing file is an empty list; lines that are
+// not items are ignored.
+func loadTodos(path string) ([]todoItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading todo list: %w", err)
+	}
+	var items []todoItem
+	for _, line := range strings.Split(string(data), "\n") {
+		m := todoLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var projects []string
+		for _, f := range strings.Fields(m[3]) {
+			projects = append(projects, strings.TrimPrefix(f, "#"))
+		}
+		items = append(items, todoItem{Date: m[2], Projects: projects, Text: m[4], Done: m[1] != " "})
+	}
+	return items, nil
+}
+
+func saveTodos(path string, items []todoItem) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating log dir: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString("# TODO\n\n")
+	for _, t := range items {
+		b.WriteString(formatTodo(t) + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("writing todo list: %w", err)
+	}
+	return nil
+}
+
+// formatTodo renders an item as a todo.md line, e.g.
+// "- [ ] 2024-01-15 #infra fix the flaky test".
+func formatTodo(t todoItem) string {
+	box := " "
+	if t.Done {
+		box = "x"
+	}
+	line := "- [" + box + "] " + t.Date
+	for _, p := range t.Projects {
+		line += " #" + p
+	}
+	return line + " " + t.Text
+}
+
+// mergeTodos adds the items found in notes to the list. Items already listed
+// keep their position and first date; an item checked off in either place
+// stays done.
+func mergeTodos(items, found []todoItem) []todoItem {
+	index := make(map[string]int, len(items))
+	for i, t := range items {
+		index[t.key()] = i
+	}
+	for _, t := range found {
+		if i, ok := index[t.key()]; ok {
+			items[i].Done = items[i].Done || t.Done
+			continue
+		}
+		index[t.key()] = len(items)
+		items = append(items, t)
+	}
+	return items
+}
+
+// syncTodos collects the action items in the notes for dates into todo.md
+// and returns the updated list.
+func syncTodos(cfg Config, dates []string) ([]todoItem, error) {
+	path := resolveTodoPath(cfg)
+	items, err := loadTodos(path)
+	if err != nil {
+		return nil, err
+	}
+	n := len(items)
+	changed := false
+	for _, date := range dates {
+		notes := readNotes(cfg, date)
+		if notes == "" {
+			continue
+		}
+		before := countDone(items)
+		items = mergeTodos(items, extractTodos(cfg.noteHeadings(), notes, date))
+		changed = changed || countDone(items) != before
+	}
+	if changed || len(items) != n {
+		if err := saveTodos(path, items); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func countDone(items []todoItem) int {
+	n := 0
+	for _, t := range items {
+		if t.Done {
+			n++
+		}
+	}
+	return n
+}
+
+// printTodos lists items numbered by their position in todo.md, the numbers
+// accepted by "devlog todo done".
+func printTodos(w io.Writer, items []todoItem, project string, all bool) int {
+	shown := 0
+	for i, t := range items {
+		if t.Done && !all {
+			continue
+		}
+		if project == "general" && len(t.Projects) > 0 ||
+			project != "" && project != "general" && !containsString(t.Projects, project) {
+			continue
+		}
+		fmt.Fprintf(w, "%3d. %s\n", i+1, formatTodo(t)[2:])
+		shown++
+	}
+	return shown
+}
@@ -0,0 +1,96 @@
+package devlog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractTodos(t *testing.T) {
+	content := "### At 09:00 #infra\nCI is flaky.\nTODO: retry the upload step\n- [ ] pin the runner image\n\n" +
+		"### At 10:00\n- [x] renew certificate\nNothing to do about TODO items here\n\n"
+
+	items := extractTodos(nil, content, "2024-01-15")
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %+v", items)
+	}
+	if items[0].Text != "retry the upload step" || items[0].Done || items[0].Projects[0] != "infra" {
+		t.Errorf("unexpected TODO item: %+v", items[0])
+	}
+	if items[1].Text != "pin the runner image" || items[1].Done {
+		t.Errorf("unexpected checkbox item: %+v", items[1])
+	}
+	if items[2].Text != "renew certificate" || !items[2].Done || len(items[2].Projects) != 0 {
+		t.Errorf("unexpected checked item: %+v", items[2])
+	}
+}
+
+func TestTodoRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.md")
+	items := []todoItem{
+		{Date: "2024-01-15", Projects: []string{"alpha", "beta"}, Text: "update the shared client"},
+		{Date: "2024-01-16", Text: "book travel", Done: true},
+	}
+	if err := saveTodos(path, items); err != nil {
+		t.Fatalf("saveTodos: %v", err)
+	}
+	got, err := loadTodos(path)
+	if err != nil {
+		t.Fatalf("loadTodos: %v", err)
+	}
+	if len(got) != 2 || got[0].key() != items[0].key() || got[0].Date != "2024-01-15" || !got[1].Done {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+}
+
+func TestSyncTodos(t *testing.T) {
+	rawDir := t.TempDir()
+	cfg := Config{RawDir: rawDir, LogDir: t.TempDir()}
+	notesPath := filepath.Join(rawDir, "2024-01-15", "notes.md")
+	os.MkdirAll(filepath.Dir(notesPath), 0o755)
+	os.WriteFile(notesPath, []byte("### At 09:00 #infra\nTODO: retry the upload step\n- [ ] pin the runner image\n\n"), 0o644)
+
+	items, err := syncTodos(cfg, []string{"2024-01-15"})
+	if err != nil || len(items) != 2 {
+		t.Fatalf("syncTodos = %+v, %v", items, err)
+	}
+
+	// Checking an item off in todo.md survives a later sync.
+	items[0].Done = true
+	saveTodos(resolveTodoPath(cfg), items)
+	items, _ = syncTodos(cfg, []string{"2024-01-15"})
+	if len(items) != 2 || !items[0].Done {
+		t.Errorf("expected first item to stay done: %+v", items)
+	}
+
+	// Ticking the box in the notes resolves the item too.
+	os.WriteFile(notesPath, []byte("### At 09:00 #infra\nTODO: retry the upload step\n- [x] pin the runner image\n\n"), 0o644)
+	items, _ = syncTodos(cfg, []string{"2024-01-15"})
+	if !items[1].Done {
+		t.Errorf("expected second item to be done: %+v", items)
+	}
+
+	var buf bytes.Buffer
+	if n := printTodos(&buf, items, "", false); n != 0 {
+		t.Errorf("expected no open items, got %d:\n%s", n, buf.String())
+	}
+	printTodos(&buf, items, "infra", true)
+	if !strings.Contains(buf.String(), "  2. [x] 2024-01-15 #infra pin the runner image") {
+		t.Errorf("unexpected listing:\n%s", buf.String())
+	}
+}
+
+func TestAssemblePromptOpenTodos(t *testing.T) {
+	files := map[string]string{
+		"notes.md": "### At 10:20 #myproject\nTODO: write the migration\n- [x] review the schema\n",
+	}
+	prompt := assemblePrompt(nil, "myproject", "2024-01-15", files, "", "")
+	if !strings.Contains(prompt, "Open TODO items recorded in the notes:\n- write the migration\n") {
+		t.Error("prompt should list open TODO items")
+	}
+	if strings.Contains(prompt, "- review the schema") {
+		t.Error("prompt should not list done items")
+	}
+}
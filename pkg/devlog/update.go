@@ -0,0 +1,150 @@
+package devlog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// updatePromptFile is the name the current summary goes by in the update
+// prompt.
+const updatePromptFile = "summary.md"
+
+// canUpdateSummaries reports whether stale sections may be updated rather
+// than regenerated. The question and decision trailers list the whole day's
+// questions and decisions, which an update sees only part of.
+func canUpdateSummaries(cfg Config) bool {
+	return cfg.UpdateSummaries && !cfg.TrackQuestions && !cfg.TrackDecisions
+}
+
+// stripDiffFooter returns a section's text without the line diffFooter
+// added to it, so that it is not fed back to the summarizer.
+func stripDiffFooter(text string) string {
+	i := strings.LastIndex(text, "\n\nDiff stats: ")
+	if i < 0 || strings.Contains(text[i+2:], "\n") {
+		return text
+	}
+	return text[:i]
+}
+
+// assembleUpdatePrompt returns the prompt asking to update summary, the
+// section of project for date written at since, with files, the data that
+// arrived after it (see updateActivity).
+func assembleUpdatePrompt(project, date, summary string, files map[string]string, since time.Time, instructions string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are updating the summary of a day of software engineering work on the\n"+
+		"project %q for the date %s. The summary was written at %s, and more\n"+
+		"work was done after that. Below are the summary and the data collected since.\n", project, date, since.Format("15:04"))
+
+	fmt.Fprintf(&b, "\n--- %s ---\n%s\n", updatePromptFile, summary)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n--- %s ---\n%s\n", name, files[name])
+	}
+
+	fmt.Fprintf(&b, `
+Description of data sources:
+
+- %s: The current summary of the day, up to %s.
+- git-%s.log: Time-stamped snapshots of the uncommitted code changes taken
+  since, each a full diff; the last one is the current state of the code.
+- term-%s.log: The end of the terminal session recordings written to since:
+  commands run and their output, including test runs and errors.
+- claude-%s.txt: Conversations with an AI coding assistant since.
+- notes-%s.md: All of the day's notes on the project, with their times. The
+  summary already reflects those from before %s.
+
+Not all sources may be present. Work with whatever is available.
+
+Task: Rewrite the summary so that it covers the whole day, including the new
+work. Keep what it says about the earlier work, and its wording, unless the
+new data changes it: a problem that was solved later, an approach that was
+abandoned, or next steps that were done. Follow the rules the summary was
+written by:
+
+- Write flowing prose in first person, with bullet points where appropriate
+  for lists of items.
+- Do NOT include timestamps in the summary.
+- Do NOT use headings.
+- End with a line "Next steps:" followed by a bulleted list of concrete next
+  steps, updated for the new work.
+`, updatePromptFile, since.Format("15:04"), project, project, project, project, since.Format("15:04"))
+
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		fmt.Fprintf(&b, "\nAdditional guidelines for this project:\n%s\n", instructions)
+	}
+	b.WriteString(`
+Output only the updated summary text, nothing else.
+`)
+	return b.String()
+}
+
+// updateActivity returns the data of project on date that arrived after
+// since, as recentActivity finds it, with all of the day's notes on the
+// project in place of the recent ones: a note can be backdated, so its time
+// does not say whether the summary saw it. It returns nil if none of the
+// data is dated after since, or since is not on date.
+func updateActivity(cfg Config, state State, project, date string, since, now time.Time) map[string]string {
+	if since.Format("2006-01-02") != date {
+		return nil
+	}
+	if end, err := time.ParseInLocation("2006-01-02", date, since.Location()); err == nil {
+		end = end.AddDate(0, 0, 1).Add(-time.Second)
+		if now.After(end) {
+			now = end
+		}
+	}
+	files := recentActivity(cfg, state, project, since, now)
+	notesFile := "notes-" + project + ".md"
+	delete(files, notesFile)
+	if len(files) == 0 {
+		return nil
+	}
+	if notes := strings.TrimSpace(collectProjectNotes(cfg, project, date)); notes != "" {
+		files[notesFile] = notes
+	}
+	return files
+}
+
+// updateProjectSummary regenerates the section of project on date by
+// asking gen_cmd to update old, its text in the summary written at since,
+// with the data that arrived after since. It reports false, for the section
+// to be regenerated in full, if there is no such data: the change cannot be
+// told apart by time, as for a backdated note or data of a past day.
+func updateProjectSummary(ctx context.Context, cfg Config, state State, project, date, old string, since time.Time, p *genProgress) (summary string, meta projectMeta, ok bool, err error) {
+	files := updateActivity(cfg, state, project, date, since, time.Now())
+	if files == nil {
+		return "", meta, false, nil
+	}
+	meta = projectMeta{
+		Project: project,
+		Sources: bulkSourceMeta(cfg, collectBulkSources(cfg, state, project, date)),
+		Notes:   notesHash(collectProjectNotes(cfg, project, date)),
+	}
+
+	counts := applyTokenBudget(files, cfg.TokenBudget)
+	warnTruncated("update prompt for "+project, counts, cfg.TokenBudget)
+	prompt := assembleUpdatePrompt(project, date, stripDiffFooter(old), files, since, projectInstructions(cfg, state, project))
+
+	p.printf("updating %s with the data since %s (~%d tokens)…", project, since.Format("15:04"), estimateTokens(prompt))
+	start := time.Now()
+	defer func() { p.record(project, "update", time.Since(start)) }()
+
+	meta.Generated, meta.PromptTokens = time.Now(), estimateTokens(prompt)
+	summary, meta.GenCmd, err = runSummarizer(ctx, cfg, project, prompt, p)
+	if err != nil {
+		return "", meta, false, err
+	}
+	if cfg.NormalizeSummary {
+		summary = normalizeSummary(cfg, summary)
+	}
+	meta.SummaryTokens = estimateTokens(summary)
+	meta.Cost = estimateCost(cfg, meta)
+	return summary, meta, true, nil
+}
@@ -0,0 +1,164 @@
+package devlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateActivity(t *testing.T) {
+	rawDir := t.TempDir()
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	cfg := Config{ClaudeCodeDir: new(string)}
+	date := "2024-01-15"
+	dayDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dayDir, 0o755)
+	os.WriteFile(filepath.Join(dayDir, "git-api.log"),
+		[]byte("=== SNAPSHOT 10:00 ===\nold diff\n\n=== SNAPSHOT 14:00 ===\nnew diff\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dayDir, "notes.md"),
+		[]byte("### At 09:00 #api\nBackdated.\n\n### At 13:00 #api\nAfternoon.\n"), 0o644)
+
+	since := time.Date(2024, 1, 15, 12, 0, 0, 0, time.Local)
+	got := updateActivity(cfg, State{}, "api", date, since, since.AddDate(0, 0, 1))
+	want := map[string]string{
+		"git-api.log":  "=== SNAPSHOT 14:00 ===\nnew diff",
+		"notes-api.md": "### At 09:00 #api\nBackdated.\n\n### At 13:00 #api\nAfternoon.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("updateActivity = %q, want %q", got, want)
+	}
+
+	// Only notes since the summary, or a summary written on a later day:
+	// nothing that can be told apart by time.
+	if got := updateActivity(cfg, State{}, "api", date, since.Add(3*time.Hour), since.AddDate(0, 0, 1)); got != nil {
+		t.Errorf("updateActivity with only notes = %q, want nil", got)
+	}
+	if got := updateActivity(cfg, State{}, "api", date, since.AddDate(0, 0, 1), since.AddDate(0, 0, 2)); got != nil {
+		t.Errorf("updateActivity for a summary of a past day = %q, want nil", got)
+	}
+
+	old := "I started on the pool.\n\nNext steps:\n- Tune it\n\nDiff stats: +3/-1 lines in 1 file, 4 lines of churn."
+	prompt := assembleUpdatePrompt("api", date, stripDiffFooter(old), got, since, "")
+	if !strings.Contains(prompt, "--- summary.md ---\nI started on the pool.\n\nNext steps:\n- Tune it\n\n--- git-api.log ---") ||
+		!strings.Contains(prompt, "written at 12:00") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+	if err := validateSummary(prompt); err == nil {
+		t.Error("an echoed update prompt should be rejected")
+	}
+}
+
+func TestRunGenUpdatesSummary(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+
+	// The summarizer says which prompt it got.
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"),
+		[]byte("#!/bin/sh\nif grep -q 'You are updating' ; then echo 'Updated summary.'; else echo 'Full summary.'; fi\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	now := time.Now()
+	date := now.Format("2006-01-02")
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	gitLog := filepath.Join(dateDir, "git-api.log")
+	os.WriteFile(gitLog, []byte("=== SNAPSHOT 00:00 ===\ndiff\n\n"), 0o644)
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mycompressor", ClaudeCodeDir: new(string), UpdateSummaries: true}
+	if _, err := runGen(context.Background(), cfg, State{}, date, nil); err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+
+	// A snapshot taken after the summary was written.
+	summaryPath := filepath.Join(logDir, date+".md")
+	earlier := now.Add(-time.Minute)
+	os.Chtimes(summaryPath, earlier, earlier)
+	f, _ := os.OpenFile(gitLog, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("=== SNAPSHOT " + now.Format("15:04") + " ===\nmore diff\n\n")
+	f.Close()
+
+	if _, err := runGen(context.Background(), cfg, State{}, date, nil); err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+	content, _ := os.ReadFile(summaryPath)
+	if sections := splitSummary(stripSummaryMeta(string(content))); len(sections) != 1 || sections[0].Text != "Updated summary." {
+		t.Errorf("sections = %+v, want the updated summary", sections)
+	}
+}
+
+func TestRunGenDryRunPredictsSections(t *testing.T) {
+	tmp := t.TempDir()
+	rawDir := filepath.Join(tmp, "raw")
+	logDir := filepath.Join(tmp, "log")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	t.Setenv("DEVLOG_LOG_DIR", logDir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmp, "state"))
+
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"), []byte("#!/bin/sh\ncat > /dev/null\necho 'Full summary.'\n"), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "mycompressor"), []byte("#!/bin/sh\necho 'Compressed data.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	now := time.Now()
+	date := now.Format("2006-01-02")
+	dateDir := filepath.Join(rawDir, date)
+	os.MkdirAll(dateDir, 0o755)
+	for _, p := range []string{"api", "web"} {
+		os.WriteFile(filepath.Join(dateDir, "git-"+p+".log"), []byte("=== SNAPSHOT 00:00 ===\ndiff\n\n"), 0o644)
+	}
+	cfg := Config{GenCmd: "mysummarizer", CompCmd: "mycompressor", ClaudeCodeDir: new(string)}
+	if _, err := runGen(context.Background(), cfg, State{}, date, nil); err != nil {
+		t.Fatalf("runGen: %v", err)
+	}
+
+	// Only api has data from after the summary.
+	summaryPath := filepath.Join(logDir, date+".md")
+	earlier := now.Add(-time.Minute)
+	os.Chtimes(summaryPath, earlier, earlier)
+	f, _ := os.OpenFile(filepath.Join(dateDir, "git-api.log"), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("=== SNAPSHOT " + now.Format("15:04") + " ===\nmore diff\n\n")
+	f.Close()
+
+	dryRun := func(cfg Config) string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := runGenDryRun(cfg, State{}, date, nil)
+		w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("runGenDryRun: %v", err)
+		}
+		out, _ := io.ReadAll(r)
+		return string(out)
+	}
+
+	out := dryRun(cfg)
+	for _, want := range []string{
+		"(stale; would be regenerated)",
+		"api:\n  comp-git-api.md: stale",
+		"summary: would regenerate the section from a ~",
+		"web:\n  summary: no new data, would be kept\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+
+	cfg.UpdateSummaries = true
+	out = dryRun(cfg)
+	if !strings.Contains(out, "api:\n  git-api.log: ~") || !strings.Contains(out, "summary: would update the section with a ~") {
+		t.Errorf("expected api's section to be updated, got:\n%s", out)
+	}
+}
@@ -0,0 +1,86 @@
+package devlog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxSummaryLen is the longest project summary accepted from gen_cmd, in
+// bytes. Summaries are asked to be concise; one far longer than this is
+// usually the prompt or the raw data echoed back.
+const maxSummaryLen = 16 * 1024
+
+// refusalRe matches a summary that opens with an apology or refusal instead
+// of a summary.
+var refusalRe = regexp.MustCompile(`(?i)^(i'm sorry|i am sorry|i apologi[sz]e|sorry,|unfortunately,? i|i can(no|')t|i'm unable|i am unable|i'm not able|i am not able|as an ai\b)`)
+
+// promptLeakMarkers are pieces of the summary prompt (see assemblePrompt)
+// that never belong in a summary. Finding one means gen_cmd echoed the
+// prompt.
+var promptLeakMarkers = []string{
+	"You are summarizing a day of software engineering work",
+	"You are updating the summary of a day of software engineering work",
+	"Below is the data collected during the day.",
+	"Description of data sources:",
+	"Not all sources may be present. Work with whatever is available.",
+	"Task: Write a concise summary of the day's work",
+}
+
+// promptFileMarkerRe matches the line that starts a data file in the
+// prompt, e.g. "--- notes.md ---".
+var promptFileMarkerRe = regexp.MustCompile(`(?m)^--- [\w.<>-]+\.(md|log|txt) ---$`)
+
+// validateSummary returns why summary, the output of gen_cmd for a project,
+// is not usable as its section of the daily summary, or nil if it is.
+func validateSummary(summary string) error {
+	summary = strings.TrimSpace(summary)
+	switch {
+	case summary == "":
+		return fmt.Errorf("the output is empty")
+	case refusalRe.MatchString(summary):
+		return fmt.Errorf("the output is an apology or refusal, not a summary")
+	case len(summary) > maxSummaryLen:
+		return fmt.Errorf("the output is %s, more than the limit of %s", formatSize(len(summary)), formatSize(maxSummaryLen))
+	case promptFileMarkerRe.MatchString(summary):
+		return fmt.Errorf("the output repeats the data given in the prompt")
+	}
+	for _, m := range promptLeakMarkers {
+		if strings.Contains(summary, m) {
+			return fmt.Errorf("the output repeats the instructions of the prompt")
+		}
+	}
+	return nil
+}
+
+// correctivePrompt returns prompt with an instruction added saying why the
+// previous output was rejected.
+func correctivePrompt(prompt string, rejected error) string {
+	return prompt + "\nYour previous answer to this prompt was rejected because " + rejected.Error() +
+		". Write the summary as instructed above. Output only the summary text: do not\n" +
+		"apologize, refuse, or repeat the prompt or the data.\n"
+}
+
+// runSummarizer runs gen_cmd on prompt and validates its output with
+// validateSummary. Rejected output is retried once with a corrective
+// instruction; if that is rejected too, runSummarizer fails rather than let
+// it be written to the summary. It also returns the gen_cmd command that
+// wrote the summary (see runGenCmd).
+func runSummarizer(ctx context.Context, cfg Config, project, prompt string, p *genProgress) (summary, used string, err error) {
+	if summary, used, err = runGenCmd(ctx, cfg, prompt, p.streamTo()); err != nil {
+		return "", "", err
+	}
+	invalid := validateSummary(summary)
+	if invalid == nil {
+		return summary, used, nil
+	}
+	p.printf("summary of %s rejected (%v), asking again…", project, invalid)
+	if summary, used, err = runGenCmd(ctx, cfg, correctivePrompt(prompt, invalid), p.streamTo()); err != nil {
+		return "", "", err
+	}
+	if invalid := validateSummary(summary); invalid != nil {
+		return "", "", fmt.Errorf("gen_cmd output rejected twice: %v", invalid)
+	}
+	return summary, used, nil
+}
@@ -0,0 +1,51 @@
+package devlog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateSummary(t *testing.T) {
+	for _, tc := range []struct {
+		name, summary string
+		ok            bool
+	}{
+		{"summary", "I fixed the reconnect race in the websocket client.\n\nNext steps:\n- Add a test", true},
+		{"mentions sorry later", "I rewrote the parser. Sorry state of the old tests, so I replaced them.", true},
+		{"empty", "  \n", false},
+		{"apology", "I'm sorry, but I can't summarize this data.", false},
+		{"refusal", "I cannot help with that.", false},
+		{"unfortunately", "Unfortunately I don't have enough information.", false},
+		{"too long", strings.Repeat("word ", maxSummaryLen/4), false},
+		{"echoed data", "Here is the data:\n--- notes.md ---\n### At 10:00 #api", false},
+		{"echoed instructions", "I worked on it.\n\nDescription of data sources:\n\n- notes.md: ...", false},
+	} {
+		err := validateSummary(tc.summary)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: validateSummary = %v, want ok %v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestRunSummarizerRetries(t *testing.T) {
+	tmp := t.TempDir()
+	mockBin := filepath.Join(tmp, "bin")
+	os.MkdirAll(mockBin, 0o755)
+	// Refuses the first time, then follows the corrective instruction.
+	script := "#!/bin/sh\nif grep -q 'was rejected because' ; then echo 'I fixed the bug.'; else echo \"I'm sorry, I can't do that.\"; fi\n"
+	os.WriteFile(filepath.Join(mockBin, "mysummarizer"), []byte(script), 0o755)
+	os.WriteFile(filepath.Join(mockBin, "refuser"), []byte("#!/bin/sh\necho 'I apologize, but no.'\n"), 0o755)
+	t.Setenv("PATH", mockBin+":"+os.Getenv("PATH"))
+
+	got, used, err := runSummarizer(context.Background(), Config{GenCmd: "mysummarizer"}, "api", "Summarize.\n", nil)
+	if err != nil || got != "I fixed the bug." || used != "mysummarizer" {
+		t.Errorf("runSummarizer = %q, %q, %v; want the retried summary", got, used, err)
+	}
+
+	if _, _, err := runSummarizer(context.Background(), Config{GenCmd: "refuser"}, "api", "Summarize.\n", nil); err == nil || !strings.Contains(err.Error(), "rejected twice") {
+		t.Errorf("expected an error after two rejections, got %v", err)
+	}
+}
@@ -0,0 +1,347 @@
+package devlog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// webUI serves the local web app started by `devlog web`: daily summaries,
+// raw data for a date, and (with allowGen) regeneration.
+type webUI struct {
+	cfg      Config
+	addr     string // the address listened on
+	allowGen bool
+	token    string // the API token, required for every request
+	csrf     string // per-process token that POST forms must carry
+	logger   *slog.Logger
+	genMu    sync.Mutex // one generation at a time
+}
+
+// webTokenCookie holds the API token in the browser once the link printed by
+// `devlog web` has been opened.
+const webTokenCookie = "devlog_token"
+
+// newWebUI returns the web UI of cfg served on addr. Browsers must present
+// the API token (see loadAPIToken), which is created if needed.
+func newWebUI(cfg Config, addr string, allowGen bool, logger *slog.Logger) (*webUI, error) {
+	token, err := loadAPIToken(resolveAPITokenPath())
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("generating form token: %w", err)
+	}
+	return &webUI{cfg: cfg, addr: addr, allowGen: allowGen, token: token, csrf: hex.EncodeToString(buf), logger: logger}, nil
+}
+
+// summarySection is one project's part of a generated summary.
+type summarySection struct {
+	Project string
+	Text    string
+}
+
+// splitSummary splits a summary file into its "## <project>" sections.
+func splitSummary(content string) []summarySection {
+	var sections []summarySection
+	for _, line := range strings.Split(content, "\n") {
+		if name, ok := strings.CutPrefix(line, "## "); ok {
+			sections = append(sections, summarySection{Project: strings.TrimSpace(name)})
+			continue
+		}
+		if len(sections) > 0 {
+			sections[len(sections)-1].Text += line + "\n"
+		}
+	}
+	for i := range sections {
+		sections[i].Text = strings.TrimSpace(sections[i].Text)
+	}
+	return sections
+}
+
+func (u *webUI) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", u.handleIndex)
+	mux.HandleFunc("GET /day/{date}", u.handleDay)
+	mux.HandleFunc("GET /day/{date}/notes", u.handleNotes)
+	mux.HandleFunc("GET /day/{date}/git/{project}", u.handleGit)
+	mux.HandleFunc("GET /day/{date}/term/{project}", u.handleTerm)
+	mux.HandleFunc("POST /day/{date}/gen", u.handleGen)
+	return u.protect(mux)
+}
+
+// protect guards the web UI against other sites and other local users. The
+// Host must be a loopback name or the address listened on, so that a page
+// whose domain is rebound to 127.0.0.1 cannot read it. Every request must
+// carry the API token, from a bearer header or the cookie set by opening a
+// link with ?token=<token>. A POST must also come from a page of the UI: its
+// Origin must be the UI's and its form must carry the per-process token.
+func (u *webUI) protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackAddr(r.Host) && r.Host != u.addr {
+			http.Error(w, "invalid host", http.StatusForbidden)
+			return
+		}
+		if token := r.URL.Query().Get("token"); token != "" && tokensEqual(token, u.token) {
+			http.SetCookie(w, &http.Cookie{Name: webTokenCookie, Value: token, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+			// Take the token out of the address bar and history.
+			q := r.URL.Query()
+			q.Del("token")
+			r.URL.RawQuery = q.Encode()
+			http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+			return
+		}
+		if !u.authorized(r) {
+			http.Error(w, "missing or invalid token; open the link printed by devlog web", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost &&
+			(r.Header.Get("Origin") != "http://"+r.Host || !tokensEqual(r.PostFormValue("csrf"), u.csrf)) {
+			http.Error(w, "invalid origin or form token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized reports whether r carries the API token.
+func (u *webUI) authorized(r *http.Request) bool {
+	if got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return tokensEqual(got, u.token)
+	}
+	c, err := r.Cookie(webTokenCookie)
+	return err == nil && tokensEqual(c.Value, u.token)
+}
+
+func tokensEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+type webDay struct {
+	Date       string
+	HasSummary bool
+	Projects   []string
+}
+
+func (u *webUI) handleIndex(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("project")
+	dates := rawDataDates(u.cfg)
+
+	var days []webDay
+	for i := len(dates) - 1; i >= 0; i-- {
+		day := u.day(dates[i])
+		if project != "" && !containsString(day.Projects, project) {
+			continue
+		}
+		days = append(days, day)
+	}
+	u.render(w, "index", map[string]any{"Project": project, "Days": days})
+}
+
+// day returns what is known about date: whether it has a summary, and the
+// projects with raw data or summary sections.
+func (u *webUI) day(date string) webDay {
+	day := webDay{Date: date}
+	seen := make(map[string]bool)
+	for _, p := range discoverProjects(u.cfg, date) {
+		seen[p] = true
+	}
+	if data, err := os.ReadFile(summaryPath(u.cfg, date)); err == nil {
+		day.HasSummary = true
+		for _, s := range splitSummary(string(data)) {
+			seen[s.Project] = true
+		}
+	}
+	for p := range seen {
+		day.Projects = append(day.Projects, p)
+	}
+	sort.Strings(day.Projects)
+	return day
+}
+
+func (u *webUI) handleDay(w http.ResponseWriter, r *http.Request) {
+	date := r.PathValue("date")
+	if !isValidDate(date) {
+		http.NotFound(w, r)
+		return
+	}
+	project := r.URL.Query().Get("project")
+
+	var sections []summarySection
+	if data, err := os.ReadFile(summaryPath(u.cfg, date)); err == nil {
+		for _, s := range splitSummary(string(data)) {
+			if project == "" || s.Project == project {
+				sections = append(sections, s)
+			}
+		}
+	}
+
+	day := u.day(date)
+	var gitProjects, termProjects []string
+	for _, p := range day.Projects {
+		if _, err := os.Stat(resolveGitPath(u.cfg, date, p)); err == nil {
+			gitProjects = append(gitProjects, p)
+		}
+		if len(u.termFiles(date, p)) > 0 {
+			termProjects = append(termProjects, p)
+		}
+	}
+	hasNotes := len(notesPaths(u.cfg, date)) > 0
+
+	u.render(w, "day", map[string]any{
+		"Date":         date,
+		"Project":      project,
+		"Projects":     day.Projects,
+		"Sections":     sections,
+		"HasSummary":   day.HasSummary,
+		"HasNotes":     hasNotes,
+		"GitProjects":  gitProjects,
+		"TermProjects": termProjects,
+		"AllowGen":     u.allowGen,
+		"CSRF":         u.csrf,
+		"Generated":    r.URL.Query().Get("generated"),
+	})
+}
+
+func (u *webUI) handleNotes(w http.ResponseWriter, r *http.Request) {
+	date := r.PathValue("date")
+	if !isValidDate(date) {
+		http.NotFound(w, r)
+		return
+	}
+	days := collectNotes(u.cfg, []string{date}, r.URL.Query().Get("project"))
+	if len(days) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	writeText(w, days[0].Content+"\n")
+}
+
+func (u *webUI) handleGit(w http.ResponseWriter, r *http.Request) {
+	date, project := r.PathValue("date"), r.PathValue("project")
+	if !isValidDate(date) || validateProjectName(project) != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := readGitLog(resolveGitPath(u.cfg, date, project))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeText(w, data)
+}
+
+func (u *webUI) handleTerm(w http.ResponseWriter, r *http.Request) {
+	date, project := r.PathValue("date"), r.PathValue("project")
+	if !isValidDate(date) || validateProjectName(project) != nil {
+		http.NotFound(w, r)
+		return
+	}
+	files := u.termFiles(date, project)
+	if len(files) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	var b strings.Builder
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "=== %s ===\n%s\n", f, data)
+	}
+	writeText(w, b.String())
+}
+
+func (u *webUI) termFiles(date, project string) []string {
+	state, _ := loadState()
+	return termFilesForProject(u.cfg, state, date, project)
+}
+
+func (u *webUI) handleGen(w http.ResponseWriter, r *http.Request) {
+	date := r.PathValue("date")
+	if !isValidDate(date) {
+		http.NotFound(w, r)
+		return
+	}
+	if !u.allowGen {
+		http.Error(w, "regeneration is disabled; restart with devlog web --allow-gen", http.StatusForbidden)
+		return
+	}
+
+	u.genMu.Lock()
+	defer u.genMu.Unlock()
+	state, _ := loadState()
+	u.logger.Info("generating summary", "date", date)
+	// The generation is cancelled if the browser gives up on the request.
+	n, err := runGen(r.Context(), u.cfg, state, date, nil)
+	if err != nil {
+		u.logger.Error("generation failed", "date", date, "err", err)
+		http.Error(w, "generation failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/day/%s?generated=%d", date, n), http.StatusSeeOther)
+}
+
+func writeText(w http.ResponseWriter, s string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, s)
+}
+
+func (u *webUI) render(w http.ResponseWriter, name string, data any) {
+	var buf bytes.Buffer
+	if err := webTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		u.logger.Error("rendering page failed", "page", name, "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+var webTemplates = template.Must(template.New("").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; line-height: 1.5; }
+pre { white-space: pre-wrap; background: #f6f6f6; padding: 1em; }
+.muted { color: #777; }
+</style></head><body>
+<p><a href="/">devlog</a></p>
+{{end}}
+
+{{define "index"}}{{template "head" "devlog"}}
+<h1>Daily log{{if .Project}} for #{{.Project}} <a class="muted" href="/">(all)</a>{{end}}</h1>
+{{if not .Days}}<p class="muted">No data.</p>{{end}}
+<ul>
+{{range .Days}}<li><a href="/day/{{.Date}}{{if $.Project}}?project={{$.Project}}{{end}}">{{.Date}}</a>
+{{if not .HasSummary}}<span class="muted">(no summary)</span>{{end}}
+{{range .Projects}} <a class="muted" href="/?project={{.}}">#{{.}}</a>{{end}}</li>
+{{end}}</ul>
+</body></html>{{end}}
+
+{{define "day"}}{{template "head" .Date}}
+<h1>{{.Date}}{{if .Project}} #{{.Project}} <a class="muted" href="/day/{{.Date}}">(all)</a>{{end}}</h1>
+{{if .Generated}}<p>Generated summaries for {{.Generated}} projects.</p>{{end}}
+{{if .Sections}}{{range .Sections}}<h2><a href="/day/{{$.Date}}?project={{.Project}}">{{.Project}}</a></h2>
+<pre>{{.Text}}</pre>
+{{end}}{{else}}<p class="muted">{{if .HasSummary}}No summary for this project.{{else}}No summary yet.{{end}}</p>{{end}}
+{{if .AllowGen}}<form method="post" action="/day/{{.Date}}/gen"><input type="hidden" name="csrf" value="{{.CSRF}}"><button>Regenerate summary</button></form>{{end}}
+<h2>Raw data</h2>
+<ul>
+{{if .HasNotes}}<li><a href="/day/{{.Date}}/notes{{if .Project}}?project={{.Project}}{{end}}">Notes</a></li>{{end}}
+{{range .GitProjects}}{{if or (not $.Project) (eq . $.Project)}}<li><a href="/day/{{$.Date}}/git/{{.}}">Git snapshots: {{.}}</a></li>{{end}}{{end}}
+{{range .TermProjects}}{{if or (not $.Project) (eq . $.Project)}}<li><a href="/day/{{$.Date}}/term/{{.}}">Terminal logs: {{.}}</a></li>{{end}}{{end}}
+</ul>
+</body></html>{{end}}
+`))
@@ -0,0 +1,195 @@
+package devlog
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitSummary(t *testing.T) {
+	content := "# 2024-01-15\n\n## alpha\n\nDid alpha things.\n\n## beta\n\nDid beta things.\n"
+	sections := splitSummary(content)
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %+v", sections)
+	}
+	if sections[0].Project != "alpha" || sections[0].Text != "Did alpha things." {
+		t.Errorf("unexpected first section: %+v", sections[0])
+	}
+	if sections[1].Project != "beta" || sections[1].Text != "Did beta things." {
+		t.Errorf("unexpected second section: %+v", sections[1])
+	}
+}
+
+func newTestWebUI(t *testing.T, allowGen bool) *webUI {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := Config{RawDir: t.TempDir(), LogDir: t.TempDir()}
+
+	dayDir := filepath.Join(cfg.RawDir, "2024-01-15")
+	os.MkdirAll(dayDir, 0o755)
+	os.WriteFile(filepath.Join(dayDir, "git-alpha.log"), []byte("=== SNAPSHOT 10:00 ===\n+alpha change\n"), 0o644)
+	os.WriteFile(filepath.Join(dayDir, "notes.md"), []byte("### At 09:00 #alpha\nAlpha note\n\n### At 10:00 #beta\nBeta note\n\n"), 0o644)
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"),
+		[]byte("# 2024-01-15\n\n## alpha\n\nAlpha <summary>.\n\n## beta\n\nBeta summary.\n"), 0o644)
+
+	return &webUI{cfg: cfg, addr: "127.0.0.1:8377", allowGen: allowGen, token: "secret", csrf: "form-token",
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+// webRequest returns a request to the test web UI carrying its token.
+func webRequest(method, path string, body io.Reader) *http.Request {
+	r := httptest.NewRequest(method, path, body)
+	r.Host = "127.0.0.1:8377"
+	r.AddCookie(&http.Cookie{Name: webTokenCookie, Value: "secret"})
+	return r
+}
+
+func webGet(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, webRequest("GET", path, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestWebIndex(t *testing.T) {
+	h := newTestWebUI(t, false).routes()
+
+	code, body := webGet(t, h, "/")
+	if code != http.StatusOK || !strings.Contains(body, `href="/day/2024-01-15"`) {
+		t.Errorf("expected link to date, got %d:\n%s", code, body)
+	}
+
+	_, body = webGet(t, h, "/?project=gamma")
+	if strings.Contains(body, "/day/2024-01-15") {
+		t.Errorf("expected no dates for unknown project:\n%s", body)
+	}
+}
+
+func TestWebDay(t *testing.T) {
+	h := newTestWebUI(t, false).routes()
+
+	code, body := webGet(t, h, "/day/2024-01-15")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if !strings.Contains(body, "Alpha &lt;summary&gt;.") || !strings.Contains(body, "Beta summary.") {
+		t.Errorf("expected escaped summaries for both projects:\n%s", body)
+	}
+	if !strings.Contains(body, "/day/2024-01-15/git/alpha") || !strings.Contains(body, "/day/2024-01-15/notes") {
+		t.Errorf("expected raw data links:\n%s", body)
+	}
+	if strings.Contains(body, "Regenerate") {
+		t.Errorf("regeneration should be hidden when not allowed")
+	}
+
+	_, body = webGet(t, h, "/day/2024-01-15?project=beta")
+	if strings.Contains(body, "Alpha &lt;summary&gt;.") || !strings.Contains(body, "Beta summary.") {
+		t.Errorf("expected only the beta summary:\n%s", body)
+	}
+
+	if code, _ := webGet(t, h, "/day/not-a-date"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for invalid date, got %d", code)
+	}
+}
+
+func TestWebRawData(t *testing.T) {
+	h := newTestWebUI(t, false).routes()
+
+	code, body := webGet(t, h, "/day/2024-01-15/git/alpha")
+	if code != http.StatusOK || !strings.Contains(body, "+alpha change") {
+		t.Errorf("expected git log, got %d:\n%s", code, body)
+	}
+	if code, _ := webGet(t, h, "/day/2024-01-15/git/..%2Fnotes"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for invalid project, got %d", code)
+	}
+
+	_, body = webGet(t, h, "/day/2024-01-15/notes?project=beta")
+	if !strings.Contains(body, "Beta note") || strings.Contains(body, "Alpha note") {
+		t.Errorf("expected only beta notes:\n%s", body)
+	}
+}
+
+func TestWebGenDisabled(t *testing.T) {
+	h := newTestWebUI(t, false).routes()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, webGenRequest("http://127.0.0.1:8377", "form-token"))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "--allow-gen") {
+		t.Errorf("expected 403 without --allow-gen, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, webRequest("GET", "/day/2024-01-15/gen", nil))
+	if rec.Code == http.StatusOK {
+		t.Errorf("expected GET on gen endpoint to be rejected")
+	}
+}
+
+func webGenRequest(origin, csrf string) *http.Request {
+	r := webRequest("POST", "/day/2024-01-15/gen", strings.NewReader(url.Values{"csrf": {csrf}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	return r
+}
+
+func TestWebAccess(t *testing.T) {
+	h := newTestWebUI(t, false).routes()
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+
+	// A rebound domain pointing at 127.0.0.1 is refused.
+	r := webRequest("GET", "/", nil)
+	r.Host = "attacker.example:8377"
+	if rec := serve(r); rec.Code != http.StatusForbidden {
+		t.Errorf("foreign Host: got %d, want 403", rec.Code)
+	}
+
+	// Reads need the token.
+	r = httptest.NewRequest("GET", "/day/2024-01-15/notes", nil)
+	r.Host = "localhost:8377"
+	if rec := serve(r); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: got %d, want 401", rec.Code)
+	}
+	r.Header.Set("Authorization", "Bearer secret")
+	if rec := serve(r); rec.Code != http.StatusOK {
+		t.Errorf("bearer token: got %d, want 200", rec.Code)
+	}
+
+	// The printed link stores the token in a cookie and drops it from the URL.
+	r = httptest.NewRequest("GET", "/day/2024-01-15?token=secret&project=beta", nil)
+	r.Host = "127.0.0.1:8377"
+	rec := serve(r)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/day/2024-01-15?project=beta" {
+		t.Errorf("token link: got %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if c := rec.Result().Cookies(); len(c) != 1 || c[0].Value != "secret" || !c[0].HttpOnly || c[0].SameSite != http.SameSiteStrictMode {
+		t.Errorf("token link cookies = %+v", c)
+	}
+
+	// POSTs need the UI's Origin and form token.
+	for _, tc := range []struct{ origin, csrf string }{
+		{"", "form-token"},
+		{"http://attacker.example", "form-token"},
+		{"http://127.0.0.1:8377", ""},
+		{"http://127.0.0.1:8377", "wrong"},
+	} {
+		if rec := serve(webGenRequest(tc.origin, tc.csrf)); rec.Code != http.StatusForbidden || strings.Contains(rec.Body.String(), "--allow-gen") {
+			t.Errorf("POST with origin %q and token %q: got %d: %s", tc.origin, tc.csrf, rec.Code, rec.Body)
+		}
+	}
+
+	_, body := webGet(t, newTestWebUI(t, true).routes(), "/day/2024-01-15")
+	if !strings.Contains(body, `name="csrf" value="form-token"`) {
+		t.Errorf("the regenerate form should carry the form token:\n%s", body)
+	}
+}
@@ -0,0 +1,146 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message length limits: Discord rejects content over 2000 characters, and
+// Slack truncates text over 4000, so longer summaries are sent in parts.
+const (
+	discordMessageLimit = 2000
+	slackMessageLimit   = 4000
+)
+
+// isDiscordWebhook reports whether rawURL is a Discord webhook. Any other URL
+// is sent Slack-formatted messages, which Mattermost, Rocket.Chat, and others
+// also accept.
+func isDiscordWebhook(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	return (host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com")) &&
+		strings.HasPrefix(u.Path, "/api/webhooks/")
+}
+
+// selectSummarySections returns the sections of a summary for projects, or
+// every section if projects is empty.
+func selectSummarySections(content string, projects []string) []summarySection {
+	var sections []summarySection
+	for _, s := range splitSummary(content) {
+		if len(projects) == 0 || containsString(projects, s.Project) {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
+
+var (
+	mdHeadingRe = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	mdBoldRe    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdLinkRe    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBulletRe  = regexp.MustCompile(`(?m)^(\s*)[-*]\s+`)
+)
+
+// slackMrkdwn converts the Markdown of a summary to Slack's mrkdwn: headings
+// and **bold** become *bold*, links become <url|text>, and list markers
+// become bullets. Discord renders Markdown as is.
+func slackMrkdwn(md string) string {
+	s := mdBulletRe.ReplaceAllString(md, "$1• ")
+	s = mdLinkRe.ReplaceAllString(s, "<$2|$1>")
+	s = mdBoldRe.ReplaceAllString(s, "*$1*")
+	s = mdHeadingRe.ReplaceAllString(s, "*$1*")
+	return s
+}
+
+// formatWebhookMessage renders the summary sections for date as the text of
+// a chat message.
+func formatWebhookMessage(date string, sections []summarySection, discord bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Devlog for %s**", date)
+	for _, s := range sections {
+		fmt.Fprintf(&b, "\n\n**%s**\n%s", s.Project, s.Text)
+	}
+	if discord {
+		return b.String()
+	}
+	return slackMrkdwn(b.String())
+}
+
+// splitMessage splits text into parts of at most limit bytes, breaking
+// between paragraphs, then lines, where possible.
+func splitMessage(text string, limit int) []string {
+	var parts []string
+	for len(text) > limit {
+		cut := strings.LastIndex(text[:limit], "\n\n")
+		if cut <= 0 {
+			cut = strings.LastIndex(text[:limit], "\n")
+		}
+		if cut <= 0 {
+			cut = limit
+		}
+		parts = append(parts, strings.TrimRight(text[:cut], "\n"))
+		text = strings.TrimLeft(text[cut:], "\n")
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+// webhookPayloads returns the JSON bodies to post to webhookURL for the
+// summary sections of date, in order.
+func webhookPayloads(webhookURL, date string, sections []summarySection) [][]byte {
+	discord := isDiscordWebhook(webhookURL)
+	limit, field := slackMessageLimit, "text"
+	if discord {
+		limit, field = discordMessageLimit, "content"
+	}
+	var payloads [][]byte
+	for _, part := range splitMessage(formatWebhookMessage(date, sections, discord), limit) {
+		data, _ := json.Marshal(map[string]string{field: part})
+		payloads = append(payloads, data)
+	}
+	return payloads
+}
+
+// postWebhook posts each payload to webhookURL, stopping at the first
+// failure.
+func postWebhook(webhookURL string, payloads [][]byte) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	for _, p := range payloads {
+		resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(p))
+		if err != nil {
+			return fmt.Errorf("posting to webhook: %w", err)
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+	}
+	return nil
+}
+
+// postSummary posts the summary for date, limited to projects if set, to
+// webhookURL. It fails if there is no summary or no section to post.
+func postSummary(cfg Config, webhookURL, date string, projects []string) error {
+	content, err := readSummary(cfg, date)
+	if err != nil {
+		return err
+	}
+	sections := selectSummarySections(content, projects)
+	if len(sections) == 0 {
+		return fmt.Errorf("summary for %s has no sections for %s", date, strings.Join(projects, ", "))
+	}
+	return postWebhook(webhookURL, webhookPayloads(webhookURL, date, sections))
+}
@@ -0,0 +1,102 @@
+package devlog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsDiscordWebhook(t *testing.T) {
+	for u, want := range map[string]bool{
+		"https://discord.com/api/webhooks/123/abc":        true,
+		"https://discordapp.com/api/webhooks/123/abc":     true,
+		"https://ptb.discord.com/api/webhooks/123/abc":    true,
+		"https://hooks.slack.com/services/T000/B000/XXXX": false,
+		"https://discord.com.evil.example/api/webhooks/1": false,
+		"not a url": false,
+	} {
+		if got := isDiscordWebhook(u); got != want {
+			t.Errorf("isDiscordWebhook(%q) = %v, want %v", u, got, want)
+		}
+	}
+}
+
+func TestSlackMrkdwn(t *testing.T) {
+	in := "### Details\n- Fixed **the leak** in [pool](https://example.com/pool)\n  * nested item"
+	want := "*Details*\n• Fixed *the leak* in <https://example.com/pool|pool>\n  • nested item"
+	if got := slackMrkdwn(in); got != want {
+		t.Errorf("slackMrkdwn:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	text := strings.Repeat("a", 30) + "\n\n" + strings.Repeat("b", 30) + "\n" + strings.Repeat("c", 30)
+	parts := splitMessage(text, 70)
+	if len(parts) != 2 || parts[0] != strings.Repeat("a", 30) || !strings.HasPrefix(parts[1], "bbb") {
+		t.Errorf("expected split at the paragraph break, got %q", parts)
+	}
+	for _, p := range splitMessage(strings.Repeat("x", 250), 100) {
+		if len(p) > 100 {
+			t.Errorf("part longer than limit: %d", len(p))
+		}
+	}
+}
+
+func TestWebhookPayloads(t *testing.T) {
+	sections := []summarySection{{Project: "alpha", Text: "- Did **things**"}}
+
+	var slack map[string]string
+	json.Unmarshal(webhookPayloads("https://hooks.slack.com/services/x", "2024-01-15", sections)[0], &slack)
+	if slack["text"] != "*Devlog for 2024-01-15*\n\n*alpha*\n• Did *things*" {
+		t.Errorf("unexpected slack payload: %q", slack)
+	}
+
+	var discord map[string]string
+	json.Unmarshal(webhookPayloads("https://discord.com/api/webhooks/1/x", "2024-01-15", sections)[0], &discord)
+	if discord["content"] != "**Devlog for 2024-01-15**\n\n**alpha**\n- Did **things**" {
+		t.Errorf("unexpected discord payload: %q", discord)
+	}
+
+	long := []summarySection{{Project: "alpha", Text: strings.Repeat("word ", 1000)}}
+	if n := len(webhookPayloads("https://discord.com/api/webhooks/1/x", "2024-01-15", long)); n < 3 {
+		t.Errorf("expected a long summary to be split for discord, got %d parts", n)
+	}
+}
+
+func TestPostSummary(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir()}
+	os.WriteFile(filepath.Join(cfg.LogDir, "2024-01-15.md"), []byte("# 2024-01-15\n\n## alpha\n\nAlpha work.\n\n## beta\n\nBeta work.\n"), 0o644)
+
+	var bodies []string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	if err := postSummary(cfg, srv.URL, "2024-01-15", []string{"beta"}); err != nil {
+		t.Fatalf("postSummary: %v", err)
+	}
+	if len(bodies) != 1 || !strings.Contains(bodies[0], "Beta work.") || strings.Contains(bodies[0], "Alpha") {
+		t.Errorf("expected only beta to be posted, got %q", bodies)
+	}
+
+	if err := postSummary(cfg, srv.URL, "2024-01-15", []string{"gamma"}); err == nil {
+		t.Error("expected error when no sections match")
+	}
+	if err := postSummary(cfg, srv.URL, "2024-01-16", nil); err == nil {
+		t.Error("expected error for missing summary")
+	}
+
+	status = http.StatusBadRequest
+	if err := postSummary(cfg, srv.URL, "2024-01-15", nil); err == nil {
+		t.Error("expected error for a rejected post")
+	}
+}
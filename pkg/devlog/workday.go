@@ -0,0 +1,147 @@
+package devlog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// workdayGap is the shortest pause in activity that is reported as a gap in
+// the workday.
+const workdayGap = time.Hour
+
+// workday is the shape of a day's work inferred from activity timestamps.
+type workday struct {
+	Start, End time.Time
+	Gaps       []timeSpan // pauses of at least workdayGap, in order
+}
+
+// inferWorkday finds the start and end of the work in spans, and the gaps
+// in it, merging activity separated by at most defaultIdleGap as `devlog
+// time` does. It returns false if there is no activity.
+func inferWorkday(spans []timeSpan) (workday, bool) {
+	merged := mergeSpans(spans, defaultIdleGap)
+	if len(merged) == 0 {
+		return workday{}, false
+	}
+	w := workday{Start: merged[0].Start, End: merged[0].End}
+	for _, s := range merged[1:] {
+		if s.Start.Sub(w.End) >= workdayGap {
+			w.Gaps = append(w.Gaps, timeSpan{Start: w.End, End: s.Start})
+		}
+		if s.End.After(w.End) {
+			w.End = s.End
+		}
+	}
+	return w, true
+}
+
+// activitySpans returns project's activity on date, including its work
+// sessions.
+func activitySpans(cfg Config, state State, date, project string) []timeSpan {
+	now := time.Now()
+	return append(projectActivity(cfg, state, date, project, now.Location()), sessionSpans(cfg, date, project, now)...)
+}
+
+// projectTimeline returns the activity timeline of project on date for the
+// summary prompt, or "" if it has no timed activity.
+func projectTimeline(cfg Config, state State, date, project string) string {
+	w, ok := inferWorkday(activitySpans(cfg, state, date, project))
+	if !ok {
+		return ""
+	}
+	return w.timeline()
+}
+
+// dayWorkday infers the workday on date across all projects that are
+// summarized, including unaffiliated notes.
+func dayWorkday(cfg Config, state State, date string) (workday, bool) {
+	projects := withoutExcluded(cfg, state, discoverAllProjects(cfg, state, date))
+	if hasUnaffiliatedNotes(cfg, date) && !genExcluded(cfg, state, "general") {
+		projects = append(projects, "general")
+	}
+	for _, proj := range sessionProjects(cfg, date) {
+		if !containsString(projects, proj) && !genExcluded(cfg, state, proj) {
+			projects = append(projects, proj)
+		}
+	}
+	var spans []timeSpan
+	for _, proj := range projects {
+		spans = append(spans, activitySpans(cfg, state, date, proj)...)
+	}
+	return inferWorkday(spans)
+}
+
+// partOfDay names the part of the day in which a gap falls, from its
+// midpoint.
+func partOfDay(s timeSpan) string {
+	mid := s.Start.Add(s.End.Sub(s.Start) / 2)
+	switch h := mid.Hour(); {
+	case h < 11:
+		return "morning"
+	case h < 14:
+		return "midday"
+	case h < 18:
+		return "afternoon"
+	default:
+		return "evening"
+	}
+}
+
+// formatGapDuration renders d rounded to the quarter hour, e.g. "2h",
+// "1h30m", or "45m".
+func formatGapDuration(d time.Duration) string {
+	m := int(d.Round(15 * time.Minute).Minutes())
+	switch {
+	case m < 60:
+		return fmt.Sprintf("%dm", m)
+	case m%60 == 0:
+		return fmt.Sprintf("%dh", m/60)
+	default:
+		return fmt.Sprintf("%dh%02dm", m/60, m%60)
+	}
+}
+
+// String describes the workday, e.g. "worked ~09:10–18:40 with a 2h midday
+// gap".
+func (w workday) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "worked ~%s–%s", w.Start.Format("15:04"), w.End.Format("15:04"))
+	for i, g := range w.Gaps {
+		switch {
+		case i == 0:
+			b.WriteString(" with a ")
+		case i == len(w.Gaps)-1:
+			b.WriteString(" and a ")
+		default:
+			b.WriteString(", a ")
+		}
+		fmt.Fprintf(&b, "%s %s gap", formatGapDuration(g.End.Sub(g.Start)), partOfDay(g))
+	}
+	return b.String()
+}
+
+// timeline lists the workday for the summary prompt: its start and end,
+// and each gap with its times.
+func (w workday) timeline() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- First activity: ~%s\n", w.Start.Format("15:04"))
+	for _, g := range w.Gaps {
+		fmt.Fprintf(&b, "- Gap: %s–%s (%s, %s)\n", g.Start.Format("15:04"), g.End.Format("15:04"),
+			formatGapDuration(g.End.Sub(g.Start)), partOfDay(g))
+	}
+	fmt.Fprintf(&b, "- Last activity: ~%s\n", w.End.Format("15:04"))
+	return b.String()
+}
+
+// workdayHeader returns the line under a summary's date heading describing
+// the day's working hours, e.g. "Worked ~09:10–18:40 with a 2h midday gap.",
+// or "" if there was no timed activity.
+func workdayHeader(cfg Config, state State, date string) string {
+	w, ok := dayWorkday(cfg, state, date)
+	if !ok {
+		return ""
+	}
+	s := w.String()
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
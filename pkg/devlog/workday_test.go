@@ -0,0 +1,74 @@
+package devlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferWorkday(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		tm, _ := clockOn("2024-01-15", hhmm, time.Local)
+		return tm
+	}
+	spans := []timeSpan{
+		{at("14:30"), at("18:40")},
+		{at("09:10"), at("12:30")},
+		{at("12:35"), at("12:40")}, // within the idle gap
+		{at("20:00"), at("20:45")},
+	}
+	w, ok := inferWorkday(spans)
+	if !ok {
+		t.Fatal("expected a workday")
+	}
+	if got, want := w.String(), "worked ~09:10–20:45 with a 1h45m midday gap and a 1h15m evening gap"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	want := "- First activity: ~09:10\n" +
+		"- Gap: 12:40–14:30 (1h45m, midday)\n" +
+		"- Gap: 18:40–20:00 (1h15m, evening)\n" +
+		"- Last activity: ~20:45\n"
+	if got := w.timeline(); got != want {
+		t.Errorf("timeline:\n%s\nwant:\n%s", got, want)
+	}
+
+	w, _ = inferWorkday([]timeSpan{{at("09:00"), at("09:30")}, {at("10:00"), at("11:00")}})
+	if got := w.String(); got != "worked ~09:00–11:00" {
+		t.Errorf("expected no gaps under an hour, got %q", got)
+	}
+	if _, ok := inferWorkday(nil); ok {
+		t.Error("expected no workday without activity")
+	}
+}
+
+func TestWorkdayHeader(t *testing.T) {
+	rawDir := filepath.Join(t.TempDir(), "raw")
+	t.Setenv("DEVLOG_RAW_DIR", rawDir)
+	dateDir := filepath.Join(rawDir, "2024-01-15")
+	os.MkdirAll(dateDir, 0o755)
+	os.WriteFile(filepath.Join(dateDir, "git-alpha.log"), []byte(
+		"=== SNAPSHOT 09:15 ===\n+a\n\n=== SNAPSHOT 12:00 ===\n+b\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "git-scratch.log"), []byte(
+		"=== SNAPSHOT 22:00 ===\n+a\n\n"), 0o644)
+	os.WriteFile(filepath.Join(dateDir, "notes.md"), []byte("### At 17:30\nwrap up\n"), 0o644)
+
+	empty := ""
+	cfg := Config{ClaudeCodeDir: &empty, GenExclude: []string{"scratch"}}
+	if got, want := workdayHeader(cfg, State{}, "2024-01-15"), "Worked ~09:10–17:30 with a 2h45m morning gap and a 5h30m afternoon gap."; got != want {
+		t.Errorf("workdayHeader = %q, want %q", got, want)
+	}
+	if got := workdayHeader(cfg, State{}, "2024-01-16"); got != "" {
+		t.Errorf("expected no header without activity, got %q", got)
+	}
+
+	timeline := projectTimeline(cfg, State{}, "2024-01-15", "alpha")
+	prompt := assemblePrompt(nil, "alpha", "2024-01-15", map[string]string{"notes.md": "x"}, timeline, "")
+	if !strings.Contains(prompt, "Activity timeline") || !strings.Contains(prompt, "- Gap: 09:15–11:55 (2h45m, morning)") {
+		t.Errorf("expected the timeline in the prompt, got:\n%s", prompt)
+	}
+	if strings.Contains(assemblePrompt(nil, "alpha", "2024-01-15", map[string]string{}, "", ""), "Activity timeline") {
+		t.Error("expected no timeline section without a timeline")
+	}
+}